@@ -0,0 +1,59 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api
+
+import "time"
+
+// A Clock tells the time and waits for time to pass. The clock in the gdt
+// context is the system clock unless a fixture implementing ClockProvider
+// supplies one, e.g. a clock that test specs advance deterministically.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel that receives the current time once the
+	// supplied duration has passed.
+	After(time.Duration) <-chan time.Time
+	// Sleep blocks until the supplied duration has passed.
+	Sleep(time.Duration)
+}
+
+// A ClockProvider is a fixture that supplies the Clock stored in the context
+// while the fixture is started.
+type ClockProvider interface {
+	// Clock returns the fixture's Clock.
+	Clock() Clock
+}
+
+// A ClockAdvancer is a Clock that only moves when it is advanced, e.g. the
+// clock fixture's clock. Instead of waiting for such a Clock to move, gdt
+// advances it, so that test spec waits and retries complete instantly, and
+// test spec timeouts are measured on the Clock in addition to the system
+// clock.
+type ClockAdvancer interface {
+	Clock
+	// Advance moves the clock forward by the supplied duration.
+	Advance(time.Duration)
+}
+
+// systemClock is a Clock that uses the time package.
+type systemClock struct{}
+
+// Now returns time.Now()
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d)
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Sleep calls time.Sleep(d)
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// SystemClock is the Clock that uses the system's time.
+var SystemClock Clock = systemClock{}
@@ -1,10 +1,18 @@
 package api
 
 import (
+	"errors"
+	"io/fs"
+	"net"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/samber/lo"
+	"github.com/theory/jsonpath"
 	"gopkg.in/yaml.v3"
 
 	"github.com/gdt-dev/core/parse"
@@ -16,12 +24,93 @@ var (
 		"darwin",
 		"windows",
 	}
+	// ValidPackageManagers are the package managers that a
+	// DependencyVersionSelector's `package-manager` may name.
+	ValidPackageManagers = []string{
+		"dpkg",
+		"rpm",
+		"brew",
+	}
+	// ValidArchs are the architectures that a Dependency's `when.arch`
+	// condition may name, using Go's names for them.
+	ValidArchs = []string{
+		"386",
+		"amd64",
+		"arm",
+		"arm64",
+		"loong64",
+		"ppc64",
+		"ppc64le",
+		"riscv64",
+		"s390x",
+	}
 )
 
-// Dependency describes a prerequisite binary that must be present.
+// DependencyMissing describes what happens when a Dependency is not
+// satisfied.
+type DependencyMissing string
+
+const (
+	// DependencyMissingFail indicates that the test scenario fails with an
+	// ErrDependencyNotSatisfied when the dependency is not satisfied.
+	DependencyMissingFail DependencyMissing = "fail"
+	// DependencyMissingSkip indicates that the test scenario is skipped when
+	// the dependency is not satisfied.
+	DependencyMissingSkip DependencyMissing = "skip"
+)
+
+// DependencyMissings contains the valid policies for dependencies that are
+// not satisfied.
+var DependencyMissings = []DependencyMissing{
+	DependencyMissingFail,
+	DependencyMissingSkip,
+}
+
+// DependencyMissingAt returns the DependencyMissing policy in the supplied
+// YAML scalar node.
+func DependencyMissingAt(node *yaml.Node) (DependencyMissing, error) {
+	if node.Kind != yaml.ScalarNode {
+		return "", parse.ExpectedScalarAt(node)
+	}
+	policy := DependencyMissing(strings.ToLower(node.Value))
+	if !lo.Contains(DependencyMissings, policy) {
+		return "", parse.InvalidDependencyMissingAt(
+			node, node.Value,
+			lo.Map(DependencyMissings, func(
+				m DependencyMissing, _ int,
+			) string {
+				return string(m)
+			}),
+		)
+	}
+	return policy, nil
+}
+
+// Dependency describes a prerequisite binary that must be present, a service
+// that must be reachable or a file or directory that must exist.
 type Dependency struct {
-	// Name is the name of the binary that must be present.
+	// Name is the name of the binary that must be present. For a service,
+	// file or directory dependency, Name optionally names the dependency in
+	// error messages.
 	Name string `yaml:"name"`
+	// Service describes a TCP endpoint or HTTP URL that must be reachable.
+	// When set, no binary is looked up.
+	Service *DependencyService `yaml:"service,omitempty"`
+	// File is the path, relative to the scenario file, of a file that must
+	// exist. When set, no binary is looked up.
+	File string `yaml:"file,omitempty"`
+	// Dir is the path, relative to the scenario file, of a directory that
+	// must exist. When set, no binary is looked up.
+	Dir string `yaml:"dir,omitempty"`
+	// Mode is the octal permission bits, e.g. "0600", that File or Dir must
+	// have.
+	Mode     string      `yaml:"mode,omitempty"`
+	FileMode fs.FileMode `yaml:"-"`
+	// Owner is the user name or numeric user ID that must own File or Dir.
+	Owner string `yaml:"owner,omitempty"`
+	// Missing is what happens when the Dependency is not satisfied. If
+	// empty, the scenario's default policy applies, which is to fail.
+	Missing DependencyMissing `yaml:"missing,omitempty"`
 	// When describes any constraining conditions that apply to this
 	// Dependency.
 	When *DependencyConditions `yaml:"when,omitempty"`
@@ -65,20 +154,102 @@ func (d *Dependency) UnmarshalYAML(node *yaml.Node) error {
 				return err
 			}
 			d.Version = &dv
+		case "service":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			var svc DependencyService
+			if err := valNode.Decode(&svc); err != nil {
+				return err
+			}
+			d.Service = &svc
+		case "file", "dir":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			if valNode.Value == "" {
+				return parse.InvalidDependencyAt(
+					valNode, key+" must be a path",
+				)
+			}
+			if key == "file" {
+				d.File = valNode.Value
+			} else {
+				d.Dir = valNode.Value
+			}
+		case "mode":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			modeStr := strings.TrimPrefix(valNode.Value, "0o")
+			mode, err := strconv.ParseUint(modeStr, 8, 32)
+			if err != nil || mode > 0o777 {
+				return parse.InvalidDependencyAt(
+					valNode, "mode must be octal permission bits, e.g. 0600",
+				)
+			}
+			d.Mode = valNode.Value
+			d.FileMode = fs.FileMode(mode)
+		case "owner":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			d.Owner = valNode.Value
+		case "missing":
+			missing, err := DependencyMissingAt(valNode)
+			if err != nil {
+				return err
+			}
+			d.Missing = missing
 		default:
 			return parse.UnknownFieldAt(key, keyNode)
 		}
 	}
+	kinds := 0
+	for _, set := range []bool{d.Service != nil, d.File != "", d.Dir != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return parse.InvalidDependencyAt(
+			node, "expected only one of service, file or dir",
+		)
+	}
+	if (d.Mode != "" || d.Owner != "") && d.File == "" && d.Dir == "" {
+		return parse.InvalidDependencyAt(
+			node, "mode and owner require file or dir",
+		)
+	}
 	return nil
 }
 
+// Path returns the path of the Dependency's File or Dir.
+func (d *Dependency) Path() string {
+	if d.Dir != "" {
+		return d.Dir
+	}
+	return d.File
+}
+
 // DependencyConditions describes constraining conditions that apply to a
 // Dependency, for instance whether the dependency is only required on a
-// particular OS.
+// particular OS. The dependency only applies when all of the conditions hold.
 type DependencyConditions struct {
 	// OS indicates that the dependency only applies when the tests are run on
 	// a particular operating system.
 	OS string `yaml:"os,omitempty"`
+	// Arch indicates that the dependency only applies when the tests are run
+	// on a particular architecture, e.g. "amd64".
+	Arch string `yaml:"arch,omitempty"`
+	// KernelVersion is a version constraint, e.g. ">= 5.10", indicating that
+	// the dependency only applies when the tests are run on an operating
+	// system kernel whose version meets the constraint.
+	KernelVersion           string              `yaml:"kernel-version,omitempty"`
+	KernelSemVerConstraints *semver.Constraints `yaml:"-"`
+	// Env contains the names of environment variables that must all be set
+	// for the dependency to apply, e.g. "DOCKER_HOST".
+	Env []string `yaml:"env,omitempty"`
 }
 
 func (c *DependencyConditions) UnmarshalYAML(node *yaml.Node) error {
@@ -104,6 +275,38 @@ func (c *DependencyConditions) UnmarshalYAML(node *yaml.Node) error {
 				}
 				c.OS = os
 			}
+		case "arch":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			arch := valNode.Value
+			if arch != "" {
+				if !lo.Contains(ValidArchs, arch) {
+					return parse.InvalidArchAt(valNode, arch, ValidArchs)
+				}
+				c.Arch = arch
+			}
+		case "kernel-version":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			conStr := valNode.Value
+			if conStr != "" {
+				con, err := semver.NewConstraint(conStr)
+				if err != nil {
+					return parse.InvalidVersionConstraintAt(
+						valNode, conStr, err,
+					)
+				}
+				c.KernelVersion = conStr
+				c.KernelSemVerConstraints = con
+			}
+		case "env":
+			var env FlexStrings
+			if err := valNode.Decode(&env); err != nil {
+				return err
+			}
+			c.Env = env.Values()
 		default:
 			return parse.UnknownFieldAt(key, keyNode)
 		}
@@ -111,6 +314,102 @@ func (c *DependencyConditions) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
+// DependencyService describes a service that must be reachable, either a TCP
+// endpoint or an HTTP URL.
+type DependencyService struct {
+	// TCP is a "host:port" address that must accept TCP connections.
+	TCP string `yaml:"tcp,omitempty"`
+	// HTTP is an http or https URL that must respond to a GET request.
+	HTTP string `yaml:"http,omitempty"`
+	// Status is the HTTP status code the HTTP URL must respond with. If
+	// zero, any 2xx status code is accepted.
+	Status int `yaml:"status,omitempty"`
+	// Timeout is how long to wait for the service to respond. If zero,
+	// DefaultDependencyServiceTimeout is used.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// DefaultDependencyServiceTimeout is how long to wait for a service
+// dependency to respond when the dependency does not specify a timeout.
+const DefaultDependencyServiceTimeout = 5 * time.Second
+
+// Target returns the TCP address or HTTP URL of the service.
+func (s *DependencyService) Target() string {
+	if s.HTTP != "" {
+		return s.HTTP
+	}
+	return "tcp://" + s.TCP
+}
+
+func (s *DependencyService) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "tcp":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			if _, _, err := net.SplitHostPort(valNode.Value); err != nil {
+				return parse.InvalidServiceAt(
+					valNode, "tcp must be a host:port address",
+				)
+			}
+			s.TCP = valNode.Value
+		case "http":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			u, err := url.Parse(valNode.Value)
+			if err != nil || u.Host == "" ||
+				(u.Scheme != "http" && u.Scheme != "https") {
+				return parse.InvalidServiceAt(
+					valNode, "http must be an http or https URL",
+				)
+			}
+			s.HTTP = valNode.Value
+		case "status":
+			var status int
+			if err := valNode.Decode(&status); err != nil {
+				return parse.ExpectedIntAt(valNode)
+			}
+			if status < 100 || status > 599 {
+				return parse.InvalidServiceAt(
+					valNode, "status must be an HTTP status code",
+				)
+			}
+			s.Status = status
+		case "timeout":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			dur, err := time.ParseDuration(valNode.Value)
+			if err != nil || dur <= 0 {
+				return parse.ExpectedDurationAt(valNode)
+			}
+			s.Timeout = dur
+		default:
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	if (s.TCP == "") == (s.HTTP == "") {
+		return parse.InvalidServiceAt(
+			node, "expected exactly one of tcp or http",
+		)
+	}
+	if s.Status != 0 && s.HTTP == "" {
+		return parse.InvalidServiceAt(node, "status requires http")
+	}
+	return nil
+}
+
 // DependencyVersion expresses a version constraint that must be met for a
 // particular dependency and instructs gdt how to get the version for a
 // dependency from a binary or package manager.
@@ -172,6 +471,18 @@ type DependencyVersionSelector struct {
 	// Args is the command-line to execute the dependency binary to output
 	// version information, e.g. '-v' or '--version-json'.
 	Args []string `yaml:"args,omitempty"`
+	// PackageManager is the package manager, one of "dpkg", "rpm" or "brew",
+	// to query for the version of the package that installed the binary
+	// instead of executing the binary.
+	PackageManager string `yaml:"package-manager,omitempty"`
+	// Package is the name of the package to query the PackageManager for. If
+	// empty, the Dependency's Name is used.
+	Package string `yaml:"package,omitempty"`
+	// JSONPath is an optional JSONPath expression, e.g.
+	// '$.clientVersion.gitVersion', that selects the version from JSON
+	// output returned by Command. Filter is run against the selected value.
+	JSONPath     string         `yaml:"json-path,omitempty"`
+	JSONPathExpr *jsonpath.Path `yaml:"-"`
 	// Filter is an optional regex to run against the output returned by
 	// Command, e.g. 'v?(\d)+\.(\d+)(\.(\d)+)?'.
 	Filter      string         `yaml:"filter,omitempty"`
@@ -199,6 +510,38 @@ func (s *DependencyVersionSelector) UnmarshalYAML(node *yaml.Node) error {
 				return err
 			}
 			s.Args = args
+		case "package-manager":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			pm := valNode.Value
+			if !lo.Contains(ValidPackageManagers, pm) {
+				return parse.InvalidPackageManagerAt(
+					valNode, pm, ValidPackageManagers,
+				)
+			}
+			s.PackageManager = pm
+		case "package":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Package = valNode.Value
+		case "json-path":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			path := valNode.Value
+			if !strings.HasPrefix(path, "$") {
+				return parse.InvalidJSONPathAt(
+					valNode, path, errors.New("expression must start with '$'"),
+				)
+			}
+			p, err := jsonpath.Parse(path)
+			if err != nil {
+				return parse.InvalidJSONPathAt(valNode, path, err)
+			}
+			s.JSONPath = path
+			s.JSONPathExpr = p
 		case "filter":
 			if valNode.Kind != yaml.ScalarNode {
 				return parse.ExpectedMapAt(valNode)
@@ -216,5 +559,20 @@ func (s *DependencyVersionSelector) UnmarshalYAML(node *yaml.Node) error {
 			return parse.UnknownFieldAt(key, keyNode)
 		}
 	}
+	if s.PackageManager != "" && len(s.Args) > 0 {
+		return parse.InvalidDependencyAt(
+			node, "expected only one of args or package-manager",
+		)
+	}
+	if s.PackageManager != "" && s.JSONPath != "" {
+		return parse.InvalidDependencyAt(
+			node, "expected only one of json-path or package-manager",
+		)
+	}
+	if s.Package != "" && s.PackageManager == "" {
+		return parse.InvalidDependencyAt(
+			node, "package requires package-manager",
+		)
+	}
 	return nil
 }
@@ -7,6 +7,8 @@ package api
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -33,9 +35,18 @@ var (
 	// ErrNoneIn is an ErrFailure when none of a list of elements appears in an
 	// expected container.
 	ErrNoneIn = fmt.Errorf("%w: none in", ErrFailure)
+	// ErrDurationOutOfRange is an ErrFailure when an observed duration is
+	// longer than an expected maximum or shorter than an expected minimum.
+	ErrDurationOutOfRange = fmt.Errorf("%w: duration out of range", ErrFailure)
 	// ErrUnexpectedError is an ErrFailure when an unexpected error has
 	// occurred.
 	ErrUnexpectedError = fmt.Errorf("%w: unexpected error", ErrFailure)
+	// ErrExpressionFalse is an ErrFailure when an asserted expression is
+	// false or cannot be evaluated.
+	ErrExpressionFalse = fmt.Errorf("%w: expression false", ErrFailure)
+	// ErrNotStable is an ErrFailure when a test's assertions did not pass
+	// the required number of consecutive times.
+	ErrNotStable = fmt.Errorf("%w: not stable", ErrFailure)
 )
 
 // TimeoutExceeded returns an ErrTimeoutExceeded when a test's execution
@@ -51,45 +62,122 @@ func TimeoutExceeded(duration string, failure error) error {
 	return fmt.Errorf("%s (%s)", ErrTimeoutExceeded, duration)
 }
 
+// AttemptTimeoutExceeded returns an ErrTimeoutExceeded when a single attempt
+// at a test's execution exceeds the per-attempt timeout length.
+func AttemptTimeoutExceeded(duration string) error {
+	return fmt.Errorf("%w: attempt timed out (%s)", ErrTimeoutExceeded, duration)
+}
+
+// terminalError is an assertion failure that retrying cannot fix.
+type terminalError struct {
+	err error
+}
+
+// Error returns the wrapped failure's message.
+func (e *terminalError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped failure.
+func (e *terminalError) Unwrap() error {
+	return e.err
+}
+
+// Terminal returns the supplied assertion failure marked as terminal, e.g. a
+// deterministic mismatch against a fixed expected value, so that the test
+// spec is not retried after it fails. Returns nil if err is nil.
+func Terminal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &terminalError{err: err}
+}
+
+// IsTerminal returns true if the supplied error, or any error it wraps, was
+// marked with Terminal().
+func IsTerminal(err error) bool {
+	var te *terminalError
+	return errors.As(err, &te)
+}
+
 // NotEqualLength returns an ErrNotEqual when an expected length doesn't
 // equal an observed length.
 func NotEqualLength(exp, got int) error {
-	return fmt.Errorf(
-		"%w: expected length of %d but got %d",
-		ErrNotEqual, exp, got,
-	)
+	return &Failure{
+		Class:    ErrNotEqual,
+		Message:  fmt.Sprintf("expected length of %d but got %d", exp, got),
+		Expected: exp,
+		Actual:   got,
+	}
 }
 
 // NotEqual returns an ErrNotEqual when an expected thing doesn't equal an
 // observed thing.
 func NotEqual(exp, got interface{}) error {
-	return fmt.Errorf("%w: expected %v but got %v", ErrNotEqual, exp, got)
+	return &Failure{
+		Class:    ErrNotEqual,
+		Message:  fmt.Sprintf("expected %v but got %v", exp, got),
+		Expected: exp,
+		Actual:   got,
+	}
 }
 
 // In returns an ErrIn when a thing unexpectedly appears in a container.
 func In(element, container interface{}) error {
-	return fmt.Errorf(
-		"%w: expected %v not to contain %v",
-		ErrIn, container, element,
-	)
+	return &Failure{
+		Class: ErrIn,
+		Message: fmt.Sprintf(
+			"expected %v not to contain %v", container, element,
+		),
+		Expected: element,
+		Actual:   container,
+	}
 }
 
 // NotIn returns an ErrNotIn when an expected thing doesn't appear in an
 // expected container.
 func NotIn(element, container interface{}) error {
-	return fmt.Errorf(
-		"%w: expected %v to contain %v",
-		ErrNotIn, container, element,
-	)
+	return &Failure{
+		Class:    ErrNotIn,
+		Message:  fmt.Sprintf("expected %v to contain %v", container, element),
+		Expected: element,
+		Actual:   container,
+	}
 }
 
 // NoneIn returns an ErrNoneIn when none of a list of elements appears in an
 // expected container.
 func NoneIn(elements, container interface{}) error {
-	return fmt.Errorf(
-		"%w: expected %v to contain one of %v",
-		ErrNoneIn, container, elements,
-	)
+	return &Failure{
+		Class: ErrNoneIn,
+		Message: fmt.Sprintf(
+			"expected %v to contain one of %v", container, elements,
+		),
+		Expected: elements,
+		Actual:   container,
+	}
+}
+
+// DurationTooLong returns an ErrDurationOutOfRange when an observed duration
+// is longer than an expected maximum.
+func DurationTooLong(max, got time.Duration) error {
+	return &Failure{
+		Class:    ErrDurationOutOfRange,
+		Message:  fmt.Sprintf("expected at most %s but took %s", max, got),
+		Expected: max,
+		Actual:   got,
+	}
+}
+
+// DurationTooShort returns an ErrDurationOutOfRange when an observed duration
+// is shorter than an expected minimum.
+func DurationTooShort(min, got time.Duration) error {
+	return &Failure{
+		Class:    ErrDurationOutOfRange,
+		Message:  fmt.Sprintf("expected at least %s but took %s", min, got),
+		Expected: min,
+		Actual:   got,
+	}
 }
 
 // UnexpectedError returns an ErrUnexpectedError when a supplied error is not
@@ -98,6 +186,27 @@ func UnexpectedError(err error) error {
 	return fmt.Errorf("%w: %s", ErrUnexpectedError, err)
 }
 
+// ExpressionFalse returns an ErrExpressionFalse when the supplied asserted
+// expression is false.
+func ExpressionFalse(expr string) error {
+	return fmt.Errorf("%w: %s", ErrExpressionFalse, expr)
+}
+
+// ExpressionFailed returns an ErrExpressionFalse when the supplied asserted
+// expression cannot be evaluated.
+func ExpressionFailed(expr string, err error) error {
+	return fmt.Errorf("%w: %s: %s", ErrExpressionFalse, expr, err)
+}
+
+// NotStable returns an ErrNotStable when a test's assertions passed fewer
+// consecutive times than required before retries stopped.
+func NotStable(required, got int) error {
+	return fmt.Errorf(
+		"%w: passed %d of %d required consecutive attempts",
+		ErrNotStable, got, required,
+	)
+}
+
 var (
 	// ErrUnknownSourceType indicates that a From() function was called with an
 	// unknown source parameter type.
@@ -110,38 +219,200 @@ func UnknownSourceType(source interface{}) error {
 	return fmt.Errorf("%w: %T", ErrUnknownSourceType, source)
 }
 
+// Stable, machine-readable codes for runtime errors. These codes never change
+// meaning once released, which allows CLI output, SARIF reports and
+// documentation to cross-reference a particular class of error
+// deterministically.
+const (
+	// CodeRuntimeError is the code for a generic RuntimeError.
+	CodeRuntimeError = "GDT-R001"
+	// CodeRequiredFixture is the code for ErrRequiredFixture.
+	CodeRequiredFixture = "GDT-R002"
+	// CodeDependencyNotSatisfied is the code for ErrDependencyNotSatisfied.
+	CodeDependencyNotSatisfied = "GDT-R003"
+	// CodeTimeoutConflict is the code for ErrTimeoutConflict.
+	CodeTimeoutConflict = "GDT-R004"
+	// CodeJSONPathVarFromNotMatched is the code for
+	// ErrJSONPathVarFromNotMatched.
+	CodeJSONPathVarFromNotMatched = "GDT-R005"
+	// CodePluginHook is the code for ErrPluginHook.
+	CodePluginHook = "GDT-R006"
+	// CodeSpecInvalid is the code for ErrSpecInvalid.
+	CodeSpecInvalid = "GDT-R007"
+	// CodeFixtureConfig is the code for ErrFixtureConfig.
+	CodeFixtureConfig = "GDT-R008"
+	// CodeFixtureStart is the code for ErrFixtureStart.
+	CodeFixtureStart = "GDT-R009"
+	// CodeFixtureStop is the code for ErrFixtureStop.
+	CodeFixtureStop = "GDT-R010"
+	// CodeFixtureDied is the code for ErrFixtureDied.
+	CodeFixtureDied = "GDT-R011"
+	// CodeFixtureState is the code for ErrFixtureState.
+	CodeFixtureState = "GDT-R012"
+	// CodeInterrupted is the code for ErrInterrupted.
+	CodeInterrupted = "GDT-R013"
+	// CodeVariable is the code for ErrVariable.
+	CodeVariable = "GDT-R014"
+	// CodeNotify is the code for ErrNotify.
+	CodeNotify = "GDT-R015"
+)
+
+// ErrorCoder is implemented by errors that carry a stable, machine-readable
+// error code, e.g. "GDT-P003" or "GDT-R002". Both `*parse.Error` and all
+// RuntimeError variants implement ErrorCoder.
+type ErrorCoder interface {
+	// ErrorCode returns the machine-readable error code.
+	ErrorCode() string
+}
+
+// ErrorCode returns the error code of the first error in the supplied error's
+// chain that implements ErrorCoder, or the empty string if no error in the
+// chain carries a code.
+func ErrorCode(err error) string {
+	var coder ErrorCoder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	return ""
+}
+
+// codedError is a sentinel error that carries an error code and optionally
+// wraps a more general sentinel error.
+type codedError struct {
+	code    string
+	msg     string
+	wrapped error
+}
+
+// Error implements the error interface for codedError.
+func (e *codedError) Error() string {
+	if e.wrapped != nil {
+		return e.wrapped.Error() + ": " + e.msg
+	}
+	return e.msg
+}
+
+// Unwrap returns the wrapped sentinel error, if any.
+func (e *codedError) Unwrap() error {
+	return e.wrapped
+}
+
+// ErrorCode returns the machine-readable error code.
+func (e *codedError) ErrorCode() string {
+	return e.code
+}
+
 var (
 	// RuntimeError is the base error class for all errors occurring during
 	// runtime (and not during the parsing of a scenario or spec)
 	// nolint:staticcheck
-	RuntimeError = errors.New("runtime error")
+	RuntimeError error = &codedError{
+		code: CodeRuntimeError,
+		msg:  "runtime error",
+	}
 	// ErrRequiredFixture is returned when a required fixture has not
 	// been registered with the context.
-	ErrRequiredFixture = fmt.Errorf(
-		"%w: required fixture missing",
-		RuntimeError,
-	)
+	ErrRequiredFixture error = &codedError{
+		code:    CodeRequiredFixture,
+		msg:     "required fixture missing",
+		wrapped: RuntimeError,
+	}
 	// ErrDependencyNotSatisfied is returned when a required fixture has not
 	// been registered with the context.
-	ErrDependencyNotSatisfied = fmt.Errorf(
-		"%w: dependency not satisfied",
-		RuntimeError,
-	)
+	ErrDependencyNotSatisfied error = &codedError{
+		code:    CodeDependencyNotSatisfied,
+		msg:     "dependency not satisfied",
+		wrapped: RuntimeError,
+	}
 	// ErrTimeoutConflict is returned when the Go test tool's timeout conflicts
 	// with either a total wait time or a timeout in a scenario or test spec
-	ErrTimeoutConflict = fmt.Errorf(
-		"%w: timeout conflict",
-		RuntimeError,
-	)
+	ErrTimeoutConflict error = &codedError{
+		code:    CodeTimeoutConflict,
+		msg:     "timeout conflict",
+		wrapped: RuntimeError,
+	}
 	// ErrJSONPathVarFromNotMatched is returned when the `var.$VAR.from`
 	// JSONPath expression fails to match some output results. This is a
 	// runtime error because we cannot continue execution after failing to
 	// populate the value of a variable that subsequent test specifications may
 	// depend on.
-	ErrJSONPathVarFromNotMatched = fmt.Errorf(
-		"%w: var.from JSONPath not matched",
-		RuntimeError,
-	)
+	ErrJSONPathVarFromNotMatched error = &codedError{
+		code:    CodeJSONPathVarFromNotMatched,
+		msg:     "var.from JSONPath not matched",
+		wrapped: RuntimeError,
+	}
+	// ErrPluginHook is returned when a plugin's scenario lifecycle hook
+	// returns an error.
+	ErrPluginHook error = &codedError{
+		code:    CodePluginHook,
+		msg:     "plugin hook failed",
+		wrapped: RuntimeError,
+	}
+	// ErrSpecInvalid is returned when a plugin's Validator finds a problem
+	// with a parsed test spec.
+	ErrSpecInvalid error = &codedError{
+		code:    CodeSpecInvalid,
+		msg:     "invalid test spec",
+		wrapped: RuntimeError,
+	}
+	// ErrFixtureConfig is returned when a fixture cannot be configured with
+	// the parameters in a test scenario's `fixtures` entry.
+	ErrFixtureConfig error = &codedError{
+		code:    CodeFixtureConfig,
+		msg:     "fixture configuration failed",
+		wrapped: RuntimeError,
+	}
+	// ErrFixtureStart is returned when a fixture fails to start or does not
+	// start within its start timeout.
+	ErrFixtureStart error = &codedError{
+		code:    CodeFixtureStart,
+		msg:     "fixture start failed",
+		wrapped: RuntimeError,
+	}
+	// ErrFixtureStop is returned when a FixtureV2 returns an error from Stop.
+	ErrFixtureStop error = &codedError{
+		code:    CodeFixtureStop,
+		msg:     "fixture stop failed",
+		wrapped: RuntimeError,
+	}
+	// ErrFixtureDied is returned when a fixture's health check fails between
+	// test specs and the fixture is not, or cannot be, restarted.
+	ErrFixtureDied error = &codedError{
+		code:    CodeFixtureDied,
+		msg:     "fixture died",
+		wrapped: RuntimeError,
+	}
+	// ErrFixtureState is returned when a test spec's `set` field cannot be
+	// applied to a fixture's state.
+	ErrFixtureState error = &codedError{
+		code:    CodeFixtureState,
+		msg:     "fixture state not set",
+		wrapped: RuntimeError,
+	}
+	// ErrInterrupted is returned when a test run is interrupted by a signal,
+	// e.g. SIGINT or SIGTERM.
+	ErrInterrupted error = &codedError{
+		code:    CodeInterrupted,
+		msg:     "test run interrupted",
+		wrapped: RuntimeError,
+	}
+	// ErrVariable is returned when the value of a scenario's variable, secret
+	// or imported variable cannot be loaded, e.g. because the file it refers
+	// to cannot be read, or when a variables file or state file cannot be
+	// loaded or saved.
+	ErrVariable error = &codedError{
+		code:    CodeVariable,
+		msg:     "variable not loaded",
+		wrapped: RuntimeError,
+	}
+	// ErrNotify is returned when a test run's notifier fails to send the
+	// summary of the test run, e.g. because a webhook returned an error
+	// status.
+	ErrNotify error = &codedError{
+		code:    CodeNotify,
+		msg:     "notification failed",
+		wrapped: RuntimeError,
+	}
 )
 
 // DependencyNotSatified returns an ErrDependencyNotSatisfied with the supplied
@@ -154,8 +425,19 @@ func DependencyNotSatisfied(dep *Dependency) error {
 		if dep.When.OS != "" {
 			conditions = append(conditions, "OS:"+dep.When.OS)
 		}
+		if dep.When.Arch != "" {
+			conditions = append(conditions, "arch:"+dep.When.Arch)
+		}
+		if dep.When.KernelVersion != "" {
+			conditions = append(conditions, "kernel:"+dep.When.KernelVersion)
+		}
+		for _, name := range dep.When.Env {
+			conditions = append(conditions, "env:"+name)
+		}
+	}
+	if len(conditions) > 0 {
+		conditionsStr = fmt.Sprintf(" (%s)", strings.Join(conditions, ","))
 	}
-	conditionsStr = fmt.Sprintf(" (%s)", strings.Join(conditions, ","))
 	return fmt.Errorf("%w: %s%s", ErrDependencyNotSatisfied, progName, conditionsStr)
 }
 
@@ -172,41 +454,210 @@ func DependencyNotSatisfiedVersionConstraint(
 	)
 }
 
+// DependencyNotSatisfiedService returns an ErrDependencyNotSatisfied for a
+// service dependency that could not be reached.
+func DependencyNotSatisfiedService(dep *Dependency, err error) error {
+	return fmt.Errorf(
+		"%w: %s not reachable: %w",
+		ErrDependencyNotSatisfied, dependencyName(dep), err,
+	)
+}
+
+// DependencyNotSatisfiedStatus returns an ErrDependencyNotSatisfied for a
+// service dependency that responded with an unexpected HTTP status code.
+func DependencyNotSatisfiedStatus(dep *Dependency, status int) error {
+	expect := "2xx"
+	if dep.Service.Status != 0 {
+		expect = strconv.Itoa(dep.Service.Status)
+	}
+	return fmt.Errorf(
+		"%w: %s returned status %d, expected %s",
+		ErrDependencyNotSatisfied, dependencyName(dep), status, expect,
+	)
+}
+
+// DependencyNotSatisfiedPath returns an ErrDependencyNotSatisfied for a file
+// or directory dependency, with the supplied reason it is not satisfied.
+func DependencyNotSatisfiedPath(dep *Dependency, reason string) error {
+	return fmt.Errorf(
+		"%w: %s %s", ErrDependencyNotSatisfied, dependencyName(dep), reason,
+	)
+}
+
+// dependencyName returns the name of a service, file or directory dependency
+// used in error messages.
+func dependencyName(dep *Dependency) string {
+	var target string
+	switch {
+	case dep.Service != nil:
+		target = dep.Service.Target()
+	case dep.Dir != "":
+		target = "dir " + dep.Dir
+	default:
+		target = "file " + dep.File
+	}
+	if dep.Name != "" {
+		return fmt.Sprintf("%s (%s)", dep.Name, target)
+	}
+	return target
+}
+
 // RequiredFixtureMissing returns an ErrRequiredFixture with the supplied
 // fixture name
 func RequiredFixtureMissing(name string) error {
 	return fmt.Errorf("%w: %s", ErrRequiredFixture, name)
 }
 
+// FixtureNotConfigurable returns an ErrFixtureConfig for a fixture with the
+// supplied name that was given parameters but is not a ConfigurableFixture.
+func FixtureNotConfigurable(name string) error {
+	return fmt.Errorf(
+		"%w: %s does not accept parameters", ErrFixtureConfig, name,
+	)
+}
+
+// FixtureConfigInvalid returns an ErrFixtureConfig for a fixture with the
+// supplied name that returned the supplied error from Configure.
+func FixtureConfigInvalid(name string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrFixtureConfig, name, err)
+}
+
+// FixtureStartFailed returns an ErrFixtureStart for a fixture with the
+// supplied name that returned the supplied error from Start.
+func FixtureStartFailed(name string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrFixtureStart, name, err)
+}
+
+// FixtureStartTimeout returns an ErrFixtureStart for a fixture with the
+// supplied name that did not start within the supplied timeout.
+func FixtureStartTimeout(name string, timeout time.Duration) error {
+	return fmt.Errorf(
+		"%w: %s did not start within %s", ErrFixtureStart, name, timeout,
+	)
+}
+
+// FixtureStopFailed returns an ErrFixtureStop for a fixture with the supplied
+// name that returned the supplied error from Stop.
+func FixtureStopFailed(name string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrFixtureStop, name, err)
+}
+
+// FixtureDied returns an ErrFixtureDied for a fixture with the supplied name
+// that was found unhealthy with the supplied error.
+func FixtureDied(name string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrFixtureDied, name, err)
+}
+
+// FixtureRestartFailed returns an ErrFixtureDied for an unhealthy fixture with
+// the supplied name that could not be restarted due to the supplied error.
+func FixtureRestartFailed(name string, unhealthy error, err error) error {
+	return fmt.Errorf(
+		"%w: %s: %w: restart failed: %w", ErrFixtureDied, name, unhealthy, err,
+	)
+}
+
+// FixtureStateNotSettable returns an ErrFixtureState for a fixture with the
+// supplied name that does not implement StateSetter.
+func FixtureStateNotSettable(name string) error {
+	return fmt.Errorf(
+		"%w: %s does not accept state changes", ErrFixtureState, name,
+	)
+}
+
+// FixtureStateSetFailed returns an ErrFixtureState for a fixture with the
+// supplied name that returned the supplied error from SetState for the
+// supplied path.
+func FixtureStateSetFailed(name string, path string, err error) error {
+	return fmt.Errorf("%w: %s: %s: %w", ErrFixtureState, name, path, err)
+}
+
+// Interrupted returns an ErrInterrupted for a test run interrupted by the
+// supplied signal.
+func Interrupted(sig os.Signal) error {
+	return fmt.Errorf("%w: received %s", ErrInterrupted, sig)
+}
+
+// ShutdownGraceExceeded returns an ErrInterrupted when cleanups and fixture
+// stops did not finish within the supplied grace period after a test run was
+// interrupted.
+func ShutdownGraceExceeded(grace time.Duration) error {
+	return fmt.Errorf(
+		"%w: cleanup did not finish within grace period of %s",
+		ErrInterrupted, grace,
+	)
+}
+
+// VariableLoadFailed returns an ErrVariable for the variable with the supplied
+// name whose value could not be loaded.
+func VariableLoadFailed(name string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrVariable, name, err)
+}
+
+// SecretLoadFailed returns an ErrVariable for the secret with the supplied
+// name whose value could not be resolved.
+func SecretLoadFailed(name string, err error) error {
+	return fmt.Errorf("%w: secret %s: %w", ErrVariable, name, err)
+}
+
+// ImportFailed returns an ErrVariable for the imported variable with the
+// supplied name whose value could not be imported.
+func ImportFailed(name string, err error) error {
+	return fmt.Errorf("%w: import %s: %w", ErrVariable, name, err)
+}
+
+// VarFileLoadFailed returns an ErrVariable for the variables file with the
+// supplied path that could not be loaded.
+func VarFileLoadFailed(path string, err error) error {
+	return fmt.Errorf("%w: var file %s: %w", ErrVariable, path, err)
+}
+
+// StateFileFailed returns an ErrVariable for the state file with the supplied
+// path that could not be loaded or saved.
+func StateFileFailed(path string, err error) error {
+	return fmt.Errorf("%w: state file %s: %w", ErrVariable, path, err)
+}
+
+// NotifyFailed returns an ErrNotify for the notifier with the supplied name
+// that failed to send a test run's summary.
+func NotifyFailed(name string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrNotify, name, err)
+}
+
 // TimeoutConflict returns an ErrTimeoutConflict describing how the Go test
-// tool's timeout conflicts with either a total wait time or a timeout value
-// from a scenario or spec.
+// tool's timeout, or the deadline of a test run executed without the Go test
+// tool, conflicts with either a total wait time or a timeout value from a
+// scenario or spec.
 func TimeoutConflict(
 	ti *Timings,
 ) error {
-	goTestTimeout := ti.GoTestTimeout
+	limit := ti.GoTestTimeout
+	source := "go test -timeout value"
+	if limit == 0 {
+		limit = ti.RunTimeout
+		source = "run deadline"
+	}
 	totalWait := ti.TotalWait
 	maxTimeout := ti.MaxTimeout
 	msg := fmt.Sprintf(
-		"go test -timeout value of %s ",
-		(goTestTimeout + time.Second).Round(time.Second),
+		"%s of %s ",
+		source, (limit + time.Second).Round(time.Second),
 	)
 	if totalWait > 0 {
-		if totalWait.Abs() > goTestTimeout.Abs() {
+		if totalWait.Abs() > limit.Abs() {
 			msg += fmt.Sprintf(
 				"is shorter than the total wait time in the scenario: %s. "+
 					"either decrease the wait times or increase the "+
-					"go test -timeout value.",
-				totalWait.Round(time.Second),
+					"%s.",
+				totalWait.Round(time.Second), source,
 			)
 		}
 	} else {
-		if maxTimeout.Abs() > goTestTimeout.Abs() {
+		if maxTimeout.Abs() > limit.Abs() {
 			msg += fmt.Sprintf(
 				"is shorter than the maximum timeout specified in the "+
 					"scenario: %s. either decrease the scenario or spec "+
-					"timeout or increase the go test -timeout value.",
-				maxTimeout.Round(time.Second),
+					"timeout or increase the %s.",
+				maxTimeout.Round(time.Second), source,
 			)
 		}
 	}
@@ -227,3 +678,21 @@ func JSONPathVarFromNotMatched(
 		ErrJSONPathVarFromNotMatched, varName, path,
 	)
 }
+
+// PluginHookFailed returns an ErrPluginHook describing the error returned from
+// the named lifecycle hook of the supplied plugin.
+func PluginHookFailed(
+	plugin string,
+	hook string,
+	err error,
+) error {
+	return fmt.Errorf("%w: %s.%s: %s", ErrPluginHook, plugin, hook, err)
+}
+
+// SpecInvalid returns an ErrSpecInvalid describing a problem that a plugin's
+// Validator found with the supplied test spec.
+func SpecInvalid(spec *Spec, err error) error {
+	return fmt.Errorf(
+		"%w: spec %d (%s): %s", ErrSpecInvalid, spec.Index, spec.Title(), err,
+	)
+}
@@ -5,10 +5,14 @@
 package api_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/parse"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestUnknownSourceType(t *testing.T) {
@@ -21,3 +25,77 @@ func TestUnknownSourceType(t *testing.T) {
 	err = api.UnknownSourceType(source)
 	assert.ErrorContains(err, "[]string")
 }
+
+func TestErrorCode(t *testing.T) {
+	assert := assert.New(t)
+
+	err := api.RequiredFixtureMissing("foo")
+	assert.Equal(api.CodeRequiredFixture, api.ErrorCode(err))
+	assert.ErrorIs(err, api.ErrRequiredFixture)
+	assert.ErrorIs(err, api.RuntimeError)
+	assert.ErrorContains(err, "runtime error: required fixture missing: foo")
+
+	err = fmt.Errorf("%w: something bad", api.RuntimeError)
+	assert.Equal(api.CodeRuntimeError, api.ErrorCode(err))
+
+	err = parse.ExpectedMapAt(&yaml.Node{Line: 1, Column: 2})
+	assert.Equal(parse.CodeExpectedMap, api.ErrorCode(err))
+
+	err = parse.UnknownFieldAt("foo", &yaml.Node{Line: 1, Column: 2})
+	assert.Equal(parse.CodeUnknownField, api.ErrorCode(err))
+	assert.ErrorIs(err, parse.ErrParseUnknownField)
+
+	assert.Equal("", api.ErrorCode(errors.New("uncoded")))
+}
+
+func TestTerminal(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(api.Terminal(nil))
+
+	fail := api.NotEqual(1, 2)
+	assert.False(api.IsTerminal(fail))
+
+	err := api.Terminal(fail)
+	assert.True(api.IsTerminal(err))
+	assert.ErrorIs(err, api.ErrNotEqual)
+	assert.Equal(fail.Error(), err.Error())
+	assert.True(api.IsTerminal(fmt.Errorf("wrapped: %w", err)))
+
+	res := api.NewResult(api.WithFailures(fail))
+	assert.False(res.Terminal())
+	res = api.NewResult(api.WithFailures(fail, err))
+	assert.True(res.Terminal())
+	res = api.NewResult(api.WithFailures(fail), api.WithTerminal(true))
+	assert.True(res.Terminal())
+}
+
+func TestFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	err := api.NotEqual(1, 2)
+	assert.ErrorIs(err, api.ErrNotEqual)
+	assert.ErrorIs(err, api.ErrFailure)
+	assert.EqualError(err, "assertion failed: not equal: expected 1 but got 2")
+	f, ok := api.AsFailure(err)
+	assert.True(ok)
+	assert.Equal(1, f.Expected)
+	assert.Equal(2, f.Actual)
+
+	err = &api.Failure{
+		Code:    "GDT-X001",
+		Message: "bodies differ",
+		Diff:    "-a\n+b",
+		Path:    "$.body",
+	}
+	assert.ErrorIs(err, api.ErrFailure)
+	assert.EqualError(err, "assertion failed: bodies differ:\n-a\n+b")
+	assert.Equal("GDT-X001", api.ErrorCode(err))
+
+	f, ok = api.AsFailure(fmt.Errorf("wrapped: %w", api.Terminal(err)))
+	assert.True(ok)
+	assert.Equal("$.body", f.Path)
+
+	_, ok = api.AsFailure(errors.New("bare"))
+	assert.False(ok)
+}
@@ -26,3 +26,11 @@ type Evaluable interface {
 	// Timeout returns the Evaluable's Timeout override, if any
 	Timeout() *Timeout
 }
+
+// A FileReferencer is an Evaluable whose result depends on the contents of
+// files that it references, e.g. a script to execute or a golden file. The
+// result cache includes the digests of these files in a test spec's key.
+type FileReferencer interface {
+	// Files returns the paths of the files the Evaluable references.
+	Files() []string
+}
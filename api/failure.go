@@ -0,0 +1,71 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api
+
+import (
+	"errors"
+)
+
+// Failure is an assertion failure that carries structured detail about what a
+// test spec expected and what it observed, so that reports can render the
+// failure richly, e.g. as a colored diff. Plugins may return a *Failure
+// wherever they would return a bare assertion failure error.
+//
+// A *Failure matches ErrFailure, and its Class if any, with errors.Is.
+type Failure struct {
+	// Code is an optional machine-readable code identifying the failure,
+	// returned by ErrorCode.
+	Code string
+	// Class is the sentinel error for the kind of failure, e.g. ErrNotEqual.
+	// It should wrap ErrFailure. Defaults to ErrFailure.
+	Class error
+	// Message describes the failure.
+	Message string
+	// Expected is the value the test spec expected, if any.
+	Expected any
+	// Actual is the value the test spec observed, if any.
+	Actual any
+	// Diff is a unified diff between the expected and observed content, if
+	// any.
+	Diff string
+	// Path locates the compared value within the observed content, e.g. a
+	// JSONPath expression, if any.
+	Path string
+}
+
+// Error returns the failure's class, message and diff.
+func (f *Failure) Error() string {
+	msg := f.Unwrap().Error()
+	if f.Message != "" {
+		msg += ": " + f.Message
+	}
+	if f.Diff != "" {
+		msg += ":\n" + f.Diff
+	}
+	return msg
+}
+
+// Unwrap returns the failure's class.
+func (f *Failure) Unwrap() error {
+	if f.Class == nil {
+		return ErrFailure
+	}
+	return f.Class
+}
+
+// ErrorCode returns the failure's machine-readable code, if any.
+func (f *Failure) ErrorCode() string {
+	return f.Code
+}
+
+// AsFailure returns the first *Failure in the supplied error's chain and true,
+// or nil and false if there is none.
+func AsFailure(err error) (*Failure, bool) {
+	var f *Failure
+	if errors.As(err, &f) {
+		return f, true
+	}
+	return nil, false
+}
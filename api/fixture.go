@@ -4,7 +4,80 @@
 
 package api
 
-import "context"
+import (
+	"context"
+	"sync"
+)
+
+// FixtureScope describes how long a started fixture is used for.
+type FixtureScope string
+
+const (
+	// FixtureScopeSuite indicates that a fixture is started once for the test
+	// suite containing the test scenario and stopped when the test suite
+	// finishes.
+	FixtureScopeSuite FixtureScope = "suite"
+	// FixtureScopeScenario indicates that a fixture is started before the
+	// test scenario's test specs run and stopped when the test scenario
+	// finishes. This is the default.
+	FixtureScopeScenario FixtureScope = "scenario"
+	// FixtureScopeSpec indicates that a fixture is started before each of the
+	// test scenario's test specs and stopped after each test spec.
+	FixtureScopeSpec FixtureScope = "spec"
+)
+
+// FixtureScopes contains the valid fixture scopes.
+var FixtureScopes = []FixtureScope{
+	FixtureScopeSuite,
+	FixtureScopeScenario,
+	FixtureScopeSpec,
+}
+
+// FixtureHealth describes what happens when a fixture that implements
+// HealthChecker is found to be unhealthy between test specs.
+type FixtureHealth string
+
+const (
+	// FixtureHealthFail indicates that the test scenario fails with an
+	// ErrFixtureDied when the fixture is unhealthy.
+	FixtureHealthFail FixtureHealth = "fail"
+	// FixtureHealthRestart indicates that an unhealthy fixture is stopped and
+	// started again. The test scenario fails with an ErrFixtureDied if the
+	// fixture cannot be restarted.
+	FixtureHealthRestart FixtureHealth = "restart"
+)
+
+// FixtureHealths contains the valid fixture health policies.
+var FixtureHealths = []FixtureHealth{
+	FixtureHealthFail,
+	FixtureHealthRestart,
+}
+
+// A HealthChecker is a fixture that can report whether it is still usable,
+// e.g. whether a server process it started is still running.
+type HealthChecker interface {
+	// Healthy returns nil if the fixture is healthy, otherwise an error
+	// describing why it is not.
+	Healthy(context.Context) error
+}
+
+// A StateSetter is a fixture whose state can be changed by test specs, using
+// the `set` field of a test spec, so that later test specs and `skip-if`
+// conditions see the new state.
+type StateSetter interface {
+	// SetState sets the state at the supplied path to the supplied value.
+	SetState(path string, value interface{}) error
+}
+
+// An EnvProvider is a fixture that publishes environment variables, e.g.
+// `HTTPMOCK_URL`, describing how to reach the resources it started. While the
+// fixture is started, the variables are stored in the context, and plugins
+// such as `exec` add them to the environment of the processes they start.
+type EnvProvider interface {
+	// Env returns the environment variables published by the started
+	// fixture.
+	Env() map[string]string
+}
 
 // A Fixture allows state to be passed from setups
 type Fixture interface {
@@ -19,3 +92,150 @@ type Fixture interface {
 	// key is managed by the fixture
 	State(string) interface{}
 }
+
+// A ConfigurableFixture is a Fixture that accepts parameters from the `with`
+// field of a test scenario's `fixtures` entry, allowing a single registered
+// fixture to serve many configurations.
+type ConfigurableFixture interface {
+	Fixture
+	// Configure returns a Fixture configured with the supplied parameters.
+	// The returned Fixture is started and stopped instead of the
+	// ConfigurableFixture.
+	Configure(params map[string]interface{}) (Fixture, error)
+}
+
+// A FixtureV2 is a fixture whose Stop method reports a failure to tear down
+// the fixture. Errors returned from Stop are returned from the test run as
+// ErrFixtureStop errors instead of being ignored.
+//
+// Register a FixtureV2 with `gdtcontext.RegisterFixtureV2` or adapt it into a
+// Fixture with FixtureFromV2.
+type FixtureV2 interface {
+	// Start sets up the fixture
+	Start(context.Context) error
+	// Stop tears down the fixture, cleaning up any owned resources, and
+	// returns any error encountered doing so
+	Stop(context.Context) error
+	// HasState returns true if the fixture contains some state with the given
+	// key
+	HasState(string) bool
+	// State returns the state data at the given key, or nil if no such state
+	// key is managed by the fixture
+	State(string) interface{}
+}
+
+// fixtureFromV2 adapts a FixtureV2 into a Fixture.
+type fixtureFromV2 struct {
+	FixtureV2
+}
+
+// Stop tears down the wrapped FixtureV2, discarding any error.
+func (f *fixtureFromV2) Stop(ctx context.Context) {
+	_ = f.FixtureV2.Stop(ctx)
+}
+
+// fixtureV2From adapts a Fixture into a FixtureV2.
+type fixtureV2From struct {
+	Fixture
+}
+
+// Stop tears down the wrapped Fixture and always returns nil.
+func (f *fixtureV2From) Stop(ctx context.Context) error {
+	f.Fixture.Stop(ctx)
+	return nil
+}
+
+// FixtureFromV2 returns a Fixture that wraps the supplied FixtureV2. Use
+// AsFixtureV2 to get the FixtureV2 back.
+func FixtureFromV2(f FixtureV2) Fixture {
+	return &fixtureFromV2{f}
+}
+
+// AsFixtureV2 returns the supplied Fixture as a FixtureV2. If the Fixture was
+// returned from FixtureFromV2, the wrapped FixtureV2 is returned, otherwise
+// the Fixture is adapted into a FixtureV2 whose Stop method always returns
+// nil.
+func AsFixtureV2(f Fixture) FixtureV2 {
+	if v2, ok := f.(*fixtureFromV2); ok {
+		return v2.FixtureV2
+	}
+	return &fixtureV2From{f}
+}
+
+// A FixtureFactory constructs a Fixture. Registering a FixtureFactory instead
+// of a constructed Fixture means an expensive fixture is only constructed
+// when a test scenario references it.
+type FixtureFactory func(context.Context) (Fixture, error)
+
+// A LazyFixture is a Fixture that is constructed by a FixtureFactory the first
+// time it is needed. Its Fixture methods are passed to the constructed
+// Fixture.
+type LazyFixture struct {
+	factory FixtureFactory
+	mu      sync.Mutex
+	done    bool
+	fix     Fixture
+	err     error
+}
+
+// Build constructs the Fixture, if it has not already been constructed, and
+// returns it along with any error returned from the FixtureFactory.
+func (f *LazyFixture) Build(ctx context.Context) (Fixture, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.done {
+		f.fix, f.err = f.factory(ctx)
+		f.done = true
+	}
+	return f.fix, f.err
+}
+
+// built returns the constructed Fixture or nil if the Fixture has not been
+// successfully constructed. Unlike Build, built never constructs the Fixture.
+func (f *LazyFixture) built() Fixture {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.done || f.err != nil {
+		return nil
+	}
+	return f.fix
+}
+
+// Start constructs and starts the Fixture
+func (f *LazyFixture) Start(ctx context.Context) error {
+	fix, err := f.Build(ctx)
+	if err != nil {
+		return err
+	}
+	return fix.Start(ctx)
+}
+
+// Stop stops the Fixture if it has been constructed
+func (f *LazyFixture) Stop(ctx context.Context) {
+	if fix := f.built(); fix != nil {
+		fix.Stop(ctx)
+	}
+}
+
+// HasState returns true if the constructed Fixture contains some state with
+// the given key
+func (f *LazyFixture) HasState(key string) bool {
+	if fix := f.built(); fix != nil {
+		return fix.HasState(key)
+	}
+	return false
+}
+
+// State returns the state data at the given key from the constructed Fixture
+func (f *LazyFixture) State(key string) interface{} {
+	if fix := f.built(); fix != nil {
+		return fix.State(key)
+	}
+	return nil
+}
+
+// NewLazyFixture returns a LazyFixture that is constructed by the supplied
+// FixtureFactory.
+func NewLazyFixture(factory FixtureFactory) *LazyFixture {
+	return &LazyFixture{factory: factory}
+}
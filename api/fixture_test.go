@@ -0,0 +1,59 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/fixture"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixtureV2(t *testing.T) {
+	assert := assert.New(t)
+
+	stopped := false
+	v1 := fixture.New(
+		fixture.WithStopper(func(context.Context) {
+			stopped = true
+		}),
+	)
+	v2 := api.AsFixtureV2(v1)
+	assert.Nil(v2.Stop(context.TODO()))
+	assert.True(stopped)
+
+	// A FixtureV2 adapted into a Fixture is unwrapped by AsFixtureV2.
+	adapted := api.FixtureFromV2(v2)
+	assert.Same(v2, api.AsFixtureV2(adapted))
+}
+
+func TestLazyFixture(t *testing.T) {
+	assert := assert.New(t)
+
+	builds := 0
+	lazy := api.NewLazyFixture(func(context.Context) (api.Fixture, error) {
+		builds++
+		return fixture.New(
+			fixture.WithState(map[string]interface{}{"foo": "bar"}),
+		), nil
+	})
+
+	// Looking up state does not construct the fixture.
+	assert.False(lazy.HasState("foo"))
+	assert.Nil(lazy.State("foo"))
+	lazy.Stop(context.TODO())
+	assert.Equal(0, builds)
+
+	assert.Nil(lazy.Start(context.TODO()))
+	assert.True(lazy.HasState("foo"))
+	assert.Equal("bar", lazy.State("foo"))
+
+	fix, err := lazy.Build(context.TODO())
+	assert.Nil(err)
+	assert.Equal("bar", fix.State("foo"))
+	assert.Equal(1, builds)
+}
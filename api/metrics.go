@@ -0,0 +1,123 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Metrics contains counters describing the work a test spec performed while
+// it was evaluated.
+type Metrics struct {
+	// Requests is the number of requests, e.g. HTTP calls or Kubernetes API
+	// calls, that were made.
+	Requests int
+	// BytesSent is the number of bytes sent to the system under test.
+	BytesSent int64
+	// BytesReceived is the number of bytes received from the system under
+	// test.
+	BytesReceived int64
+	// Retries is the number of times the test spec was retried because its
+	// assertions failed.
+	Retries int
+}
+
+// Add adds the counters in the supplied Metrics to the Metrics.
+func (m *Metrics) Add(other *Metrics) {
+	if other == nil {
+		return
+	}
+	m.Requests += other.Requests
+	m.BytesSent += other.BytesSent
+	m.BytesReceived += other.BytesReceived
+	m.Retries += other.Retries
+}
+
+// IsZero returns true if none of the Metrics' counters have been
+// incremented.
+func (m *Metrics) IsZero() bool {
+	return m == nil || *m == Metrics{}
+}
+
+// MetricsReporter is an optional interface that an Evaluable can implement in
+// order to report Metrics about its most recent Eval. The scenario runner
+// calls Metrics after each call to Eval and adds the returned counters to the
+// Result's Metrics. Plugins that construct their Results with WithMetrics do
+// not need to implement MetricsReporter.
+type MetricsReporter interface {
+	// Metrics returns the Metrics for the most recent call to Eval.
+	Metrics() *Metrics
+}
+
+// Measurement is a named value, e.g. a request latency or a throughput,
+// recorded by a plugin while evaluating a test spec.
+type Measurement struct {
+	// Name identifies the measurement, e.g. "http.latency".
+	Name string
+	// Value is the measured value.
+	Value float64
+	// Unit is the unit of the measured value, e.g. "ms" or "req/s".
+	Unit string
+}
+
+// MeasurementSummary aggregates the values of all the Measurements with the
+// same name and unit.
+type MeasurementSummary struct {
+	// Name is the name of the summarized Measurements.
+	Name string
+	// Unit is the unit of the summarized Measurements.
+	Unit string
+	// Count is the number of summarized Measurements.
+	Count int
+	// Sum is the sum of the summarized Measurements' values.
+	Sum float64
+	// Min is the smallest of the summarized Measurements' values.
+	Min float64
+	// Max is the largest of the summarized Measurements' values.
+	Max float64
+}
+
+// Mean returns the mean of the summarized Measurements' values.
+func (s MeasurementSummary) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// SummarizeMeasurements returns a MeasurementSummary for each distinct name
+// and unit of the supplied Measurements, sorted by name and unit.
+func SummarizeMeasurements(measurements []Measurement) []MeasurementSummary {
+	type key struct{ name, unit string }
+	byKey := map[key]*MeasurementSummary{}
+	for _, m := range measurements {
+		k := key{m.Name, m.Unit}
+		s, ok := byKey[k]
+		if !ok {
+			byKey[k] = &MeasurementSummary{
+				Name:  m.Name,
+				Unit:  m.Unit,
+				Count: 1,
+				Sum:   m.Value,
+				Min:   m.Value,
+				Max:   m.Value,
+			}
+			continue
+		}
+		s.Count++
+		s.Sum += m.Value
+		s.Min = min(s.Min, m.Value)
+		s.Max = max(s.Max, m.Value)
+	}
+	res := make([]MeasurementSummary, 0, len(byKey))
+	for _, s := range byKey {
+		res = append(res, *s)
+	}
+	slices.SortFunc(res, func(a, b MeasurementSummary) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Unit, b.Unit))
+	})
+	return res
+}
@@ -0,0 +1,37 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeMeasurements(t *testing.T) {
+	assert := assert.New(t)
+
+	res := api.NewResult(
+		api.WithMetric("latency", 30, "ms"),
+		api.WithMetric("latency", 10, "ms"),
+	)
+	res.RecordMetric("latency", 2, "s")
+	res.RecordMetric("throughput", 100, "req/s")
+	res.RecordMetric("latency", 20, "ms")
+
+	assert.Len(res.Measurements(), 5)
+	assert.Equal(
+		[]api.MeasurementSummary{
+			{Name: "latency", Unit: "ms", Count: 3, Sum: 60, Min: 10, Max: 30},
+			{Name: "latency", Unit: "s", Count: 1, Sum: 2, Min: 2, Max: 2},
+			{Name: "throughput", Unit: "req/s", Count: 1, Sum: 100, Min: 100, Max: 100},
+		},
+		api.SummarizeMeasurements(res.Measurements()),
+	)
+	assert.Equal(20.0, api.SummarizeMeasurements(res.Measurements())[0].Mean())
+	assert.Empty(api.SummarizeMeasurements(nil))
+	assert.Equal(0.0, api.MeasurementSummary{}.Mean())
+}
@@ -4,13 +4,23 @@
 
 package api
 
-import "gopkg.in/yaml.v3"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 // PluginInfo contains basic information about the plugin and what type of
 // tests it can handle.
 type PluginInfo struct {
 	// Name is the primary name of the plugin
 	Name string
+	// APIVersion is the version of the gdt core plugin API that the plugin
+	// was built against. Plugins should set this to api.APIVersion. Plugins
+	// that leave APIVersion unset are assumed to be compatible.
+	APIVersion int
 	// Aliases is an optional set of aliased names for the plugin
 	Aliases []string
 	// Description describes what types of tests the plugin can handle.
@@ -21,6 +31,63 @@ type PluginInfo struct {
 	// Retry is a Retry that should be used by default for test specs of this
 	// plugin.
 	Retry *Retry
+	// Fields documents the plugin-specific fields of the plugin's test specs.
+	// Fields common to all test specs, e.g. `name` or `timeout`, should not be
+	// included.
+	Fields []FieldDoc
+	// Deprecated lists the plugin's test spec fields that are deprecated.
+	// gdt emits a warning when a scenario uses one of these fields.
+	Deprecated []DeprecatedField
+}
+
+// FieldDoc documents a single field in a plugin's test spec.
+type FieldDoc struct {
+	// Name is the YAML name of the field.
+	Name string
+	// Type describes the type of the field's value, e.g. "string", "int" or
+	// "map".
+	Type string
+	// Description describes what the field does.
+	Description string
+	// Required is true when the field must be set.
+	Required bool
+	// Examples is an optional set of example values for the field.
+	Examples []string
+	// Fields documents the nested fields of the field when the field's value
+	// is a map.
+	Fields []FieldDoc
+}
+
+// DeprecatedField describes a deprecated field in a plugin's test spec and
+// what test authors should use instead.
+type DeprecatedField struct {
+	// Name is the YAML name of the deprecated field. Nested fields are
+	// referred to with a dotted path, e.g. "assert.out".
+	Name string
+	// Replacement is the optional name of the field that should be used
+	// instead of the deprecated field.
+	Replacement string
+	// RemovedIn is the optional version of the plugin in which the
+	// deprecated field will be removed.
+	RemovedIn string
+	// Message is an optional free-form explanation of the deprecation.
+	Message string
+}
+
+// Guidance returns a description of what test authors should do about the
+// deprecated field.
+func (f DeprecatedField) Guidance() string {
+	parts := []string{}
+	if f.Replacement != "" {
+		parts = append(parts, fmt.Sprintf("use %q instead", f.Replacement))
+	}
+	if f.RemovedIn != "" {
+		parts = append(parts, "it will be removed in "+f.RemovedIn)
+	}
+	if f.Message != "" {
+		parts = append(parts, f.Message)
+	}
+	return strings.Join(parts, "; ")
 }
 
 type DefaultsHandler interface {
@@ -44,3 +111,68 @@ type Plugin interface {
 	// how to parse.
 	Specs() []Evaluable
 }
+
+// Validator is an optional interface that a Plugin can implement in order to
+// validate a parsed test spec. Validate is called when a scenario is linted
+// and before a scenario is run, and should catch semantic errors, such as
+// mutually exclusive fields being set, that cannot be detected while
+// unmarshaling the test spec's YAML.
+type Validator interface {
+	// Validate returns any problems with the supplied test spec, which was
+	// parsed by the Plugin.
+	Validate(context.Context, Evaluable) []error
+}
+
+// ScenarioInfo describes a run of a test scenario to a Plugin's
+// ScenarioHooks.
+type ScenarioInfo struct {
+	// ID identifies this run of the scenario. It is also returned by
+	// `gdtcontext.ScenarioID()` for the contexts passed to the scenario's test
+	// specs, so plugins can keep state for each run of a scenario.
+	ID string
+	// RunID identifies the test run that the scenario is part of.
+	RunID string
+	// Path is the filepath to the scenario, if it was read from a file.
+	Path string
+	// Title is the scenario's name, or the base name of its Path if it has
+	// no name.
+	Title string
+	// Tests contains the scenario's test specs.
+	Tests []Evaluable
+	// Results contains, in order, the results of the scenario's test specs
+	// that were run or skipped. It is empty when passed to BeforeScenario
+	// and when the scenario was skipped as a whole, e.g. by `skip-if`.
+	Results []SpecResult
+}
+
+// SpecResult is the result of one of a scenario's test specs, passed to
+// ScenarioHooks.AfterScenario.
+type SpecResult struct {
+	// Index is the index of the test spec in the scenario's Tests.
+	Index int
+	// Title is the test spec's title.
+	Title string
+	// Skipped is true if the test spec was skipped, e.g. because one of its
+	// dependencies was not satisfied.
+	Skipped bool
+	// Failures contains the test spec's failures, if any.
+	Failures []error
+}
+
+// OK returns true if the test spec was skipped or passed.
+func (r SpecResult) OK() bool {
+	return len(r.Failures) == 0
+}
+
+// ScenarioHooks is an optional interface that a Plugin can implement in order
+// to be notified once before and once after a scenario containing test specs
+// for the plugin is run. Plugins use these hooks to set up clients that are
+// shared by all of the scenario's test specs or to flush buffered telemetry.
+type ScenarioHooks interface {
+	// BeforeScenario is called before any of the scenario's test specs are
+	// evaluated. Returning an error stops the scenario from running.
+	BeforeScenario(context.Context, ScenarioInfo) error
+	// AfterScenario is called after all of the scenario's test specs have
+	// been evaluated, regardless of whether they succeeded.
+	AfterScenario(context.Context, ScenarioInfo) error
+}
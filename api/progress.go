@@ -0,0 +1,95 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api
+
+import "time"
+
+// ProgressKind describes what happened in a test run for a ProgressEvent.
+type ProgressKind int
+
+const (
+	// ProgressSpecStarted is the kind of event sent when a test spec starts
+	// to run.
+	ProgressSpecStarted ProgressKind = iota
+	// ProgressSpecFinished is the kind of event sent when a test spec has
+	// finished running.
+	ProgressSpecFinished
+	// ProgressAttempt is the kind of event sent before each attempt to
+	// evaluate a test spec.
+	ProgressAttempt
+	// ProgressWaiting is the kind of event sent before waiting, either before
+	// or after a test spec runs or between the attempts of a retried test
+	// spec.
+	ProgressWaiting
+	// ProgressFixtureStarting is the kind of event sent before a fixture is
+	// started.
+	ProgressFixtureStarting
+	// ProgressScenarioChanged is the kind of event sent when a watched
+	// scenario file has been created or modified and is about to run.
+	ProgressScenarioChanged
+	// ProgressScenarioFinished is the kind of event sent when a watched
+	// scenario has finished running.
+	ProgressScenarioFinished
+)
+
+// String returns the name of the ProgressKind.
+func (k ProgressKind) String() string {
+	switch k {
+	case ProgressSpecStarted:
+		return "spec-started"
+	case ProgressSpecFinished:
+		return "spec-finished"
+	case ProgressAttempt:
+		return "attempt"
+	case ProgressWaiting:
+		return "waiting"
+	case ProgressFixtureStarting:
+		return "fixture-starting"
+	case ProgressScenarioChanged:
+		return "scenario-changed"
+	case ProgressScenarioFinished:
+		return "scenario-finished"
+	default:
+		return "unknown"
+	}
+}
+
+// ProgressEvent describes something that happened in a test run, allowing
+// e.g. a CLI tool to show the progress of long waits and retries.
+type ProgressEvent struct {
+	// Kind is what happened.
+	Kind ProgressKind
+	// Time is when the event happened, according to the context's Clock.
+	Time time.Time
+	// Trace is the trace name of the test scenario or test spec, e.g.
+	// "my-scenario/0:create-thing".
+	Trace string
+	// RunID identifies the test run.
+	RunID string
+	// SpecID identifies the run of the test spec. It is empty for events
+	// sent outside of a test spec's run, such as the starting of fixtures.
+	SpecID string
+	// Attempt is the 1-based number of the attempt for a ProgressAttempt
+	// event.
+	Attempt int
+	// Wait is how long will be waited for a ProgressWaiting event.
+	Wait time.Duration
+	// Fixture is the name of the fixture for a ProgressFixtureStarting event.
+	Fixture string
+	// Path is the filepath to the scenario for a ProgressScenarioChanged or
+	// ProgressScenarioFinished event.
+	Path string
+	// Failed is true for a ProgressSpecFinished event if the test spec failed
+	// or errored, and for a ProgressScenarioFinished event if any of the
+	// scenario's test specs failed or the scenario errored.
+	Failed bool
+	// Err is the error, e.g. a parse error, that stopped the scenario from
+	// running for a ProgressScenarioFinished event.
+	Err error
+}
+
+// ProgressFunc receives the ProgressEvents of a test run. ProgressFuncs are
+// called synchronously by the test runner and should return quickly.
+type ProgressFunc func(ProgressEvent)
@@ -19,6 +19,9 @@ type Result struct {
 	// stopOnFail is an indication to the scenario that if there are any
 	// failures, the scenario should not proceed with test execution.
 	stopOnFail bool
+	// terminal is an indication that the failures cannot be fixed by
+	// retrying the test spec, so no further attempts should be made.
+	terminal bool
 	// failures is the collection of error messages from assertion failures
 	// that occurred during Eval(). These are *not* `gdterrors.RuntimeError`.
 	failures []error
@@ -30,6 +33,30 @@ type Result struct {
 	// the `gdtcontext.PriorRunData()` function. Plugins are responsible for
 	// clearing and setting any used prior run data.
 	data map[string]any
+	// metrics contains counters describing the work performed during Eval().
+	metrics *Metrics
+	// artifacts is the collection of named content produced during Eval(),
+	// e.g. diagnostic command output, that is kept with the test's result.
+	artifacts []Artifact
+	// measurements is the collection of named values, e.g. request
+	// latencies, recorded during Eval().
+	measurements []Measurement
+}
+
+// Artifact is named content produced while evaluating a test spec that is
+// kept with the test's result, e.g. the output of a diagnostic command run
+// when an assertion fails.
+type Artifact struct {
+	// Name identifies the artifact within the test's result.
+	Name string
+	// ContentType is the MIME type of the artifact's content, e.g.
+	// "application/json", if known.
+	ContentType string
+	// Content is the artifact's content.
+	Content []byte
+	// Path is the file the artifact's content was written to when the test
+	// run keeps artifacts in a directory, or empty.
+	Path string
 }
 
 // HasData returns true if any of the run data has been set, false otherwise.
@@ -48,6 +75,21 @@ func (r *Result) StopOnFail() bool {
 	return r.stopOnFail
 }
 
+// Terminal returns true if the Result was marked terminal or any of its
+// assertion failures was marked with Terminal(), meaning that retrying the
+// test spec cannot change the outcome.
+func (r *Result) Terminal() bool {
+	if r.terminal {
+		return true
+	}
+	for _, f := range r.failures {
+		if IsTerminal(f) {
+			return true
+		}
+	}
+	return false
+}
+
 // Failed returns true if any assertion failed during Eval(), false otherwise.
 func (r *Result) Failed() bool {
 	return len(r.failures) > 0
@@ -90,6 +132,78 @@ func (r *Result) SetData(
 	r.data[key] = val
 }
 
+// Metrics returns the counters describing the work performed during Eval().
+// Metrics never returns nil.
+func (r *Result) Metrics() *Metrics {
+	if r.metrics == nil {
+		r.metrics = &Metrics{}
+	}
+	return r.metrics
+}
+
+// SetMetrics sets the result's Metrics.
+func (r *Result) SetMetrics(m *Metrics) {
+	r.metrics = m
+}
+
+// AddMetrics adds the supplied counters to the result's Metrics.
+func (r *Result) AddMetrics(m *Metrics) {
+	r.Metrics().Add(m)
+}
+
+// RecordMetric records a named value, e.g. a request latency or throughput,
+// in the supplied unit. Recorded values are summarized for each scenario and
+// test run.
+func (r *Result) RecordMetric(name string, value float64, unit string) {
+	r.measurements = append(r.measurements, Measurement{
+		Name:  name,
+		Value: value,
+		Unit:  unit,
+	})
+}
+
+// Measurements returns the named values recorded during Eval().
+func (r *Result) Measurements() []Measurement {
+	return r.measurements
+}
+
+// SetMeasurements sets the result's collection of recorded named values.
+func (r *Result) SetMeasurements(measurements ...Measurement) {
+	r.measurements = measurements
+}
+
+// Artifacts returns the named content produced during Eval().
+func (r *Result) Artifacts() []Artifact {
+	return r.artifacts
+}
+
+// AddArtifact adds named content to the result's artifacts.
+func (r *Result) AddArtifact(name string, content []byte) {
+	r.artifacts = append(r.artifacts, Artifact{Name: name, Content: content})
+}
+
+// Attach adds named content of the supplied MIME content type, e.g. a raw
+// HTTP response body, to the result's artifacts so that it is kept for
+// debugging after the test run.
+func (r *Result) Attach(name string, contentType string, data []byte) {
+	r.artifacts = append(r.artifacts, Artifact{
+		Name:        name,
+		ContentType: contentType,
+		Content:     data,
+	})
+}
+
+// Artifact returns the result's artifact with the supplied name and true, or
+// an empty Artifact and false if there is none.
+func (r *Result) Artifact(name string) (Artifact, bool) {
+	return findArtifact(r.artifacts, name)
+}
+
+// SetArtifacts sets the result's collection of named content.
+func (r *Result) SetArtifacts(artifacts ...Artifact) {
+	r.artifacts = artifacts
+}
+
 // SetFailures sets the result's collection of assertion failures.
 func (r *Result) SetFailures(failures ...error) {
 	r.failures = failures
@@ -104,6 +218,36 @@ func WithData(key string, val any) ResultModifier {
 	}
 }
 
+// WithMetrics modifies the Result with the supplied Metrics
+func WithMetrics(m *Metrics) ResultModifier {
+	return func(r *Result) {
+		r.AddMetrics(m)
+	}
+}
+
+// WithArtifact modifies the Result with the supplied named content
+func WithArtifact(name string, content []byte) ResultModifier {
+	return func(r *Result) {
+		r.AddArtifact(name, content)
+	}
+}
+
+// WithAttachment modifies the Result with the supplied named content of the
+// supplied MIME content type.
+func WithAttachment(name string, contentType string, data []byte) ResultModifier {
+	return func(r *Result) {
+		r.Attach(name, contentType, data)
+	}
+}
+
+// WithMetric modifies the Result with the supplied named value in the
+// supplied unit.
+func WithMetric(name string, value float64, unit string) ResultModifier {
+	return func(r *Result) {
+		r.RecordMetric(name, value, unit)
+	}
+}
+
 // WithStopOnFail sets the stopOnFail value for the test spec result.
 // failures
 func WithStopOnFail(val bool) ResultModifier {
@@ -112,6 +256,14 @@ func WithStopOnFail(val bool) ResultModifier {
 	}
 }
 
+// WithTerminal sets the terminal value for the test spec result, indicating
+// whether any failures should stop further retries of the test spec.
+func WithTerminal(val bool) ResultModifier {
+	return func(r *Result) {
+		r.terminal = val
+	}
+}
+
 // WithFailures modifies the Result the supplied collection of assertion
 // failures
 func WithFailures(failures ...error) ResultModifier {
@@ -128,3 +280,14 @@ func NewResult(mods ...ResultModifier) *Result {
 	}
 	return r
 }
+
+// findArtifact returns the artifact with the supplied name from the supplied
+// artifacts and true, or an empty Artifact and false if there is none.
+func findArtifact(artifacts []Artifact, name string) (Artifact, bool) {
+	for _, a := range artifacts {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Artifact{}, false
+}
@@ -6,6 +6,10 @@ package api
 
 import (
 	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
 )
 
 const (
@@ -41,6 +45,79 @@ type Retry struct {
 	// the retry. When true, the value of Interval, if any, is used as the
 	// initial interval for the backoff algoritm.
 	Exponential bool `yaml:"exponential,omitempty"`
+	// Jitter is the randomization factor, between 0 and 1, applied to each
+	// exponential backoff interval. For example, a Jitter of 0.5 waits
+	// between half and one and a half times the interval. Only applies when
+	// Exponential is true.
+	Jitter *float64 `yaml:"jitter,omitempty"`
+	// Multiplier is the factor, at least 1, that the exponential backoff
+	// interval grows by after each attempt. Only applies when Exponential is
+	// true.
+	Multiplier *float64 `yaml:"multiplier,omitempty"`
+	// MaxInterval caps the exponential backoff interval. Only applies when
+	// Exponential is true.
+	MaxInterval string `yaml:"max-interval,omitempty"`
+	// MaxElapsed is the amount of time after the first attempt after which
+	// no more attempts are made.
+	MaxElapsed string `yaml:"max-elapsed,omitempty"`
+	// SuccessesRequired is the number of consecutive attempts whose
+	// assertions must pass before the test spec is considered successful.
+	// Defaults to 1.
+	SuccessesRequired *int `yaml:"successes-required,omitempty"`
+}
+
+// ValidateAt returns a parse error, annotated with the line/column of the
+// supplied YAML node the Retry was decoded from, if any of the Retry's fields
+// are invalid.
+func (r *Retry) ValidateAt(node *yaml.Node) error {
+	if r.Attempts != nil {
+		attempts := *r.Attempts
+		if attempts < 1 {
+			return parse.InvalidRetryAttemptsAt(node, attempts)
+		}
+	}
+	if r.Interval != "" {
+		_, err := time.ParseDuration(r.Interval)
+		if err != nil {
+			return err
+		}
+	}
+	if r.SuccessesRequired != nil {
+		required := *r.SuccessesRequired
+		if required < 1 {
+			return parse.InvalidRetryAt(
+				node, "successes-required must be at least 1",
+			)
+		}
+		if r.Attempts != nil && *r.Attempts < required {
+			return parse.InvalidRetryAt(
+				node, "attempts must be at least successes-required",
+			)
+		}
+	}
+	if !r.Exponential &&
+		(r.Jitter != nil || r.Multiplier != nil || r.MaxInterval != "") {
+		return parse.InvalidRetryAt(
+			node, "jitter, multiplier and max-interval require exponential",
+		)
+	}
+	if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+		return parse.InvalidRetryAt(node, "jitter must be between 0 and 1")
+	}
+	if r.Multiplier != nil && *r.Multiplier < 1 {
+		return parse.InvalidRetryAt(node, "multiplier must be at least 1")
+	}
+	for _, dur := range []string{r.MaxInterval, r.MaxElapsed} {
+		if dur == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(dur); err != nil || d <= 0 {
+			return parse.InvalidRetryAt(
+				node, "max-interval and max-elapsed must be durations",
+			)
+		}
+	}
+	return nil
 }
 
 // IntervalDuration returns the time duration of the Retry.Interval
@@ -50,3 +127,17 @@ func (r *Retry) IntervalDuration() time.Duration {
 	dur, _ := time.ParseDuration(r.Interval)
 	return dur
 }
+
+// MaxIntervalDuration returns the time duration of the Retry.MaxInterval, or
+// zero if there is none.
+func (r *Retry) MaxIntervalDuration() time.Duration {
+	dur, _ := time.ParseDuration(r.MaxInterval)
+	return dur
+}
+
+// MaxElapsedDuration returns the time duration of the Retry.MaxElapsed, or
+// zero if there is none.
+func (r *Retry) MaxElapsedDuration() time.Duration {
+	dur, _ := time.ParseDuration(r.MaxElapsed)
+	return dur
+}
@@ -11,6 +11,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/gdt-dev/core/expr"
 	"github.com/gdt-dev/core/parse"
 )
 
@@ -18,11 +19,15 @@ var (
 	// BaseSpecFields contains the list of base spec fields for plugin Spec
 	// types to use in ignoring unknown fields.
 	BaseSpecFields = []string{
+		"id",
 		"name",
 		"description",
 		"timeout",
 		"wait",
 		"retry",
+		"plugin",
+		"set",
+		"depends",
 	}
 )
 
@@ -37,6 +42,10 @@ type Spec struct {
 	Defaults *Defaults `yaml:"-"`
 	// Index within the scenario where this Spec is located
 	Index int `yaml:"-"`
+	// ID is an optional identifier for the test unit that, unlike Index and
+	// Name, stays the same when test specs are renamed or reordered. IDs
+	// should be unique within a scenario.
+	ID string `yaml:"id,omitempty"`
 	// Name for the individual test unit
 	Name string `yaml:"name,omitempty"`
 	// Description of the test unit
@@ -47,6 +56,22 @@ type Spec struct {
 	Wait *Wait `yaml:"wait,omitempty"`
 	// Retry contains the retry configuration for the Spec
 	Retry *Retry `yaml:"retry,omitempty"`
+	// PluginName is the name or alias of the plugin that should parse the
+	// Spec. When empty, every registered plugin is tried in priority order.
+	PluginName string `yaml:"plugin,omitempty"`
+	// Set contains state values, keyed by fixture name and then state path,
+	// that are set on fixtures implementing StateSetter after the Spec
+	// passes.
+	Set map[string]map[string]interface{} `yaml:"set,omitempty"`
+	// Depends contains dependencies that must be satisfied for the Spec to
+	// run. Unlike a scenario's dependencies, a Spec whose dependencies are
+	// not satisfied is skipped rather than failed.
+	Depends []*Dependency `yaml:"depends,omitempty"`
+	// AssertExpr contains the expressions, from the `expr` field of the
+	// Spec's `assert` field, that must all be true once the Spec has been
+	// evaluated for the Spec to pass. The scenario parses these expressions
+	// so that plugins never see them.
+	AssertExpr []*expr.Expr `yaml:"-"`
 }
 
 // Title returns the Name of the scenario or the Path's file/base name if there
@@ -97,6 +122,11 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 		key := keyNode.Value
 		valNode := node.Content[i+1]
 		switch key {
+		case "id":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.ID = valNode.Value
 		case "name":
 			if valNode.Kind != yaml.ScalarNode {
 				return parse.ExpectedScalarAt(valNode)
@@ -123,8 +153,7 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 			default:
 				return parse.ExpectedScalarOrMapAt(valNode)
 			}
-			_, err := time.ParseDuration(to.After)
-			if err != nil {
+			if err := to.ValidateAt(valNode); err != nil {
 				return err
 			}
 			s.Timeout = to
@@ -157,19 +186,45 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 			if err := valNode.Decode(&r); err != nil {
 				return parse.ExpectedRetryAt(valNode)
 			}
-			if r.Attempts != nil {
-				attempts := *r.Attempts
-				if attempts < 1 {
-					return parse.InvalidRetryAttemptsAt(valNode, attempts)
-				}
+			if err := r.ValidateAt(valNode); err != nil {
+				return err
 			}
-			if r.Interval != "" {
-				_, err := time.ParseDuration(r.Interval)
-				if err != nil {
+			s.Retry = r
+		case "plugin":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.PluginName = valNode.Value
+		case "set":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			set := map[string]map[string]interface{}{}
+			for j := 0; j < len(valNode.Content); j += 2 {
+				fixNode := valNode.Content[j]
+				stateNode := valNode.Content[j+1]
+				if fixNode.Kind != yaml.ScalarNode {
+					return parse.ExpectedScalarAt(fixNode)
+				}
+				if stateNode.Kind != yaml.MappingNode {
+					return parse.ExpectedMapAt(stateNode)
+				}
+				var state map[string]interface{}
+				if err := stateNode.Decode(&state); err != nil {
 					return err
 				}
+				set[fixNode.Value] = state
 			}
-			s.Retry = r
+			s.Set = set
+		case "depends":
+			if valNode.Kind != yaml.SequenceNode {
+				return parse.ExpectedSequenceAt(valNode)
+			}
+			var deps []*Dependency
+			if err := valNode.Decode(&deps); err != nil {
+				return err
+			}
+			s.Depends = deps
 		}
 	}
 	return nil
@@ -6,6 +6,10 @@ package api
 
 import (
 	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
 )
 
 // Timeout contains information about the duration within which a Spec should
@@ -16,6 +20,35 @@ type Timeout struct {
 	// Specify a duration using Go's time duration string.
 	// See https://pkg.go.dev/time#ParseDuration
 	After string `yaml:"after,omitempty"`
+	// Attempt is the amount of time that each attempt at the test unit,
+	// including retries, should complete within. An attempt that exceeds it
+	// fails and is retried if the retry configuration and After allow.
+	// Specify a duration using Go's time duration string.
+	Attempt string `yaml:"attempt,omitempty"`
+}
+
+// ValidateAt returns an error, annotated with the line/column of the supplied
+// YAML node the Timeout was decoded from, if the Timeout is invalid.
+func (t *Timeout) ValidateAt(node *yaml.Node) error {
+	if t.After != "" || t.Attempt == "" {
+		_, err := time.ParseDuration(t.After)
+		if err != nil {
+			return err
+		}
+	}
+	if t.Attempt == "" {
+		return nil
+	}
+	attempt, err := time.ParseDuration(t.Attempt)
+	if err != nil {
+		return err
+	}
+	if t.After != "" && attempt > t.Duration() {
+		return parse.InvalidTimeoutAt(
+			node, "attempt must not be longer than after",
+		)
+	}
+	return nil
 }
 
 // Duration returns the time duration of the Timeout
@@ -25,3 +58,10 @@ func (t *Timeout) Duration() time.Duration {
 	dur, _ := time.ParseDuration(t.After)
 	return dur
 }
+
+// AttemptDuration returns the time duration of the Timeout's Attempt, or zero
+// if there is none.
+func (t *Timeout) AttemptDuration() time.Duration {
+	dur, _ := time.ParseDuration(t.Attempt)
+	return dur
+}
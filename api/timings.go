@@ -30,6 +30,9 @@ type Timings struct {
 	// GoTestTimeout will be the duration of the timeout specified (or
 	// defaulted) by the Go test tool
 	GoTestTimeout time.Duration
+	// RunTimeout will be the duration remaining before the deadline of a test
+	// run executed without the Go test tool, if any. See run.WithDeadline.
+	RunTimeout time.Duration
 	// TotalWait will be non-zero when there is a wait specified for either the
 	// scenario or a test spec and will contain the aggregate duration of all
 	// waits
@@ -69,3 +72,12 @@ func (t *Timings) AddTimeout(
 		t.MaxTimeoutSpecIndex = specIndex
 	}
 }
+
+// Exceed returns true if the Timings' total wait time or maximum timeout is
+// longer than the supplied duration.
+func (t *Timings) Exceed(d time.Duration) bool {
+	if t.TotalWait > 0 && t.TotalWait.Abs() > d.Abs() {
+		return true
+	}
+	return t.MaxTimeout > 0 && t.MaxTimeout.Abs() > d.Abs()
+}
@@ -0,0 +1,30 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package api
+
+const (
+	// APIVersion is the version of the gdt core plugin API. It is incremented
+	// whenever a change is made to the plugin API that is incompatible with
+	// plugins built against an earlier version.
+	//
+	// Plugins should set PluginInfo.APIVersion to this constant so that gdt
+	// can refuse to register a plugin that was built against an incompatible
+	// version of gdt core.
+	APIVersion = 1
+	// MinAPIVersion is the oldest version of the gdt core plugin API that
+	// this version of gdt core can load plugins for.
+	MinAPIVersion = 1
+)
+
+// APIVersionCompatible returns true if a plugin built against the supplied
+// version of the gdt core plugin API can be used with this version of gdt
+// core. A zero version means the plugin did not declare the API version it
+// was built against and is assumed to be compatible.
+func APIVersionCompatible(version int) bool {
+	if version == 0 {
+		return true
+	}
+	return version >= MinAPIVersion && version <= APIVersion
+}
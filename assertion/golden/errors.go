@@ -0,0 +1,40 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package golden
+
+import (
+	"fmt"
+
+	"github.com/gdt-dev/core/api"
+)
+
+var (
+	// ErrNotEqual returns an ErrFailure when content was not equal to the
+	// content of a golden file.
+	ErrNotEqual = fmt.Errorf(
+		"%w: content not equal to golden file", api.ErrFailure,
+	)
+	// ErrFile returns an ErrFailure when a golden file could not be read or
+	// written.
+	ErrFile = fmt.Errorf(
+		"%w: golden file error", api.ErrFailure,
+	)
+)
+
+// NotEqual returns an ErrFailure when content was not equal to the content of
+// a golden file. The supplied unified diff is included in the error message.
+func NotEqual(path string, name string, diff string) error {
+	return &api.Failure{
+		Class:   ErrNotEqual,
+		Message: fmt.Sprintf("%s differs from %s", name, path),
+		Diff:    diff,
+	}
+}
+
+// FileError returns an ErrFailure when a golden file could not be read or
+// written.
+func FileError(path string, err error) error {
+	return fmt.Errorf("%w: %s: %s", ErrFile, path, err)
+}
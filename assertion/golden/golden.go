@@ -0,0 +1,103 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package golden
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+const (
+	// UpdateEnv is the name of the environment variable that, when set to a
+	// non-empty value, causes golden files to be written with the actual
+	// content instead of being compared with it.
+	UpdateEnv = "GDT_UPDATE_GOLDEN"
+	// Dir is the directory, relative to the test scenario's directory, that
+	// contains golden files.
+	Dir = "testdata/golden"
+)
+
+// Path returns the absolute path to the golden file with the supplied name.
+// Absolute names are returned unchanged. Relative names are resolved against
+// the `testdata/golden` directory in the current working directory, which is
+// the test scenario's directory while a scenario is parsed. When the scenario
+// is itself in a `testdata` directory, the `golden` directory beside it is
+// used instead.
+func Path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	wd, _ := os.Getwd()
+	dir := filepath.Join(wd, filepath.FromSlash(Dir))
+	if filepath.Base(wd) == "testdata" {
+		dir = filepath.Join(wd, "golden")
+	}
+	return filepath.Join(dir, name)
+}
+
+// Updating returns true if golden files should be written with the actual
+// content instead of being compared with it, either because the context was
+// created with `gdtcontext.WithUpdateGolden()` or because the
+// GDT_UPDATE_GOLDEN environment variable is set.
+func Updating(ctx context.Context) bool {
+	return gdtcontext.UpdateGolden(ctx) || os.Getenv(UpdateEnv) != ""
+}
+
+// Compare returns nil if the supplied content is equal to the content of the
+// golden file at path, otherwise an ErrFailure containing a unified diff
+// between the two. The supplied name, e.g. "stdout", describes the content in
+// the diff.
+//
+// When golden files are being updated, the golden file is written with the
+// content instead. If that changed the golden file, its path is recorded as
+// dirty on the context's test unit so that it is reported in the run results.
+func Compare(
+	ctx context.Context,
+	path string,
+	name string,
+	content []byte,
+) error {
+	b, err := os.ReadFile(path)
+	if err != nil && !(errors.Is(err, fs.ErrNotExist) && Updating(ctx)) {
+		return FileError(path, err)
+	}
+	if err == nil && bytes.Equal(b, content) {
+		return nil
+	}
+	if Updating(ctx) {
+		if err := write(path, content); err != nil {
+			return FileError(path, err)
+		}
+		if tu := gdtcontext.TestUnit(ctx); tu != nil {
+			tu.AddDirtyGolden(path)
+		}
+		return nil
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(b)),
+		B:        difflib.SplitLines(string(content)),
+		FromFile: path,
+		ToFile:   name,
+		Context:  3,
+	})
+	return NotEqual(path, name, diff)
+}
+
+// write writes the content to the golden file at path, creating the golden
+// file's directory if necessary.
+func write(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
@@ -0,0 +1,94 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package golden_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/assertion/golden"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/testunit"
+)
+
+func TestPath(t *testing.T) {
+	require := require.New(t)
+
+	wd, err := os.Getwd()
+	require.Nil(err)
+
+	require.Equal(
+		filepath.Join(wd, "testdata", "golden", "pets.golden"),
+		golden.Path("pets.golden"),
+	)
+	require.Equal("/tmp/pets.golden", golden.Path("/tmp/pets.golden"))
+
+	t.Chdir("testdata")
+	require.Equal(
+		filepath.Join(wd, "testdata", "golden", "pets.golden"),
+		golden.Path("pets.golden"),
+	)
+}
+
+func TestCompare(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	path := golden.Path("pets.golden")
+
+	err := golden.Compare(ctx, path, "stdout", []byte("cat\ndog\n"))
+	require.Nil(err)
+
+	err = golden.Compare(ctx, path, "stdout", []byte("cat\nbird\n"))
+	require.ErrorIs(err, golden.ErrNotEqual)
+	require.ErrorContains(err, "+++ stdout\n")
+	require.ErrorContains(err, "-dog\n+bird\n")
+
+	err = golden.Compare(
+		ctx, golden.Path("noexist.golden"), "stdout", []byte("cat\n"),
+	)
+	require.ErrorIs(err, golden.ErrFile)
+}
+
+func TestCompareUpdate(t *testing.T) {
+	require := require.New(t)
+
+	tu := testunit.New(context.TODO())
+	ctx := gdtcontext.New(gdtcontext.WithUpdateGolden())
+	ctx = gdtcontext.SetTestUnit(ctx, tu)
+	require.True(golden.Updating(ctx))
+
+	dir := t.TempDir()
+	same := filepath.Join(dir, "same.golden")
+	require.Nil(os.WriteFile(same, []byte("cat\n"), 0o644))
+	changed := filepath.Join(dir, "changed.golden")
+	require.Nil(os.WriteFile(changed, []byte("cat\n"), 0o644))
+	created := filepath.Join(dir, "golden", "created.golden")
+
+	require.Nil(golden.Compare(ctx, same, "stdout", []byte("cat\n")))
+	require.Nil(golden.Compare(ctx, changed, "stdout", []byte("dog\n")))
+	require.Nil(golden.Compare(ctx, created, "stdout", []byte("bird\n")))
+
+	b, err := os.ReadFile(changed)
+	require.Nil(err)
+	require.Equal("dog\n", string(b))
+	b, err = os.ReadFile(created)
+	require.Nil(err)
+	require.Equal("bird\n", string(b))
+
+	require.Equal([]string{changed, created}, tu.DirtyGoldens())
+}
+
+func TestUpdatingEnv(t *testing.T) {
+	require := require.New(t)
+
+	require.False(golden.Updating(context.TODO()))
+	t.Setenv(golden.UpdateEnv, "1")
+	require.True(golden.Updating(context.TODO()))
+}
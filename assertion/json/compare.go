@@ -0,0 +1,167 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package json
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// comparison operators that may prefix an expected value in Expect.Paths.
+const (
+	opEqual        = "=="
+	opNotEqual     = "!="
+	opGreater      = ">"
+	opGreaterEqual = ">="
+	opLess         = "<"
+	opLessEqual    = "<="
+)
+
+// operators is the set of comparison operators, ordered so that the
+// two-character operators are matched before their one-character prefixes.
+var operators = []string{
+	opGreaterEqual,
+	opLessEqual,
+	opEqual,
+	opNotEqual,
+	opGreater,
+	opLess,
+}
+
+// nullValue is the operand that matches a JSON null.
+const nullValue = "null"
+
+// timeLayouts are the layouts, tried in order, used to coerce an operand and
+// a found string value into a time.Time for ordered comparison.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.DateTime,
+	time.DateOnly,
+}
+
+// splitOperator splits an expected value into a comparison operator and its
+// operand. An operator must be followed by whitespace so that a plain string
+// such as "<html>" is still matched by equality. When the expected value has
+// no operator, the returned operator is empty and the operand is the
+// unmodified expected value.
+func splitOperator(expVal string) (string, string) {
+	for _, op := range operators {
+		rest, found := strings.CutPrefix(expVal, op)
+		if !found || len(rest) == 0 || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		return op, strings.TrimSpace(rest)
+	}
+	return "", expVal
+}
+
+// isOrdering returns true if the operator requires ordered operands.
+func isOrdering(op string) bool {
+	switch op {
+	case opGreater, opGreaterEqual, opLess, opLessEqual:
+		return true
+	}
+	return false
+}
+
+// parseTime attempts to parse the supplied string using each of the supported
+// time layouts.
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareOrdered returns the result of applying the operator to the result of
+// a three-way comparison between a found value and an operand.
+func compareOrdered(op string, c int) bool {
+	switch op {
+	case opEqual:
+		return c == 0
+	case opNotEqual:
+		return c != 0
+	case opGreater:
+		return c > 0
+	case opGreaterEqual:
+		return c >= 0
+	case opLess:
+		return c < 0
+	case opLessEqual:
+		return c <= 0
+	}
+	return false
+}
+
+// compare evaluates the operator against the value found at a JSONPath and
+// the expected operand, coercing the operand into the type of the found
+// value. Numbers that differ by no more than tolerance are equal. The first
+// returned bool indicates whether the comparison held. The second is false if
+// the operand could not be converted into a type comparable with the found
+// value.
+func compare(
+	op string,
+	operand string,
+	got interface{},
+	tolerance float64,
+) (bool, bool) {
+	if operand == nullValue && (op != "" || got == nil) {
+		if isOrdering(op) {
+			return false, false
+		}
+		return (got == nil) == (op != opNotEqual), true
+	}
+	switch got := got.(type) {
+	case nil:
+		if op == "" {
+			return false, false
+		}
+		if isOrdering(op) {
+			return false, false
+		}
+		// A non-null operand is never equal to a JSON null.
+		return op == opNotEqual, true
+	case string:
+		if op == "" {
+			return operand == got, true
+		}
+		if opTime, ok := parseTime(operand); ok {
+			gotTime, ok := parseTime(got)
+			if !ok {
+				return false, false
+			}
+			return compareOrdered(op, gotTime.Compare(opTime)), true
+		}
+		return compareOrdered(op, strings.Compare(got, operand)), true
+	case float64:
+		opFloat, err := strconv.ParseFloat(operand, 64)
+		if err != nil {
+			return false, false
+		}
+		c := cmp.Compare(got, opFloat)
+		if math.Abs(got-opFloat) <= tolerance {
+			c = 0
+		}
+		if op == "" {
+			return c == 0, true
+		}
+		return compareOrdered(op, c), true
+	case bool:
+		if isOrdering(op) {
+			return false, false
+		}
+		opBool, err := strconv.ParseBool(operand)
+		if err != nil {
+			return false, false
+		}
+		return (opBool == got) == (op != opNotEqual), true
+	}
+	return false, false
+}
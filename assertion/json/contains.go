@@ -0,0 +1,247 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
+)
+
+// FromYAML returns the JSON-compatible value of a YAML node, with the same
+// types that decoding the equivalent JSON document produces. Timestamps are
+// kept as strings, because JSON has no timestamp type, object keys are
+// converted to strings and numbers are normalized to float64.
+func FromYAML(node *yaml.Node) (interface{}, error) {
+	doc, err := nodeValue(node)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// nodeValue returns the decoded value of a YAML node with object keys
+// converted to strings.
+func nodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case 0:
+		// An empty YAML document.
+		return nil, nil
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return nodeValue(node.Content[0])
+	case yaml.AliasNode:
+		return nodeValue(node.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+			if keyNode.Kind != yaml.ScalarNode {
+				return nil, parse.ExpectedScalarAt(keyNode)
+			}
+			v, err := nodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[keyNode.Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		s := make([]interface{}, len(node.Content))
+		for x, elem := range node.Content {
+			v, err := nodeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			s[x] = v
+		}
+		return s, nil
+	}
+	if node.ShortTag() == "!!timestamp" {
+		return node.Value, nil
+	}
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// containsDiff returns a list of differences between an expected document and
+// an actual document, where the expected document must be a subset of the
+// actual document. An empty list means the expected document is contained in
+// the actual document.
+//
+// Objects match when every key in the expected object is present in the
+// actual object and its value matches. Arrays match when every element of the
+// expected array matches a distinct element of the actual array, regardless
+// of order. When key is not empty, an expected array element that is an object
+// with the key matches the actual element whose key has the same value. All
+// other values must be equal.
+func containsDiff(
+	path string,
+	exp interface{},
+	got interface{},
+	key string,
+) []string {
+	switch exp := exp.(type) {
+	case map[string]interface{}:
+		gotMap, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{typeDiff(path, exp, got)}
+		}
+		keys := make([]string, 0, len(exp))
+		for k := range exp {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		diffs := []string{}
+		for _, k := range keys {
+			kpath := fmt.Sprintf("%s[%q]", path, k)
+			gotVal, found := gotMap[k]
+			if !found {
+				diffs = append(
+					diffs, fmt.Sprintf("%s: missing, expected %s", kpath, render(exp[k])),
+				)
+				continue
+			}
+			diffs = append(diffs, containsDiff(kpath, exp[k], gotVal, key)...)
+		}
+		return diffs
+	case []interface{}:
+		gotSlice, ok := got.([]interface{})
+		if !ok {
+			return []string{typeDiff(path, exp, got)}
+		}
+		diffs := []string{}
+		used := make([]bool, len(gotSlice))
+		for x, expElem := range exp {
+			if keyVal, keyed := keyValue(expElem, key); keyed {
+				y := indexOfKey(gotSlice, used, key, keyVal)
+				if y < 0 {
+					diffs = append(diffs, fmt.Sprintf(
+						"%s[%d]: no element with %q equal to %s",
+						path, x, key, render(keyVal),
+					))
+					continue
+				}
+				used[y] = true
+				diffs = append(diffs, containsDiff(
+					fmt.Sprintf("%s[%d]", path, y), expElem, gotSlice[y], key,
+				)...)
+				continue
+			}
+			matched := false
+			for y, gotElem := range gotSlice {
+				if used[y] {
+					continue
+				}
+				if len(containsDiff(path, expElem, gotElem, key)) == 0 {
+					used[y] = true
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				diffs = append(diffs, fmt.Sprintf(
+					"%s[%d]: no element matching %s", path, x, render(expElem),
+				))
+			}
+		}
+		return diffs
+	}
+	if typeName(exp) != typeName(got) {
+		return []string{typeDiff(path, exp, got)}
+	}
+	if !reflect.DeepEqual(exp, got) {
+		return []string{fmt.Sprintf(
+			"%s: expected %s but got %s", path, render(exp), render(got),
+		)}
+	}
+	return nil
+}
+
+// keyValue returns the value of the key in the supplied array element and
+// true if the element is an object containing the key.
+func keyValue(elem interface{}, key string) (interface{}, bool) {
+	if key == "" {
+		return nil, false
+	}
+	m, ok := elem.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, found := m[key]
+	return v, found
+}
+
+// indexOfKey returns the index of the first unused element of the array that
+// is an object whose key has the supplied value, or -1 if there is none.
+func indexOfKey(
+	elems []interface{},
+	used []bool,
+	key string,
+	keyVal interface{},
+) int {
+	for y, elem := range elems {
+		if used[y] {
+			continue
+		}
+		if v, keyed := keyValue(elem, key); keyed && reflect.DeepEqual(v, keyVal) {
+			return y
+		}
+	}
+	return -1
+}
+
+// typeDiff returns a difference describing a mismatched JSON type.
+func typeDiff(path string, exp interface{}, got interface{}) string {
+	return fmt.Sprintf(
+		"%s: expected %s but got %s %s",
+		path, typeName(exp), typeName(got), render(got),
+	)
+}
+
+// typeName returns the JSON type name of a decoded JSON value.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// render returns the compact JSON representation of a decoded JSON value.
+func render(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
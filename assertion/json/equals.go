@@ -0,0 +1,215 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package json
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// equalsDocument returns the JSON-compatible value of a YAML node holding an
+// equals document and, if the node is a reference to a file, the absolute
+// path of the file. A scalar string starting with "file://" is a reference to
+// a JSON or YAML file, relative to the current working directory, containing
+// the document.
+func equalsDocument(node *yaml.Node) (interface{}, string, error) {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" ||
+		!strings.HasPrefix(node.Value, "file://") {
+		doc, err := FromYAML(node)
+		return doc, "", err
+	}
+	path := strings.TrimPrefix(node.Value, "file://")
+	path, _ = filepath.Abs(path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", JSONEqualsFileNotFound(path, node)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, "", JSONUnmarshalError(err, node)
+	}
+	v, err := FromYAML(&doc)
+	return v, path, err
+}
+
+// equalsDiff returns a unified diff between the expected and actual
+// documents after removing the elements at any of the ignore JSONPath
+// expressions from both. When ignoreOrder is true, the order of elements in
+// arrays is not significant. When key is not empty, the order of elements in
+// arrays of objects that all contain the key is not significant and elements
+// are compared with the element having the same key value. An empty string
+// means the documents are equal.
+func equalsDiff(
+	exp interface{},
+	got interface{},
+	ignore []string,
+	ignoreOrder bool,
+	key string,
+) string {
+	// Work on a copy of the expected document because removing ignored
+	// elements and sorting arrays modify the document in place and the
+	// expected document is reused when a spec is retried.
+	exp = copyDocument(exp)
+	for _, path := range ignore {
+		// JSONPath expressions are validated during parse.
+		p := jsonpath.MustParse(path)
+		exp = removeAt(exp, p)
+		got = removeAt(got, p)
+	}
+	if ignoreOrder || key != "" {
+		exp = sortArrays(exp, key, ignoreOrder)
+		got = sortArrays(got, key, ignoreOrder)
+	}
+	expStr := renderIndent(exp)
+	gotStr := renderIndent(got)
+	if expStr == gotStr {
+		return ""
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expStr),
+		B:        difflib.SplitLines(gotStr),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  3,
+	})
+	return diff
+}
+
+// copyDocument returns a deep copy of a decoded JSON value.
+func copyDocument(doc interface{}) interface{} {
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(doc))
+		for k, v := range doc {
+			m[k] = copyDocument(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(doc))
+		for x, v := range doc {
+			s[x] = copyDocument(v)
+		}
+		return s
+	}
+	return doc
+}
+
+// removeAt returns the document with all elements selected by the JSONPath
+// expression removed.
+func removeAt(doc interface{}, p *jsonpath.Path) interface{} {
+	located := p.SelectLocated(doc)
+	paths := make([]spec.NormalizedPath, len(located))
+	for x, n := range located {
+		paths[x] = n.Path
+	}
+	// Remove the deepest and highest-indexed elements first so that removing
+	// an array element does not shift the location of another selected
+	// element.
+	slices.SortFunc(paths, func(a, b spec.NormalizedPath) int {
+		return b.Compare(a)
+	})
+	for _, np := range paths {
+		if len(np) == 0 {
+			// The root itself was selected.
+			return nil
+		}
+		doc = removeNormalized(doc, np)
+	}
+	return doc
+}
+
+// removeNormalized returns the document with the element at the normalized
+// path removed.
+func removeNormalized(doc interface{}, np spec.NormalizedPath) interface{} {
+	switch sel := np[0].(type) {
+	case spec.Name:
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return doc
+		}
+		if len(np) == 1 {
+			delete(m, string(sel))
+			return m
+		}
+		if v, found := m[string(sel)]; found {
+			m[string(sel)] = removeNormalized(v, np[1:])
+		}
+		return m
+	case spec.Index:
+		s, ok := doc.([]interface{})
+		if !ok || int(sel) < 0 || int(sel) >= len(s) {
+			return doc
+		}
+		if len(np) == 1 {
+			return slices.Delete(s, int(sel), int(sel)+1)
+		}
+		s[sel] = removeNormalized(s[sel], np[1:])
+		return s
+	}
+	return doc
+}
+
+// sortArrays returns the document with the elements of arrays sorted. Arrays
+// of objects that all contain the key are sorted by the value of the key.
+// When all is true, other arrays are sorted by the JSON representation of
+// their elements.
+func sortArrays(doc interface{}, key string, all bool) interface{} {
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		for k, v := range doc {
+			doc[k] = sortArrays(v, key, all)
+		}
+		return doc
+	case []interface{}:
+		for x, v := range doc {
+			doc[x] = sortArrays(v, key, all)
+		}
+		if allKeyed(doc, key) {
+			slices.SortStableFunc(doc, func(a, b interface{}) int {
+				av, _ := keyValue(a, key)
+				bv, _ := keyValue(b, key)
+				return strings.Compare(render(av), render(bv))
+			})
+		} else if all {
+			slices.SortStableFunc(doc, func(a, b interface{}) int {
+				return strings.Compare(render(a), render(b))
+			})
+		}
+		return doc
+	}
+	return doc
+}
+
+// allKeyed returns true if the array is not empty and every element is an
+// object containing the key.
+func allKeyed(elems []interface{}, key string) bool {
+	if key == "" || len(elems) == 0 {
+		return false
+	}
+	for _, elem := range elems {
+		if _, keyed := keyValue(elem, key); !keyed {
+			return false
+		}
+	}
+	return true
+}
+
+// renderIndent returns the indented JSON representation of a decoded JSON
+// value, terminated with a newline. Object keys are sorted.
+func renderIndent(v interface{}) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return render(v) + "\n"
+	}
+	return string(b) + "\n"
+}
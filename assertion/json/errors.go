@@ -6,6 +6,7 @@ package json
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/gdt-dev/core/api"
 )
@@ -16,6 +17,11 @@ var (
 	ErrJSONPathNotFound = fmt.Errorf(
 		"%w: failed to find element at JSONPath", api.ErrFailure,
 	)
+	// ErrJSONPathFound returns an ErrFailure when a JSONPath expression
+	// evaluated to a found element that was expected to be absent.
+	ErrJSONPathFound = fmt.Errorf(
+		"%w: found element at JSONPath", api.ErrFailure,
+	)
 	// ErrJSONPathConversionError returns an ErrFailure when a JSONPath
 	// expression evaluated to a found element but could not be converted to a
 	// string.
@@ -28,6 +34,22 @@ var (
 	ErrJSONPathNotEqual = fmt.Errorf(
 		"%w: JSONPath values not equal", api.ErrFailure,
 	)
+	// ErrJSONPathComparisonFailed returns an ErrFailure when a JSONPath
+	// expression evaluated to a found element but the value did not satisfy
+	// an expected comparison.
+	ErrJSONPathComparisonFailed = fmt.Errorf(
+		"%w: JSONPath value did not satisfy comparison", api.ErrFailure,
+	)
+	// ErrJSONNotContains returns an ErrFailure when JSON content did not
+	// contain an expected JSON document.
+	ErrJSONNotContains = fmt.Errorf(
+		"%w: JSON content did not contain expected document", api.ErrFailure,
+	)
+	// ErrJSONNotEqual returns an ErrFailure when JSON content was not equal to
+	// an expected JSON document.
+	ErrJSONNotEqual = fmt.Errorf(
+		"%w: JSON content not equal to expected document", api.ErrFailure,
+	)
 	// ErrJSONSchemaValidateError returns an ErrFailure when a JSONSchema could
 	// not be parsed.
 	ErrJSONSchemaValidateError = fmt.Errorf(
@@ -59,6 +81,15 @@ func JSONPathNotFound(path string, err error) error {
 	return fmt.Errorf("%w: %s: %s", ErrJSONPathNotFound, path, err)
 }
 
+// JSONPathFound returns an ErrFailure when a JSONPath expression evaluated to
+// a found element that was expected to be absent.
+func JSONPathFound(path string, got interface{}) error {
+	return fmt.Errorf(
+		"%w: expected no element at %s but got %s",
+		ErrJSONPathFound, path, render(got),
+	)
+}
+
 // JSONPathConversionError returns an ErrFailure when a JSONPath expression
 // evaluated to a found element but the expected and found value types were
 // incomparable.
@@ -72,12 +103,54 @@ func JSONPathConversionError(path string, exp interface{}, got interface{}) erro
 // JSONPathValueNotEqual returns an ErrFailure when a JSONPath expression
 // evaluated to a found element but the value did not match an expected string.
 func JSONPathNotEqual(path string, exp interface{}, got interface{}) error {
+	return &api.Failure{
+		Class:    ErrJSONPathNotEqual,
+		Message:  fmt.Sprintf("expected %v but got %v at %s", exp, got, path),
+		Expected: exp,
+		Actual:   got,
+		Path:     path,
+	}
+}
+
+// JSONPathComparisonFailed returns an ErrFailure when a JSONPath expression
+// evaluated to a found element but the value did not satisfy an expected
+// comparison.
+func JSONPathComparisonFailed(
+	path string,
+	op string,
+	exp interface{},
+	got interface{},
+) error {
+	return &api.Failure{
+		Class: ErrJSONPathComparisonFailed,
+		Message: fmt.Sprintf(
+			"expected value %s %v but got %v at %s", op, exp, got, path,
+		),
+		Expected: exp,
+		Actual:   got,
+		Path:     path,
+	}
+}
+
+// JSONNotContains returns an ErrFailure when JSON content did not contain an
+// expected JSON document. The supplied differences are included, one per
+// line, in the error message.
+func JSONNotContains(diffs []string) error {
 	return fmt.Errorf(
-		"%w: expected %v but got %v at %s",
-		ErrJSONPathNotEqual, exp, got, path,
+		"%w:\n- %s", ErrJSONNotContains, strings.Join(diffs, "\n- "),
 	)
 }
 
+// JSONNotEqual returns an ErrFailure when JSON content was not equal to an
+// expected JSON document. The supplied unified diff is included in the error
+// message.
+func JSONNotEqual(diff string) error {
+	return &api.Failure{
+		Class: ErrJSONNotEqual,
+		Diff:  diff,
+	}
+}
+
 // JSONSchemaValidateError returns an ErrFailure when a JSONSchema could not be
 // parsed.
 func JSONSchemaValidateError(path string, err error) error {
@@ -6,13 +6,30 @@ package json
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/google/uuid"
 	gjs "github.com/xeipuuv/gojsonschema"
 )
 
+// FormatChecker determines whether a value found at a JSONPath expression is
+// in a particular format.
+type FormatChecker interface {
+	// IsFormat returns true if the supplied value is in the format.
+	IsFormat(input interface{}) bool
+}
+
+// FormatCheckerFunc is a function that implements FormatChecker.
+type FormatCheckerFunc func(input interface{}) bool
+
+// IsFormat returns true if the supplied value is in the format.
+func (f FormatCheckerFunc) IsFormat(input interface{}) bool {
+	return f(input)
+}
+
 var (
-	validators = map[string]gjs.FormatChecker{
+	validatorsLock sync.RWMutex
+	validators     = map[string]FormatChecker{
 		"date":                  gjs.DateFormatChecker{},
 		"time":                  gjs.TimeFormatChecker{},
 		"date-time":             gjs.DateTimeFormatChecker{},
@@ -34,11 +51,35 @@ var (
 	}
 )
 
+// RegisterFormat registers a FormatChecker for the named format, making the
+// format available to `path-formats` and to the "format" keyword in
+// JSONSchemas. Registering a format with the same name as an
+// already-registered format, including a built-in format, replaces it.
+//
+// RegisterFormat is typically called from a plugin's or test package's init
+// function.
+func RegisterFormat(name string, checker FormatChecker) {
+	validatorsLock.Lock()
+	defer validatorsLock.Unlock()
+	validators[name] = checker
+	gjs.FormatCheckers.Add(name, checker)
+}
+
+// RemoveFormat delists the named format. Only really useful for testing.
+func RemoveFormat(name string) {
+	validatorsLock.Lock()
+	defer validatorsLock.Unlock()
+	delete(validators, name)
+	gjs.FormatCheckers.Remove(name)
+}
+
 // isFormatted takes a format string and a string value, determines the
 // validator function for that type of format string and returns whether the
 // value string is formatted correctly.
 func isFormatted(format string, input interface{}) (bool, error) {
+	validatorsLock.RLock()
 	c, ok := validators[format]
+	validatorsLock.RUnlock()
 	if !ok {
 		return false, fmt.Errorf("unknown format %s", format)
 	}
@@ -8,13 +8,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/theory/jsonpath"
 	gjs "github.com/xeipuuv/gojsonschema"
 
 	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
 )
 
 // Expect represents one or more assertions about JSON data responses
@@ -22,14 +24,79 @@ type Expect struct {
 	// Length of the expected JSON string.
 	Len *int `yaml:"len,omitempty"`
 	// Paths is a map, keyed by JSONPath expression, of expected values to find
-	// at that expression.
+	// at that expression. An expected value may be prefixed with one of the
+	// comparison operators `==`, `!=`, `>`, `>=`, `<` or `<=` followed by a
+	// space, in which case the value found at the expression is compared to
+	// the remainder of the expected value. An expected value of `null`
+	// matches a JSON null, while an expression that selects no element
+	// always fails; use PathsAbsent to assert an element is missing.
 	Paths map[string]string `yaml:"paths,omitempty"`
+	// Tolerance is the largest difference between a number found at a Paths
+	// expression and an expected number for which the two are considered
+	// equal. Defaults to zero, meaning numbers must be exactly equal.
+	Tolerance float64 `yaml:"tolerance,omitempty"`
+	// PathsAbsent is a list of JSONPath expressions that must not select any
+	// element. An element with a null value is present.
+	PathsAbsent []string `yaml:"paths-absent,omitempty"`
 	// PathFormats is a map, keyed by JSONPath expression, of expected formats
 	// that values found at the expression should have.
 	PathFormats map[string]string `yaml:"path-formats,omitempty"`
+	// Contains is a JSON document that must be a subset of the JSON content.
+	// Objects in the content may have keys not present in Contains and arrays
+	// in the content may have additional elements in any order.
+	Contains interface{} `yaml:"contains,omitempty"`
+	// Equals is a JSON document that must be equal to the JSON content. In
+	// YAML, Equals may be a "file://" reference to a JSON or YAML file
+	// containing the document.
+	Equals interface{} `yaml:"equals,omitempty"`
+	// EqualsIgnore is a list of JSONPath expressions for elements that are
+	// removed from both the Equals document and the JSON content before they
+	// are compared.
+	EqualsIgnore []string `yaml:"equals-ignore,omitempty"`
+	// EqualsIgnoreOrder indicates that the order of elements in arrays is not
+	// significant when comparing the Equals document to the JSON content.
+	// The order of keys in objects is never significant.
+	EqualsIgnoreOrder bool `yaml:"equals-ignore-order,omitempty"`
+	// ArrayKey is the name of a field, e.g. "id", identifying the objects in
+	// arrays. When comparing with Contains or Equals, an array element that
+	// is an object containing the field is compared with the actual element
+	// having the same value for the field, regardless of order.
+	ArrayKey string `yaml:"array-key,omitempty"`
 	// Schema is a file path to the JSONSchema that the JSON should validate
-	// against.
+	// against. In YAML, Schema may also be an http(s) URL, in which case the
+	// JSONSchema is fetched during parse and cached so that later runs do not
+	// need network access.
 	Schema string `yaml:"schema,omitempty"`
+	// InlineSchema is a JSONSchema document that the JSON should validate
+	// against. In YAML, InlineSchema is set when `schema` is a mapping
+	// instead of a file path or URL.
+	InlineSchema interface{} `yaml:"-"`
+	// SchemaTimeout is the amount of time to wait for a remote JSONSchema to
+	// be fetched. Defaults to DefaultSchemaTimeout.
+	SchemaTimeout time.Duration `yaml:"schema-timeout,omitempty"`
+	// SchemaSHA256 is the optional hex-encoded SHA-256 checksum the content
+	// of the JSONSchema must match.
+	SchemaSHA256 string `yaml:"schema-sha256,omitempty"`
+	// equalsFile is the path of the file the Equals document was read from,
+	// if Equals was a "file://" reference.
+	equalsFile string
+}
+
+// Files returns the paths of the files that the Expect references: the
+// JSONSchema file, including a cached remote JSONSchema, and the file the
+// Equals document was read from.
+func (e *Expect) Files() []string {
+	files := []string{}
+	if path, ok := strings.CutPrefix(e.Schema, "file://"); ok {
+		if runtime.GOOS == "windows" {
+			path = strings.TrimPrefix(path, "/")
+		}
+		files = append(files, path)
+	}
+	if e.equalsFile != "" {
+		files = append(files, e.equalsFile)
+	}
+	return files
 }
 
 // New returns a `api.Assertions` that asserts various conditions about
@@ -75,12 +142,21 @@ func (a *assertions) OK(ctx context.Context) bool {
 	if !a.lenOK() {
 		return false
 	}
-	if !a.pathsOK() {
+	if !a.pathsOK(ctx) {
+		return false
+	}
+	if !a.pathsAbsentOK() {
 		return false
 	}
 	if !a.pathFormatsOK() {
 		return false
 	}
+	if !a.containsOK() {
+		return false
+	}
+	if !a.equalsOK() {
+		return false
+	}
 	if !a.schemaOK() {
 		return false
 	}
@@ -105,8 +181,8 @@ func (a *assertions) lenOK() bool {
 }
 
 // pathsOK returns true if the content matches the Paths conditions, false
-// otherwise
-func (a *assertions) pathsOK() bool {
+// otherwise. Expected values may refer to variables in the prior run data.
+func (a *assertions) pathsOK(ctx context.Context) bool {
 	if a == nil || a.exp == nil {
 		return true
 	}
@@ -119,6 +195,7 @@ func (a *assertions) pathsOK() bool {
 		return false
 	}
 	for path, expVal := range a.exp.Paths {
+		expVal = gdtcontext.ReplaceVariables(ctx, expVal)
 		p, err := jsonpath.Parse(path)
 		if err != nil {
 			// Not terminal because during parse we validate the JSONPath
@@ -132,47 +209,51 @@ func (a *assertions) pathsOK() bool {
 			return false
 		}
 		got := nodes[0]
-		switch got := got.(type) {
-		case string:
-			if expVal != got {
-				a.Fail(JSONPathNotEqual(path, expVal, got))
-				return false
-			}
-		case int, uint, int64, uint64:
-			expValInt, err := strconv.Atoi(expVal)
-			if err != nil {
-				a.Fail(JSONPathConversionError(path, expVal, got))
-				return false
-			}
-			if expValInt != got {
-				a.Fail(JSONPathNotEqual(path, expVal, got))
-				return false
-			}
-		case float32, float64:
-			expValFloat, err := strconv.ParseFloat(expVal, 64)
-			if err != nil {
-				a.Fail(JSONPathConversionError(path, expVal, got))
-				return false
-			}
-			if expValFloat != got {
-				a.Fail(JSONPathNotEqual(path, expVal, got))
-				return false
-			}
-		case bool:
-			expValBool, err := strconv.ParseBool(expVal)
-			if err != nil {
-				a.Fail(JSONPathConversionError(path, expVal, got))
-				return false
-			}
-			if expValBool != got {
-				a.Fail(JSONPathNotEqual(path, expVal, got))
-				return false
-			}
-		default:
+		op, operand := splitOperator(expVal)
+		ok, comparable := compare(op, operand, got, a.exp.Tolerance)
+		if !comparable {
 			a.Fail(JSONPathConversionError(path, expVal, got))
 			return false
 		}
+		if !ok {
+			if op == "" || op == opEqual {
+				a.Fail(JSONPathNotEqual(path, operand, got))
+			} else {
+				a.Fail(JSONPathComparisonFailed(path, op, operand, got))
+			}
+			return false
+		}
+	}
+	return true
+}
 
+// pathsAbsentOK returns true if none of the PathsAbsent expressions select an
+// element in the content, false otherwise
+func (a *assertions) pathsAbsentOK() bool {
+	if a == nil || a.exp == nil {
+		return true
+	}
+	if len(a.exp.PathsAbsent) == 0 {
+		return true
+	}
+	v := interface{}(nil)
+	if err := json.Unmarshal(a.content, &v); err != nil {
+		a.Fail(JSONUnmarshalError(err, nil))
+		return false
+	}
+	for _, path := range a.exp.PathsAbsent {
+		p, err := jsonpath.Parse(path)
+		if err != nil {
+			// Not terminal because during parse we validate the JSONPath
+			// expression is valid.
+			a.Fail(JSONPathNotFound(path, err))
+			return false
+		}
+		nodes := p.Select(v)
+		if len(nodes) > 0 {
+			a.Fail(JSONPathFound(path, nodes[0]))
+			return false
+		}
 	}
 	return true
 }
@@ -218,18 +299,69 @@ func (a *assertions) pathFormatsOK() bool {
 	return true
 }
 
+// containsOK returns true if the content contains the Contains document,
+// false otherwise
+func (a *assertions) containsOK() bool {
+	if a == nil || a.exp == nil {
+		return true
+	}
+	if a.exp.Contains == nil {
+		return true
+	}
+	v := interface{}(nil)
+	if err := json.Unmarshal(a.content, &v); err != nil {
+		a.Fail(JSONUnmarshalError(err, nil))
+		return false
+	}
+	diffs := containsDiff("$", a.exp.Contains, v, a.exp.ArrayKey)
+	if len(diffs) > 0 {
+		a.Fail(JSONNotContains(diffs))
+		return false
+	}
+	return true
+}
+
+// equalsOK returns true if the content is equal to the Equals document, false
+// otherwise
+func (a *assertions) equalsOK() bool {
+	if a == nil || a.exp == nil {
+		return true
+	}
+	if a.exp.Equals == nil {
+		return true
+	}
+	v := interface{}(nil)
+	if err := json.Unmarshal(a.content, &v); err != nil {
+		a.Fail(JSONUnmarshalError(err, nil))
+		return false
+	}
+	diff := equalsDiff(
+		a.exp.Equals, v, a.exp.EqualsIgnore, a.exp.EqualsIgnoreOrder,
+		a.exp.ArrayKey,
+	)
+	if diff != "" {
+		a.Fail(JSONNotEqual(diff))
+		return false
+	}
+	return true
+}
+
 // schemaOK returns true if the content matches the Schema condition, false
 // otherwise
 func (a *assertions) schemaOK() bool {
 	if a == nil || a.exp == nil {
 		return true
 	}
-	if a.exp.Schema == "" {
+	if a.exp.Schema == "" && a.exp.InlineSchema == nil {
 		return true
 	}
 
 	schemaPath := a.exp.Schema
 	schemaLoader := gjs.NewReferenceLoader(schemaPath)
+	if a.exp.InlineSchema != nil {
+		schemaPath = "inline schema"
+		schemaLoader = gjs.NewGoLoader(a.exp.InlineSchema)
+	}
 	docLoader := gjs.NewStringLoader(string(a.content))
 
 	res, err := gjs.Validate(schemaLoader, docLoader)
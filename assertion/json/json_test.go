@@ -6,13 +6,21 @@ package json_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gdt-dev/core/api"
 	gdtjson "github.com/gdt-dev/core/assertion/json"
 	"github.com/gdt-dev/core/parse"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
@@ -22,9 +30,9 @@ func TestUnsupportedJSONSchemaReference(t *testing.T) {
 
 	var exp gdtjson.Expect
 
-	// http lookups are not allowed...
+	// only file, http and https schemes are supported...
 	content := []byte(`
-schema: http://example.com/schema
+schema: ftp://example.com/schema
 `)
 	err := yaml.Unmarshal(content, &exp)
 	require.NotNil(err)
@@ -282,3 +290,848 @@ func TestJSONPathKeyWithDot(t *testing.T) {
 	require.Len(failures, 1)
 	require.ErrorIs(failures[0], gdtjson.ErrJSONPathNotEqual)
 }
+
+func TestJSONPathComparison(t *testing.T) {
+	ctx := context.TODO()
+	c := content()
+
+	tests := []struct {
+		name string
+		path string
+		exp  string
+		ok   bool
+	}{
+		{"number equal", "$[0].pages", "== 127", true},
+		{"number not equal", "$[0].pages", "!= 127", false},
+		{"number greater", "$[0].pages", "> 100", true},
+		{"number greater equal", "$[0].pages", ">= 127", true},
+		{"number less", "$[0].pages", "< 127", false},
+		{"number less equal", "$[0].pages", "<= 126.5", false},
+		{"date before", "$[0].published_on", "< 2025-01-01", true},
+		{"date after", "$[0].published_on", "> 1952-10-01", false},
+		{"date-time after", "$[0].published_on", ">= 1952-09-30T23:00:00Z", true},
+		{"string ordering", "$[0].title", "> Old", true},
+		{"string not equal", "$[0].title", "!= New Man", true},
+		{"null equal", "$[0].subtitle", "== null", true},
+		{"null not equal", "$[0].title", "!= null", true},
+		{"null missing", "$[0].title", "== null", false},
+		{"null vs value", "$[0].subtitle", "!= 42", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			exp := gdtjson.Expect{
+				Paths: map[string]string{
+					tc.path: tc.exp,
+				},
+			}
+			a := gdtjson.New(&exp, c)
+			require.Equal(tc.ok, a.OK(ctx))
+			if tc.ok {
+				require.Empty(a.Failures())
+			} else {
+				require.Len(a.Failures(), 1)
+			}
+		})
+	}
+}
+
+func TestJSONPathComparisonFailed(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	exp := gdtjson.Expect{
+		Paths: map[string]string{
+			"$[0].pages": ">= 200",
+		},
+	}
+
+	a := gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONPathComparisonFailed)
+	require.ErrorContains(
+		failures[0], "expected value >= 200 but got 127 at $[0].pages",
+	)
+
+	// The == operator reports the same failure as an implicit equality.
+	exp = gdtjson.Expect{
+		Paths: map[string]string{
+			"$[0].pages": "== 200",
+		},
+	}
+
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures = a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONPathNotEqual)
+}
+
+func TestJSONPathComparisonIncomparable(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	for path, expVal := range map[string]string{
+		"$[0].pages":    "> ten",
+		"$[0].subtitle": "> 1",
+		"$[0].title":    "< 2025-01-01",
+		"$[0].author":   "!= null-ish",
+	} {
+		exp := gdtjson.Expect{
+			Paths: map[string]string{
+				path: expVal,
+			},
+		}
+		a := gdtjson.New(&exp, c)
+		require.False(a.OK(ctx))
+		failures := a.Failures()
+		require.Len(failures, 1)
+		require.ErrorIs(failures[0], gdtjson.ErrJSONPathConversionError)
+	}
+}
+
+func TestJSONPathInvalidComparison(t *testing.T) {
+	require := require.New(t)
+
+	var exp gdtjson.Expect
+
+	content := []byte(`
+paths:
+  $[0].subtitle: "> null"
+`)
+	err := yaml.Unmarshal(content, &exp)
+	require.NotNil(err)
+	var perr *parse.Error
+	require.ErrorAs(err, &perr)
+	require.Equal(gdtjson.CodeJSONPathInvalidComparison, perr.Code)
+
+	content = []byte(`
+paths:
+  $[0].subtitle: "!= null"
+  $[0].title: "<html>"
+`)
+	err = yaml.Unmarshal(content, &exp)
+	require.Nil(err)
+}
+
+func TestContains(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+contains:
+  - title: Old Man and the Sea
+    published_on: 1952-10-01
+    pages: 127
+    subtitle: null
+    publisher:
+      address:
+        city: New York City
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	// Inline JSON is valid YAML.
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+contains: [{"author": {"name": "Ernest Hemingway"}}]
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+}
+
+func TestContainsNotMatched(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+contains:
+  - pages: 42
+    isbn: 0-684-80122-1
+    author: Ernest Hemingway
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONNotContains)
+	require.ErrorContains(failures[0], `$[0]: no element matching`)
+
+	c = []byte(`{"pages": 127, "author": {"name": "Ernest Hemingway"}}`)
+	exp = gdtjson.Expect{
+		Contains: map[string]interface{}{
+			"pages":  float64(42),
+			"isbn":   "0-684-80122-1",
+			"author": "Ernest Hemingway",
+		},
+	}
+
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures = a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONNotContains)
+	msg := failures[0].Error()
+	require.Contains(
+		msg, `$["author"]: expected string but got object {"name":"Ernest Hemingway"}`,
+	)
+	require.Contains(msg, `$["isbn"]: missing, expected "0-684-80122-1"`)
+	require.Contains(msg, `$["pages"]: expected 42 but got 127`)
+}
+
+func TestContainsInvalid(t *testing.T) {
+	require := require.New(t)
+
+	var exp gdtjson.Expect
+
+	err := yaml.Unmarshal([]byte(`
+contains:
+  1: one
+  [2]: two
+`), &exp)
+	require.NotNil(err)
+	var perr *parse.Error
+	require.ErrorAs(err, &perr)
+	require.Equal(parse.CodeExpectedScalar, perr.Code)
+}
+
+func TestEquals(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := []byte(`{
+  "id": "12ac1b94-5667-461e-80cb-ba8619cae61a",
+  "tags": ["fiction", "classic"],
+  "author": {"id": "1", "name": "Ernest Hemingway"}
+}`)
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+equals:
+  author:
+    name: Ernest Hemingway
+    id: "1"
+  tags: [fiction, classic]
+  id: 12ac1b94-5667-461e-80cb-ba8619cae61a
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	// Array order is significant unless equals-ignore-order is set.
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+equals:
+  author: {"id": "1", "name": "Ernest Hemingway"}
+  tags: [classic, fiction]
+equals-ignore:
+  - $.id
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONNotEqual)
+
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+equals: {"author": {"id": "1", "name": "Ernest Hemingway"}, "tags": ["classic", "fiction"]}
+equals-ignore:
+  - $.id
+equals-ignore-order: true
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+	// The expected document is unchanged so that it may be reused.
+	require.Equal(
+		[]interface{}{"classic", "fiction"},
+		exp.Equals.(map[string]interface{})["tags"],
+	)
+}
+
+func TestEqualsFile(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+equals: file://testdata/books.json
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+equals: file://testdata/author.yaml
+`), &exp)
+	require.Nil(err)
+
+	c = []byte(`{"id": "1", "name": "Ernest Hemingway"}`)
+	a = gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+equals: file://testdata/noexist.json
+`), &exp)
+	require.NotNil(err)
+	var perr *parse.Error
+	require.ErrorAs(err, &perr)
+	require.Equal(gdtjson.CodeJSONEqualsFileNotFound, perr.Code)
+}
+
+func TestEqualsDiff(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := []byte(`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`)
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+equals:
+  - name: a
+    id: 1
+  - name: c
+    id: 2
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONNotEqual)
+	msg := failures[0].Error()
+	require.Contains(msg, "--- expected\n+++ actual\n")
+	require.Contains(msg, "-    \"name\": \"c\"\n+    \"name\": \"b\"\n")
+
+	// Ignored elements are removed from both documents, including array
+	// elements selected by a filter.
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+equals:
+  - name: a
+    id: 1
+equals-ignore:
+  - $[?@.id > 1]
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+}
+
+func TestEqualsIgnoreInvalid(t *testing.T) {
+	require := require.New(t)
+
+	var exp gdtjson.Expect
+
+	err := yaml.Unmarshal([]byte(`
+equals: {}
+equals-ignore: $.id
+`), &exp)
+	require.NotNil(err)
+	require.Error(err, &parse.Error{})
+
+	err = yaml.Unmarshal([]byte(`
+equals: {}
+equals-ignore:
+  - id
+`), &exp)
+	require.NotNil(err)
+	var perr *parse.Error
+	require.ErrorAs(err, &perr)
+	require.Equal(gdtjson.CodeJSONPathInvalidNoRoot, perr.Code)
+}
+
+func TestJSONPathNull(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	for _, expVal := range []string{"null", "== null"} {
+		exp := gdtjson.Expect{
+			Paths: map[string]string{
+				"$[0].subtitle": expVal,
+			},
+		}
+		a := gdtjson.New(&exp, c)
+		require.True(a.OK(ctx))
+		require.Empty(a.Failures())
+
+		// A missing element is not null.
+		exp = gdtjson.Expect{
+			Paths: map[string]string{
+				"$[0].noexist": expVal,
+			},
+		}
+		a = gdtjson.New(&exp, c)
+		require.False(a.OK(ctx))
+		failures := a.Failures()
+		require.Len(failures, 1)
+		require.ErrorIs(failures[0], gdtjson.ErrJSONPathNotFound)
+	}
+}
+
+func TestPathsAbsent(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+paths-absent:
+  - $[0].noexist
+  - $[1]
+  - $[?@.pages > 200]
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	// A null element is present.
+	exp = gdtjson.Expect{
+		PathsAbsent: []string{"$[0].subtitle"},
+	}
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONPathFound)
+	require.ErrorContains(
+		failures[0], "expected no element at $[0].subtitle but got null",
+	)
+
+	err = yaml.Unmarshal([]byte(`
+paths-absent: $[0].noexist
+`), &exp)
+	require.NotNil(err)
+	require.Error(err, &parse.Error{})
+
+	err = yaml.Unmarshal([]byte(`
+paths-absent:
+  - noroot
+`), &exp)
+	require.NotNil(err)
+	var perr *parse.Error
+	require.ErrorAs(err, &perr)
+	require.Equal(gdtjson.CodeJSONPathInvalidNoRoot, perr.Code)
+}
+
+func TestJSONPathTolerance(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := []byte(`{"total": 0.30000000000000004}`)
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+paths:
+  $.total: "0.3"
+`), &exp)
+	require.Nil(err)
+
+	a := gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONPathNotEqual)
+
+	err = yaml.Unmarshal([]byte(`
+paths:
+  $.total: "0.3"
+tolerance: 1e-9
+`), &exp)
+	require.Nil(err)
+	require.Equal(1e-9, exp.Tolerance)
+
+	a = gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	// Numbers within the tolerance are equal for the comparison operators.
+	for expVal, ok := range map[string]bool{
+		"== 0.3":  true,
+		"!= 0.3":  false,
+		"<= 0.3":  true,
+		"> 0.3":   false,
+		"> 0.2":   true,
+		"!= 0.31": true,
+	} {
+		exp.Paths = map[string]string{"$.total": expVal}
+		a = gdtjson.New(&exp, c)
+		require.Equal(ok, a.OK(ctx), expVal)
+	}
+}
+
+func TestJSONToleranceInvalid(t *testing.T) {
+	require := require.New(t)
+
+	for _, tolerance := range []string{"-0.1", "small", ".nan"} {
+		var exp gdtjson.Expect
+		err := yaml.Unmarshal([]byte("tolerance: "+tolerance), &exp)
+		require.NotNil(err, tolerance)
+		var perr *parse.Error
+		require.ErrorAs(err, &perr)
+		require.Equal(gdtjson.CodeJSONToleranceInvalid, perr.Code)
+	}
+}
+
+func schemaServer(t *testing.T) (*httptest.Server, *atomic.Int32, string) {
+	schema, err := os.ReadFile(filepath.Join("testdata", "book.schema.json"))
+	require.Nil(t, err)
+	sum := sha256.Sum256(schema)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			switch r.URL.Path {
+			case "/book.schema.json":
+				_, _ = w.Write(schema)
+			case "/slow.schema.json":
+				time.Sleep(200 * time.Millisecond)
+				_, _ = w.Write(schema)
+			default:
+				http.NotFound(w, r)
+			}
+		},
+	))
+	return srv, &hits, hex.EncodeToString(sum[:])
+}
+
+func TestRemoteJSONSchema(t *testing.T) {
+	require := require.New(t)
+	t.Setenv(gdtjson.SchemaCacheDirEnv, t.TempDir())
+
+	ctx := context.TODO()
+	srv, hits, checksum := schemaServer(t)
+	url := srv.URL + "/book.schema.json"
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+schema: `+url+`
+schema-sha256: `+checksum+`
+`), &exp)
+	require.Nil(err)
+	require.Equal(int32(1), hits.Load())
+
+	a := gdtjson.New(&exp, content())
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	a = gdtjson.New(&exp, []byte(`[{"id": 1}]`))
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONSchemaInvalid)
+
+	// The cached schema is used without contacting the server, even when
+	// the server is no longer reachable.
+	srv.Close()
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+schema: `+url+`
+`), &exp)
+	require.Nil(err)
+	require.Equal(int32(1), hits.Load())
+
+	a = gdtjson.New(&exp, content())
+	require.True(a.OK(ctx))
+}
+
+func TestRemoteJSONSchemaErrors(t *testing.T) {
+	require := require.New(t)
+	t.Setenv(gdtjson.SchemaCacheDirEnv, t.TempDir())
+
+	srv, _, _ := schemaServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		doc  string
+		code string
+	}{
+		{
+			"not found",
+			"schema: " + srv.URL + "/noexist.json",
+			gdtjson.CodeJSONSchemaFetchError,
+		},
+		{
+			"timeout",
+			"schema: " + srv.URL + "/slow.schema.json\nschema-timeout: 50ms",
+			gdtjson.CodeJSONSchemaFetchError,
+		},
+		{
+			"checksum mismatch",
+			"schema: " + srv.URL + "/book.schema.json\nschema-sha256: abc123",
+			gdtjson.CodeJSONSchemaChecksumMismatch,
+		},
+		{
+			"local checksum mismatch",
+			"schema: testdata/book.schema.json\nschema-sha256: abc123",
+			gdtjson.CodeJSONSchemaChecksumMismatch,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var exp gdtjson.Expect
+			err := yaml.Unmarshal([]byte(tc.doc), &exp)
+			assert.NotNil(t, err)
+			var perr *parse.Error
+			if assert.ErrorAs(t, err, &perr) {
+				assert.Equal(t, tc.code, perr.Code)
+			}
+		})
+	}
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+schema: `+srv.URL+`/slow.schema.json
+schema-timeout: notaduration
+`), &exp)
+	require.NotNil(err)
+	require.ErrorContains(err, "duration")
+}
+
+func TestInlineJSONSchema(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+schema:
+  type: array
+  items:
+    type: object
+    required: [id, title, pages]
+    properties:
+      pages:
+        type: integer
+        minimum: 1
+`), &exp)
+	require.Nil(err)
+	require.Empty(exp.Schema)
+	require.NotNil(exp.InlineSchema)
+
+	a := gdtjson.New(&exp, content())
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	a = gdtjson.New(&exp, []byte(`[{"id": "1", "title": "t", "pages": 0}]`))
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONSchemaInvalid)
+	require.ErrorContains(failures[0], "inline schema")
+
+	// JSON is valid YAML.
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+schema: {"type": "object"}
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, content())
+	require.False(a.OK(ctx))
+	require.ErrorIs(a.Failures()[0], gdtjson.ErrJSONSchemaInvalid)
+}
+
+func TestInlineJSONSchemaInvalid(t *testing.T) {
+	require := require.New(t)
+
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+schema:
+  type: notatype
+`), &exp)
+	require.NotNil(err)
+	var perr *parse.Error
+	require.ErrorAs(err, &perr)
+	require.Equal(gdtjson.CodeJSONSchemaDocumentInvalid, perr.Code)
+
+	err = yaml.Unmarshal([]byte(`
+schema:
+  - type: object
+`), &exp)
+	require.NotNil(err)
+	require.ErrorAs(err, &perr)
+	require.Equal(parse.CodeExpectedScalarOrMap, perr.Code)
+}
+
+func TestRegisterFormat(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := []byte(`{"id": "01ARZ3NDEKTSV4RRFFQ69G5FAV", "name": "Not-A-Name"}`)
+
+	gdtjson.RegisterFormat("ulid", gdtjson.FormatCheckerFunc(
+		func(input interface{}) bool {
+			s, ok := input.(string)
+			return ok && len(s) == 26 && strings.ToUpper(s) == s
+		},
+	))
+	defer gdtjson.RemoveFormat("ulid")
+
+	exp := gdtjson.Expect{
+		PathFormats: map[string]string{
+			"$.id": "ulid",
+		},
+	}
+	a := gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	exp = gdtjson.Expect{
+		PathFormats: map[string]string{
+			"$.name": "ulid",
+		},
+	}
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONFormatNotEqual)
+
+	// Registered formats are also available to JSONSchemas.
+	exp = gdtjson.Expect{}
+	err := yaml.Unmarshal([]byte(`
+schema:
+  type: object
+  properties:
+    name:
+      type: string
+      format: ulid
+`), &exp)
+	require.Nil(err)
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	require.ErrorIs(a.Failures()[0], gdtjson.ErrJSONSchemaInvalid)
+
+	// Removed formats are unknown.
+	gdtjson.RemoveFormat("ulid")
+	exp = gdtjson.Expect{
+		PathFormats: map[string]string{
+			"$.id": "ulid",
+		},
+	}
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	require.ErrorIs(a.Failures()[0], gdtjson.ErrJSONFormatError)
+}
+
+func TestArrayKey(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := []byte(`{"items": [
+  {"id": 2, "name": "two", "tags": ["b", "a"]},
+  {"id": 1, "name": "one", "tags": []}
+]}`)
+
+	// Elements are compared with the element having the same id, regardless
+	// of order.
+	var exp gdtjson.Expect
+	err := yaml.Unmarshal([]byte(`
+equals:
+  items:
+    - id: 1
+      name: one
+      tags: []
+    - id: 2
+      name: two
+      tags: [b, a]
+array-key: id
+`), &exp)
+	require.Nil(err)
+	require.Equal("id", exp.ArrayKey)
+
+	a := gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	// Arrays without the key are still ordered.
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+equals:
+  items:
+    - id: 1
+      name: one
+      tags: []
+    - id: 2
+      name: two
+      tags: [a, b]
+array-key: id
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONNotEqual)
+
+	exp.EqualsIgnoreOrder = true
+	a = gdtjson.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	// The diff for a keyed element refers to the matching actual element.
+	exp = gdtjson.Expect{}
+	err = yaml.Unmarshal([]byte(`
+contains:
+  items:
+    - id: 1
+      name: uno
+    - id: 3
+array-key: id
+`), &exp)
+	require.Nil(err)
+
+	a = gdtjson.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures = a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONNotContains)
+	msg := failures[0].Error()
+	require.Contains(msg, `$["items"][1]["name"]: expected "uno" but got "one"`)
+	require.Contains(msg, `$["items"][1]: no element with "id" equal to 3`)
+}
@@ -6,21 +6,58 @@ package json
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
 
 	"github.com/theory/jsonpath"
+	gjs "github.com/xeipuuv/gojsonschema"
 	"gopkg.in/yaml.v3"
 
 	"github.com/gdt-dev/core/parse"
 )
 
+// Error codes for JSON assertion parse errors.
+const (
+	// CodeUnsupportedJSONSchemaReference indicates an unsupported JSONSchema
+	// reference.
+	CodeUnsupportedJSONSchemaReference = "GDT-P101"
+	// CodeJSONSchemaFileNotFound indicates a JSONSchema file was not found.
+	CodeJSONSchemaFileNotFound = "GDT-P102"
+	// CodeJSONUnmarshalError indicates JSON content could not be decoded.
+	CodeJSONUnmarshalError = "GDT-P103"
+	// CodeJSONPathInvalid indicates an invalid JSONPath expression.
+	CodeJSONPathInvalid = "GDT-P104"
+	// CodeJSONPathInvalidNoRoot indicates a JSONPath expression did not start
+	// with '$'.
+	CodeJSONPathInvalidNoRoot = "GDT-P105"
+	// CodeJSONPathInvalidComparison indicates an expected value used an
+	// ordering operator with a null operand.
+	CodeJSONPathInvalidComparison = "GDT-P106"
+	// CodeJSONEqualsFileNotFound indicates a file referenced by an equals
+	// document was not found.
+	CodeJSONEqualsFileNotFound = "GDT-P107"
+	// CodeJSONToleranceInvalid indicates a tolerance was not a non-negative
+	// number.
+	CodeJSONToleranceInvalid = "GDT-P108"
+	// CodeJSONSchemaFetchError indicates a remote JSONSchema could not be
+	// fetched.
+	CodeJSONSchemaFetchError = "GDT-P109"
+	// CodeJSONSchemaChecksumMismatch indicates a JSONSchema did not match its
+	// expected checksum.
+	CodeJSONSchemaChecksumMismatch = "GDT-P110"
+	// CodeJSONSchemaDocumentInvalid indicates an inline JSONSchema document
+	// was not a valid JSONSchema.
+	CodeJSONSchemaDocumentInvalid = "GDT-P111"
+)
+
 // UnsupportedJSONSchemaReference returns ErrUnsupportedJSONSchemaReference for
-// a supplied URL.
+// a supplied URL with a scheme other than file, http or https.
 func UnsupportedJSONSchemaReference(url string, node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeUnsupportedJSONSchemaReference,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("unsupported JSONSchema reference: %s", url),
@@ -31,23 +68,92 @@ func UnsupportedJSONSchemaReference(url string, node *yaml.Node) error {
 // path.
 func JSONSchemaFileNotFound(path string, node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeJSONSchemaFileNotFound,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("unable to find JSONSchema file %q", path),
 	}
 }
 
+// JSONEqualsFileNotFound returns a ParseError when a file referenced by an
+// equals document was not found.
+func JSONEqualsFileNotFound(path string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeJSONEqualsFileNotFound,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("unable to find equals document file %q", path),
+	}
+}
+
+// JSONToleranceInvalid returns a ParseError when a tolerance was not a
+// non-negative number.
+func JSONToleranceInvalid(node *yaml.Node) error {
+	return &parse.Error{
+		Code:   CodeJSONToleranceInvalid,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"expected tolerance to be a non-negative number but got %q",
+			node.Value,
+		),
+	}
+}
+
+// JSONSchemaFetchError returns a ParseError when a remote JSONSchema could not
+// be fetched.
+func JSONSchemaFetchError(url string, err error, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeJSONSchemaFetchError,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("failed to fetch JSONSchema %s: %s", url, err),
+	}
+}
+
+// JSONSchemaChecksumMismatch returns a ParseError when a JSONSchema did not
+// match its expected SHA-256 checksum.
+func JSONSchemaChecksumMismatch(
+	url string,
+	exp string,
+	got string,
+	node *yaml.Node,
+) error {
+	return &parse.Error{
+		Code:   CodeJSONSchemaChecksumMismatch,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"JSONSchema %s checksum mismatch: expected sha256 %s but got %s",
+			url, exp, got,
+		),
+	}
+}
+
+// JSONSchemaDocumentInvalid returns a ParseError when an inline JSONSchema
+// document was not a valid JSONSchema.
+func JSONSchemaDocumentInvalid(err error, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeJSONSchemaDocumentInvalid,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("invalid inline JSONSchema: %s", err),
+	}
+}
+
 // JSONUnmarshalError returns an ErrFailure when JSON content cannot be
 // decoded.
 func JSONUnmarshalError(err error, node *yaml.Node) error {
 	if node != nil {
 		return &parse.Error{
+			Code:    CodeJSONUnmarshalError,
 			Line:    node.Line,
 			Column:  node.Column,
 			Message: fmt.Sprintf("failed to unmarshal JSON: %s", err),
 		}
 	}
 	return &parse.Error{
+		Code:    CodeJSONUnmarshalError,
 		Message: fmt.Sprintf("failed to unmarshal JSON: %s", err),
 	}
 }
@@ -56,6 +162,7 @@ func JSONUnmarshalError(err error, node *yaml.Node) error {
 // parsed.
 func JSONPathInvalid(path string, err error, node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeJSONPathInvalid,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("JSONPath invalid: %s: %s", path, err),
@@ -66,18 +173,35 @@ func JSONPathInvalid(path string, err error, node *yaml.Node) error {
 // expression does not start with '$'.
 func JSONPathInvalidNoRoot(path string, node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeJSONPathInvalidNoRoot,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("JSONPath expression %s invalid: expression must start with '$'", path),
 	}
 }
 
+// JSONPathInvalidComparison returns a ParseError when an expected value for a
+// JSONPath expression uses an ordering operator with a null operand.
+func JSONPathInvalidComparison(path string, expVal string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:   CodeJSONPathInvalidComparison,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"JSONPath %s expected value %q invalid: null may only be "+
+				"compared with == or !=", path, expVal,
+		),
+	}
+}
+
 // UnmarshalYAML is a custom unmarshaler that ensures that JSONPath expressions
 // contained in the Expect are valid.
 func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 	if node.Kind != yaml.MappingNode {
 		return parse.ExpectedMapAt(node)
 	}
+	var remoteSchemaNode, localSchemaNode *yaml.Node
+	var localSchemaPath string
 	// maps/structs are stored in a top-level Node.Content field which is a
 	// concatenated slice of Node pointers in pairs of key/values.
 	for i := 0; i < len(node.Content); i += 2 {
@@ -97,16 +221,45 @@ func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 				return err
 			}
 			e.Len = v
-		case "schema":
+		case "tolerance":
 			if valNode.Kind != yaml.ScalarNode {
 				return parse.ExpectedScalarAt(valNode)
 			}
-			// Ensure any JSONSchema URL specified in exponse.json.schema exists
+			var tolerance float64
+			if err := valNode.Decode(&tolerance); err != nil {
+				return JSONToleranceInvalid(valNode)
+			}
+			if tolerance < 0 || math.IsNaN(tolerance) {
+				return JSONToleranceInvalid(valNode)
+			}
+			e.Tolerance = tolerance
+		case "schema":
+			if valNode.Kind == yaml.MappingNode {
+				doc, err := FromYAML(valNode)
+				if err != nil {
+					return err
+				}
+				if _, err := gjs.NewSchema(gjs.NewGoLoader(doc)); err != nil {
+					return JSONSchemaDocumentInvalid(err, valNode)
+				}
+				e.InlineSchema = doc
+				continue
+			}
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarOrMapAt(valNode)
+			}
 			schemaURL := valNode.Value
-			if strings.HasPrefix(schemaURL, "http://") || strings.HasPrefix(schemaURL, "https://") {
-				// TODO(jaypipes): Support network lookups?
+			if isRemoteSchema(schemaURL) {
+				// Remote JSONSchemas are fetched once all fields are parsed
+				// because the fetch depends on schema-timeout and
+				// schema-sha256.
+				remoteSchemaNode = valNode
+				continue
+			}
+			if strings.Contains(schemaURL, "://") && !strings.HasPrefix(schemaURL, "file://") {
 				return UnsupportedJSONSchemaReference(schemaURL, valNode)
 			}
+			// Ensure any JSONSchema URL specified in exponse.json.schema exists
 			// Convert relative filepaths to absolute filepaths rooted in the context's
 			// testdir after stripping any "file://" scheme prefix
 			schemaURL = strings.TrimPrefix(schemaURL, "file://")
@@ -117,14 +270,23 @@ func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 				return JSONSchemaFileNotFound(schemaURL, valNode)
 			}
 			defer f.Close()
-			if runtime.GOOS == "windows" {
-				// Need to do this because of an "optimization" done in the
-				// gojsonreference library:
-				// https://github.com/xeipuuv/gojsonreference/blob/bd5ef7bd5415a7ac448318e64f11a24cd21e594b/reference.go#L107-L114
-				e.Schema = "file:///" + schemaURL
-			} else {
-				e.Schema = "file://" + schemaURL
+			localSchemaNode = valNode
+			localSchemaPath = schemaURL
+			e.Schema = schemaFileURL(schemaURL)
+		case "schema-timeout", "schema_timeout":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			timeout, err := time.ParseDuration(valNode.Value)
+			if err != nil {
+				return parse.ExpectedDurationAt(valNode)
+			}
+			e.SchemaTimeout = timeout
+		case "schema-sha256", "schema_sha256":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
 			}
+			e.SchemaSHA256 = valNode.Value
 		case "paths":
 			if valNode.Kind != yaml.MappingNode {
 				return parse.ExpectedMapAt(valNode)
@@ -133,15 +295,80 @@ func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 			if err := valNode.Decode(&paths); err != nil {
 				return err
 			}
-			for path := range paths {
+			for path, expVal := range paths {
 				if len(path) == 0 || path[0] != '$' {
 					return JSONPathInvalidNoRoot(path, valNode)
 				}
 				if _, err := jsonpath.Parse(path); err != nil {
 					return JSONPathInvalid(path, err, valNode)
 				}
+				op, operand := splitOperator(expVal)
+				if isOrdering(op) && operand == nullValue {
+					return JSONPathInvalidComparison(path, expVal, valNode)
+				}
 			}
 			e.Paths = paths
+		case "contains":
+			doc, err := FromYAML(valNode)
+			if err != nil {
+				return err
+			}
+			e.Contains = doc
+		case "equals":
+			doc, path, err := equalsDocument(valNode)
+			if err != nil {
+				return err
+			}
+			e.Equals = doc
+			e.equalsFile = path
+		case "equals-ignore":
+			if valNode.Kind != yaml.SequenceNode {
+				return parse.ExpectedSequenceAt(valNode)
+			}
+			var ignore []string
+			if err := valNode.Decode(&ignore); err != nil {
+				return err
+			}
+			for _, path := range ignore {
+				if len(path) == 0 || path[0] != '$' {
+					return JSONPathInvalidNoRoot(path, valNode)
+				}
+				if _, err := jsonpath.Parse(path); err != nil {
+					return JSONPathInvalid(path, err, valNode)
+				}
+			}
+			e.EqualsIgnore = ignore
+		case "array-key", "array_key":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			e.ArrayKey = valNode.Value
+		case "equals-ignore-order":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			var ignoreOrder bool
+			if err := valNode.Decode(&ignoreOrder); err != nil {
+				return err
+			}
+			e.EqualsIgnoreOrder = ignoreOrder
+		case "paths_absent", "paths-absent":
+			if valNode.Kind != yaml.SequenceNode {
+				return parse.ExpectedSequenceAt(valNode)
+			}
+			var pathsAbsent []string
+			if err := valNode.Decode(&pathsAbsent); err != nil {
+				return err
+			}
+			for _, path := range pathsAbsent {
+				if len(path) == 0 || path[0] != '$' {
+					return JSONPathInvalidNoRoot(path, valNode)
+				}
+				if _, err := jsonpath.Parse(path); err != nil {
+					return JSONPathInvalid(path, err, valNode)
+				}
+			}
+			e.PathsAbsent = pathsAbsent
 		case "path_formats", "path-formats":
 			if valNode.Kind != yaml.MappingNode {
 				return parse.ExpectedMapAt(valNode)
@@ -161,5 +388,24 @@ func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 			e.PathFormats = pathFormats
 		}
 	}
+	if remoteSchemaNode != nil {
+		timeout := e.SchemaTimeout
+		if timeout == 0 {
+			timeout = DefaultSchemaTimeout
+		}
+		path, err := fetchSchema(
+			remoteSchemaNode.Value, timeout, e.SchemaSHA256, remoteSchemaNode,
+		)
+		if err != nil {
+			return err
+		}
+		e.Schema = schemaFileURL(path)
+	} else if localSchemaNode != nil && e.SchemaSHA256 != "" {
+		if err := verifySchemaFile(
+			localSchemaPath, e.SchemaSHA256, localSchemaNode,
+		); err != nil {
+			return err
+		}
+	}
 	return nil
 }
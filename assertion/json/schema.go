@@ -0,0 +1,150 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package json
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultSchemaTimeout is the default amount of time to wait for a remote
+	// JSONSchema to be fetched.
+	DefaultSchemaTimeout = 30 * time.Second
+	// SchemaCacheDirEnv is the name of the environment variable containing
+	// the directory remote JSONSchemas are cached in. Defaults to a "gdt/schemas"
+	// directory in the user's cache directory.
+	SchemaCacheDirEnv = "GDT_SCHEMA_CACHE_DIR"
+)
+
+// isRemoteSchema returns true if the supplied schema URL refers to a remote
+// JSONSchema.
+func isRemoteSchema(schemaURL string) bool {
+	return strings.HasPrefix(schemaURL, "http://") ||
+		strings.HasPrefix(schemaURL, "https://")
+}
+
+// schemaFileURL returns the "file://" URL gojsonschema expects for an
+// absolute file path.
+func schemaFileURL(path string) string {
+	if runtime.GOOS == "windows" {
+		// Need to do this because of an "optimization" done in the
+		// gojsonreference library:
+		// https://github.com/xeipuuv/gojsonreference/blob/bd5ef7bd5415a7ac448318e64f11a24cd21e594b/reference.go#L107-L114
+		return "file:///" + path
+	}
+	return "file://" + path
+}
+
+// schemaCacheDir returns the directory remote JSONSchemas are cached in.
+func schemaCacheDir() string {
+	if dir := os.Getenv(SchemaCacheDirEnv); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gdt", "schemas")
+}
+
+// checksumOK returns true if the supplied checksum is empty or is the
+// hex-encoded SHA-256 digest of the content.
+func checksumOK(content []byte, checksum string) bool {
+	if checksum == "" {
+		return true
+	}
+	return strings.EqualFold(checksum, sha256Hex(content))
+}
+
+// sha256Hex returns the hex-encoded SHA-256 digest of the content.
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifySchemaFile returns an error if the content of the JSONSchema file at
+// the supplied path does not match the supplied checksum.
+func verifySchemaFile(path string, checksum string, node *yaml.Node) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return JSONSchemaFileNotFound(path, node)
+	}
+	if !checksumOK(b, checksum) {
+		return JSONSchemaChecksumMismatch(path, checksum, sha256Hex(b), node)
+	}
+	return nil
+}
+
+// fetchSchema returns the path to a cached copy of the remote JSONSchema at
+// the supplied URL. A cached copy matching the checksum is used without
+// contacting the remote server, so that tests may be re-run offline.
+// Otherwise the JSONSchema is fetched, waiting at most timeout, verified
+// against the checksum and cached.
+func fetchSchema(
+	schemaURL string,
+	timeout time.Duration,
+	checksum string,
+	node *yaml.Node,
+) (string, error) {
+	dir := schemaCacheDir()
+	path := filepath.Join(dir, sha256Hex([]byte(schemaURL))+".json")
+	if b, err := os.ReadFile(path); err == nil && checksumOK(b, checksum) {
+		return path, nil
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(schemaURL)
+	if err != nil {
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", JSONSchemaFetchError(
+			schemaURL, fmt.Errorf("unexpected status %s", resp.Status), node,
+		)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	if !checksumOK(b, checksum) {
+		return "", JSONSchemaChecksumMismatch(
+			schemaURL, checksum, sha256Hex(b), node,
+		)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	// Write to a temporary file and rename it so that a concurrently running
+	// test never reads a partially written schema.
+	f, err := os.CreateTemp(dir, "schema-*.json")
+	if err != nil {
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	if err := f.Close(); err != nil {
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", JSONSchemaFetchError(schemaURL, err, node)
+	}
+	return path, nil
+}
@@ -0,0 +1,46 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package text
+
+import (
+	"fmt"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/assertion/golden"
+)
+
+var (
+	// ErrNotMatched returns an ErrFailure when a regular expression did not
+	// match text content.
+	ErrNotMatched = fmt.Errorf(
+		"%w: regular expression did not match", api.ErrFailure,
+	)
+	// ErrLineCountNotEqual returns an ErrFailure when text content did not
+	// have the expected number of lines.
+	ErrLineCountNotEqual = fmt.Errorf(
+		"%w: line count not equal", api.ErrFailure,
+	)
+	// ErrGoldenNotEqual returns an ErrFailure when text content was not equal
+	// to the content of a golden file.
+	ErrGoldenNotEqual = golden.ErrNotEqual
+	// ErrGoldenError returns an ErrFailure when a golden file could not be
+	// read or written.
+	ErrGoldenError = golden.ErrFile
+)
+
+// NotMatched returns an ErrFailure when a regular expression did not match
+// text content.
+func NotMatched(pattern string, name string) error {
+	return fmt.Errorf("%w: expected %s to match %q", ErrNotMatched, name, pattern)
+}
+
+// LineCountNotEqual returns an ErrFailure when text content did not have the
+// expected number of lines.
+func LineCountNotEqual(name string, exp int, got int) error {
+	return fmt.Errorf(
+		"%w: expected %s to have %d lines but got %d",
+		ErrLineCountNotEqual, name, exp, got,
+	)
+}
@@ -0,0 +1,119 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package text
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/assertion/golden"
+	"github.com/gdt-dev/core/parse"
+)
+
+// Error codes for text assertion parse errors.
+const (
+	// CodeRegexInvalid indicates an invalid regular expression.
+	CodeRegexInvalid = "GDT-P301"
+	// CodeLineCountInvalid indicates a line count was not a non-negative
+	// integer.
+	CodeLineCountInvalid = "GDT-P302"
+)
+
+// RegexInvalid returns a ParseError when a regular expression could not be
+// compiled.
+func RegexInvalid(pattern string, err error, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeRegexInvalid,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("invalid regular expression %q: %s", pattern, err),
+	}
+}
+
+// LineCountInvalid returns a ParseError when a line count was not a
+// non-negative integer.
+func LineCountInvalid(node *yaml.Node) error {
+	return &parse.Error{
+		Code:   CodeLineCountInvalid,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"expected line-count to be a non-negative integer but got %q",
+			node.Value,
+		),
+	}
+}
+
+// UnmarshalYAML is a custom unmarshaler that ensures that regular expressions
+// contained in the Expect are valid.
+func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "all", "contains", "contains-all", "contains_all":
+			var v api.FlexStrings
+			if err := valNode.Decode(&v); err != nil {
+				return err
+			}
+			e.ContainsAll = &v
+		case "any", "contains-one-of", "contains-any", "contains_one_of", "contains_any":
+			var v api.FlexStrings
+			if err := valNode.Decode(&v); err != nil {
+				return err
+			}
+			e.ContainsAny = &v
+		case "none", "none-of", "contains-none-of", "contains-none", "none_of", "contains_none_of", "contains_none":
+			var v api.FlexStrings
+			if err := valNode.Decode(&v); err != nil {
+				return err
+			}
+			e.ContainsNone = &v
+		case "matches":
+			var v api.FlexStrings
+			if err := valNode.Decode(&v); err != nil {
+				return err
+			}
+			for _, pattern := range v.Values() {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return RegexInvalid(pattern, err, valNode)
+				}
+			}
+			e.Matches = &v
+		case "line-count", "line_count":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			var lineCount int
+			if err := valNode.Decode(&lineCount); err != nil || lineCount < 0 {
+				return LineCountInvalid(valNode)
+			}
+			e.LineCount = &lineCount
+		case "golden":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			// Resolve the golden file's path while the working directory
+			// is the scenario's directory so that the golden file does not
+			// depend on the working directory of the command under test.
+			e.Golden = golden.Path(valNode.Value)
+		default:
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	return nil
+}
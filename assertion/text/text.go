@@ -0,0 +1,243 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package text
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/assertion/golden"
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// UpdateGoldenEnv is the name of the environment variable that, when set to a
+// non-empty value, causes golden files to be written with the actual content
+// instead of being compared with it.
+const UpdateGoldenEnv = golden.UpdateEnv
+
+// Expect represents one or more assertions about text content
+type Expect struct {
+	// ContainsAll is one or more strings that *all* must be present in the
+	// content.
+	ContainsAll *api.FlexStrings `yaml:"contains,omitempty"`
+	// ContainsAny is one or more strings of which *at least one* must be
+	// present in the content.
+	ContainsAny *api.FlexStrings `yaml:"contains-one-of,omitempty"`
+	// ContainsNone is one or more strings, *none of which* should be present
+	// in the content.
+	ContainsNone *api.FlexStrings `yaml:"contains-none-of,omitempty"`
+	// Matches is one or more regular expressions that *all* must match the
+	// content.
+	Matches *api.FlexStrings `yaml:"matches,omitempty"`
+	// LineCount is the expected number of lines in the content. A final line
+	// that does not end with a newline is counted.
+	LineCount *int `yaml:"line-count,omitempty"`
+	// Golden is the path to a file with the exact expected content. Relative
+	// paths are resolved against the scenario's golden file directory. When
+	// golden files are being updated, the file is written with the actual
+	// content instead.
+	Golden string `yaml:"golden,omitempty"`
+}
+
+// Files returns the paths of the files that the Expect references, which is
+// the golden file, if any.
+func (e *Expect) Files() []string {
+	if e.Golden == "" {
+		return []string{}
+	}
+	return []string{e.Golden}
+}
+
+// New returns a `api.Assertions` that asserts various conditions about text
+// content. The supplied name, e.g. "stdout", describes the content in
+// failure messages.
+func New(
+	exp *Expect,
+	name string,
+	content []byte,
+) api.Assertions {
+	return &assertions{
+		failures: []error{},
+		exp:      exp,
+		name:     name,
+		content:  content,
+	}
+}
+
+// assertions represents one or more assertions about text content and
+// implements the api.Assertions interface
+type assertions struct {
+	// failures contains the set of error messages for failed assertions
+	failures []error
+	// exp contains the expected conditions for to be asserted
+	exp *Expect
+	// name describes the content in failure messages
+	name string
+	// content is the text content we will check
+	content []byte
+}
+
+// Fail appends a supplied error to the set of failed assertions
+func (a *assertions) Fail(err error) {
+	a.failures = append(a.failures, err)
+}
+
+// Failures returns a slice of failure messages indicating which assertions did
+// not succeed.
+func (a *assertions) Failures() []error {
+	return a.failures
+}
+
+// OK returns true if all contained assertions pass successfully
+func (a *assertions) OK(ctx context.Context) bool {
+	if a == nil || a.exp == nil {
+		return true
+	}
+	res := true
+	if !a.containsAllOK(ctx) {
+		res = false
+	}
+	if !a.containsAnyOK(ctx) {
+		res = false
+	}
+	if !a.containsNoneOK(ctx) {
+		res = false
+	}
+	if !a.matchesOK(ctx) {
+		res = false
+	}
+	if !a.lineCountOK() {
+		res = false
+	}
+	if !a.goldenOK(ctx) {
+		res = false
+	}
+	return res
+}
+
+// values returns the supplied strings with any variables replaced.
+func values(ctx context.Context, vals *api.FlexStrings) []string {
+	res := make([]string, len(vals.Values()))
+	for x, val := range vals.Values() {
+		res[x] = gdtcontext.ReplaceVariables(ctx, val)
+	}
+	return res
+}
+
+// containsAllOK returns true if the content contains all of the ContainsAll
+// strings, false otherwise
+func (a *assertions) containsAllOK(ctx context.Context) bool {
+	if a.exp.ContainsAll == nil {
+		return true
+	}
+	res := true
+	for _, find := range values(ctx, a.exp.ContainsAll) {
+		if !bytes.Contains(a.content, []byte(find)) {
+			a.Fail(api.NotIn(find, a.name))
+			res = false
+		}
+	}
+	return res
+}
+
+// containsAnyOK returns true if the content contains at least one of the
+// ContainsAny strings, false otherwise
+func (a *assertions) containsAnyOK(ctx context.Context) bool {
+	if a.exp.ContainsAny == nil {
+		return true
+	}
+	vals := values(ctx, a.exp.ContainsAny)
+	for _, find := range vals {
+		if bytes.Contains(a.content, []byte(find)) {
+			return true
+		}
+	}
+	a.Fail(api.NoneIn(vals, a.name))
+	return false
+}
+
+// containsNoneOK returns true if the content contains none of the
+// ContainsNone strings, false otherwise
+func (a *assertions) containsNoneOK(ctx context.Context) bool {
+	if a.exp.ContainsNone == nil {
+		return true
+	}
+	res := true
+	for _, find := range values(ctx, a.exp.ContainsNone) {
+		if bytes.Contains(a.content, []byte(find)) {
+			a.Fail(api.In(find, a.name))
+			res = false
+		}
+	}
+	return res
+}
+
+// matchesOK returns true if all of the Matches regular expressions match the
+// content, false otherwise
+func (a *assertions) matchesOK(ctx context.Context) bool {
+	if a.exp.Matches == nil {
+		return true
+	}
+	res := true
+	for _, pattern := range values(ctx, a.exp.Matches) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			// Patterns are validated during parse, but a variable may have
+			// been replaced with something that is not a valid pattern.
+			a.Fail(api.UnexpectedError(err))
+			res = false
+			continue
+		}
+		if !re.Match(a.content) {
+			a.Fail(NotMatched(pattern, a.name))
+			res = false
+		}
+	}
+	return res
+}
+
+// lineCountOK returns true if the number of lines in the content matches
+// LineCount, false otherwise
+func (a *assertions) lineCountOK() bool {
+	if a.exp.LineCount == nil {
+		return true
+	}
+	exp := *a.exp.LineCount
+	got := lineCount(a.content)
+	if exp != got {
+		a.Fail(LineCountNotEqual(a.name, exp, got))
+		return false
+	}
+	return true
+}
+
+// lineCount returns the number of lines in the content. A final line that
+// does not end with a newline is counted.
+func lineCount(content []byte) int {
+	if len(content) == 0 {
+		return 0
+	}
+	n := bytes.Count(content, []byte("\n"))
+	if content[len(content)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// goldenOK returns true if the content is equal to the content of the Golden
+// file, false otherwise. When golden files are being updated, the Golden file
+// is written with the content instead.
+func (a *assertions) goldenOK(ctx context.Context) bool {
+	if a.exp.Golden == "" {
+		return true
+	}
+	if err := golden.Compare(ctx, a.exp.Golden, a.name, a.content); err != nil {
+		a.Fail(err)
+		return false
+	}
+	return true
+}
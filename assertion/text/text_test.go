@@ -0,0 +1,159 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package text_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdttext "github.com/gdt-dev/core/assertion/text"
+	"github.com/gdt-dev/core/parse"
+)
+
+var content = []byte("Old Man and the Sea\nErnest Hemingway\n127 pages\n")
+
+func TestText(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+
+	var exp gdttext.Expect
+	err := yaml.Unmarshal([]byte(`
+contains: [Old Man, Hemingway]
+contains-one-of: [Faulkner, Hemingway]
+contains-none-of: Steinbeck
+matches:
+  - '(?m)^\d+ pages$'
+line-count: 3
+golden: book.golden
+`), &exp)
+	require.Nil(err)
+	require.True(filepath.IsAbs(exp.Golden))
+
+	a := gdttext.New(&exp, "stdout", content)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+}
+
+func TestTextFailures(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+
+	var exp gdttext.Expect
+	err := yaml.Unmarshal([]byte(`
+contains: Faulkner
+contains-one-of: [Faulkner, Steinbeck]
+contains-none-of: Hemingway
+matches: '^\d+ pages$'
+line-count: 2
+`), &exp)
+	require.Nil(err)
+
+	a := gdttext.New(&exp, "stdout", content)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 5)
+	require.ErrorIs(failures[0], api.ErrNotIn)
+	require.ErrorIs(failures[1], api.ErrNoneIn)
+	require.ErrorIs(failures[2], api.ErrIn)
+	require.ErrorIs(failures[3], gdttext.ErrNotMatched)
+	require.ErrorIs(failures[4], gdttext.ErrLineCountNotEqual)
+	require.ErrorContains(
+		failures[4], "expected stdout to have 2 lines but got 3",
+	)
+}
+
+func TestLineCount(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+
+	for c, lines := range map[string]int{
+		"":       0,
+		"\n":     1,
+		"a":      1,
+		"a\nb":   2,
+		"a\nb\n": 2,
+	} {
+		exp := gdttext.Expect{LineCount: &lines}
+		a := gdttext.New(&exp, "stdout", []byte(c))
+		require.True(a.OK(ctx), "%q", c)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+
+	exp := gdttext.Expect{
+		Golden: filepath.Join("testdata", "golden", "book.golden"),
+	}
+	a := gdttext.New(
+		&exp, "stdout",
+		[]byte("Old Man and the Sea\nErnest Hemingway\n128 pages\n"),
+	)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdttext.ErrGoldenNotEqual)
+	msg := failures[0].Error()
+	require.Contains(msg, "+++ stdout\n")
+	require.Contains(msg, "-127 pages\n+128 pages\n")
+
+	exp = gdttext.Expect{
+		Golden: filepath.Join("testdata", "golden", "noexist.golden"),
+	}
+	a = gdttext.New(&exp, "stdout", content)
+	require.False(a.OK(ctx))
+	require.ErrorIs(a.Failures()[0], gdttext.ErrGoldenError)
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	require := require.New(t)
+	t.Setenv(gdttext.UpdateGoldenEnv, "1")
+
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "out.golden")
+
+	exp := gdttext.Expect{Golden: path}
+	a := gdttext.New(&exp, "stdout", content)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	b, err := os.ReadFile(path)
+	require.Nil(err)
+	require.Equal(content, b)
+}
+
+func TestTextParseErrors(t *testing.T) {
+	require := require.New(t)
+
+	tests := map[string]string{
+		"matches: '(unclosed'": gdttext.CodeRegexInvalid,
+		"line-count: -1":       gdttext.CodeLineCountInvalid,
+		"line-count: many":     gdttext.CodeLineCountInvalid,
+		"golden: [a, b]":       parse.CodeExpectedScalar,
+	}
+	for doc, code := range tests {
+		var exp gdttext.Expect
+		err := yaml.Unmarshal([]byte(doc), &exp)
+		require.NotNil(err, doc)
+		var perr *parse.Error
+		require.ErrorAs(err, &perr, doc)
+		require.Equal(code, perr.Code, doc)
+	}
+
+	var exp gdttext.Expect
+	err := yaml.Unmarshal([]byte("unknown: field"), &exp)
+	require.ErrorIs(err, parse.ErrParseUnknownField)
+}
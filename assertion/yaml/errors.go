@@ -0,0 +1,25 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package yaml
+
+import (
+	"fmt"
+
+	"github.com/gdt-dev/core/api"
+)
+
+var (
+	// ErrYAMLUnmarshalError returns an ErrFailure when YAML content could not
+	// be decoded.
+	ErrYAMLUnmarshalError = fmt.Errorf(
+		"%w: failed to unmarshal YAML", api.ErrFailure,
+	)
+)
+
+// YAMLUnmarshalError returns an ErrFailure when YAML content could not be
+// decoded.
+func YAMLUnmarshalError(err error) error {
+	return fmt.Errorf("%w: %s", ErrYAMLUnmarshalError, err)
+}
@@ -0,0 +1,131 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package yaml
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtjson "github.com/gdt-dev/core/assertion/json"
+)
+
+// Expect represents one or more assertions about YAML content. It has the
+// same fields as the JSON assertion's Expect. The YAML content is converted
+// to its JSON equivalent before JSONPath expressions, `contains`, `equals`
+// and `schema` are evaluated, so a JSONSchema validates YAML content too.
+// Content containing more than one YAML document, e.g. a set of Kubernetes
+// manifests separated by `---`, is treated as an array of those documents.
+type Expect struct {
+	gdtjson.Expect `yaml:",inline"`
+}
+
+// New returns a `api.Assertions` that asserts various conditions about
+// YAML content
+func New(
+	exp *Expect,
+	content []byte,
+) api.Assertions {
+	return &assertions{
+		failures: []error{},
+		exp:      exp,
+		content:  content,
+	}
+}
+
+// assertions represents one or more assertions about YAML content and
+// implements the api.Assertions interface
+type assertions struct {
+	// failures contains the set of error messages for failed assertions
+	failures []error
+	// exp contains the expected conditions for to be asserted
+	exp *Expect
+	// content is the YAML content we will check
+	content []byte
+}
+
+// Fail appends a supplied error to the set of failed assertions
+func (a *assertions) Fail(err error) {
+	a.failures = append(a.failures, err)
+}
+
+// Failures returns a slice of failure messages indicating which assertions did
+// not succeed.
+func (a *assertions) Failures() []error {
+	return a.failures
+}
+
+// OK returns true if all contained assertions pass successfully
+func (a *assertions) OK(ctx context.Context) bool {
+	if a == nil || a.exp == nil {
+		return true
+	}
+	if !a.lenOK() {
+		return false
+	}
+	jb, err := ToJSON(a.content)
+	if err != nil {
+		a.Fail(YAMLUnmarshalError(err))
+		return false
+	}
+	// The length assertion applies to the YAML content, not its JSON
+	// equivalent.
+	jexp := a.exp.Expect
+	jexp.Len = nil
+	ja := gdtjson.New(&jexp, jb)
+	if !ja.OK(ctx) {
+		a.failures = append(a.failures, ja.Failures()...)
+		return false
+	}
+	return true
+}
+
+// lenOK returns true if the content length matches expectations, false
+// otherwise
+func (a *assertions) lenOK() bool {
+	if a.exp.Len != nil {
+		exp := *a.exp.Len
+		got := len(a.content)
+		if exp != got {
+			a.Fail(api.NotEqualLength(exp, got))
+			return false
+		}
+	}
+	return true
+}
+
+// ToJSON returns the JSON equivalent of the supplied YAML content. Content
+// containing more than one YAML document is converted to a JSON array of the
+// documents.
+func ToJSON(content []byte) ([]byte, error) {
+	docs := []interface{}{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		doc, err := gdtjson.FromYAML(&node)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	switch len(docs) {
+	case 0:
+		return json.Marshal(nil)
+	case 1:
+		return json.Marshal(docs[0])
+	}
+	return json.Marshal(docs)
+}
@@ -0,0 +1,156 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package yaml_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtjson "github.com/gdt-dev/core/assertion/json"
+	gdtyaml "github.com/gdt-dev/core/assertion/yaml"
+)
+
+func content() []byte {
+	b, _ := os.ReadFile(filepath.Join("testdata", "manifests.yaml"))
+	return b
+}
+
+func TestYAML(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+
+	var exp gdtyaml.Expect
+	err := yaml.Unmarshal([]byte(`
+paths:
+  $[0].metadata.name: settings
+  $[0].data.published_on: "< 2000-01-01"
+  $[1].spec.replicas: ">= 2"
+paths-absent:
+  - $[2]
+contains:
+  - kind: Deployment
+    spec:
+      template:
+        spec:
+          containers:
+            - image: books:1.2.3
+schema:
+  type: array
+  items:
+    type: object
+    required: [apiVersion, kind, metadata]
+`), &exp)
+	require.Nil(err)
+	require.Len(exp.Paths, 3)
+	require.NotNil(exp.InlineSchema)
+
+	a := gdtyaml.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+
+	exp = gdtyaml.Expect{}
+	err = yaml.Unmarshal([]byte(`
+paths:
+  $[1].spec.replicas: "5"
+`), &exp)
+	require.Nil(err)
+
+	a = gdtyaml.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtjson.ErrJSONPathNotEqual)
+}
+
+func TestYAMLSingleDocument(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := []byte(`
+name: books
+ports: [80, 443]
+`)
+
+	var exp gdtyaml.Expect
+	err := yaml.Unmarshal([]byte(`
+equals:
+  name: books
+  ports: [443, 80]
+equals-ignore-order: true
+`), &exp)
+	require.Nil(err)
+
+	a := gdtyaml.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+}
+
+func TestYAMLLength(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+	c := content()
+	expLen := len(c)
+
+	exp := gdtyaml.Expect{}
+	exp.Len = &expLen
+
+	a := gdtyaml.New(&exp, c)
+	require.True(a.OK(ctx))
+	require.Empty(a.Failures())
+	// The length applies to the YAML content rather than its JSON
+	// equivalent and does not change the Expect.
+	require.Equal(&expLen, exp.Len)
+
+	expLen = 0
+	a = gdtyaml.New(&exp, c)
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], api.ErrNotEqual)
+}
+
+func TestYAMLUnmarshalError(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.TODO()
+
+	exp := gdtyaml.Expect{}
+	exp.Paths = map[string]string{"$.name": "books"}
+
+	a := gdtyaml.New(&exp, []byte("name: [books"))
+	require.False(a.OK(ctx))
+	failures := a.Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], gdtyaml.ErrYAMLUnmarshalError)
+}
+
+func TestToJSON(t *testing.T) {
+	require := require.New(t)
+
+	b, err := gdtyaml.ToJSON([]byte(`
+on: 2025-01-01
+count: 3
+1: one
+`))
+	require.Nil(err)
+	require.JSONEq(`{"on": "2025-01-01", "count": 3, "1": "one"}`, string(b))
+
+	b, err = gdtyaml.ToJSON([]byte{})
+	require.Nil(err)
+	require.Equal("null", string(b))
+
+	b, err = gdtyaml.ToJSON([]byte("a: 1\n---\nb: 2\n"))
+	require.Nil(err)
+	require.JSONEq(`[{"a": 1}, {"b": 2}]`, string(b))
+}
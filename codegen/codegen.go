@@ -0,0 +1,185 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package codegen converts gdt test scenarios into standalone Go test files.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/scenario"
+)
+
+var (
+	// builtinPackages contains the import paths of packages that declare test
+	// spec types but do not need to be imported by a generated test file,
+	// either because the scenario package already imports them or because
+	// the plugin runs out of process and is registered at runtime.
+	builtinPackages = map[string]bool{
+		"github.com/gdt-dev/core/scenario":        true,
+		"github.com/gdt-dev/core/plugin/check":    true,
+		"github.com/gdt-dev/core/plugin/external": true,
+	}
+)
+
+// GoTest parses the gdt test scenario at the supplied path and returns the
+// contents of a gofmt'd Go test file in package pkg that runs the scenario.
+//
+// The scenario's YAML is embedded in the generated file and parsed with
+// scenario.FromBytes, so the generated test behaves exactly as the YAML file
+// does. The plugins that parsed the scenario's test specs must be registered
+// when GoTest is called; the generated file blank-imports their packages so
+// they are registered when the test runs.
+func GoTest(path string, pkg string) ([]byte, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return GoTestFromBytes(path, pkg, contents)
+}
+
+// GoTestFromBytes is like GoTest but parses the supplied scenario contents
+// instead of reading them from path. path is recorded as the scenario's Path
+// in the generated file.
+func GoTestFromBytes(path string, pkg string, contents []byte) ([]byte, error) {
+	s, err := scenario.FromBytes(contents, scenario.WithPath(path))
+	if err != nil {
+		return nil, err
+	}
+	name := testName(s.Title())
+	data := fileData{
+		Source:   path,
+		Package:  pkg,
+		Imports:  pluginPackages(s),
+		TestName: name,
+		Const:    "scenario" + strings.TrimPrefix(name, "Test"),
+		YAML:     quote(string(contents)),
+		Path:     strconv.Quote(path),
+		Fixtures: s.Fixtures,
+	}
+	var b bytes.Buffer
+	if err := fileTemplate.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated test for %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// fileData is the data supplied to fileTemplate.
+type fileData struct {
+	Source   string
+	Package  string
+	Imports  []string
+	TestName string
+	Const    string
+	YAML     string
+	Path     string
+	Fixtures []string
+}
+
+var fileTemplate = template.Must(template.New("gotest").Parse(
+	`// Code generated by gdt from {{ .Source }}. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"testing"
+
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/scenario"
+{{- range .Imports }}
+	_ "{{ . }}"
+{{- end }}
+)
+
+const {{ .Const }} = {{ .YAML }}
+
+func {{ .TestName }}(t *testing.T) {
+	s, err := scenario.FromBytes(
+		[]byte({{ .Const }}),
+		scenario.WithPath({{ .Path }}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := gdtcontext.New()
+{{- if .Fixtures }}
+	// The scenario requires the following fixtures, which must be registered
+	// with gdtcontext.RegisterFixture before the scenario is run:
+{{- range .Fixtures }}
+	//   - {{ . }}
+{{- end }}
+{{- end }}
+	if err := s.Run(ctx, t); err != nil {
+		t.Fatal(err)
+	}
+}
+`))
+
+// pluginPackages returns the sorted import paths of the packages declaring
+// the scenario's test spec and condition types.
+func pluginPackages(s *scenario.Scenario) []string {
+	seen := map[string]bool{}
+	evs := []api.Evaluable{}
+	evs = append(evs, s.SkipIf...)
+	evs = append(evs, s.RunIf...)
+	evs = append(evs, s.Tests...)
+	for _, ev := range evs {
+		t := reflect.TypeOf(ev)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		pkg := t.PkgPath()
+		if pkg == "" || builtinPackages[pkg] {
+			continue
+		}
+		seen[pkg] = true
+	}
+	pkgs := make([]string, 0, len(seen))
+	for pkg := range seen {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// testName returns the name of a Go test function for the scenario title,
+// e.g. "TestCreateThenDelete" for "create-then-delete.yaml".
+func testName(title string) string {
+	title = strings.TrimSuffix(strings.TrimSuffix(title, ".yaml"), ".yml")
+	var b strings.Builder
+	b.WriteString("Test")
+	upper := true
+	for _, r := range title {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quote returns s as a Go raw string literal, splicing in interpreted string
+// literals for any backquotes s contains.
+func quote(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "` + \"`\" + `") + "`"
+}
@@ -0,0 +1,88 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package codegen_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/codegen"
+	_ "github.com/gdt-dev/core/internal/testutil/plugin/foo"
+	"github.com/gdt-dev/core/scenario"
+)
+
+func TestGoTest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo-fixtures.yaml")
+	out, err := codegen.GoTest(fp, "foo_test")
+	require.Nil(err)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo_fixtures_test.go", out, parser.ParseComments)
+	require.Nil(err)
+	assert.Equal("foo_test", f.Name.Name)
+
+	imports := []string{}
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		require.Nil(err)
+		imports = append(imports, path)
+	}
+	assert.Contains(imports, "github.com/gdt-dev/core/internal/testutil/plugin/foo")
+	assert.Contains(imports, "github.com/gdt-dev/core/scenario")
+	assert.NotContains(imports, "github.com/gdt-dev/core/plugin/check")
+
+	fn, ok := f.Scope.Lookup("TestFooFixtures").Decl.(*ast.FuncDecl)
+	require.True(ok)
+	assert.Equal("TestFooFixtures", fn.Name.Name)
+	assert.Contains(string(out), "//   - start")
+
+	// The embedded YAML round-trips to the original scenario contents.
+	spec := f.Scope.Lookup("scenarioFooFixtures").Decl.(*ast.ValueSpec)
+	lit := constValue(t, spec.Values[0])
+	contents, err := os.ReadFile(fp)
+	require.Nil(err)
+	assert.Equal(string(contents), lit)
+
+	s, err := scenario.FromBytes([]byte(lit), scenario.WithPath(fp))
+	require.Nil(err)
+	assert.Equal("foo fixtures", s.Name)
+	assert.Len(s.Tests, 2)
+}
+
+func TestGoTestParseError(t *testing.T) {
+	require := require.New(t)
+
+	_, err := codegen.GoTestFromBytes(
+		"bad.yaml", "bad_test", []byte("tests: notalist"),
+	)
+	require.NotNil(err)
+}
+
+// constValue concatenates the string literals in the supplied constant
+// expression.
+func constValue(t *testing.T, expr ast.Expr) string {
+	var b bytes.Buffer
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok {
+			s, err := strconv.Unquote(lit.Value)
+			require.Nil(t, err)
+			b.WriteString(s)
+		}
+		return true
+	})
+	return b.String()
+}
@@ -8,9 +8,12 @@ import (
 	"context"
 	"io"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gdt-dev/core/api"
 	"github.com/gdt-dev/core/testunit"
@@ -26,6 +29,12 @@ var (
 	fixturesKey    = ContextKey("gdt.fixtures")
 	runKey         = ContextKey("gdt.run")
 	unitKey        = ContextKey("gdt.unit")
+	envKey         = ContextKey("gdt.env")
+	clockKey       = ContextKey("gdt.clock")
+	goldenKey      = ContextKey("gdt.golden.update")
+	tracerKey      = ContextKey("gdt.tracer.provider")
+	progressKey    = ContextKey("gdt.progress")
+	scenStartKey   = ContextKey("gdt.scenario.start")
 )
 
 // ContextModifier sets some value on the context
@@ -92,6 +101,10 @@ func WithDebug(writers ...io.Writer) ContextModifier {
 	}
 }
 
+// WithDebugPrefix sets the prefix on the gdt debug log entries. The prefix
+// may be a `text/template` template, which the `debug` package executes for
+// each entry, e.g. to include the wall-clock time or the time elapsed since
+// the test scenario started.
 func WithDebugPrefix(prefix string) ContextModifier {
 	return func(ctx context.Context) context.Context {
 		return context.WithValue(ctx, debugPrefixKey, prefix)
@@ -112,6 +125,44 @@ func WithFixtures(fixtures map[string]api.Fixture) ContextModifier {
 	}
 }
 
+// WithUpdateGolden informs gdt to write golden files with the actual content
+// instead of comparing the actual content with them.
+func WithUpdateGolden() ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return SetUpdateGolden(ctx, true)
+	}
+}
+
+// WithTracerProvider sets the OpenTelemetry TracerProvider that gdt creates
+// spans with for test suites, scenarios, specs, retry attempts and fixtures.
+// The TracerProvider is typically an SDK TracerProvider configured with the
+// exporter for the tracing backend. Without a TracerProvider, no spans are
+// created.
+func WithTracerProvider(tp trace.TracerProvider) ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return SetTracerProvider(ctx, tp)
+	}
+}
+
+// WithProgress registers functions that receive the progress events of a test
+// run, e.g. a test spec starting, each attempt of a retried test spec, waits
+// and fixtures starting, so that a CLI tool can show a live progress
+// indicator during long waits and retries.
+func WithProgress(fns ...api.ProgressFunc) ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return AddProgress(ctx, fns...)
+	}
+}
+
+// WithProgressChannel registers a channel that receives the progress events
+// of a test run. Sending an event blocks until the channel's receiver reads
+// it, so the receiver must keep reading for the duration of the test run.
+func WithProgressChannel(ch chan<- api.ProgressEvent) ContextModifier {
+	return WithProgress(func(ev api.ProgressEvent) {
+		ch <- ev
+	})
+}
+
 // SetDebug sets gdt's debug logging to the supplied `io.Writer`.
 //
 // The `writers` parameters is optional. If no `io.Writer` objects are
@@ -128,7 +179,8 @@ func SetDebug(
 	return context.WithValue(ctx, debugKey, writers)
 }
 
-// SetDebugPrefix sets the prefix on the gdt debug log entries.
+// SetDebugPrefix sets the prefix on the gdt debug log entries. See
+// WithDebugPrefix for templated prefixes.
 func SetDebugPrefix(
 	ctx context.Context,
 	prefix string,
@@ -136,6 +188,17 @@ func SetDebugPrefix(
 	return context.WithValue(ctx, debugPrefixKey, prefix)
 }
 
+// AddProgress registers functions that receive the progress events of a test
+// run in addition to any already registered.
+func AddProgress(
+	ctx context.Context,
+	fns ...api.ProgressFunc,
+) context.Context {
+	return context.WithValue(
+		ctx, progressKey, append(slices.Clone(Progress(ctx)), fns...),
+	)
+}
+
 // RegisterFixture registers a named fixtures with the context
 func RegisterFixture(
 	ctx context.Context,
@@ -147,6 +210,26 @@ func RegisterFixture(
 	return context.WithValue(ctx, fixturesKey, fixtures)
 }
 
+// RegisterFixtureV2 registers a named FixtureV2 with the context. Errors
+// returned from the fixture's Stop method are returned from the test run.
+func RegisterFixtureV2(
+	ctx context.Context,
+	name string,
+	f api.FixtureV2,
+) context.Context {
+	return RegisterFixture(ctx, name, api.FixtureFromV2(f))
+}
+
+// RegisterFixtureFactory registers a named fixture that is constructed by the
+// supplied factory the first time a test scenario references it.
+func RegisterFixtureFactory(
+	ctx context.Context,
+	name string,
+	factory api.FixtureFactory,
+) context.Context {
+	return RegisterFixture(ctx, name, api.NewLazyFixture(factory))
+}
+
 // RegisterPlugin registers a plugin with the context
 func RegisterPlugin(
 	ctx context.Context,
@@ -175,6 +258,45 @@ func SetRun(
 	return context.WithValue(ctx, runKey, merged)
 }
 
+// SetEnv saves environment variables in the context, e.g. those published by
+// fixtures implementing api.EnvProvider, for plugins to add to the environment
+// of any processes they start. If there are already environment variables in
+// the supplied context, the existing variables are merged with the supplied
+// variables.
+func SetEnv(
+	ctx context.Context,
+	env map[string]string,
+) context.Context {
+	merged := lo.Assign(Env(ctx), env)
+	return context.WithValue(ctx, envKey, merged)
+}
+
+// SetClock sets the Clock in the context, replacing any existing Clock.
+func SetClock(
+	ctx context.Context,
+	clock api.Clock,
+) context.Context {
+	return context.WithValue(ctx, clockKey, clock)
+}
+
+// SetUpdateGolden sets whether golden files are written with the actual
+// content instead of being compared with it.
+func SetUpdateGolden(
+	ctx context.Context,
+	update bool,
+) context.Context {
+	return context.WithValue(ctx, goldenKey, update)
+}
+
+// SetTracerProvider sets the OpenTelemetry TracerProvider in the context,
+// replacing any existing TracerProvider.
+func SetTracerProvider(
+	ctx context.Context,
+	tp trace.TracerProvider,
+) context.Context {
+	return context.WithValue(ctx, tracerKey, tp)
+}
+
 // deprecated. use SetRun()
 func StorePriorRun(
 	ctx context.Context,
@@ -216,6 +338,15 @@ func PopTrace(
 	return context.WithValue(ctx, traceKey, stack)
 }
 
+// SetScenarioStart sets the wall-clock time at which the current test
+// scenario started running.
+func SetScenarioStart(
+	ctx context.Context,
+	start time.Time,
+) context.Context {
+	return context.WithValue(ctx, scenStartKey, start)
+}
+
 // SetTestUnit sets the current test unit in the context. Any previously existing
 // test unit in the context is overwritten.
 func SetTestUnit(
@@ -0,0 +1,55 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package context
+
+import (
+	"context"
+	"sync"
+)
+
+var dependenciesKey = ContextKey("gdt.dependencies")
+
+// dependencies are the results of the dependency checks of a test suite run,
+// keyed by what was checked.
+type dependencies struct {
+	sync.Mutex
+	results map[string]error
+}
+
+// SetDependencyCache returns a copy of the supplied context with a new, empty
+// cache of the results of dependency checks that a test suite's scenarios
+// share.
+func SetDependencyCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dependenciesKey, &dependencies{
+		results: map[string]error{},
+	})
+}
+
+// CheckDependency returns the result of the dependency check with the
+// supplied key. The first time the key is checked in a test suite run, check
+// is called and its result cached for the rest of the run, and false is
+// returned. Later checks return the cached result and true. Outside of a test
+// suite run, check is always called.
+func CheckDependency(
+	ctx context.Context,
+	key string,
+	check func() error,
+) (bool, error) {
+	var deps *dependencies
+	if ctx != nil {
+		deps, _ = ctx.Value(dependenciesKey).(*dependencies)
+	}
+	if deps == nil {
+		return false, check()
+	}
+	deps.Lock()
+	defer deps.Unlock()
+	if err, found := deps.results[key]; found {
+		return true, err
+	}
+	err := check()
+	deps.results[key] = err
+	return false, err
+}
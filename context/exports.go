@@ -0,0 +1,115 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package context
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+var exportsKey = ContextKey("gdt.exports")
+
+var (
+	// ErrNoExports is returned from Export and Import when the context does
+	// not belong to a test suite run.
+	ErrNoExports = errors.New("not running in a test suite")
+	// ErrNotExported is returned from Import when no scenario has exported
+	// the variable.
+	ErrNotExported = errors.New("variable not exported")
+)
+
+// exported is the value of an exported variable.
+type exported struct {
+	val any
+	// secret is true if the value was a secret registered with the
+	// exporting scenario's context.
+	secret bool
+}
+
+// exports are the variables exported by the scenarios of a test suite run.
+type exports struct {
+	sync.Mutex
+	vars map[string]exported
+}
+
+// SetExports returns a copy of the supplied context with a new, empty
+// collection of the variables that a test suite's scenarios export for later
+// scenarios to import.
+func SetExports(ctx context.Context) context.Context {
+	return context.WithValue(ctx, exportsKey, &exports{
+		vars: map[string]exported{},
+	})
+}
+
+// getExports returns the context's exports, or nil if the context does not
+// belong to a test suite run.
+func getExports(ctx context.Context) *exports {
+	if ctx == nil {
+		return nil
+	}
+	if v := ctx.Value(exportsKey); v != nil {
+		return v.(*exports)
+	}
+	return nil
+}
+
+// Export stores the value of a variable for later scenarios in the test suite
+// run to import, replacing any value already exported with the same name. If
+// the value is one of the context's secrets, it remains a secret in the
+// contexts of the scenarios that import it.
+func Export(ctx context.Context, name string, val any) error {
+	ex := getExports(ctx)
+	if ex == nil {
+		return ErrNoExports
+	}
+	s, isStr := val.(string)
+	ex.Lock()
+	defer ex.Unlock()
+	ex.vars[name] = exported{
+		val:    val,
+		secret: isStr && slices.Contains(getSecrets(ctx).values, s),
+	}
+	return nil
+}
+
+// Import returns a copy of the supplied context with the value of the named
+// variable exported by an earlier scenario in the test suite run seeded into
+// the run data, or ErrNotExported if no scenario has exported the variable.
+func Import(ctx context.Context, name string) (context.Context, error) {
+	ex := getExports(ctx)
+	if ex == nil {
+		return ctx, ErrNoExports
+	}
+	ex.Lock()
+	v, found := ex.vars[name]
+	ex.Unlock()
+	if !found {
+		return ctx, ErrNotExported
+	}
+	if v.secret {
+		ctx = AddSecrets(ctx, v.val.(string))
+	}
+	return SetRun(ctx, map[string]any{name: v.val}), nil
+}
+
+// Exported returns the names and values of the variables exported in the test
+// suite run, leaving out secrets.
+func Exported(ctx context.Context) map[string]any {
+	ex := getExports(ctx)
+	if ex == nil {
+		return map[string]any{}
+	}
+	ex.Lock()
+	defer ex.Unlock()
+	res := make(map[string]any, len(ex.vars))
+	for name, v := range ex.vars {
+		if !v.secret {
+			res[name] = v.val
+		}
+	}
+	return res
+}
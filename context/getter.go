@@ -8,8 +8,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 
 	"github.com/gdt-dev/core/api"
 	"github.com/gdt-dev/core/testunit"
@@ -103,6 +109,76 @@ func PriorRun(ctx context.Context) map[string]any {
 	return Run(ctx)
 }
 
+// Env gets a context's environment variables
+func Env(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return map[string]string{}
+	}
+	if v := ctx.Value(envKey); v != nil {
+		return v.(map[string]string)
+	}
+	return map[string]string{}
+}
+
+// Clock gets a context's Clock or api.SystemClock if none is set.
+func Clock(ctx context.Context) api.Clock {
+	if ctx == nil {
+		return api.SystemClock
+	}
+	if v := ctx.Value(clockKey); v != nil {
+		return v.(api.Clock)
+	}
+	return api.SystemClock
+}
+
+// UpdateGolden returns true if golden files should be written with the actual
+// content instead of being compared with it.
+func UpdateGolden(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	if v := ctx.Value(goldenKey); v != nil {
+		return v.(bool)
+	}
+	return false
+}
+
+// TracerProvider gets a context's OpenTelemetry TracerProvider or, if none is
+// set, a TracerProvider that creates no spans.
+func TracerProvider(ctx context.Context) trace.TracerProvider {
+	if ctx == nil {
+		return noop.NewTracerProvider()
+	}
+	if v := ctx.Value(tracerKey); v != nil {
+		return v.(trace.TracerProvider)
+	}
+	return noop.NewTracerProvider()
+}
+
+// Progress gets the functions registered with the context that receive the
+// progress events of a test run.
+func Progress(ctx context.Context) []api.ProgressFunc {
+	if ctx == nil {
+		return []api.ProgressFunc{}
+	}
+	if v := ctx.Value(progressKey); v != nil {
+		return v.([]api.ProgressFunc)
+	}
+	return []api.ProgressFunc{}
+}
+
+// ScenarioStart gets the wall-clock time at which the current test scenario
+// started running, or the zero time if none is set.
+func ScenarioStart(ctx context.Context) time.Time {
+	if ctx == nil {
+		return time.Time{}
+	}
+	if v := ctx.Value(scenStartKey); v != nil {
+		return v.(time.Time)
+	}
+	return time.Time{}
+}
+
 // TestUnit gets a context's test unit
 func TestUnit(ctx context.Context) *testunit.TestUnit {
 	if ctx == nil {
@@ -114,32 +190,113 @@ func TestUnit(ctx context.Context) *testunit.TestUnit {
 	return nil
 }
 
+// templateVarRe matches a reference to a variable in the prior run data using
+// template syntax, e.g. `{{ .vars.myvar }}`.
+var templateVarRe = regexp.MustCompile(`\{\{\s*\.vars\.([\w.-]+)\s*\}\}`)
+
 // ReplaceVariables replaces all occurrences of any of the variables in the
-// prior run data with their stored variable values
+// prior run data with their stored variable values. A variable named `myvar`
+// may be referred to as `$myvar`, `${myvar}` or `{{ .vars.myvar }}`. A Lazy
+// value is only computed if the subject refers to it.
+//
+// Note that test scenario contents have environment variables expanded when
+// parsed, so test authors write `$$myvar` or `$${myvar}` to refer to a
+// variable instead of an environment variable.
 func ReplaceVariables(
 	ctx context.Context,
 	subject string,
 ) string {
 	data := PriorRun(ctx)
-	for dataKey, dataVal := range data {
-		var dataValStr string
-		switch dataVal := dataVal.(type) {
-		case string:
-			dataValStr = dataVal
-		case []byte:
-			dataValStr = string(dataVal)
-		case int, uint, int8, int16, int32, int64:
-			dataValStr = strconv.Itoa(dataVal.(int))
-		case float32, float64:
-			dataValStr = strconv.FormatFloat(dataVal.(float64), 'f', -1, 64)
-		default:
+	if len(data) == 0 {
+		return subject
+	}
+	subject = replaceTemplateVariables(ctx, subject)
+	if !strings.Contains(subject, "$") {
+		return subject
+	}
+	// Replace the longest variable names first so that `$foo` does not
+	// replace the beginning of `$foobar`.
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	for _, name := range names {
+		braced := "${" + name + "}"
+		bare := "$" + name
+		if !strings.Contains(subject, braced) && !strings.Contains(subject, bare) {
+			continue
+		}
+		val, ok := variableValueString(ctx, name)
+		if !ok {
 			continue
 		}
-		subject = strings.ReplaceAll(
-			subject,
-			fmt.Sprintf("$%s", dataKey),
-			dataValStr,
-		)
+		subject = strings.ReplaceAll(subject, braced, val)
+		subject = strings.ReplaceAll(subject, bare, val)
 	}
 	return subject
 }
+
+// ReplaceTemplateVariables replaces all references to variables in the prior
+// run data that use the template notation, e.g. `{{ .vars.myvar }}`, with
+// their stored variable values. Unlike ReplaceVariables, `$myvar` and
+// `${myvar}` are left alone.
+func ReplaceTemplateVariables(
+	ctx context.Context,
+	subject string,
+) string {
+	if !strings.Contains(subject, "{{") || len(PriorRun(ctx)) == 0 {
+		return subject
+	}
+	return replaceTemplateVariables(ctx, subject)
+}
+
+// replaceTemplateVariables replaces the template references in the supplied
+// subject to the variables in the prior run data.
+func replaceTemplateVariables(ctx context.Context, subject string) string {
+	if !strings.Contains(subject, "{{") {
+		return subject
+	}
+	return templateVarRe.ReplaceAllStringFunc(subject, func(ref string) string {
+		name := templateVarRe.FindStringSubmatch(ref)[1]
+		if val, ok := variableValueString(ctx, name); ok {
+			return val
+		}
+		return ref
+	})
+}
+
+// variableValueString returns the string form of the value of the named
+// variable in the prior run data and whether the variable has a value that
+// can be used in ReplaceVariables. A Lazy value that cannot be computed has
+// no such value.
+func variableValueString(ctx context.Context, name string) (string, bool) {
+	val, found, err := RunValue(ctx, name)
+	if !found || err != nil {
+		return "", false
+	}
+	return variableString(val)
+}
+
+// variableString returns the string form of a variable's value and whether
+// the value can be used in ReplaceVariables.
+func variableString(val any) (string, bool) {
+	switch val := val.(type) {
+	case string:
+		return val, true
+	case []byte:
+		return string(val), true
+	case int, uint, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val), true
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(val), true
+	default:
+		return "", false
+	}
+}
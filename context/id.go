@@ -0,0 +1,110 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package context
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// runIDSize is the number of random bytes in a run ID, the same as in an
+	// OpenTelemetry trace ID.
+	runIDSize = 16
+	// specIDSize is the number of random bytes in a test spec ID, the same as
+	// in an OpenTelemetry span ID.
+	specIDSize = 8
+	// scenarioIDSize is the number of random bytes in a scenario ID.
+	scenarioIDSize = 8
+)
+
+var (
+	runIDKey      = ContextKey("gdt.run.id")
+	scenarioIDKey = ContextKey("gdt.scenario.id")
+	specIDKey     = ContextKey("gdt.spec.id")
+)
+
+// NewRunID returns a new random identifier for a test run.
+func NewRunID() string {
+	return newID(runIDSize)
+}
+
+// NewScenarioID returns a new random identifier for the run of a test
+// scenario.
+func NewScenarioID() string {
+	return newID(scenarioIDSize)
+}
+
+// NewSpecID returns a new random identifier for the run of a test spec.
+func NewSpecID() string {
+	return newID(specIDSize)
+}
+
+// newID returns the hex encoding of the supplied number of random bytes.
+func newID(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRunID sets the identifier of the test run. Without a run ID, gdt
+// generates one when a test suite or scenario is run.
+func WithRunID(id string) ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return SetRunID(ctx, id)
+	}
+}
+
+// SetRunID sets the identifier of the test run.
+func SetRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey, id)
+}
+
+// RunID gets the identifier of the test run or an empty string if none is
+// set.
+func RunID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v := ctx.Value(runIDKey); v != nil {
+		return v.(string)
+	}
+	return ""
+}
+
+// SetScenarioID sets the identifier of the run of a test scenario.
+func SetScenarioID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, scenarioIDKey, id)
+}
+
+// ScenarioID gets the identifier of the run of a test scenario or an empty
+// string if none is set.
+func ScenarioID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v := ctx.Value(scenarioIDKey); v != nil {
+		return v.(string)
+	}
+	return ""
+}
+
+// SetSpecID sets the identifier of the run of a test spec.
+func SetSpecID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, specIDKey, id)
+}
+
+// SpecID gets the identifier of the run of a test spec or an empty string if
+// none is set.
+func SpecID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v := ctx.Value(specIDKey); v != nil {
+		return v.(string)
+	}
+	return ""
+}
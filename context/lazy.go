@@ -0,0 +1,48 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package context
+
+import (
+	"context"
+	"sync"
+)
+
+// Lazy is a value in the run data that is computed the first time it is
+// referenced, e.g. a computed scenario variable. The computed value, or the
+// error computing it, is kept for all later references.
+type Lazy struct {
+	once sync.Once
+	fn   func(context.Context) (any, error)
+	val  any
+	err  error
+}
+
+// NewLazy returns a Lazy whose value is computed by the supplied function.
+func NewLazy(fn func(ctx context.Context) (any, error)) *Lazy {
+	return &Lazy{fn: fn}
+}
+
+// Value returns the Lazy's value, computing it with the supplied context if
+// it has not yet been computed.
+func (l *Lazy) Value(ctx context.Context) (any, error) {
+	l.once.Do(func() {
+		l.val, l.err = l.fn(ctx)
+	})
+	return l.val, l.err
+}
+
+// RunValue returns the value of the named variable in the prior run data,
+// computing it if it is a Lazy, and whether the variable was found.
+func RunValue(ctx context.Context, name string) (any, bool, error) {
+	val, found := PriorRun(ctx)[name]
+	if !found {
+		return nil, false, nil
+	}
+	if l, ok := val.(*Lazy); ok {
+		val, err := l.Value(ctx)
+		return val, true, err
+	}
+	return val, true, nil
+}
@@ -0,0 +1,88 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package context
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// scratchDirPattern is the pattern of the names of the temporary directories
+// created by ScratchDir.
+const scratchDirPattern = "gdt-scratch-*"
+
+var scratchKey = ContextKey("gdt.scratch")
+
+// ErrNoScratchDir is returned from ScratchDir when the context does not
+// belong to a test spec or the test spec has finished.
+var ErrNoScratchDir = errors.New("no scratch directory")
+
+// scratch is a test spec's scratch directory, which is created the first time
+// it is requested.
+type scratch struct {
+	sync.Mutex
+	dir    string
+	closed bool
+}
+
+// SetScratchDir returns a copy of the supplied context with a new scratch
+// directory for a test spec. The directory is not created until ScratchDir is
+// called with the context.
+func SetScratchDir(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scratchKey, &scratch{})
+}
+
+// ScratchDir returns the path to the test spec's scratch directory, creating
+// a unique temporary directory the first time it is called for the test spec.
+// Plugins use the scratch directory for intermediate files. The directory and
+// its contents are removed by the cleanups of the test spec's Result.
+func ScratchDir(ctx context.Context) (string, error) {
+	if ctx == nil {
+		return "", ErrNoScratchDir
+	}
+	s, ok := ctx.Value(scratchKey).(*scratch)
+	if !ok {
+		return "", ErrNoScratchDir
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		return "", ErrNoScratchDir
+	}
+	if s.dir == "" {
+		dir, err := os.MkdirTemp("", scratchDirPattern)
+		if err != nil {
+			return "", fmt.Errorf("creating scratch directory: %w", err)
+		}
+		s.dir = dir
+	}
+	return s.dir, nil
+}
+
+// CloseScratchDir prevents ScratchDir from creating the test spec's scratch
+// directory and returns a function that removes the directory, or nil if the
+// directory was never created.
+func CloseScratchDir(ctx context.Context) func() {
+	if ctx == nil {
+		return nil
+	}
+	s, ok := ctx.Value(scratchKey).(*scratch)
+	if !ok {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.closed = true
+	if s.dir == "" {
+		return nil
+	}
+	dir := s.dir
+	return func() {
+		_ = os.RemoveAll(dir)
+	}
+}
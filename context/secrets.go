@@ -0,0 +1,254 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+)
+
+// SecretMask is the text that secret values are replaced with when masked.
+const SecretMask = "********"
+
+var secretsKey = ContextKey("gdt.secrets")
+
+// Redaction patterns matching credentials with well-known formats. Only the
+// text matching a pattern's `secret` subexpression is masked, so that e.g. the
+// "Bearer" scheme of an Authorization header remains readable.
+var (
+	// RedactBearerToken matches the token in a bearer Authorization header.
+	RedactBearerToken = regexp.MustCompile(
+		`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`,
+	)
+	// RedactAWSAccessKeyID matches an AWS access key ID.
+	RedactAWSAccessKeyID = regexp.MustCompile(
+		`\b(?P<secret>(?:AKIA|ASIA|AIDA|AROA)[0-9A-Z]{16})\b`,
+	)
+	// RedactAWSSecretAccessKey matches an AWS secret access key assigned to
+	// a variable or field named like `aws_secret_access_key`.
+	RedactAWSSecretAccessKey = regexp.MustCompile(
+		`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`,
+	)
+	// DefaultRedactions is the collection of redaction patterns registered by
+	// WithRedaction when no patterns are supplied.
+	DefaultRedactions = []*regexp.Regexp{
+		RedactBearerToken,
+		RedactAWSAccessKeyID,
+		RedactAWSSecretAccessKey,
+	}
+)
+
+// secrets is the collection of secret values and patterns matching secret
+// values registered with a context.
+type secrets struct {
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// WithSecrets registers secret values with the context. Secret values are
+// masked in debug output, test unit detail, assertion failure messages and
+// test result artifacts.
+func WithSecrets(values ...string) ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return AddSecrets(ctx, values...)
+	}
+}
+
+// WithSecretPatterns registers regular expressions matching secret values
+// with the context. Any text matching the patterns is masked in debug output,
+// test unit detail, assertion failure messages and test result artifacts.
+func WithSecretPatterns(patterns ...*regexp.Regexp) ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return AddSecretPatterns(ctx, patterns...)
+	}
+}
+
+// WithRedaction registers regular expressions matching credentials, e.g.
+// bearer tokens or cloud provider keys, that are masked in debug output, test
+// unit detail, assertion failure messages and test result artifacts
+// regardless of which plugin produced them. If a pattern has a subexpression
+// named `secret`, only the text matching that subexpression is masked. If no
+// patterns are supplied, DefaultRedactions are registered.
+func WithRedaction(patterns ...*regexp.Regexp) ContextModifier {
+	if len(patterns) == 0 {
+		patterns = DefaultRedactions
+	}
+	return WithSecretPatterns(patterns...)
+}
+
+// AddSecrets registers secret values with the context in addition to any
+// already registered. Empty values are ignored.
+func AddSecrets(
+	ctx context.Context,
+	values ...string,
+) context.Context {
+	existing := getSecrets(ctx)
+	s := &secrets{
+		values:   slices.Clone(existing.values),
+		patterns: existing.patterns,
+	}
+	for _, v := range values {
+		if v != "" && !slices.Contains(s.values, v) {
+			s.values = append(s.values, v)
+		}
+	}
+	// Mask longer values first so that a secret containing another secret
+	// is masked entirely.
+	slices.SortStableFunc(s.values, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return context.WithValue(ctx, secretsKey, s)
+}
+
+// AddSecretPatterns registers regular expressions matching secret values with
+// the context in addition to any already registered.
+func AddSecretPatterns(
+	ctx context.Context,
+	patterns ...*regexp.Regexp,
+) context.Context {
+	existing := getSecrets(ctx)
+	s := &secrets{
+		values:   existing.values,
+		patterns: append(slices.Clone(existing.patterns), patterns...),
+	}
+	return context.WithValue(ctx, secretsKey, s)
+}
+
+// getSecrets returns the context's secrets.
+func getSecrets(ctx context.Context) *secrets {
+	if ctx == nil {
+		return &secrets{}
+	}
+	if v := ctx.Value(secretsKey); v != nil {
+		return v.(*secrets)
+	}
+	return &secrets{}
+}
+
+// HasSecrets returns true if any secret values or patterns are registered with
+// the context.
+func HasSecrets(ctx context.Context) bool {
+	s := getSecrets(ctx)
+	return len(s.values) > 0 || len(s.patterns) > 0
+}
+
+// MaskSecrets returns the supplied string with any of the context's secret
+// values, and any text matching the context's secret patterns, replaced with
+// SecretMask.
+func MaskSecrets(ctx context.Context, s string) string {
+	sec := getSecrets(ctx)
+	for _, v := range sec.values {
+		s = strings.ReplaceAll(s, v, SecretMask)
+	}
+	for _, re := range sec.patterns {
+		s = maskPattern(re, s)
+	}
+	return s
+}
+
+// maskPattern returns the supplied string with text matching the supplied
+// pattern replaced with SecretMask. If the pattern has a subexpression named
+// `secret`, only the text matching the subexpression is replaced.
+func maskPattern(re *regexp.Regexp, s string) string {
+	group := re.SubexpIndex("secret")
+	if group < 0 {
+		return re.ReplaceAllLiteralString(s, SecretMask)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(SecretMask)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// MaskError returns an error with the message of the supplied error with any
+// of the context's secrets masked. The returned error wraps the supplied
+// error so that `errors.Is` and `errors.As` behave as for the supplied error.
+// If there is nothing to mask, the supplied error is returned.
+func MaskError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	masked := MaskSecrets(ctx, msg)
+	if masked == msg {
+		return err
+	}
+	me := &maskedError{err: err, msg: masked}
+	if f, ok := api.AsFailure(err); ok {
+		me.failure = maskFailure(ctx, f)
+	}
+	return me
+}
+
+// maskFailure returns a copy of the supplied Failure with any of the
+// context's secrets masked in its detail.
+func maskFailure(ctx context.Context, f *api.Failure) *api.Failure {
+	return &api.Failure{
+		Code:     f.Code,
+		Class:    f.Class,
+		Message:  MaskSecrets(ctx, f.Message),
+		Expected: maskValue(ctx, f.Expected),
+		Actual:   maskValue(ctx, f.Actual),
+		Diff:     MaskSecrets(ctx, f.Diff),
+		Path:     MaskSecrets(ctx, f.Path),
+	}
+}
+
+// maskValue returns the supplied value, or its string representation with
+// any of the context's secrets masked if it contains any.
+func maskValue(ctx context.Context, v any) any {
+	if v == nil {
+		return nil
+	}
+	s := fmt.Sprint(v)
+	masked := MaskSecrets(ctx, s)
+	if masked == s {
+		return v
+	}
+	return masked
+}
+
+// maskedError is an error whose message has had secrets masked.
+type maskedError struct {
+	err error
+	msg string
+	// failure is the masked copy of the api.Failure in err's chain, if any.
+	failure *api.Failure
+}
+
+// As sets the supplied target to the masked copy of the api.Failure in the
+// wrapped error's chain, so that its detail does not reveal secrets.
+func (e *maskedError) As(target any) bool {
+	if e.failure == nil {
+		return false
+	}
+	if t, ok := target.(**api.Failure); ok {
+		*t = e.failure
+		return true
+	}
+	return false
+}
+
+func (e *maskedError) Error() string {
+	return e.msg
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,143 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// DefaultFailureDumpSize is the default number of debug messages kept for
+// each test spec when debug output is only written for failed test specs.
+const DefaultFailureDumpSize = 1000
+
+var (
+	failureDumpKey = gdtcontext.ContextKey("gdt.debug.failure-dump")
+	captureKey     = gdtcontext.ContextKey("gdt.debug.capture")
+)
+
+// WithFailureDump informs gdt to only write the debug messages for a test
+// spec to the context's Debug output and test unit detail if the test spec
+// fails. Until the test spec's result is known, its debug messages are kept in
+// an in-memory ring buffer holding the supplied number of most recent
+// messages. If size is not positive, DefaultFailureDumpSize is used.
+//
+// This keeps the debug output of successful test runs quiet while preserving
+// the full diagnostics of failed test specs.
+func WithFailureDump(size int) gdtcontext.ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return SetFailureDump(ctx, size)
+	}
+}
+
+// SetFailureDump sets the number of debug messages kept for each test spec
+// when debug output is only written for failed test specs. If size is not
+// positive, DefaultFailureDumpSize is used.
+func SetFailureDump(ctx context.Context, size int) context.Context {
+	if size <= 0 {
+		size = DefaultFailureDumpSize
+	}
+	return context.WithValue(ctx, failureDumpKey, size)
+}
+
+// StartCapture returns a context in which debug messages are kept in a ring
+// buffer instead of being written, if the supplied context was created with
+// WithFailureDump. Otherwise the supplied context is returned. The captured
+// messages are written or discarded by EndCapture.
+func StartCapture(ctx context.Context) context.Context {
+	v := ctx.Value(failureDumpKey)
+	if v == nil {
+		return ctx
+	}
+	size := v.(int)
+	return context.WithValue(ctx, captureKey, &ring{
+		msgs: make([]string, 0, min(size, 64)),
+		size: size,
+	})
+}
+
+// EndCapture stops capturing debug messages in the supplied context and, if
+// flush is true, writes the captured messages to the context's Debug output
+// and test unit detail. Otherwise, the captured messages are discarded.
+func EndCapture(ctx context.Context, flush bool) {
+	r := capturing(ctx)
+	if r == nil {
+		return
+	}
+	msgs, dropped := r.end()
+	if !flush {
+		return
+	}
+	if dropped > 0 {
+		msgs = append([]string{fmt.Sprintf(
+			"%s %d earlier debug messages dropped\n",
+			prefix(ctx), dropped,
+		)}, msgs...)
+	}
+	tu := gdtcontext.TestUnit(ctx)
+	for _, msg := range msgs {
+		output(ctx, tu, msg)
+	}
+}
+
+// capturing returns the ring buffer capturing debug messages in the supplied
+// context, or nil if debug messages are not being captured.
+func capturing(ctx context.Context) *ring {
+	if ctx == nil {
+		return nil
+	}
+	if v := ctx.Value(captureKey); v != nil {
+		return v.(*ring)
+	}
+	return nil
+}
+
+// ring is a ring buffer holding the most recent debug messages.
+type ring struct {
+	sync.Mutex
+	msgs []string
+	size int
+	// next is the index in msgs of the oldest message once the buffer is
+	// full.
+	next int
+	// dropped is the number of messages overwritten by newer messages.
+	dropped int
+	// done is true once the capture has ended. Messages written afterwards,
+	// e.g. by a test spec that is still running after its timeout, are
+	// written directly.
+	done bool
+}
+
+// add adds the message to the ring buffer, returning false if the capture
+// has ended.
+func (r *ring) add(msg string) bool {
+	r.Lock()
+	defer r.Unlock()
+	if r.done {
+		return false
+	}
+	if len(r.msgs) < r.size {
+		r.msgs = append(r.msgs, msg)
+		return true
+	}
+	r.msgs[r.next] = msg
+	r.next = (r.next + 1) % r.size
+	r.dropped++
+	return true
+}
+
+// end ends the capture and returns the captured messages, oldest first, and
+// the number of messages dropped.
+func (r *ring) end() ([]string, int) {
+	r.Lock()
+	defer r.Unlock()
+	r.done = true
+	msgs := append(r.msgs[r.next:], r.msgs[:r.next]...)
+	r.msgs = nil
+	return msgs, r.dropped
+}
@@ -0,0 +1,89 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// Level is the severity of a debug message. Messages with a Level below the
+// context's threshold Level are not written.
+type Level int
+
+const (
+	// LevelTrace is for very detailed messages, e.g. the result of each
+	// attempt of a retried test spec.
+	LevelTrace Level = iota
+	// LevelDebug is for messages describing what gdt is doing. Messages
+	// written with Printf and Println have this Level.
+	LevelDebug
+	// LevelInfo is for messages about notable events.
+	LevelInfo
+	// LevelWarn is for messages about unexpected conditions that gdt
+	// recovered from, e.g. an unhealthy fixture being restarted.
+	LevelWarn
+	// LevelError is for messages about errors that gdt could not recover
+	// from but that do not stop the test run.
+	LevelError
+)
+
+var levelNames = []string{"trace", "debug", "info", "warn", "error"}
+
+// String returns the name of the Level.
+func (l Level) String() string {
+	if l < LevelTrace || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the Level with the supplied case-insensitive name, e.g.
+// "warn".
+func ParseLevel(name string) (Level, error) {
+	for x, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(x), nil
+		}
+	}
+	return LevelTrace, fmt.Errorf("unknown debug level %q", name)
+}
+
+var levelKey = gdtcontext.ContextKey("gdt.debug.level")
+
+// WithLevel sets the threshold Level of debug messages written to the
+// context's Debug output. Messages below the threshold are discarded. By
+// default, messages of all levels are written.
+func WithLevel(level Level) gdtcontext.ContextModifier {
+	return func(ctx context.Context) context.Context {
+		return SetLevel(ctx, level)
+	}
+}
+
+// SetLevel sets the threshold Level of debug messages written to the
+// context's Debug output.
+func SetLevel(ctx context.Context, level Level) context.Context {
+	return context.WithValue(ctx, levelKey, level)
+}
+
+// LevelFrom returns the context's threshold Level of debug messages.
+func LevelFrom(ctx context.Context) Level {
+	if ctx == nil {
+		return LevelTrace
+	}
+	if v := ctx.Value(levelKey); v != nil {
+		return v.(Level)
+	}
+	return LevelTrace
+}
+
+// Enabled returns true if messages with the supplied Level are written to the
+// context's Debug output.
+func Enabled(ctx context.Context, level Level) bool {
+	return level >= LevelFrom(ctx)
+}
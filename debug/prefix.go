@@ -0,0 +1,88 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package debug
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// PrefixTimestamps is a debug prefix template that adds the wall-clock time
+// and the time elapsed since the test scenario started to each debug message,
+// e.g. `[gdt] 15:04:05.000 +1.25s`.
+const PrefixTimestamps = `[gdt] {{ .Time.Format "15:04:05.000" }} +{{ .Elapsed }}`
+
+// PrefixData is the data that a templated debug prefix is executed with.
+type PrefixData struct {
+	// Time is the wall-clock time at which the debug message was written.
+	Time time.Time
+	// Elapsed is the time since the test scenario started, rounded to the
+	// millisecond, or zero outside of a test scenario.
+	Elapsed time.Duration
+	// Trace is the trace name of the test scenario or test spec.
+	Trace string
+	// RunID identifies the test run.
+	RunID string
+	// SpecID identifies the run of the test spec.
+	SpecID string
+}
+
+// prefixTemplates caches the parsed templates of debug prefixes, keyed by
+// the prefix. A prefix that is not a valid template is cached as nil and
+// written as is.
+var prefixTemplates sync.Map
+
+// WithTimestamps sets the debug prefix to PrefixTimestamps, so that slow
+// spots in long-running scenarios are visible in the debug output.
+func WithTimestamps() gdtcontext.ContextModifier {
+	return gdtcontext.WithDebugPrefix(PrefixTimestamps)
+}
+
+// prefix returns the context's debug prefix, executing it with the current
+// PrefixData if the prefix is a template.
+func prefix(ctx context.Context) string {
+	p := gdtcontext.DebugPrefix(ctx)
+	if !strings.Contains(p, "{{") {
+		return p
+	}
+	tmpl := prefixTemplate(p)
+	if tmpl == nil {
+		return p
+	}
+	now := time.Now()
+	data := PrefixData{
+		Time:   now,
+		Trace:  gdtcontext.Trace(ctx),
+		RunID:  gdtcontext.RunID(ctx),
+		SpecID: gdtcontext.SpecID(ctx),
+	}
+	if start := gdtcontext.ScenarioStart(ctx); !start.IsZero() {
+		data.Elapsed = now.Sub(start).Round(time.Millisecond)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return p
+	}
+	return b.String()
+}
+
+// prefixTemplate returns the parsed template for the supplied debug prefix,
+// or nil if the prefix is not a valid template.
+func prefixTemplate(p string) *template.Template {
+	if v, ok := prefixTemplates.Load(p); ok {
+		return v.(*template.Template)
+	}
+	tmpl, err := template.New("prefix").Parse(p)
+	if err != nil {
+		tmpl = nil
+	}
+	prefixTemplates.Store(p, tmpl)
+	return tmpl
+}
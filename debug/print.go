@@ -10,46 +10,82 @@ import (
 	"strings"
 
 	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/testunit"
 )
 
 // Printf writes a message with optional message arguments to the context's
-// Debug output. The behaviour is analogous to `fmt.Printf`.
+// Debug output. The behaviour is analogous to `fmt.Printf`. The message has
+// LevelDebug.
 func Printf(
 	ctx context.Context,
 	format string,
 	args ...any,
 ) {
-	tu := gdtcontext.TestUnit(ctx)
-	writers := gdtcontext.Debug(ctx)
-	if len(writers) == 0 && tu == nil {
-		return
-	}
-
-	trace := gdtcontext.Trace(ctx)
-
-	prefix := gdtcontext.DebugPrefix(ctx)
-	msg := prefix
-	if trace != "" {
-		msg += " [" + trace + "] "
-	}
-	msg += fmt.Sprintf(format, args...)
-	msg = strings.TrimSuffix(msg, "\n") + "\n"
-	for _, w := range writers {
-		//nolint:errcheck
-		w.Write([]byte(msg))
-	}
-	if tu != nil {
-		tu.Log(strings.TrimSuffix(msg, "\n"))
-	}
+	write(ctx, LevelDebug, fmt.Sprintf(format, args...))
 }
 
 // Println writes a message with optional message arguments to the context's
 // Debug output, ensuring there is a newline in the message line. This is
-// analogous to `fmt.Println` behaviour.
+// analogous to `fmt.Println` behaviour. The message has LevelDebug.
 func Println(
 	ctx context.Context,
 	args ...any,
 ) {
+	write(ctx, LevelDebug, fmt.Sprintln(args...))
+}
+
+// Tracef writes a message with LevelTrace to the context's Debug output. The
+// behaviour is analogous to `fmt.Printf`.
+func Tracef(
+	ctx context.Context,
+	format string,
+	args ...any,
+) {
+	write(ctx, LevelTrace, fmt.Sprintf(format, args...))
+}
+
+// Infof writes a message with LevelInfo to the context's Debug output. The
+// behaviour is analogous to `fmt.Printf`.
+func Infof(
+	ctx context.Context,
+	format string,
+	args ...any,
+) {
+	write(ctx, LevelInfo, fmt.Sprintf(format, args...))
+}
+
+// Warnf writes a message with LevelWarn to the context's Debug output. The
+// message is marked with "warning:". The behaviour is analogous to
+// `fmt.Printf`.
+func Warnf(
+	ctx context.Context,
+	format string,
+	args ...any,
+) {
+	write(ctx, LevelWarn, "warning: "+fmt.Sprintf(format, args...))
+}
+
+// Errorf writes a message with LevelError to the context's Debug output. The
+// message is marked with "error:". The behaviour is analogous to
+// `fmt.Printf`.
+func Errorf(
+	ctx context.Context,
+	format string,
+	args ...any,
+) {
+	write(ctx, LevelError, "error: "+fmt.Sprintf(format, args...))
+}
+
+// write writes the message to the context's Debug output and test unit log if
+// the supplied Level is at or above the context's threshold Level.
+func write(
+	ctx context.Context,
+	level Level,
+	message string,
+) {
+	if !Enabled(ctx, level) {
+		return
+	}
 	tu := gdtcontext.TestUnit(ctx)
 	writers := gdtcontext.Debug(ctx)
 	if len(writers) == 0 && tu == nil {
@@ -58,13 +94,26 @@ func Println(
 
 	trace := gdtcontext.Trace(ctx)
 
-	prefix := gdtcontext.DebugPrefix(ctx)
-	msg := prefix
+	msg := prefix(ctx)
 	if trace != "" {
 		msg += " [" + trace + "] "
 	}
-	msg += fmt.Sprintln(args...)
-	for _, w := range writers {
+	msg += gdtcontext.MaskSecrets(ctx, message)
+	msg = strings.TrimSuffix(msg, "\n") + "\n"
+	if r := capturing(ctx); r != nil && r.add(msg) {
+		return
+	}
+	output(ctx, tu, msg)
+}
+
+// output writes the formatted message to the context's Debug output and the
+// supplied test unit's log, if any.
+func output(
+	ctx context.Context,
+	tu *testunit.TestUnit,
+	msg string,
+) {
+	for _, w := range gdtcontext.Debug(ctx) {
 		//nolint:errcheck
 		w.Write([]byte(msg))
 	}
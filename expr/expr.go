@@ -0,0 +1,481 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package expr implements the small expression language used to compute the
+// values of scenario variables, e.g. `"bucket-" + uuid()` or `60 * 5`, and to
+// write conditions and assertions, e.g. `env.CI == "true"`.
+//
+// An expression is made up of integer, floating point, string and boolean
+// literals, references to other variables written `vars.NAME` and to
+// environment variables written `env.NAME`, calls to the built-in functions,
+// the arithmetic operators `+`, `-`, `*`, `/` and `%`, the comparison
+// operators `==`, `!=`, `<`, `<=`, `>` and `>=`, the logical operators `&&`,
+// `||` and `!`, and parentheses. The `+` operator concatenates when either
+// operand is a string. Dividing two integers truncates, as in Go.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrSyntax indicates an expression could not be parsed.
+	ErrSyntax = errors.New("syntax error")
+	// ErrUnknownFunction indicates an expression called a function that is
+	// not one of the built-in functions.
+	ErrUnknownFunction = errors.New("unknown function")
+	// ErrUnknownVariable indicates an expression referred to a variable that
+	// has no value.
+	ErrUnknownVariable = errors.New("unknown variable")
+	// ErrEval indicates an expression could not be evaluated, e.g. because
+	// of a division by zero or an operand of the wrong type.
+	ErrEval = errors.New("evaluation failed")
+)
+
+// Env supplies the values an expression is evaluated with.
+type Env struct {
+	// Var returns the value of the named variable, or an error wrapping
+	// ErrUnknownVariable if the variable has no value.
+	Var func(name string) (any, error)
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+	// Getenv returns the value of the named environment variable, referred
+	// to as `env.NAME`. If nil, os.Getenv is used.
+	Getenv func(name string) string
+	// State returns the state at the supplied key of the named fixture, for
+	// the state() function. If nil, state() fails.
+	State func(fixture, key string) (any, error)
+}
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+	vars []string
+}
+
+// Parse parses the supplied expression source, returning an error wrapping
+// ErrSyntax or ErrUnknownFunction if the source is not a valid expression.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &Expr{src: src, root: root, vars: p.vars}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Vars returns the names of the variables the expression refers to, in the
+// order they first appear.
+func (e *Expr) Vars() []string {
+	return e.vars
+}
+
+// IsConstant returns true if the expression's value does not depend on
+// variables, environment variables or function calls, e.g. `1 < 2`, so
+// that evaluating it always returns the same value.
+func (e *Expr) IsConstant() bool {
+	return isConstant(e.root)
+}
+
+// isConstant returns true if the supplied node and all of its operands are
+// literals.
+func isConstant(n node) bool {
+	switch n := n.(type) {
+	case *literal:
+		return true
+	case *negate:
+		return isConstant(n.x)
+	case *not:
+		return isConstant(n.x)
+	case *logical:
+		return isConstant(n.x) && isConstant(n.y)
+	case *compare:
+		return isConstant(n.x) && isConstant(n.y)
+	case *binary:
+		return isConstant(n.x) && isConstant(n.y)
+	default:
+		return false
+	}
+}
+
+// Eval evaluates the expression with the supplied Env, returning a string,
+// an int, a float64 or a bool.
+func (e *Expr) Eval(env Env) (any, error) {
+	if env.Now == nil {
+		env.Now = time.Now
+	}
+	if env.Getenv == nil {
+		env.Getenv = os.Getenv
+	}
+	return e.root.eval(&env)
+}
+
+// EvalBool evaluates the expression, e.g. a condition, with the supplied Env,
+// returning an error wrapping ErrEval if the result is not a bool.
+func (e *Expr) EvalBool(env Env) (bool, error) {
+	val, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf(
+			"%w: expected a bool result but got %q", ErrEval, format(val),
+		)
+	}
+	return b, nil
+}
+
+// node is a parsed part of an expression.
+type node interface {
+	eval(env *Env) (any, error)
+}
+
+// literal is a string, int, float64 or bool literal.
+type literal struct {
+	val any
+}
+
+func (n *literal) eval(_ *Env) (any, error) {
+	return n.val, nil
+}
+
+// varRef is a reference to a variable, e.g. `vars.PREFIX`.
+type varRef struct {
+	name string
+}
+
+func (n *varRef) eval(env *Env) (any, error) {
+	if env.Var == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVariable, n.name)
+	}
+	val, err := env.Var(n.name)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(val)
+}
+
+// envRef is a reference to an environment variable, e.g. `env.HOME`. An
+// unset environment variable is the empty string.
+type envRef struct {
+	name string
+}
+
+func (n *envRef) eval(env *Env) (any, error) {
+	return env.Getenv(n.name), nil
+}
+
+// call is a call to a built-in function.
+type call struct {
+	name string
+	fn   *function
+	args []node
+}
+
+func (n *call) eval(env *Env) (any, error) {
+	args := make([]any, len(n.args))
+	for x, arg := range n.args {
+		val, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[x] = val
+	}
+	val, err := n.fn.call(env, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return val, nil
+}
+
+// negate is the unary `-` operator.
+type negate struct {
+	x node
+}
+
+func (n *negate) eval(env *Env) (any, error) {
+	val, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch val := val.(type) {
+	case int:
+		return -val, nil
+	case float64:
+		return -val, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot negate %q", ErrEval, format(val))
+	}
+}
+
+// not is the unary `!` operator.
+type not struct {
+	x node
+}
+
+func (n *not) eval(env *Env) (any, error) {
+	val, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: operator ! requires a bool, got %q", ErrEval, format(val),
+		)
+	}
+	return !b, nil
+}
+
+// logical is the `&&` or `||` operator. The right operand is only evaluated
+// if the left operand does not decide the result.
+type logical struct {
+	op   string
+	x, y node
+}
+
+func (n *logical) eval(env *Env) (any, error) {
+	for x, operand := range []node{n.x, n.y} {
+		val, err := operand.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := val.(bool)
+		if !ok {
+			return nil, fmt.Errorf(
+				"%w: operator %s requires bools, got %q",
+				ErrEval, n.op, format(val),
+			)
+		}
+		if x == 1 || b == (n.op == "||") {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+// compare is one of the comparison operators. Numbers are compared by value,
+// whether ints or floats, and strings lexically. Values of different types
+// are never equal and cannot be ordered.
+type compare struct {
+	op   string
+	x, y node
+}
+
+func (n *compare) eval(env *Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	xf, xIsNum := toFloat(x)
+	yf, yIsNum := toFloat(y)
+	var cmp int
+	switch {
+	case xIsNum && yIsNum:
+		cmp = cmpFloat(xf, yf)
+	case n.op == "==":
+		return x == y, nil
+	case n.op == "!=":
+		return x != y, nil
+	default:
+		xs, xIsStr := x.(string)
+		ys, yIsStr := y.(string)
+		if !xIsStr || !yIsStr {
+			return nil, fmt.Errorf(
+				"%w: operator %s requires two numbers or two strings, got %q and %q",
+				ErrEval, n.op, format(x), format(y),
+			)
+		}
+		cmp = strings.Compare(xs, ys)
+	}
+	switch n.op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// cmpFloat compares two numbers, returning -1, 0 or 1.
+func cmpFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// binary is one of the binary arithmetic operators.
+type binary struct {
+	op   byte
+	x, y node
+}
+
+func (n *binary) eval(env *Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == '+' {
+		xs, xIsStr := x.(string)
+		ys, yIsStr := y.(string)
+		if xIsStr || yIsStr {
+			if !xIsStr {
+				xs = format(x)
+			}
+			if !yIsStr {
+				ys = format(y)
+			}
+			return xs + ys, nil
+		}
+	}
+	xi, xIsInt := x.(int)
+	yi, yIsInt := y.(int)
+	if xIsInt && yIsInt {
+		switch n.op {
+		case '+':
+			return xi + yi, nil
+		case '-':
+			return xi - yi, nil
+		case '*':
+			return xi * yi, nil
+		case '/', '%':
+			if yi == 0 {
+				return nil, fmt.Errorf("%w: division by zero", ErrEval)
+			}
+			if n.op == '/' {
+				return xi / yi, nil
+			}
+			return xi % yi, nil
+		}
+	}
+	xf, xok := toFloat(x)
+	yf, yok := toFloat(y)
+	if !xok || !yok {
+		return nil, fmt.Errorf(
+			"%w: operator %c requires numbers, got %q and %q",
+			ErrEval, n.op, format(x), format(y),
+		)
+	}
+	switch n.op {
+	case '+':
+		return xf + yf, nil
+	case '-':
+		return xf - yf, nil
+	case '*':
+		return xf * yf, nil
+	case '/':
+		if yf == 0 {
+			return nil, fmt.Errorf("%w: division by zero", ErrEval)
+		}
+		return xf / yf, nil
+	default:
+		return nil, fmt.Errorf(
+			"%w: operator %% requires integers", ErrEval,
+		)
+	}
+}
+
+// normalize returns the supplied variable or fixture state value as a
+// string, an int, a float64 or a bool.
+func normalize(val any) (any, error) {
+	switch val := val.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case int:
+		return val, nil
+	case int8:
+		return int(val), nil
+	case int16:
+		return int(val), nil
+	case int32:
+		return int(val), nil
+	case int64:
+		return int(val), nil
+	case uint:
+		return int(val), nil
+	case uint8:
+		return int(val), nil
+	case uint16:
+		return int(val), nil
+	case uint32:
+		return int(val), nil
+	case uint64:
+		return int(val), nil
+	case float32:
+		return float64(val), nil
+	case float64:
+		return val, nil
+	case bool:
+		return val, nil
+	default:
+		return nil, fmt.Errorf(
+			"%w: unsupported value of type %T", ErrEval, val,
+		)
+	}
+}
+
+// toFloat returns the supplied int or float64 as a float64.
+func toFloat(val any) (float64, bool) {
+	switch val := val.(type) {
+	case int:
+		return float64(val), true
+	case float64:
+		return val, true
+	default:
+		return 0, false
+	}
+}
+
+// format returns the string form of the supplied string, int, float64 or
+// bool.
+func format(val any) string {
+	switch val := val.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,211 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package expr_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/expr"
+)
+
+func testEnv() expr.Env {
+	vars := map[string]any{
+		"PREFIX":  "test",
+		"MINUTES": 5,
+		"RATIO":   0.5,
+		"COUNT":   "3",
+	}
+	return expr.Env{
+		Var: func(name string) (any, error) {
+			if val, found := vars[name]; found {
+				return val, nil
+			}
+			return nil, fmt.Errorf("%w: %s", expr.ErrUnknownVariable, name)
+		},
+		Now: func() time.Time {
+			return time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+		},
+		Getenv: func(name string) string {
+			if name == "CI" {
+				return "true"
+			}
+			return ""
+		},
+		State: func(fixture, key string) (any, error) {
+			if fixture == "db" && key == "ready" {
+				return true, nil
+			}
+			return nil, fmt.Errorf("%w: no state %s", expr.ErrEval, key)
+		},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src string
+		exp any
+	}{
+		{`42`, 42},
+		{`1.5`, 1.5},
+		{`"a\tb"`, "a\tb"},
+		{`'raw\n'`, `raw\n`},
+		{`1 + 2 * 3`, 7},
+		{`(1 + 2) * 3`, 9},
+		{`-2 * -3`, 6},
+		{`7 / 2`, 3},
+		{`7 % 4`, 3},
+		{`7 / 2.0`, 3.5},
+		{`vars.MINUTES * 60`, 300},
+		{`vars.RATIO * 4`, 2.0},
+		{`vars.PREFIX + "-" + vars.MINUTES`, "test-5"},
+		{`"v" + 1.25`, "v1.25"},
+		{`int(vars.COUNT) + 1`, 4},
+		{`string(1 + 1) + "x"`, "2x"},
+		{`now()`, "2024-03-01T12:30:00Z"},
+		{`now("20060102")`, "20240301"},
+		{`unix() % 60`, 0},
+		{`true`, true},
+		{`1 < 2 && 2 <= 2`, true},
+		{`vars.MINUTES == 5.0`, true},
+		{`vars.MINUTES != 5`, false},
+		{`"abc" < "abd"`, true},
+		{`vars.COUNT == 3`, false},
+		{`!(1 > 2) || 1 / 0 == 0`, true},
+		{`false && 1 / 0 == 0`, false},
+		{`env.CI == "true" && env.UNSET == ""`, true},
+		{`state("db", "ready")`, true},
+		{`os() != "" && arch() != ""`, true},
+		{`"ready: " + true`, "ready: true"},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			e, err := expr.Parse(test.src)
+			require.Nil(t, err)
+			val, err := e.Eval(testEnv())
+			require.Nil(t, err)
+			assert.Equal(t, test.exp, val)
+		})
+	}
+}
+
+func TestEvalRandom(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	e, err := expr.Parse(`"bucket-" + uuid()`)
+	require.Nil(err)
+	val, err := e.Eval(expr.Env{})
+	require.Nil(err)
+	require.IsType("", val)
+	_, err = uuid.Parse(val.(string)[len("bucket-"):])
+	assert.Nil(err)
+
+	e, err = expr.Parse(`randomString(12)`)
+	require.Nil(err)
+	val, err = e.Eval(expr.Env{})
+	require.Nil(err)
+	assert.Regexp(`^[a-z0-9]{12}$`, val)
+
+	e, err = expr.Parse(`random(10)`)
+	require.Nil(err)
+	val, err = e.Eval(expr.Env{})
+	require.Nil(err)
+	assert.GreaterOrEqual(val, 0)
+	assert.Less(val, 10)
+}
+
+func TestEvalBool(t *testing.T) {
+	e, err := expr.Parse(`vars.MINUTES > 1`)
+	require.Nil(t, err)
+	ok, err := e.EvalBool(testEnv())
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	e, err = expr.Parse(`vars.MINUTES + 1`)
+	require.Nil(t, err)
+	_, err = e.EvalBool(testEnv())
+	require.ErrorIs(t, err, expr.ErrEval)
+	require.ErrorContains(t, err, "expected a bool result")
+}
+
+func TestVars(t *testing.T) {
+	e, err := expr.Parse(`vars.A + vars.B * vars.A`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"A", "B"}, e.Vars())
+}
+
+func TestIsConstant(t *testing.T) {
+	tests := map[string]bool{
+		`true`:                      true,
+		`!(1 + 2 > 4) && "a" < "b"`: true,
+		`-1.5 * 2 == -3`:            true,
+		`vars.A == 1`:               false,
+		`env.CI == "true"`:          false,
+		`now() != ""`:               false,
+		`true || vars.A`:            false,
+	}
+	for src, exp := range tests {
+		e, err := expr.Parse(src)
+		require.Nil(t, err, src)
+		assert.Equal(t, exp, e.IsConstant(), src)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err error
+		msg string
+	}{
+		{`1 +`, expr.ErrSyntax, "position 4"},
+		{`(1 + 2`, expr.ErrSyntax, `expected ")"`},
+		{`"abc`, expr.ErrSyntax, "unterminated string"},
+		{`PREFIX + "x"`, expr.ErrSyntax, "vars.PREFIX"},
+		{`1 $ 2`, expr.ErrSyntax, "unexpected character"},
+		{`1 = 2`, expr.ErrSyntax, "unexpected character"},
+		{`uuid(1)`, expr.ErrSyntax, "takes 0 arguments"},
+		{`shout("x")`, expr.ErrUnknownFunction, "shout"},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			_, err := expr.Parse(test.src)
+			require.ErrorIs(t, err, test.err)
+			require.ErrorContains(t, err, test.msg)
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err error
+	}{
+		{`1 / 0`, expr.ErrEval},
+		{`1.5 % 2`, expr.ErrEval},
+		{`"a" * 2`, expr.ErrEval},
+		{`-"a"`, expr.ErrEval},
+		{`random(0)`, expr.ErrEval},
+		{`int("x")`, expr.ErrEval},
+		{`vars.MISSING`, expr.ErrUnknownVariable},
+		{`"a" < 1`, expr.ErrEval},
+		{`1 && true`, expr.ErrEval},
+		{`!"a"`, expr.ErrEval},
+		{`state("db", "unknown")`, expr.ErrEval},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			e, err := expr.Parse(test.src)
+			require.Nil(t, err)
+			_, err = e.Eval(testEnv())
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package expr
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// randomStringChars are the characters of the strings returned by the
+// randomString() function. Only lowercase letters and digits are used so
+// that the strings are valid in most resource names.
+const randomStringChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// function is a built-in function.
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(env *Env, args []any) (any, error)
+}
+
+// arity describes the number of arguments the function takes.
+func (f *function) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// functions are the built-in functions, keyed by name.
+var functions = map[string]*function{
+	// now() returns the current time in RFC 3339 format, or in the layout
+	// of its optional argument, e.g. `now("20060102")`.
+	"now": {
+		minArgs: 0,
+		maxArgs: 1,
+		call: func(env *Env, args []any) (any, error) {
+			layout := time.RFC3339
+			if len(args) == 1 {
+				s, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: layout must be a string", ErrEval)
+				}
+				layout = s
+			}
+			return env.Now().UTC().Format(layout), nil
+		},
+	},
+	// unix() returns the current time as seconds since the Unix epoch.
+	"unix": {
+		call: func(env *Env, _ []any) (any, error) {
+			return int(env.Now().Unix()), nil
+		},
+	},
+	// uuid() returns a random UUID.
+	"uuid": {
+		call: func(_ *Env, _ []any) (any, error) {
+			return uuid.NewString(), nil
+		},
+	},
+	// random(n) returns a random integer in [0, n).
+	"random": {
+		minArgs: 1,
+		maxArgs: 1,
+		call: func(_ *Env, args []any) (any, error) {
+			n, ok := args[0].(int)
+			if !ok || n <= 0 {
+				return nil, fmt.Errorf("%w: n must be a positive integer", ErrEval)
+			}
+			return rand.IntN(n), nil
+		},
+	},
+	// randomString(n) returns a random string of n lowercase letters and
+	// digits.
+	"randomString": {
+		minArgs: 1,
+		maxArgs: 1,
+		call: func(_ *Env, args []any) (any, error) {
+			n, ok := args[0].(int)
+			if !ok || n <= 0 {
+				return nil, fmt.Errorf("%w: n must be a positive integer", ErrEval)
+			}
+			var b strings.Builder
+			for range n {
+				b.WriteByte(randomStringChars[rand.IntN(len(randomStringChars))])
+			}
+			return b.String(), nil
+		},
+	},
+	// int(x) converts a string or number to an integer, truncating floats.
+	"int": {
+		minArgs: 1,
+		maxArgs: 1,
+		call: func(_ *Env, args []any) (any, error) {
+			switch val := args[0].(type) {
+			case int:
+				return val, nil
+			case float64:
+				return int(val), nil
+			case bool:
+				return nil, fmt.Errorf("%w: cannot convert a bool to an int", ErrEval)
+			default:
+				s := strings.TrimSpace(val.(string))
+				i, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %q is not an integer", ErrEval, s)
+				}
+				return i, nil
+			}
+		},
+	},
+	// os() returns the operating system gdt is running on, e.g. "linux".
+	"os": {
+		call: func(_ *Env, _ []any) (any, error) {
+			return runtime.GOOS, nil
+		},
+	},
+	// arch() returns the architecture gdt is running on, e.g. "amd64".
+	"arch": {
+		call: func(_ *Env, _ []any) (any, error) {
+			return runtime.GOARCH, nil
+		},
+	},
+	// state(fixture, key) returns the state at a key of a fixture.
+	"state": {
+		minArgs: 2,
+		maxArgs: 2,
+		call: func(env *Env, args []any) (any, error) {
+			fixture, ok := args[0].(string)
+			key, ok2 := args[1].(string)
+			if !ok || !ok2 {
+				return nil, fmt.Errorf(
+					"%w: fixture and key must be strings", ErrEval,
+				)
+			}
+			if env.State == nil {
+				return nil, fmt.Errorf("%w: no fixture state", ErrEval)
+			}
+			val, err := env.State(fixture, key)
+			if err != nil {
+				return nil, err
+			}
+			return normalize(val)
+		},
+	},
+	// string(x) converts a number or bool to a string.
+	"string": {
+		minArgs: 1,
+		maxArgs: 1,
+		call: func(_ *Env, args []any) (any, error) {
+			return format(args[0]), nil
+		},
+	},
+}
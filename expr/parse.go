@@ -0,0 +1,394 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package expr
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind is the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokInt
+	tokFloat
+	tokString
+	tokIdent
+	tokPunct
+)
+
+// token is a lexical token of an expression.
+type token struct {
+	kind tokenKind
+	// text is the source text of the token, or the unquoted value of a
+	// string token.
+	text string
+	// pos is the 1-based position of the token in the expression source.
+	pos int
+}
+
+// String returns a description of the token for error messages.
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// parser is a recursive descent parser of expressions. The grammar is:
+//
+//	expr    = and { "||" and }
+//	and     = cmp { "&&" cmp }
+//	cmp     = sum [ ("==" | "!=" | "<" | "<=" | ">" | ">=") sum ]
+//	sum     = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("-" | "!") unary | primary
+//	primary = number | string | "true" | "false"
+//	        | ("vars" | "env") "." ident
+//	        | ident "(" [ expr { "," expr } ] ")" | "(" expr ")"
+type parser struct {
+	src string
+	// off is the offset in src of the next token to lex.
+	off int
+	tok token
+	// vars are the names of the variables referred to so far.
+	vars []string
+}
+
+// errorf returns an ErrSyntax error at the position of the current token.
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf(
+		"%w at position %d: %s",
+		ErrSyntax, p.tok.pos, fmt.Sprintf(format, args...),
+	)
+}
+
+// next lexes the next token into p.tok.
+func (p *parser) next() error {
+	for p.off < len(p.src) && unicode.IsSpace(rune(p.src[p.off])) {
+		p.off++
+	}
+	start := p.off
+	p.tok = token{pos: start + 1}
+	if p.off >= len(p.src) {
+		p.tok.kind = tokEOF
+		return nil
+	}
+	c := p.src[p.off]
+	switch {
+	case isDigit(c):
+		p.tok.kind = tokInt
+		for p.off < len(p.src) && (isDigit(p.src[p.off]) || p.src[p.off] == '.') {
+			if p.src[p.off] == '.' {
+				p.tok.kind = tokFloat
+			}
+			p.off++
+		}
+		p.tok.text = p.src[start:p.off]
+	case isIdentStart(c):
+		p.tok.kind = tokIdent
+		for p.off < len(p.src) && (isIdentStart(p.src[p.off]) || isDigit(p.src[p.off])) {
+			p.off++
+		}
+		p.tok.text = p.src[start:p.off]
+	case c == '"':
+		end := p.off + 1
+		for ; end < len(p.src) && p.src[end] != '"'; end++ {
+			if p.src[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(p.src) {
+			return p.errorf("unterminated string")
+		}
+		s, err := strconv.Unquote(p.src[start : end+1])
+		if err != nil {
+			return p.errorf("invalid string %s", p.src[start:end+1])
+		}
+		p.tok.kind = tokString
+		p.tok.text = s
+		p.off = end + 1
+	case c == '\'':
+		end := strings.IndexByte(p.src[p.off+1:], '\'')
+		if end < 0 {
+			return p.errorf("unterminated string")
+		}
+		p.tok.kind = tokString
+		p.tok.text = p.src[p.off+1 : p.off+1+end]
+		p.off += end + 2
+	case strings.IndexByte("=!<>&|", c) >= 0:
+		p.tok.kind = tokPunct
+		if p.off+1 < len(p.src) {
+			if op := p.src[p.off : p.off+2]; slices.Contains(twoCharOps, op) {
+				p.tok.text = op
+				p.off += 2
+				return nil
+			}
+		}
+		if strings.IndexByte("!<>", c) < 0 {
+			p.tok.text = string(c)
+			return p.errorf("unexpected character %q", c)
+		}
+		p.tok.text = string(c)
+		p.off++
+	case strings.IndexByte("+-*/%(),.", c) >= 0:
+		p.tok.kind = tokPunct
+		p.tok.text = string(c)
+		p.off++
+	default:
+		p.tok.text = string(c)
+		return p.errorf("unexpected character %q", c)
+	}
+	return nil
+}
+
+// twoCharOps are the operators that are two characters long.
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+// isPunct returns true if the current token is the supplied punctuation.
+func (p *parser) isPunct(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.text == punct
+}
+
+// expect consumes the supplied punctuation or returns an error.
+func (p *parser) expect(punct string) error {
+	if !p.isPunct(punct) {
+		return p.errorf("expected %q but found %s", punct, p.tok)
+	}
+	return p.next()
+}
+
+func (p *parser) parseExpr() (node, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("||") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &logical{op: "||", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	x, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("&&") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		x = &logical{op: "&&", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	x, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.isPunct(op) {
+			continue
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return &compare{op: op, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	x, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("+") || p.isPunct("-") {
+		op := p.tok.text[0]
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("*") || p.isPunct("/") || p.isPunct("%") {
+		op := p.tok.text[0]
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isPunct("-") || p.isPunct("!") {
+		op := p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "!" {
+			return &not{x: x}, nil
+		}
+		return &negate{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokInt:
+		val, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return nil, p.errorf("invalid integer %s", tok.text)
+		}
+		return &literal{val: val}, p.next()
+	case tokFloat:
+		val, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %s", tok.text)
+		}
+		return &literal{val: val}, p.next()
+	case tokString:
+		return &literal{val: tok.text}, p.next()
+	case tokIdent:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if (tok.text == "vars" || tok.text == "env") && p.isPunct(".") {
+			return p.parseRef(tok.text)
+		}
+		if tok.text == "true" || tok.text == "false" {
+			return &literal{val: tok.text == "true"}, nil
+		}
+		if p.isPunct("(") {
+			return p.parseCall(tok)
+		}
+		p.tok = tok
+		return nil, p.errorf(
+			"unexpected identifier %s, variables are referred to as vars.%s",
+			tok.text, tok.text,
+		)
+	case tokPunct:
+		if tok.text == "(" {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+// parseRef parses the remainder of a reference to a variable, after `vars`,
+// or to an environment variable, after `env`.
+func (p *parser) parseRef(ns string) (node, error) {
+	if err := p.expect("."); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokIdent {
+		return nil, p.errorf("expected variable name but found %s", p.tok)
+	}
+	name := p.tok.text
+	if ns == "env" {
+		return &envRef{name: name}, p.next()
+	}
+	if !slices.Contains(p.vars, name) {
+		p.vars = append(p.vars, name)
+	}
+	return &varRef{name: name}, p.next()
+}
+
+// parseCall parses the arguments of a call to the function named by the
+// supplied identifier token.
+func (p *parser) parseCall(ident token) (node, error) {
+	fn, found := functions[ident.text]
+	if !found {
+		return nil, fmt.Errorf(
+			"%w at position %d: %s", ErrUnknownFunction, ident.pos, ident.text,
+		)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	c := &call{name: ident.text, fn: fn}
+	for !p.isPunct(")") {
+		if len(c.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+	}
+	if len(c.args) < fn.minArgs || len(c.args) > fn.maxArgs {
+		return nil, p.errorf(
+			"%s() takes %s but was called with %d",
+			ident.text, fn.arity(), len(c.args),
+		)
+	}
+	return c, p.next()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
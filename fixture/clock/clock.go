@@ -0,0 +1,208 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package clock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+)
+
+const (
+	// StateNow is the state key for the clock's current time, formatted as
+	// RFC 3339. Setting it moves the clock to the supplied time.
+	StateNow = "now"
+	// StateAdvance is the state key that advances the clock by the supplied
+	// duration when set, e.g. `set: {clock: {advance: 1h}}`.
+	StateAdvance = "advance"
+)
+
+var (
+	// ErrInvalidState indicates that a state path or value supplied to
+	// SetState is not understood by the clock.
+	ErrInvalidState = errors.New("invalid clock state")
+)
+
+// InvalidState returns an ErrInvalidState for the supplied state path and
+// value.
+func InvalidState(path string, value interface{}) error {
+	return fmt.Errorf("%w: %s: %v", ErrInvalidState, path, value)
+}
+
+// waiter is a channel waiting for the clock to reach a time.
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// clockFixture is an api.Clock that only moves when it is advanced
+type clockFixture struct {
+	sync.Mutex
+	start   time.Time
+	now     time.Time
+	waiters []waiter
+}
+
+// Start sets the clock to its start time, or to the current system time if
+// the clock has no start time.
+func (f *clockFixture) Start(_ context.Context) error {
+	start := f.start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	f.Set(start)
+	return nil
+}
+
+// Stop releases anything waiting on the clock
+func (f *clockFixture) Stop(_ context.Context) {
+	f.Lock()
+	defer f.Unlock()
+	for _, w := range f.waiters {
+		w.ch <- f.now
+	}
+	f.waiters = nil
+}
+
+// HasState returns true if the supplied key is StateNow
+func (f *clockFixture) HasState(key string) bool {
+	return strings.ToLower(key) == StateNow
+}
+
+// State returns the clock's current time for StateNow
+func (f *clockFixture) State(key string) interface{} {
+	if !f.HasState(key) {
+		return nil
+	}
+	return f.Now().Format(time.RFC3339Nano)
+}
+
+// SetState advances the clock by a duration for StateAdvance or moves the
+// clock to an RFC 3339 time for StateNow
+func (f *clockFixture) SetState(path string, value interface{}) error {
+	switch strings.ToLower(path) {
+	case StateAdvance:
+		d, err := time.ParseDuration(fmt.Sprint(value))
+		if err != nil || d < 0 {
+			return InvalidState(path, value)
+		}
+		f.Advance(d)
+	case StateNow:
+		t, ok := value.(time.Time)
+		if !ok {
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, fmt.Sprint(value))
+			if err != nil {
+				return InvalidState(path, value)
+			}
+		}
+		f.Set(t)
+	default:
+		return InvalidState(path, value)
+	}
+	return nil
+}
+
+// Clock returns the fixture itself, which the test scenario stores in the
+// context while the fixture is started
+func (f *clockFixture) Clock() api.Clock {
+	return f
+}
+
+// Now returns the clock's current time
+func (f *clockFixture) Now() time.Time {
+	f.Lock()
+	defer f.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the clock's time once the clock has
+// been advanced by at least the supplied duration
+func (f *clockFixture) After(d time.Duration) <-chan time.Time {
+	f.Lock()
+	defer f.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, waiter{at: f.now.Add(d), ch: ch})
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].at.Before(f.waiters[j].at)
+	})
+	return ch
+}
+
+// Sleep blocks until the clock has been advanced by at least the supplied
+// duration
+func (f *clockFixture) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Advance moves the clock forward by the supplied duration
+func (f *clockFixture) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to the supplied time, waking anything waiting for a
+// time at or before it
+func (f *clockFixture) Set(t time.Time) {
+	f.Lock()
+	defer f.Unlock()
+	f.now = t
+	fired := 0
+	for _, w := range f.waiters {
+		if w.at.After(t) {
+			break
+		}
+		w.ch <- t
+		fired++
+	}
+	f.waiters = f.waiters[fired:]
+}
+
+// clockFixtureModifier sets some value on the clock fixture
+type clockFixtureModifier func(f *clockFixture)
+
+// WithStart sets the time the clock is set to when the fixture starts
+func WithStart(start time.Time) clockFixtureModifier {
+	return func(f *clockFixture) {
+		f.start = start
+	}
+}
+
+// Fixture is a fixture that supplies a controllable api.Clock to the test
+// scenario
+type Fixture interface {
+	api.Fixture
+	api.Clock
+	api.ClockProvider
+	api.StateSetter
+	// Advance moves the clock forward by the supplied duration
+	Advance(time.Duration)
+	// Set moves the clock to the supplied time
+	Set(time.Time)
+}
+
+// New returns a new clock Fixture. While the fixture is started, the test
+// scenario's context holds the fixture's clock, which only moves when it is
+// advanced with Advance or Set or with the `set` field of a test spec, e.g.
+//
+//	set:
+//	  clock:
+//	    advance: 1h
+func New(mods ...clockFixtureModifier) Fixture {
+	f := &clockFixture{}
+	for _, mod := range mods {
+		mod(f)
+	}
+	return f
+}
@@ -0,0 +1,85 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package clock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/api"
+	clockfix "github.com/gdt-dev/core/fixture/clock"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestAdvance(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	f := clockfix.New(clockfix.WithStart(start))
+	require.Nil(f.Start(context.TODO()))
+	defer f.Stop(context.TODO())
+	assert.Equal(start, f.Now())
+	assert.Same(f, f.Clock())
+
+	ch := f.After(time.Minute)
+	f.Advance(30 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("clock fired before being advanced far enough")
+	default:
+	}
+	f.Advance(30 * time.Second)
+	select {
+	case got := <-ch:
+		assert.Equal(start.Add(time.Minute), got)
+	default:
+		t.Fatal("clock did not fire after being advanced")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Hour)
+		close(done)
+	}()
+	require.Eventually(func() bool {
+		f.Advance(time.Hour)
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func TestSetState(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	f := clockfix.New(clockfix.WithStart(start))
+	require.Implements((*api.StateSetter)(nil), f)
+	require.Nil(f.Start(context.TODO()))
+
+	assert.True(f.HasState(clockfix.StateNow))
+	assert.Equal("2025-01-01T00:00:00Z", f.State(clockfix.StateNow))
+
+	require.Nil(f.SetState(clockfix.StateAdvance, "90m"))
+	assert.Equal("2025-01-01T01:30:00Z", f.State(clockfix.StateNow))
+
+	require.Nil(f.SetState(clockfix.StateNow, "2030-06-01T12:00:00Z"))
+	assert.Equal(time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC), f.Now())
+
+	err := f.SetState(clockfix.StateAdvance, "soon")
+	assert.ErrorIs(err, clockfix.ErrInvalidState)
+	err = f.SetState(clockfix.StateNow, "tomorrow")
+	assert.ErrorIs(err, clockfix.ErrInvalidState)
+	err = f.SetState("rewind", "1h")
+	assert.ErrorIs(err, clockfix.ErrInvalidState)
+}
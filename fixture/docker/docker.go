@@ -0,0 +1,403 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/debug"
+)
+
+const (
+	// StateID is the state key for the ID of the started container.
+	StateID = "id"
+	// StateLogs is the state key for the combined stdout and stderr that the
+	// container has written.
+	StateLogs = "logs"
+	// statePrefixAddr is prepended to a container port, e.g. "8080/tcp", for
+	// the state key of the host:port address it is published on.
+	statePrefixAddr = "addr."
+	// statePrefixPort is prepended to a container port, e.g. "8080/tcp", for
+	// the state key of the host port number it is published on.
+	statePrefixPort = "port."
+	// publishHost is the host interface that container ports are published
+	// on.
+	publishHost = "127.0.0.1"
+)
+
+var (
+	// DefaultReadyTimeout is how long Start waits for the container to become
+	// ready.
+	DefaultReadyTimeout = 60 * time.Second
+)
+
+const (
+	// probeInterval is how often the readiness probes are checked.
+	probeInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrDocker indicates that a docker command failed.
+	ErrDocker = errors.New("docker command failed")
+	// ErrNotReady indicates that the container did not become ready before
+	// the ready timeout.
+	ErrNotReady = errors.New("container not ready")
+	// ErrExited indicates that the container stopped running.
+	ErrExited = errors.New("container exited")
+)
+
+// DockerFailed returns an ErrDocker for the supplied docker arguments and
+// command output.
+func DockerFailed(args []string, err error, output string) error {
+	return fmt.Errorf(
+		"%w: docker %s: %s: %s",
+		ErrDocker, strings.Join(args, " "), err, strings.TrimSpace(output),
+	)
+}
+
+// NotReady returns an ErrNotReady for the supplied timeout.
+func NotReady(timeout time.Duration) error {
+	return fmt.Errorf("%w: not ready within %s", ErrNotReady, timeout)
+}
+
+// Exited returns an ErrExited with the supplied container logs.
+func Exited(logs string) error {
+	return fmt.Errorf("%w: %s", ErrExited, strings.TrimSpace(logs))
+}
+
+// probe returns true when the container is ready.
+type probe func(ctx context.Context, f *dockerFixture, logs string) bool
+
+// dockerFixture runs a container with the docker CLI
+type dockerFixture struct {
+	docker       string
+	image        string
+	name         string
+	args         []string
+	env          []string
+	volumes      []string
+	ports        []string
+	probes       []probe
+	readyTimeout time.Duration
+	id           string
+	// published maps a container port to the host:port it is published on.
+	published map[string]string
+}
+
+// run runs docker with the supplied arguments and returns its stdout.
+func (f *dockerFixture) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, f.docker, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", DockerFailed(args, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// Start runs the container, detached, and waits until all readiness probes
+// pass. The container is removed if it does not become ready before the ready
+// timeout.
+func (f *dockerFixture) Start(ctx context.Context) error {
+	args := []string{"run", "--detach"}
+	if f.name != "" {
+		args = append(args, "--name", f.name)
+	}
+	for _, e := range f.env {
+		args = append(args, "--env", e)
+	}
+	for _, v := range f.volumes {
+		args = append(args, "--volume", v)
+	}
+	for _, p := range f.ports {
+		args = append(args, "--publish", publishHost+"::"+p)
+	}
+	args = append(args, f.image)
+	args = append(args, f.args...)
+	out, err := f.run(ctx, args...)
+	if err != nil {
+		return err
+	}
+	f.id = strings.TrimSpace(out)
+	debug.Printf(ctx, "docker: %s started (id %s)", f.image, f.id)
+	if err := f.start(ctx); err != nil {
+		_ = f.Stop(ctx)
+		return err
+	}
+	debug.Printf(ctx, "docker: %s ready", f.image)
+	return nil
+}
+
+// start looks up the container's published ports and waits for it to become
+// ready.
+func (f *dockerFixture) start(ctx context.Context) error {
+	f.published = map[string]string{}
+	for _, p := range f.ports {
+		out, err := f.run(ctx, "port", f.id, p)
+		if err != nil {
+			return err
+		}
+		// docker prints one line per published address.
+		addr := strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])
+		f.published[p] = addr
+		debug.Printf(ctx, "docker: %s published on %s", p, addr)
+	}
+	return f.waitReady(ctx)
+}
+
+// waitReady waits until all readiness probes pass, the container stops
+// running or the ready timeout elapses.
+func (f *dockerFixture) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, f.readyTimeout)
+	defer cancel()
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for {
+		if err := f.Healthy(ctx); err != nil {
+			if ctx.Err() != nil {
+				// The docker command was killed by the ready timeout.
+				return NotReady(f.readyTimeout)
+			}
+			return err
+		}
+		if f.ready(ctx) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return NotReady(f.readyTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// ready returns true if all readiness probes pass.
+func (f *dockerFixture) ready(ctx context.Context) bool {
+	if len(f.probes) == 0 {
+		return true
+	}
+	logs := f.logs(ctx)
+	for _, p := range f.probes {
+		if !p(ctx, f, logs) {
+			return false
+		}
+	}
+	return true
+}
+
+// logs returns the container's combined stdout and stderr.
+func (f *dockerFixture) logs(ctx context.Context) string {
+	cmd := exec.CommandContext(ctx, f.docker, "logs", f.id)
+	out, _ := cmd.CombinedOutput()
+	return string(out)
+}
+
+// Healthy returns an ErrExited if the container is no longer running,
+// implementing api.HealthChecker
+func (f *dockerFixture) Healthy(ctx context.Context) error {
+	if f.id == "" {
+		return nil
+	}
+	out, err := f.run(ctx, "inspect", "--format", "{{.State.Running}}", f.id)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) != "true" {
+		return Exited(f.logs(ctx))
+	}
+	return nil
+}
+
+// Stop removes the container, returning an ErrDocker if it cannot be removed
+func (f *dockerFixture) Stop(ctx context.Context) error {
+	if f.id == "" {
+		return nil
+	}
+	id := f.id
+	f.id = ""
+	f.published = nil
+	if _, err := f.run(ctx, "rm", "--force", "--volumes", id); err != nil {
+		return err
+	}
+	debug.Printf(ctx, "docker: %s removed (id %s)", f.image, id)
+	return nil
+}
+
+// HasState returns true if the supplied key is StateID, StateLogs or the
+// `addr.<port>` or `port.<port>` of a published container port and the
+// container has been started
+func (f *dockerFixture) HasState(key string) bool {
+	if f.id == "" {
+		return false
+	}
+	key = strings.ToLower(key)
+	switch key {
+	case StateID, StateLogs:
+		return true
+	}
+	_, found := f.publishedFor(key)
+	return found
+}
+
+// publishedFor returns the published host:port for an `addr.<port>` or
+// `port.<port>` state key.
+func (f *dockerFixture) publishedFor(key string) (string, bool) {
+	for _, prefix := range []string{statePrefixAddr, statePrefixPort} {
+		if p, found := strings.CutPrefix(key, prefix); found {
+			addr, found := f.published[p]
+			return addr, found
+		}
+	}
+	return "", false
+}
+
+// State returns the container ID for StateID, the container's logs for
+// StateLogs, the host:port address a container port is published on for
+// `addr.<port>` and the host port number for `port.<port>`
+func (f *dockerFixture) State(key string) interface{} {
+	if !f.HasState(key) {
+		return nil
+	}
+	key = strings.ToLower(key)
+	switch key {
+	case StateID:
+		return f.id
+	case StateLogs:
+		return f.logs(context.TODO())
+	}
+	addr, _ := f.publishedFor(key)
+	if strings.HasPrefix(key, statePrefixPort) {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil
+		}
+		n, _ := strconv.Atoi(port)
+		return n
+	}
+	return addr
+}
+
+// dockerFixtureModifier sets some value on the docker fixture
+type dockerFixtureModifier func(f *dockerFixture)
+
+// WithName sets the name of the container
+func WithName(name string) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.name = name
+	}
+}
+
+// WithArgs sets the arguments passed to the container's entrypoint
+func WithArgs(args ...string) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.args = args
+	}
+}
+
+// WithEnv adds environment variables, in "KEY=value" form, to the container's
+// environment
+func WithEnv(env ...string) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.env = append(f.env, env...)
+	}
+}
+
+// WithVolumes adds volumes, in docker's "source:target[:options]" form, to
+// the container
+func WithVolumes(volumes ...string) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.volumes = append(f.volumes, volumes...)
+	}
+}
+
+// WithPorts publishes the supplied container ports, e.g. "8080" or "53/udp",
+// on free ports of the host's loopback interface
+func WithPorts(ports ...string) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		for _, p := range ports {
+			f.ports = append(f.ports, strings.ToLower(p))
+		}
+	}
+}
+
+// WithReadyTCP adds a readiness probe that passes once a TCP connection to
+// the host port that the supplied container port is published on succeeds.
+// The container port must also be supplied to WithPorts.
+func WithReadyTCP(port string) dockerFixtureModifier {
+	port = strings.ToLower(port)
+	return func(f *dockerFixture) {
+		f.probes = append(f.probes, func(
+			ctx context.Context, f *dockerFixture, _ string,
+		) bool {
+			addr, found := f.published[port]
+			if !found {
+				return false
+			}
+			d := net.Dialer{Timeout: probeInterval}
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return false
+			}
+			_ = conn.Close()
+			return true
+		})
+	}
+}
+
+// WithReadyLog adds a readiness probe that passes once the container's logs
+// match the supplied regular expression
+func WithReadyLog(re *regexp.Regexp) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.probes = append(f.probes, func(
+			_ context.Context, _ *dockerFixture, logs string,
+		) bool {
+			return re.MatchString(logs)
+		})
+	}
+}
+
+// WithReadyTimeout sets how long Start waits for the container to become
+// ready
+func WithReadyTimeout(timeout time.Duration) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.readyTimeout = timeout
+	}
+}
+
+// WithDocker sets the path of the docker CLI. Defaults to "docker", found in
+// the PATH. Any CLI accepting docker's arguments, e.g. podman, may be used.
+func WithDocker(path string) dockerFixtureModifier {
+	return func(f *dockerFixture) {
+		f.docker = path
+	}
+}
+
+// New returns a new api.FixtureV2 that runs a container from the supplied
+// image when started and removes the container when stopped. Register it with
+// `gdtcontext.RegisterFixtureV2` so that a failure to remove the container is
+// reported. If no readiness probes are supplied, the container is ready as
+// soon as it is running.
+func New(image string, mods ...dockerFixtureModifier) api.FixtureV2 {
+	f := &dockerFixture{
+		docker:       "docker",
+		image:        image,
+		readyTimeout: DefaultReadyTimeout,
+	}
+	for _, mod := range mods {
+		mod(f)
+	}
+	return f
+}
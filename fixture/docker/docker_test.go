@@ -0,0 +1,177 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package docker_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/api"
+	dockerfix "github.com/gdt-dev/core/fixture/docker"
+)
+
+const fakeContainerID = "c0ffee"
+
+// TestMain makes the test binary act as a fake docker CLI when
+// FAKE_DOCKER_DIR is set. The fake records each command line in the
+// directory's `calls` file and prints the address in FAKE_DOCKER_ADDR for
+// `docker port`. The container is not running once the directory contains an
+// `exited` file.
+func TestMain(m *testing.M) {
+	dir := os.Getenv("FAKE_DOCKER_DIR")
+	if dir == "" {
+		os.Exit(m.Run())
+	}
+	args := os.Args[1:]
+	calls, err := os.OpenFile(
+		filepath.Join(dir, "calls"),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644,
+	)
+	if err != nil {
+		os.Exit(2)
+	}
+	fmt.Fprintln(calls, strings.Join(args, " "))
+	_ = calls.Close()
+	switch args[0] {
+	case "run":
+		fmt.Println(fakeContainerID)
+	case "port":
+		fmt.Println(os.Getenv("FAKE_DOCKER_ADDR"))
+	case "logs":
+		fmt.Println("server listening")
+	case "inspect":
+		if _, err := os.Stat(filepath.Join(dir, "exited")); err == nil {
+			fmt.Println("false")
+		} else {
+			fmt.Println("true")
+		}
+	case "rm":
+		if os.Getenv("FAKE_DOCKER_RM_FAIL") != "" {
+			fmt.Fprintln(os.Stderr, "no such container")
+			os.Exit(1)
+		}
+	}
+	os.Exit(0)
+}
+
+// fakeDocker configures the test binary as the fake docker CLI and returns
+// the directory recording its calls.
+func fakeDocker(t *testing.T, addr string) string {
+	dir := t.TempDir()
+	t.Setenv("FAKE_DOCKER_DIR", dir)
+	t.Setenv("FAKE_DOCKER_ADDR", addr)
+	return dir
+}
+
+// calls returns the command lines the fake docker CLI was called with.
+func calls(t *testing.T, dir string) []string {
+	b, err := os.ReadFile(filepath.Join(dir, "calls"))
+	require.Nil(t, err)
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+func TestStartStop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(err)
+	defer l.Close()
+	addr := l.Addr().String()
+	dir := fakeDocker(t, addr)
+
+	f := dockerfix.New(
+		"nginx:1.27",
+		dockerfix.WithDocker(os.Args[0]),
+		dockerfix.WithName("web"),
+		dockerfix.WithEnv("MODE=test"),
+		dockerfix.WithVolumes("/tmp/site:/usr/share/nginx/html:ro"),
+		dockerfix.WithPorts("80/tcp"),
+		dockerfix.WithReadyTCP("80/tcp"),
+		dockerfix.WithReadyLog(regexp.MustCompile(`listening`)),
+		dockerfix.WithReadyTimeout(5*time.Second),
+	)
+	assert.False(f.HasState(dockerfix.StateID))
+
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+
+	assert.Equal(fakeContainerID, f.State(dockerfix.StateID))
+	assert.Equal(addr, f.State("addr.80/tcp"))
+	assert.Equal(l.Addr().(*net.TCPAddr).Port, f.State("port.80/tcp"))
+	assert.Contains(f.State(dockerfix.StateLogs), "server listening")
+	assert.Nil(f.(api.HealthChecker).Healthy(ctx))
+
+	require.Nil(f.Stop(ctx))
+	assert.False(f.HasState(dockerfix.StateID))
+
+	got := calls(t, dir)
+	assert.Equal(
+		"run --detach --name web --env MODE=test "+
+			"--volume /tmp/site:/usr/share/nginx/html:ro "+
+			"--publish 127.0.0.1::80/tcp nginx:1.27",
+		got[0],
+	)
+	assert.Equal("port "+fakeContainerID+" 80/tcp", got[1])
+	assert.Equal("rm --force --volumes "+fakeContainerID, got[len(got)-1])
+}
+
+func TestExited(t *testing.T) {
+	require := require.New(t)
+
+	dir := fakeDocker(t, "")
+	require.Nil(os.WriteFile(filepath.Join(dir, "exited"), nil, 0o644))
+
+	f := dockerfix.New(
+		"busybox",
+		dockerfix.WithDocker(os.Args[0]),
+		dockerfix.WithReadyTimeout(5*time.Second),
+	)
+	err := f.Start(context.TODO())
+	require.ErrorIs(err, dockerfix.ErrExited)
+	require.ErrorContains(err, "server listening")
+
+	// The container that did not become ready was removed.
+	got := calls(t, dir)
+	require.Equal("rm --force --volumes "+fakeContainerID, got[len(got)-1])
+}
+
+func TestNotReady(t *testing.T) {
+	require := require.New(t)
+
+	fakeDocker(t, "")
+	f := dockerfix.New(
+		"busybox",
+		dockerfix.WithDocker(os.Args[0]),
+		dockerfix.WithReadyLog(regexp.MustCompile(`never logged`)),
+		dockerfix.WithReadyTimeout(300*time.Millisecond),
+	)
+	err := f.Start(context.TODO())
+	require.ErrorIs(err, dockerfix.ErrNotReady)
+}
+
+func TestStopError(t *testing.T) {
+	require := require.New(t)
+
+	fakeDocker(t, "")
+	t.Setenv("FAKE_DOCKER_RM_FAIL", "1")
+	f := dockerfix.New("busybox", dockerfix.WithDocker(os.Args[0]))
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+
+	err := f.Stop(ctx)
+	require.ErrorIs(err, dockerfix.ErrDocker)
+	require.ErrorContains(err, "no such container")
+}
@@ -0,0 +1,165 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+)
+
+const (
+	// StateRoot is the state key for the absolute path of the directory that
+	// the file tree is materialized under.
+	StateRoot = "root"
+	// defaultFileMode is the permission bits of a File with no Mode.
+	defaultFileMode iofs.FileMode = 0o644
+	// defaultDirMode is the permission bits of a directory with no Mode.
+	defaultDirMode iofs.FileMode = 0o755
+)
+
+var (
+	// ErrPathNotLocal indicates that a File's path is absolute or refers to a
+	// location outside of the file tree's root directory.
+	ErrPathNotLocal = errors.New("file path is not within the file tree")
+)
+
+// PathNotLocal returns an ErrPathNotLocal for the supplied path.
+func PathNotLocal(path string) error {
+	return fmt.Errorf("%w: %s", ErrPathNotLocal, path)
+}
+
+// File describes a file or directory in a file tree.
+type File struct {
+	// Path is the slash-separated path of the file relative to the root of
+	// the file tree. Parent directories are created as needed.
+	Path string `yaml:"path"`
+	// Content is the content of the file. Ignored for directories.
+	Content string `yaml:"content,omitempty"`
+	// Mode is the permission bits of the file, e.g. 0600. If zero, files are
+	// 0644 and directories are 0755.
+	Mode iofs.FileMode `yaml:"mode,omitempty"`
+	// Dir indicates that the File is a directory.
+	Dir bool `yaml:"dir,omitempty"`
+}
+
+// fsFixture materializes a tree of files under a temporary directory
+type fsFixture struct {
+	files []File
+	root  string
+}
+
+// Start creates a temporary directory and writes the fixture's files under it
+func (f *fsFixture) Start(_ context.Context) error {
+	for _, file := range f.files {
+		if !filepath.IsLocal(filepath.FromSlash(file.Path)) {
+			return PathNotLocal(file.Path)
+		}
+	}
+	root, err := os.MkdirTemp("", "gdt-fs-*")
+	if err != nil {
+		return err
+	}
+	f.root = root
+	if err := f.write(); err != nil {
+		_ = removeAll(root)
+		f.root = ""
+		return err
+	}
+	return nil
+}
+
+// write writes the fixture's files under the root directory. Permissions are
+// set once all files are written, deepest first, so that a directory without
+// write permission can still contain files.
+func (f *fsFixture) write() error {
+	for _, file := range f.files {
+		path := filepath.Join(f.root, filepath.FromSlash(file.Path))
+		if file.Dir {
+			if err := os.MkdirAll(path, defaultDirMode); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), defaultDirMode); err != nil {
+			return err
+		}
+		err := os.WriteFile(path, []byte(file.Content), defaultFileMode)
+		if err != nil {
+			return err
+		}
+	}
+	files := make([]File, len(f.files))
+	copy(files, f.files)
+	sort.SliceStable(files, func(i, j int) bool {
+		return strings.Count(files[i].Path, "/") >
+			strings.Count(files[j].Path, "/")
+	})
+	for _, file := range files {
+		mode := file.Mode
+		if mode == 0 {
+			mode = defaultFileMode
+			if file.Dir {
+				mode = defaultDirMode
+			}
+		}
+		path := filepath.Join(f.root, filepath.FromSlash(file.Path))
+		if err := os.Chmod(path, mode.Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop removes the temporary directory and all files under it
+func (f *fsFixture) Stop(_ context.Context) {
+	if f.root == "" {
+		return
+	}
+	_ = removeAll(f.root)
+	f.root = ""
+}
+
+// removeAll removes the supplied directory and everything under it, first
+// making each directory writable so that its contents can be removed.
+func removeAll(root string) error {
+	_ = filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(path, 0o700)
+		}
+		return nil
+	})
+	return os.RemoveAll(root)
+}
+
+// HasState returns true if the supplied key is StateRoot and the fixture has
+// been started
+func (f *fsFixture) HasState(key string) bool {
+	return strings.ToLower(key) == StateRoot && f.root != ""
+}
+
+// State returns the absolute path of the file tree's root directory for the
+// StateRoot key, otherwise returns nil
+func (f *fsFixture) State(key string) interface{} {
+	if !f.HasState(key) {
+		return nil
+	}
+	return f.root
+}
+
+// New returns a new api.Fixture that, when started, materializes the supplied
+// files under a new temporary directory. The directory's path is available
+// from the fixture's state with the StateRoot key. Stopping the fixture
+// removes the directory.
+func New(files ...File) api.Fixture {
+	return &fsFixture{files: files}
+}
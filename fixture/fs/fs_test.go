@@ -0,0 +1,73 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/gdt-dev/core/api"
+	fsfix "github.com/gdt-dev/core/fixture/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartStop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	f := fsfix.New(
+		fsfix.File{Path: "config/app.yaml", Content: "debug: true\n"},
+		fsfix.File{Path: "secret", Content: "s3cr3t", Mode: 0o600},
+		fsfix.File{Path: "readonly", Dir: true, Mode: 0o555},
+		fsfix.File{Path: "readonly/file", Content: "x", Mode: 0o444},
+	)
+	require.Implements((*api.Fixture)(nil), f)
+	assert.False(f.HasState(fsfix.StateRoot))
+	assert.Nil(f.State(fsfix.StateRoot))
+
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+
+	require.True(f.HasState(fsfix.StateRoot))
+	root, ok := f.State(fsfix.StateRoot).(string)
+	require.True(ok)
+	assert.True(filepath.IsAbs(root))
+
+	b, err := os.ReadFile(filepath.Join(root, "config", "app.yaml"))
+	require.Nil(err)
+	assert.Equal("debug: true\n", string(b))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(root, "secret"))
+		require.Nil(err)
+		assert.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+		info, err = os.Stat(filepath.Join(root, "readonly"))
+		require.Nil(err)
+		assert.True(info.IsDir())
+		assert.Equal(os.FileMode(0o555), info.Mode().Perm())
+	}
+
+	f.Stop(ctx)
+	_, err = os.Stat(root)
+	assert.True(errors.Is(err, os.ErrNotExist))
+	assert.False(f.HasState(fsfix.StateRoot))
+}
+
+func TestPathNotLocal(t *testing.T) {
+	assert := assert.New(t)
+
+	f := fsfix.New(
+		fsfix.File{Path: "../escape", Content: "x"},
+	)
+	err := f.Start(context.TODO())
+	assert.ErrorIs(err, fsfix.ErrPathNotLocal)
+	assert.False(f.HasState(fsfix.StateRoot))
+}
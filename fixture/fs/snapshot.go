@@ -0,0 +1,183 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package fs
+
+import (
+	"context"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/debug"
+)
+
+// snapshotFixture copies a directory's contents aside when started and puts
+// them back when stopped
+type snapshotFixture struct {
+	dir string
+	// snapshot is the temporary directory holding the copy of dir.
+	snapshot string
+}
+
+// Start copies the contents of the directory to a new temporary directory
+func (f *snapshotFixture) Start(ctx context.Context) error {
+	dir, err := filepath.Abs(f.dir)
+	if err != nil {
+		return err
+	}
+	snapshot, err := os.MkdirTemp("", "gdt-snapshot-*")
+	if err != nil {
+		return err
+	}
+	if err := copyTree(dir, snapshot); err != nil {
+		_ = removeAll(snapshot)
+		return err
+	}
+	f.dir = dir
+	f.snapshot = snapshot
+	debug.Printf(ctx, "fs: snapshot of %s saved", dir)
+	return nil
+}
+
+// Stop replaces the directory's contents with the snapshot and removes the
+// snapshot. The directory itself is kept, so processes holding it open are
+// unaffected.
+func (f *snapshotFixture) Stop(ctx context.Context) error {
+	if f.snapshot == "" {
+		return nil
+	}
+	defer func() {
+		_ = removeAll(f.snapshot)
+		f.snapshot = ""
+	}()
+	_ = os.Chmod(f.dir, 0o700)
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := removeAll(filepath.Join(f.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if err := copyTree(f.snapshot, f.dir); err != nil {
+		return err
+	}
+	debug.Printf(ctx, "fs: snapshot of %s restored", f.dir)
+	return nil
+}
+
+// copyTree copies the contents of the src directory into the existing dst
+// directory, preserving permissions, modification times and symbolic links.
+// Permissions and times of directories are set once their contents are
+// copied, deepest first, so that a directory without write permission can
+// still be filled.
+func copyTree(src string, dst string) error {
+	dirs := []string{}
+	err := filepath.WalkDir(src, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, rel)
+			if rel == "." {
+				return nil
+			}
+			return os.Mkdir(target, 0o700)
+		case info.Mode()&iofs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+			return os.Chtimes(target, info.ModTime(), info.ModTime())
+		default:
+			// Sockets, devices and named pipes are not copied.
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for x := len(dirs) - 1; x >= 0; x-- {
+		info, err := os.Stat(filepath.Join(src, dirs[x]))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, dirs[x])
+		if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(target, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the contents of the src file to a new dst file with the
+// supplied permissions.
+func copyFile(src string, dst string, perm iofs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
+
+// HasState returns true if the supplied key is StateRoot and the fixture has
+// been started
+func (f *snapshotFixture) HasState(key string) bool {
+	return strings.ToLower(key) == StateRoot && f.snapshot != ""
+}
+
+// State returns the absolute path of the snapshotted directory for the
+// StateRoot key, otherwise returns nil
+func (f *snapshotFixture) State(key string) interface{} {
+	if !f.HasState(key) {
+		return nil
+	}
+	return f.dir
+}
+
+// Snapshot returns a new api.FixtureV2 that, when started, saves a copy of
+// the contents of the supplied directory and, when stopped, restores the
+// directory's contents from that copy, so that test specs may change the
+// directory freely. The directory's absolute path is available from the
+// fixture's state with the StateRoot key. Register the fixture with
+// `gdtcontext.RegisterFixtureV2` so that a failure to restore the directory
+// is reported.
+func Snapshot(dir string) api.FixtureV2 {
+	return &snapshotFixture{dir: dir}
+}
@@ -0,0 +1,73 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package fs_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fsfix "github.com/gdt-dev/core/fixture/fs"
+)
+
+func TestSnapshot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "app.conf")
+	require.Nil(os.WriteFile(conf, []byte("level=info\n"), 0o600))
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Nil(os.Chtimes(conf, old, old))
+	require.Nil(os.Mkdir(filepath.Join(dir, "conf.d"), 0o755))
+	extra := filepath.Join(dir, "conf.d", "extra.conf")
+	require.Nil(os.WriteFile(extra, []byte("x=1\n"), 0o644))
+	if runtime.GOOS != "windows" {
+		require.Nil(os.Symlink("app.conf", filepath.Join(dir, "current")))
+	}
+
+	f := fsfix.Snapshot(dir)
+	assert.False(f.HasState(fsfix.StateRoot))
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+	assert.Equal(dir, f.State(fsfix.StateRoot))
+
+	// A destructive test rewrites, removes and adds files.
+	require.Nil(os.WriteFile(conf, []byte("level=debug\n"), 0o644))
+	require.Nil(os.RemoveAll(filepath.Join(dir, "conf.d")))
+	require.Nil(os.WriteFile(filepath.Join(dir, "new.conf"), nil, 0o644))
+
+	require.Nil(f.Stop(ctx))
+	assert.False(f.HasState(fsfix.StateRoot))
+
+	content, err := os.ReadFile(conf)
+	require.Nil(err)
+	assert.Equal("level=info\n", string(content))
+	info, err := os.Stat(conf)
+	require.Nil(err)
+	assert.True(old.Equal(info.ModTime()))
+	if runtime.GOOS != "windows" {
+		assert.Equal(os.FileMode(0o600), info.Mode().Perm())
+		link, err := os.Readlink(filepath.Join(dir, "current"))
+		require.Nil(err)
+		assert.Equal("app.conf", link)
+	}
+	content, err = os.ReadFile(extra)
+	require.Nil(err)
+	assert.Equal("x=1\n", string(content))
+	_, err = os.Stat(filepath.Join(dir, "new.conf"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestSnapshotNotStarted(t *testing.T) {
+	f := fsfix.Snapshot(t.TempDir())
+	require.Nil(t, f.Stop(context.TODO()))
+}
@@ -16,6 +16,7 @@ type genericFixture struct {
 	starter func(context.Context) error
 	stopper func(context.Context)
 	state   map[string]interface{}
+	env     map[string]string
 }
 
 // Start sets up any resources the fixture uses
@@ -52,6 +53,21 @@ func (f *genericFixture) State(key string) interface{} {
 	return nil
 }
 
+// SetState sets the fixture's state attribute with the supplied key to the
+// supplied value
+func (f *genericFixture) SetState(key string, value interface{}) error {
+	if f.state == nil {
+		f.state = map[string]interface{}{}
+	}
+	f.state[strings.ToLower(key)] = value
+	return nil
+}
+
+// Env returns the environment variables the fixture publishes
+func (f *genericFixture) Env() map[string]string {
+	return f.env
+}
+
 // genericFixtureModifier sets some value on the test scenario
 type genericFixtureModifier func(s *genericFixture)
 
@@ -76,6 +92,13 @@ func WithState(state map[string]interface{}) genericFixtureModifier {
 	}
 }
 
+// WithEnv allows a map of environment variables to be published by a fixture
+func WithEnv(env map[string]string) genericFixtureModifier {
+	return func(f *genericFixture) {
+		f.env = env
+	}
+}
+
 // New returns a new generic Fixture
 func New(mods ...genericFixtureModifier) api.Fixture {
 	f := &genericFixture{}
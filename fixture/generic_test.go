@@ -8,6 +8,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/gdt-dev/core/api"
 	"github.com/gdt-dev/core/fixture"
 	"github.com/stretchr/testify/assert"
 )
@@ -31,6 +32,19 @@ func TestState(t *testing.T) {
 	assert.False(f.HasState("bar"))
 }
 
+func TestSetState(t *testing.T) {
+	assert := assert.New(t)
+
+	f := fixture.New()
+	setter, ok := f.(api.StateSetter)
+	assert.True(ok)
+
+	assert.False(f.HasState("foo"))
+	assert.Nil(setter.SetState("Foo", "bar"))
+	assert.True(f.HasState("foo"))
+	assert.Equal("bar", f.State("foo"))
+}
+
 func TestStarter(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,299 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gdt-dev/core/api"
+)
+
+const (
+	// DefaultHost is the host that ports are reserved and listeners are
+	// started on.
+	DefaultHost = "127.0.0.1"
+	// stateSuffixPort is appended to an endpoint's name for the state key of
+	// its port number.
+	stateSuffixPort = ".port"
+	// stateSuffixAddr is appended to an endpoint's name for the state key of
+	// its host:port address.
+	stateSuffixAddr = ".addr"
+)
+
+var (
+	// ErrDuplicateName indicates that more than one port or listener was
+	// given the same name.
+	ErrDuplicateName = errors.New("duplicate endpoint name")
+)
+
+// DuplicateName returns an ErrDuplicateName for the supplied name.
+func DuplicateName(name string) error {
+	return fmt.Errorf("%w: %s", ErrDuplicateName, name)
+}
+
+// endpointKind is the kind of a named endpoint.
+type endpointKind int
+
+const (
+	// kindPort is a reserved port with nothing listening on it.
+	kindPort endpointKind = iota
+	// kindTCPEcho is a TCP listener that echoes what it reads.
+	kindTCPEcho
+	// kindUDPEcho is a UDP listener that echoes the packets it receives.
+	kindUDPEcho
+)
+
+// endpoint is a named port, optionally with an echo listener on it.
+type endpoint struct {
+	name string
+	kind endpointKind
+	port int
+}
+
+// netFixture reserves free ports and runs echo listeners
+type netFixture struct {
+	host      string
+	endpoints []*endpoint
+	closers   []io.Closer
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	conns     map[net.Conn]struct{}
+}
+
+// Start reserves a free port for each endpoint and starts the echo listeners
+func (f *netFixture) Start(_ context.Context) error {
+	seen := map[string]bool{}
+	for _, ep := range f.endpoints {
+		if seen[ep.name] {
+			return DuplicateName(ep.name)
+		}
+		seen[ep.name] = true
+	}
+	f.conns = map[net.Conn]struct{}{}
+	for _, ep := range f.endpoints {
+		if err := f.start(ep); err != nil {
+			f.close()
+			return err
+		}
+	}
+	return nil
+}
+
+// start reserves a port for the supplied endpoint and, for echo endpoints,
+// starts a listener on it.
+func (f *netFixture) start(ep *endpoint) error {
+	addr := net.JoinHostPort(f.host, "0")
+	switch ep.kind {
+	case kindUDPEcho:
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return err
+		}
+		ep.port = pc.LocalAddr().(*net.UDPAddr).Port
+		f.closers = append(f.closers, pc)
+		f.wg.Add(1)
+		go f.echoUDP(pc)
+	default:
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		ep.port = l.Addr().(*net.TCPAddr).Port
+		if ep.kind == kindPort {
+			// The port is only reserved long enough to learn that it is
+			// free. The code under test listens on it.
+			return l.Close()
+		}
+		f.closers = append(f.closers, l)
+		f.wg.Add(1)
+		go f.echoTCP(l)
+	}
+	return nil
+}
+
+// echoTCP accepts connections on the supplied listener and writes back
+// everything read from each connection until the listener is closed.
+func (f *netFixture) echoTCP(l net.Listener) {
+	defer f.wg.Done()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns[conn] = struct{}{}
+		f.mu.Unlock()
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			_, _ = io.Copy(conn, conn)
+			_ = conn.Close()
+			f.mu.Lock()
+			delete(f.conns, conn)
+			f.mu.Unlock()
+		}()
+	}
+}
+
+// echoUDP sends each packet received on the supplied connection back to its
+// sender until the connection is closed.
+func (f *netFixture) echoUDP(pc net.PacketConn) {
+	defer f.wg.Done()
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		_, _ = pc.WriteTo(buf[:n], from)
+	}
+}
+
+// close closes the listeners and open connections and waits for the echo
+// goroutines to finish.
+func (f *netFixture) close() {
+	for _, c := range f.closers {
+		_ = c.Close()
+	}
+	f.closers = nil
+	f.mu.Lock()
+	for conn := range f.conns {
+		_ = conn.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+// Stop closes the echo listeners and forgets the endpoints' ports
+func (f *netFixture) Stop(_ context.Context) {
+	f.close()
+	for _, ep := range f.endpoints {
+		ep.port = 0
+	}
+}
+
+// lookup returns the endpoint and state suffix for the supplied state key.
+func (f *netFixture) lookup(key string) (*endpoint, string) {
+	key = strings.ToLower(key)
+	for _, suffix := range []string{stateSuffixPort, stateSuffixAddr} {
+		name, found := strings.CutSuffix(key, suffix)
+		if !found {
+			continue
+		}
+		for _, ep := range f.endpoints {
+			if ep.name == name && ep.port != 0 {
+				return ep, suffix
+			}
+		}
+	}
+	return nil, ""
+}
+
+// HasState returns true if the supplied key is the `<name>.port` or
+// `<name>.addr` of a started endpoint
+func (f *netFixture) HasState(key string) bool {
+	ep, _ := f.lookup(key)
+	return ep != nil
+}
+
+// State returns the port number for a `<name>.port` key and the host:port
+// address for a `<name>.addr` key
+func (f *netFixture) State(key string) interface{} {
+	ep, suffix := f.lookup(key)
+	if ep == nil {
+		return nil
+	}
+	if suffix == stateSuffixPort {
+		return ep.port
+	}
+	return f.addr(ep)
+}
+
+// addr returns the host:port address of the supplied endpoint.
+func (f *netFixture) addr(ep *endpoint) string {
+	return net.JoinHostPort(f.host, strconv.Itoa(ep.port))
+}
+
+// Env publishes `<NAME>_PORT` and `<NAME>_ADDR` environment variables for
+// each endpoint
+func (f *netFixture) Env() map[string]string {
+	env := map[string]string{}
+	for _, ep := range f.endpoints {
+		if ep.port == 0 {
+			continue
+		}
+		prefix := strings.ToUpper(
+			strings.Map(func(r rune) rune {
+				if r == '-' || r == '.' {
+					return '_'
+				}
+				return r
+			}, ep.name),
+		)
+		env[prefix+"_PORT"] = strconv.Itoa(ep.port)
+		env[prefix+"_ADDR"] = f.addr(ep)
+	}
+	return env
+}
+
+// netFixtureModifier sets some value on the net fixture
+type netFixtureModifier func(f *netFixture)
+
+// addEndpoints adds an endpoint of the supplied kind for each name.
+func addEndpoints(kind endpointKind, names []string) netFixtureModifier {
+	return func(f *netFixture) {
+		for _, name := range names {
+			f.endpoints = append(f.endpoints, &endpoint{
+				name: strings.ToLower(name),
+				kind: kind,
+			})
+		}
+	}
+}
+
+// WithPorts reserves a free TCP port for each of the supplied names. Nothing
+// listens on a reserved port, so the code under test can listen on it.
+func WithPorts(names ...string) netFixtureModifier {
+	return addEndpoints(kindPort, names)
+}
+
+// WithTCPEcho starts a TCP listener that echoes what it reads for each of the
+// supplied names.
+func WithTCPEcho(names ...string) netFixtureModifier {
+	return addEndpoints(kindTCPEcho, names)
+}
+
+// WithUDPEcho starts a UDP listener that echoes the packets it receives for
+// each of the supplied names.
+func WithUDPEcho(names ...string) netFixtureModifier {
+	return addEndpoints(kindUDPEcho, names)
+}
+
+// WithHost sets the host that ports are reserved and listeners are started
+// on. Defaults to DefaultHost.
+func WithHost(host string) netFixtureModifier {
+	return func(f *netFixture) {
+		f.host = host
+	}
+}
+
+// New returns a new net Fixture. Each named endpoint's port is published as
+// the fixture's `<name>.port` state and its host:port address as the
+// `<name>.addr` state, and as the `<NAME>_PORT` and `<NAME>_ADDR` environment
+// variables.
+func New(mods ...netFixtureModifier) api.Fixture {
+	f := &netFixture{host: DefaultHost}
+	for _, mod := range mods {
+		mod(f)
+	}
+	return f
+}
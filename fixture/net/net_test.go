@@ -0,0 +1,99 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package net_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/api"
+	netfix "github.com/gdt-dev/core/fixture/net"
+)
+
+func TestPorts(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	f := netfix.New(netfix.WithPorts("api", "metrics"))
+	assert.False(f.HasState("api.port"))
+
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+
+	apiPort, ok := f.State("api.port").(int)
+	require.True(ok)
+	metricsPort, ok := f.State("metrics.port").(int)
+	require.True(ok)
+	assert.NotEqual(apiPort, metricsPort)
+	assert.Equal("127.0.0.1:"+strconv.Itoa(apiPort), f.State("api.addr"))
+	assert.False(f.HasState("other.port"))
+
+	// Nothing listens on a reserved port, so it can be listened on.
+	l, err := net.Listen("tcp", f.State("api.addr").(string))
+	require.Nil(err)
+	require.Nil(l.Close())
+
+	require.Implements((*api.EnvProvider)(nil), f)
+	env := f.(api.EnvProvider).Env()
+	assert.Equal(strconv.Itoa(apiPort), env["API_PORT"])
+	assert.Equal(f.State("metrics.addr"), env["METRICS_ADDR"])
+
+	f.Stop(ctx)
+	assert.False(f.HasState("api.port"))
+}
+
+func TestTCPEcho(t *testing.T) {
+	require := require.New(t)
+
+	f := netfix.New(netfix.WithTCPEcho("echo"))
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+	defer f.Stop(ctx)
+
+	conn, err := net.DialTimeout(
+		"tcp", f.State("echo.addr").(string), time.Second,
+	)
+	require.Nil(err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello\n"))
+	require.Nil(err)
+	require.Nil(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.Nil(err)
+	require.Equal("hello\n", line)
+}
+
+func TestUDPEcho(t *testing.T) {
+	require := require.New(t)
+
+	f := netfix.New(netfix.WithUDPEcho("echo"))
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+	defer f.Stop(ctx)
+
+	conn, err := net.Dial("udp", f.State("echo.addr").(string))
+	require.Nil(err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	require.Nil(err)
+	require.Nil(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	require.Nil(err)
+	require.Equal("ping", string(buf[:n]))
+}
+
+func TestDuplicateName(t *testing.T) {
+	f := netfix.New(netfix.WithPorts("api"), netfix.WithTCPEcho("API"))
+	err := f.Start(context.TODO())
+	require.ErrorIs(t, err, netfix.ErrDuplicateName)
+}
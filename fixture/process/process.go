@@ -0,0 +1,358 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/debug"
+)
+
+const (
+	// StatePID is the state key for the process ID of the started process.
+	StatePID = "pid"
+	// StateOutput is the state key for the combined stdout and stderr that
+	// the process has written.
+	StateOutput = "output"
+)
+
+var (
+	// DefaultReadyTimeout is how long Start waits for the process to become
+	// ready.
+	DefaultReadyTimeout = 30 * time.Second
+	// DefaultGracePeriod is how long Stop waits for the process to exit after
+	// sending the stop signal before killing it.
+	DefaultGracePeriod = 5 * time.Second
+)
+
+const (
+	// probeInterval is how often the readiness probes are checked.
+	probeInterval = 50 * time.Millisecond
+)
+
+var (
+	// ErrNotReady indicates that the process did not become ready before the
+	// ready timeout.
+	ErrNotReady = errors.New("process not ready")
+	// ErrExited indicates that the process exited before it became ready.
+	ErrExited = errors.New("process exited")
+)
+
+// NotReady returns an ErrNotReady for the supplied timeout.
+func NotReady(timeout time.Duration) error {
+	return fmt.Errorf("%w: not ready within %s", ErrNotReady, timeout)
+}
+
+// Exited returns an ErrExited for the supplied exit code and process output.
+func Exited(exitCode int, output string) error {
+	return fmt.Errorf(
+		"%w: exit code %d before becoming ready: %s",
+		ErrExited, exitCode, strings.TrimSpace(output),
+	)
+}
+
+// probe returns true when the process is ready.
+type probe func(ctx context.Context, output string) bool
+
+// lockedBuffer is the io.Writer for the process's stdout and stderr. It
+// allows the output to be read safely while the process is running.
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+// processFixture starts a process and waits for it to become ready
+type processFixture struct {
+	path         string
+	args         []string
+	env          []string
+	dir          string
+	probes       []probe
+	readyTimeout time.Duration
+	stopSignal   os.Signal
+	grace        time.Duration
+	cmd          *exec.Cmd
+	output       *lockedBuffer
+	// done is closed when the process has exited.
+	done chan struct{}
+}
+
+// Start starts the process and waits until all readiness probes pass. The
+// process is killed if it does not become ready before the ready timeout.
+func (f *processFixture) Start(ctx context.Context) error {
+	cmd := exec.Command(f.path, f.args...)
+	cmd.Dir = f.dir
+	if len(f.env) > 0 {
+		cmd.Env = append(os.Environ(), f.env...)
+	}
+	output := &lockedBuffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	debug.Printf(ctx, "process: %s started (pid %d)", f.path, cmd.Process.Pid)
+	f.cmd = cmd
+	f.output = output
+	f.done = make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(f.done)
+	}()
+	if err := f.waitReady(ctx); err != nil {
+		f.kill()
+		return err
+	}
+	debug.Printf(ctx, "process: %s ready", f.path)
+	return nil
+}
+
+// waitReady waits until all readiness probes pass, the process exits or the
+// ready timeout elapses.
+func (f *processFixture) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, f.readyTimeout)
+	defer cancel()
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for {
+		if f.ready(ctx) {
+			return nil
+		}
+		select {
+		case <-f.done:
+			return Exited(f.cmd.ProcessState.ExitCode(), f.output.String())
+		case <-ctx.Done():
+			return NotReady(f.readyTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// ready returns true if all readiness probes pass.
+func (f *processFixture) ready(ctx context.Context) bool {
+	output := f.output.String()
+	for _, p := range f.probes {
+		if !p(ctx, output) {
+			return false
+		}
+	}
+	return true
+}
+
+// Stop sends the stop signal to the process and kills it if it has not exited
+// within the grace period
+func (f *processFixture) Stop(ctx context.Context) {
+	if f.cmd == nil {
+		return
+	}
+	select {
+	case <-f.done:
+		return
+	default:
+	}
+	if err := f.cmd.Process.Signal(f.stopSignal); err != nil {
+		// Not all platforms support sending signals other than os.Kill.
+		f.kill()
+		return
+	}
+	select {
+	case <-f.done:
+		debug.Printf(ctx, "process: %s stopped", f.path)
+	case <-time.After(f.grace):
+		debug.Warnf(
+			ctx, "process: %s killed after grace period of %s",
+			f.path, f.grace,
+		)
+		f.kill()
+	}
+}
+
+// Healthy returns an ErrExited if the process has exited since it started,
+// implementing api.HealthChecker so that a test scenario can restart the
+// process or fail when the process dies.
+func (f *processFixture) Healthy(_ context.Context) error {
+	if f.cmd == nil {
+		return nil
+	}
+	select {
+	case <-f.done:
+		return fmt.Errorf(
+			"%w: exit code %d: %s",
+			ErrExited, f.cmd.ProcessState.ExitCode(),
+			strings.TrimSpace(f.output.String()),
+		)
+	default:
+		return nil
+	}
+}
+
+// kill kills the process and waits for it to exit.
+func (f *processFixture) kill() {
+	_ = f.cmd.Process.Kill()
+	<-f.done
+}
+
+// HasState returns true if the supplied key is StatePID or StateOutput and
+// the process has been started
+func (f *processFixture) HasState(key string) bool {
+	if f.cmd == nil {
+		return false
+	}
+	switch strings.ToLower(key) {
+	case StatePID, StateOutput:
+		return true
+	}
+	return false
+}
+
+// State returns the process ID as a string for the StatePID key and the
+// process's output for the StateOutput key, otherwise returns nil
+func (f *processFixture) State(key string) interface{} {
+	if !f.HasState(key) {
+		return nil
+	}
+	switch strings.ToLower(key) {
+	case StatePID:
+		return strconv.Itoa(f.cmd.Process.Pid)
+	case StateOutput:
+		return f.output.String()
+	}
+	return nil
+}
+
+// processFixtureModifier sets some value on the process fixture
+type processFixtureModifier func(f *processFixture)
+
+// WithArgs sets the arguments the process is started with
+func WithArgs(args ...string) processFixtureModifier {
+	return func(f *processFixture) {
+		f.args = args
+	}
+}
+
+// WithEnv adds environment variables, in "KEY=value" form, to the environment
+// the process is started with
+func WithEnv(env ...string) processFixtureModifier {
+	return func(f *processFixture) {
+		f.env = append(f.env, env...)
+	}
+}
+
+// WithDir sets the working directory the process is started in
+func WithDir(dir string) processFixtureModifier {
+	return func(f *processFixture) {
+		f.dir = dir
+	}
+}
+
+// WithReadyTCP adds a readiness probe that passes once a TCP connection to
+// the supplied address, e.g. "127.0.0.1:8080", succeeds
+func WithReadyTCP(addr string) processFixtureModifier {
+	return func(f *processFixture) {
+		f.probes = append(f.probes, func(ctx context.Context, _ string) bool {
+			d := net.Dialer{Timeout: probeInterval}
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return false
+			}
+			_ = conn.Close()
+			return true
+		})
+	}
+}
+
+// WithReadyLog adds a readiness probe that passes once the process's stdout
+// or stderr matches the supplied regular expression
+func WithReadyLog(re *regexp.Regexp) processFixtureModifier {
+	return func(f *processFixture) {
+		f.probes = append(f.probes, func(_ context.Context, output string) bool {
+			return re.MatchString(output)
+		})
+	}
+}
+
+// WithReadyHTTP adds a readiness probe that passes once a GET request to the
+// supplied URL returns a 200 status code
+func WithReadyHTTP(url string) processFixtureModifier {
+	return func(f *processFixture) {
+		f.probes = append(f.probes, func(ctx context.Context, _ string) bool {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return false
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return false
+			}
+			_ = resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		})
+	}
+}
+
+// WithReadyTimeout sets how long Start waits for the process to become ready
+func WithReadyTimeout(timeout time.Duration) processFixtureModifier {
+	return func(f *processFixture) {
+		f.readyTimeout = timeout
+	}
+}
+
+// WithStopSignal sets the signal sent to the process by Stop. Defaults to
+// SIGTERM.
+func WithStopSignal(sig os.Signal) processFixtureModifier {
+	return func(f *processFixture) {
+		f.stopSignal = sig
+	}
+}
+
+// WithGracePeriod sets how long Stop waits for the process to exit after
+// sending the stop signal before killing it
+func WithGracePeriod(grace time.Duration) processFixtureModifier {
+	return func(f *processFixture) {
+		f.grace = grace
+	}
+}
+
+// New returns a new api.Fixture that starts the executable at the supplied
+// path when started and terminates it when stopped. If no readiness probes
+// are supplied, the process is ready as soon as it has started.
+func New(path string, mods ...processFixtureModifier) api.Fixture {
+	f := &processFixture{
+		path:         path,
+		readyTimeout: DefaultReadyTimeout,
+		stopSignal:   syscall.SIGTERM,
+		grace:        DefaultGracePeriod,
+	}
+	for _, mod := range mods {
+		mod(f)
+	}
+	return f
+}
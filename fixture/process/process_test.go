@@ -0,0 +1,157 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package process_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+	procfix "github.com/gdt-dev/core/fixture/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperProcess is not a real test. It is the process started by the
+// other tests, and serves HTTP on the address in HELPER_ADDR until it
+// receives SIGTERM, unless HELPER_EXIT is set.
+func TestHelperProcess(t *testing.T) {
+	addr := os.Getenv("HELPER_ADDR")
+	if addr == "" {
+		t.Skip("helper process only")
+	}
+	if os.Getenv("HELPER_EXIT") != "" {
+		fmt.Println("crashing")
+		os.Exit(3)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		os.Exit(1)
+	}
+	go func() {
+		_ = http.Serve(l, http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		))
+	}()
+	fmt.Printf("listening on %s\n", addr)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM)
+	<-sigs
+	os.Exit(0)
+}
+
+// freeAddr returns a local TCP address that is not in use.
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := l.Addr().String()
+	require.Nil(t, l.Close())
+	return addr
+}
+
+func TestStartStop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	addr := freeAddr(t)
+	f := procfix.New(
+		os.Args[0],
+		procfix.WithArgs("-test.run=TestHelperProcess"),
+		procfix.WithEnv("HELPER_ADDR="+addr),
+		procfix.WithReadyTCP(addr),
+		procfix.WithReadyHTTP("http://"+addr+"/"),
+		procfix.WithReadyLog(regexp.MustCompile(`listening on \S+`)),
+		procfix.WithReadyTimeout(5*time.Second),
+		procfix.WithGracePeriod(2*time.Second),
+	)
+	require.Implements((*api.Fixture)(nil), f)
+	assert.False(f.HasState(procfix.StatePID))
+
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+
+	require.True(f.HasState(procfix.StatePID))
+	assert.NotEmpty(f.State(procfix.StatePID))
+	assert.Contains(f.State(procfix.StateOutput), "listening on "+addr)
+
+	f.Stop(ctx)
+	_, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+	assert.NotNil(err)
+}
+
+func TestHealthy(t *testing.T) {
+	require := require.New(t)
+
+	addr := freeAddr(t)
+	f := procfix.New(
+		os.Args[0],
+		procfix.WithArgs("-test.run=TestHelperProcess"),
+		procfix.WithEnv("HELPER_ADDR="+addr),
+		procfix.WithReadyTCP(addr),
+		procfix.WithReadyTimeout(5*time.Second),
+	)
+	require.Implements((*api.HealthChecker)(nil), f)
+	hc := f.(api.HealthChecker)
+
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+	defer f.Stop(ctx)
+	require.Nil(hc.Healthy(ctx))
+
+	pid, err := strconv.Atoi(f.State(procfix.StatePID).(string))
+	require.Nil(err)
+	proc, err := os.FindProcess(pid)
+	require.Nil(err)
+	require.Nil(proc.Kill())
+	require.Eventually(func() bool {
+		return errors.Is(hc.Healthy(ctx), procfix.ErrExited)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestExitedBeforeReady(t *testing.T) {
+	require := require.New(t)
+
+	f := procfix.New(
+		os.Args[0],
+		procfix.WithArgs("-test.run=TestHelperProcess"),
+		procfix.WithEnv("HELPER_ADDR="+freeAddr(t), "HELPER_EXIT=1"),
+		procfix.WithReadyLog(regexp.MustCompile(`listening`)),
+		procfix.WithReadyTimeout(5*time.Second),
+	)
+	err := f.Start(context.TODO())
+	require.ErrorIs(err, procfix.ErrExited)
+	require.Contains(err.Error(), "crashing")
+}
+
+func TestNotReady(t *testing.T) {
+	require := require.New(t)
+
+	addr := freeAddr(t)
+	f := procfix.New(
+		os.Args[0],
+		procfix.WithArgs("-test.run=TestHelperProcess"),
+		procfix.WithEnv("HELPER_ADDR="+addr),
+		procfix.WithReadyLog(regexp.MustCompile(`never printed`)),
+		procfix.WithReadyTimeout(200*time.Millisecond),
+	)
+	err := f.Start(context.TODO())
+	require.ErrorIs(err, procfix.ErrNotReady)
+
+	// The process was killed, so nothing is listening on the address.
+	_, err = net.DialTimeout("tcp", addr, 100*time.Millisecond)
+	require.NotNil(err)
+}
@@ -0,0 +1,186 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/debug"
+)
+
+const (
+	// StateDSN is the state key for the data source name of the database,
+	// which is the path of the database file.
+	StateDSN = "dsn"
+	// StateDriver is the state key for the name of the database/sql driver
+	// used to open the database.
+	StateDriver = "driver"
+	// dbFilename is the name of the database file in the temporary directory.
+	dbFilename = "gdt.sqlite"
+)
+
+var (
+	// DefaultDriver is the name of the database/sql driver used to open the
+	// database. "sqlite" is the name registered by modernc.org/sqlite.
+	DefaultDriver = "sqlite"
+)
+
+// sqliteFixture creates a temporary SQLite database seeded with SQL
+type sqliteFixture struct {
+	driver string
+	// scripts are SQL file paths or, for entries added with WithSQL,
+	// SQL statements, run in order.
+	scripts []script
+	dir     string
+	dsn     string
+}
+
+// script is a SQL file or a string of SQL statements.
+type script struct {
+	path string
+	sql  string
+}
+
+// Start creates the database in a new temporary directory and runs the
+// fixture's SQL files and statements in order
+func (f *sqliteFixture) Start(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "gdt-sqlite-*")
+	if err != nil {
+		return err
+	}
+	dsn := filepath.Join(dir, dbFilename)
+	if err := f.seed(ctx, dsn); err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	debug.Printf(ctx, "sqlite: created %s", dsn)
+	f.dir = dir
+	f.dsn = dsn
+	return nil
+}
+
+// seed opens the database at the supplied DSN and runs the fixture's SQL
+// files and statements in order.
+func (f *sqliteFixture) seed(ctx context.Context, dsn string) error {
+	db, err := sql.Open(f.driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// Ensure the database file exists even when there is nothing to run.
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+	for _, s := range f.scripts {
+		stmts := s.sql
+		name := "SQL"
+		if s.path != "" {
+			b, err := os.ReadFile(s.path)
+			if err != nil {
+				return err
+			}
+			stmts = string(b)
+			name = s.path
+		}
+		if strings.TrimSpace(stmts) == "" {
+			continue
+		}
+		debug.Printf(ctx, "sqlite: running %s", name)
+		if _, err := db.ExecContext(ctx, stmts); err != nil {
+			return fmt.Errorf("running %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Stop removes the database and its temporary directory
+func (f *sqliteFixture) Stop(_ context.Context) {
+	if f.dir == "" {
+		return
+	}
+	_ = os.RemoveAll(f.dir)
+	f.dir = ""
+	f.dsn = ""
+}
+
+// HasState returns true if the supplied key is StateDSN or StateDriver and
+// the fixture has been started
+func (f *sqliteFixture) HasState(key string) bool {
+	if f.dsn == "" {
+		return false
+	}
+	switch strings.ToLower(key) {
+	case StateDSN, StateDriver:
+		return true
+	}
+	return false
+}
+
+// State returns the database's DSN for the StateDSN key and the driver name
+// for the StateDriver key, otherwise returns nil
+func (f *sqliteFixture) State(key string) interface{} {
+	if !f.HasState(key) {
+		return nil
+	}
+	switch strings.ToLower(key) {
+	case StateDSN:
+		return f.dsn
+	case StateDriver:
+		return f.driver
+	}
+	return nil
+}
+
+// sqliteFixtureModifier sets some value on the sqlite fixture
+type sqliteFixtureModifier func(f *sqliteFixture)
+
+// WithDriver sets the name of the database/sql driver used to open the
+// database, e.g. "sqlite3" for github.com/mattn/go-sqlite3
+func WithDriver(driver string) sqliteFixtureModifier {
+	return func(f *sqliteFixture) {
+		f.driver = driver
+	}
+}
+
+// WithFiles adds SQL files, e.g. DDL and seed data, that are run in order
+// when the fixture starts
+func WithFiles(paths ...string) sqliteFixtureModifier {
+	return func(f *sqliteFixture) {
+		for _, path := range paths {
+			f.scripts = append(f.scripts, script{path: path})
+		}
+	}
+}
+
+// WithSQL adds SQL statements that are run in order when the fixture starts
+func WithSQL(stmts ...string) sqliteFixtureModifier {
+	return func(f *sqliteFixture) {
+		for _, stmt := range stmts {
+			f.scripts = append(f.scripts, script{sql: stmt})
+		}
+	}
+}
+
+// New returns a new api.Fixture that, when started, creates a SQLite database
+// in a new temporary directory and runs the supplied SQL files and statements
+// against it. Stopping the fixture removes the database.
+//
+// The fixture does not import a SQLite driver. Import one, e.g.
+// modernc.org/sqlite, and use WithDriver if its name is not DefaultDriver.
+func New(mods ...sqliteFixtureModifier) api.Fixture {
+	f := &sqliteFixture{
+		driver: DefaultDriver,
+	}
+	for _, mod := range mods {
+		mod(f)
+	}
+	return f
+}
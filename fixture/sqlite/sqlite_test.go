@@ -0,0 +1,81 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/gdt-dev/core/api"
+	sqlitefix "github.com/gdt-dev/core/fixture/sqlite"
+)
+
+func TestStartStop(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	f := sqlitefix.New(
+		sqlitefix.WithFiles(
+			filepath.Join("testdata", "schema.sql"),
+			filepath.Join("testdata", "seed.sql"),
+		),
+		sqlitefix.WithSQL(
+			"INSERT INTO books (title, year) VALUES ('Fox in Socks', 1965)",
+		),
+	)
+	require.Implements((*api.Fixture)(nil), f)
+	assert.False(f.HasState(sqlitefix.StateDSN))
+
+	ctx := context.TODO()
+	require.Nil(f.Start(ctx))
+
+	require.True(f.HasState(sqlitefix.StateDSN))
+	dsn, ok := f.State(sqlitefix.StateDSN).(string)
+	require.True(ok)
+	assert.Equal(sqlitefix.DefaultDriver, f.State(sqlitefix.StateDriver))
+
+	db, err := sql.Open(sqlitefix.DefaultDriver, dsn)
+	require.Nil(err)
+	var count int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books").Scan(&count)
+	require.Nil(err)
+	assert.Equal(3, count)
+	require.Nil(db.Close())
+
+	f.Stop(ctx)
+	_, err = os.Stat(dsn)
+	assert.True(errors.Is(err, os.ErrNotExist))
+	assert.False(f.HasState(sqlitefix.StateDSN))
+}
+
+func TestInvalidSQL(t *testing.T) {
+	require := require.New(t)
+
+	f := sqlitefix.New(
+		sqlitefix.WithSQL("CREATE TABLEE oops"),
+	)
+	err := f.Start(context.TODO())
+	require.NotNil(err)
+	require.Contains(err.Error(), "running SQL")
+	require.False(f.HasState(sqlitefix.StateDSN))
+}
+
+func TestFileNotFound(t *testing.T) {
+	require := require.New(t)
+
+	f := sqlitefix.New(
+		sqlitefix.WithFiles(filepath.Join("testdata", "missing.sql")),
+	)
+	err := f.Start(context.TODO())
+	require.ErrorIs(err, os.ErrNotExist)
+}
@@ -0,0 +1,2 @@
+INSERT INTO books (title, year) VALUES ('The Cat in the Hat', 1957);
+INSERT INTO books (title, year) VALUES ('Green Eggs and Ham', 1960);
@@ -0,0 +1,74 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package specconfig resolves the timeout and retry configuration that applies
+// to a test spec from the test spec itself, its scenario's defaults and its
+// plugin's defaults. It is shared by the scenario runner and the linter.
+package specconfig
+
+import (
+	"github.com/gdt-dev/core/api"
+)
+
+// Source describes where the configuration that applies to a test spec came
+// from.
+type Source int
+
+const (
+	// SourceNone indicates that no configuration applies to the test spec.
+	SourceNone Source = iota
+	// SourceSpec indicates that the configuration came from the test spec.
+	SourceSpec
+	// SourceScenario indicates that the configuration came from the
+	// scenario's defaults.
+	SourceScenario
+	// SourcePlugin indicates that the configuration came from the test
+	// spec's plugin's defaults.
+	SourcePlugin
+)
+
+// Timeout returns the timeout that applies to the supplied test spec, given
+// the default timeout of the test spec's scenario, and where the timeout came
+// from. It returns nil and SourceNone if the test spec has no timeout.
+func Timeout(
+	eval api.Evaluable,
+	scenarioDefault *api.Timeout,
+) (*api.Timeout, Source) {
+	if to := eval.Timeout(); to != nil {
+		return to, SourceSpec
+	}
+	if to := eval.Base().Timeout; to != nil {
+		return to, SourceSpec
+	}
+	if scenarioDefault != nil {
+		return scenarioDefault, SourceScenario
+	}
+	if to := eval.Base().Plugin.Info().Timeout; to != nil {
+		return to, SourcePlugin
+	}
+	return nil, SourceNone
+}
+
+// Retry returns the retry configuration that applies to the supplied test
+// spec, given the default retry configuration of the test spec's scenario, and
+// where the configuration came from. It returns nil and SourceNone if the test
+// spec has no retry configuration.
+func Retry(
+	eval api.Evaluable,
+	scenarioDefault *api.Retry,
+) (*api.Retry, Source) {
+	if rt := eval.Retry(); rt != nil {
+		return rt, SourceSpec
+	}
+	if rt := eval.Base().Retry; rt != nil {
+		return rt, SourceSpec
+	}
+	if scenarioDefault != nil {
+		return scenarioDefault, SourceScenario
+	}
+	if rt := eval.Base().Plugin.Info().Retry; rt != nil {
+		return rt, SourcePlugin
+	}
+	return nil, SourceNone
+}
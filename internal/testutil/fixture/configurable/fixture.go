@@ -0,0 +1,53 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package configurable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/fixture"
+)
+
+// configurableFixture is an api.ConfigurableFixture that records the
+// parameters of each fixture it configures when that fixture starts.
+type configurableFixture struct {
+	api.Fixture
+	sync.Mutex
+	started []map[string]interface{}
+}
+
+// Configure returns a fixture with the supplied parameters as its state.
+// Returns an error if the parameters contain a "fail" key.
+func (f *configurableFixture) Configure(
+	params map[string]interface{},
+) (api.Fixture, error) {
+	if _, found := params["fail"]; found {
+		return nil, fmt.Errorf("fail parameter supplied")
+	}
+	return fixture.New(
+		fixture.WithState(params),
+		fixture.WithStarter(func(context.Context) error {
+			f.Lock()
+			defer f.Unlock()
+			f.started = append(f.started, params)
+			return nil
+		}),
+	), nil
+}
+
+// Started returns the parameters of each configured fixture that started.
+func (f *configurableFixture) Started() []map[string]interface{} {
+	f.Lock()
+	defer f.Unlock()
+	return f.started
+}
+
+// New returns a new configurable fixture
+func New() *configurableFixture {
+	return &configurableFixture{Fixture: fixture.New()}
+}
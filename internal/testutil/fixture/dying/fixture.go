@@ -0,0 +1,68 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package dying
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/fixture"
+)
+
+// dyingFixture is an api.HealthChecker that is healthy for the first health
+// check after each start and unhealthy afterwards.
+type dyingFixture struct {
+	api.Fixture
+	sync.Mutex
+	starts int
+	checks int
+	// failStart is the start that fails, if any.
+	failStart int
+}
+
+// Start records the start and resets the fixture's health.
+func (f *dyingFixture) Start(_ context.Context) error {
+	f.Lock()
+	defer f.Unlock()
+	f.starts++
+	f.checks = 0
+	if f.starts == f.failStart {
+		// nolint:staticcheck
+		return fmt.Errorf("fixture failed to restart!")
+	}
+	return nil
+}
+
+// Healthy returns an error for all but the first check after a start.
+func (f *dyingFixture) Healthy(_ context.Context) error {
+	f.Lock()
+	defer f.Unlock()
+	f.checks++
+	if f.checks > 1 {
+		// nolint:staticcheck
+		return fmt.Errorf("fixture died!")
+	}
+	return nil
+}
+
+// Starts returns the number of times the fixture has started.
+func (f *dyingFixture) Starts() int {
+	f.Lock()
+	defer f.Unlock()
+	return f.starts
+}
+
+// New returns a new dying fixture
+func New() *dyingFixture {
+	return &dyingFixture{Fixture: fixture.New()}
+}
+
+// NewFailingRestart returns a new dying fixture that fails to start the
+// second time it is started.
+func NewFailingRestart() *dyingFixture {
+	return &dyingFixture{Fixture: fixture.New(), failStart: 2}
+}
@@ -0,0 +1,32 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package errstopper
+
+import (
+	"context"
+	"fmt"
+)
+
+// fixture is an api.FixtureV2 that returns an error from Stop.
+type fixture struct{}
+
+func (f *fixture) Start(_ context.Context) error {
+	return nil
+}
+
+func (f *fixture) Stop(_ context.Context) error {
+	// nolint:staticcheck
+	return fmt.Errorf("error stopping fixture!")
+}
+
+func (f *fixture) HasState(_ string) bool {
+	return false
+}
+
+func (f *fixture) State(_ string) interface{} {
+	return nil
+}
+
+var Fixture = &fixture{}
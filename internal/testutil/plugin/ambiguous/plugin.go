@@ -0,0 +1,126 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package ambiguous
+
+import (
+	"context"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// HighPriority is the registration priority of the "prio-high" plugin.
+const HighPriority = 10
+
+func init() {
+	// dupe-a and dupe-b can both parse specs with a "dupe" field and have the
+	// same priority, which makes those specs ambiguous.
+	plugin.Register(&Plugin{name: "dupe-a", field: "dupe"})
+	plugin.Register(&Plugin{name: "dupe-b", field: "dupe"})
+	// prio-high and prio-low can both parse specs with a "prio" field but
+	// prio-high has a higher priority and is always chosen.
+	plugin.Register(&Plugin{name: "prio-low", field: "prio"})
+	plugin.Register(
+		&Plugin{name: "prio-high", field: "prio"},
+		plugin.WithPriority(HighPriority),
+	)
+	// Two plugins named "http" in different namespaces can both parse specs
+	// with an "ns-http" field. Specs select one with a qualified plugin name.
+	plugin.Register(
+		&Plugin{name: "http", field: "ns-http"},
+		plugin.WithNamespace("myorg"),
+	)
+	plugin.Register(
+		&Plugin{name: "http", field: "ns-http"},
+		plugin.WithNamespace("acme"),
+	)
+}
+
+type Defaults struct{}
+
+func (d *Defaults) Merge(map[string]any) {}
+
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	return nil
+}
+
+type Spec struct {
+	api.Spec
+	field string
+	Value string
+}
+
+func (s *Spec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *Spec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *Spec) Retry() *api.Retry {
+	return nil
+}
+
+func (s *Spec) Timeout() *api.Timeout {
+	return nil
+}
+
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case s.field:
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Value = valNode.Value
+		default:
+			if lo.Contains(api.BaseSpecFields, key) {
+				continue
+			}
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	return nil
+}
+
+func (s *Spec) Eval(context.Context) (*api.Result, error) {
+	return api.NewResult(), nil
+}
+
+// Plugin is a test plugin that parses specs containing a single configurable
+// field.
+type Plugin struct {
+	name  string
+	field string
+}
+
+func (p *Plugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name: p.name,
+	}
+}
+
+func (p *Plugin) Defaults() api.DefaultsHandler {
+	return &Defaults{}
+}
+
+func (p *Plugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&Spec{field: p.field}}
+}
@@ -6,12 +6,10 @@ package bar
 
 import (
 	"context"
-	"strconv"
 
 	"github.com/gdt-dev/core/api"
-	"github.com/gdt-dev/core/parse"
 	"github.com/gdt-dev/core/plugin"
-	"github.com/samber/lo"
+	"github.com/gdt-dev/core/plugin/pluginutil"
 	"gopkg.in/yaml.v3"
 )
 
@@ -60,36 +58,9 @@ func (s *Spec) Eval(context.Context) (*api.Result, error) {
 }
 
 func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
-	if node.Kind != yaml.MappingNode {
-		return parse.ExpectedMapAt(node)
-	}
-	// maps/structs are stored in a top-level Node.Content field which is a
-	// concatenated slice of Node pointers in pairs of key/values.
-	for i := 0; i < len(node.Content); i += 2 {
-		keyNode := node.Content[i]
-		if keyNode.Kind != yaml.ScalarNode {
-			return parse.ExpectedScalarAt(keyNode)
-		}
-		key := keyNode.Value
-		valNode := node.Content[i+1]
-		switch key {
-		case "bar":
-			if valNode.Kind != yaml.ScalarNode {
-				return parse.ExpectedScalarAt(valNode)
-			}
-			if v, err := strconv.Atoi(valNode.Value); err != nil {
-				return parse.ExpectedIntAt(valNode)
-			} else {
-				s.Bar = v
-			}
-		default:
-			if lo.Contains(api.BaseSpecFields, key) {
-				continue
-			}
-			return parse.UnknownFieldAt(key, keyNode)
-		}
-	}
-	return nil
+	return pluginutil.DecodeSpec(node, pluginutil.Fields{
+		"bar": pluginutil.Int(&s.Bar),
+	})
 }
 
 type Plugin struct{}
@@ -0,0 +1,123 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package deprecated
+
+import (
+	"context"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	plugin.Register(&Plugin{})
+}
+
+type Defaults struct{}
+
+func (d *Defaults) Merge(map[string]any) {}
+
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	return nil
+}
+
+type Spec struct {
+	api.Spec
+	Current string            `yaml:"current"`
+	Legacy  string            `yaml:"legacy"`
+	Opts    map[string]string `yaml:"opts"`
+}
+
+func (s *Spec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *Spec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *Spec) Retry() *api.Retry {
+	return nil
+}
+
+func (s *Spec) Timeout() *api.Timeout {
+	return nil
+}
+
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "current":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Current = valNode.Value
+		case "legacy":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Legacy = valNode.Value
+		case "opts":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			if err := valNode.Decode(&s.Opts); err != nil {
+				return err
+			}
+		default:
+			if lo.Contains(api.BaseSpecFields, key) {
+				continue
+			}
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	return nil
+}
+
+func (s *Spec) Eval(context.Context) (*api.Result, error) {
+	return api.NewResult(), nil
+}
+
+// Plugin is a test plugin that declares deprecated spec fields.
+type Plugin struct{}
+
+func (p *Plugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name: "deprecated",
+		Deprecated: []api.DeprecatedField{
+			{
+				Name:        "legacy",
+				Replacement: "current",
+				RemovedIn:   "v2",
+			},
+			{
+				Name:    "opts.old",
+				Message: "it no longer has any effect",
+			},
+		},
+	}
+}
+
+func (p *Plugin) Defaults() api.DefaultsHandler {
+	return &Defaults{}
+}
+
+func (p *Plugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&Spec{}}
+}
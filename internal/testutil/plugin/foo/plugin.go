@@ -118,6 +118,11 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 func (s *Spec) Eval(ctx context.Context) (*api.Result, error) {
 	fails := []error{}
 	debug.Printf(ctx, "in %s Foo=%s", s.Title(), s.Foo)
+	// A Foo of "terminal" fails in a way that retrying cannot fix.
+	if s.Foo == "terminal" {
+		fail := fmt.Errorf("expected s.Foo = 'baz', got %s", s.Foo)
+		return api.NewResult(api.WithFailures(api.Terminal(fail))), nil
+	}
 	// This is just a silly test to demonstrate how to write Eval() methods
 	// for plugin Spec specialization classes.
 	if s.Name == "bar" && s.Foo != "bar" {
@@ -0,0 +1,188 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// this is just for testing purposes...
+	PluginRef = &Plugin{}
+	// ErrBeforeScenario is returned from BeforeScenario when the scenario is
+	// named "hooks-before-fail".
+	ErrBeforeScenario = errors.New("before scenario failed")
+	// ErrInvalid is returned from Validate when a spec's hooks field is
+	// "invalid".
+	ErrInvalid = errors.New("hooks must not be invalid")
+	// ErrSpecFailed is the failure of a spec whose hooks field is "fail".
+	ErrSpecFailed = errors.New("hooks spec failed")
+)
+
+func init() {
+	plugin.Register(PluginRef)
+}
+
+type Defaults struct{}
+
+func (d *Defaults) Merge(map[string]any) {}
+
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	return nil
+}
+
+type Spec struct {
+	api.Spec
+	Hooks string `yaml:"hooks"`
+}
+
+func (s *Spec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *Spec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *Spec) Retry() *api.Retry {
+	return nil
+}
+
+func (s *Spec) Timeout() *api.Timeout {
+	return nil
+}
+
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "hooks":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Hooks = valNode.Value
+		default:
+			if lo.Contains(api.BaseSpecFields, key) {
+				continue
+			}
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	return nil
+}
+
+func (s *Spec) Eval(ctx context.Context) (*api.Result, error) {
+	call := "eval:" + s.Hooks
+	PluginRef.Lock()
+	if gdtcontext.ScenarioID(ctx) != PluginRef.scenarioID {
+		call += ":wrong-id"
+	}
+	PluginRef.Unlock()
+	PluginRef.record(call)
+	if s.Hooks == "fail" {
+		return api.NewResult(api.WithFailures(ErrSpecFailed)), nil
+	}
+	return api.NewResult(), nil
+}
+
+// Metrics reports a single request that sent the spec's hooks field.
+func (s *Spec) Metrics() *api.Metrics {
+	return &api.Metrics{
+		Requests:  1,
+		BytesSent: int64(len(s.Hooks)),
+	}
+}
+
+// Plugin is a test plugin that records calls to its scenario lifecycle hooks,
+// validates its specs and reports metrics for each Eval.
+type Plugin struct {
+	sync.Mutex
+	calls []string
+	// scenarioID is the ID of the scenario run passed to BeforeScenario.
+	scenarioID string
+}
+
+func (p *Plugin) record(call string) {
+	p.Lock()
+	defer p.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+// Calls returns the recorded calls and resets the recording.
+func (p *Plugin) Calls() []string {
+	p.Lock()
+	defer p.Unlock()
+	calls := p.calls
+	p.calls = nil
+	return calls
+}
+
+func (p *Plugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name: "hooks",
+	}
+}
+
+func (p *Plugin) Defaults() api.DefaultsHandler {
+	return &Defaults{}
+}
+
+func (p *Plugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&Spec{}}
+}
+
+func (p *Plugin) BeforeScenario(_ context.Context, sc api.ScenarioInfo) error {
+	p.record("before:" + sc.Title)
+	p.Lock()
+	p.scenarioID = sc.ID
+	p.Unlock()
+	if sc.Title == "hooks-before-fail" {
+		return ErrBeforeScenario
+	}
+	return nil
+}
+
+func (p *Plugin) AfterScenario(_ context.Context, sc api.ScenarioInfo) error {
+	call := "after:" + sc.Title
+	p.Lock()
+	if sc.ID == "" || sc.ID != p.scenarioID {
+		call += ":wrong-id"
+	}
+	for _, res := range sc.Results {
+		if !res.OK() {
+			call += fmt.Sprintf(":failed(%s: %s)", res.Title, res.Failures[0])
+		}
+	}
+	p.Unlock()
+	p.record(call)
+	return nil
+}
+
+func (p *Plugin) Validate(_ context.Context, e api.Evaluable) []error {
+	if e.(*Spec).Hooks == "invalid" {
+		return []error{ErrInvalid}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package stubborn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	plugin.Register(&Plugin{})
+}
+
+type Defaults struct{}
+
+func (d *Defaults) Merge(map[string]any) {}
+
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	return nil
+}
+
+// Spec sleeps for the duration in its `stubborn` field, ignoring the context's
+// cancellation, and then logs to and fails the context's test unit.
+type Spec struct {
+	api.Spec
+	Sleep time.Duration `yaml:"stubborn"`
+}
+
+func (s *Spec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *Spec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *Spec) Retry() *api.Retry {
+	return api.NoRetry
+}
+
+func (s *Spec) Timeout() *api.Timeout {
+	return nil
+}
+
+func (s *Spec) Eval(ctx context.Context) (*api.Result, error) {
+	time.Sleep(s.Sleep)
+	debug.Printf(ctx, "stubborn: woke after %s", s.Sleep)
+	if tu := gdtcontext.TestUnit(ctx); tu != nil {
+		tu.Logf("stubborn: woke after %s", s.Sleep)
+		tu.Error(fmt.Errorf("stubborn: failed after %s", s.Sleep))
+	}
+	return api.NewResult(), nil
+}
+
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "stubborn":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			d, err := time.ParseDuration(valNode.Value)
+			if err != nil {
+				return parse.ExpectedDurationAt(valNode)
+			}
+			s.Sleep = d
+		default:
+			if lo.Contains(api.BaseSpecFields, key) {
+				continue
+			}
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	return nil
+}
+
+type Plugin struct{}
+
+func (p *Plugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name: "stubborn",
+	}
+}
+
+func (p *Plugin) Defaults() api.DefaultsHandler {
+	return &Defaults{}
+}
+
+func (p *Plugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&Spec{}}
+}
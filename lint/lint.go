@@ -0,0 +1,166 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/scenario"
+)
+
+// Severity describes how serious a Finding is.
+type Severity string
+
+const (
+	// SeverityError indicates a problem that should be fixed before the
+	// scenario is run.
+	SeverityError Severity = "error"
+	// SeverityWarning indicates a problem that does not prevent the scenario
+	// from running correctly.
+	SeverityWarning Severity = "warning"
+)
+
+var (
+	// severities contains the Severity of scenario warnings, keyed by error
+	// code. Warnings with codes that are not in this map are reported with
+	// SeverityWarning.
+	severities = map[string]Severity{
+		parse.CodeAmbiguousSpec:   SeverityError,
+		parse.CodeDuplicateSpecID: SeverityError,
+	}
+)
+
+// Finding is a single problem found while linting a gdt test scenario.
+type Finding struct {
+	// Code is the machine-readable error code for the problem, e.g.
+	// "GDT-P020".
+	Code string
+	// Severity is how serious the problem is.
+	Severity Severity
+	// Path is the filepath to the test scenario.
+	Path string
+	// Line is the line number where the problem was found, if known.
+	Line int
+	// Column is the column number where the problem was found, if known.
+	Column int
+	// Message describes the problem.
+	Message string
+}
+
+// String returns the Finding in the familiar
+// "path:line:column: severity: message [code]" format.
+func (f Finding) String() string {
+	return fmt.Sprintf(
+		"%s:%d:%d: %s: %s [%s]",
+		f.Path, f.Line, f.Column, f.Severity, f.Message, f.Code,
+	)
+}
+
+// Run lints the gdt test scenario at the supplied path and returns the
+// problems found. A scenario that cannot be parsed produces a single Finding
+// with SeverityError describing the parse error.
+//
+// Besides validation errors and parse warnings, the scenario is checked with
+// each registered Rule that is not disabled with WithoutRules, and each
+// Optional Rule that is enabled with WithRules. A finding is
+// suppressed by a `# gdt:lint-ignore <rule-or-code>[,...]` comment at the top
+// of the scenario file or on the YAML node where the problem was found or any
+// node containing it, e.g. a test spec.
+func Run(path string, opts ...Option) []Finding {
+	cfg := &config{
+		enabled:    map[string]bool{},
+		disabled:   map[string]bool{},
+		severities: map[string]Severity{},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return []Finding{newFinding(path, err, SeverityError)}
+	}
+	s, err := scenario.FromBytes(contents, scenario.WithPath(path))
+	if err != nil {
+		return []Finding{newFinding(path, err, SeverityError)}
+	}
+	// The scenario parsed, so its contents are valid YAML.
+	doc := &yaml.Node{}
+	_ = yaml.Unmarshal(contents, doc)
+	sup := newSuppressor(doc)
+
+	findings := []Finding{}
+	for _, err := range s.Validate(context.TODO()) {
+		findings = append(findings, newFinding(path, err, SeverityError))
+	}
+	for _, warn := range s.Warnings {
+		code := api.ErrorCode(warn)
+		if sup.suppressed(code, code, nil) {
+			continue
+		}
+		sev, found := severities[code]
+		if !found {
+			sev = SeverityWarning
+		}
+		findings = append(findings, newFinding(path, warn, sev))
+	}
+
+	target := &Target{Path: path, Scenario: s}
+	if len(doc.Content) > 0 {
+		target.Root = doc.Content[0]
+	}
+	for _, rule := range Rules() {
+		if cfg.disabled[rule.Name] || (rule.Optional && !cfg.enabled[rule.Name]) {
+			continue
+		}
+		sev, found := cfg.severities[rule.Name]
+		if !found {
+			sev = rule.Severity
+		}
+		for _, p := range rule.Check(target) {
+			if sup.suppressed(rule.Name, rule.Code, p.Node) {
+				continue
+			}
+			f := Finding{
+				Code:     rule.Code,
+				Severity: sev,
+				Path:     path,
+				Message:  p.Message,
+			}
+			if p.Node != nil {
+				f.Line = p.Node.Line
+				f.Column = p.Node.Column
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// newFinding returns a Finding describing the supplied error.
+func newFinding(path string, err error, sev Severity) Finding {
+	f := Finding{
+		Code:     api.ErrorCode(err),
+		Severity: sev,
+		Path:     path,
+		Message:  err.Error(),
+	}
+	var perr *parse.Error
+	if errors.As(err, &perr) {
+		f.Line = perr.Line
+		f.Column = perr.Column
+		f.Message = perr.Message
+	}
+	if f.Code == "" {
+		f.Code = parse.CodeUnknown
+	}
+	return f
+}
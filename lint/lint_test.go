@@ -0,0 +1,251 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package lint_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/api"
+	_ "github.com/gdt-dev/core/internal/testutil/plugin/ambiguous"
+	_ "github.com/gdt-dev/core/internal/testutil/plugin/deprecated"
+	_ "github.com/gdt-dev/core/internal/testutil/plugin/foo"
+	_ "github.com/gdt-dev/core/internal/testutil/plugin/hooks"
+	"github.com/gdt-dev/core/lint"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin"
+	"github.com/gdt-dev/core/scenario"
+)
+
+func TestAmbiguousSpec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "ambiguous.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 1)
+
+	f := findings[0]
+	assert.Equal(parse.CodeAmbiguousSpec, f.Code)
+	assert.Equal(lint.SeverityError, f.Severity)
+	assert.Equal(fp, f.Path)
+	assert.Equal(4, f.Line)
+	assert.Contains(f.Message, "dupe-a, dupe-b")
+}
+
+func TestDuplicateSpecID(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "duplicate-id.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 1)
+
+	f := findings[0]
+	assert.Equal(parse.CodeDuplicateSpecID, f.Code)
+	assert.Equal(lint.SeverityError, f.Severity)
+	assert.Equal(9, f.Line)
+	assert.Contains(f.Message, `"create-widget"`)
+}
+
+func TestPriorityNotAmbiguous(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "priority.yaml")
+	require.Empty(lint.Run(fp))
+
+	f, err := os.Open(fp)
+	require.Nil(err)
+	defer f.Close()
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.Len(s.Tests, 1)
+	require.Equal("prio-high", s.Tests[0].Base().Plugin.Info().Name)
+}
+
+func TestParseError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse-error.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 1)
+
+	f := findings[0]
+	assert.Equal(parse.CodeUnknownPlugin, f.Code)
+	assert.Equal(lint.SeverityError, f.Severity)
+	assert.Equal(4, f.Line)
+	assert.Equal(
+		fp+":4:5: error: unknown plugin \"unknown\" [GDT-P019]",
+		f.String(),
+	)
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "invalid.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 1)
+
+	f := findings[0]
+	assert.Equal(api.CodeSpecInvalid, f.Code)
+	assert.Equal(lint.SeverityError, f.Severity)
+	assert.Contains(f.Message, "hooks must not be invalid")
+}
+
+func TestNamespace(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "namespace.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 1)
+
+	// Only the spec without a qualified plugin name is ambiguous.
+	f := findings[0]
+	assert.Equal(parse.CodeAmbiguousSpec, f.Code)
+	assert.Equal(6, f.Line)
+	assert.Contains(f.Message, "myorg.http, acme.http")
+
+	f2, err := os.Open(fp)
+	require.Nil(err)
+	defer f2.Close()
+	s, err := scenario.FromReader(f2, scenario.WithPath(fp))
+	require.Nil(err)
+	require.Len(s.Tests, 2)
+	assert.Equal("acme.http", plugin.QualifiedName(s.Tests[0].Base().Plugin))
+}
+
+func TestDeprecatedFields(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "deprecated.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 2)
+
+	for _, f := range findings {
+		assert.Equal(parse.CodeDeprecatedField, f.Code)
+		assert.Equal(lint.SeverityWarning, f.Severity)
+	}
+	assert.Equal(5, findings[0].Line)
+	assert.Equal(
+		"field \"legacy\" of plugin \"deprecated\" is deprecated: "+
+			"use \"current\" instead; it will be removed in v2",
+		findings[0].Message,
+	)
+	assert.Equal(8, findings[1].Line)
+	assert.Equal(
+		"field \"opts.old\" of plugin \"deprecated\" is deprecated: "+
+			"it no longer has any effect",
+		findings[1].Message,
+	)
+}
+
+func TestRules(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The Optional unnamed-spec rule is not checked by default.
+	fp := filepath.Join("testdata", "rules.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 5)
+
+	for _, f := range findings {
+		assert.Equal(lint.SeverityWarning, f.Severity)
+	}
+
+	assert.Equal(lint.CodeUnusedFixture, findings[0].Code)
+	assert.Equal(5, findings[0].Line)
+	assert.Contains(findings[0].Message, `"unused"`)
+
+	assert.Equal(lint.CodeRetryWithoutTimeout, findings[1].Code)
+	assert.Equal(13, findings[1].Line)
+	assert.Contains(findings[1].Message, `"retried"`)
+
+	assert.Equal(lint.CodeUnreachableSkipIf, findings[2].Code)
+	assert.Equal(8, findings[2].Line)
+	assert.Contains(findings[2].Message, "always false")
+	assert.Equal(lint.CodeUnreachableSkipIf, findings[3].Code)
+	assert.Equal(9, findings[3].Line)
+	assert.Contains(findings[3].Message, "always true")
+	assert.Equal(lint.CodeUnreachableSkipIf, findings[4].Code)
+	assert.Equal(10, findings[4].Line)
+	assert.Contains(findings[4].Message, "unreachable")
+
+	findings = lint.Run(fp, lint.WithRules("unnamed-spec"))
+	require.Len(findings, 6)
+	assert.Equal(lint.CodeUnnamedSpec, findings[1].Code)
+	assert.Equal(12, findings[1].Line)
+}
+
+func TestRuleOptions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "rules.yaml")
+	findings := lint.Run(
+		fp,
+		lint.WithoutRules("unreachable-skip-if", "unused-fixture"),
+		lint.WithRules("unnamed-spec"),
+		lint.WithSeverity("unnamed-spec", lint.SeverityError),
+	)
+	require.Len(findings, 2)
+
+	assert.Equal(lint.CodeUnnamedSpec, findings[0].Code)
+	assert.Equal(lint.SeverityError, findings[0].Severity)
+	assert.Equal(lint.CodeRetryWithoutTimeout, findings[1].Code)
+	assert.Equal(lint.SeverityWarning, findings[1].Severity)
+}
+
+func TestSuppressRules(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "suppressed.yaml")
+	findings := lint.Run(fp, lint.WithRules("unnamed-spec"))
+	require.Len(findings, 1)
+
+	// Only the last, unsuppressed, spec without a name is reported.
+	assert.Equal(lint.CodeUnnamedSpec, findings[0].Code)
+	assert.Equal(16, findings[0].Line)
+}
+
+func TestRegisterRule(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	lint.Register(lint.Rule{
+		Name:     "no-description",
+		Code:     "GDT-L999",
+		Severity: lint.SeverityWarning,
+		Check: func(t *lint.Target) []lint.Problem {
+			if t.Scenario.Description != "" {
+				return nil
+			}
+			return []lint.Problem{{
+				Node:    t.Field("name"),
+				Message: "scenario has no description",
+			}}
+		},
+	})
+
+	fp := filepath.Join("testdata", "no-description.yaml")
+	findings := lint.Run(fp)
+	require.Len(findings, 1)
+
+	f := findings[0]
+	assert.Equal("GDT-L999", f.Code)
+	assert.Equal(1, f.Line)
+	assert.Equal("scenario has no description", f.Message)
+
+	require.Empty(lint.Run(fp, lint.WithoutRules("no-description")))
+}
@@ -0,0 +1,229 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package lint
+
+import (
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/scenario"
+)
+
+const (
+	// ignoreDirective is the YAML comment prefix that suppresses findings of
+	// the rules or codes that follow it, e.g.
+	// `# gdt:lint-ignore unnamed-spec`.
+	ignoreDirective = "gdt:lint-ignore"
+)
+
+// Rule checks a parsed gdt test scenario for a class of problem that does not
+// stop the scenario from being parsed, e.g. a test spec without a name.
+type Rule struct {
+	// Name identifies the rule in suppressions, e.g. "unnamed-spec".
+	Name string
+	// Code is the machine-readable error code of the rule's findings, e.g.
+	// "GDT-L002".
+	Code string
+	// Severity is the default Severity of the rule's findings.
+	Severity Severity
+	// Optional rules are only checked when they are enabled with WithRules,
+	// e.g. rules about matters of style that many scenarios do not follow.
+	Optional bool
+	// Check returns the problems that the rule finds in the supplied Target.
+	Check func(t *Target) []Problem
+}
+
+// Problem is a single problem found by a Rule.
+type Problem struct {
+	// Node is the YAML node where the problem was found. It may be nil if the
+	// problem is not at a particular location.
+	Node *yaml.Node
+	// Message describes the problem.
+	Message string
+}
+
+// Target is a gdt test scenario that is checked by the lint Rules.
+type Target struct {
+	// Path is the filepath to the test scenario.
+	Path string
+	// Scenario is the parsed test scenario.
+	Scenario *scenario.Scenario
+	// Root is the mapping node of the test scenario's YAML document.
+	Root *yaml.Node
+}
+
+// Field returns the value node of the scenario's top-level field with the
+// supplied name, or nil if the scenario does not have the field.
+func (t *Target) Field(name string) *yaml.Node {
+	if t.Root == nil || t.Root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(t.Root.Content); i += 2 {
+		if t.Root.Content[i].Value == name {
+			return t.Root.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Entry returns the node of the entry with the supplied index in the
+// scenario's top-level sequence field with the supplied name, e.g. a test spec
+// in `tests`, or nil if there is no such entry.
+func (t *Target) Entry(field string, idx int) *yaml.Node {
+	node := t.Field(field)
+	if node == nil || node.Kind != yaml.SequenceNode ||
+		idx < 0 || idx >= len(node.Content) {
+		return nil
+	}
+	return node.Content[idx]
+}
+
+var (
+	rulesMu sync.RWMutex
+	// rules contains the registered Rules in the order they were registered.
+	rules = []Rule{}
+)
+
+// Register adds the supplied Rule to the Rules that Run checks scenarios
+// with. A Rule with the same Name as a registered Rule replaces it.
+func Register(r Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	for x, existing := range rules {
+		if existing.Name == r.Name {
+			rules[x] = r
+			return
+		}
+	}
+	rules = append(rules, r)
+}
+
+// Rules returns the registered Rules in the order they were registered.
+func Rules() []Rule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return append([]Rule{}, rules...)
+}
+
+// Option configures how Run lints a scenario.
+type Option func(*config)
+
+// config contains the configuration of a call to Run.
+type config struct {
+	// enabled contains the names of the Optional Rules that are checked.
+	enabled map[string]bool
+	// disabled contains the names of the Rules that are not checked.
+	disabled map[string]bool
+	// severities contains overridden Severities, keyed by Rule name.
+	severities map[string]Severity
+}
+
+// WithRules enables the Optional Rules with the supplied names.
+func WithRules(names ...string) Option {
+	return func(c *config) {
+		for _, name := range names {
+			c.enabled[name] = true
+		}
+	}
+}
+
+// WithoutRules disables the Rules with the supplied names.
+func WithoutRules(names ...string) Option {
+	return func(c *config) {
+		for _, name := range names {
+			c.disabled[name] = true
+		}
+	}
+}
+
+// WithSeverity reports the findings of the Rule with the supplied name with
+// the supplied Severity instead of the Rule's default Severity.
+func WithSeverity(name string, sev Severity) Option {
+	return func(c *config) {
+		c.severities[name] = sev
+	}
+}
+
+// suppressions returns the rule names and codes that the `gdt:lint-ignore`
+// directives in the supplied node's comments, or the comments of its scalar
+// children, suppress.
+func suppressions(node *yaml.Node) []string {
+	res := []string{}
+	nodes := []*yaml.Node{node}
+	for _, child := range node.Content {
+		if child.Kind == yaml.ScalarNode {
+			nodes = append(nodes, child)
+		}
+	}
+	for _, n := range nodes {
+		for _, c := range []string{n.HeadComment, n.LineComment, n.FootComment} {
+			for _, line := range strings.Split(c, "\n") {
+				line = strings.TrimSpace(strings.TrimPrefix(
+					strings.TrimSpace(line), "#",
+				))
+				rest, found := strings.CutPrefix(line, ignoreDirective)
+				if !found {
+					continue
+				}
+				res = append(res, strings.FieldsFunc(rest, func(r rune) bool {
+					return r == ',' || r == ' ' || r == '\t'
+				})...)
+			}
+		}
+	}
+	return res
+}
+
+// suppressor determines whether findings are suppressed by `gdt:lint-ignore`
+// directives in a scenario's YAML comments.
+type suppressor struct {
+	// doc is the scenario's YAML document node.
+	doc *yaml.Node
+	// parents contains the parent of each node in the document.
+	parents map[*yaml.Node]*yaml.Node
+}
+
+// newSuppressor returns a suppressor for the supplied YAML document node.
+func newSuppressor(doc *yaml.Node) *suppressor {
+	s := &suppressor{doc: doc, parents: map[*yaml.Node]*yaml.Node{}}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		for _, child := range n.Content {
+			s.parents[child] = n
+			walk(child)
+		}
+	}
+	if doc != nil {
+		walk(doc)
+	}
+	return s
+}
+
+// suppressed returns true if a finding with the supplied rule name or code at
+// the supplied node is suppressed by a directive on the node, on any node
+// containing it or at the top of the scenario file. A nil node is only
+// suppressed by a directive at the top of the file.
+func (s *suppressor) suppressed(name string, code string, node *yaml.Node) bool {
+	if s.doc == nil {
+		return false
+	}
+	nodes := []*yaml.Node{s.doc}
+	if len(s.doc.Content) > 0 {
+		nodes = append(nodes, s.doc.Content[0])
+	}
+	for n := node; n != nil && n != s.doc; n = s.parents[n] {
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		for _, sup := range suppressions(n) {
+			if sup == name || sup == code {
+				return true
+			}
+		}
+	}
+	return false
+}
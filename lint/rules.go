@@ -0,0 +1,209 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/expr"
+	"github.com/gdt-dev/core/internal/specconfig"
+	"github.com/gdt-dev/core/scenario"
+)
+
+const (
+	// CodeUnusedFixture is the code of findings of the unused-fixture Rule.
+	CodeUnusedFixture = "GDT-L001"
+	// CodeUnnamedSpec is the code of findings of the unnamed-spec Rule.
+	CodeUnnamedSpec = "GDT-L002"
+	// CodeRetryWithoutTimeout is the code of findings of the
+	// retry-without-timeout Rule.
+	CodeRetryWithoutTimeout = "GDT-L003"
+	// CodeUnreachableSkipIf is the code of findings of the
+	// unreachable-skip-if Rule.
+	CodeUnreachableSkipIf = "GDT-L004"
+)
+
+func init() {
+	Register(Rule{
+		Name:     "unused-fixture",
+		Code:     CodeUnusedFixture,
+		Severity: SeverityWarning,
+		Check:    checkUnusedFixtures,
+	})
+	Register(Rule{
+		Name:     "unnamed-spec",
+		Code:     CodeUnnamedSpec,
+		Severity: SeverityWarning,
+		Optional: true,
+		Check:    checkUnnamedSpecs,
+	})
+	Register(Rule{
+		Name:     "retry-without-timeout",
+		Code:     CodeRetryWithoutTimeout,
+		Severity: SeverityWarning,
+		Check:    checkRetryWithoutTimeout,
+	})
+	Register(Rule{
+		Name:     "unreachable-skip-if",
+		Code:     CodeUnreachableSkipIf,
+		Severity: SeverityWarning,
+		Check:    checkUnreachableSkipIf,
+	})
+}
+
+// checkUnusedFixtures finds fixtures that are listed in the scenario's
+// `fixtures` field but whose name is not referred to anywhere else in the
+// scenario, e.g. in a `state()` expression, a `set` field or a
+// `check.state` field. Fixtures that are only needed for their side effects
+// should suppress the rule.
+func checkUnusedFixtures(t *Target) []Problem {
+	fixNode := t.Field("fixtures")
+	if fixNode == nil {
+		return nil
+	}
+	refs := []string{}
+	var collect func(n *yaml.Node)
+	collect = func(n *yaml.Node) {
+		if n == fixNode {
+			return
+		}
+		if n.Kind == yaml.ScalarNode {
+			refs = append(refs, strings.FieldsFunc(
+				strings.ToLower(n.Value), isNameSeparator,
+			)...)
+		}
+		for _, child := range n.Content {
+			collect(child)
+		}
+	}
+	collect(t.Root)
+	res := []Problem{}
+	for x, name := range t.Scenario.Fixtures {
+		if !slices.Contains(refs, strings.ToLower(name)) {
+			res = append(res, Problem{
+				Node: t.Entry("fixtures", x),
+				Message: fmt.Sprintf(
+					"fixture %q is not referred to by the scenario", name,
+				),
+			})
+		}
+	}
+	return res
+}
+
+// isNameSeparator returns true if the supplied rune cannot be part of a
+// fixture name, e.g. the quotes and parentheses in `state("db", "ready")`.
+func isNameSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) &&
+		r != '_' && r != '-' && r != '.'
+}
+
+// checkUnnamedSpecs finds test specs without a `name`, which makes their
+// results hard to identify in test output. Many scenarios leave simple test
+// specs unnamed, so the rule is Optional.
+func checkUnnamedSpecs(t *Target) []Problem {
+	res := []Problem{}
+	for x, spec := range t.Scenario.Tests {
+		if spec.Base().Name != "" {
+			continue
+		}
+		res = append(res, Problem{
+			Node: t.Entry("tests", x),
+			Message: fmt.Sprintf(
+				"test spec %d has no name", x,
+			),
+		})
+	}
+	return res
+}
+
+// checkRetryWithoutTimeout finds test specs that are retried but have no
+// timeout, including from the scenario's or plugin's defaults. Such a test
+// spec retries forever when it has no limit on its retry attempts.
+func checkRetryWithoutTimeout(t *Target) []Problem {
+	defaults, ok := t.Scenario.Defaults[scenario.DefaultsKey].(*scenario.Defaults)
+	if !ok {
+		defaults = &scenario.Defaults{}
+	}
+	res := []Problem{}
+	for x, spec := range t.Scenario.Tests {
+		retry, _ := specconfig.Retry(spec, defaults.Retry)
+		if retry == nil || retry == api.NoRetry ||
+			(retry.Attempts != nil && *retry.Attempts == 0) {
+			continue
+		}
+		timeout, _ := specconfig.Timeout(spec, defaults.Timeout)
+		if timeout != nil && timeout.After != "" {
+			continue
+		}
+		res = append(res, Problem{
+			Node: t.Entry("tests", x),
+			Message: fmt.Sprintf(
+				"test spec %q is retried but has no timeout",
+				spec.Base().Title(),
+			),
+		})
+	}
+	return res
+}
+
+// checkUnreachableSkipIf finds `skip-if` expression conditions that do not
+// depend on anything, e.g. `expr: 1 > 2`. A condition that is always false
+// never skips the scenario. A condition that is always true skips the
+// scenario every time, so its tests and any later `skip-if` conditions are
+// never reached.
+func checkUnreachableSkipIf(t *Target) []Problem {
+	res := []Problem{}
+	alwaysSkips := false
+	for x, cond := range t.Scenario.SkipIf {
+		node := t.Entry("skip-if", x)
+		if alwaysSkips {
+			res = append(res, Problem{
+				Node: node,
+				Message: fmt.Sprintf(
+					"skip-if condition %q is unreachable because an "+
+						"earlier condition is always true",
+					cond.Base().Title(),
+				),
+			})
+			continue
+		}
+		c, ok := cond.(*scenario.Condition)
+		if !ok || !c.Expr.IsConstant() {
+			continue
+		}
+		val, err := c.Expr.EvalBool(expr.Env{})
+		if err != nil {
+			continue
+		}
+		if val {
+			alwaysSkips = true
+			res = append(res, Problem{
+				Node: node,
+				Message: fmt.Sprintf(
+					"skip-if condition %q is always true, so the scenario's "+
+						"tests are never run",
+					c.Expr,
+				),
+			})
+			continue
+		}
+		res = append(res, Problem{
+			Node: node,
+			Message: fmt.Sprintf(
+				"skip-if condition %q is always false and never skips the "+
+					"scenario",
+				c.Expr,
+			),
+		})
+	}
+	return res
+}
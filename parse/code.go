@@ -0,0 +1,116 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package parse
+
+// Stable, machine-readable codes for errors that occur while parsing gdt test
+// scenarios. These codes never change meaning once released, which allows
+// CLI output, SARIF reports and documentation to cross-reference a particular
+// class of error deterministically.
+//
+// The code ranges are allocated as follows:
+//
+//   - GDT-P0xx: the core parser (this package).
+//   - GDT-P1xx: the core JSON assertion package (assertion/json).
+//   - GDT-P2xx: the core exec plugin (plugin/exec).
+//   - GDT-P3xx: the core text assertion package (assertion/text), e.g.
+//     GDT-P301 and GDT-P302.
+//   - GDT-P4xx: the core check plugin (plugin/check), e.g. GDT-P401.
+//   - GDT-R0xx: runtime errors that occur while running test scenarios,
+//     defined in the api package.
+//   - GDT-L0xx: findings of the scenario linter, defined in the lint
+//     package.
+const (
+	// CodeUnknown is used for parse errors that have not been assigned a
+	// specific code.
+	CodeUnknown = "GDT-P000"
+	// CodeUnknownSpec indicates that no plugin could parse a spec.
+	CodeUnknownSpec = "GDT-P001"
+	// CodeUnknownField indicates an unknown field was found.
+	CodeUnknownField = "GDT-P002"
+	// CodeExpectedMap indicates a map field was expected.
+	CodeExpectedMap = "GDT-P003"
+	// CodeExpectedMapOrYAMLString indicates a map or a string containing
+	// embedded YAML was expected.
+	CodeExpectedMapOrYAMLString = "GDT-P004"
+	// CodeExpectedScalar indicates a scalar field was expected.
+	CodeExpectedScalar = "GDT-P005"
+	// CodeExpectedSequence indicates a sequence field was expected.
+	CodeExpectedSequence = "GDT-P006"
+	// CodeExpectedInt indicates an integer value was expected.
+	CodeExpectedInt = "GDT-P007"
+	// CodeExpectedScalarOrSequence indicates a scalar or sequence field was
+	// expected.
+	CodeExpectedScalarOrSequence = "GDT-P008"
+	// CodeExpectedScalarOrMap indicates a scalar or map field was expected.
+	CodeExpectedScalarOrMap = "GDT-P009"
+	// CodeExpectedBool indicates a boolean value was expected.
+	CodeExpectedBool = "GDT-P010"
+	// CodeExpectedTimeout indicates a timeout specification was expected.
+	CodeExpectedTimeout = "GDT-P011"
+	// CodeExpectedWait indicates a wait specification was expected.
+	CodeExpectedWait = "GDT-P012"
+	// CodeExpectedRetry indicates a retry specification was expected.
+	CodeExpectedRetry = "GDT-P013"
+	// CodeInvalidRetryAttempts indicates an invalid number of retry attempts.
+	CodeInvalidRetryAttempts = "GDT-P014"
+	// CodeFileNotFound indicates a referenced file could not be found.
+	CodeFileNotFound = "GDT-P015"
+	// CodeInvalidOS indicates an invalid operating system was specified.
+	CodeInvalidOS = "GDT-P016"
+	// CodeInvalidVersionConstraint indicates an invalid version constraint.
+	CodeInvalidVersionConstraint = "GDT-P017"
+	// CodeInvalidRegex indicates an invalid regular expression.
+	CodeInvalidRegex = "GDT-P018"
+	// CodeUnknownPlugin indicates that a spec selected a plugin that is not
+	// registered.
+	CodeUnknownPlugin = "GDT-P019"
+	// CodeAmbiguousSpec indicates that more than one plugin with the same
+	// priority could parse a spec.
+	CodeAmbiguousSpec = "GDT-P020"
+	// CodeDeprecatedField indicates that a spec used a field that its plugin
+	// has deprecated.
+	CodeDeprecatedField = "GDT-P021"
+	// CodeExpectedDuration indicates a duration value, e.g. "1s", was
+	// expected.
+	CodeExpectedDuration = "GDT-P022"
+	// CodeInvalidFixtureScope indicates an invalid fixture scope was
+	// specified.
+	CodeInvalidFixtureScope = "GDT-P023"
+	// CodeInvalidFixtureHealth indicates an invalid fixture health policy was
+	// specified.
+	CodeInvalidFixtureHealth = "GDT-P024"
+	// CodeInvalidExpression indicates an expression computing the value of a
+	// variable could not be parsed.
+	CodeInvalidExpression = "GDT-P025"
+	// CodeInvalidVariable indicates a variable's declaration, or its literal
+	// value, is invalid, e.g. the value does not have the variable's type.
+	CodeInvalidVariable = "GDT-P026"
+	// CodeInvalidArch indicates an invalid architecture was specified.
+	CodeInvalidArch = "GDT-P027"
+	// CodeInvalidService indicates an invalid service dependency was
+	// specified.
+	CodeInvalidService = "GDT-P028"
+	// CodeInvalidDependency indicates an invalid dependency was specified,
+	// for instance one with conflicting fields.
+	CodeInvalidDependency = "GDT-P029"
+	// CodeInvalidPackageManager indicates an invalid package manager was
+	// specified.
+	CodeInvalidPackageManager = "GDT-P030"
+	// CodeInvalidJSONPath indicates an invalid JSONPath expression was
+	// specified.
+	CodeInvalidJSONPath = "GDT-P031"
+	// CodeInvalidDependencyMissing indicates an invalid policy for missing
+	// dependencies was specified.
+	CodeInvalidDependencyMissing = "GDT-P032"
+	// CodeInvalidRetry indicates an invalid retry specification, e.g. a
+	// jitter outside of 0 to 1.
+	CodeInvalidRetry = "GDT-P033"
+	// CodeInvalidTimeout indicates an invalid timeout specification, e.g. a
+	// per-attempt timeout longer than the overall timeout.
+	CodeInvalidTimeout = "GDT-P034"
+	// CodeDuplicateSpecID indicates that more than one test spec in a
+	// scenario has the same `id`.
+	CodeDuplicateSpecID = "GDT-P035"
+)
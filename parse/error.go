@@ -6,7 +6,6 @@ package parse
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -18,12 +17,33 @@ var (
 	// ErrParseUnknownField indicates that there was an unknown field in the
 	// parsing of a spec or scenario. This is a sentinel error we use in
 	// parsing gdt test scenarios in the plugin system.
-	ErrParseUnknownField = errors.New("unknown field")
+	ErrParseUnknownField = &codedError{
+		code: CodeUnknownField,
+		msg:  "unknown field",
+	}
 )
 
+// codedError is a sentinel error that carries a stable error code.
+type codedError struct {
+	code string
+	msg  string
+}
+
+// Error implements the error interface for codedError.
+func (e *codedError) Error() string {
+	return e.msg
+}
+
+// ErrorCode returns the machine-readable error code.
+func (e *codedError) ErrorCode() string {
+	return e.code
+}
+
 // Error is a custom error type that stores the location of an error that
 // occurred while parsing a gdt test specification.
 type Error struct {
+	// Code is the stable, machine-readable error code, e.g. "GDT-P003".
+	Code string
 	// Path is the filepath to the parsed document.
 	Path string
 	// Line is the line number where the parse error occurred.
@@ -48,6 +68,15 @@ func (e *Error) Error() string {
 	)
 }
 
+// ErrorCode returns the machine-readable error code for the parse error. If
+// no specific code was assigned, CodeUnknown is returned.
+func (e *Error) ErrorCode() string {
+	if e.Code == "" {
+		return CodeUnknown
+	}
+	return e.Code
+}
+
 // SetContents adds the detail to the error message for surrounding contents if
 // the Path, Line and Column is set.
 func (e *Error) SetContents() {
@@ -91,6 +120,7 @@ func (e *Error) SetContents() {
 // YAML node.
 func UnknownSpecAt(path string, node *yaml.Node) error {
 	return &Error{
+		Code:    CodeUnknownSpec,
 		Path:    path,
 		Line:    node.Line,
 		Column:  node.Column,
@@ -98,6 +128,71 @@ func UnknownSpecAt(path string, node *yaml.Node) error {
 	}
 }
 
+// UnknownPluginAt returns a parse error for when a spec selects a plugin
+// that is not registered, annotated with the line/column of the supplied YAML
+// node.
+func UnknownPluginAt(name string, node *yaml.Node) error {
+	return &Error{
+		Code:    CodeUnknownPlugin,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("unknown plugin %q", name),
+	}
+}
+
+// AmbiguousSpecAt returns a parse error for when more than one plugin with the
+// same priority can parse a spec, annotated with the line/column of the
+// supplied YAML node.
+func AmbiguousSpecAt(path string, plugins []string, node *yaml.Node) error {
+	return &Error{
+		Code:   CodeAmbiguousSpec,
+		Path:   path,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"spec can be parsed by more than one plugin (%s). "+
+				"use the `plugin` field to select one",
+			strings.Join(plugins, ", "),
+		),
+	}
+}
+
+// DuplicateSpecIDAt returns a parse error for when a spec has the same `id`
+// as an earlier spec in the scenario, annotated with the line/column of the
+// supplied YAML node.
+func DuplicateSpecIDAt(path string, id string, node *yaml.Node) error {
+	return &Error{
+		Code:    CodeDuplicateSpecID,
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("duplicate spec id %q", id),
+	}
+}
+
+// DeprecatedFieldAt returns a parse error for when a spec uses a field that
+// the supplied plugin has deprecated, annotated with the line/column of the
+// supplied YAML node. guidance optionally describes what to use instead.
+func DeprecatedFieldAt(
+	path string,
+	plugin string,
+	field string,
+	guidance string,
+	node *yaml.Node,
+) error {
+	msg := fmt.Sprintf("field %q of plugin %q is deprecated", field, plugin)
+	if guidance != "" {
+		msg += ": " + guidance
+	}
+	return &Error{
+		Code:    CodeDeprecatedField,
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: msg,
+	}
+}
+
 // UnknownFieldAt returns an ErrUnknownField for a supplied field annotated
 // with the line/column of the supplied YAML node.
 func UnknownFieldAt(field string, node *yaml.Node) error {
@@ -111,6 +206,7 @@ func UnknownFieldAt(field string, node *yaml.Node) error {
 // map[string]interface{} did not contain that.
 func ExpectedMapAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedMap,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected map field",
@@ -122,6 +218,7 @@ func ExpectedMapAt(node *yaml.Node) error {
 // either of those things.
 func ExpectedMapOrYAMLStringAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedMapOrYAMLString,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected either map[string]interface{} or a string with embedded YAML",
@@ -132,6 +229,7 @@ func ExpectedMapOrYAMLStringAt(node *yaml.Node) error {
 // the line/column of the supplied YAML node.
 func ExpectedScalarAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedScalar,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected scalar field",
@@ -142,6 +240,7 @@ func ExpectedScalarAt(node *yaml.Node) error {
 // []interface{} did not contain that.
 func ExpectedSequenceAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedSequence,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected sequence field",
@@ -152,6 +251,7 @@ func ExpectedSequenceAt(node *yaml.Node) error {
 // integer did not contain that.
 func ExpectedIntAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedInt,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected int value",
@@ -163,6 +263,7 @@ func ExpectedIntAt(node *yaml.Node) error {
 // those things.
 func ExpectedScalarOrSequenceAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedScalarOrSequence,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected scalar or sequence of scalars field",
@@ -173,6 +274,7 @@ func ExpectedScalarOrSequenceAt(node *yaml.Node) error {
 // the line/column of the supplied YAML node.
 func ExpectedScalarOrMapAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedScalarOrMap,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected scalar or map field",
@@ -183,26 +285,51 @@ func ExpectedScalarOrMapAt(node *yaml.Node) error {
 // and annotated with the line/column of the supplied YAML node.
 func ExpectedBoolAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedBool,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected boolean value",
 	}
 }
 
+// ExpectedDurationAt returns a parse error indicating a duration value was
+// expected and annotated with the line/column of the supplied YAML node.
+func ExpectedDurationAt(node *yaml.Node) error {
+	return &Error{
+		Code:    CodeExpectedDuration,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "expected duration value, e.g. \"1s\"",
+	}
+}
+
 // ExpectedTimeoutAt returns an ErrExpectedTimeout error annotated
 // with the line/column of the supplied YAML node.
 func ExpectedTimeoutAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedTimeout,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected timeout specification",
 	}
 }
 
+// InvalidTimeoutAt returns an error indicating an invalid timeout
+// specification, annotated with the line/column of the supplied YAML node.
+func InvalidTimeoutAt(node *yaml.Node, reason string) error {
+	return &Error{
+		Code:    CodeInvalidTimeout,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid timeout: " + reason,
+	}
+}
+
 // ExpectedWaitAt returns an ErrExpectedWait error annotated with the
 // line/column of the supplied YAML node.
 func ExpectedWaitAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedWait,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected wait specification",
@@ -213,6 +340,7 @@ func ExpectedWaitAt(node *yaml.Node) error {
 // line/column of the supplied YAML node.
 func ExpectedRetryAt(node *yaml.Node) error {
 	return &Error{
+		Code:    CodeExpectedRetry,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected retry specification",
@@ -223,21 +351,90 @@ func ExpectedRetryAt(node *yaml.Node) error {
 // the line/column of the supplied YAML node.
 func InvalidRetryAttemptsAt(node *yaml.Node, attempts int) error {
 	return &Error{
+		Code:    CodeInvalidRetryAttempts,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("invalid retry attempts: %d", attempts),
 	}
 }
 
+// InvalidRetryAt returns an error indicating an invalid retry specification,
+// annotated with the line/column of the supplied YAML node.
+func InvalidRetryAt(node *yaml.Node, reason string) error {
+	return &Error{
+		Code:    CodeInvalidRetry,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid retry: " + reason,
+	}
+}
+
 // FileNotFoundAt returns ErrFileNotFound for a given file path
 func FileNotFoundAt(path string, node *yaml.Node) error {
 	return &Error{
+		Code:    CodeFileNotFound,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("file not found: %q", path),
 	}
 }
 
+// InvalidFixtureScopeAt returns an error indicating an invalid fixture scope
+// was specified, annotated with the line/column of the supplied YAML node.
+func InvalidFixtureScopeAt(
+	node *yaml.Node,
+	scope string,
+	valid []string,
+) error {
+	return &Error{
+		Code:   CodeInvalidFixtureScope,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid fixture scope specified: %s. valid values are %v",
+			scope, valid,
+		),
+	}
+}
+
+// InvalidFixtureHealthAt returns an error indicating an invalid fixture health
+// policy was specified, annotated with the line/column of the supplied YAML
+// node.
+func InvalidFixtureHealthAt(
+	node *yaml.Node,
+	policy string,
+	valid []string,
+) error {
+	return &Error{
+		Code:   CodeInvalidFixtureHealth,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid fixture health policy specified: %s. valid values are %v",
+			policy, valid,
+		),
+	}
+}
+
+// InvalidDependencyMissingAt returns an error indicating an invalid policy for
+// missing dependencies was specified, annotated with the line/column of the
+// supplied YAML node.
+func InvalidDependencyMissingAt(
+	node *yaml.Node,
+	policy string,
+	valid []string,
+) error {
+	return &Error{
+		Code:   CodeInvalidDependencyMissing,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid dependency missing policy specified: %s. valid values are %v",
+			policy, valid,
+		),
+	}
+}
+
 // InvalidOSAt returns an error indicating an invalid operating system was
 // specified, annotated with the line/column of the supplied YAML node.
 func InvalidOSAt(
@@ -246,6 +443,7 @@ func InvalidOSAt(
 	valid []string,
 ) error {
 	return &Error{
+		Code:   CodeInvalidOS,
 		Line:   node.Line,
 		Column: node.Column,
 		Message: fmt.Sprintf(
@@ -255,6 +453,84 @@ func InvalidOSAt(
 	}
 }
 
+// InvalidArchAt returns an error indicating an invalid architecture was
+// specified, annotated with the line/column of the supplied YAML node.
+func InvalidArchAt(
+	node *yaml.Node,
+	arch string,
+	valid []string,
+) error {
+	return &Error{
+		Code:   CodeInvalidArch,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid architecture specified: %s. valid values are %v",
+			arch, valid,
+		),
+	}
+}
+
+// InvalidServiceAt returns an error indicating an invalid service dependency
+// was specified, annotated with the line/column of the supplied YAML node.
+func InvalidServiceAt(node *yaml.Node, reason string) error {
+	return &Error{
+		Code:    CodeInvalidService,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid service dependency: " + reason,
+	}
+}
+
+// InvalidDependencyAt returns an error indicating an invalid dependency was
+// specified, annotated with the line/column of the supplied YAML node.
+func InvalidDependencyAt(node *yaml.Node, reason string) error {
+	return &Error{
+		Code:    CodeInvalidDependency,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid dependency: " + reason,
+	}
+}
+
+// InvalidPackageManagerAt returns an error indicating an invalid package
+// manager was specified, annotated with the line/column of the supplied YAML
+// node.
+func InvalidPackageManagerAt(
+	node *yaml.Node,
+	pm string,
+	valid []string,
+) error {
+	return &Error{
+		Code:   CodeInvalidPackageManager,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid package manager specified: %s. valid values are %v",
+			pm, valid,
+		),
+	}
+}
+
+// InvalidJSONPathAt returns an error indicating an invalid JSONPath
+// expression was specified, annotated with the line/column of the supplied
+// YAML node.
+func InvalidJSONPathAt(
+	node *yaml.Node,
+	path string,
+	err error,
+) error {
+	return &Error{
+		Code:   CodeInvalidJSONPath,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid JSONPath expression specified: %s: %s",
+			path, err,
+		),
+	}
+}
+
 // InvalidVersionConstraint returns an error indicating an invalid version
 // constraint was specified, annotated with the line/column of the supplied
 // YAML node.
@@ -264,6 +540,7 @@ func InvalidVersionConstraintAt(
 	err error,
 ) error {
 	return &Error{
+		Code:   CodeInvalidVersionConstraint,
 		Line:   node.Line,
 		Column: node.Column,
 		Message: fmt.Sprintf(
@@ -281,6 +558,7 @@ func InvalidRegexAt(
 	err error,
 ) error {
 	return &Error{
+		Code:   CodeInvalidRegex,
 		Line:   node.Line,
 		Column: node.Column,
 		Message: fmt.Sprintf(
@@ -289,3 +567,37 @@ func InvalidRegexAt(
 		),
 	}
 }
+
+// InvalidExpressionAt returns an error indicating an invalid expression was
+// specified, annotated with the line/column of the supplied YAML node.
+func InvalidExpressionAt(
+	node *yaml.Node,
+	expr string,
+	err error,
+) error {
+	return &Error{
+		Code:   CodeInvalidExpression,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid expression specified: %s: %s",
+			expr, err,
+		),
+	}
+}
+
+// InvalidVariableAt returns an error indicating the declaration or literal
+// value of the variable with the supplied name is invalid, annotated with the
+// line/column of the supplied YAML node.
+func InvalidVariableAt(
+	node *yaml.Node,
+	name string,
+	err error,
+) error {
+	return &Error{
+		Code:    CodeInvalidVariable,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("invalid variable %s: %s", name, err),
+	}
+}
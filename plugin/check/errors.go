@@ -0,0 +1,40 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package check
+
+import (
+	"fmt"
+
+	"github.com/gdt-dev/core/api"
+)
+
+var (
+	// ErrEnvNotSet is an api.ErrFailure when an environment variable that a
+	// check requires is not set.
+	ErrEnvNotSet = fmt.Errorf("%w: environment variable not set", api.ErrFailure)
+	// ErrFileNotFound is an api.ErrFailure when a file that a check requires
+	// does not exist.
+	ErrFileNotFound = fmt.Errorf("%w: file not found", api.ErrFailure)
+	// ErrStateNotFound is an api.ErrFailure when a fixture does not have
+	// state that a check requires.
+	ErrStateNotFound = fmt.Errorf("%w: fixture state not found", api.ErrFailure)
+)
+
+// EnvNotSet returns an ErrEnvNotSet for the supplied environment variable
+// name.
+func EnvNotSet(name string) error {
+	return fmt.Errorf("%w: %s", ErrEnvNotSet, name)
+}
+
+// FileNotFound returns an ErrFileNotFound for the supplied path.
+func FileNotFound(path string) error {
+	return fmt.Errorf("%w: %s", ErrFileNotFound, path)
+}
+
+// StateNotFound returns an ErrStateNotFound for the supplied fixture name and
+// state key.
+func StateNotFound(fixture, key string) error {
+	return fmt.Errorf("%w: %s has no %q", ErrStateNotFound, fixture, key)
+}
@@ -0,0 +1,77 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package check
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// Eval checks each of the Spec's conditions, returning a Result with a
+// failure for each condition that does not hold.
+//
+// Errors returned by Eval() are **RuntimeErrors**, not failures in assertions.
+func (s *Spec) Eval(ctx context.Context) (*api.Result, error) {
+	c := s.Check
+	failures := []error{}
+	for _, name := range c.Env {
+		if !envSet(ctx, name) {
+			failures = append(failures, EnvNotSet(name))
+		}
+	}
+	for _, path := range c.File {
+		if _, err := os.Stat(path); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			failures = append(failures, FileNotFound(path))
+		}
+	}
+	fixtures := gdtcontext.Fixtures(ctx)
+	for _, name := range slices.Sorted(maps.Keys(c.State)) {
+		fix, found := fixtures[name]
+		if !found {
+			return nil, api.RequiredFixtureMissing(name)
+		}
+		for _, key := range c.State[name] {
+			if !fix.HasState(key) {
+				failures = append(failures, StateNotFound(name, key))
+			}
+		}
+	}
+	if len(c.Expr) > 0 {
+		env := pluginutil.ExprEnv(ctx)
+		for _, e := range c.Expr {
+			ok, err := e.EvalBool(env)
+			switch {
+			case err != nil:
+				failures = append(failures, api.ExpressionFailed(e.String(), err))
+			case !ok:
+				failures = append(failures, api.ExpressionFalse(e.String()))
+			}
+		}
+	}
+	debug.Printf(ctx, "check: %d of the conditions failed", len(failures))
+	return api.NewResult(api.WithFailures(failures...)), nil
+}
+
+// envSet returns true if the named environment variable is published by one
+// of the context's fixtures or is set in the gdt process.
+func envSet(ctx context.Context, name string) bool {
+	if _, found := gdtcontext.Env(ctx)[name]; found {
+		return true
+	}
+	_, found := os.LookupEnv(name)
+	return found
+}
@@ -0,0 +1,135 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package check_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/fixture"
+	"github.com/gdt-dev/core/plugin/check"
+	"github.com/gdt-dev/core/run"
+	"github.com/gdt-dev/core/scenario"
+)
+
+func dbContext() context.Context {
+	return gdtcontext.New(gdtcontext.WithFixtures(map[string]api.Fixture{
+		"db": fixture.New(fixture.WithState(map[string]any{
+			"dsn":  "postgres://localhost:5432/books",
+			"port": 5432,
+		})),
+	}))
+}
+
+func TestCheck(t *testing.T) {
+	require := require.New(t)
+
+	t.Setenv("GDT_CHECK_REGION", "eu-west-1")
+
+	fp := filepath.Join("testdata", "check.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(dbContext(), t)
+	require.Nil(err)
+}
+
+func TestCheckFailures(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "check-failures.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(dbContext(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	failures := results[0].Failures()
+	require.Len(failures, 4)
+	require.ErrorIs(failures[0], check.ErrEnvNotSet)
+	require.ErrorContains(failures[0], "GDT_CHECK_UNSET")
+	require.ErrorIs(failures[1], check.ErrFileNotFound)
+	require.ErrorContains(failures[1], "missing.yaml")
+	require.ErrorIs(failures[2], check.ErrStateNotFound)
+	require.ErrorContains(failures[2], `db has no "password"`)
+	require.ErrorIs(failures[3], api.ErrExpressionFalse)
+}
+
+func TestCheckCancelled(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "check-retry.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	// Cancelling the context, e.g. to stop `run.Watch`, stops the retries of
+	// a test spec that has no timeout and is not reported as a timeout.
+	ctx, cancel := context.WithCancel(gdtcontext.New())
+	defer cancel()
+	time.AfterFunc(200*time.Millisecond, cancel)
+	r := run.New()
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	failures := results[0].Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], context.Canceled)
+	require.NotContains(failures[0].Error(), "timeout exceeded")
+}
+
+func TestSkipIfCheck(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "skip-if-check.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+	require.True(t.Skipped())
+}
+
+func TestFailingCheckEmpty(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "check-empty.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(check.CodeCheckEmpty, api.ErrorCode(err))
+	require.Nil(s)
+}
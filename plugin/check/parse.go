@@ -0,0 +1,102 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package check
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// Error codes for check plugin parse errors.
+const (
+	// CodeCheckEmpty indicates a missing or empty check field.
+	CodeCheckEmpty = "GDT-P401"
+)
+
+// CheckEmpty returns a parse error with the line/column of the supplied YAML
+// node indicating that a check field has no conditions.
+func CheckEmpty(node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeCheckEmpty,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "expected check field with at least one of env, file, state or expr",
+	}
+}
+
+// Defaults is the known check plugin defaults collection. The check plugin
+// has no defaults.
+type Defaults struct{}
+
+// Merge merges the supplied map of key/value combinations with the set of
+// handled defaults for the plugin.
+func (d *Defaults) Merge(map[string]any) {}
+
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	return nil
+}
+
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	err := pluginutil.DecodeSpec(node, pluginutil.Fields{
+		"check": func(valNode *yaml.Node) error {
+			c := &Check{}
+			if err := valNode.Decode(c); err != nil {
+				return err
+			}
+			if len(c.Env) == 0 && len(c.File) == 0 &&
+				len(c.State) == 0 && len(c.Expr) == 0 {
+				return CheckEmpty(valNode)
+			}
+			s.Check = c
+			return nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if s.Check == nil {
+		return CheckEmpty(node)
+	}
+	return nil
+}
+
+func (c *Check) UnmarshalYAML(node *yaml.Node) error {
+	return pluginutil.DecodeFields(node, pluginutil.Fields{
+		"env":  pluginutil.Strings(&c.Env),
+		"file": pluginutil.Strings(&c.File),
+		"state": func(valNode *yaml.Node) error {
+			state := map[string][]string{}
+			err := pluginutil.WalkMap(
+				valNode,
+				func(fixture string, _, keysNode *yaml.Node) error {
+					keys, err := pluginutil.StringsAt(keysNode)
+					if err != nil {
+						return err
+					}
+					state[fixture] = keys
+					return nil
+				},
+			)
+			if err != nil {
+				return err
+			}
+			c.State = state
+			return nil
+		},
+		"expr": func(valNode *yaml.Node) error {
+			exprs, err := pluginutil.ExprsAt(valNode)
+			if err != nil {
+				return err
+			}
+			c.Expr = exprs
+			return nil
+		},
+	})
+}
@@ -0,0 +1,92 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package check implements the `check` plugin that is built into gdt core.
+// A check test spec asserts that environment variables are set, that files
+// exist, that fixtures have state and that expressions are true, without
+// executing a command, which makes it a natural fit for `skip-if` and
+// `run-if` conditions.
+package check
+
+import (
+	"github.com/gdt-dev/core/api"
+	gdtplugin "github.com/gdt-dev/core/plugin"
+)
+
+var (
+	// this is just for testing purposes...
+	PluginRef = &plugin{}
+)
+
+func init() {
+	// The check plugin is registered with a lower priority than other
+	// plugins so that it is only tried after them and never shadows them.
+	gdtplugin.Register(PluginRef, gdtplugin.WithPriority(-1))
+}
+
+const (
+	pluginName = "check"
+)
+
+type plugin struct{}
+
+var (
+	// fieldDocs documents the check plugin's test spec fields.
+	fieldDocs = []api.FieldDoc{
+		{
+			Name:        "check",
+			Type:        "map",
+			Description: "conditions that must all hold. at least one field is required",
+			Required:    true,
+			Fields: []api.FieldDoc{
+				{
+					Name:        "env",
+					Type:        "string or []string",
+					Description: "environment variables that must be set, either by a fixture or in the gdt process",
+					Examples:    []string{"CI", "[AWS_REGION, AWS_PROFILE]"},
+				},
+				{
+					Name:        "file",
+					Type:        "string or []string",
+					Description: "paths, relative to the scenario file, that must exist",
+					Examples:    []string{"testdata/config.yaml"},
+				},
+				{
+					Name:        "state",
+					Type:        "map",
+					Description: "state keys, or lists of state keys, that the fixture with each name must have",
+					Examples:    []string{"{db: dsn}", "{db: [dsn, port]}"},
+				},
+				{
+					Name:        "expr",
+					Type:        "string or []string",
+					Description: "expressions that must be true",
+					Examples:    []string{"env.CI == \"true\"", "state(\"db\", \"port\") > 0"},
+				},
+			},
+		},
+	}
+)
+
+func (p *plugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name:        pluginName,
+		APIVersion:  api.APIVersion,
+		Description: "checks environment variables, files, fixture state and expressions",
+		Fields:      fieldDocs,
+	}
+}
+
+func (p *plugin) Defaults() api.DefaultsHandler {
+	return &Defaults{}
+}
+
+func (p *plugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&Spec{}}
+}
+
+// Plugin returns the check gdt plugin
+func Plugin() api.Plugin {
+	return &plugin{}
+}
@@ -0,0 +1,49 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package check
+
+import (
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/expr"
+)
+
+// Spec describes a single Spec that checks conditions of the test
+// environment.
+type Spec struct {
+	api.Spec
+	// Check contains the conditions that must all hold for the Spec to pass.
+	Check *Check `yaml:"check"`
+}
+
+// Check contains the conditions of a check Spec.
+type Check struct {
+	// Env contains the names of environment variables that must be set.
+	// Environment variables published by fixtures are checked before those
+	// of the gdt process.
+	Env []string `yaml:"env,omitempty"`
+	// File contains paths, relative to the scenario file, that must exist.
+	File []string `yaml:"file,omitempty"`
+	// State contains the state keys, keyed by fixture name, that each
+	// fixture must have.
+	State map[string][]string `yaml:"state,omitempty"`
+	// Expr contains the expressions that must be true.
+	Expr []*expr.Expr `yaml:"-"`
+}
+
+func (s *Spec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *Spec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *Spec) Retry() *api.Retry {
+	return nil
+}
+
+func (s *Spec) Timeout() *api.Timeout {
+	return nil
+}
@@ -0,0 +1,173 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+)
+
+// HasName returns true if the supplied plugin's name or one of its aliases
+// matches the supplied name, ignoring case. If the plugin was registered
+// under a namespace, the name or alias qualified with the namespace, e.g.
+// "myorg.http", also matches.
+func HasName(p api.Plugin, name string) bool {
+	info := p.Info()
+	ns := Namespace(p)
+	names := append([]string{info.Name}, info.Aliases...)
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+		if ns != "" && strings.EqualFold(qualify(ns, n), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the registered plugin with the supplied name or alias,
+// ignoring case. The name may be qualified with the namespace that the plugin
+// was registered under. Returns an ErrNotFound if no such plugin is
+// registered or an ErrAmbiguousName if an unqualified name matches plugins in
+// more than one namespace.
+func Resolve(name string) (api.Plugin, error) {
+	matches := []api.Plugin{}
+	for _, p := range Registered() {
+		if HasName(p, name) {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, NotFound(name)
+	case 1:
+		return matches[0], nil
+	}
+	qualified := make([]string, len(matches))
+	for x, p := range matches {
+		qualified[x] = QualifiedName(p)
+	}
+	return nil, AmbiguousName(name, qualified)
+}
+
+// Lookup returns the registered plugin with the supplied name or alias,
+// ignoring case. See Resolve for how namespaced names are handled.
+func Lookup(name string) (api.Plugin, bool) {
+	p, err := Resolve(name)
+	return p, err == nil
+}
+
+// Describe returns reference documentation for the registered plugin with the
+// supplied name or alias, generated from the plugin's PluginInfo. Returns the
+// same errors as Resolve.
+func Describe(name string) (string, error) {
+	p, err := Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	info := p.Info()
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n", QualifiedName(p))
+	if info.Description != "" {
+		fmt.Fprintf(b, "\n%s\n", info.Description)
+	}
+	if len(info.Aliases) > 0 {
+		fmt.Fprintf(b, "\naliases: %s\n", strings.Join(info.Aliases, ", "))
+	}
+	if info.Timeout != nil || info.Retry != nil {
+		b.WriteString("\n")
+	}
+	if info.Timeout != nil {
+		if info.Timeout.After != "" {
+			fmt.Fprintf(b, "default timeout: %s\n", info.Timeout.After)
+		}
+		if info.Timeout.Attempt != "" {
+			fmt.Fprintf(b, "default attempt timeout: %s\n", info.Timeout.Attempt)
+		}
+	}
+	if info.Retry != nil {
+		fmt.Fprintf(b, "default retry: %s\n", describeRetry(info.Retry))
+	}
+	if len(info.Fields) > 0 {
+		b.WriteString("\nfields:\n")
+		describeFields(b, info.Fields, "")
+	}
+	if len(info.Deprecated) > 0 {
+		b.WriteString("\ndeprecated fields:\n")
+		for _, df := range info.Deprecated {
+			fmt.Fprintf(b, "  %s\n", df.Name)
+			if g := df.Guidance(); g != "" {
+				fmt.Fprintf(b, "      %s\n", g)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// describeRetry returns a short description of the supplied Retry.
+func describeRetry(r *api.Retry) string {
+	if r.Attempts == nil && r.Interval == "" && !r.Exponential &&
+		r.MaxElapsed == "" && r.SuccessesRequired == nil {
+		return "none"
+	}
+	parts := []string{}
+	if r.Attempts != nil {
+		parts = append(parts, fmt.Sprintf("%d attempts", *r.Attempts))
+	}
+	if r.Interval != "" {
+		parts = append(parts, "interval "+r.Interval)
+	}
+	if r.Exponential {
+		parts = append(parts, "exponential backoff")
+	}
+	if r.Jitter != nil {
+		parts = append(parts, fmt.Sprintf("jitter %g", *r.Jitter))
+	}
+	if r.Multiplier != nil {
+		parts = append(parts, fmt.Sprintf("multiplier %g", *r.Multiplier))
+	}
+	if r.MaxInterval != "" {
+		parts = append(parts, "max interval "+r.MaxInterval)
+	}
+	if r.MaxElapsed != "" {
+		parts = append(parts, "max elapsed "+r.MaxElapsed)
+	}
+	if r.SuccessesRequired != nil {
+		parts = append(parts, fmt.Sprintf(
+			"%d consecutive successes", *r.SuccessesRequired,
+		))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeFields writes documentation for the supplied fields, using the
+// fully-qualified dotted name for nested fields.
+func describeFields(b *strings.Builder, fields []api.FieldDoc, prefix string) {
+	for _, f := range fields {
+		name := prefix + f.Name
+		attrs := []string{}
+		if f.Type != "" {
+			attrs = append(attrs, f.Type)
+		}
+		if f.Required {
+			attrs = append(attrs, "required")
+		}
+		fmt.Fprintf(b, "  %s", name)
+		if len(attrs) > 0 {
+			fmt.Fprintf(b, " (%s)", strings.Join(attrs, ", "))
+		}
+		b.WriteString("\n")
+		if f.Description != "" {
+			fmt.Fprintf(b, "      %s\n", f.Description)
+		}
+		for _, ex := range f.Examples {
+			fmt.Fprintf(b, "      example: %s\n", ex)
+		}
+		describeFields(b, f.Fields, name+".")
+	}
+}
@@ -0,0 +1,55 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+)
+
+var (
+	// ErrNotFound indicates that no registered plugin has a requested name
+	// or alias.
+	ErrNotFound = errors.New("plugin not found")
+	// ErrAmbiguousName indicates that more than one registered plugin has a
+	// requested name or alias.
+	ErrAmbiguousName = errors.New("ambiguous plugin name")
+	// ErrIncompatibleAPIVersion indicates that a plugin was built against a
+	// version of the gdt core plugin API that this version of gdt core does
+	// not support.
+	ErrIncompatibleAPIVersion = errors.New("incompatible plugin API version")
+)
+
+// NotFound returns an ErrNotFound describing the supplied plugin name.
+func NotFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// AmbiguousName returns an ErrAmbiguousName describing the supplied plugin
+// name and the qualified names of the plugins that it matches.
+func AmbiguousName(name string, matches []string) error {
+	return fmt.Errorf(
+		"%w: %s matches %s; use a qualified plugin name",
+		ErrAmbiguousName, name, strings.Join(matches, ", "),
+	)
+}
+
+// IncompatibleAPIVersion returns an ErrIncompatibleAPIVersion describing the
+// supplied plugin name and the API version it was built against.
+func IncompatibleAPIVersion(name string, version int) error {
+	advice := "upgrade gdt core"
+	if version < api.MinAPIVersion {
+		advice = "upgrade the plugin"
+	}
+	return fmt.Errorf(
+		"%w: plugin %q was built against API version %d but this version "+
+			"of gdt core supports API versions %d through %d; %s",
+		ErrIncompatibleAPIVersion, name, version,
+		api.MinAPIVersion, api.APIVersion, advice,
+	)
+}
@@ -5,9 +5,12 @@
 package exec
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"io"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"github.com/gdt-dev/core/api"
@@ -17,6 +20,15 @@ import (
 	"github.com/samber/lo"
 )
 
+const (
+	// stdinFilePrefix is the prefix of a `stdin` field value that refers to
+	// a file.
+	stdinFilePrefix = "file://"
+	// defaultScriptShell is the shell used to execute a Script when no Shell
+	// is specified.
+	defaultScriptShell = "sh"
+)
+
 // Action describes a single execution of one or more commands via the
 // operating system's `exec` family of functions.
 type Action struct {
@@ -27,51 +39,236 @@ type Action struct {
 	// practice, however, to simply use multiple `exec` specs instead of
 	// executing multiple commands in a single shell call.
 	Exec string `yaml:"exec"`
+	// Sequence is a list of commands to execute one after another, used
+	// instead of Exec when the `exec` field contains a list. Execution stops
+	// at the first command that returns a non-zero exit code unless
+	// IgnoreErrors is true. The stdout and stderr of all executed commands
+	// are combined.
+	Sequence []string `yaml:"-"`
+	// IgnoreErrors indicates that all commands in Sequence should be executed
+	// even if one of them returns a non-zero exit code.
+	IgnoreErrors bool `yaml:"ignore-errors,omitempty"`
+	// Script is the path to a shell script file to execute instead of Exec.
+	// A relative Script is relative to the directory containing the test
+	// scenario file. The script is executed with Shell, or "sh" if Shell is
+	// empty.
+	Script string `yaml:"script,omitempty"`
 	// Shell is the specific shell to use in executing the command. If empty
 	// (the default), no shell is used to execute the command and instead the
 	// operating system's `exec` family of calls is used.
 	Shell string `yaml:"shell,omitempty"`
+	// Stdin is optional content that is piped to the command's standard
+	// input. If Stdin begins with "file://", the contents of the file at the
+	// remainder of the value are piped to the command's standard input
+	// instead.
+	Stdin string `yaml:"stdin,omitempty"`
+	// Dir is the optional working directory for the command. A relative Dir
+	// is relative to the directory containing the test scenario file. If
+	// empty, the command is run in the test scenario file's directory.
+	Dir string `yaml:"dir,omitempty"`
+	// User is the name or numeric ID of the user the command runs as. Running
+	// a command as a different user usually requires gdt to run as root. Not
+	// supported on Windows.
+	User string `yaml:"user,omitempty"`
+	// Group is the name or numeric ID of the group the command runs as. If
+	// empty, the command runs as User's primary group. Not supported on
+	// Windows.
+	Group string `yaml:"group,omitempty"`
+	// RLimit contains resource limits applied to the command. Only supported
+	// on Linux.
+	RLimit *RLimit `yaml:"rlimit,omitempty"`
 	// VarStdout is a shortcut for Var:{VARIABLE_NAME}:from:stdout
 	VarStdout string `yaml:"var-stdout,omitempty"`
 	// VarStderr is a shortcut for Var:{VARIABLE_NAME}:from:stderr
 	VarStderr string `yaml:"var-stderr,omitempty"`
 	// VarRC is a shortcut for Var:{VARIABLE_NAME}:from:returncode
 	VarRC string `yaml:"var-rc,omitempty"`
+	// env is the set of environment variables, from the exec plugin's
+	// defaults, that are added to the command's environment.
+	env map[string]string
+}
+
+// applyDefaults sets the Action's shell and working directory from the
+// supplied exec plugin defaults when the Action does not specify its own, and
+// adds the defaults' environment variables to the command's environment.
+func (a *Action) applyDefaults(d *execDefaults) {
+	if a.Shell == "" {
+		a.Shell = d.Shell
+	}
+	if a.Dir == "" {
+		a.Dir = d.Dir
+	}
+	a.env = d.Env
 }
 
-// Do performs a single command or shell execution returning the corresponding
-// exit code and any runtime error. The `outbuf` and `errbuf` buffers will be
-// filled with the contents of the command's stdout and stderr pipes
-// respectively.
+// Do performs a single command or shell execution, or each command in the
+// Action's Sequence, returning the corresponding exit code and any runtime
+// error. The `outbuf` and `errbuf` buffers will be filled with the contents
+// of the commands' stdout and stderr pipes respectively. For a Sequence, the
+// exit code is that of the last command executed.
 func (a *Action) Do(
 	ctx context.Context,
-	outbuf *bytes.Buffer,
-	errbuf *bytes.Buffer,
+	outbuf *Spool,
+	errbuf *Spool,
+	exitcode *int,
+) error {
+	cmds := a.Sequence
+	if len(cmds) == 0 {
+		cmds = []string{a.Exec}
+	}
+	for x, cmd := range cmds {
+		ec := 0
+		if err := a.run(ctx, cmd, outbuf, errbuf, &ec); err != nil {
+			return err
+		}
+		if exitcode != nil {
+			*exitcode = ec
+		}
+		if ec != 0 && !a.IgnoreErrors && x < len(cmds)-1 {
+			debug.Printf(
+				ctx, "exec: stopping after command %d exited %d", x, ec,
+			)
+			break
+		}
+	}
+	return nil
+}
+
+// run performs a single command or shell execution of the supplied command.
+func (a *Action) run(
+	ctx context.Context,
+	cmdstr string,
+	outbuf *Spool,
+	errbuf *Spool,
 	exitcode *int,
 ) error {
+	target, args := a.command(ctx, cmdstr)
+	cmd := exec.CommandContext(ctx, target, args...)
+	stdin, err := a.configure(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer stdin.Close()
+
+	// The command's stdout and stderr are copied into the Spools
+	// concurrently, so that a command that fills one pipe before writing to
+	// the other does not block. Output that is not wanted is discarded.
+	outstart, errstart := int64(0), int64(0)
+	if outbuf != nil {
+		outstart = outbuf.Len()
+		cmd.Stdout = outbuf
+	}
+	if errbuf != nil {
+		errstart = errbuf.Len()
+		cmd.Stderr = errbuf
+	}
+
+	err = a.start(cmd)
+	if gdtcontext.TimedOut(ctx, err) {
+		return api.ErrTimeoutExceeded
+	}
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Wait()
+	logOutput(ctx, "stdout", outbuf, outstart)
+	logOutput(ctx, "stderr", errbuf, errstart)
+	if gdtcontext.TimedOut(ctx, err) {
+		return api.ErrTimeoutExceeded
+	}
+	var eerr *exec.ExitError
+	if errors.As(err, &eerr) {
+		if exitcode != nil {
+			*exitcode = eerr.ExitCode()
+		}
+		return nil
+	}
+	if err != nil {
+		// e.g. the output could not be spooled to a temporary file.
+		debug.Printf(ctx, "exec: error reading output: %s", err)
+	}
+	return nil
+}
+
+// logOutput writes a debug message with the output of the named pipe that was
+// written to the supplied Spool after the supplied offset.
+func logOutput(
+	ctx context.Context,
+	name string,
+	buf *Spool,
+	start int64,
+) {
+	switch {
+	case buf == nil || buf.Len() == start:
+	case buf.Spooled():
+		debug.Printf(
+			ctx, "exec: %s: %d bytes spooled to disk", name, buf.Len(),
+		)
+	default:
+		debug.Printf(
+			ctx, "exec: %s: %s", name, strings.TrimSpace(buf.String()),
+		)
+	}
+}
+
+// files returns the paths of the files that the action references: its script
+// and a file piped to its stdin.
+func (a *Action) files() []string {
+	files := []string{}
+	if a.Script != "" {
+		files = append(files, a.Script)
+	}
+	if path, ok := strings.CutPrefix(a.Stdin, stdinFilePrefix); ok {
+		files = append(files, path)
+	}
+	return files
+}
+
+// stdin returns a reader for the content that should be piped to the
+// command's standard input.
+func (a *Action) stdin(ctx context.Context) (io.ReadCloser, error) {
+	if path, ok := strings.CutPrefix(a.Stdin, stdinFilePrefix); ok {
+		debug.Printf(ctx, "exec: stdin: %s", path)
+		return os.Open(path)
+	}
+	contents := gdtcontext.ReplaceVariables(ctx, a.Stdin)
+	return io.NopCloser(strings.NewReader(contents)), nil
+}
+
+// command returns the target executable and arguments for the supplied
+// command, with any variables from prior test specs replaced.
+func (a *Action) command(
+	ctx context.Context,
+	cmdstr string,
+) (string, []string) {
 	var target string
 	var args []string
-	if a.Shell == "" {
+	if a.Script != "" {
+		target = a.Shell
+		if target == "" {
+			target = defaultScriptShell
+		}
+		args = []string{a.Script}
+	} else if a.Shell == "" {
 		// Parse time already validated exec string parses into valid shell
 		// args
-		args, _ = shlex.Split(a.Exec)
+		args, _ = shlex.Split(cmdstr)
 		target = args[0]
 		args = args[1:]
 	} else {
 		target = a.Shell
-		args = []string{"-c", a.Exec}
+		args = []string{"-c", cmdstr}
 	}
 
 	origTarget := target
 	target = gdtcontext.ReplaceVariables(ctx, target)
 	if target != origTarget {
-		if origTarget != target {
-			debug.Printf(
-				ctx,
-				"exec: replaced target: %s -> %s",
-				origTarget, target,
-			)
-		}
+		debug.Printf(
+			ctx,
+			"exec: replaced target: %s -> %s",
+			origTarget, target,
+		)
 	}
 	args = lo.Map(args, func(arg string, _ int) string {
 		origArg := arg
@@ -87,56 +284,55 @@ func (a *Action) Do(
 	})
 
 	debug.Printf(ctx, "exec: %s %s", target, args)
+	return target, args
+}
 
-	cmd := exec.CommandContext(ctx, target, args...)
-
-	outpipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-	errpipe, err := cmd.StderrPipe()
-	if err != nil {
-		return err
+// start starts the supplied command, applying the Action's resource limits,
+// if any.
+func (a *Action) start(cmd *exec.Cmd) error {
+	if a.RLimit == nil {
+		return cmd.Start()
 	}
+	return a.RLimit.start(cmd)
+}
 
-	err = cmd.Start()
-	if gdtcontext.TimedOut(ctx, err) {
-		return api.ErrTimeoutExceeded
-	}
-	if err != nil {
-		return err
+// configure sets the working directory, environment, user and group, and
+// standard input of the supplied command. The returned Closer must be closed
+// once the command has exited.
+func (a *Action) configure(
+	ctx context.Context,
+	cmd *exec.Cmd,
+) (io.Closer, error) {
+	if err := a.setCredential(ctx, cmd); err != nil {
+		return nil, err
 	}
-	if outbuf != nil {
-		if _, err = outbuf.ReadFrom(outpipe); err != nil {
-			debug.Printf(ctx, "exec: error reading from stdout: %s", err)
-		}
-		if outbuf.Len() > 0 {
-			debug.Printf(
-				ctx, "exec: stdout: %s",
-				strings.TrimSpace(outbuf.String()),
-			)
-		}
+	if a.Dir != "" {
+		debug.Printf(ctx, "exec: dir: %s", a.Dir)
+		cmd.Dir = a.Dir
 	}
-	if errbuf != nil {
-		if _, err = errbuf.ReadFrom(errpipe); err != nil {
-			debug.Printf(ctx, "exec: error reading from stderr: %s", err)
+	fixEnv := gdtcontext.Env(ctx)
+	if len(fixEnv) > 0 || len(a.env) > 0 {
+		cmd.Env = os.Environ()
+		keys := lo.Keys(fixEnv)
+		sort.Strings(keys)
+		for _, k := range keys {
+			cmd.Env = append(cmd.Env, k+"="+fixEnv[k])
 		}
-		if errbuf.Len() > 0 {
-			debug.Printf(
-				ctx, "exec: stderr: %s",
-				strings.TrimSpace(errbuf.String()),
-			)
+		keys = lo.Keys(a.env)
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := gdtcontext.ReplaceVariables(ctx, a.env[k])
+			debug.Printf(ctx, "exec: env: %s=%s", k, v)
+			cmd.Env = append(cmd.Env, k+"="+v)
 		}
 	}
-
-	err = cmd.Wait()
-	if gdtcontext.TimedOut(ctx, err) {
-		return api.ErrTimeoutExceeded
+	if a.Stdin == "" {
+		return io.NopCloser(nil), nil
 	}
-	if err != nil && exitcode != nil {
-		eerr, _ := err.(*exec.ExitError)
-		ec := eerr.ExitCode()
-		*exitcode = ec
+	stdin, err := a.stdin(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	cmd.Stdin = stdin
+	return stdin, nil
 }
@@ -5,13 +5,16 @@
 package exec
 
 import (
-	"bytes"
 	"context"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 
 	"github.com/gdt-dev/core/api"
+	gdtjson "github.com/gdt-dev/core/assertion/json"
+	gdttext "github.com/gdt-dev/core/assertion/text"
+	gdtyaml "github.com/gdt-dev/core/assertion/yaml"
 	gdtcontext "github.com/gdt-dev/core/context"
 	"github.com/gdt-dev/core/debug"
 )
@@ -25,10 +28,55 @@ type Expect struct {
 	// (0) is the universal successful exit code, so you only need to set this
 	// if you expect a non-successful result from executing the command.
 	ExitCode int `yaml:"exit-code,omitempty"`
+	// ExitCodes is a set of exit codes, any one of which is expected for the
+	// executed command. ExitCodes is populated when the `exit-code` field
+	// contains a list or a range, e.g. "1-3", and ExitCode is ignored when
+	// ExitCodes is not empty.
+	ExitCodes []int `yaml:"-"`
 	// Out has things that are expected in the stdout response
 	Out *PipeExpect `yaml:"out,omitempty"`
 	// Err has things that are expected in the stderr response
 	Err *PipeExpect `yaml:"err,omitempty"`
+	// Duration has the bounds on how long the command is expected to take to
+	// execute.
+	Duration *DurationExpect `yaml:"duration,omitempty"`
+	// Files is a map, keyed by file path, of assertions about files after the
+	// command has executed. Relative file paths are relative to the command's
+	// working directory.
+	Files map[string]*FileExpect `yaml:"files,omitempty"`
+}
+
+// files returns the paths of the files that the assertions reference, e.g.
+// golden files and JSONSchema files.
+func (e *Expect) files() []string {
+	files := []string{}
+	for _, pe := range []*PipeExpect{e.Out, e.Err} {
+		if pe == nil {
+			continue
+		}
+		if pe.JSON != nil {
+			files = append(files, pe.JSON.Files()...)
+		}
+		if pe.Text != nil {
+			files = append(files, pe.Text.Files()...)
+		}
+	}
+	for _, fe := range e.Files {
+		if fe != nil && fe.JSON != nil {
+			files = append(files, fe.JSON.Files()...)
+		}
+	}
+	return files
+}
+
+// DurationExpect contains assertions about how long a command took to execute
+type DurationExpect struct {
+	// Max is the longest the command may take to execute. Zero means no
+	// maximum.
+	Max time.Duration `yaml:"max,omitempty"`
+	// Min is the shortest the command may take to execute. Zero means no
+	// minimum.
+	Min time.Duration `yaml:"min,omitempty"`
 }
 
 // PipeExpect contains assertions about the contents of a pipe
@@ -42,13 +90,23 @@ type PipeExpect struct {
 	// ContainsOneOf is one or more strings of which *at least one* must be
 	// present in the contents of the pipe
 	ContainsAny *api.FlexStrings `yaml:"contains-one-of,omitempty"`
+	// JSON contains assertions about the contents of the pipe when it is
+	// parsed as JSON.
+	JSON *gdtjson.Expect `yaml:"json,omitempty"`
+	// YAML contains assertions about the contents of the pipe when it is
+	// parsed as YAML.
+	YAML *gdtyaml.Expect `yaml:"yaml,omitempty"`
+	// Text contains the regular expression (`matches`), line count
+	// (`line-count`) and golden file (`golden`) assertions about the contents
+	// of the pipe.
+	Text *gdttext.Expect `yaml:"-"`
 }
 
 // pipeAssertions contains assertions about the contents of a pipe
 type pipeAssertions struct {
 	PipeExpect
 	// pipe is the contents of the pipe that we will evaluate.
-	pipe *bytes.Buffer
+	pipe *Spool
 	// name is the string name of the pipe.
 	name string
 	// failures contains the set of error messages for failed assertions.
@@ -76,7 +134,6 @@ func (a *pipeAssertions) OK(ctx context.Context) bool {
 	}
 
 	res := true
-	contents := strings.TrimSpace(a.pipe.String())
 	if a.ContainsAll != nil {
 		vals := a.ContainsAll.Values()
 		vals = lo.Map(vals, func(val string, _ int) string {
@@ -92,7 +149,7 @@ func (a *pipeAssertions) OK(ctx context.Context) bool {
 			return val
 		})
 		for _, find := range vals {
-			if !strings.Contains(contents, find) {
+			if !a.pipe.Contains(find) {
 				a.Fail(api.NotIn(find, a.name))
 				res = false
 			}
@@ -114,7 +171,7 @@ func (a *pipeAssertions) OK(ctx context.Context) bool {
 			return val
 		})
 		for _, find := range vals {
-			if idx := strings.Index(contents, find); idx > -1 {
+			if a.pipe.Contains(find) {
 				found = true
 				break
 			}
@@ -139,12 +196,34 @@ func (a *pipeAssertions) OK(ctx context.Context) bool {
 			return val
 		})
 		for _, find := range vals {
-			if strings.Contains(contents, find) {
+			if a.pipe.Contains(find) {
 				a.Fail(api.In(find, a.name))
 				res = false
 			}
 		}
 	}
+	if a.JSON != nil {
+		contents := strings.TrimSpace(a.pipe.String())
+		ja := gdtjson.New(a.JSON, []byte(contents))
+		if !ja.OK(ctx) {
+			a.failures = append(a.failures, ja.Failures()...)
+			res = false
+		}
+	}
+	if a.YAML != nil {
+		ya := gdtyaml.New(a.YAML, []byte(a.pipe.String()))
+		if !ya.OK(ctx) {
+			a.failures = append(a.failures, ya.Failures()...)
+			res = false
+		}
+	}
+	if a.Text != nil {
+		ta := gdttext.New(a.Text, a.name, []byte(a.pipe.String()))
+		if !ta.OK(ctx) {
+			a.failures = append(a.failures, ta.Failures()...)
+			res = false
+		}
+	}
 	return res
 }
 
@@ -154,12 +233,20 @@ type assertions struct {
 	failures []error
 	// expExitCode contains the expected exit code
 	expExitCode int
+	// expExitCodes contains the set of expected exit codes, if any
+	expExitCodes []int
 	// exitCode is the exit code we got from the execution
 	exitCode int
+	// expDuration contains the expected bounds on the execution's duration
+	expDuration *DurationExpect
+	// elapsed is how long the execution took
+	elapsed time.Duration
 	// expOutPipe contains the assertions against stdout
 	expOutPipe *pipeAssertions
 	// expErrPipe contains the assertions against stderr
 	expErrPipe *pipeAssertions
+	// expFiles contains the assertions against files
+	expFiles *fileAssertions
 }
 
 // Fail appends a supplied error to the set of failed assertions
@@ -179,10 +266,25 @@ func (a *assertions) Failures() []error {
 // if all assertions pass.
 func (a *assertions) OK(ctx context.Context) bool {
 	res := true
-	if a.expExitCode != a.exitCode {
+	if len(a.expExitCodes) > 0 {
+		if !lo.Contains(a.expExitCodes, a.exitCode) {
+			a.Fail(api.NotIn(a.exitCode, a.expExitCodes))
+			res = false
+		}
+	} else if a.expExitCode != a.exitCode {
 		a.Fail(api.NotEqual(a.expExitCode, a.exitCode))
 		res = false
 	}
+	if a.expDuration != nil {
+		if a.expDuration.Max > 0 && a.elapsed > a.expDuration.Max {
+			a.Fail(api.DurationTooLong(a.expDuration.Max, a.elapsed))
+			res = false
+		}
+		if a.elapsed < a.expDuration.Min {
+			a.Fail(api.DurationTooShort(a.expDuration.Min, a.elapsed))
+			res = false
+		}
+	}
 	if !a.expOutPipe.OK(ctx) {
 		a.failures = append(a.failures, a.expOutPipe.Failures()...)
 		res = false
@@ -191,6 +293,10 @@ func (a *assertions) OK(ctx context.Context) bool {
 		a.failures = append(a.failures, a.expErrPipe.Failures()...)
 		res = false
 	}
+	if !a.expFiles.OK(ctx) {
+		a.failures = append(a.failures, a.expFiles.Failures()...)
+		res = false
+	}
 	return res
 }
 
@@ -199,8 +305,10 @@ func (a *assertions) OK(ctx context.Context) bool {
 func newAssertions(
 	e *Expect,
 	exitCode int,
-	outPipe *bytes.Buffer,
-	errPipe *bytes.Buffer,
+	elapsed time.Duration,
+	outPipe *Spool,
+	errPipe *Spool,
+	dir string,
 ) api.Assertions {
 	expExitCode := 0
 	if e != nil {
@@ -210,8 +318,17 @@ func newAssertions(
 		failures:    []error{},
 		expExitCode: expExitCode,
 		exitCode:    exitCode,
+		elapsed:     elapsed,
 	}
 	if e != nil {
+		a.expExitCodes = e.ExitCodes
+		a.expDuration = e.Duration
+		if len(e.Files) > 0 {
+			a.expFiles = &fileAssertions{
+				exp: e.Files,
+				dir: dir,
+			}
+		}
 		if e.Out != nil {
 			a.expOutPipe = &pipeAssertions{
 				PipeExpect: *e.Out,
@@ -0,0 +1,335 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+)
+
+const (
+	// backgroundDataPrefix is the prefix of the run data key that a
+	// background process is stored under. The remainder of the key is the
+	// name of the test spec that started the process.
+	backgroundDataPrefix = "exec.background."
+)
+
+// Background is a command started by a test spec with `background: true`
+// that runs until it is stopped by a `stop` test spec or the test scenario
+// ends.
+type Background struct {
+	sync.Mutex
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.Closer
+	outbuf bytes.Buffer
+	errbuf bytes.Buffer
+	// done is closed when the command has exited.
+	done chan struct{}
+}
+
+// Name returns the name of the test spec that started the command.
+func (b *Background) Name() string {
+	return b.name
+}
+
+// Stdout returns the output that the command has written to stdout.
+func (b *Background) Stdout() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.outbuf.String()
+}
+
+// Stderr returns the output that the command has written to stderr.
+func (b *Background) Stderr() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.errbuf.String()
+}
+
+// lockedWriter is the io.Writer for a background command's stdout and stderr.
+// It allows the output to be read safely while the command is running.
+type lockedWriter struct {
+	b   *Background
+	buf *bytes.Buffer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.b.Lock()
+	defer w.b.Unlock()
+	return w.buf.Write(p)
+}
+
+// Stop kills the command if it is still running and waits for it to exit.
+func (b *Background) Stop() {
+	if b.exited() {
+		return
+	}
+	_ = b.cmd.Process.Kill()
+	<-b.done
+}
+
+// backgrounds tracks the running background commands so that they can be
+// stopped when the test scenario ends. The commands are keyed by the ID of
+// the scenario run that started them, as returned by gdtcontext.ScenarioID,
+// and then by the test spec that started them, so that runs of the same
+// scenario do not stop each other's commands.
+var backgrounds = struct {
+	sync.Mutex
+	running map[string]map[*Spec]*Background
+}{
+	running: map[string]map[*Spec]*Background{},
+}
+
+// startBackground starts the spec's command without waiting for it to exit
+// and stores the Background in the result's run data.
+func (s *Spec) startBackground(ctx context.Context) (*api.Result, error) {
+	target, args := s.command(ctx, s.Exec)
+	// The command must outlive the test spec's context, which is cancelled
+	// when the test spec's Eval returns.
+	cmd := exec.Command(target, args...)
+	stdin, err := s.configure(ctx, cmd)
+	if err != nil {
+		return nil, ExecRuntimeError(err)
+	}
+	b := &Background{
+		name:  s.Name,
+		cmd:   cmd,
+		stdin: stdin,
+		done:  make(chan struct{}),
+	}
+	cmd.Stdout = lockedWriter{b, &b.outbuf}
+	cmd.Stderr = lockedWriter{b, &b.errbuf}
+	if err := s.start(cmd); err != nil {
+		_ = stdin.Close()
+		return nil, ExecRuntimeError(err)
+	}
+	debug.Printf(ctx, "exec: background: %s started (pid %d)", b.name, cmd.Process.Pid)
+	go func() {
+		_ = cmd.Wait()
+		_ = b.stdin.Close()
+		close(b.done)
+	}()
+
+	scID := gdtcontext.ScenarioID(ctx)
+	backgrounds.Lock()
+	running, found := backgrounds.running[scID]
+	if !found {
+		running = map[*Spec]*Background{}
+		backgrounds.running[scID] = running
+	}
+	prev, found := running[s]
+	running[s] = b
+	backgrounds.Unlock()
+	if found {
+		// The test spec was retried, so stop the previous command.
+		prev.Stop()
+	}
+
+	return api.NewResult(
+		api.WithData(backgroundDataPrefix+b.name, b),
+	), nil
+}
+
+// StopSpec describes a test spec that stops a command started by an earlier
+// test spec with `background: true`.
+type StopSpec struct {
+	api.Spec
+	// Stop is the name of the test spec that started the background command.
+	Stop string `yaml:"stop"`
+}
+
+func (s *StopSpec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *StopSpec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *StopSpec) Retry() *api.Retry {
+	return api.NoRetry
+}
+
+func (s *StopSpec) Timeout() *api.Timeout {
+	return nil
+}
+
+// Eval stops the named background command. A failure is returned if no
+// background command with that name was started.
+func (s *StopSpec) Eval(ctx context.Context) (*api.Result, error) {
+	b, err := lookupBackground(ctx, s.Stop)
+	if err != nil {
+		return api.NewResult(api.WithFailures(err)), nil
+	}
+	b.Stop()
+	debug.Printf(ctx, "exec: background: %s stopped", b.name)
+	return api.NewResult(), nil
+}
+
+// lookupBackground returns the background command started by the test spec
+// with the supplied name, or a failure if no such command was started.
+func lookupBackground(ctx context.Context, name string) (*Background, error) {
+	v, found := gdtcontext.Run(ctx)[backgroundDataPrefix+name]
+	b, ok := v.(*Background)
+	if !found || !ok {
+		return nil, fmt.Errorf(
+			"%w: no background command named %q", api.ErrFailure, name,
+		)
+	}
+	return b, nil
+}
+
+var (
+	// ErrBackgroundExited is an api.ErrFailure when a background command
+	// that is expected to be running has exited.
+	ErrBackgroundExited = fmt.Errorf(
+		"%w: background command exited", api.ErrFailure,
+	)
+	// ErrBackgroundRunning is an api.ErrFailure when a background command
+	// that is expected to have exited is still running.
+	ErrBackgroundRunning = fmt.Errorf(
+		"%w: background command still running", api.ErrFailure,
+	)
+)
+
+// BackgroundExited returns an ErrBackgroundExited for the supplied background
+// command name and exit code.
+func BackgroundExited(name string, exitCode int) error {
+	return fmt.Errorf(
+		"%w: %s exited with exit code %d", ErrBackgroundExited, name, exitCode,
+	)
+}
+
+// BackgroundRunning returns an ErrBackgroundRunning for the supplied
+// background command name and the duration waited for it to exit.
+func BackgroundRunning(name string, after time.Duration) error {
+	return fmt.Errorf(
+		"%w: %s did not exit within %s", ErrBackgroundRunning, name, after,
+	)
+}
+
+// exited returns true if the command has exited.
+func (b *Background) exited() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitCode returns the exit code of the command, which must have exited.
+func (b *Background) exitCode() int {
+	return b.cmd.ProcessState.ExitCode()
+}
+
+// StatusSpec describes a test spec that asserts a command started by an
+// earlier test spec with `background: true` is still running, or has exited.
+type StatusSpec struct {
+	api.Spec
+	// Running is the name of the test spec that started a background command
+	// that is expected to still be running.
+	Running string `yaml:"running,omitempty"`
+	// Exited is the name of the test spec that started a background command
+	// that is expected to have exited.
+	Exited string `yaml:"exited,omitempty"`
+	// After is how long to wait before checking the background command. For
+	// Running, the command must keep running for all of After and the test
+	// spec fails as soon as the command exits. For Exited, the test spec
+	// passes as soon as the command exits and fails if the command is still
+	// running once After has elapsed.
+	After time.Duration `yaml:"after,omitempty"`
+}
+
+func (s *StatusSpec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *StatusSpec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *StatusSpec) Retry() *api.Retry {
+	return api.NoRetry
+}
+
+func (s *StatusSpec) Timeout() *api.Timeout {
+	return nil
+}
+
+// Eval checks whether the named background command is running or has exited.
+// A failure is returned if no background command with that name was started.
+func (s *StatusSpec) Eval(ctx context.Context) (*api.Result, error) {
+	name := s.Running
+	if name == "" {
+		name = s.Exited
+	}
+	b, err := lookupBackground(ctx, name)
+	if err != nil {
+		return api.NewResult(api.WithFailures(err)), nil
+	}
+	timer := time.NewTimer(s.After)
+	defer timer.Stop()
+	select {
+	case <-b.done:
+	case <-timer.C:
+	case <-ctx.Done():
+		return api.NewResult(api.WithFailures(api.ErrTimeoutExceeded)), nil
+	}
+	exited := b.exited()
+	if s.Running != "" && exited {
+		return api.NewResult(
+			api.WithFailures(BackgroundExited(name, b.exitCode())),
+		), nil
+	}
+	if s.Exited != "" && !exited {
+		return api.NewResult(
+			api.WithFailures(BackgroundRunning(name, s.After)),
+		), nil
+	}
+	if exited {
+		debug.Printf(
+			ctx, "exec: background: %s exited with exit code %d",
+			name, b.exitCode(),
+		)
+	} else {
+		debug.Printf(ctx, "exec: background: %s running after %s", name, s.After)
+	}
+	return api.NewResult(), nil
+}
+
+// BeforeScenario implements api.ScenarioHooks.
+func (p *plugin) BeforeScenario(context.Context, api.ScenarioInfo) error {
+	return nil
+}
+
+// AfterScenario stops any background commands started during the scenario
+// run that are still running.
+func (p *plugin) AfterScenario(
+	ctx context.Context,
+	sc api.ScenarioInfo,
+) error {
+	backgrounds.Lock()
+	running := backgrounds.running[sc.ID]
+	delete(backgrounds.running, sc.ID)
+	backgrounds.Unlock()
+	for _, b := range running {
+		if !b.exited() {
+			debug.Printf(ctx, "exec: background: %s stopped at scenario end", b.name)
+		}
+		b.Stop()
+	}
+	return nil
+}
@@ -5,11 +5,83 @@
 package exec
 
 import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
 	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
 	"gopkg.in/yaml.v3"
 )
 
-type execDefaults struct{}
+type execDefaults struct {
+	// SpoolThreshold is the number of bytes of a command's stdout or stderr
+	// held in memory before the output is spooled to a temporary file.
+	SpoolThreshold int64 `yaml:"spool-threshold,omitempty"`
+	// Shell is the shell used to execute the commands of all exec test specs
+	// in the scenario that do not specify their own shell.
+	Shell string `yaml:"shell,omitempty"`
+	// Dir is the working directory for the commands of all exec test specs in
+	// the scenario that do not specify their own dir. A relative Dir is
+	// relative to the directory containing the test scenario file.
+	Dir string `yaml:"dir,omitempty"`
+	// Env is a map of environment variables that are set, in addition to the
+	// environment of the gdt process, for the commands of all exec test specs
+	// in the scenario.
+	Env map[string]string `yaml:"env,omitempty"`
+	// Timeout is the timeout for all exec test specs in the scenario that do
+	// not specify their own timeout.
+	Timeout *api.Timeout `yaml:"timeout,omitempty"`
+}
+
+func (d *execDefaults) UnmarshalYAML(node *yaml.Node) error {
+	return pluginutil.DecodeFields(node, pluginutil.Fields{
+		"spool-threshold": func(valNode *yaml.Node) error {
+			threshold, err := pluginutil.IntAt(valNode)
+			if err != nil {
+				return err
+			}
+			d.SpoolThreshold = int64(threshold)
+			return nil
+		},
+		"shell": func(valNode *yaml.Node) error {
+			shellNode, err := forOS(valNode)
+			if err != nil {
+				return err
+			}
+			if shellNode == nil {
+				return nil
+			}
+			if shellNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarOrMapAt(shellNode)
+			}
+			d.Shell = strings.TrimSpace(shellNode.Value)
+			if _, err := exec.LookPath(d.Shell); err != nil {
+				return ExecUnknownShell(d.Shell, valNode)
+			}
+			return nil
+		},
+		"dir": func(valNode *yaml.Node) error {
+			dir, err := pluginutil.StringAt(valNode)
+			if err != nil {
+				return err
+			}
+			// Relative directories are relative to the scenario file's
+			// directory, which is the working directory during parsing.
+			d.Dir, _ = filepath.Abs(strings.TrimSpace(dir))
+			return nil
+		},
+		"env": pluginutil.Map(&d.Env),
+		"timeout": func(valNode *yaml.Node) error {
+			if _, err := pluginutil.DurationAt(valNode); err != nil {
+				return err
+			}
+			d.Timeout = &api.Timeout{After: valNode.Value}
+			return nil
+		},
+	})
+}
 
 // Defaults is the known exec plugin defaults collection
 type Defaults struct {
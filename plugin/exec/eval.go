@@ -5,10 +5,13 @@
 package exec
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
 	"github.com/gdt-dev/core/debug"
 )
 
@@ -20,39 +23,98 @@ import (
 func (s *Spec) Eval(
 	ctx context.Context,
 ) (*api.Result, error) {
-	outbuf := &bytes.Buffer{}
-	errbuf := &bytes.Buffer{}
+	if s.Background {
+		return s.startBackground(ctx)
+	}
+	threshold := s.spoolThreshold()
+	outbuf := NewSpool(threshold)
+	defer outbuf.Close()
+	errbuf := NewSpool(threshold)
+	defer errbuf.Close()
 
 	var ec int
 
-	if err := s.Do(ctx, outbuf, errbuf, &ec); err != nil {
+	start := time.Now()
+	var err error
+	if len(s.Interact) > 0 {
+		err = s.interact(ctx, outbuf, errbuf, &ec)
+	} else {
+		err = s.Do(ctx, outbuf, errbuf, &ec)
+	}
+	elapsed := time.Since(start)
+	if err != nil {
 		if err == api.ErrTimeoutExceeded {
 			return api.NewResult(api.WithFailures(api.ErrTimeoutExceeded)), nil
 		}
+		if errors.Is(err, ErrInteractExpectTimeout) {
+			return api.NewResult(api.WithFailures(err)), nil
+		}
 		return nil, ExecRuntimeError(err)
 	}
-	a := newAssertions(s.Assert, ec, outbuf, errbuf)
+	a := newAssertions(s.Assert, ec, elapsed, outbuf, errbuf, s.Dir)
 	if a.OK(ctx) {
 		res := api.NewResult()
-		saveVars(ctx, s.Var, outbuf, errbuf, ec, res)
-		return res, nil
-	}
-	if s.On != nil {
-		if s.On.Fail != nil {
-			outbuf.Reset()
-			errbuf.Reset()
-			err := s.On.Fail.Do(ctx, outbuf, errbuf, nil)
-			if err != nil {
-				debug.Printf(ctx, "error in on.fail.exec: %s", err)
-			}
+		if err := saveVars(ctx, s.Var, outbuf, errbuf, ec, res); err != nil {
+			return api.NewResult(api.WithFailures(err)), nil
 		}
+		return res, nil
 	}
 	stopOnFail := false
 	if s.Assert != nil {
 		stopOnFail = s.Assert.Require
 	}
-	return api.NewResult(
+	res := api.NewResult(
 		api.WithStopOnFail(stopOnFail),
 		api.WithFailures(a.Failures()...),
-	), nil
+	)
+	if s.On != nil && s.On.Fail != nil {
+		outbuf.Reset()
+		errbuf.Reset()
+		err := s.On.Fail.Do(ctx, outbuf, errbuf, nil)
+		if err != nil {
+			debug.Printf(ctx, "error in on.fail.exec: %s", err)
+		}
+		recordOnFail(ctx, res, outbuf, errbuf)
+	}
+	return res, nil
+}
+
+// recordOnFail writes the output of an on.fail action to the current test
+// unit's detail and attaches it to the supplied Result as artifacts named
+// "on.fail.stdout" and "on.fail.stderr".
+func recordOnFail(
+	ctx context.Context,
+	res *api.Result,
+	outbuf *Spool,
+	errbuf *Spool,
+) {
+	tu := gdtcontext.TestUnit(ctx)
+	for _, pipe := range []struct {
+		name string
+		buf  *Spool
+	}{
+		{"stdout", outbuf},
+		{"stderr", errbuf},
+	} {
+		if pipe.buf.Len() == 0 {
+			continue
+		}
+		contents := pipe.buf.String()
+		res.Attach("on.fail."+pipe.name, "text/plain", []byte(contents))
+		if tu != nil {
+			tu.Logf(
+				"on.fail: %s:\n%s", pipe.name, strings.TrimSpace(contents),
+			)
+		}
+	}
+}
+
+// spoolThreshold returns the number of bytes of output held in memory before
+// the output is spooled to disk.
+func (s *Spec) spoolThreshold() int64 {
+	d, ok := s.Spec.Defaults.For(pluginName).(*Defaults)
+	if ok && d.SpoolThreshold > 0 {
+		return d.SpoolThreshold
+	}
+	return DefaultSpoolThreshold
 }
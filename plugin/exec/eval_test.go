@@ -14,10 +14,19 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/gdt-dev/core/api"
 	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/fixture"
 	execplugin "github.com/gdt-dev/core/plugin/exec"
+	"github.com/gdt-dev/core/run"
 	"github.com/gdt-dev/core/scenario"
 	"github.com/stretchr/testify/require"
 )
@@ -72,13 +81,919 @@ func TestExitCode(t *testing.T) {
 	require.Nil(err)
 }
 
+func TestExitCodeSet(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exit-code-set.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
 func TestFailExecExitCodeNotSpecified(t *testing.T) {
 	if !*failFlag {
 		t.Skip("skipping without -fail flag")
 	}
 	require := require.New(t)
 
-	fp := filepath.Join("testdata", "ls-fail-no-exit-code.yaml")
+	fp := filepath.Join("testdata", "ls-fail-no-exit-code.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := gdtcontext.New(gdtcontext.WithDebug())
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestExecFailExitCodeNotSpecified(t *testing.T) {
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailExecExitCodeNotSpecified",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+	debugout := string(outerr)
+	ec := 2
+	// Yay, different exit codes for the same not found error...
+	if runtime.GOOS == "darwin" {
+		ec = 1
+	}
+	msg := fmt.Sprintf(
+		"assertion failed: not equal: expected 0 but got %d", ec,
+	)
+	require.Contains(debugout, msg)
+}
+
+func TestShellList(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "shell-ls.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestIs(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "echo-cat.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cat is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "stdin.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestLargeStderrBeforeStdout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	// The command fills the stderr pipe before writing to stdout, which
+	// blocks unless both pipes are read at the same time.
+	a := execplugin.Action{
+		Exec:  "head -c 1000000 /dev/zero >&2; echo done",
+		Shell: "sh",
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
+	defer cancel()
+	outbuf := execplugin.NewSpool(0)
+	errbuf := execplugin.NewSpool(0)
+	ec := 0
+	err := a.Do(ctx, outbuf, errbuf, &ec)
+	require.Nil(err)
+	require.Equal(0, ec)
+	require.Equal("done\n", outbuf.String())
+	require.Equal(int64(1000000), errbuf.Len())
+}
+
+func TestDir(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "dir.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestDefaults(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "defaults.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestFixtureEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-env.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	mock := fixture.New(
+		fixture.WithEnv(map[string]string{
+			"MOCK_URL": "http://127.0.0.1:8081",
+		}),
+	)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "mock", mock)
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestRLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "rlimit.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestUser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("running as a different user is not supported on Windows")
+	}
+	if os.Getuid() != 0 {
+		t.Skip("running as a different user requires root")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "user.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestBackground(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestBackgroundStoppedAtScenarioEnd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	pidfile := filepath.Join(t.TempDir(), "pid")
+	t.Setenv("PIDFILE", pidfile)
+
+	fp := filepath.Join("testdata", "background-cleanup.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+
+	b, err := os.ReadFile(pidfile)
+	require.Nil(err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	require.Nil(err)
+	// The background command has been killed and reaped, so signalling its
+	// PID fails.
+	proc, err := os.FindProcess(pid)
+	require.Nil(err)
+	require.NotNil(proc.Signal(syscall.Signal(0)))
+}
+
+func TestBackgroundConcurrentRuns(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background-concurrent.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	// The scenario has no path, because running a scenario with a path
+	// changes the working directory.
+	s, err := scenario.FromReader(f)
+	require.Nil(err)
+	require.NotNil(s)
+
+	// Starting the background command in the second run must not stop the
+	// command started by the same test spec in the first run.
+	runs := []*run.Run{run.New(), run.New()}
+	errs := make(chan error, len(runs))
+	for _, r := range runs {
+		go func() {
+			errs <- s.Run(context.TODO(), r)
+		}()
+		time.Sleep(100 * time.Millisecond)
+	}
+	for range runs {
+		require.Nil(<-errs)
+	}
+	for _, r := range runs {
+		results := r.ScenarioResults("")
+		require.Len(results, 2)
+		for _, res := range results {
+			require.True(res.OK(), res.Failures())
+		}
+	}
+}
+
+func TestFailStopUnknown(t *testing.T) {
+	if !*failFlag {
+		t.Skip("skipping without -fail flag")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "stop-unknown.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestStopUnknown(t *testing.T) {
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailStopUnknown",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+
+	debugout := string(outerr)
+	require.Contains(debugout, "no background command named \"server\"")
+}
+
+func TestBackgroundStatus(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background-status.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestFailBackgroundStatus(t *testing.T) {
+	if !*failFlag {
+		t.Skip("skipping without -fail flag")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background-status-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestBackgroundStatusFail(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailBackgroundStatus",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+
+	debugout := string(outerr)
+	require.Contains(debugout, "crasher exited with exit code 3")
+}
+
+func TestJSONOut(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "json-out.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestYAMLOut(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "yaml-out.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestTextOut(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "text-out.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestTextOutUpdateGolden(t *testing.T) {
+	require := require.New(t)
+
+	b, err := os.ReadFile(filepath.Join("testdata", "text-out.yaml"))
+	require.Nil(err)
+	dir := filepath.Join(t.TempDir(), "testdata")
+	require.Nil(os.Mkdir(dir, 0o755))
+	fp := filepath.Join(dir, "text-out.yaml")
+	require.Nil(os.WriteFile(fp, b, 0o644))
+
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	ctx := gdtcontext.New(gdtcontext.WithUpdateGolden())
+	err = s.Run(ctx, r)
+	require.Nil(err)
+	require.True(r.OK())
+
+	path := filepath.Join(dir, "golden", "text-out.golden")
+	require.Equal([]string{path}, r.DirtyGoldens())
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.Equal([]string{path}, results[0].DirtyGoldens())
+
+	b, err = os.ReadFile(path)
+	require.Nil(err)
+	require.Equal("cat\ndog\n", string(b))
+}
+
+func TestFailJSONOut(t *testing.T) {
+	if !*failFlag {
+		t.Skip("skipping without -fail flag")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "json-out-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestJSONOutFail(t *testing.T) {
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailJSONOut",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+
+	debugout := string(outerr)
+	require.Contains(debugout, "$.name")
+	require.Contains(debugout, "dog")
+}
+
+func TestDuration(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "duration.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestFailDuration(t *testing.T) {
+	if !*failFlag {
+		t.Skip("skipping without -fail flag")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "duration-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestDurationFail(t *testing.T) {
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailDuration",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+
+	debugout := string(outerr)
+	require.Contains(debugout, "duration out of range")
+	require.Contains(debugout, "expected at most 10ms")
+}
+
+func TestExecSequence(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exec-sequence.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestSpool(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "spool.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	ctx := gdtcontext.New(gdtcontext.WithDebug(w))
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	w.Flush()
+	require.Contains(b.String(), "exec: stdout: 20 bytes spooled to disk")
+}
+
+func TestFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping file mode assertions on Windows")
+	}
+	require := require.New(t)
+
+	t.Setenv("FILES_DIR", t.TempDir())
+	fp := filepath.Join("testdata", "files.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestInteract(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "interact.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestFailInteract(t *testing.T) {
+	if !*failFlag {
+		t.Skip("skipping without -fail flag")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "interact-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestInteractFail(t *testing.T) {
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailInteract",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+
+	debugout := string(outerr)
+	require.Contains(debugout, "interact expect timed out")
+	require.Contains(debugout, `"password:" did not appear in stdout within 100ms`)
+}
+
+func TestScript(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "script.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestVarInterpolation(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "var-interpolation.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestScenarioVars(t *testing.T) {
+	require := require.New(t)
+
+	t.Setenv("GDT_VARS_USER", "gopher")
+
+	fp := filepath.Join("testdata", "vars.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestScenarioVarsExpr(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "vars-expr.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestScenarioVarsTyped(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "vars-typed.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	// The environment variable's "5" is converted to the integer 5, so the
+	// expression multiplies instead of failing.
+	t.Setenv("GDT_VARS_REPLICAS", "5")
+	t.Setenv("GDT_VARS_REGION", "eu-west-1")
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+
+	t.Setenv("GDT_VARS_REPLICAS", "five")
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrVariable)
+	require.ErrorContains(err, `REPLICAS: value "five" is not a valid int`)
+
+	t.Setenv("GDT_VARS_REPLICAS", "5")
+	t.Setenv("GDT_VARS_REGION", "mars-1")
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrVariable)
+	require.ErrorContains(err, `value "mars-1" is not one of us-east-1, eu-west-1`)
+}
+
+func TestScenarioAssertExpr(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "assert-expr.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestScenarioAssertExprFalse(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "assert-expr-false.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -89,39 +1004,113 @@ func TestFailExecExitCodeNotSpecified(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	ctx := gdtcontext.New(gdtcontext.WithDebug())
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	failures := results[0].Failures()
+	require.Len(failures, 1)
+	require.ErrorIs(failures[0], api.ErrExpressionFalse)
+	require.ErrorContains(failures[0], "int(vars.ANSWER) == 42")
+}
+
+func TestScenarioSkipIfExpr(t *testing.T) {
+	require := require.New(t)
+
+	t.Setenv("GDT_SKIP_IF_EXPR", "yes")
+
+	fp := filepath.Join("testdata", "skip-if-expr.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+	require.True(t.Skipped())
+}
+
+func TestScenarioRunIfExpr(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "run-if-expr.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+	require.True(t.Skipped())
+}
+
+func TestScenarioSecrets(t *testing.T) {
+	require := require.New(t)
+
+	t.Setenv("GDT_SECRETS_TOKEN", "tok-1234")
+
+	fp := filepath.Join("testdata", "secrets.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	ctx := gdtcontext.New(gdtcontext.WithDebug(w))
 	err = s.Run(ctx, t)
 	require.Nil(err)
+	w.Flush()
+	debugout := b.String()
+	require.Contains(debugout, "secrets: API_TOKEN resolved from env GDT_SECRETS_TOKEN")
+	require.Contains(debugout, "exec: stdout: ******** ******** ********")
+	require.NotContains(debugout, "tok-1234")
+	require.NotContains(debugout, "hunter2")
+	require.NotContains(debugout, "s3ss10n")
 }
 
-func TestExecFailExitCodeNotSpecified(t *testing.T) {
+func TestScenarioSecretsUnset(t *testing.T) {
 	require := require.New(t)
-	target := os.Args[0]
-	failArgs := []string{
-		"-test.v",
-		"-test.run=FailExecExitCodeNotSpecified",
-		"-fail",
-	}
-	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
 
-	// The test should have failed...
-	require.NotNil(err)
-	debugout := string(outerr)
-	ec := 2
-	// Yay, different exit codes for the same not found error...
-	if runtime.GOOS == "darwin" {
-		ec = 1
-	}
-	msg := fmt.Sprintf(
-		"assertion failed: not equal: expected 0 but got %d", ec,
+	fp := filepath.Join("testdata", "secrets-unset.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
 	)
-	require.Contains(debugout, msg)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.ErrorIs(err, api.ErrVariable)
+	require.ErrorContains(err, "GDT_SECRETS_UNSET is not set")
 }
 
-func TestShellList(t *testing.T) {
+func TestScenarioVarFiles(t *testing.T) {
 	require := require.New(t)
 
-	fp := filepath.Join("testdata", "shell-ls.yaml")
+	fp := filepath.Join("testdata", "var-files.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -131,16 +1120,77 @@ func TestShellList(t *testing.T) {
 	)
 	require.Nil(err)
 	require.NotNil(s)
+	require.Equal(
+		[]string{"vars/endpoints.yaml", "vars/account.json"}, s.VarFiles,
+	)
 
 	ctx := context.TODO()
 	err = s.Run(ctx, t)
 	require.Nil(err)
 }
 
-func TestIs(t *testing.T) {
+func TestVarMatch(t *testing.T) {
 	require := require.New(t)
 
-	fp := filepath.Join("testdata", "echo-cat.yaml")
+	fp := filepath.Join("testdata", "var-match.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestFailVarMatch(t *testing.T) {
+	if !*failFlag {
+		t.Skip("skipping without -fail flag")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "var-match-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := context.TODO()
+	err = s.Run(ctx, t)
+	require.Nil(err)
+}
+
+func TestVarMatchFail(t *testing.T) {
+	require := require.New(t)
+	target := os.Args[0]
+	failArgs := []string{
+		"-test.v",
+		"-test.run=FailVarMatch",
+		"-fail",
+	}
+	outerr, err := exec.Command(target, failArgs...).CombinedOutput()
+
+	// The test should have failed...
+	require.NotNil(err)
+
+	debugout := string(outerr)
+	require.Contains(debugout, "variable did not match: ID")
+}
+
+func TestExecPerOS(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exec-per-os.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -290,6 +1340,36 @@ func TestExecSleepTimeout(t *testing.T) {
 	require.Contains(debugout, "assertion failed: timeout exceeded")
 }
 
+func TestExecSleepAttemptTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "sleep-attempt-timeout.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	began := time.Now()
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+	require.Less(time.Since(began), 2*time.Second)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.Equal(2, results[0].Metrics().Retries)
+	require.ErrorIs(results[0].Failures()[0], api.ErrTimeoutExceeded)
+	require.ErrorContains(
+		results[0].Failures()[0], "attempt timed out (100ms)",
+	)
+}
+
 func TestDebugWriter(t *testing.T) {
 	require := require.New(t)
 
@@ -424,6 +1504,199 @@ func TestExecOnFail(t *testing.T) {
 	require.Contains(debugout, "echo [bad kitty]")
 }
 
+func TestOnFailArtifacts(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "on-fail-exec.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.Contains(results[0].Detail(), "on.fail: stdout:")
+	require.Contains(results[0].Detail(), "bad kitty")
+
+	artifacts := results[0].Artifacts()
+	require.Len(artifacts, 1)
+	require.Equal("on.fail.stdout", artifacts[0].Name)
+	require.Equal("bad kitty\n", string(artifacts[0].Content))
+}
+
+func TestOnFailArtifactsDir(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "on-fail-exec.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	dir := t.TempDir()
+	r := run.New(run.WithArtifactsDir(dir))
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+
+	artifact, ok := results[0].Artifact("on.fail.stdout")
+	require.True(ok)
+	require.Equal("text/plain", artifact.ContentType)
+	require.Equal(
+		filepath.Join(dir, results[0].ID(), "on.fail.stdout"), artifact.Path,
+	)
+	content, err := os.ReadFile(artifact.Path)
+	require.Nil(err)
+	require.Equal("bad kitty\n", string(content))
+
+	_, ok = results[0].Artifact("on.fail.stderr")
+	require.False(ok)
+}
+
+func TestOnFailArtifactsMasked(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "on-fail-exec.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	var debugout bytes.Buffer
+	r := run.New()
+	ctx := gdtcontext.New(
+		gdtcontext.WithDebug(&debugout),
+		gdtcontext.WithSecrets("kitty", "dat"),
+	)
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.Contains(results[0].Detail(), "bad ********")
+	require.NotContains(results[0].Detail(), "kitty")
+	require.NotContains(debugout.String(), "kitty")
+
+	failures := results[0].Failures()
+	require.Len(failures, 1)
+	require.NotContains(failures[0].Error(), "dat")
+	require.ErrorIs(failures[0], api.ErrFailure)
+
+	artifacts := results[0].Artifacts()
+	require.Len(artifacts, 1)
+	require.Equal("bad ********\n", string(artifacts[0].Content))
+}
+
+func TestInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending SIGINT is not supported on Windows")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "interrupt.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.NotNil(s)
+
+	var stopped atomic.Bool
+	fix := fixture.New(
+		fixture.WithStopper(func(context.Context) {
+			stopped.Store(true)
+		}),
+	)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "stopper", fix)
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		proc, _ := os.FindProcess(os.Getpid())
+		_ = proc.Signal(os.Interrupt)
+	}()
+
+	r := run.New(run.WithShutdownGrace(time.Second))
+	err = s.Run(ctx, r)
+	require.ErrorIs(err, api.ErrInterrupted)
+	require.Equal(os.Interrupt, r.Interrupted())
+	require.False(r.OK())
+	require.True(stopped.Load())
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 3)
+	require.True(results[0].OK())
+	require.False(results[1].OK())
+	require.ErrorIs(results[1].Failures()[0], api.ErrInterrupted)
+	require.True(results[2].Skipped())
+}
+
+func TestDebugFailureDump(t *testing.T) {
+	require := require.New(t)
+
+	for _, tc := range []struct {
+		path   string
+		output bool
+	}{
+		{filepath.Join("testdata", "echo-cat.yaml"), false},
+		{filepath.Join("testdata", "on-fail-exec.yaml"), true},
+	} {
+		f, err := os.Open(tc.path)
+		require.Nil(err)
+
+		s, err := scenario.FromReader(
+			f,
+			scenario.WithPath(tc.path),
+		)
+		require.Nil(err)
+		require.NotNil(s)
+
+		var debugout bytes.Buffer
+		ctx := gdtcontext.New(
+			gdtcontext.WithDebug(&debugout),
+			debug.WithFailureDump(0),
+		)
+		r := run.New()
+		err = s.Run(ctx, r)
+		require.Nil(err)
+
+		if tc.output {
+			require.Contains(debugout.String(), "echo [bad kitty]")
+			require.Contains(
+				r.ScenarioResults(tc.path)[0].Detail(), "echo [bad kitty]",
+			)
+		} else {
+			require.Empty(debugout.String(), tc.path)
+		}
+	}
+}
+
 func TestTimeoutWithWait(t *testing.T) {
 	require := require.New(t)
 
@@ -507,3 +1780,63 @@ func TestStopOnFail(t *testing.T) {
 	// The third test spec should NOT have been executed...
 	require.NotContains(debugout, "[gdt] [stop-on-fail/2] exec: stdout: 24")
 }
+
+func TestResultCacheFilesAndSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping windows host")
+	}
+	require := require.New(t)
+
+	t.Setenv("GDT_CACHE_TOKEN", "tok-5678")
+	dir := t.TempDir()
+	script := filepath.Join(dir, "check.sh")
+	require.Nil(os.WriteFile(script, []byte("echo ok\n"), 0o644))
+
+	s, err := scenario.FromReader(strings.NewReader(`
+name: cache-files-and-secrets
+secrets:
+  TOKEN:
+    env: GDT_CACHE_TOKEN
+tests:
+  - script: ` + script + `
+  - exec: echo $${TOKEN}
+    var:
+      SAVED:
+        from: stdout
+`))
+	require.Nil(err)
+
+	cacheDir := filepath.Join(dir, "cache")
+	cached := func() []bool {
+		r := run.New(run.WithCache(cacheDir, 0))
+		err := s.Run(gdtcontext.New(), r)
+		require.Nil(err)
+		res := []bool{}
+		for _, tur := range r.ScenarioResults("") {
+			require.True(tur.OK())
+			res = append(res, tur.Cached())
+		}
+		return res
+	}
+
+	// A test spec that saves a secret is never cached.
+	require.Equal([]bool{false, false}, cached())
+	require.Equal([]bool{true, false}, cached())
+
+	// The contents of the script are part of the cache key.
+	require.Nil(os.WriteFile(script, []byte("echo changed\n"), 0o644))
+	require.Equal([]bool{false, false}, cached())
+	require.Equal([]bool{true, false}, cached())
+
+	entries, err := os.ReadDir(cacheDir)
+	require.Nil(err)
+	require.Len(entries, 2)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		require.Nil(err)
+		require.Equal(os.FileMode(0o600), info.Mode().Perm())
+		b, err := os.ReadFile(filepath.Join(cacheDir, entry.Name()))
+		require.Nil(err)
+		require.NotContains(string(b), "tok-5678")
+	}
+}
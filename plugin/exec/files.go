@@ -0,0 +1,328 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtjson "github.com/gdt-dev/core/assertion/json"
+	gdtyaml "github.com/gdt-dev/core/assertion/yaml"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// FileExpect contains assertions about a file after the command has executed
+type FileExpect struct {
+	// Exists indicates whether the file is expected to exist. If nil, the
+	// file is expected to exist.
+	Exists *bool `yaml:"exists,omitempty"`
+	// Size is the expected size of the file in bytes.
+	Size *int64 `yaml:"size,omitempty"`
+	// Mode is the expected permission bits of the file, e.g. 0644.
+	Mode *fs.FileMode `yaml:"mode,omitempty"`
+	// Content is the exact expected content of the file.
+	Content *string `yaml:"content,omitempty"`
+	// ContainsAll is one or more strings that *all* must be present in the
+	// content of the file.
+	ContainsAll *api.FlexStrings `yaml:"contains,omitempty"`
+	// ContainsAny is one or more strings of which *at least one* must be
+	// present in the content of the file.
+	ContainsAny *api.FlexStrings `yaml:"contains-one-of,omitempty"`
+	// ContainsNone is one or more strings, *none of which* should be present
+	// in the content of the file.
+	ContainsNone *api.FlexStrings `yaml:"contains-none-of,omitempty"`
+	// JSON contains assertions about the content of the file when it is
+	// parsed as JSON.
+	JSON *gdtjson.Expect `yaml:"json,omitempty"`
+	// YAML contains assertions about the content of the file when it is
+	// parsed as YAML. The assertions are the same as for JSON content.
+	YAML *gdtyaml.Expect `yaml:"yaml,omitempty"`
+}
+
+// ExecInvalidFileMode returns a parse error indicating that the mode of a file
+// assertion is not an octal number.
+func ExecInvalidFileMode(mode string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:   CodeExecInvalidFileMode,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid file mode %q: expected an octal number, e.g. 0644",
+			mode,
+		),
+	}
+}
+
+func (e *FileExpect) UnmarshalYAML(node *yaml.Node) error {
+	flexStrings := func(target **api.FlexStrings) pluginutil.FieldFunc {
+		return func(valNode *yaml.Node) error {
+			var vals api.FlexStrings
+			if err := valNode.Decode(&vals); err != nil {
+				return err
+			}
+			*target = &vals
+			return nil
+		}
+	}
+	jsonExpect := func(target **gdtjson.Expect) pluginutil.FieldFunc {
+		return func(valNode *yaml.Node) error {
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			var je gdtjson.Expect
+			if err := valNode.Decode(&je); err != nil {
+				return err
+			}
+			*target = &je
+			return nil
+		}
+	}
+	yamlExpect := func(target **gdtyaml.Expect) pluginutil.FieldFunc {
+		return func(valNode *yaml.Node) error {
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			var ye gdtyaml.Expect
+			if err := valNode.Decode(&ye); err != nil {
+				return err
+			}
+			*target = &ye
+			return nil
+		}
+	}
+	return pluginutil.DecodeFields(node, pluginutil.Fields{
+		"exists": func(valNode *yaml.Node) error {
+			exists, err := pluginutil.BoolAt(valNode)
+			if err != nil {
+				return err
+			}
+			e.Exists = &exists
+			return nil
+		},
+		"size": func(valNode *yaml.Node) error {
+			size, err := pluginutil.IntAt(valNode)
+			if err != nil {
+				return err
+			}
+			size64 := int64(size)
+			e.Size = &size64
+			return nil
+		},
+		"mode": func(valNode *yaml.Node) error {
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			perm, err := strconv.ParseUint(valNode.Value, 8, 32)
+			if err != nil || perm > uint64(fs.ModePerm) {
+				return ExecInvalidFileMode(valNode.Value, valNode)
+			}
+			mode := fs.FileMode(perm)
+			e.Mode = &mode
+			return nil
+		},
+		"content": func(valNode *yaml.Node) error {
+			content, err := pluginutil.StringAt(valNode)
+			if err != nil {
+				return err
+			}
+			e.Content = &content
+			return nil
+		},
+		"contains":         flexStrings(&e.ContainsAll),
+		"all":              flexStrings(&e.ContainsAll),
+		"contains-one-of":  flexStrings(&e.ContainsAny),
+		"any":              flexStrings(&e.ContainsAny),
+		"contains-none-of": flexStrings(&e.ContainsNone),
+		"none":             flexStrings(&e.ContainsNone),
+		"json":             jsonExpect(&e.JSON),
+		"yaml":             yamlExpect(&e.YAML),
+	})
+}
+
+var (
+	// ErrFileNotExists is an api.ErrFailure when an expected file does not
+	// exist.
+	ErrFileNotExists = fmt.Errorf("%w: file does not exist", api.ErrFailure)
+	// ErrFileExists is an api.ErrFailure when a file unexpectedly exists.
+	ErrFileExists = fmt.Errorf("%w: file exists", api.ErrFailure)
+)
+
+// FileNotExists returns an ErrFileNotExists for the supplied path.
+func FileNotExists(path string) error {
+	return fmt.Errorf("%w: %s", ErrFileNotExists, path)
+}
+
+// FileExists returns an ErrFileExists for the supplied path.
+func FileExists(path string) error {
+	return fmt.Errorf("%w: %s", ErrFileExists, path)
+}
+
+// fileAssertions contains assertions about files after the command executed
+type fileAssertions struct {
+	// exp is the map, keyed by file path, of file assertions.
+	exp map[string]*FileExpect
+	// dir is the directory that relative file paths are relative to. If
+	// empty, relative file paths are relative to the working directory.
+	dir string
+	// failures contains the set of error messages for failed assertions.
+	failures []error
+}
+
+// Fail appends a supplied error to the set of failed assertions
+func (a *fileAssertions) Fail(err error) {
+	a.failures = append(a.failures, err)
+}
+
+// Failures returns a slice of errors for all failed assertions
+func (a *fileAssertions) Failures() []error {
+	if a == nil {
+		return []error{}
+	}
+	return a.failures
+}
+
+// OK checks all the assertions about files and returns true if all
+// assertions pass.
+func (a *fileAssertions) OK(ctx context.Context) bool {
+	if a == nil {
+		return true
+	}
+	paths := make([]string, 0, len(a.exp))
+	for path := range a.exp {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	res := true
+	for _, path := range paths {
+		if !a.fileOK(ctx, path, a.exp[path]) {
+			res = false
+		}
+	}
+	return res
+}
+
+// fileOK checks the assertions about a single file.
+func (a *fileAssertions) fileOK(
+	ctx context.Context,
+	path string,
+	exp *FileExpect,
+) bool {
+	path = gdtcontext.ReplaceVariables(ctx, path)
+	if !filepath.IsAbs(path) && a.dir != "" {
+		path = filepath.Join(a.dir, path)
+	}
+	info, err := os.Stat(path)
+	if exp.Exists != nil && !*exp.Exists {
+		if err == nil {
+			a.Fail(FileExists(path))
+			return false
+		}
+		return true
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			a.Fail(FileNotExists(path))
+		} else {
+			a.Fail(api.UnexpectedError(err))
+		}
+		return false
+	}
+	res := true
+	if exp.Size != nil && *exp.Size != info.Size() {
+		a.Fail(fmt.Errorf(
+			"%s: size: %w", path, api.NotEqual(*exp.Size, info.Size()),
+		))
+		res = false
+	}
+	if exp.Mode != nil && *exp.Mode != info.Mode().Perm() {
+		a.Fail(fmt.Errorf(
+			"%s: mode: %w", path, api.NotEqual(*exp.Mode, info.Mode().Perm()),
+		))
+		res = false
+	}
+	if !exp.needsContent() {
+		return res
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		a.Fail(api.UnexpectedError(err))
+		return false
+	}
+	content := string(b)
+	if exp.Content != nil {
+		expContent := gdtcontext.ReplaceVariables(ctx, *exp.Content)
+		if expContent != content {
+			a.Fail(fmt.Errorf(
+				"%s: content: %w", path, api.NotEqual(expContent, content),
+			))
+			res = false
+		}
+	}
+	if exp.ContainsAll != nil {
+		for _, find := range exp.ContainsAll.Values() {
+			find = gdtcontext.ReplaceVariables(ctx, find)
+			if !strings.Contains(content, find) {
+				a.Fail(api.NotIn(find, path))
+				res = false
+			}
+		}
+	}
+	if exp.ContainsAny != nil {
+		vals := lo.Map(exp.ContainsAny.Values(), func(val string, _ int) string {
+			return gdtcontext.ReplaceVariables(ctx, val)
+		})
+		found := lo.SomeBy(vals, func(find string) bool {
+			return strings.Contains(content, find)
+		})
+		if !found {
+			a.Fail(api.NoneIn(vals, path))
+			res = false
+		}
+	}
+	if exp.ContainsNone != nil {
+		for _, find := range exp.ContainsNone.Values() {
+			find = gdtcontext.ReplaceVariables(ctx, find)
+			if strings.Contains(content, find) {
+				a.Fail(api.In(find, path))
+				res = false
+			}
+		}
+	}
+	if exp.JSON != nil {
+		ja := gdtjson.New(exp.JSON, b)
+		if !ja.OK(ctx) {
+			a.failures = append(a.failures, ja.Failures()...)
+			res = false
+		}
+	}
+	if exp.YAML != nil {
+		ya := gdtyaml.New(exp.YAML, b)
+		if !ya.OK(ctx) {
+			a.failures = append(a.failures, ya.Failures()...)
+			res = false
+		}
+	}
+	return res
+}
+
+// needsContent returns true if any of the assertions examine the content of
+// the file.
+func (e *FileExpect) needsContent() bool {
+	return e.Content != nil || e.ContainsAll != nil || e.ContainsAny != nil ||
+		e.ContainsNone != nil || e.JSON != nil || e.YAML != nil
+}
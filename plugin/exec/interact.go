@@ -0,0 +1,212 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// InteractStep is a single step in driving an interactive command. The step
+// waits for Expect to appear in the command's stdout and then writes Send,
+// followed by a newline, to the command's stdin.
+type InteractStep struct {
+	// Expect is a string to wait for in the command's stdout. Only output
+	// written after the previous step's Expect matched is searched. If empty,
+	// Send is written immediately.
+	Expect string `yaml:"expect,omitempty"`
+	// Send is a line to write to the command's stdin once Expect has
+	// matched.
+	Send string `yaml:"send,omitempty"`
+	// Timeout is how long to wait for Expect to appear. If zero, the step
+	// waits until the test spec's timeout.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// ExecInvalidInteract returns a parse error describing why an exec spec's
+// interact field is invalid.
+func ExecInvalidInteract(msg string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeExecInvalidInteract,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid interact field: " + msg,
+	}
+}
+
+func (s *InteractStep) UnmarshalYAML(node *yaml.Node) error {
+	err := pluginutil.DecodeFields(node, pluginutil.Fields{
+		"expect":  pluginutil.String(&s.Expect),
+		"send":    pluginutil.String(&s.Send),
+		"timeout": pluginutil.Duration(&s.Timeout),
+	})
+	if err != nil {
+		return err
+	}
+	if s.Expect == "" && s.Send == "" {
+		return ExecInvalidInteract(
+			"each step requires expect or send", node,
+		)
+	}
+	return nil
+}
+
+var (
+	// ErrInteractExpectTimeout is an api.ErrFailure when an interact step's
+	// expected output does not appear before the step's timeout.
+	ErrInteractExpectTimeout = fmt.Errorf(
+		"%w: interact expect timed out", api.ErrFailure,
+	)
+)
+
+// InteractExpectTimeout returns an ErrInteractExpectTimeout for the supplied
+// expected output and timeout.
+func InteractExpectTimeout(expect string, timeout time.Duration) error {
+	return fmt.Errorf(
+		"%w: %q did not appear in stdout within %s",
+		ErrInteractExpectTimeout, expect, timeout,
+	)
+}
+
+// interactOutput is the io.Writer for an interactive command's stdout. It
+// writes to a Spool and keeps the output that has not yet been matched by an
+// interact step's Expect.
+type interactOutput struct {
+	sync.Mutex
+	spool   *Spool
+	pending string
+	// changed receives a value whenever output is written.
+	changed chan struct{}
+}
+
+func (o *interactOutput) Write(p []byte) (int, error) {
+	o.Lock()
+	n, err := o.spool.Write(p)
+	o.pending += string(p[:n])
+	o.Unlock()
+	select {
+	case o.changed <- struct{}{}:
+	default:
+	}
+	return n, err
+}
+
+// consume returns true if the supplied string is in the pending output, and
+// discards the pending output up to and including the match.
+func (o *interactOutput) consume(find string) bool {
+	o.Lock()
+	defer o.Unlock()
+	_, after, found := strings.Cut(o.pending, find)
+	if found {
+		o.pending = after
+	}
+	return found
+}
+
+// wait waits for the supplied string to appear in the output, returning false
+// if it does not appear before the supplied timeout or the context is done.
+func (o *interactOutput) wait(
+	ctx context.Context,
+	find string,
+	timeout time.Duration,
+) bool {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for !o.consume(find) {
+		select {
+		case <-o.changed:
+		case <-expired:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// interact executes the Spec's command and drives it with the Spec's
+// Interact steps. The `outbuf` and `errbuf` Spools will be filled with the
+// contents of the command's stdout and stderr pipes respectively. A failed
+// step returns an ErrInteractExpectTimeout.
+func (s *Spec) interact(
+	ctx context.Context,
+	outbuf *Spool,
+	errbuf *Spool,
+	exitcode *int,
+) error {
+	target, args := s.command(ctx, s.Exec)
+	cmd := exec.CommandContext(ctx, target, args...)
+	if _, err := s.configure(ctx, cmd); err != nil {
+		return err
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out := &interactOutput{
+		spool:   outbuf,
+		changed: make(chan struct{}, 1),
+	}
+	cmd.Stdout = out
+	cmd.Stderr = errbuf
+
+	err = s.start(cmd)
+	if gdtcontext.TimedOut(ctx, err) {
+		return api.ErrTimeoutExceeded
+	}
+	if err != nil {
+		return err
+	}
+	for x, step := range s.Interact {
+		if step.Expect != "" {
+			expect := gdtcontext.ReplaceVariables(ctx, step.Expect)
+			debug.Printf(ctx, "exec: interact[%d]: expect: %q", x, expect)
+			if !out.wait(ctx, expect, step.Timeout) {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+				if ctx.Err() != nil {
+					return api.ErrTimeoutExceeded
+				}
+				return InteractExpectTimeout(expect, step.Timeout)
+			}
+		}
+		if step.Send != "" {
+			send := gdtcontext.ReplaceVariables(ctx, step.Send)
+			debug.Printf(ctx, "exec: interact[%d]: send: %q", x, send)
+			if _, err := io.WriteString(stdin, send+"\n"); err != nil {
+				debug.Printf(ctx, "exec: interact[%d]: error sending: %s", x, err)
+			}
+		}
+	}
+	_ = stdin.Close()
+
+	err = cmd.Wait()
+	if gdtcontext.TimedOut(ctx, err) {
+		return api.ErrTimeoutExceeded
+	}
+	if err != nil && exitcode != nil {
+		if eerr, ok := err.(*exec.ExitError); ok {
+			*exitcode = eerr.ExitCode()
+		}
+	}
+	return nil
+}
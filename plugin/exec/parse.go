@@ -6,7 +6,10 @@ package exec
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -15,13 +18,61 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/gdt-dev/core/api"
+	gdtjson "github.com/gdt-dev/core/assertion/json"
+	gdttext "github.com/gdt-dev/core/assertion/text"
+	gdtyaml "github.com/gdt-dev/core/assertion/yaml"
 	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// Error codes for exec plugin parse errors.
+const (
+	// CodeExecEmpty indicates an empty exec field.
+	CodeExecEmpty = "GDT-P201"
+	// CodeExecInvalidShellParse indicates the exec field could not be parsed
+	// into shell arguments.
+	CodeExecInvalidShellParse = "GDT-P202"
+	// CodeExecUnknownShell indicates an unknown shell was specified.
+	CodeExecUnknownShell = "GDT-P203"
+	// CodeExecInvalidBackground indicates a background exec spec is
+	// missing a name or has assertions.
+	CodeExecInvalidBackground = "GDT-P204"
+	// CodeExecNoCommandForOS indicates the map form of the exec field has no
+	// command for the current operating system.
+	CodeExecNoCommandForOS = "GDT-P205"
+	// CodeExecInvalidExitCode indicates an exit code list or range could not
+	// be parsed.
+	CodeExecInvalidExitCode = "GDT-P206"
+	// CodeExecInvalidFileMode indicates the mode of a file assertion is not
+	// an octal number.
+	CodeExecInvalidFileMode = "GDT-P207"
+	// CodeExecInvalidInteract indicates an invalid interact field.
+	CodeExecInvalidInteract = "GDT-P208"
+	// CodeExecInvalidScript indicates the script field was combined with the
+	// exec field.
+	CodeExecInvalidScript = "GDT-P209"
+	// CodeExecInvalidVar indicates an invalid variable definition.
+	CodeExecInvalidVar = "GDT-P210"
+	// CodeExecInvalidUser indicates an unknown user or group, or a user or
+	// group on a platform that does not support them.
+	CodeExecInvalidUser = "GDT-P211"
+	// CodeExecInvalidRLimit indicates an invalid resource limit, or a
+	// resource limit on a platform that does not support them.
+	CodeExecInvalidRLimit = "GDT-P212"
+)
+
+const (
+	// defaultOSKey is the key in the map form of the exec and shell fields
+	// whose value is used when there is no key for the current operating
+	// system.
+	defaultOSKey = "default"
 )
 
 // ExecEmpty returns an ErrExecEmpty with the line/column of the supplied YAML
 // node.
 func ExecEmpty(node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeExecEmpty,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: "expected non-empty exec field",
@@ -32,6 +83,7 @@ func ExecEmpty(node *yaml.Node) error {
 // shlex.Split
 func ExecInvalidShellParse(err error, node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeExecInvalidShellParse,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("cannot parse shell args: %s", err),
@@ -42,18 +94,144 @@ func ExecInvalidShellParse(err error, node *yaml.Node) error {
 // user specified an unknown shell.
 func ExecUnknownShell(shell string, node *yaml.Node) error {
 	return &parse.Error{
+		Code:    CodeExecUnknownShell,
 		Line:    node.Line,
 		Column:  node.Column,
 		Message: fmt.Sprintf("unknown shell %q", shell),
 	}
 }
 
+// ExecInvalidBackground returns a parse error describing why a background
+// exec spec is invalid.
+func ExecInvalidBackground(msg string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeExecInvalidBackground,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid background exec spec: " + msg,
+	}
+}
+
+// ExecNoCommandForOS returns a parse error indicating that the map form of the
+// exec field has no command for the supplied operating system.
+func ExecNoCommandForOS(goos string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:   CodeExecNoCommandForOS,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"no command for operating system %q and no %q command",
+			goos, defaultOSKey,
+		),
+	}
+}
+
+// ExecInvalidExitCode returns an ErrExecInvalidExitCode with the line/column
+// of the supplied YAML node.
+func ExecInvalidExitCode(val string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:   CodeExecInvalidExitCode,
+		Line:   node.Line,
+		Column: node.Column,
+		Message: fmt.Sprintf(
+			"invalid exit code %q: expected an integer or a range, e.g. \"1-3\"",
+			val,
+		),
+	}
+}
+
+// exitCodesAt returns the exit codes in a supplied YAML node, which may be an
+// integer, a range of integers such as "1-3", or a sequence of either.
+func exitCodesAt(node *yaml.Node) ([]int, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		val := strings.TrimSpace(node.Value)
+		if ec, err := strconv.Atoi(val); err == nil {
+			return []int{ec}, nil
+		}
+		firstVal, lastVal, ok := strings.Cut(val, "-")
+		if !ok {
+			return nil, ExecInvalidExitCode(val, node)
+		}
+		first, err := strconv.Atoi(strings.TrimSpace(firstVal))
+		if err != nil {
+			return nil, ExecInvalidExitCode(val, node)
+		}
+		last, err := strconv.Atoi(strings.TrimSpace(lastVal))
+		if err != nil || last < first {
+			return nil, ExecInvalidExitCode(val, node)
+		}
+		codes := []int{}
+		for ec := first; ec <= last; ec++ {
+			codes = append(codes, ec)
+		}
+		return codes, nil
+	case yaml.SequenceNode:
+		codes := []int{}
+		for _, ecNode := range node.Content {
+			if ecNode.Kind != yaml.ScalarNode {
+				return nil, parse.ExpectedScalarAt(ecNode)
+			}
+			ecs, err := exitCodesAt(ecNode)
+			if err != nil {
+				return nil, err
+			}
+			codes = append(codes, ecs...)
+		}
+		return codes, nil
+	default:
+		return nil, parse.ExpectedScalarOrSequenceAt(node)
+	}
+}
+
+// ExecInvalidScript returns a parse error describing why an exec spec's script
+// field is invalid.
+func ExecInvalidScript(msg string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeExecInvalidScript,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid script field: " + msg,
+	}
+}
+
+// forOS returns the YAML node holding the value of a field that may be a map
+// of values keyed by operating system, e.g. "linux" or "windows". For the map
+// form, the value for the current operating system is returned, falling back
+// to the value of the "default" key, or nil if the map has neither. Any other
+// node is returned as-is.
+func forOS(node *yaml.Node) (*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return node, nil
+	}
+	vals := map[string]*yaml.Node{}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return nil, parse.ExpectedScalarAt(keyNode)
+		}
+		goos := keyNode.Value
+		if goos != defaultOSKey && !lo.Contains(api.ValidOSs, goos) {
+			return nil, parse.InvalidOSAt(keyNode, goos, api.ValidOSs)
+		}
+		vals[goos] = node.Content[i+1]
+	}
+	if v, found := vals[runtime.GOOS]; found {
+		return v, nil
+	}
+	return vals[defaultOSKey], nil
+}
+
 func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 	if node.Kind != yaml.MappingNode {
 		return parse.ExpectedMapAt(node)
 	}
 	vars := Variables{}
 	var execValNode *yaml.Node
+	var backgroundValNode *yaml.Node
+	var interactValNode *yaml.Node
+	var scriptValNode *yaml.Node
+	hasName := false
 	// maps/structs are stored in a top-level Node.Content field which is a
 	// concatenated slice of Node pointers in pairs of key/values.
 	for i := 0; i < len(node.Content); i += 2 {
@@ -98,22 +276,157 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 			}
 			vars = lo.Assign(specVars, vars)
 		case "shell":
-			if valNode.Kind != yaml.ScalarNode {
-				return parse.ExpectedScalarAt(valNode)
+			shellNode, err := forOS(valNode)
+			if err != nil {
+				return err
 			}
-			s.Shell = strings.TrimSpace(valNode.Value)
+			if shellNode == nil {
+				// No shell for this OS means execute the command directly.
+				continue
+			}
+			if shellNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarOrMapAt(shellNode)
+			}
+			s.Shell = strings.TrimSpace(shellNode.Value)
 			if _, err := exec.LookPath(s.Shell); err != nil {
 				return ExecUnknownShell(s.Shell, valNode)
 			}
+		case "stdin":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Stdin = valNode.Value
+			if path, ok := strings.CutPrefix(s.Stdin, stdinFilePrefix); ok {
+				// Relative filepaths are relative to the scenario file's
+				// directory, which is the working directory during parsing.
+				path, _ = filepath.Abs(path)
+				if _, err := os.Stat(path); err != nil {
+					return parse.FileNotFoundAt(path, valNode)
+				}
+				s.Stdin = stdinFilePrefix + path
+			}
+		case "script":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			// Relative filepaths are relative to the scenario file's
+			// directory, which is the working directory during parsing.
+			path, _ := filepath.Abs(strings.TrimSpace(valNode.Value))
+			if _, err := os.Stat(path); err != nil {
+				return parse.FileNotFoundAt(path, valNode)
+			}
+			s.Script = path
+			scriptValNode = valNode
+		case "user":
+			name, err := pluginutil.StringAt(valNode)
+			if err != nil {
+				return err
+			}
+			if !userSupported {
+				return ExecInvalidUser(
+					"not supported on "+runtime.GOOS, valNode,
+				)
+			}
+			s.User = strings.TrimSpace(name)
+			if _, _, _, err := lookupUser(s.User); err != nil {
+				return ExecInvalidUser(err.Error(), valNode)
+			}
+		case "group":
+			name, err := pluginutil.StringAt(valNode)
+			if err != nil {
+				return err
+			}
+			if !userSupported {
+				return ExecInvalidUser(
+					"not supported on "+runtime.GOOS, valNode,
+				)
+			}
+			s.Group = strings.TrimSpace(name)
+			if _, err := lookupGroup(s.Group); err != nil {
+				return ExecInvalidUser(err.Error(), valNode)
+			}
+		case "rlimit":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			if !rlimitSupported {
+				return ExecInvalidRLimit(
+					"not supported on "+runtime.GOOS, valNode,
+				)
+			}
+			var r *RLimit
+			if err := valNode.Decode(&r); err != nil {
+				return err
+			}
+			s.RLimit = r
+		case "background":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			bg, err := strconv.ParseBool(valNode.Value)
+			if err != nil {
+				return parse.ExpectedBoolAt(valNode)
+			}
+			s.Background = bg
+			backgroundValNode = valNode
+		case "dir":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			// The directory may be created by an earlier test spec, so we
+			// only make it absolute here and do not check it exists.
+			s.Dir, _ = filepath.Abs(strings.TrimSpace(valNode.Value))
 		case "exec":
+			cmdNode, err := forOS(valNode)
+			if err != nil {
+				return err
+			}
+			if cmdNode == nil {
+				return ExecNoCommandForOS(runtime.GOOS, valNode)
+			}
+			execValNode = cmdNode
+			switch cmdNode.Kind {
+			case yaml.ScalarNode:
+				s.Exec = strings.TrimSpace(cmdNode.Value)
+				if s.Exec == "" {
+					return ExecEmpty(cmdNode)
+				}
+			case yaml.SequenceNode:
+				for _, stepNode := range cmdNode.Content {
+					if stepNode.Kind != yaml.ScalarNode {
+						return parse.ExpectedScalarAt(stepNode)
+					}
+					step := strings.TrimSpace(stepNode.Value)
+					if step == "" {
+						return ExecEmpty(stepNode)
+					}
+					s.Sequence = append(s.Sequence, step)
+				}
+				if len(s.Sequence) == 0 {
+					return ExecEmpty(cmdNode)
+				}
+			default:
+				return parse.ExpectedScalarOrSequenceAt(cmdNode)
+			}
+		case "ignore-errors", "ignore_errors":
 			if valNode.Kind != yaml.ScalarNode {
 				return parse.ExpectedScalarAt(valNode)
 			}
-			execValNode = valNode
-			s.Exec = strings.TrimSpace(valNode.Value)
-			if s.Exec == "" {
-				return ExecEmpty(valNode)
+			ignore, err := strconv.ParseBool(valNode.Value)
+			if err != nil {
+				return parse.ExpectedBoolAt(valNode)
+			}
+			s.IgnoreErrors = ignore
+		case "interact":
+			if valNode.Kind != yaml.SequenceNode {
+				return parse.ExpectedSequenceAt(valNode)
 			}
+			var steps []InteractStep
+			if err := valNode.Decode(&steps); err != nil {
+				return err
+			}
+			s.Interact = steps
+			interactValNode = valNode
 		case "assert":
 			if valNode.Kind != yaml.MappingNode {
 				return parse.ExpectedMapAt(valNode)
@@ -144,21 +457,64 @@ func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
 			s.On = o
 		default:
 			if lo.Contains(api.BaseSpecFields, key) {
+				hasName = hasName || key == "name"
 				continue
 			}
 			return parse.UnknownFieldAt(key, keyNode)
 		}
 	}
+	if s.Background {
+		if !hasName {
+			return ExecInvalidBackground(
+				"a name is required to refer to the command", backgroundValNode,
+			)
+		}
+		if s.Assert != nil || len(vars) > 0 {
+			return ExecInvalidBackground(
+				"assertions and variables are not supported", backgroundValNode,
+			)
+		}
+		if len(s.Sequence) > 0 {
+			return ExecInvalidBackground(
+				"a list of commands is not supported", backgroundValNode,
+			)
+		}
+	}
+	if len(s.Interact) > 0 {
+		switch {
+		case s.Background:
+			return ExecInvalidInteract(
+				"not supported for background commands", interactValNode,
+			)
+		case len(s.Sequence) > 0:
+			return ExecInvalidInteract(
+				"not supported for a list of commands", interactValNode,
+			)
+		case s.Stdin != "":
+			return ExecInvalidInteract(
+				"cannot be used with stdin", interactValNode,
+			)
+		}
+	}
 	if len(vars) > 0 {
 		s.Var = vars
 	}
-	if s.Exec == "" {
+	if s.Script != "" {
+		if s.Exec != "" || len(s.Sequence) > 0 {
+			return ExecInvalidScript(
+				"cannot be used with exec", scriptValNode,
+			)
+		}
+		return nil
+	}
+	if s.Exec == "" && len(s.Sequence) == 0 {
 		return ExecEmpty(node)
 	}
 	if s.Shell != "" {
-		_, err := shlex.Split(s.Exec)
-		if err != nil {
-			return ExecInvalidShellParse(err, execValNode)
+		for _, cmd := range append([]string{s.Exec}, s.Sequence...) {
+			if _, err := shlex.Split(cmd); err != nil {
+				return ExecInvalidShellParse(err, execValNode)
+			}
 		}
 	}
 	return nil
@@ -189,14 +545,15 @@ func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 			}
 			e.Require = req
 		case "exit_code", "exit-code":
-			if valNode.Kind != yaml.ScalarNode {
-				return parse.ExpectedScalarAt(valNode)
-			}
-			ec, err := strconv.Atoi(valNode.Value)
+			codes, err := exitCodesAt(valNode)
 			if err != nil {
 				return err
 			}
-			e.ExitCode = ec
+			if valNode.Kind == yaml.ScalarNode && len(codes) == 1 {
+				e.ExitCode = codes[0]
+				continue
+			}
+			e.ExitCodes = codes
 		case "out":
 			if valNode.Kind != yaml.MappingNode {
 				return parse.ExpectedMapAt(valNode)
@@ -215,6 +572,25 @@ func (e *Expect) UnmarshalYAML(node *yaml.Node) error {
 				return err
 			}
 			e.Err = pe
+		case "duration":
+			var de DurationExpect
+			err := pluginutil.DecodeFields(valNode, pluginutil.Fields{
+				"max": pluginutil.Duration(&de.Max),
+				"min": pluginutil.Duration(&de.Min),
+			})
+			if err != nil {
+				return err
+			}
+			e.Duration = &de
+		case "files":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			var fe map[string]*FileExpect
+			if err := valNode.Decode(&fe); err != nil {
+				return err
+			}
+			e.Files = fe
 		default:
 			return parse.UnknownFieldAt(key, keyNode)
 		}
@@ -226,6 +602,8 @@ func (e *PipeExpect) UnmarshalYAML(node *yaml.Node) error {
 	if node.Kind != yaml.MappingNode {
 		return parse.ExpectedMapAt(node)
 	}
+	// textNode collects the fields decoded by the text assertion package.
+	textNode := &yaml.Node{Kind: yaml.MappingNode}
 	// maps/structs are stored in a top-level Node.Content field which is a
 	// concatenated slice of Node pointers in pairs of key/values.
 	for i := 0; i < len(node.Content); i += 2 {
@@ -263,9 +641,100 @@ func (e *PipeExpect) UnmarshalYAML(node *yaml.Node) error {
 				return err
 			}
 			e.ContainsNone = &v
+		case "json":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			var je *gdtjson.Expect
+			if err := valNode.Decode(&je); err != nil {
+				return err
+			}
+			e.JSON = je
+		case "yaml":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			var ye *gdtyaml.Expect
+			if err := valNode.Decode(&ye); err != nil {
+				return err
+			}
+			e.YAML = ye
+		case "matches", "line-count", "line_count", "golden":
+			textNode.Content = append(textNode.Content, keyNode, valNode)
+		default:
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	if len(textNode.Content) > 0 {
+		var te gdttext.Expect
+		if err := textNode.Decode(&te); err != nil {
+			return err
+		}
+		e.Text = &te
+	}
+	return nil
+}
+
+func (s *StopSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "stop":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Stop = strings.TrimSpace(valNode.Value)
 		default:
+			if lo.Contains(api.BaseSpecFields, key) {
+				continue
+			}
 			return parse.UnknownFieldAt(key, keyNode)
 		}
 	}
+	if s.Stop == "" {
+		return parse.UnknownFieldAt("stop", node)
+	}
+	return nil
+}
+
+func (s *StatusSpec) UnmarshalYAML(node *yaml.Node) error {
+	var runningNode, exitedNode *yaml.Node
+	err := pluginutil.DecodeSpec(node, pluginutil.Fields{
+		"running": func(valNode *yaml.Node) error {
+			runningNode = valNode
+			return pluginutil.String(&s.Running)(valNode)
+		},
+		"exited": func(valNode *yaml.Node) error {
+			exitedNode = valNode
+			return pluginutil.String(&s.Exited)(valNode)
+		},
+		"after": pluginutil.Duration(&s.After),
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case runningNode == nil && exitedNode == nil:
+		return parse.UnknownFieldAt("running", node)
+	case runningNode != nil && exitedNode != nil:
+		return ExecInvalidBackground(
+			"running and exited cannot be used together", exitedNode,
+		)
+	case s.Running == "" && s.Exited == "":
+		if runningNode != nil {
+			return parse.ExpectedScalarAt(runningNode)
+		}
+		return parse.ExpectedScalarAt(exitedNode)
+	}
 	return nil
 }
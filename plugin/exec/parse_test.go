@@ -7,7 +7,9 @@ package exec_test
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/gdt-dev/core/api"
 	"github.com/gdt-dev/core/parse"
@@ -34,6 +36,336 @@ func TestParseUnknownShell(t *testing.T) {
 	assert.Nil(s)
 }
 
+func TestParseStdinFileNotFound(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "stdin-file-not-found.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(parse.CodeFileNotFound, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseBackgroundNoName(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background-no-name.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidBackground, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseBackgroundSequence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background-sequence.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidBackground, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseBackgroundStatusBoth(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "background-status-both.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidBackground, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseExecSequence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exec-sequence.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.Len(s.Tests, 3)
+
+	sp := s.Tests[0].(*gdtexec.Spec)
+	assert.Equal("", sp.Exec)
+	assert.Equal([]string{"echo one", "echo two"}, sp.Sequence)
+	assert.False(sp.IgnoreErrors)
+
+	sp = s.Tests[2].(*gdtexec.Spec)
+	assert.True(sp.IgnoreErrors)
+}
+
+func TestParseExitCodeSet(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exit-code-set.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.Len(s.Tests, 3)
+
+	sp := s.Tests[0].(*gdtexec.Spec)
+	assert.Equal([]int{1, 2}, sp.Assert.ExitCodes)
+	sp = s.Tests[1].(*gdtexec.Spec)
+	assert.Equal([]int{1, 2}, sp.Assert.ExitCodes)
+	sp = s.Tests[2].(*gdtexec.Spec)
+	assert.Equal([]int{0, 3, 4, 5}, sp.Assert.ExitCodes)
+}
+
+func TestParseExitCodeInvalidRange(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exit-code-invalid-range.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidExitCode, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseFilesInvalidMode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "files-invalid-mode.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidFileMode, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseInteractWithStdin(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "interact-with-stdin.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidInteract, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseScriptWithExec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "script-with-exec.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidScript, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseScriptNotFound(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "script-not-found.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(parse.CodeFileNotFound, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseVarMatchReturnCode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "var-match-returncode.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidVar, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseExecPerOSInvalid(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exec-per-os-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(parse.CodeInvalidOS, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseExecPerOS(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "exec-per-os.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.Len(s.Tests, 2)
+
+	sp := s.Tests[0].(*gdtexec.Spec)
+	sh := s.Tests[1].(*gdtexec.Spec)
+	if runtime.GOOS == "windows" {
+		assert.Equal("cmd /c echo windows", sp.Exec)
+		assert.Equal("powershell", sh.Shell)
+	} else {
+		assert.Equal("echo unix", sp.Exec)
+		assert.Equal("sh", sh.Shell)
+	}
+}
+
+func TestParseUserUnknown(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "user-unknown.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidUser, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseRLimitInvalid(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "rlimit-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.NotNil(err)
+	assert.Equal(gdtexec.CodeExecInvalidRLimit, api.ErrorCode(err))
+	assert.Nil(s)
+}
+
+func TestParseDefaults(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "defaults.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(
+		f,
+		scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	require.Len(s.Tests, 3)
+
+	dir, err := filepath.Abs(filepath.Join("testdata", "dir"))
+	require.Nil(err)
+
+	sp := s.Tests[0].(*gdtexec.Spec)
+	assert.Equal("sh", sp.Shell)
+	assert.Equal(dir, sp.Dir)
+	require.NotNil(sp.Timeout())
+	assert.Equal("2s", sp.Timeout().After)
+
+	override := s.Tests[2].(*gdtexec.Spec)
+	assert.Equal(filepath.Dir(dir), override.Dir)
+	assert.Nil(override.Timeout())
+	assert.Equal(5*time.Second, s.Timings.MaxTimeout)
+}
+
 func TestParseSimpleCommand(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -5,6 +5,8 @@
 package exec
 
 import (
+	"slices"
+
 	"github.com/gdt-dev/core/api"
 	gdtplugin "github.com/gdt-dev/core/plugin"
 )
@@ -33,12 +35,379 @@ const (
 
 type plugin struct{}
 
+var (
+	// pipeFields documents the assertions for the `out` and `err` pipes.
+	pipeFields = []api.FieldDoc{
+		{
+			Name:        "contains",
+			Type:        "string or []string",
+			Description: "strings that must all be present in the pipe",
+			Examples:    []string{"cat", "[cat, dog]"},
+		},
+		{
+			Name:        "contains-one-of",
+			Type:        "string or []string",
+			Description: "strings of which at least one must be present in the pipe",
+		},
+		{
+			Name:        "contains-none-of",
+			Type:        "string or []string",
+			Description: "strings that must not be present in the pipe",
+		},
+		{
+			Name:        "json",
+			Type:        "map",
+			Description: "assertions about the pipe's contents parsed as JSON",
+			Fields: []api.FieldDoc{
+				{
+					Name:        "len",
+					Type:        "int",
+					Description: "expected length of the JSON content",
+				},
+				{
+					Name:        "paths",
+					Type:        "map",
+					Description: "expected values keyed by JSONPath expression",
+					Examples:    []string{"$.name: cat"},
+				},
+				{
+					Name:        "path-formats",
+					Type:        "map",
+					Description: "expected value formats keyed by JSONPath expression",
+					Examples:    []string{"$.id: uuid4"},
+				},
+				{
+					Name:        "schema",
+					Type:        "string",
+					Description: "filepath to a JSONSchema the content must validate against",
+				},
+			},
+		},
+		{
+			Name:        "yaml",
+			Type:        "map",
+			Description: "assertions about the pipe's contents parsed as YAML, with the same fields as json",
+		},
+		{
+			Name:        "matches",
+			Type:        "string or []string",
+			Description: "regular expressions that must all match the pipe's contents",
+			Examples:    []string{"'^id=[0-9]+$'"},
+		},
+		{
+			Name:        "line-count",
+			Type:        "int",
+			Description: "expected number of lines in the pipe's contents",
+		},
+		{
+			Name:        "golden",
+			Type:        "string",
+			Description: "path to a golden file with the exact expected contents, relative to the scenario's golden file directory. the file is rewritten with the actual contents when golden files are being updated",
+			Examples:    []string{"list.txt"},
+		},
+	}
+	// fileFields documents the assertions for each file in `files`.
+	fileFields = []api.FieldDoc{
+		{
+			Name:        "exists",
+			Type:        "bool",
+			Description: "whether the file is expected to exist (default true)",
+		},
+		{
+			Name:        "size",
+			Type:        "int",
+			Description: "expected size of the file in bytes",
+		},
+		{
+			Name:        "mode",
+			Type:        "string",
+			Description: "expected permission bits of the file in octal",
+			Examples:    []string{"0644"},
+		},
+		{
+			Name:        "content",
+			Type:        "string",
+			Description: "exact expected content of the file",
+		},
+		{
+			Name:        "contains",
+			Type:        "string or []string",
+			Description: "strings that must all be present in the file",
+		},
+		{
+			Name:        "contains-one-of",
+			Type:        "string or []string",
+			Description: "strings of which at least one must be present in the file",
+		},
+		{
+			Name:        "contains-none-of",
+			Type:        "string or []string",
+			Description: "strings that must not be present in the file",
+		},
+		{
+			Name:        "json",
+			Type:        "map",
+			Description: "assertions about the file's content parsed as JSON",
+		},
+		{
+			Name:        "yaml",
+			Type:        "map",
+			Description: "assertions about the file's content parsed as YAML, with the same fields as json",
+		},
+	}
+	// expectFields documents the `assert` and `require` fields.
+	expectFields = []api.FieldDoc{
+		{
+			Name:        "require",
+			Type:        "bool",
+			Description: "stop the scenario if any of the assertions fail",
+		},
+		{
+			Name:        "exit-code",
+			Type:        "int, list or range",
+			Description: "expected exit code of the command (default 0), or a list or range of exit codes any of which is expected",
+			Examples:    []string{"2", "[0, 2]", "1-3"},
+		},
+		{
+			Name:        "out",
+			Type:        "map",
+			Description: "assertions about the command's stdout",
+			Fields:      pipeFields,
+		},
+		{
+			Name:        "err",
+			Type:        "map",
+			Description: "assertions about the command's stderr",
+			Fields:      pipeFields,
+		},
+		{
+			Name:        "duration",
+			Type:        "map",
+			Description: "bounds on how long the command may take to execute",
+			Fields: []api.FieldDoc{
+				{
+					Name:        "max",
+					Type:        "duration",
+					Description: "longest the command may take to execute",
+					Examples:    []string{"500ms", "2s"},
+				},
+				{
+					Name:        "min",
+					Type:        "duration",
+					Description: "shortest the command may take to execute",
+					Examples:    []string{"10ms"},
+				},
+			},
+		},
+		{
+			Name:        "files",
+			Type:        "map",
+			Description: "assertions about files after the command executes, keyed by file path relative to the command's working directory",
+			Fields:      fileFields,
+		},
+	}
+	// actionFields documents the fields of the command a test spec or its
+	// `on.fail` executes.
+	actionFields = []api.FieldDoc{
+		{
+			Name:        "exec",
+			Type:        "string, list or map",
+			Description: "the exact command to execute, a list of commands to execute in order, or a map of either keyed by operating system (linux, darwin, windows or default). required unless script is set",
+			Examples:    []string{"echo cat", "ls -l /tmp", "[make, make install]", "{linux: ls, windows: dir}"},
+		},
+		{
+			Name:        "ignore-errors",
+			Type:        "bool",
+			Description: "when exec is a list, execute every command even if one returns a non-zero exit code",
+		},
+		{
+			Name:        "shell",
+			Type:        "string or map",
+			Description: "shell to execute the command with, or a map of shells keyed by operating system. when empty, the command is executed directly",
+			Examples:    []string{"sh", "bash"},
+		},
+		{
+			Name:        "script",
+			Type:        "string",
+			Description: "path to a shell script file, relative to the scenario file, to execute with shell (default sh) instead of exec",
+			Examples:    []string{"scripts/setup.sh"},
+		},
+		{
+			Name:        "stdin",
+			Type:        "string",
+			Description: "content to pipe to the command's stdin, or a file:// reference to a file whose contents are piped",
+			Examples:    []string{"hello", "file://testdata/input.txt"},
+		},
+		{
+			Name:        "dir",
+			Type:        "string",
+			Description: "working directory for the command, relative to the scenario file",
+			Examples:    []string{"testdata", "/tmp"},
+		},
+		{
+			Name:        "user",
+			Type:        "string",
+			Description: "name or numeric ID of the user to run the command as. usually requires gdt to run as root. not supported on Windows",
+			Examples:    []string{"nobody", "65534"},
+		},
+		{
+			Name:        "group",
+			Type:        "string",
+			Description: "name or numeric ID of the group to run the command as (default the primary group of user). not supported on Windows",
+			Examples:    []string{"nogroup"},
+		},
+		{
+			Name:        "rlimit",
+			Type:        "map",
+			Description: "resource limits applied to the command. only supported on Linux",
+			Fields: []api.FieldDoc{
+				{
+					Name:        "nofile",
+					Type:        "int",
+					Description: "maximum number of open file descriptors",
+					Examples:    []string{"64"},
+				},
+				{
+					Name:        "cpu",
+					Type:        "duration",
+					Description: "maximum CPU time, rounded up to whole seconds",
+					Examples:    []string{"2s"},
+				},
+				{
+					Name:        "memory",
+					Type:        "string",
+					Description: "maximum virtual memory in bytes, with an optional K, M or G suffix",
+					Examples:    []string{"256M"},
+				},
+			},
+		},
+		{
+			Name:        "var-stdout",
+			Type:        "string",
+			Description: "name of a variable to save the command's stdout in",
+		},
+		{
+			Name:        "var-stderr",
+			Type:        "string",
+			Description: "name of a variable to save the command's stderr in",
+		},
+		{
+			Name:        "var-rc",
+			Type:        "string",
+			Description: "name of a variable to save the command's exit code in",
+		},
+	}
+	// fieldDocs documents the exec plugin's test spec fields.
+	fieldDocs = append(slices.Clone(actionFields), []api.FieldDoc{
+		{
+			Name:        "assert",
+			Type:        "map",
+			Description: "conditions to assert about the command's result",
+			Fields:      expectFields,
+		},
+		{
+			Name:        "require",
+			Type:        "map",
+			Description: "conditions to assert about the command's result. any failure stops the scenario",
+			Fields:      expectFields,
+		},
+		{
+			Name:        "on",
+			Type:        "map",
+			Description: "actions to take upon certain conditions",
+			Fields: []api.FieldDoc{
+				{
+					Name:        "fail",
+					Type:        "map",
+					Description: "command to execute when any assertion fails",
+					Fields:      actionFields,
+				},
+			},
+		},
+		{
+			Name:        "var",
+			Type:        "map",
+			Description: "variables to save for subsequent test specs, keyed by variable name",
+			Examples:    []string{"{MYVAR: {from: stdout}}", "{ID: {from: stdout, match: 'id=(?P<id>\\d+)'}}"},
+			Fields: []api.FieldDoc{
+				{
+					Name:        "from",
+					Type:        "string",
+					Description: "stdout, stderr, returncode or the name of an environment variable to save the variable's value from",
+					Required:    true,
+				},
+				{
+					Name:        "match",
+					Type:        "string",
+					Description: "regular expression that extracts the value: the first named capture group, else the first capture group, else the entire match",
+				},
+			},
+		},
+		{
+			Name:        "background",
+			Type:        "bool",
+			Description: "start the command and leave it running until a `stop` spec naming this spec or the end of the scenario. requires `name`",
+		},
+		{
+			Name:        "interact",
+			Type:        "[]map",
+			Description: "steps that drive an interactive command, each waiting for `expect` in stdout (within an optional `timeout`) and then writing the `send` line to stdin",
+			Examples:    []string{"[{expect: \"name?\", send: cat, timeout: 1s}]"},
+			Fields: []api.FieldDoc{
+				{
+					Name:        "expect",
+					Type:        "string",
+					Description: "text to wait for in the command's stdout",
+				},
+				{
+					Name:        "send",
+					Type:        "string",
+					Description: "line to write to the command's stdin",
+				},
+				{
+					Name:        "timeout",
+					Type:        "duration",
+					Description: "how long to wait for expect",
+					Examples:    []string{"1s"},
+				},
+			},
+		},
+		{
+			Name:        "stop",
+			Type:        "string",
+			Description: "name of a `background` spec whose command should be stopped. used instead of `exec`",
+			Examples:    []string{"server"},
+		},
+		{
+			Name:        "running",
+			Type:        "string",
+			Description: "name of a `background` spec whose command is expected to still be running. used instead of `exec`",
+			Examples:    []string{"server"},
+		},
+		{
+			Name:        "exited",
+			Type:        "string",
+			Description: "name of a `background` spec whose command is expected to have exited. used instead of `exec`",
+			Examples:    []string{"server"},
+		},
+		{
+			Name:        "after",
+			Type:        "duration",
+			Description: "with running, how long the command must keep running. with exited, how long to wait for the command to exit",
+			Examples:    []string{"500ms"},
+		},
+	}...)
+)
+
 func (p *plugin) Info() api.PluginInfo {
 	return api.PluginInfo{
-		Name: pluginName,
+		Name:        pluginName,
+		APIVersion:  api.APIVersion,
+		Description: "executes commands and asserts their exit code and output",
 		Timeout: &api.Timeout{
 			After: DefaultTimeout,
 		},
+		Fields: fieldDocs,
 	}
 }
 
@@ -47,7 +416,7 @@ func (p *plugin) Defaults() api.DefaultsHandler {
 }
 
 func (p *plugin) Specs() []api.Evaluable {
-	return []api.Evaluable{&Spec{}}
+	return []api.Evaluable{&Spec{}, &StopSpec{}, &StatusSpec{}}
 }
 
 // Plugin returns the HTTP gdt plugin
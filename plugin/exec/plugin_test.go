@@ -0,0 +1,93 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gdt-dev/core/api"
+	gdtexec "github.com/gdt-dev/core/plugin/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+// execPkgPath is the package path of the exec plugin's types. The fields of
+// nested types from other packages, e.g. JSON assertions, are not walked.
+var execPkgPath = reflect.TypeOf(gdtexec.Spec{}).PkgPath()
+
+// inlineFields are the types of fields without a YAML name whose fields are
+// parsed as if they were fields of the containing type.
+var inlineFields = map[string]bool{
+	"Text": true,
+}
+
+// undocumentedFields walks the YAML fields of the supplied type, recursing
+// into nested exec plugin types, and returns the dotted names of those fields
+// missing from the supplied FieldDocs.
+func undocumentedFields(
+	typ reflect.Type,
+	docs []api.FieldDoc,
+	prefix string,
+) []string {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice ||
+		typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	missing := []string{}
+	for x := 0; x < typ.NumField(); x++ {
+		field := typ.Field(x)
+		if field.Type == reflect.TypeOf(api.Spec{}) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if field.Anonymous && name == "" {
+			missing = append(missing, undocumentedFields(field.Type, docs, prefix)...)
+			continue
+		}
+		if name == "-" && inlineFields[field.Name] {
+			missing = append(missing, undocumentedFields(field.Type, docs, prefix)...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		var doc *api.FieldDoc
+		for y := range docs {
+			if docs[y].Name == name {
+				doc = &docs[y]
+				break
+			}
+		}
+		if doc == nil {
+			missing = append(missing, prefix+name)
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice ||
+			ft.Kind() == reflect.Map {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft.PkgPath() == execPkgPath {
+			missing = append(
+				missing, undocumentedFields(ft, doc.Fields, prefix+name+".")...,
+			)
+		}
+	}
+	return missing
+}
+
+func TestInfoFieldsDocumented(t *testing.T) {
+	assert := assert.New(t)
+
+	fields := gdtexec.Plugin().Info().Fields
+	for _, spec := range gdtexec.Plugin().Specs() {
+		typ := reflect.TypeOf(spec)
+		assert.Empty(
+			undocumentedFields(typ.Elem(), fields, ""),
+			"%s has fields missing from Info().Fields", typ,
+		)
+	}
+}
@@ -0,0 +1,104 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// memoryUnits are the suffixes accepted in an RLimit's memory field.
+var memoryUnits = map[string]uint64{
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+}
+
+// RLimit contains resource limits that are applied to an executed command.
+// Both the soft and hard limits are set. Resource limits are only supported
+// on Linux.
+type RLimit struct {
+	// NoFile is the maximum number of file descriptors the command may have
+	// open.
+	NoFile *uint64 `yaml:"nofile,omitempty"`
+	// CPU is the maximum amount of CPU time the command may use, rounded up
+	// to a whole number of seconds.
+	CPU *time.Duration `yaml:"cpu,omitempty"`
+	// Memory is the maximum size in bytes of the command's virtual memory,
+	// rounded up to a whole number of kilobytes. A suffix of K, M or G may be
+	// used, e.g. "256M".
+	Memory *uint64 `yaml:"memory,omitempty"`
+}
+
+// ExecInvalidRLimit returns a parse error describing why an exec spec's
+// rlimit field is invalid.
+func ExecInvalidRLimit(msg string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeExecInvalidRLimit,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid rlimit field: " + msg,
+	}
+}
+
+func (r *RLimit) UnmarshalYAML(node *yaml.Node) error {
+	return pluginutil.DecodeFields(node, pluginutil.Fields{
+		"nofile": func(valNode *yaml.Node) error {
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			n, err := strconv.ParseUint(valNode.Value, 10, 64)
+			if err != nil {
+				return ExecInvalidRLimit(
+					fmt.Sprintf("invalid nofile %q", valNode.Value), valNode,
+				)
+			}
+			r.NoFile = &n
+			return nil
+		},
+		"cpu": func(valNode *yaml.Node) error {
+			d, err := pluginutil.DurationAt(valNode)
+			if err != nil {
+				return err
+			}
+			if d <= 0 {
+				return ExecInvalidRLimit("cpu must be positive", valNode)
+			}
+			r.CPU = &d
+			return nil
+		},
+		"memory": func(valNode *yaml.Node) error {
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			val := strings.TrimSpace(valNode.Value)
+			unit := uint64(1)
+			for suffix, mult := range memoryUnits {
+				if num, ok := strings.CutSuffix(val, suffix); ok {
+					val = num
+					unit = mult
+					break
+				}
+			}
+			n, err := strconv.ParseUint(val, 10, 64)
+			if err != nil || n == 0 || n > math.MaxUint64/unit {
+				return ExecInvalidRLimit(
+					fmt.Sprintf("invalid memory %q", valNode.Value), valNode,
+				)
+			}
+			mem := n * unit
+			r.Memory = &mem
+			return nil
+		},
+	})
+}
@@ -0,0 +1,83 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// rlimitSupported indicates that resource limits can be applied to commands
+// on this platform.
+const rlimitSupported = true
+
+// start starts the supplied command with the resource limits applied. The
+// command is started traced, so that it stops as soon as it executes the
+// target and before the target runs. The limits are then set on the stopped
+// command with prlimit(2) and the command is released. Only the thread that
+// started a traced command may release it, so the goroutine is locked to its
+// thread until then.
+func (r *RLimit) start(cmd *exec.Cmd) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Ptrace = true
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pid := cmd.Process.Pid
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(pid, &ws, 0, nil); err != nil {
+		return r.abort(cmd, err)
+	}
+	if !ws.Stopped() {
+		return fmt.Errorf("rlimit: command exited before its limits were set")
+	}
+	if err := r.apply(pid); err != nil {
+		return r.abort(cmd, err)
+	}
+	if err := syscall.PtraceDetach(pid); err != nil {
+		return r.abort(cmd, err)
+	}
+	return nil
+}
+
+// apply sets the resource limits on the process with the supplied ID.
+func (r *RLimit) apply(pid int) error {
+	limits := map[int]uint64{}
+	if r.NoFile != nil {
+		limits[unix.RLIMIT_NOFILE] = *r.NoFile
+	}
+	if r.CPU != nil {
+		limits[unix.RLIMIT_CPU] = uint64(math.Ceil(r.CPU.Seconds()))
+	}
+	if r.Memory != nil {
+		// The memory limit is rounded up to a whole number of kilobytes.
+		limits[unix.RLIMIT_AS] = (*r.Memory + 1023) / 1024 * 1024
+	}
+	for resource, limit := range limits {
+		rlim := &unix.Rlimit{Cur: limit, Max: limit}
+		if err := unix.Prlimit(pid, resource, rlim, nil); err != nil {
+			return fmt.Errorf("rlimit: %w", err)
+		}
+	}
+	return nil
+}
+
+// abort kills the supplied command, which was started but could not have its
+// resource limits applied, and returns the supplied error.
+func (r *RLimit) abort(cmd *exec.Cmd, err error) error {
+	killErr := cmd.Process.Kill()
+	_ = cmd.Wait()
+	return errors.Join(err, killErr)
+}
@@ -0,0 +1,21 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+//go:build !linux
+
+package exec
+
+import (
+	"os/exec"
+)
+
+// rlimitSupported indicates that resource limits can be applied to commands
+// on this platform.
+const rlimitSupported = false
+
+// start starts the supplied command. Parsing rejects the rlimit field on
+// platforms that do not support resource limits.
+func (r *RLimit) start(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
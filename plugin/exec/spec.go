@@ -25,10 +25,38 @@ type Spec struct {
 	// facilitating the passing of variables between test specs potentially
 	// provided by different gdt Plugins.
 	Var Variables `yaml:"var,omitempty"`
+	// Background indicates that the command should be started and left
+	// running while subsequent test specs are evaluated. The command is
+	// stopped by a `stop` test spec referring to this test spec's name or
+	// when the test scenario ends.
+	Background bool `yaml:"background,omitempty"`
+	// Interact is a list of steps that drive an interactive command by
+	// waiting for output on the command's stdout and writing lines to the
+	// command's stdin.
+	Interact []InteractStep `yaml:"interact,omitempty"`
+}
+
+// Files returns the paths of the files that the test spec references: its
+// script, a file piped to its stdin, and the golden, JSONSchema and JSON
+// files referenced by its assertions.
+func (s *Spec) Files() []string {
+	files := s.Action.files()
+	for _, exp := range []*Expect{s.Require, s.Assert} {
+		if exp != nil {
+			files = append(files, exp.files()...)
+		}
+	}
+	return files
 }
 
 func (s *Spec) SetBase(b api.Spec) {
 	s.Spec = b
+	if d, ok := b.Defaults.For(pluginName).(*Defaults); ok {
+		s.Action.applyDefaults(&d.execDefaults)
+		if s.On != nil && s.On.Fail != nil {
+			s.On.Fail.applyDefaults(&d.execDefaults)
+		}
+	}
 }
 
 func (s *Spec) Base() *api.Spec {
@@ -39,6 +67,14 @@ func (s *Spec) Retry() *api.Retry {
 	return nil
 }
 
+// Timeout returns the timeout from the exec plugin's defaults unless the test
+// spec specifies its own timeout.
 func (s *Spec) Timeout() *api.Timeout {
+	if s.Spec.Timeout != nil {
+		return nil
+	}
+	if d, ok := s.Spec.Defaults.For(pluginName).(*Defaults); ok {
+		return d.Timeout
+	}
 	return nil
 }
@@ -0,0 +1,186 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+var (
+	// DefaultSpoolThreshold is the number of bytes of a command's stdout or
+	// stderr that are held in memory before the output is spooled to a
+	// temporary file. Override per scenario with the `spool-threshold` field
+	// in the exec plugin's defaults.
+	DefaultSpoolThreshold int64 = 16 << 20
+)
+
+const (
+	// spoolChunkSize is the size of the chunks read from a spool's temporary
+	// file when searching it.
+	spoolChunkSize = 64 << 10
+)
+
+// Spool is an io.Writer that holds written content in memory until the
+// content exceeds a threshold, after which all content is written to a
+// temporary file. This allows commands that write hundreds of megabytes to
+// stdout or stderr to be tested without exhausting memory.
+//
+// Close must be called to remove any temporary file.
+type Spool struct {
+	threshold int64
+	buf       bytes.Buffer
+	file      *os.File
+	size      int64
+}
+
+// NewSpool returns a Spool that spools content to a temporary file once the
+// content exceeds the supplied number of bytes. A threshold of zero or less
+// means content is always held in memory.
+func NewSpool(threshold int64) *Spool {
+	return &Spool{threshold: threshold}
+}
+
+// Write implements io.Writer for Spool.
+func (s *Spool) Write(p []byte) (int, error) {
+	if s.file == nil && s.threshold > 0 &&
+		int64(s.buf.Len()+len(p)) > s.threshold {
+		if err := s.overflow(); err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+// overflow moves the in-memory content to a new temporary file.
+func (s *Spool) overflow() error {
+	f, err := os.CreateTemp("", "gdt-exec-*")
+	if err != nil {
+		return err
+	}
+	if _, err := s.buf.WriteTo(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	s.buf = bytes.Buffer{}
+	s.file = f
+	return nil
+}
+
+// Len returns the number of bytes written to the Spool.
+func (s *Spool) Len() int64 {
+	return s.size
+}
+
+// Spooled returns true if the Spool's content has been written to a temporary
+// file.
+func (s *Spool) Spooled() bool {
+	return s.file != nil
+}
+
+// String returns the content written to the Spool. For spooled content, this
+// reads the entire temporary file into memory, so prefer Contains when only
+// searching the content.
+func (s *Spool) String() string {
+	if s.file == nil {
+		return s.buf.String()
+	}
+	b := &strings.Builder{}
+	_, _ = io.Copy(b, io.NewSectionReader(s.file, 0, s.size))
+	return b.String()
+}
+
+// Contains returns true if the content written to the Spool, with leading and
+// trailing white space removed, contains the supplied string. Spooled content
+// is searched without reading the entire temporary file into memory.
+func (s *Spool) Contains(find string) bool {
+	if s.file == nil {
+		return strings.Contains(strings.TrimSpace(s.buf.String()), find)
+	}
+	if find == "" {
+		return true
+	}
+	start, end := s.trimmedBounds()
+	sub := []byte(find)
+	r := io.NewSectionReader(s.file, start, end-start)
+	chunk := make([]byte, max(spoolChunkSize, 2*len(sub)))
+	// carry is the number of bytes at the end of the previous chunk that are
+	// kept so that a match spanning two chunks is found.
+	carry := 0
+	for {
+		n, err := r.Read(chunk[carry:])
+		window := chunk[:carry+n]
+		if bytes.Contains(window, sub) {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+		carry = min(len(sub)-1, len(window))
+		copy(chunk, window[len(window)-carry:])
+	}
+}
+
+// trimmedBounds returns the offsets in the temporary file of the start and
+// end of the spooled content with leading and trailing white space removed,
+// as strings.TrimSpace would.
+func (s *Spool) trimmedBounds() (int64, int64) {
+	chunk := make([]byte, spoolChunkSize)
+	start := int64(0)
+	for start < s.size {
+		n, _ := s.file.ReadAt(chunk[:min(int64(len(chunk)), s.size-start)], start)
+		if n == 0 {
+			break
+		}
+		trimmed := bytes.TrimLeftFunc(chunk[:n], unicode.IsSpace)
+		start += int64(n - len(trimmed))
+		if len(trimmed) > 0 {
+			break
+		}
+	}
+	end := s.size
+	for end > start {
+		off := max(start, end-int64(len(chunk)))
+		n, _ := s.file.ReadAt(chunk[:end-off], off)
+		if n == 0 {
+			break
+		}
+		end = off + int64(len(bytes.TrimRightFunc(chunk[:n], unicode.IsSpace)))
+		if end > off {
+			break
+		}
+	}
+	return start, end
+}
+
+// Reset discards the content written to the Spool and removes any temporary
+// file.
+func (s *Spool) Reset() {
+	s.buf.Reset()
+	s.size = 0
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
+
+// Close implements io.Closer for Spool and removes any temporary file.
+func (s *Spool) Close() error {
+	s.Reset()
+	return nil
+}
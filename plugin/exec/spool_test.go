@@ -0,0 +1,90 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gdtexec "github.com/gdt-dev/core/plugin/exec"
+)
+
+func TestSpoolInMemory(t *testing.T) {
+	assert := assert.New(t)
+
+	s := gdtexec.NewSpool(1024)
+	defer s.Close()
+
+	_, err := s.Write([]byte("the quick brown fox"))
+	assert.Nil(err)
+	assert.False(s.Spooled())
+	assert.Equal(int64(19), s.Len())
+	assert.Equal("the quick brown fox", s.String())
+	assert.True(s.Contains("brown"))
+	assert.False(s.Contains("dog"))
+}
+
+func TestSpoolContainsTrimsSpace(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Content is searched with leading and trailing white space removed,
+	// whether it is held in memory or spooled.
+	for _, threshold := range []int64{0, 8} {
+		s := gdtexec.NewSpool(threshold)
+		defer s.Close()
+
+		_, err := s.Write([]byte("\n  the quick brown fox\n\n"))
+		require.Nil(err)
+		assert.Equal(threshold > 0, s.Spooled())
+		assert.True(s.Contains("the quick"))
+		assert.True(s.Contains("fox"))
+		assert.False(s.Contains("fox\n"))
+		assert.False(s.Contains(" the"))
+		assert.False(s.Contains("\n"))
+	}
+
+	// White space that spans more than one chunk of the spooled file.
+	s := gdtexec.NewSpool(8)
+	defer s.Close()
+	pad := strings.Repeat(" ", 70<<10)
+	_, err := s.Write([]byte(pad + "fox" + pad))
+	require.Nil(err)
+	assert.True(s.Contains("fox"))
+	assert.False(s.Contains(" fox"))
+	assert.False(s.Contains("fox "))
+}
+
+func TestSpoolOverflow(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s := gdtexec.NewSpool(8)
+	defer s.Close()
+
+	_, err := s.Write([]byte("the quick "))
+	require.Nil(err)
+	assert.True(s.Spooled())
+
+	// Write enough content that the spooled file is searched in more than
+	// one chunk and put the needle across a chunk boundary.
+	filler := strings.Repeat("x", 64<<10-len("the quick ")-2)
+	_, err = s.Write([]byte(filler + "brown fox"))
+	require.Nil(err)
+	assert.Equal(int64(64<<10+7), s.Len())
+	assert.True(s.Contains("the quick"))
+	assert.True(s.Contains("xbrown"))
+	assert.True(s.Contains("fox"))
+	assert.False(s.Contains("dog"))
+	assert.True(strings.HasSuffix(s.String(), "brown fox"))
+
+	s.Reset()
+	assert.False(s.Spooled())
+	assert.Equal(int64(0), s.Len())
+	assert.Equal("", s.String())
+}
@@ -0,0 +1,3 @@
+# A script with quoting that would be awkward to inline in YAML.
+name="cat"
+echo "hello from the \"$name\" script"
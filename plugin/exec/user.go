@@ -0,0 +1,80 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package exec
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
+)
+
+// ExecInvalidUser returns a parse error describing why an exec spec's user or
+// group field is invalid.
+func ExecInvalidUser(msg string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeExecInvalidUser,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid user or group: " + msg,
+	}
+}
+
+// lookupUser returns the user ID, primary group ID and supplementary group IDs
+// of the supplied user name or numeric user ID. A numeric user ID that is not
+// in the user database is returned with no group IDs.
+func lookupUser(name string) (uint32, *uint32, []uint32, error) {
+	var u *user.User
+	var err error
+	if _, perr := strconv.ParseUint(name, 10, 32); perr == nil {
+		u, err = user.LookupId(name)
+		if err != nil {
+			uid, _ := strconv.ParseUint(name, 10, 32)
+			return uint32(uid), nil, nil, nil
+		}
+	} else {
+		u, err = user.Lookup(name)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("unknown user %q", name)
+		}
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("user %q has non-numeric ID %q", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("user %q has non-numeric group ID %q", name, u.Gid)
+	}
+	primary := uint32(gid)
+	groups := []uint32{}
+	groupIDs, _ := u.GroupIds()
+	for _, id := range groupIDs {
+		if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+			groups = append(groups, uint32(g))
+		}
+	}
+	return uint32(uid), &primary, groups, nil
+}
+
+// lookupGroup returns the group ID of the supplied group name or numeric
+// group ID. A numeric group ID need not be in the group database.
+func lookupGroup(name string) (uint32, error) {
+	if gid, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(gid), nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %q", name)
+	}
+	gid, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("group %q has non-numeric ID %q", name, g.Gid)
+	}
+	return uint32(gid), nil
+}
@@ -0,0 +1,23 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+//go:build !unix
+
+package exec
+
+import (
+	"context"
+	"os/exec"
+)
+
+// userSupported indicates that commands can be run as a different user or
+// group on this platform.
+const userSupported = false
+
+// setCredential does nothing on platforms that do not support running a
+// command as a different user or group. Parsing rejects the user and group
+// fields on these platforms.
+func (a *Action) setCredential(context.Context, *exec.Cmd) error {
+	return nil
+}
@@ -0,0 +1,61 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+//go:build unix
+
+package exec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/gdt-dev/core/debug"
+)
+
+// userSupported indicates that commands can be run as a different user or
+// group on this platform.
+const userSupported = true
+
+// setCredential sets the user and group that the supplied command runs as
+// from the Action's User and Group. Running a command as a different user or
+// group requires the gdt process to have the privileges to do so, which
+// usually means running as root.
+func (a *Action) setCredential(ctx context.Context, cmd *exec.Cmd) error {
+	if a.User == "" && a.Group == "" {
+		return nil
+	}
+	cred := &syscall.Credential{
+		Uid: uint32(os.Getuid()),
+		Gid: uint32(os.Getgid()),
+	}
+	if a.User != "" {
+		uid, gid, groups, err := lookupUser(a.User)
+		if err != nil {
+			return err
+		}
+		cred.Uid = uid
+		if gid != nil {
+			cred.Gid = *gid
+		}
+		cred.Groups = groups
+	} else {
+		// Only the group is changing, so keep the supplementary groups.
+		cred.NoSetGroups = true
+	}
+	if a.Group != "" {
+		gid, err := lookupGroup(a.Group)
+		if err != nil {
+			return err
+		}
+		cred.Gid = gid
+	}
+	debug.Printf(ctx, "exec: uid: %d gid: %d", cred.Uid, cred.Gid)
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+	return nil
+}
@@ -5,13 +5,19 @@
 package exec
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
 	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
 )
 
 const (
@@ -27,6 +33,93 @@ type VarEntry struct {
 	// returncode value. All other strings indicate the value of the variable
 	// should be sourced from an envvar of the same name.
 	From string `yaml:"from"`
+	// Match is an optional regular expression used to extract the value of
+	// the variable from the value sourced with From. The value of the
+	// variable is the first named capture group in Match, or the first
+	// capture group if Match has no named groups, or the entire match if
+	// Match has no groups. Match cannot be used with `returncode`.
+	Match string `yaml:"match,omitempty"`
+	// matchRe is the compiled Match regular expression.
+	matchRe *regexp.Regexp
+}
+
+var (
+	// ErrVarNoMatch is an api.ErrFailure when a variable's Match regular
+	// expression does not match the variable's source.
+	ErrVarNoMatch = fmt.Errorf("%w: variable did not match", api.ErrFailure)
+)
+
+// VarNoMatch returns an ErrVarNoMatch for the supplied variable.
+func VarNoMatch(name string, entry VarEntry) error {
+	return fmt.Errorf(
+		"%w: %s: %q does not match %s",
+		ErrVarNoMatch, name, entry.Match, entry.From,
+	)
+}
+
+// ExecInvalidVar returns a parse error describing why a variable definition
+// is invalid.
+func ExecInvalidVar(msg string, node *yaml.Node) error {
+	return &parse.Error{
+		Code:    CodeExecInvalidVar,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "invalid variable: " + msg,
+	}
+}
+
+func (e *VarEntry) UnmarshalYAML(node *yaml.Node) error {
+	var matchNode *yaml.Node
+	err := pluginutil.DecodeFields(node, pluginutil.Fields{
+		"from": pluginutil.String(&e.From),
+		"match": func(valNode *yaml.Node) error {
+			matchNode = valNode
+			return pluginutil.String(&e.Match)(valNode)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if e.From == "" {
+		return ExecInvalidVar("from is required", node)
+	}
+	if e.Match == "" {
+		return nil
+	}
+	if e.From == varFromRC {
+		return ExecInvalidVar(
+			"match cannot be used with from: returncode", matchNode,
+		)
+	}
+	re, err := regexp.Compile(e.Match)
+	if err != nil {
+		return parse.InvalidRegexAt(matchNode, e.Match, err)
+	}
+	e.matchRe = re
+	return nil
+}
+
+// extract returns the value of the variable from the supplied source value
+// and false if the variable's Match does not match the source value.
+func (e VarEntry) extract(val string) (string, bool) {
+	if e.matchRe == nil {
+		return val, true
+	}
+	m := e.matchRe.FindStringSubmatch(val)
+	if m == nil {
+		return "", false
+	}
+	group := 0
+	if e.matchRe.NumSubexp() > 0 {
+		group = 1
+		for x, name := range e.matchRe.SubexpNames() {
+			if name != "" {
+				group = x
+				break
+			}
+		}
+	}
+	return m[group], true
 }
 
 // Variables allows the test author to save arbitrary data to the test scenario,
@@ -36,29 +129,46 @@ type Variables map[string]VarEntry
 
 // saveVars examines the supplied Variables and what we got back from the
 // Action.Do() call and sets any variables in the run data context key.
+// Returns an ErrVarNoMatch for a variable whose Match does not match.
 func saveVars(
 	ctx context.Context,
 	vars Variables,
-	outbuf *bytes.Buffer,
-	errbuf *bytes.Buffer,
+	outbuf *Spool,
+	errbuf *Spool,
 	ec int,
 	res *api.Result,
-) {
+) error {
 	for varName, entry := range vars {
+		var val string
 		switch entry.From {
 		case varFromStdout:
 			debug.Printf(ctx, "save.vars: %s -> <stdout>", varName)
-			res.SetData(varName, strings.TrimSpace(outbuf.String()))
+			val = strings.TrimSpace(outbuf.String())
 		case varFromStderr:
 			debug.Printf(ctx, "save.vars: %s -> <stderr>", varName)
-			res.SetData(varName, strings.TrimSpace(errbuf.String()))
+			val = strings.TrimSpace(errbuf.String())
 		case varFromRC:
 			debug.Printf(ctx, "save.vars: %s -> <returncode>", varName)
 			res.SetData(varName, ec)
+			continue
 		default:
-			extracted := os.Getenv(entry.From)
-			debug.Printf(ctx, "save.vars: %s -> %s", varName, extracted)
-			res.SetData(varName, extracted)
+			var found bool
+			if val, found = gdtcontext.Env(ctx)[entry.From]; !found {
+				val = os.Getenv(entry.From)
+			}
+			debug.Printf(ctx, "save.vars: %s -> %s", varName, val)
+		}
+		extracted, ok := entry.extract(val)
+		if !ok {
+			return VarNoMatch(varName, entry)
+		}
+		if entry.Match != "" {
+			debug.Printf(
+				ctx, "save.vars: %s: matched %q -> %s",
+				varName, entry.Match, extracted,
+			)
 		}
+		res.SetData(varName, extracted)
 	}
+	return nil
 }
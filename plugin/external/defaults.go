@@ -0,0 +1,61 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/parse"
+)
+
+// Defaults stores an external plugin's section of a scenario's defaults. The
+// external plugin binary is responsible for interpreting the contents.
+type Defaults struct {
+	name string
+	vals map[string]any
+}
+
+// Merge merges the supplies map of key/value combinations with the set of
+// handled defaults for the plugin. The supplied key/value map will NOT be
+// unpacked from its top-most plugin named element.
+func (d *Defaults) Merge(vals map[string]any) {
+	if inner, ok := vals[d.name].(map[string]any); ok {
+		d.vals = lo.Assign(inner, d.vals)
+	}
+}
+
+// Map returns the external plugin's defaults.
+func (d *Defaults) Map() map[string]any {
+	return d.vals
+}
+
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		if key != d.name {
+			continue
+		}
+		if valNode.Kind != yaml.MappingNode {
+			return parse.ExpectedMapAt(valNode)
+		}
+		vals := map[string]any{}
+		if err := valNode.Decode(&vals); err != nil {
+			return err
+		}
+		d.vals = vals
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// ExecutablePrefix is the filename prefix of external plugin executables
+	// that are discovered by Discover.
+	ExecutablePrefix = "gdt-plugin-"
+	// PluginDirEnv is the name of the environment variable containing a
+	// list of directories, separated by the OS path list separator, that
+	// Discover searches for external plugin executables instead of PATH.
+	PluginDirEnv = "GDT_PLUGIN_DIR"
+)
+
+// Find returns the paths of all external plugin executables in the supplied
+// directories. An external plugin executable is an executable file whose name
+// starts with "gdt-plugin-".
+//
+// If no directories are supplied, the directories listed in the
+// GDT_PLUGIN_DIR environment variable are searched. If GDT_PLUGIN_DIR is not
+// set, the directories listed in the PATH environment variable are searched.
+//
+// As with PATH lookups, when more than one directory contains an executable
+// with the same name, only the first one found is returned.
+func Find(dirs ...string) []string {
+	if len(dirs) == 0 {
+		dirs = searchDirs()
+	}
+	seen := map[string]bool{}
+	res := []string{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			// Like exec.LookPath, ignore directories in PATH that don't
+			// exist or that we can't read.
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, ExecutablePrefix) || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			res = append(res, path)
+		}
+	}
+	return res
+}
+
+// Discover finds all external plugin executables using Find, launches them
+// and registers them with gdt's set of known plugins. The supplied Options
+// are applied to every launched plugin.
+//
+// Discover returns the plugins that were successfully registered. Callers
+// should call Close on each returned plugin when they no longer need it. If
+// any plugin executable could not be launched, Discover still registers the
+// remaining plugins and returns an error describing each failure.
+func Discover(
+	ctx context.Context,
+	dirs []string,
+	opts ...Option,
+) ([]*Plugin, error) {
+	plugins := []*Plugin{}
+	errs := []error{}
+	for _, path := range Find(dirs...) {
+		p, err := Register(ctx, path, opts...)
+		if err != nil {
+			errs = append(errs, DiscoverFailed(path, err))
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, errors.Join(errs...)
+}
+
+// searchDirs returns the list of directories to search for external plugin
+// executables.
+func searchDirs() []string {
+	if dirs := os.Getenv(PluginDirEnv); dirs != "" {
+		return filepath.SplitList(dirs)
+	}
+	return filepath.SplitList(os.Getenv("PATH"))
+}
+
+// isExecutable returns true if the supplied path is an executable file.
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return fi.Mode().Perm()&0o111 != 0
+}
@@ -0,0 +1,88 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/plugin"
+	"github.com/gdt-dev/core/plugin/external"
+)
+
+func TestFind(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping executable bit checks on windows")
+	}
+	require := require.New(t)
+
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	touch := func(dir, name string, mode os.FileMode) {
+		fp := filepath.Join(dir, name)
+		require.Nil(os.WriteFile(fp, []byte("#!/bin/sh\n"), mode))
+	}
+	touch(dirA, "gdt-plugin-a", 0o755)
+	touch(dirA, "gdt-plugin-noexec", 0o644)
+	touch(dirA, "not-a-plugin", 0o755)
+	touch(dirB, "gdt-plugin-a", 0o755)
+	touch(dirB, "gdt-plugin-b", 0o755)
+	require.Nil(os.Mkdir(filepath.Join(dirB, "gdt-plugin-dir"), 0o755))
+
+	found := external.Find(dirA, dirB)
+	require.Equal(
+		[]string{
+			filepath.Join(dirA, "gdt-plugin-a"),
+			filepath.Join(dirB, "gdt-plugin-b"),
+		},
+		found,
+	)
+
+	t.Setenv(external.PluginDirEnv, dirB)
+	found = external.Find()
+	require.Equal(
+		[]string{
+			filepath.Join(dirB, "gdt-plugin-a"),
+			filepath.Join(dirB, "gdt-plugin-b"),
+		},
+		found,
+	)
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping symlinked executable on windows")
+	}
+	require := require.New(t)
+
+	// Make sure we restore the plugin registered in TestMain after the
+	// discovered plugin replaces it in the registry.
+	defer plugin.Register(echoRef)
+
+	dir := t.TempDir()
+	require.Nil(os.Symlink(os.Args[0], filepath.Join(dir, "gdt-plugin-echo")))
+
+	plugins, err := external.Discover(
+		context.TODO(), []string{dir},
+		external.WithEnv(serveEnv+"=1"),
+	)
+	require.Nil(err)
+	require.Len(plugins, 1)
+	defer plugins[0].Close()
+	require.Equal("echo", plugins[0].Info().Name)
+
+	registered := false
+	for _, p := range plugin.Registered() {
+		if p == plugins[0] {
+			registered = true
+		}
+	}
+	require.True(registered)
+}
@@ -0,0 +1,143 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gdt-dev/core/api"
+)
+
+var (
+	// ErrInvalidHandshake indicates that an external plugin binary did not
+	// write a valid handshake line to stdout.
+	ErrInvalidHandshake = errors.New("invalid external plugin handshake")
+	// ErrIncompatibleProtocol indicates that an external plugin binary speaks
+	// a version of the external plugin protocol that this version of gdt does
+	// not support.
+	ErrIncompatibleProtocol = errors.New(
+		"incompatible external plugin protocol version",
+	)
+	// ErrStartTimeout indicates that an external plugin binary did not write
+	// its handshake line before the start timeout elapsed.
+	ErrStartTimeout = errors.New("timed out waiting for external plugin")
+	// ErrNotLaunchedByGDT is returned from Serve when the process was not
+	// launched by gdt.
+	ErrNotLaunchedByGDT = errors.New(
+		"external plugins are meant to be launched by gdt, not run directly",
+	)
+	// ErrNoSpecs is returned from Serve when the supplied plugin does not
+	// return any Specs.
+	ErrNoSpecs = errors.New("plugin does not return any specs")
+)
+
+// InvalidHandshake returns an ErrInvalidHandshake describing the supplied
+// handshake line.
+func InvalidHandshake(line string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidHandshake, line)
+}
+
+// IncompatibleProtocol returns an ErrIncompatibleProtocol describing the
+// supplied protocol version.
+func IncompatibleProtocol(version int) error {
+	supported := make([]string, len(SupportedProtocolVersions))
+	for x, v := range SupportedProtocolVersions {
+		supported[x] = fmt.Sprintf("%d", v)
+	}
+	return fmt.Errorf(
+		"%w: plugin speaks version %d, gdt supports version(s) %s",
+		ErrIncompatibleProtocol, version, strings.Join(supported, ","),
+	)
+}
+
+// StartTimeout returns an ErrStartTimeout describing the supplied plugin
+// path.
+func StartTimeout(path string) error {
+	return fmt.Errorf("%w: %s", ErrStartTimeout, path)
+}
+
+// DiscoverFailed returns an error describing why a discovered external plugin
+// executable could not be launched.
+func DiscoverFailed(path string, err error) error {
+	return fmt.Errorf("failed to launch external plugin %s: %w", path, err)
+}
+
+// PluginRuntimeError returns a RuntimeError describing an error that occurred
+// communicating with an external plugin.
+func PluginRuntimeError(name string, err error) error {
+	return fmt.Errorf(
+		"%w: external plugin %q: %s", api.RuntimeError, name, err,
+	)
+}
+
+// remoteError is an error that was returned from an external plugin. It
+// preserves the error message and error code produced by the plugin and
+// matches the base error class it was transmitted as, either api.ErrFailure
+// or api.RuntimeError, with errors.Is.
+type remoteError struct {
+	code string
+	msg  string
+	base error
+}
+
+// Error implements the error interface for remoteError.
+func (e *remoteError) Error() string {
+	return e.msg
+}
+
+// Is returns true if the supplied target is the remoteError's base error
+// class.
+func (e *remoteError) Is(target error) bool {
+	return target == e.base
+}
+
+// ErrorCode returns the machine-readable error code returned by the plugin.
+func (e *remoteError) ErrorCode() string {
+	return e.code
+}
+
+// newRemoteError returns a remoteError from the wire representation of an
+// error, or an *api.Failure whose class is a remoteError if the error carries
+// structured failure detail.
+func newRemoteError(msg errorMessage, base error) error {
+	if f := msg.Failure; f != nil {
+		return &api.Failure{
+			Code:     msg.Code,
+			Class:    &remoteError{msg: f.Class, base: base},
+			Message:  f.Message,
+			Expected: f.Expected,
+			Actual:   f.Actual,
+			Diff:     f.Diff,
+			Path:     f.Path,
+		}
+	}
+	return &remoteError{
+		code: msg.Code,
+		msg:  msg.Message,
+		base: base,
+	}
+}
+
+// toErrorMessage returns the wire representation of the supplied error.
+func toErrorMessage(err error) errorMessage {
+	msg := errorMessage{
+		Code:    api.ErrorCode(err),
+		Message: err.Error(),
+	}
+	if f, ok := api.AsFailure(err); ok {
+		fm := &failureMessage{
+			Class:   f.Unwrap().Error(),
+			Message: f.Message,
+			Diff:    f.Diff,
+			Path:    f.Path,
+		}
+		fm.Expected, _ = jsonSafeValue(f.Expected)
+		fm.Actual, _ = jsonSafeValue(f.Actual)
+		msg.Failure = fm
+	}
+	return msg
+}
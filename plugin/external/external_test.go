@@ -0,0 +1,351 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+	_ "github.com/gdt-dev/core/plugin/exec"
+	"github.com/gdt-dev/core/plugin/external"
+	"github.com/gdt-dev/core/run"
+	"github.com/gdt-dev/core/scenario"
+)
+
+// serveEnv is set when the test binary is re-executed as an external plugin
+// binary.
+const serveEnv = "GDT_EXTERNAL_TEST_SERVE"
+
+var echoRef *external.Plugin
+
+func TestMain(m *testing.M) {
+	if os.Getenv(serveEnv) != "" {
+		if err := external.Serve(&echoPlugin{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	p, err := external.Register(
+		context.TODO(), os.Args[0],
+		external.WithEnv(serveEnv+"=1"),
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	echoRef = p
+	code := m.Run()
+	_ = p.Close()
+	os.Exit(code)
+}
+
+func TestInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	info := echoRef.Info()
+	assert.Equal("echo", info.Name)
+	assert.Equal("echoes a string", info.Description)
+	require.NotNil(t, info.Timeout)
+	assert.Equal("2s", info.Timeout.After)
+	require.Len(t, info.Fields, 2)
+	assert.Equal("echo", info.Fields[0].Name)
+	assert.True(info.Fields[0].Required)
+	assert.Equal("the expected echoed string", info.Fields[1].Description)
+}
+
+func TestEval(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "echo.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+	require.Len(s.Tests, 2)
+
+	var b bytes.Buffer
+	ctx := gdtcontext.New(gdtcontext.WithDebug(&b))
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	require.Contains(b.String(), "echoing \"hello hello world\"")
+}
+
+func TestMeasurements(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "echo.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+	require.True(r.OK())
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	require.Equal(
+		[]api.Measurement{{Name: "echo.length", Value: 11, Unit: "bytes"}},
+		results[0].Measurements(),
+	)
+
+	summaries := r.ScenarioMeasurements(fp)
+	require.Len(summaries, 1)
+	require.Equal("echo.length", summaries[0].Name)
+	require.Equal("bytes", summaries[0].Unit)
+	require.Equal(2, summaries[0].Count)
+	require.Equal(11.0, summaries[0].Min)
+	require.Equal(17.0, summaries[0].Max)
+	require.Equal(14.0, summaries[0].Mean())
+	require.Equal(summaries, r.Measurements())
+}
+
+func TestMixedPlugins(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "echo-mixed.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+	require.Len(s.Tests, 2)
+	require.Equal("exec", s.Tests[0].Base().Plugin.Info().Name)
+	require.Equal("echo", s.Tests[1].Base().Plugin.Info().Name)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestFailure(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "echo-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+	require.False(r.OK())
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	fails := results[0].Failures()
+	require.Len(fails, 1)
+	require.ErrorIs(fails[0], api.ErrFailure)
+	require.EqualError(
+		fails[0], "assertion failed: not equal: expected mars but got world",
+	)
+	fail, ok := api.AsFailure(fails[0])
+	require.True(ok)
+	require.Equal("mars", fail.Expected)
+	require.Equal("world", fail.Actual)
+
+	artifact, ok := results[0].Artifact("echo.out")
+	require.True(ok)
+	require.Equal("text/plain", artifact.ContentType)
+	require.Equal("world", string(artifact.Content))
+}
+
+func TestParseError(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "echo-parse-error.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	_, err = scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+
+	var perr *parse.Error
+	require.True(errors.As(err, &perr))
+	require.Equal(parse.CodeExpectedScalar, perr.ErrorCode())
+	require.Equal(6, perr.Line)
+}
+
+func TestStartInvalidHandshake(t *testing.T) {
+	require := require.New(t)
+
+	path, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo not found in PATH")
+	}
+	_, err = external.Start(
+		context.TODO(), path,
+		external.WithArgs("not a handshake"),
+	)
+	require.ErrorIs(err, external.ErrInvalidHandshake)
+}
+
+func TestServeNotLaunchedByGDT(t *testing.T) {
+	require := require.New(t)
+
+	err := external.Serve(&echoPlugin{})
+	require.ErrorIs(err, external.ErrNotLaunchedByGDT)
+}
+
+type echoDefaults struct {
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+type echoDefaultsHandler struct {
+	echoDefaults
+}
+
+func (d *echoDefaultsHandler) Merge(map[string]any) {}
+
+func (d *echoDefaultsHandler) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		valNode := node.Content[i+1]
+		if keyNode.Value != "echo" {
+			continue
+		}
+		if valNode.Kind != yaml.MappingNode {
+			return parse.ExpectedMapAt(valNode)
+		}
+		return valNode.Decode(&d.echoDefaults)
+	}
+	return nil
+}
+
+type echoSpec struct {
+	api.Spec
+	Echo   string
+	Equals string
+}
+
+func (s *echoSpec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *echoSpec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *echoSpec) Retry() *api.Retry {
+	return nil
+}
+
+func (s *echoSpec) Timeout() *api.Timeout {
+	return nil
+}
+
+func (s *echoSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		key := keyNode.Value
+		valNode := node.Content[i+1]
+		switch key {
+		case "echo":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Echo = valNode.Value
+		case "equals":
+			if valNode.Kind != yaml.ScalarNode {
+				return parse.ExpectedScalarAt(valNode)
+			}
+			s.Equals = valNode.Value
+		default:
+			if lo.Contains(api.BaseSpecFields, key) {
+				continue
+			}
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+	}
+	return nil
+}
+
+func (s *echoSpec) Eval(ctx context.Context) (*api.Result, error) {
+	out := gdtcontext.ReplaceVariables(ctx, s.Echo)
+	if d, ok := s.Defaults.For("echo").(*echoDefaultsHandler); ok {
+		out = d.Prefix + out
+	}
+	debug.Printf(ctx, "echoing %q", out)
+	if out != s.Equals {
+		return api.NewResult(
+			api.WithFailures(api.NotEqual(s.Equals, out)),
+			api.WithAttachment("echo.out", "text/plain", []byte(out)),
+		), nil
+	}
+	return api.NewResult(
+		api.WithData("echo", out),
+		api.WithMetric("echo.length", float64(len(out)), "bytes"),
+	), nil
+}
+
+type echoPlugin struct{}
+
+func (p *echoPlugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name:        "echo",
+		Description: "echoes a string",
+		Timeout: &api.Timeout{
+			After: "2s",
+		},
+		Fields: []api.FieldDoc{
+			{
+				Name:        "echo",
+				Type:        "string",
+				Description: "the string to echo",
+				Required:    true,
+			},
+			{
+				Name:        "equals",
+				Type:        "string",
+				Description: "the expected echoed string",
+			},
+		},
+	}
+}
+
+func (p *echoPlugin) Defaults() api.DefaultsHandler {
+	return &echoDefaultsHandler{}
+}
+
+func (p *echoPlugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&echoSpec{}}
+}
@@ -0,0 +1,282 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/gdt-dev/core/api"
+	gdtplugin "github.com/gdt-dev/core/plugin"
+)
+
+const (
+	// DefaultStartTimeout is the amount of time gdt waits for an external
+	// plugin binary to write its handshake line after being launched.
+	DefaultStartTimeout = 10 * time.Second
+)
+
+// Option modifies how an external plugin binary is launched.
+type Option func(*Plugin)
+
+// WithArgs sets the command line arguments passed to the external plugin
+// binary.
+func WithArgs(args ...string) Option {
+	return func(p *Plugin) {
+		p.args = args
+	}
+}
+
+// WithEnv appends the supplied "KEY=value" environment variables to the
+// environment of the external plugin binary. The external plugin binary
+// always inherits the environment of the gdt process.
+func WithEnv(env ...string) Option {
+	return func(p *Plugin) {
+		p.env = append(p.env, env...)
+	}
+}
+
+// WithStderr sets the writer that the external plugin binary's stderr is
+// copied to. By default, the external plugin binary's stderr is copied to the
+// gdt process' stderr.
+func WithStderr(w io.Writer) Option {
+	return func(p *Plugin) {
+		p.stderr = w
+	}
+}
+
+// WithStartTimeout sets the amount of time to wait for the external plugin
+// binary to write its handshake line after being launched.
+func WithStartTimeout(d time.Duration) Option {
+	return func(p *Plugin) {
+		p.startTimeout = d
+	}
+}
+
+// Plugin is an api.Plugin that proxies calls to an external plugin binary
+// speaking the gdt external plugin protocol.
+type Plugin struct {
+	path         string
+	args         []string
+	env          []string
+	stderr       io.Writer
+	startTimeout time.Duration
+	cmd          *exec.Cmd
+	conn         *grpc.ClientConn
+	info         api.PluginInfo
+	closeOnce    sync.Once
+}
+
+// Info returns a struct that describes what the plugin does
+func (p *Plugin) Info() api.PluginInfo {
+	return p.info
+}
+
+// Defaults returns a DefaultsHandler that stores the plugin's section of the
+// scenario's defaults so that it can be sent to the external plugin binary.
+func (p *Plugin) Defaults() api.DefaultsHandler {
+	return &Defaults{name: p.info.Name}
+}
+
+// Specs returns the Spec proxy for the external plugin.
+func (p *Plugin) Specs() []api.Evaluable {
+	return []api.Evaluable{&Spec{plugin: p}}
+}
+
+// Close closes the connection to the external plugin binary and waits for
+// the binary to exit.
+func (p *Plugin) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		if p.conn != nil {
+			err = p.conn.Close()
+		}
+		if p.cmd != nil && p.cmd.Process != nil {
+			// The plugin protocol is stateless, so there is nothing for the
+			// binary to flush and we can simply kill it.
+			_ = p.cmd.Process.Kill()
+			_ = p.cmd.Wait()
+		}
+	})
+	return err
+}
+
+// invoke calls the supplied method on the external plugin binary.
+func (p *Plugin) invoke(
+	ctx context.Context,
+	method string,
+	req any,
+	resp any,
+) error {
+	in, err := toStruct(req)
+	if err != nil {
+		return err
+	}
+	out := &structpb.Struct{}
+	if err := p.conn.Invoke(ctx, methodPath(method), in, out); err != nil {
+		return err
+	}
+	return fromStruct(out, resp)
+}
+
+// loadInfo calls the Info method on the external plugin binary and caches
+// the returned PluginInfo.
+func (p *Plugin) loadInfo(ctx context.Context) error {
+	out := &structpb.Struct{}
+	err := p.conn.Invoke(ctx, methodPath("Info"), &emptypb.Empty{}, out)
+	if err != nil {
+		return err
+	}
+	msg := infoMessage{}
+	if err := fromStruct(out, &msg); err != nil {
+		return err
+	}
+	if msg.ProtocolVersion != ProtocolVersion {
+		return IncompatibleProtocol(msg.ProtocolVersion)
+	}
+	info := api.PluginInfo{
+		Name:        msg.Name,
+		APIVersion:  msg.APIVersion,
+		Aliases:     msg.Aliases,
+		Description: msg.Description,
+		Fields:      fieldDocs(msg.Fields),
+		Deprecated:  deprecatedFields(msg.Deprecated),
+	}
+	if msg.Timeout != "" || msg.AttemptTimeout != "" {
+		info.Timeout = &api.Timeout{
+			After:   msg.Timeout,
+			Attempt: msg.AttemptTimeout,
+		}
+	}
+	info.Retry = msg.Retry.retry()
+	p.info = info
+	return nil
+}
+
+// Start launches the external plugin binary at the supplied path, performs
+// the protocol handshake and returns a Plugin that proxies calls to the
+// binary. Callers should call Plugin.Close when they no longer need the
+// plugin.
+func Start(
+	ctx context.Context,
+	path string,
+	opts ...Option,
+) (*Plugin, error) {
+	p := &Plugin{
+		path:         path,
+		stderr:       os.Stderr,
+		startTimeout: DefaultStartTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	supported := make([]string, len(SupportedProtocolVersions))
+	for x, v := range SupportedProtocolVersions {
+		supported[x] = fmt.Sprintf("%d", v)
+	}
+	cmd := exec.Command(path, p.args...)
+	cmd.Env = append(os.Environ(), p.env...)
+	cmd.Env = append(
+		cmd.Env,
+		MagicCookieKey+"="+MagicCookieValue,
+		ProtocolVersionsKey+"="+strings.Join(supported, ","),
+	)
+	cmd.Stderr = p.stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p.cmd = cmd
+
+	lineCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(stdout)
+		line, err := r.ReadString('\n')
+		if err != nil && line == "" {
+			errCh <- err
+			return
+		}
+		lineCh <- line
+		// Keep draining stdout so that the plugin never blocks writing to
+		// it.
+		_, _ = io.Copy(io.Discard, r)
+	}()
+
+	var line string
+	select {
+	case line = <-lineCh:
+	case err := <-errCh:
+		_ = p.Close()
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHandshake, err)
+	case <-time.After(p.startTimeout):
+		_ = p.Close()
+		return nil, StartTimeout(path)
+	case <-ctx.Done():
+		_ = p.Close()
+		return nil, ctx.Err()
+	}
+
+	hs, err := parseHandshake(line)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	if hs.protocolVersion != ProtocolVersion {
+		_ = p.Close()
+		return nil, IncompatibleProtocol(hs.protocolVersion)
+	}
+	if hs.network != "tcp" {
+		_ = p.Close()
+		return nil, InvalidHandshake(line)
+	}
+	conn, err := grpc.NewClient(
+		"passthrough:///"+hs.address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	p.conn = conn
+	if err := p.loadInfo(ctx); err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Register launches the external plugin binary at the supplied path and
+// registers it with gdt's set of known plugins.
+func Register(
+	ctx context.Context,
+	path string,
+	opts ...Option,
+) (*Plugin, error) {
+	p, err := Start(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := gdtplugin.Register(p); err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	return p, nil
+}
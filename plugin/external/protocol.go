@@ -0,0 +1,497 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/gdt-dev/core/api"
+)
+
+const (
+	// ProtocolVersion is the version of the external plugin protocol spoken
+	// by this version of gdt. It is incremented whenever a
+	// backwards-incompatible change is made to the messages or methods of the
+	// protocol.
+	ProtocolVersion = 1
+	// MagicCookieKey is the name of the environment variable that gdt sets
+	// when launching an external plugin binary. External plugins use it to
+	// determine that they have been launched by gdt and not directly by a
+	// user.
+	MagicCookieKey = "GDT_PLUGIN_MAGIC_COOKIE"
+	// MagicCookieValue is the value of the MagicCookieKey environment
+	// variable.
+	MagicCookieValue = "5c1b2ad0c4e14b4e8f0a2c7d9e6b3f1a"
+	// ProtocolVersionsKey is the name of the environment variable that gdt
+	// sets when launching an external plugin binary. It contains a
+	// comma-separated list of protocol versions that gdt supports.
+	ProtocolVersionsKey = "GDT_PLUGIN_PROTOCOL_VERSIONS"
+	// handshakePrefix is the first field of the handshake line an external
+	// plugin writes to stdout once it is ready to serve requests.
+	handshakePrefix = "gdt-plugin"
+	// handshakeProtocol is the last field of the handshake line and
+	// indicates the RPC protocol being served.
+	handshakeProtocol = "grpc"
+	// serviceName is the fully-qualified name of the gRPC service.
+	serviceName = "gdt.plugin.v1.Plugin"
+)
+
+var (
+	// SupportedProtocolVersions is the set of external plugin protocol
+	// versions that this version of gdt is able to speak.
+	SupportedProtocolVersions = []int{ProtocolVersion}
+)
+
+// handshake is the parsed handshake line written by an external plugin to its
+// stdout once it is ready to serve requests. The line has the form:
+//
+//	gdt-plugin|<protocol version>|<network>|<address>|grpc
+type handshake struct {
+	protocolVersion int
+	network         string
+	address         string
+}
+
+// String returns the handshake line for the handshake.
+func (h handshake) String() string {
+	return fmt.Sprintf(
+		"%s|%d|%s|%s|%s",
+		handshakePrefix, h.protocolVersion, h.network, h.address,
+		handshakeProtocol,
+	)
+}
+
+// parseHandshake parses the supplied handshake line.
+func parseHandshake(line string) (handshake, error) {
+	h := handshake{}
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 5 || parts[0] != handshakePrefix {
+		return h, InvalidHandshake(line)
+	}
+	if parts[4] != handshakeProtocol {
+		return h, InvalidHandshake(line)
+	}
+	v, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return h, InvalidHandshake(line)
+	}
+	h.protocolVersion = v
+	h.network = parts[2]
+	h.address = parts[3]
+	return h, nil
+}
+
+// infoMessage is returned from the Info method.
+type infoMessage struct {
+	ProtocolVersion int                 `json:"protocol_version"`
+	Name            string              `json:"name"`
+	APIVersion      int                 `json:"api_version,omitempty"`
+	Aliases         []string            `json:"aliases,omitempty"`
+	Description     string              `json:"description,omitempty"`
+	Timeout         string              `json:"timeout,omitempty"`
+	AttemptTimeout  string              `json:"attempt_timeout,omitempty"`
+	Retry           *retryMessage       `json:"retry,omitempty"`
+	Fields          []fieldMessage      `json:"fields,omitempty"`
+	Deprecated      []deprecatedMessage `json:"deprecated,omitempty"`
+}
+
+// fieldMessage documents a single field in the plugin's test spec.
+type fieldMessage struct {
+	Name        string         `json:"name"`
+	Type        string         `json:"type,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Examples    []string       `json:"examples,omitempty"`
+	Fields      []fieldMessage `json:"fields,omitempty"`
+}
+
+// newFieldMessages returns the wire representation of the supplied FieldDocs.
+func newFieldMessages(docs []api.FieldDoc) []fieldMessage {
+	if len(docs) == 0 {
+		return nil
+	}
+	res := make([]fieldMessage, len(docs))
+	for x, d := range docs {
+		res[x] = fieldMessage{
+			Name:        d.Name,
+			Type:        d.Type,
+			Description: d.Description,
+			Required:    d.Required,
+			Examples:    d.Examples,
+			Fields:      newFieldMessages(d.Fields),
+		}
+	}
+	return res
+}
+
+// fieldDocs returns the FieldDocs for the supplied wire representations.
+func fieldDocs(msgs []fieldMessage) []api.FieldDoc {
+	if len(msgs) == 0 {
+		return nil
+	}
+	res := make([]api.FieldDoc, len(msgs))
+	for x, m := range msgs {
+		res[x] = api.FieldDoc{
+			Name:        m.Name,
+			Type:        m.Type,
+			Description: m.Description,
+			Required:    m.Required,
+			Examples:    m.Examples,
+			Fields:      fieldDocs(m.Fields),
+		}
+	}
+	return res
+}
+
+// deprecatedMessage describes a deprecated field in the plugin's test spec.
+type deprecatedMessage struct {
+	Name        string `json:"name"`
+	Replacement string `json:"replacement,omitempty"`
+	RemovedIn   string `json:"removed_in,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// newDeprecatedMessages returns the wire representation of the supplied
+// DeprecatedFields.
+func newDeprecatedMessages(fields []api.DeprecatedField) []deprecatedMessage {
+	if len(fields) == 0 {
+		return nil
+	}
+	res := make([]deprecatedMessage, len(fields))
+	for x, f := range fields {
+		res[x] = deprecatedMessage(f)
+	}
+	return res
+}
+
+// deprecatedFields returns the DeprecatedFields for the supplied wire
+// representations.
+func deprecatedFields(msgs []deprecatedMessage) []api.DeprecatedField {
+	if len(msgs) == 0 {
+		return nil
+	}
+	res := make([]api.DeprecatedField, len(msgs))
+	for x, m := range msgs {
+		res[x] = api.DeprecatedField(m)
+	}
+	return res
+}
+
+// retryMessage describes a plugin's default retry behaviour.
+type retryMessage struct {
+	Attempts          *int     `json:"attempts,omitempty"`
+	Interval          string   `json:"interval,omitempty"`
+	Exponential       bool     `json:"exponential,omitempty"`
+	Jitter            *float64 `json:"jitter,omitempty"`
+	Multiplier        *float64 `json:"multiplier,omitempty"`
+	MaxInterval       string   `json:"max_interval,omitempty"`
+	MaxElapsed        string   `json:"max_elapsed,omitempty"`
+	SuccessesRequired *int     `json:"successes_required,omitempty"`
+}
+
+// newRetryMessage returns the wire representation of the supplied Retry.
+func newRetryMessage(r *api.Retry) *retryMessage {
+	if r == nil {
+		return nil
+	}
+	return &retryMessage{
+		Attempts:          r.Attempts,
+		Interval:          r.Interval,
+		Exponential:       r.Exponential,
+		Jitter:            r.Jitter,
+		Multiplier:        r.Multiplier,
+		MaxInterval:       r.MaxInterval,
+		MaxElapsed:        r.MaxElapsed,
+		SuccessesRequired: r.SuccessesRequired,
+	}
+}
+
+// retry returns the api.Retry for the wire representation.
+func (m *retryMessage) retry() *api.Retry {
+	if m == nil {
+		return nil
+	}
+	return &api.Retry{
+		Attempts:          m.Attempts,
+		Interval:          m.Interval,
+		Exponential:       m.Exponential,
+		Jitter:            m.Jitter,
+		Multiplier:        m.Multiplier,
+		MaxInterval:       m.MaxInterval,
+		MaxElapsed:        m.MaxElapsed,
+		SuccessesRequired: m.SuccessesRequired,
+	}
+}
+
+// parseRequest is sent to the Parse method.
+type parseRequest struct {
+	// YAML is the YAML document for a single test spec.
+	YAML string `json:"yaml"`
+}
+
+// parseResponse is returned from the Parse method.
+type parseResponse struct {
+	// Error is set when the plugin could not parse the test spec.
+	Error *parseErrorMessage `json:"error,omitempty"`
+	// Timeout is the test spec's Timeout override, if any.
+	Timeout string `json:"timeout,omitempty"`
+	// AttemptTimeout is the test spec's per-attempt Timeout override, if
+	// any.
+	AttemptTimeout string `json:"attempt_timeout,omitempty"`
+	// Retry is the test spec's Retry override, if any.
+	Retry *retryMessage `json:"retry,omitempty"`
+}
+
+// parseErrorMessage describes why a test spec could not be parsed.
+type parseErrorMessage struct {
+	// UnknownField is true when the plugin does not recognize a field in the
+	// test spec, meaning that the test spec is likely meant for a different
+	// plugin.
+	UnknownField bool   `json:"unknown_field,omitempty"`
+	Code         string `json:"code,omitempty"`
+	Message      string `json:"message"`
+	// Line and Column are relative to the YAML document in the request.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// evalRequest is sent to the Eval method.
+type evalRequest struct {
+	// YAML is the YAML document for a single test spec that was previously
+	// successfully parsed by the plugin.
+	YAML string `json:"yaml"`
+	// Index is the index of the test spec within the scenario.
+	Index int `json:"index"`
+	// Defaults is the scenario's `defaults` map.
+	Defaults map[string]any `json:"defaults,omitempty"`
+	// Run is the scenario's run data.
+	Run map[string]any `json:"run,omitempty"`
+	// Debug is true when the plugin should return debug output.
+	Debug bool `json:"debug,omitempty"`
+}
+
+// evalResponse is returned from the Eval method.
+type evalResponse struct {
+	Failures   []errorMessage `json:"failures,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+	StopOnFail bool           `json:"stop_on_fail,omitempty"`
+	Terminal   bool           `json:"terminal,omitempty"`
+	// Error is set when Eval returned a RuntimeError.
+	Error *errorMessage `json:"error,omitempty"`
+	// Debug contains debug output lines produced during Eval.
+	Debug []string `json:"debug,omitempty"`
+	// Metrics contains counters describing the work performed during Eval.
+	Metrics *metricsMessage `json:"metrics,omitempty"`
+	// Artifacts contains the named content produced during Eval.
+	Artifacts []artifactMessage `json:"artifacts,omitempty"`
+	// Measurements contains the named values recorded during Eval.
+	Measurements []measurementMessage `json:"measurements,omitempty"`
+}
+
+// measurementMessage is the wire representation of an api.Measurement.
+type measurementMessage struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// artifactMessage is the wire representation of an api.Artifact. Content is
+// base64-encoded.
+type artifactMessage struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Content     []byte `json:"content,omitempty"`
+}
+
+// metricsMessage is the wire representation of api.Metrics.
+type metricsMessage struct {
+	Requests      int   `json:"requests,omitempty"`
+	BytesSent     int64 `json:"bytes_sent,omitempty"`
+	BytesReceived int64 `json:"bytes_received,omitempty"`
+	Retries       int   `json:"retries,omitempty"`
+}
+
+// errorMessage is the wire representation of an error.
+type errorMessage struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	// Failure is the structured detail of an assertion failure, if any.
+	Failure *failureMessage `json:"failure,omitempty"`
+}
+
+// failureMessage is the wire representation of an api.Failure.
+type failureMessage struct {
+	Class    string `json:"class"`
+	Message  string `json:"message,omitempty"`
+	Expected any    `json:"expected,omitempty"`
+	Actual   any    `json:"actual,omitempty"`
+	Diff     string `json:"diff,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// toStruct converts the supplied message into a protobuf Struct.
+func toStruct(msg any) (*structpb.Struct, error) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// fromStruct converts the supplied protobuf Struct into the supplied message.
+func fromStruct(s *structpb.Struct, msg any) error {
+	b, err := s.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, msg)
+}
+
+// jsonSafe returns a copy of the supplied map containing only the values that
+// can be represented in JSON.
+func jsonSafe(m map[string]any) map[string]any {
+	res := make(map[string]any, len(m))
+	for k, v := range m {
+		safe, ok := jsonSafeValue(v)
+		if !ok {
+			continue
+		}
+		res[k] = safe
+	}
+	return res
+}
+
+// jsonSafeValue returns the supplied value converted to its JSON equivalent
+// and true, or nil and false if it cannot be represented in JSON.
+func jsonSafeValue(v any) (any, bool) {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var safe any
+	if err := json.Unmarshal(b, &safe); err != nil {
+		return nil, false
+	}
+	return safe, true
+}
+
+// pluginServer is the server API for the gdt.plugin.v1.Plugin service.
+type pluginServer interface {
+	Info(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	Parse(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Eval(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// serviceDesc describes the gdt.plugin.v1.Plugin gRPC service. See
+// plugin.proto for the service definition.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*pluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    infoHandler,
+		},
+		{
+			MethodName: "Parse",
+			Handler:    parseHandler,
+		},
+		{
+			MethodName: "Eval",
+			Handler:    evalHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
+
+func infoHandler(
+	srv any,
+	ctx context.Context,
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(pluginServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: methodPath("Info"),
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(pluginServer).Info(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func parseHandler(
+	srv any,
+	ctx context.Context,
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(pluginServer).Parse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: methodPath("Parse"),
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(pluginServer).Parse(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func evalHandler(
+	srv any,
+	ctx context.Context,
+	dec func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(pluginServer).Eval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: methodPath("Eval"),
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(pluginServer).Eval(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// methodPath returns the full gRPC method path for the supplied method name.
+func methodPath(method string) string {
+	return "/" + serviceName + "/" + method
+}
@@ -0,0 +1,260 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/parse"
+)
+
+// Serve serves the supplied plugin over the gdt external plugin protocol.
+// Plugin authors call Serve from the main() function of a standalone plugin
+// binary:
+//
+// ```go
+//
+//	func main() {
+//	    if err := external.Serve(myplugin.Plugin()); err != nil {
+//	        fmt.Fprintln(os.Stderr, err)
+//	        os.Exit(1)
+//	    }
+//	}
+//
+// ```
+//
+// Serve blocks until the plugin binary is killed by gdt.
+//
+// The plugin protocol is stateless. Each test spec is parsed again by the
+// plugin binary before being evaluated, and only JSON-representable run data
+// is passed to and from the plugin binary. Cleanup functions registered on an
+// api.Result are not supported.
+func Serve(p api.Plugin) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return ErrNotLaunchedByGDT
+	}
+	if versions := os.Getenv(ProtocolVersionsKey); versions != "" {
+		supported := strings.Split(versions, ",")
+		if !slices.Contains(supported, strconv.Itoa(ProtocolVersion)) {
+			return IncompatibleProtocol(ProtocolVersion)
+		}
+	}
+	if len(p.Specs()) == 0 {
+		return ErrNoSpecs
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	srv.RegisterService(&serviceDesc, &server{plugin: p})
+	hs := handshake{
+		protocolVersion: ProtocolVersion,
+		network:         "tcp",
+		address:         lis.Addr().String(),
+	}
+	fmt.Fprintln(os.Stdout, hs.String())
+	return srv.Serve(lis)
+}
+
+// server implements pluginServer for an api.Plugin.
+type server struct {
+	plugin api.Plugin
+}
+
+// Info returns the wrapped plugin's PluginInfo.
+func (s *server) Info(
+	_ context.Context,
+	_ *emptypb.Empty,
+) (*structpb.Struct, error) {
+	info := s.plugin.Info()
+	msg := infoMessage{
+		ProtocolVersion: ProtocolVersion,
+		Name:            info.Name,
+		APIVersion:      info.APIVersion,
+		Aliases:         info.Aliases,
+		Description:     info.Description,
+		Retry:           newRetryMessage(info.Retry),
+		Fields:          newFieldMessages(info.Fields),
+		Deprecated:      newDeprecatedMessages(info.Deprecated),
+	}
+	if info.Timeout != nil {
+		msg.Timeout = info.Timeout.After
+		msg.AttemptTimeout = info.Timeout.Attempt
+	}
+	return toStruct(msg)
+}
+
+// Parse parses the test spec YAML in the request with the wrapped plugin's
+// Specs.
+func (s *server) Parse(
+	_ context.Context,
+	in *structpb.Struct,
+) (*structpb.Struct, error) {
+	req := parseRequest{}
+	if err := fromStruct(in, &req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp := parseResponse{}
+	sp, err := s.decode(req.YAML)
+	if err != nil {
+		resp.Error = toParseErrorMessage(err)
+		return toStruct(resp)
+	}
+	if to := sp.Timeout(); to != nil {
+		resp.Timeout = to.After
+		resp.AttemptTimeout = to.Attempt
+	}
+	resp.Retry = newRetryMessage(sp.Retry())
+	return toStruct(resp)
+}
+
+// Eval evaluates the test spec YAML in the request with the wrapped plugin's
+// Specs.
+func (s *server) Eval(
+	ctx context.Context,
+	in *structpb.Struct,
+) (*structpb.Struct, error) {
+	req := evalRequest{}
+	if err := fromStruct(in, &req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sp, err := s.decode(req.YAML)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	base := api.Spec{}
+	if err := yaml.Unmarshal([]byte(req.YAML), &base); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	name := s.plugin.Info().Name
+	defaults := s.plugin.Defaults()
+	if len(req.Defaults) > 0 {
+		b, err := yaml.Marshal(map[string]any{name: req.Defaults})
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := yaml.Unmarshal(b, defaults); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	base.Plugin = s.plugin
+	base.Index = req.Index
+	base.Defaults = &api.Defaults{name: defaults}
+	sp.SetBase(base)
+
+	ctx = gdtcontext.SetRun(ctx, req.Run)
+	var buf bytes.Buffer
+	if req.Debug {
+		ctx = gdtcontext.SetDebug(ctx, &buf)
+		ctx = gdtcontext.SetDebugPrefix(ctx, "")
+	}
+
+	resp := evalResponse{}
+	res, err := sp.Eval(ctx)
+	if err != nil {
+		msg := toErrorMessage(err)
+		resp.Error = &msg
+	} else if res != nil {
+		for _, fail := range res.Failures() {
+			resp.Failures = append(resp.Failures, toErrorMessage(fail))
+		}
+		resp.StopOnFail = res.StopOnFail()
+		resp.Terminal = res.Terminal()
+		if mr, ok := sp.(api.MetricsReporter); ok {
+			res.AddMetrics(mr.Metrics())
+		}
+		if m := res.Metrics(); !m.IsZero() {
+			msg := metricsMessage(*m)
+			resp.Metrics = &msg
+		}
+		if res.HasData() {
+			resp.Data = jsonSafe(res.Data())
+		}
+		for _, m := range res.Measurements() {
+			resp.Measurements = append(
+				resp.Measurements, measurementMessage(m),
+			)
+		}
+		for _, a := range res.Artifacts() {
+			resp.Artifacts = append(resp.Artifacts, artifactMessage{
+				Name:        a.Name,
+				ContentType: a.ContentType,
+				Content:     a.Content,
+			})
+		}
+	}
+	if buf.Len() > 0 {
+		resp.Debug = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	}
+	return toStruct(resp)
+}
+
+// decode returns the first of the wrapped plugin's Specs that successfully
+// parses the supplied YAML document.
+func (s *server) decode(doc string) (api.Evaluable, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &node); err != nil {
+		return nil, err
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = *node.Content[0]
+	}
+	var err error
+	for _, sp := range s.plugin.Specs() {
+		err = node.Decode(sp)
+		if err == nil {
+			return sp, nil
+		}
+		if !errors.Is(err, parse.ErrParseUnknownField) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// toParseErrorMessage returns the wire representation of the supplied error
+// returned from parsing a test spec.
+func toParseErrorMessage(err error) *parseErrorMessage {
+	if errors.Is(err, parse.ErrParseUnknownField) {
+		return &parseErrorMessage{
+			UnknownField: true,
+			Code:         parse.CodeUnknownField,
+			Message: strings.TrimPrefix(
+				err.Error(), parse.ErrParseUnknownField.Error()+": ",
+			),
+		}
+	}
+	var perr *parse.Error
+	if errors.As(err, &perr) {
+		return &parseErrorMessage{
+			Code:    perr.ErrorCode(),
+			Message: perr.Message,
+			Line:    perr.Line,
+			Column:  perr.Column,
+		}
+	}
+	return &parseErrorMessage{
+		Code:    parse.CodeUnknown,
+		Message: err.Error(),
+	}
+}
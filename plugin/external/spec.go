@@ -0,0 +1,159 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package external
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+)
+
+// Spec is a test spec that is parsed and evaluated by an external plugin
+// binary. The Spec keeps the raw YAML of the test spec so that it can be sent
+// to the external plugin binary at evaluation time.
+type Spec struct {
+	api.Spec
+	plugin  *Plugin
+	raw     string
+	timeout *api.Timeout
+	retry   *api.Retry
+}
+
+func (s *Spec) SetBase(b api.Spec) {
+	s.Spec = b
+}
+
+func (s *Spec) Base() *api.Spec {
+	return &s.Spec
+}
+
+func (s *Spec) Retry() *api.Retry {
+	return s.retry
+}
+
+func (s *Spec) Timeout() *api.Timeout {
+	return s.timeout
+}
+
+// UnmarshalYAML sends the YAML for the test spec to the external plugin
+// binary to be parsed.
+func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	b, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	resp := parseResponse{}
+	err = s.plugin.invoke(
+		context.Background(), "Parse",
+		parseRequest{YAML: string(b)}, &resp,
+	)
+	if err != nil {
+		return PluginRuntimeError(s.plugin.info.Name, err)
+	}
+	if resp.Error != nil {
+		if resp.Error.UnknownField {
+			// The test spec is likely meant for a different plugin, so we
+			// return ErrParseUnknownField to allow the scenario parser to try
+			// the next plugin.
+			return fmt.Errorf(
+				"%w: %s", parse.ErrParseUnknownField, resp.Error.Message,
+			)
+		}
+		// Line and column numbers returned by the plugin are relative to the
+		// YAML document we sent it, so we need to translate them into the
+		// position within the scenario file.
+		return &parse.Error{
+			Code:    resp.Error.Code,
+			Line:    node.Line + max(resp.Error.Line-1, 0),
+			Column:  node.Column - 1 + max(resp.Error.Column, 1),
+			Message: resp.Error.Message,
+		}
+	}
+	s.raw = string(b)
+	if resp.Timeout != "" || resp.AttemptTimeout != "" {
+		s.timeout = &api.Timeout{
+			After:   resp.Timeout,
+			Attempt: resp.AttemptTimeout,
+		}
+	}
+	s.retry = resp.Retry.retry()
+	return nil
+}
+
+// Eval sends the test spec to the external plugin binary for evaluation.
+func (s *Spec) Eval(ctx context.Context) (*api.Result, error) {
+	name := s.plugin.info.Name
+	req := evalRequest{
+		YAML:  s.raw,
+		Index: s.Index,
+		Run:   runData(ctx),
+		Debug: len(gdtcontext.Debug(ctx)) > 0 ||
+			gdtcontext.TestUnit(ctx) != nil,
+	}
+	if d, ok := s.Spec.Defaults.For(name).(*Defaults); ok {
+		req.Defaults = d.Map()
+	}
+	resp := evalResponse{}
+	if err := s.plugin.invoke(ctx, "Eval", req, &resp); err != nil {
+		return nil, PluginRuntimeError(name, err)
+	}
+	for _, line := range resp.Debug {
+		debug.Println(ctx, line)
+	}
+	if resp.Error != nil {
+		return nil, newRemoteError(*resp.Error, api.RuntimeError)
+	}
+	fails := make([]error, len(resp.Failures))
+	for x, fail := range resp.Failures {
+		fails[x] = newRemoteError(fail, api.ErrFailure)
+	}
+	res := api.NewResult(
+		api.WithFailures(fails...),
+		api.WithStopOnFail(resp.StopOnFail),
+		api.WithTerminal(resp.Terminal),
+	)
+	for k, v := range resp.Data {
+		res.SetData(k, v)
+	}
+	if resp.Metrics != nil {
+		m := api.Metrics(*resp.Metrics)
+		res.AddMetrics(&m)
+	}
+	for _, m := range resp.Measurements {
+		res.RecordMetric(m.Name, m.Value, m.Unit)
+	}
+	for _, a := range resp.Artifacts {
+		res.Attach(a.Name, a.ContentType, a.Content)
+	}
+	return res, nil
+}
+
+// runData returns the run data sent to the external plugin binary. Lazy
+// values are computed first, since the plugin binary cannot compute them, and
+// left out if they cannot be computed.
+func runData(ctx context.Context) map[string]any {
+	data := gdtcontext.Run(ctx)
+	res := make(map[string]any, len(data))
+	for k, v := range data {
+		if l, ok := v.(*gdtcontext.Lazy); ok {
+			val, err := l.Value(ctx)
+			if err != nil {
+				continue
+			}
+			v = val
+		}
+		res[k] = v
+	}
+	return jsonSafe(res)
+}
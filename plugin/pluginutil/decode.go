@@ -0,0 +1,225 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package pluginutil contains helpers for plugin authors that implement the
+// common parts of decoding a test spec's YAML, so that plugins do not need to
+// copy the same key/value loop into every UnmarshalYAML method.
+//
+// A typical test spec's UnmarshalYAML method looks like this:
+//
+// ```go
+//
+//	func (s *Spec) UnmarshalYAML(node *yaml.Node) error {
+//	    return pluginutil.DecodeSpec(node, pluginutil.Fields{
+//	        "url":     pluginutil.String(&s.URL),
+//	        "retries": pluginutil.Int(&s.Retries),
+//	        "wait":    pluginutil.Duration(&s.Wait),
+//	    })
+//	}
+//
+// ```
+package pluginutil
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/parse"
+)
+
+// FieldFunc decodes the value of a single field from the supplied YAML node.
+type FieldFunc func(valNode *yaml.Node) error
+
+// Fields maps the YAML names of fields to the FieldFunc that decodes them.
+type Fields map[string]FieldFunc
+
+// WalkMap calls the supplied function with each key and value in the
+// supplied YAML mapping node. Returns a parse error if the node is not a
+// mapping or a key is not a scalar. WalkMap stops at and returns the first
+// error returned by the supplied function.
+func WalkMap(
+	node *yaml.Node,
+	fn func(key string, keyNode *yaml.Node, valNode *yaml.Node) error,
+) error {
+	if node.Kind != yaml.MappingNode {
+		return parse.ExpectedMapAt(node)
+	}
+	// maps/structs are stored in a top-level Node.Content field which is a
+	// concatenated slice of Node pointers in pairs of key/values.
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		if err := fn(keyNode.Value, keyNode, node.Content[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeFields decodes each field in the supplied YAML mapping node with the
+// matching FieldFunc. Returns parse.ErrParseUnknownField for any field that
+// is not in the supplied Fields.
+func DecodeFields(node *yaml.Node, fields Fields) error {
+	return WalkMap(node, func(key string, keyNode, valNode *yaml.Node) error {
+		fn, found := fields[key]
+		if !found {
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+		return fn(valNode)
+	})
+}
+
+// DecodeSpec decodes the supplied test spec YAML mapping node with the
+// supplied Fields. Fields that are common to all test specs, e.g. `name` or
+// `timeout`, are skipped because they are decoded into the api.Spec by the
+// scenario parser. Returns parse.ErrParseUnknownField for any other field that
+// is not in the supplied Fields, which tells the scenario parser to try a
+// different plugin.
+func DecodeSpec(node *yaml.Node, fields Fields) error {
+	return WalkMap(node, func(key string, keyNode, valNode *yaml.Node) error {
+		fn, found := fields[key]
+		if !found {
+			if lo.Contains(api.BaseSpecFields, key) {
+				return nil
+			}
+			return parse.UnknownFieldAt(key, keyNode)
+		}
+		return fn(valNode)
+	})
+}
+
+// StringAt returns the string value of the supplied YAML scalar node.
+func StringAt(node *yaml.Node) (string, error) {
+	if node.Kind != yaml.ScalarNode {
+		return "", parse.ExpectedScalarAt(node)
+	}
+	return node.Value, nil
+}
+
+// IntAt returns the integer value of the supplied YAML scalar node.
+func IntAt(node *yaml.Node) (int, error) {
+	if node.Kind != yaml.ScalarNode {
+		return 0, parse.ExpectedScalarAt(node)
+	}
+	v, err := strconv.Atoi(node.Value)
+	if err != nil {
+		return 0, parse.ExpectedIntAt(node)
+	}
+	return v, nil
+}
+
+// BoolAt returns the boolean value of the supplied YAML scalar node.
+func BoolAt(node *yaml.Node) (bool, error) {
+	if node.Kind != yaml.ScalarNode {
+		return false, parse.ExpectedScalarAt(node)
+	}
+	var v bool
+	if err := node.Decode(&v); err != nil {
+		return false, parse.ExpectedBoolAt(node)
+	}
+	return v, nil
+}
+
+// DurationAt returns the time.Duration value of the supplied YAML scalar
+// node, e.g. "1s" or "500ms".
+func DurationAt(node *yaml.Node) (time.Duration, error) {
+	if node.Kind != yaml.ScalarNode {
+		return 0, parse.ExpectedScalarAt(node)
+	}
+	v, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return 0, parse.ExpectedDurationAt(node)
+	}
+	return v, nil
+}
+
+// StringsAt returns the string values of the supplied YAML node, which may
+// be either a single scalar or a sequence of scalars.
+func StringsAt(node *yaml.Node) ([]string, error) {
+	var fs api.FlexStrings
+	if err := node.Decode(&fs); err != nil {
+		return nil, err
+	}
+	return fs.Values(), nil
+}
+
+// String returns a FieldFunc that decodes a string into the supplied target.
+func String(target *string) FieldFunc {
+	return func(node *yaml.Node) error {
+		v, err := StringAt(node)
+		if err != nil {
+			return err
+		}
+		*target = v
+		return nil
+	}
+}
+
+// Int returns a FieldFunc that decodes an integer into the supplied target.
+func Int(target *int) FieldFunc {
+	return func(node *yaml.Node) error {
+		v, err := IntAt(node)
+		if err != nil {
+			return err
+		}
+		*target = v
+		return nil
+	}
+}
+
+// Bool returns a FieldFunc that decodes a boolean into the supplied target.
+func Bool(target *bool) FieldFunc {
+	return func(node *yaml.Node) error {
+		v, err := BoolAt(node)
+		if err != nil {
+			return err
+		}
+		*target = v
+		return nil
+	}
+}
+
+// Duration returns a FieldFunc that decodes a duration into the supplied
+// target.
+func Duration(target *time.Duration) FieldFunc {
+	return func(node *yaml.Node) error {
+		v, err := DurationAt(node)
+		if err != nil {
+			return err
+		}
+		*target = v
+		return nil
+	}
+}
+
+// Strings returns a FieldFunc that decodes either a single string or a
+// sequence of strings into the supplied target.
+func Strings(target *[]string) FieldFunc {
+	return func(node *yaml.Node) error {
+		v, err := StringsAt(node)
+		if err != nil {
+			return err
+		}
+		*target = v
+		return nil
+	}
+}
+
+// Map returns a FieldFunc that decodes a YAML mapping into the supplied
+// target, which is usually a pointer to a struct with its own UnmarshalYAML
+// method.
+func Map(target any) FieldFunc {
+	return func(node *yaml.Node) error {
+		if node.Kind != yaml.MappingNode {
+			return parse.ExpectedMapAt(node)
+		}
+		return node.Decode(target)
+	}
+}
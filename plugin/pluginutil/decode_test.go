@@ -0,0 +1,105 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package pluginutil_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+type spec struct {
+	URL     string
+	Retries int
+	Verbose bool
+	Wait    time.Duration
+	Tags    []string
+}
+
+func (s *spec) UnmarshalYAML(node *yaml.Node) error {
+	return pluginutil.DecodeSpec(node, pluginutil.Fields{
+		"url":     pluginutil.String(&s.URL),
+		"retries": pluginutil.Int(&s.Retries),
+		"verbose": pluginutil.Bool(&s.Verbose),
+		"wait":    pluginutil.Duration(&s.Wait),
+		"tags":    pluginutil.Strings(&s.Tags),
+	})
+}
+
+func TestDecodeSpec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	doc := `
+name: base fields are skipped
+timeout: 1s
+url: http://example.com
+retries: 3
+verbose: true
+wait: 500ms
+tags: one
+`
+	s := spec{}
+	require.Nil(yaml.Unmarshal([]byte(doc), &s))
+	assert.Equal("http://example.com", s.URL)
+	assert.Equal(3, s.Retries)
+	assert.True(s.Verbose)
+	assert.Equal(500*time.Millisecond, s.Wait)
+	assert.Equal([]string{"one"}, s.Tags)
+}
+
+func TestDecodeSpecErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		code string
+		line int
+	}{
+		{"- not a map", parse.CodeExpectedMap, 1},
+		{"url: a\nunknown: b", parse.CodeUnknownField, 0},
+		{"url: [a]", parse.CodeExpectedScalar, 1},
+		{"retries: many", parse.CodeExpectedInt, 1},
+		{"verbose: maybe", parse.CodeExpectedBool, 1},
+		{"url: a\nwait: soon", parse.CodeExpectedDuration, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.code, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := yaml.Unmarshal([]byte(tc.doc), &spec{})
+			require.NotNil(t, err)
+			assert.Equal(tc.code, api.ErrorCode(err))
+			var perr *parse.Error
+			if errors.As(err, &perr) {
+				assert.Equal(tc.line, perr.Line)
+			}
+		})
+	}
+}
+
+func TestDecodeFields(t *testing.T) {
+	require := require.New(t)
+
+	var name string
+	var node yaml.Node
+	require.Nil(yaml.Unmarshal([]byte("name: foo"), &node))
+
+	// Unlike DecodeSpec, DecodeFields does not skip the base spec fields.
+	err := pluginutil.DecodeFields(node.Content[0], pluginutil.Fields{})
+	require.ErrorIs(err, parse.ErrParseUnknownField)
+
+	err = pluginutil.DecodeFields(node.Content[0], pluginutil.Fields{
+		"name": pluginutil.String(&name),
+	})
+	require.Nil(err)
+	require.Equal("foo", name)
+}
@@ -0,0 +1,83 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package pluginutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/expr"
+	"github.com/gdt-dev/core/parse"
+)
+
+// ExprEnv returns the expr.Env that expressions are evaluated with during a
+// test run. Variables are looked up in the supplied context's run data,
+// environment variables published by fixtures take precedence over those of
+// the process and the state() function reads the state of the context's
+// fixtures.
+func ExprEnv(ctx context.Context) expr.Env {
+	return expr.Env{
+		Var: func(name string) (any, error) {
+			val, found, err := gdtcontext.RunValue(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return nil, fmt.Errorf("%w: %s", expr.ErrUnknownVariable, name)
+			}
+			return val, nil
+		},
+		Now: gdtcontext.Clock(ctx).Now,
+		Getenv: func(name string) string {
+			if val, found := gdtcontext.Env(ctx)[name]; found {
+				return val
+			}
+			return os.Getenv(name)
+		},
+		State: func(fixture, key string) (any, error) {
+			fix, found := gdtcontext.Fixtures(ctx)[fixture]
+			if !found {
+				return nil, api.RequiredFixtureMissing(fixture)
+			}
+			if !fix.HasState(key) {
+				return nil, fmt.Errorf(
+					"%w: fixture %s has no state %q", expr.ErrEval, fixture, key,
+				)
+			}
+			return fix.State(key), nil
+		},
+	}
+}
+
+// ExprsAt returns the expressions in the supplied YAML node, which may be
+// either a single scalar or a sequence of scalars. An expression that cannot
+// be parsed is a parse error with the code parse.CodeInvalidExpression.
+func ExprsAt(node *yaml.Node) ([]*expr.Expr, error) {
+	nodes := []*yaml.Node{node}
+	switch node.Kind {
+	case yaml.ScalarNode:
+	case yaml.SequenceNode:
+		nodes = node.Content
+	default:
+		return nil, parse.ExpectedScalarOrSequenceAt(node)
+	}
+	exprs := make([]*expr.Expr, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Kind != yaml.ScalarNode {
+			return nil, parse.ExpectedScalarAt(n)
+		}
+		e, err := expr.Parse(n.Value)
+		if err != nil {
+			return nil, parse.InvalidExpressionAt(n, n.Value, err)
+		}
+		exprs = append(exprs, e)
+	}
+	return exprs, nil
+}
@@ -0,0 +1,60 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package pluginutil_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/expr"
+	"github.com/gdt-dev/core/fixture"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+func TestExprEnv(t *testing.T) {
+	t.Setenv("GDT_EXPR_ENV_HOST", "process")
+
+	ctx := gdtcontext.New(gdtcontext.WithFixtures(map[string]api.Fixture{
+		"db": fixture.New(fixture.WithState(map[string]any{"port": 5432})),
+	}))
+	ctx = gdtcontext.SetEnv(ctx, map[string]string{"GDT_EXPR_ENV_HOST": "fixture"})
+	ctx = gdtcontext.SetRun(ctx, map[string]any{"ATTEMPTS": 3})
+	env := pluginutil.ExprEnv(ctx)
+
+	tests := []struct {
+		src string
+		exp any
+	}{
+		{`vars.ATTEMPTS + 1`, 4},
+		{`env.GDT_EXPR_ENV_HOST`, "fixture"},
+		{`state("db", "port") == 5432`, true},
+	}
+	for _, test := range tests {
+		e, err := expr.Parse(test.src)
+		require.Nil(t, err)
+		val, err := e.Eval(env)
+		require.Nil(t, err)
+		assert.Equal(t, test.exp, val, test.src)
+	}
+
+	e, err := expr.Parse(`vars.MISSING`)
+	require.Nil(t, err)
+	_, err = e.Eval(env)
+	assert.ErrorIs(t, err, expr.ErrUnknownVariable)
+
+	e, err = expr.Parse(`state("cache", "port")`)
+	require.Nil(t, err)
+	_, err = e.Eval(env)
+	assert.ErrorIs(t, err, api.ErrRequiredFixture)
+
+	e, err = expr.Parse(`state("db", "user")`)
+	require.Nil(t, err)
+	_, err = e.Eval(env)
+	assert.ErrorIs(t, err, expr.ErrEval)
+}
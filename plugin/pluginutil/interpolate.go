@@ -0,0 +1,135 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package pluginutil
+
+import (
+	"context"
+	"reflect"
+	"slices"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// specType is the type of the common test spec fields that plugins embed in
+// their test specs.
+var specType = reflect.TypeOf(api.Spec{})
+
+// Interpolate replaces references to variables in the prior run data that use
+// the template notation, e.g. `{{ .vars.myvar }}`, in every string field of
+// the supplied test spec, including strings nested in pointers, slices, maps
+// and interfaces. Fields tagged `yaml:"-"`, unexported fields and the
+// embedded `api.Spec` are left alone.
+//
+// The scenario runner interpolates every test spec before calling its Eval
+// method, so plugins only call Interpolate for other values, e.g. a spec
+// built while evaluating. The returned function restores the original field
+// values so that the test spec can be evaluated again with different run
+// data.
+func Interpolate(ctx context.Context, spec any) (restore func()) {
+	if len(gdtcontext.Run(ctx)) == 0 {
+		return func() {}
+	}
+	in := &interpolator{
+		replace: func(s string) string {
+			return gdtcontext.ReplaceTemplateVariables(ctx, s)
+		},
+		visited: map[uintptr]bool{},
+	}
+	in.walk(reflect.ValueOf(spec))
+	return func() {
+		for _, fn := range slices.Backward(in.restores) {
+			fn()
+		}
+	}
+}
+
+// interpolator walks a value, replacing the strings it contains and keeping
+// the functions that restore the original strings.
+type interpolator struct {
+	replace  func(string) string
+	restores []func()
+	// visited contains the pointers and maps already walked, so that cyclic
+	// values are only walked once.
+	visited map[uintptr]bool
+}
+
+// walk replaces the strings in the supplied value. Strings that are not
+// addressable, e.g. map values, are replaced by walking a copy of their
+// container and setting the copy in place of the original.
+func (in *interpolator) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if !v.CanSet() {
+			return
+		}
+		orig := v.String()
+		if repl := in.replace(orig); repl != orig {
+			v.SetString(repl)
+			in.restores = append(in.restores, func() { v.SetString(orig) })
+		}
+	case reflect.Pointer:
+		if v.IsNil() || in.visited[v.Pointer()] {
+			return
+		}
+		in.visited[v.Pointer()] = true
+		in.walk(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		elem := v.Elem()
+		switch elem.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice:
+			in.walk(elem)
+		default:
+			if v.CanSet() {
+				in.walkCopy(elem, func(cp reflect.Value) { v.Set(cp) })
+			}
+		}
+	case reflect.Struct:
+		if v.Type() == specType {
+			return
+		}
+		for x := 0; x < v.NumField(); x++ {
+			field := v.Type().Field(x)
+			if !field.IsExported() || field.Tag.Get("yaml") == "-" {
+				continue
+			}
+			in.walk(v.Field(x))
+		}
+	case reflect.Slice, reflect.Array:
+		for x := 0; x < v.Len(); x++ {
+			in.walk(v.Index(x))
+		}
+	case reflect.Map:
+		if v.IsNil() || in.visited[v.Pointer()] {
+			return
+		}
+		in.visited[v.Pointer()] = true
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			in.walkCopy(iter.Value(), func(cp reflect.Value) {
+				v.SetMapIndex(key, cp)
+			})
+		}
+	}
+}
+
+// walkCopy walks a copy of the supplied value and, if any strings were
+// replaced, calls the supplied function to set the copy in place of the
+// original, and later the original in place of the copy.
+func (in *interpolator) walkCopy(orig reflect.Value, set func(reflect.Value)) {
+	cp := reflect.New(orig.Type()).Elem()
+	cp.Set(orig)
+	n := len(in.restores)
+	in.walk(cp)
+	if len(in.restores) == n {
+		return
+	}
+	set(cp)
+	in.restores = append(in.restores, func() { set(orig) })
+}
@@ -0,0 +1,84 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package pluginutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+type request struct {
+	Path    string
+	Headers map[string]string
+}
+
+type interpolateSpec struct {
+	api.Spec
+	URL      string
+	Request  *request
+	Args     []string
+	Body     map[string]any
+	Expect   any
+	Count    int
+	Internal string `yaml:"-"`
+	private  string
+}
+
+func TestInterpolate(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := gdtcontext.SetRun(context.TODO(), map[string]any{
+		"id":    "42",
+		"count": 3,
+	})
+	s := &interpolateSpec{
+		Spec: api.Spec{Name: "get {{ .vars.id }}"},
+		URL:  "https://example.com/things/{{ .vars.id }}",
+		Request: &request{
+			Path:    "/{{.vars.id}}",
+			Headers: map[string]string{"X-Count": "{{ .vars.count }}"},
+		},
+		Args: []string{"$id", "{{ .vars.id }}", "{{ .vars.unknown }}"},
+		Body: map[string]any{
+			"id":     "{{ .vars.id }}",
+			"nested": []any{"{{ .vars.count }}"},
+		},
+		Expect:   "{{ .vars.id }}",
+		Count:    1,
+		Internal: "{{ .vars.id }}",
+		private:  "{{ .vars.id }}",
+	}
+
+	restore := pluginutil.Interpolate(ctx, s)
+	assert.Equal("https://example.com/things/42", s.URL)
+	assert.Equal("/42", s.Request.Path)
+	assert.Equal("3", s.Request.Headers["X-Count"])
+	assert.Equal([]string{"$id", "42", "{{ .vars.unknown }}"}, s.Args)
+	assert.Equal("42", s.Body["id"])
+	assert.Equal([]any{"3"}, s.Body["nested"])
+	assert.Equal("42", s.Expect)
+	assert.Equal("get {{ .vars.id }}", s.Name)
+	assert.Equal("{{ .vars.id }}", s.Internal)
+	assert.Equal("{{ .vars.id }}", s.private)
+
+	restore()
+	assert.Equal("https://example.com/things/{{ .vars.id }}", s.URL)
+	assert.Equal("/{{.vars.id}}", s.Request.Path)
+	assert.Equal("{{ .vars.count }}", s.Request.Headers["X-Count"])
+	assert.Equal([]string{"$id", "{{ .vars.id }}", "{{ .vars.unknown }}"}, s.Args)
+	assert.Equal("{{ .vars.id }}", s.Body["id"])
+	assert.Equal([]any{"{{ .vars.count }}"}, s.Body["nested"])
+	assert.Equal("{{ .vars.id }}", s.Expect)
+
+	// Without run data, nothing is replaced.
+	pluginutil.Interpolate(context.TODO(), s)()
+	assert.Equal("https://example.com/things/{{ .vars.id }}", s.URL)
+}
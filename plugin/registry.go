@@ -5,49 +5,173 @@
 package plugin
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/gdt-dev/core/api"
 )
 
+const (
+	// DefaultPriority is the priority of a plugin registered without the
+	// WithPriority option.
+	DefaultPriority = 0
+)
+
+// entry is a registered Plugin along with its registration namespace,
+// priority and order.
+type entry struct {
+	plugin    api.Plugin
+	namespace string
+	priority  int
+	// seq is the order in which the plugin was first registered.
+	seq int
+}
+
+// RegisterOption modifies how a Plugin is registered.
+type RegisterOption func(*entry)
+
+// WithPriority sets the priority of the registered Plugin. When more than one
+// plugin can parse a test spec, the plugin with the highest priority is
+// chosen. Plugins with equal priority are tried in the order in which they
+// were registered.
+func WithPriority(priority int) RegisterOption {
+	return func(e *entry) {
+		e.priority = priority
+	}
+}
+
+// WithNamespace registers the Plugin under the supplied namespace, e.g.
+// "myorg". A namespaced Plugin can be referred to by its qualified name, e.g.
+// "myorg.http", which allows Plugins with the same name from different
+// third-party modules to be registered in the same test binary.
+func WithNamespace(namespace string) RegisterOption {
+	return func(e *entry) {
+		e.namespace = namespace
+	}
+}
+
+// qualify returns the supplied name qualified with the supplied namespace.
+func qualify(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
 // registry stores a set of Plugins and is safe to use in threaded
 // environments.
 type registry struct {
 	sync.RWMutex
-	entries map[string]api.Plugin
+	entries map[string]*entry
+	nextSeq int
 }
 
 // Remove delists the Plugin with registry. Only really useful for testing.
 func (r *registry) Remove(p api.Plugin) {
 	r.Lock()
 	defer r.Unlock()
+	if key, e := r.find(p); e != nil {
+		delete(r.entries, key)
+	}
+}
+
+// find returns the registry key and entry for the supplied Plugin, or nil if
+// the Plugin is not registered. The caller must hold the registry's lock.
+func (r *registry) find(p api.Plugin) (string, *entry) {
+	for key, e := range r.entries {
+		if e.plugin == p {
+			return key, e
+		}
+	}
 	lowered := strings.ToLower(p.Info().Name)
-	delete(r.entries, lowered)
+	if e, found := r.entries[lowered]; found {
+		return lowered, e
+	}
+	return "", nil
 }
 
-// Add registers a Plugin with the registry.
-func (r *registry) Add(p api.Plugin) {
+// Add registers a Plugin with the registry. Registering a Plugin with the
+// same qualified name as an already-registered Plugin replaces the existing
+// Plugin but keeps its place in the registration order. Add returns an error and does
+// not register the Plugin if the Plugin was built against an incompatible
+// version of the gdt core plugin API.
+func (r *registry) Add(p api.Plugin, opts ...RegisterOption) error {
+	info := p.Info()
+	if !api.APIVersionCompatible(info.APIVersion) {
+		return IncompatibleAPIVersion(info.Name, info.APIVersion)
+	}
 	r.Lock()
 	defer r.Unlock()
-	lowered := strings.ToLower(p.Info().Name)
-	r.entries[lowered] = p
+	e := &entry{
+		plugin:   p,
+		priority: DefaultPriority,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	key := strings.ToLower(qualify(e.namespace, info.Name))
+	if existing, found := r.entries[key]; found {
+		e.seq = existing.seq
+	} else {
+		e.seq = r.nextSeq
+		r.nextSeq++
+	}
+	r.entries[key] = e
+	return nil
+}
+
+// sorted returns the registry entries ordered by descending priority and then
+// by registration order.
+func (r *registry) sorted() []*entry {
+	entries := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].priority != entries[j].priority {
+			return entries[i].priority > entries[j].priority
+		}
+		return entries[i].seq < entries[j].seq
+	})
+	return entries
 }
 
-// List returns a slice of Plugins that are registered with gdt.
+// List returns a slice of Plugins that are registered with gdt, ordered by
+// descending priority and then by registration order.
 func (r *registry) List() []api.Plugin {
 	r.RLock()
 	defer r.RUnlock()
 	res := []api.Plugin{}
-	for _, p := range r.entries {
-		res = append(res, p)
+	for _, e := range r.sorted() {
+		res = append(res, e.plugin)
 	}
 	return res
 }
 
+// Priority returns the registration priority of the supplied Plugin.
+func (r *registry) Priority(p api.Plugin) int {
+	r.RLock()
+	defer r.RUnlock()
+	if _, e := r.find(p); e != nil {
+		return e.priority
+	}
+	return DefaultPriority
+}
+
+// Namespace returns the namespace of the supplied Plugin.
+func (r *registry) Namespace(p api.Plugin) string {
+	r.RLock()
+	defer r.RUnlock()
+	if _, e := r.find(p); e != nil {
+		return e.namespace
+	}
+	return ""
+}
+
 var (
 	knownPlugins = &registry{
-		entries: map[string]api.Plugin{},
+		entries: map[string]*entry{},
 	}
 )
 
@@ -55,11 +179,33 @@ var (
 //
 // Generally only plugin authors will ever need to call this function. It is
 // not required for normal use of gdt or any known plugin.
-func Register(p api.Plugin) {
-	knownPlugins.Add(p)
+//
+// Register returns ErrIncompatibleAPIVersion if the plugin's
+// PluginInfo.APIVersion is not supported by this version of gdt core.
+func Register(p api.Plugin, opts ...RegisterOption) error {
+	return knownPlugins.Add(p, opts...)
 }
 
-// Registered returns a slice of pointers to gdt's known plugins.
+// Registered returns a slice of pointers to gdt's known plugins, ordered by
+// descending priority and then by registration order.
 func Registered() []api.Plugin {
 	return knownPlugins.List()
 }
+
+// Priority returns the priority that the supplied plugin was registered with.
+func Priority(p api.Plugin) int {
+	return knownPlugins.Priority(p)
+}
+
+// Namespace returns the namespace that the supplied plugin was registered
+// under, or the empty string if the plugin was registered without the
+// WithNamespace option.
+func Namespace(p api.Plugin) string {
+	return knownPlugins.Namespace(p)
+}
+
+// QualifiedName returns the supplied plugin's name qualified with the
+// namespace it was registered under, e.g. "myorg.http".
+func QualifiedName(p api.Plugin) string {
+	return qualify(Namespace(p), p.Info().Name)
+}
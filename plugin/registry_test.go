@@ -89,3 +89,154 @@ func TestRegisterAndList(t *testing.T) {
 	assert.Equal(1, len(plugins))
 	assert.Equal("foo", plugins[0].Info().Name)
 }
+
+type namedPlugin struct {
+	fooPlugin
+	name string
+}
+
+func (p *namedPlugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name: p.name,
+	}
+}
+
+func TestRegisterPriority(t *testing.T) {
+	assert := assert.New(t)
+
+	low := &namedPlugin{name: "low"}
+	high := &namedPlugin{name: "high"}
+	plugin.Register(low, plugin.WithPriority(-1))
+	plugin.Register(high, plugin.WithPriority(10))
+
+	plugins := plugin.Registered()
+	names := []string{}
+	for _, p := range plugins {
+		names = append(names, p.Info().Name)
+	}
+	// Plugins are ordered by descending priority and then registration order
+	assert.Equal([]string{"high", "foo", "low"}, names)
+	assert.Equal(10, plugin.Priority(high))
+	assert.Equal(-1, plugin.Priority(low))
+	assert.Equal(plugin.DefaultPriority, plugin.Priority(&fooPlugin{}))
+}
+
+type versionedPlugin struct {
+	fooPlugin
+	version int
+}
+
+func (p *versionedPlugin) Info() api.PluginInfo {
+	return api.PluginInfo{
+		Name:       "versioned",
+		APIVersion: p.version,
+	}
+}
+
+func TestRegisterAPIVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	err := plugin.Register(&versionedPlugin{version: api.APIVersion + 1})
+	assert.ErrorIs(err, plugin.ErrIncompatibleAPIVersion)
+	assert.ErrorContains(err, "upgrade gdt core")
+
+	_, found := plugin.Lookup("versioned")
+	assert.False(found)
+
+	err = plugin.Register(&versionedPlugin{version: api.APIVersion})
+	assert.NoError(err)
+
+	_, found = plugin.Lookup("versioned")
+	assert.True(found)
+}
+
+type describedPlugin struct {
+	fooPlugin
+}
+
+func (p *describedPlugin) Info() api.PluginInfo {
+	attempts := 2
+	return api.PluginInfo{
+		Name:        "described",
+		Aliases:     []string{"desc"},
+		Description: "a plugin with field documentation",
+		Timeout:     &api.Timeout{After: "5s"},
+		Retry:       &api.Retry{Attempts: &attempts, Interval: "1s"},
+		Fields: []api.FieldDoc{
+			{
+				Name:        "described",
+				Type:        "string",
+				Description: "the thing to describe",
+				Required:    true,
+				Examples:    []string{"cat"},
+			},
+			{
+				Name:        "assert",
+				Type:        "map",
+				Description: "assertions",
+				Fields: []api.FieldDoc{
+					{
+						Name: "len",
+						Type: "int",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+
+	plugin.Register(&describedPlugin{})
+
+	_, err := plugin.Describe("unknown")
+	assert.ErrorIs(err, plugin.ErrNotFound)
+
+	got, err := plugin.Describe("DESC")
+	assert.Nil(err)
+	assert.Equal(`described
+
+a plugin with field documentation
+
+aliases: desc
+
+default timeout: 5s
+default retry: 2 attempts, interval 1s
+
+fields:
+  described (string, required)
+      the thing to describe
+      example: cat
+  assert (map)
+      assertions
+  assert.len (int)
+`, got)
+}
+
+func TestRegisterNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	myorg := &namedPlugin{name: "nshttp"}
+	acme := &namedPlugin{name: "nshttp"}
+	assert.NoError(plugin.Register(myorg, plugin.WithNamespace("myorg")))
+	assert.NoError(plugin.Register(acme, plugin.WithNamespace("acme")))
+
+	assert.Equal("myorg", plugin.Namespace(myorg))
+	assert.Equal("acme.nshttp", plugin.QualifiedName(acme))
+
+	p, err := plugin.Resolve("MyOrg.NSHTTP")
+	assert.NoError(err)
+	assert.Same(myorg, p)
+
+	p, err = plugin.Resolve("acme.nshttp")
+	assert.NoError(err)
+	assert.Same(acme, p)
+
+	_, err = plugin.Resolve("nshttp")
+	assert.ErrorIs(err, plugin.ErrAmbiguousName)
+	assert.ErrorContains(err, "myorg.nshttp, acme.nshttp")
+
+	_, err = plugin.Resolve("other.nshttp")
+	assert.ErrorIs(err, plugin.ErrNotFound)
+}
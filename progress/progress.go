@@ -0,0 +1,74 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package progress sends the progress events of a test run to the functions
+// registered in the context with `gdtcontext.WithProgress()`.
+package progress
+
+import (
+	"context"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// Emit sends the supplied event to the context's progress functions, filling
+// in the event's time, trace name and identifiers from the context.
+func Emit(ctx context.Context, ev api.ProgressEvent) {
+	fns := gdtcontext.Progress(ctx)
+	if len(fns) == 0 {
+		return
+	}
+	ev.Time = gdtcontext.Clock(ctx).Now()
+	ev.Trace = gdtcontext.Trace(ctx)
+	ev.RunID = gdtcontext.RunID(ctx)
+	ev.SpecID = gdtcontext.SpecID(ctx)
+	for _, fn := range fns {
+		fn(ev)
+	}
+}
+
+// SpecStarted sends an event for a test spec starting to run.
+func SpecStarted(ctx context.Context) {
+	Emit(ctx, api.ProgressEvent{Kind: api.ProgressSpecStarted})
+}
+
+// SpecFinished sends an event for a test spec that has finished running.
+func SpecFinished(ctx context.Context, failed bool) {
+	Emit(ctx, api.ProgressEvent{Kind: api.ProgressSpecFinished, Failed: failed})
+}
+
+// Attempt sends an event for an attempt to evaluate a test spec.
+func Attempt(ctx context.Context, attempt int) {
+	Emit(ctx, api.ProgressEvent{Kind: api.ProgressAttempt, Attempt: attempt})
+}
+
+// Waiting sends an event for a wait of the supplied duration.
+func Waiting(ctx context.Context, wait time.Duration) {
+	Emit(ctx, api.ProgressEvent{Kind: api.ProgressWaiting, Wait: wait})
+}
+
+// FixtureStarting sends an event for the fixture with the supplied name
+// starting.
+func FixtureStarting(ctx context.Context, fixture string) {
+	Emit(ctx, api.ProgressEvent{Kind: api.ProgressFixtureStarting, Fixture: fixture})
+}
+
+// ScenarioChanged sends an event for the watched scenario file with the
+// supplied path having been created or modified.
+func ScenarioChanged(ctx context.Context, path string) {
+	Emit(ctx, api.ProgressEvent{Kind: api.ProgressScenarioChanged, Path: path})
+}
+
+// ScenarioFinished sends an event for the watched scenario with the supplied
+// path having finished running.
+func ScenarioFinished(ctx context.Context, path string, failed bool, err error) {
+	Emit(ctx, api.ProgressEvent{
+		Kind:   api.ProgressScenarioFinished,
+		Path:   path,
+		Failed: failed,
+		Err:    err,
+	})
+}
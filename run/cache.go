@@ -0,0 +1,67 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package run
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CacheEntry records that a test spec with a particular cache key passed.
+type CacheEntry struct {
+	// PassedAt is when the test spec passed.
+	PassedAt time.Time `json:"passed_at"`
+	// Data is the run data that the test spec saved, e.g. variables for later
+	// test specs. Values are stored as JSON, so numbers are restored as
+	// float64s.
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Caching returns true if the Run has a result cache.
+func (r *Run) Caching() bool {
+	return r.cacheDir != ""
+}
+
+// CachedPass returns the CacheEntry for the test spec with the supplied cache
+// key and true if the test spec passed within the Run's maximum cache age, or
+// an empty CacheEntry and false otherwise.
+func (r *Run) CachedPass(key string) (CacheEntry, bool) {
+	entry := CacheEntry{}
+	if r.cacheDir == "" || key == "" {
+		return entry, false
+	}
+	b, err := os.ReadFile(filepath.Join(r.cacheDir, key+".json"))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	if r.cacheMaxAge > 0 && time.Since(entry.PassedAt) > r.cacheMaxAge {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// StorePass records in the Run's result cache that the test spec with the
+// supplied cache key passed and saved the supplied run data. The pass is not
+// recorded if the run data cannot be stored as JSON or the cache cannot be
+// written.
+func (r *Run) StorePass(key string, data map[string]any) {
+	if r.cacheDir == "" || key == "" {
+		return
+	}
+	b, err := json.Marshal(CacheEntry{PassedAt: time.Now(), Data: data})
+	if err != nil {
+		return
+	}
+	// Run data may be sensitive, so the cache is only readable by its owner.
+	if err := os.MkdirAll(r.cacheDir, 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(r.cacheDir, key+".json"), b, 0o600)
+}
@@ -4,12 +4,84 @@
 
 package run
 
+import (
+	"time"
+
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
 type Option func(*Run)
 
+// WithID sets the Run's identifier. The default is a new random identifier.
+func WithID(id string) Option {
+	return func(r *Run) {
+		r.id = id
+	}
+}
+
+// WithShutdownGrace sets how long cleanups and fixture stops may take after
+// the Run is interrupted by a signal. The default is DefaultShutdownGrace.
+func WithShutdownGrace(grace time.Duration) Option {
+	return func(r *Run) {
+		r.grace = grace
+	}
+}
+
+// WithDeadline sets the time by which the Run must finish, e.g. the end of a
+// CI job's time budget. A scenario whose total wait time or maximum timeout
+// does not fit before the deadline fails with an ErrTimeoutConflict before
+// running any test specs. The default is no deadline.
+func WithDeadline(deadline time.Time) Option {
+	return func(r *Run) {
+		r.deadline = deadline
+	}
+}
+
+// WithArtifactsDir sets a directory that the Run writes the artifacts of
+// each test unit to, in a subdirectory named for the test unit's ID. The
+// default is to keep artifacts in memory only.
+func WithArtifactsDir(dir string) Option {
+	return func(r *Run) {
+		r.artifactsDir = dir
+	}
+}
+
+// WithCache enables a cache, stored in the supplied directory, of the test
+// specs that passed. A test spec whose YAML, scenario defaults and run data
+// are identical to a test spec that passed within maxAge is not evaluated
+// again; its result is recorded as passed and Cached. A maxAge of zero means
+// cached passes do not expire. The default is no cache.
+func WithCache(dir string, maxAge time.Duration) Option {
+	return func(r *Run) {
+		r.cacheDir = dir
+		r.cacheMaxAge = maxAge
+	}
+}
+
+// WithNotifier adds a Notifier that Run.Notify calls with the Summary of the
+// Run, e.g. a Webhook. WithNotifier may be supplied more than once.
+func WithNotifier(n Notifier) Option {
+	return func(r *Run) {
+		r.notifiers = append(r.notifiers, n)
+	}
+}
+
+// WithMetricsRegistry records the Run's test unit results to the supplied
+// MetricsRegistry, e.g. DefaultMetricsRegistry, which serves them to
+// Prometheus. The default is not to record metrics.
+func WithMetricsRegistry(reg *MetricsRegistry) Option {
+	return func(r *Run) {
+		r.registry = reg
+	}
+}
+
 // New returns a new Run object that stores test run state.
 func New(opts ...Option) *Run {
 	r := &Run{
+		id:              gdtcontext.NewRunID(),
 		scenarioResults: map[string][]TestUnitResult{},
+		scenarioStarts:  map[string]int{},
+		grace:           DefaultShutdownGrace,
 	}
 	for _, opt := range opts {
 		opt(r)
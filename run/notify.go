@@ -0,0 +1,211 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+)
+
+// Notifier is notified of the outcome of a Run, e.g. to post the outcome of a
+// CI job to a chat channel.
+type Notifier interface {
+	// Notify is called with the Summary of the Run once all of its scenarios
+	// have run.
+	Notify(context.Context, Summary) error
+}
+
+// NotifierFunc adapts an ordinary function to the Notifier interface.
+type NotifierFunc func(context.Context, Summary) error
+
+// Notify calls f(ctx, sum).
+func (f NotifierFunc) Notify(ctx context.Context, sum Summary) error {
+	return f(ctx, sum)
+}
+
+// Summary describes the outcome of a Run.
+type Summary struct {
+	// RunID is the Run's identifier.
+	RunID string `json:"run_id"`
+	// OK is true if the Run was not interrupted and no test unit failed.
+	OK bool `json:"ok"`
+	// Interrupted is true if the Run was interrupted by a signal.
+	Interrupted bool `json:"interrupted,omitempty"`
+	// Passed is the number of test units that passed, including those with a
+	// cached pass.
+	Passed int `json:"passed"`
+	// Failed is the number of test units that failed.
+	Failed int `json:"failed"`
+	// Skipped is the number of test units that were skipped.
+	Skipped int `json:"skipped"`
+	// Cached is the number of test units that were not evaluated because
+	// they had a cached pass.
+	Cached int `json:"cached,omitempty"`
+	// Elapsed is the total time spent executing the Run's test units.
+	Elapsed time.Duration `json:"elapsed_ns"`
+	// Scenarios contains the summary of each scenario in the Run, ordered by
+	// the scenario's path.
+	Scenarios []ScenarioSummary `json:"scenarios"`
+}
+
+// ScenarioSummary describes the outcome of a scenario in a Run.
+type ScenarioSummary struct {
+	// Path is the filepath to the scenario.
+	Path string `json:"path"`
+	// Passed is the number of the scenario's test units that passed.
+	Passed int `json:"passed"`
+	// Failed is the number of the scenario's test units that failed.
+	Failed int `json:"failed"`
+	// Skipped is the number of the scenario's test units that were skipped.
+	Skipped int `json:"skipped"`
+	// Failures describes each of the scenario's test units that failed.
+	Failures []FailureSummary `json:"failures,omitempty"`
+}
+
+// FailureSummary describes a test unit that failed.
+type FailureSummary struct {
+	// Name is the name of the test unit.
+	Name string `json:"name"`
+	// Index is the 0-based index of the test spec within its scenario.
+	Index int `json:"index"`
+	// StableID is the `id` of the test spec, if any.
+	StableID string `json:"id,omitempty"`
+	// Messages contains the message of each of the test unit's failures.
+	Messages []string `json:"messages"`
+}
+
+// Summary returns the Summary of the Run.
+func (r *Run) Summary() Summary {
+	sum := Summary{
+		RunID:       r.id,
+		OK:          r.OK(),
+		Interrupted: r.Interrupted() != nil,
+		Scenarios:   []ScenarioSummary{},
+	}
+	for _, path := range r.ScenarioPaths() {
+		ss := ScenarioSummary{Path: path}
+		for _, tur := range r.ScenarioResults(path) {
+			sum.Elapsed += tur.Elapsed()
+			switch {
+			case !tur.OK():
+				ss.Failed++
+				msgs := make([]string, len(tur.Failures()))
+				for x, fail := range tur.Failures() {
+					msgs[x] = fail.Error()
+				}
+				ss.Failures = append(ss.Failures, FailureSummary{
+					Name:     tur.Name(),
+					Index:    tur.Index(),
+					StableID: tur.StableID(),
+					Messages: msgs,
+				})
+			case tur.Skipped():
+				ss.Skipped++
+			default:
+				ss.Passed++
+				if tur.Cached() {
+					sum.Cached++
+				}
+			}
+		}
+		sum.Passed += ss.Passed
+		sum.Failed += ss.Failed
+		sum.Skipped += ss.Skipped
+		sum.Scenarios = append(sum.Scenarios, ss)
+	}
+	return sum
+}
+
+// Notify calls each of the Run's notifiers with the Summary of the Run. Tools
+// that run `gdt` tests call Notify once all scenarios have run. Every
+// notifier is called even if an earlier one fails, and the returned error
+// joins an ErrNotify for each notifier that failed.
+func (r *Run) Notify(ctx context.Context) error {
+	if len(r.notifiers) == 0 {
+		return nil
+	}
+	sum := r.Summary()
+	errs := []error{}
+	for _, n := range r.notifiers {
+		if err := n.Notify(ctx, sum); err != nil {
+			errs = append(errs, api.NotifyFailed(notifierName(n), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifierName returns the name of the supplied Notifier for error messages.
+func notifierName(n Notifier) string {
+	if s, ok := n.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// Webhook is a Notifier that POSTs the Summary of a Run, encoded as JSON, to
+// a URL, e.g. a CI chatops endpoint.
+type Webhook struct {
+	// URL is the URL that the Summary is POSTed to.
+	URL string
+	// Header contains additional headers for the request, e.g. an
+	// Authorization header.
+	Header http.Header
+	// Client sends the request. The default is http.DefaultClient.
+	Client *http.Client
+}
+
+// String returns a description of the Webhook that omits the URL's path and
+// query, which often contain a token.
+func (w *Webhook) String() string {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return "webhook"
+	}
+	return "webhook to " + u.Host
+}
+
+// Notify POSTs the supplied Summary to the Webhook's URL. A response with a
+// status other than 2xx is an error.
+func (w *Webhook) Notify(ctx context.Context, sum Summary) error {
+	b, err := json.Marshal(sum)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, w.URL, bytes.NewReader(b),
+	)
+	if err != nil {
+		return err
+	}
+	for k, vals := range w.Header {
+		for _, v := range vals {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package run
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// DefaultDurationBuckets are the upper bounds, in seconds, of the buckets of
+// the test unit duration histogram of a MetricsRegistry.
+var DefaultDurationBuckets = []float64{
+	.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60,
+}
+
+// DefaultMetricsRegistry is the MetricsRegistry served by MetricsHandler.
+var DefaultMetricsRegistry = NewMetricsRegistry()
+
+// MetricsHandler returns an http.Handler that serves the metrics in
+// DefaultMetricsRegistry in the Prometheus text exposition format.
+func MetricsHandler() http.Handler {
+	return DefaultMetricsRegistry.Handler()
+}
+
+// MetricsRegistry accumulates metrics, labelled by scenario path, about the
+// test units of every Run that records to it with WithMetricsRegistry, e.g.
+// when `gdt` runs scenarios continuously as a synthetic monitoring daemon.
+type MetricsRegistry struct {
+	sync.Mutex
+	// buckets are the upper bounds, in seconds, of the buckets of the test
+	// unit duration histogram.
+	buckets []float64
+	// scenarios contains the metrics for each scenario, keyed by path.
+	scenarios map[string]*scenarioMetrics
+}
+
+// scenarioMetrics contains the metrics recorded for a single scenario.
+type scenarioMetrics struct {
+	// runs is the number of times the scenario was run.
+	runs int
+	// skips is the number of runs of the scenario that were skipped
+	// wholesale.
+	skips int
+	// failures is the number of runs of the scenario that had a failed test
+	// unit.
+	failures int
+	// results is the number of test units, keyed by result.
+	results map[string]int
+	// retries is the number of times test units were retried.
+	retries int
+	// buckets is the number of evaluated test units that took no longer than
+	// the corresponding upper bound in MetricsRegistry.buckets.
+	buckets []int
+	// durationSum is the total time, in seconds, of the evaluated test units.
+	durationSum float64
+	// durationCount is the number of evaluated test units.
+	durationCount int
+}
+
+// NewMetricsRegistry returns a new, empty MetricsRegistry whose test unit
+// duration histogram has the supplied bucket upper bounds, in seconds, or
+// DefaultDurationBuckets if none are supplied.
+func NewMetricsRegistry(buckets ...float64) *MetricsRegistry {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &MetricsRegistry{
+		buckets:   buckets,
+		scenarios: map[string]*scenarioMetrics{},
+	}
+}
+
+// scenario returns the metrics for the scenario with the supplied path,
+// adding them to the registry if necessary. The registry must be locked.
+func (m *MetricsRegistry) scenario(path string) *scenarioMetrics {
+	sm, ok := m.scenarios[path]
+	if !ok {
+		sm = &scenarioMetrics{
+			results: map[string]int{},
+			buckets: make([]int, len(m.buckets)),
+		}
+		m.scenarios[path] = sm
+	}
+	return sm
+}
+
+// startScenario records a run of the scenario with the supplied path.
+func (m *MetricsRegistry) startScenario(path string) {
+	m.Lock()
+	defer m.Unlock()
+	m.scenario(path).runs++
+}
+
+// skipScenario records that a run of the scenario with the supplied path was
+// skipped wholesale.
+func (m *MetricsRegistry) skipScenario(path string) {
+	m.Lock()
+	defer m.Unlock()
+	m.scenario(path).skips++
+}
+
+// record adds the supplied test unit result of the scenario with the supplied
+// path to the registry. newFailure is true if the result is the first
+// failure in the run of the scenario.
+func (m *MetricsRegistry) record(
+	path string,
+	tur TestUnitResult,
+	newFailure bool,
+) {
+	m.Lock()
+	defer m.Unlock()
+	sm := m.scenario(path)
+	if newFailure {
+		sm.failures++
+	}
+	sm.retries += tur.metrics.Retries
+	result := "passed"
+	switch {
+	case !tur.OK():
+		result = "failed"
+	case tur.Skipped():
+		result = "skipped"
+	case tur.Cached():
+		result = "cached"
+	}
+	sm.results[result]++
+	if result == "skipped" || result == "cached" {
+		return
+	}
+	secs := tur.Elapsed().Seconds()
+	for x, le := range m.buckets {
+		if secs <= le {
+			sm.buckets[x]++
+		}
+	}
+	sm.durationSum += secs
+	sm.durationCount++
+}
+
+// Handler returns an http.Handler that serves the registry's metrics in the
+// Prometheus text exposition format.
+func (m *MetricsRegistry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.Write(w)
+	})
+}
+
+// Write writes the registry's metrics to the supplied writer in the
+// Prometheus text exposition format.
+func (m *MetricsRegistry) Write(w io.Writer) error {
+	m.Lock()
+	defer m.Unlock()
+	bw := bufio.NewWriter(w)
+	paths := lo.Keys(m.scenarios)
+	slices.Sort(paths)
+
+	header(bw, "gdt_scenarios_total", "counter",
+		"Number of times each scenario was run.")
+	for _, path := range paths {
+		sample(bw, "gdt_scenarios_total", labels(path), m.scenarios[path].runs)
+	}
+	header(bw, "gdt_scenario_skips_total", "counter",
+		"Number of runs of each scenario that were skipped wholesale.")
+	for _, path := range paths {
+		sample(bw, "gdt_scenario_skips_total", labels(path),
+			m.scenarios[path].skips)
+	}
+	header(bw, "gdt_scenario_failures_total", "counter",
+		"Number of runs of each scenario that had a failed test unit.")
+	for _, path := range paths {
+		sample(bw, "gdt_scenario_failures_total", labels(path),
+			m.scenarios[path].failures)
+	}
+	header(bw, "gdt_specs_total", "counter",
+		"Number of test units run, by result.")
+	for _, path := range paths {
+		sm := m.scenarios[path]
+		for _, result := range []string{"passed", "failed", "skipped", "cached"} {
+			if n, ok := sm.results[result]; ok {
+				sample(bw, "gdt_specs_total",
+					labels(path, "result", result), n)
+			}
+		}
+	}
+	header(bw, "gdt_spec_retries_total", "counter",
+		"Number of times test units were retried because assertions failed.")
+	for _, path := range paths {
+		sample(bw, "gdt_spec_retries_total", labels(path),
+			m.scenarios[path].retries)
+	}
+	header(bw, "gdt_spec_duration_seconds", "histogram",
+		"Time taken to evaluate test units, including retries.")
+	for _, path := range paths {
+		sm := m.scenarios[path]
+		for x, le := range m.buckets {
+			sample(bw, "gdt_spec_duration_seconds_bucket",
+				labels(path, "le", formatFloat(le)), sm.buckets[x])
+		}
+		sample(bw, "gdt_spec_duration_seconds_bucket",
+			labels(path, "le", "+Inf"), sm.durationCount)
+		sample(bw, "gdt_spec_duration_seconds_sum", labels(path),
+			sm.durationSum)
+		sample(bw, "gdt_spec_duration_seconds_count", labels(path),
+			sm.durationCount)
+	}
+	return bw.Flush()
+}
+
+// header writes the HELP and TYPE lines for the metric with the supplied
+// name.
+func header(w io.Writer, name string, typ string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a sample line for the metric with the supplied name, labels
+// and value.
+func sample(w io.Writer, name string, labels string, value any) {
+	v := fmt.Sprint(value)
+	if f, ok := value.(float64); ok {
+		v = formatFloat(f)
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, v)
+}
+
+// labels returns the label set for the scenario with the supplied path and
+// the supplied additional label name and value pairs.
+func labels(path string, pairs ...string) string {
+	b := strings.Builder{}
+	b.WriteString(`scenario="` + escapeLabel(path) + `"`)
+	for x := 0; x+1 < len(pairs); x += 2 {
+		b.WriteString(`,` + pairs[x] + `="` + escapeLabel(pairs[x+1]) + `"`)
+	}
+	return b.String()
+}
+
+// escapeLabel escapes the supplied label value for the Prometheus text
+// exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats the supplied value for the Prometheus text exposition
+// format.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -1,7 +1,11 @@
 package run
 
 import (
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdt-dev/core/api"
@@ -12,15 +16,61 @@ import (
 // Run stores state of a test run when tests are executed with the `gdt` CLI
 // tool.
 type Run struct {
+	sync.RWMutex
+	// id identifies the Run and is recorded in the context, the OpenTelemetry
+	// spans and the TestUnitResults of the Run.
+	id string
 	// scenarioResults is a map, keyed by the Scenario path, of slices of
 	// TestUnitResult structs corresponding to the test specs in the scenario.
 	// There is guaranteed to be exactly the same number of TestUnitResults in
 	// the slice as scenarios in the scenario.
 	scenarioResults map[string][]TestUnitResult
+	// scenarioStarts is a map, keyed by the Scenario path, of the number of
+	// TestUnitResults stored for the Scenario before its latest execution
+	// started.
+	scenarioStarts map[string]int
+	// grace is how long cleanups and fixture stops may take after the Run is
+	// interrupted by a signal.
+	grace time.Duration
+	// interrupted is the signal that interrupted the Run, if any.
+	interrupted os.Signal
+	// deadline is the time by which the Run must finish, if any.
+	deadline time.Time
+	// artifactsDir is the directory that test unit artifacts are written
+	// to, if any.
+	artifactsDir string
+	// cacheDir is the directory that the results of passing test specs are
+	// cached in, if any.
+	cacheDir string
+	// cacheMaxAge is how long a cached pass is used for. Zero means cached
+	// passes do not expire.
+	cacheMaxAge time.Duration
+	// registry is the MetricsRegistry that the Run's test unit results are
+	// recorded to, if any.
+	registry *MetricsRegistry
+	// notifiers are called with the Summary of the Run by Notify.
+	notifiers []Notifier
 }
 
-// OK returns true if all Scenarios in the Run had all successful test units.
+// Deadline returns the time by which the Run must finish and true, or the zero
+// time and false if the Run has no deadline.
+func (r *Run) Deadline() (time.Time, bool) {
+	return r.deadline, !r.deadline.IsZero()
+}
+
+// ID returns the Run's identifier.
+func (r *Run) ID() string {
+	return r.id
+}
+
+// OK returns true if the Run was not interrupted and all Scenarios in the Run
+// had all successful test units.
 func (r *Run) OK() bool {
+	if r.Interrupted() != nil {
+		return false
+	}
+	r.RLock()
+	defer r.RUnlock()
 	return lo.EveryBy(lo.Values(r.scenarioResults), func(results []TestUnitResult) bool {
 		return lo.EveryBy(results, func(tur TestUnitResult) bool {
 			return tur.OK()
@@ -30,6 +80,8 @@ func (r *Run) OK() bool {
 
 // ScenarioPaths returns a sorted list of Scenario Paths.
 func (r *Run) ScenarioPaths() []string {
+	r.RLock()
+	defer r.RUnlock()
 	paths := lo.Keys(r.scenarioResults)
 	slices.Sort(paths)
 	return paths
@@ -38,7 +90,30 @@ func (r *Run) ScenarioPaths() []string {
 // ScenarioResults returns the set of TestUnitResults for a Scenario with the
 // supplied path.
 func (r *Run) ScenarioResults(path string) []TestUnitResult {
-	return r.scenarioResults[path]
+	r.RLock()
+	defer r.RUnlock()
+	return slices.Clone(r.scenarioResults[path])
+}
+
+// StartScenario records that an execution of the Scenario with the supplied
+// path has started. The results subsequently stored for the Scenario belong
+// to this execution.
+func (r *Run) StartScenario(path string) {
+	r.Lock()
+	defer r.Unlock()
+	r.scenarioStarts[path] = len(r.scenarioResults[path])
+	if r.registry != nil {
+		r.registry.startScenario(path)
+	}
+}
+
+// SkipScenario records that the started execution of the Scenario with the
+// supplied path was skipped wholesale, e.g. because of a `skip-if`
+// condition or an unsatisfied dependency.
+func (r *Run) SkipScenario(path string) {
+	if r.registry != nil {
+		r.registry.skipScenario(path)
+	}
 }
 
 // StoreResult stores a test unit result to the Run for the supplied test unit.
@@ -48,30 +123,180 @@ func (r *Run) StoreResult(
 	tu *testunit.TestUnit,
 	res *api.Result,
 ) {
-	if _, ok := r.scenarioResults[path]; !ok {
-		r.scenarioResults[path] = []TestUnitResult{}
+	r.storeResult(index, path, tu, res, false)
+}
+
+// StoreCachedResult stores a test unit result to the Run for the supplied
+// test unit, which was not evaluated because the test spec has a cached pass.
+func (r *Run) StoreCachedResult(
+	index int,
+	path string, // the Scenario.Path
+	tu *testunit.TestUnit,
+	res *api.Result,
+) {
+	r.storeResult(index, path, tu, res, true)
+}
+
+// storeResult stores a test unit result to the Run for the supplied test
+// unit. cached is true if the test spec was not evaluated because it has a
+// cached pass.
+func (r *Run) storeResult(
+	index int,
+	path string,
+	tu *testunit.TestUnit,
+	res *api.Result,
+	cached bool,
+) {
+	tur := TestUnitResult{
+		index:     index,
+		id:        tu.ID(),
+		stableID:  tu.StableID(),
+		runID:     r.id,
+		name:      tu.Name(),
+		elapsed:   tu.Elapsed(),
+		skipped:   tu.Skipped(),
+		cached:    cached,
+		failures:  res.Failures(),
+		detail:    tu.Detail(),
+		metrics:   *res.Metrics(),
+		measures:  res.Measurements(),
+		artifacts: r.persistArtifacts(tu.ID(), res.Artifacts()),
+		goldens:   tu.DirtyGoldens(),
+	}
+	r.Lock()
+	defer r.Unlock()
+	prior := r.scenarioResults[path]
+	r.scenarioResults[path] = append(prior, tur)
+	if r.registry != nil {
+		// Only the results of the Scenario's current execution decide
+		// whether this is the execution's first failure.
+		current := prior[min(r.scenarioStarts[path], len(prior)):]
+		failedBefore := lo.SomeBy(current, func(p TestUnitResult) bool {
+			return !p.OK()
+		})
+		r.registry.record(path, tur, !tur.OK() && !failedBefore)
+	}
+}
+
+// persistArtifacts writes the supplied artifacts of the test unit with the
+// supplied ID to the Run's artifacts directory, if any, and returns them with
+// their Path set. An artifact that cannot be written keeps an empty Path.
+func (r *Run) persistArtifacts(
+	id string,
+	artifacts []api.Artifact,
+) []api.Artifact {
+	if r.artifactsDir == "" || len(artifacts) == 0 {
+		return artifacts
+	}
+	dir := filepath.Join(r.artifactsDir, id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return artifacts
+	}
+	res := make([]api.Artifact, len(artifacts))
+	for x, a := range artifacts {
+		path := filepath.Join(dir, artifactFileName(a.Name))
+		if err := os.WriteFile(path, a.Content, 0o644); err == nil {
+			a.Path = path
+		}
+		res[x] = a
+	}
+	return res
+}
+
+// artifactFileName returns a file name for the artifact with the supplied
+// name that cannot escape the artifacts directory.
+func artifactFileName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
+
+// Metrics returns the sum of the Metrics of all test units in the Run.
+func (r *Run) Metrics() api.Metrics {
+	total := api.Metrics{}
+	for _, path := range r.ScenarioPaths() {
+		m := r.ScenarioMetrics(path)
+		total.Add(&m)
+	}
+	return total
+}
+
+// ScenarioMetrics returns the sum of the Metrics of the test units in the
+// Scenario with the supplied path.
+func (r *Run) ScenarioMetrics(path string) api.Metrics {
+	r.RLock()
+	defer r.RUnlock()
+	total := api.Metrics{}
+	for _, tur := range r.scenarioResults[path] {
+		total.Add(&tur.metrics)
+	}
+	return total
+}
+
+// Measurements returns a summary of the values recorded by all test units in
+// the Run for each distinct measurement name and unit.
+func (r *Run) Measurements() []api.MeasurementSummary {
+	r.RLock()
+	defer r.RUnlock()
+	all := []api.Measurement{}
+	for _, results := range r.scenarioResults {
+		for _, tur := range results {
+			all = append(all, tur.measures...)
+		}
+	}
+	return api.SummarizeMeasurements(all)
+}
+
+// ScenarioMeasurements returns a summary of the values recorded by the test
+// units in the Scenario with the supplied path for each distinct measurement
+// name and unit.
+func (r *Run) ScenarioMeasurements(path string) []api.MeasurementSummary {
+	r.RLock()
+	defer r.RUnlock()
+	all := []api.Measurement{}
+	for _, tur := range r.scenarioResults[path] {
+		all = append(all, tur.measures...)
 	}
-	r.scenarioResults[path] = append(
-		r.scenarioResults[path],
-		TestUnitResult{
-			index:    index,
-			name:     tu.Name(),
-			elapsed:  tu.Elapsed(),
-			skipped:  tu.Skipped(),
-			failures: res.Failures(),
-			detail:   tu.Detail(),
-		},
-	)
+	return api.SummarizeMeasurements(all)
+}
+
+// DirtyGoldens returns a sorted list of the paths to golden files that were
+// rewritten with new content by any test unit in the Run.
+func (r *Run) DirtyGoldens() []string {
+	r.RLock()
+	defer r.RUnlock()
+	paths := []string{}
+	for _, results := range r.scenarioResults {
+		for _, tur := range results {
+			paths = append(paths, tur.goldens...)
+		}
+	}
+	paths = lo.Uniq(paths)
+	slices.Sort(paths)
+	return paths
 }
 
 // TestUnitResult stores a summary of the test execution of a single test unit.
 type TestUnitResult struct {
 	// index is the 0-based index of the test unit within the test scenario.
 	index int
+	// id identifies the run of the test spec that the test unit executed.
+	id string
+	// stableID is the identifier, from the `id` field of the test spec, that
+	// stays the same across runs even if the test spec is renamed or
+	// reordered.
+	stableID string
+	// runID identifies the Run that the test unit was executed in.
+	runID string
 	// name is the short name of the test unit
 	name string
 	// skipped is true if the test unit was skipped
 	skipped bool
+	// cached is true if the test spec was not evaluated because it has a
+	// cached pass.
+	cached bool
 	// failures is the collection of assertion failures for the test spec that
 	// occurred during the run. this will NOT include RuntimeErrors.
 	failures []error
@@ -80,6 +305,17 @@ type TestUnitResult struct {
 	// detail is a buffer holding any log entries made during the run of the
 	// test spec.
 	detail string
+	// metrics contains counters describing the work performed by the test
+	// unit, including any retries.
+	metrics api.Metrics
+	// measures is the collection of named values recorded by the test unit,
+	// including any retries.
+	measures []api.Measurement
+	// artifacts is the collection of named content produced by the test unit.
+	artifacts []api.Artifact
+	// goldens is the collection of paths to golden files that were rewritten
+	// with new content by the test unit.
+	goldens []string
 }
 
 func (u TestUnitResult) OK() bool {
@@ -98,10 +334,35 @@ func (u TestUnitResult) Failures() []error {
 	return u.failures
 }
 
+// ID returns the identifier of the run of the test spec, which is also set
+// on the test spec's OpenTelemetry span and written to its debug output.
+func (u TestUnitResult) ID() string {
+	return u.id
+}
+
+// StableID returns the identifier from the `id` field of the test spec, or
+// an empty string if the test spec has no `id`. Unlike ID, Index and Name,
+// the StableID is the same in every run of the test spec, even if the test
+// spec is renamed or reordered.
+func (u TestUnitResult) StableID() string {
+	return u.stableID
+}
+
+// RunID returns the identifier of the Run that the test unit was executed in.
+func (u TestUnitResult) RunID() string {
+	return u.runID
+}
+
 func (u TestUnitResult) Skipped() bool {
 	return u.skipped
 }
 
+// Cached returns true if the test spec was not evaluated because an identical
+// test spec recently passed. See WithCache.
+func (u TestUnitResult) Cached() bool {
+	return u.cached
+}
+
 func (u TestUnitResult) Detail() string {
 	return u.detail
 }
@@ -109,3 +370,33 @@ func (u TestUnitResult) Detail() string {
 func (u TestUnitResult) Elapsed() time.Duration {
 	return u.elapsed
 }
+
+func (u TestUnitResult) Metrics() api.Metrics {
+	return u.metrics
+}
+
+// Measurements returns the named values recorded by the test unit, including
+// any retries.
+func (u TestUnitResult) Measurements() []api.Measurement {
+	return u.measures
+}
+
+// Artifacts returns the named content produced by the test unit, e.g. the
+// output of diagnostic commands run when an assertion failed.
+func (u TestUnitResult) Artifacts() []api.Artifact {
+	return u.artifacts
+}
+
+// Artifact returns the test unit's artifact with the supplied name and true,
+// or an empty Artifact and false if there is none.
+func (u TestUnitResult) Artifact(name string) (api.Artifact, bool) {
+	return lo.Find(u.artifacts, func(a api.Artifact) bool {
+		return a.Name == name
+	})
+}
+
+// DirtyGoldens returns the paths to golden files that were rewritten with new
+// content by the test unit when golden files were being updated.
+func (u TestUnitResult) DirtyGoldens() []string {
+	return u.goldens
+}
@@ -0,0 +1,84 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package run
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gdt-dev/core/api"
+)
+
+// DefaultShutdownGrace is the default amount of time that cleanups and fixture
+// stops may take after a Run is interrupted by a signal.
+const DefaultShutdownGrace = 10 * time.Second
+
+// TrapSignals returns a copy of the supplied context that is cancelled when
+// the process receives SIGINT or SIGTERM, with an ErrInterrupted as the
+// context's cause, and records the signal as having interrupted the Run. The
+// returned function stops trapping signals and must be called once the
+// context is no longer needed.
+func (r *Run) TrapSignals(
+	ctx context.Context,
+) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-ch:
+			r.Lock()
+			r.interrupted = sig
+			r.Unlock()
+			cancel(api.Interrupted(sig))
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(ch)
+		close(done)
+		cancel(nil)
+	}
+}
+
+// Interrupted returns the signal that interrupted the Run, or nil if the Run
+// was not interrupted.
+func (r *Run) Interrupted() os.Signal {
+	r.RLock()
+	defer r.RUnlock()
+	return r.interrupted
+}
+
+// ShutdownGrace returns how long cleanups and fixture stops may take after the
+// Run is interrupted by a signal.
+func (r *Run) ShutdownGrace() time.Duration {
+	return r.grace
+}
+
+// WithinGrace calls the supplied function and returns its error. If the Run
+// was interrupted, WithinGrace waits no longer than the Run's shutdown grace
+// period for the function to return and returns an ErrInterrupted if it did
+// not.
+func (r *Run) WithinGrace(fn func() error) error {
+	if r.Interrupted() == nil {
+		return fn()
+	}
+	ch := make(chan error, 1)
+	go func() {
+		ch <- fn()
+	}()
+	timer := time.NewTimer(r.grace)
+	defer timer.Stop()
+	select {
+	case err := <-ch:
+		return err
+	case <-timer.C:
+		return api.ShutdownGraceExceeded(r.grace)
+	}
+}
@@ -0,0 +1,329 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/gdt-dev/core/progress"
+)
+
+// DefaultWatchInterval is how often Watch checks scenario files for changes
+// when it polls them.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// watchDebounce is how long Watch waits for further file system notifications
+// before running changed scenarios, since editors often write a file in
+// several steps.
+const watchDebounce = 50 * time.Millisecond
+
+// ErrNoScenarioRunner is returned by Watch when no ScenarioRunner has been
+// registered, which happens when the scenario package is not imported.
+var ErrNoScenarioRunner = errors.New("no scenario runner registered")
+
+// ScenarioRunner parses the gdt test scenario at the supplied path and runs it
+// with the supplied Run.
+type ScenarioRunner func(ctx context.Context, path string, r *Run) error
+
+var (
+	runnerMu       sync.RWMutex
+	scenarioRunner ScenarioRunner
+)
+
+// RegisterScenarioRunner sets the ScenarioRunner that Watch uses to run
+// scenarios. The scenario package registers its runner when it is imported,
+// since the run package cannot import it.
+func RegisterScenarioRunner(fn ScenarioRunner) {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	scenarioRunner = fn
+}
+
+// Watcher runs gdt test scenarios again whenever their files change, for a
+// fast edit-test loop.
+type Watcher struct {
+	// Interval is how often scenario files are checked for changes when they
+	// are polled. The default is DefaultWatchInterval.
+	Interval time.Duration
+	// Poll checks scenario files for changes by polling their modification
+	// times and sizes instead of with file system notifications, e.g. for a
+	// network file system that does not support notifications.
+	Poll bool
+	// Options are the Options of the Run that each execution of a scenario
+	// is recorded to.
+	Options []Option
+}
+
+// Watch runs the gdt test scenarios in the supplied directories with a
+// Watcher with the default settings. See Watcher.Watch.
+func Watch(ctx context.Context, dirs ...string) error {
+	return (&Watcher{}).Watch(ctx, dirs...)
+}
+
+// Watch runs every gdt test scenario file, i.e. every file with a .yaml or
+// .yml extension, in the supplied directories and their subdirectories, and
+// then runs each scenario again whenever its file is created or modified,
+// until the supplied context is cancelled. Changes are detected with file
+// system notifications. If notifications are not available, or the Watcher's
+// Poll is true, scenario files are polled for changes to their modification
+// times and sizes every Interval instead.
+//
+// Each execution of a scenario is recorded to a new Run. Results are
+// reported through the progress functions registered in the context with
+// `gdtcontext.WithProgress()`: a ProgressScenarioChanged event is sent before
+// each execution and a ProgressScenarioFinished event after it, in addition to
+// the scenario's usual progress events. An error that stops a scenario from
+// running, e.g. a parse error, is reported in the ProgressScenarioFinished
+// event rather than stopping Watch.
+//
+// Watch returns nil once the context is cancelled, or an error if a
+// directory cannot be read.
+func (w *Watcher) Watch(ctx context.Context, dirs ...string) error {
+	runnerMu.RLock()
+	runner := scenarioRunner
+	runnerMu.RUnlock()
+	if runner == nil {
+		return ErrNoScenarioRunner
+	}
+	// The notification watcher is started before the scenarios are first
+	// run so that changes made while they run are not missed.
+	var fsw *fsnotify.Watcher
+	if !w.Poll {
+		fsw = newNotifyWatcher(dirs)
+	}
+	if fsw != nil {
+		defer fsw.Close()
+	}
+	seen, err := scanScenarios(dirs)
+	if err != nil {
+		return err
+	}
+	w.runChanged(ctx, runner, map[string]fileState{}, seen)
+	if fsw != nil {
+		return w.watchNotify(ctx, runner, fsw, dirs, seen)
+	}
+	return w.watchPoll(ctx, runner, dirs, seen)
+}
+
+// watchPoll runs the scenarios in the supplied directories whose files change
+// between polls, until the supplied context is cancelled.
+func (w *Watcher) watchPoll(
+	ctx context.Context,
+	runner ScenarioRunner,
+	dirs []string,
+	seen map[string]fileState,
+) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := scanScenarios(dirs)
+		if err != nil {
+			return err
+		}
+		w.runChanged(ctx, runner, seen, current)
+		seen = current
+	}
+}
+
+// watchNotify runs the scenarios in the supplied directories whose files
+// change, as notified by the supplied file system notification watcher, until
+// the supplied context is cancelled.
+func (w *Watcher) watchNotify(
+	ctx context.Context,
+	runner ScenarioRunner,
+	fsw *fsnotify.Watcher,
+	dirs []string,
+	seen map[string]fileState,
+) error {
+	// pending contains the scenario files with notifications since the
+	// changed scenarios were last run. rescan is true if notifications may
+	// have been missed, in which case every scenario file is checked.
+	pending := map[string]bool{}
+	rescan := false
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					// Scenario files in a new directory are found by
+					// scanning it, as they may have been created before the
+					// directory was watched.
+					if err := addNotifyDirs(fsw, ev.Name); err != nil {
+						return err
+					}
+					rescan = true
+				}
+			}
+			if isScenarioFile(ev.Name) {
+				pending[ev.Name] = true
+			}
+			debounce = time.After(watchDebounce)
+		case _, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			// e.g. the notification queue overflowed.
+			rescan = true
+			debounce = time.After(watchDebounce)
+		case <-debounce:
+			debounce = nil
+			current := maps.Clone(seen)
+			if rescan {
+				var err error
+				if current, err = scanScenarios(dirs); err != nil {
+					return err
+				}
+			} else {
+				for path := range pending {
+					info, err := os.Stat(path)
+					if err != nil || info.IsDir() {
+						delete(current, path)
+						continue
+					}
+					current[path] = newFileState(info)
+				}
+			}
+			clear(pending)
+			rescan = false
+			w.runChanged(ctx, runner, seen, current)
+			seen = current
+		}
+	}
+}
+
+// runChanged runs, in order of their paths, the scenarios whose files are in
+// current but not in prev or have a different fileState in prev.
+func (w *Watcher) runChanged(
+	ctx context.Context,
+	runner ScenarioRunner,
+	prev map[string]fileState,
+	current map[string]fileState,
+) {
+	changed := []string{}
+	for path, st := range current {
+		if before, ok := prev[path]; !ok || before != st {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	for _, path := range changed {
+		if ctx.Err() != nil {
+			return
+		}
+		progress.ScenarioChanged(ctx, path)
+		r := New(w.Options...)
+		err := runner(ctx, path, r)
+		progress.ScenarioFinished(ctx, path, err != nil || !r.OK(), err)
+	}
+}
+
+// newNotifyWatcher returns a file system notification watcher of the supplied
+// directories and their subdirectories, or nil if notifications are not
+// available, e.g. because the limit on the number of watches was reached.
+func newNotifyWatcher(dirs []string) *fsnotify.Watcher {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil
+	}
+	for _, dir := range dirs {
+		if err := addNotifyDirs(fsw, dir); err != nil {
+			_ = fsw.Close()
+			return nil
+		}
+	}
+	return fsw
+}
+
+// addNotifyDirs adds the supplied directory and its subdirectories to the
+// supplied file system notification watcher.
+func addNotifyDirs(fsw *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// The directory was removed while we were walking it.
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return fsw.Add(path)
+	})
+}
+
+// fileState is the modification time and size of a scenario file, which
+// change when the file is modified.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// newFileState returns the fileState of the file with the supplied info.
+func newFileState(info fs.FileInfo) fileState {
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// isScenarioFile returns true if the supplied path has the extension of a gdt
+// test scenario file.
+func isScenarioFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// scanScenarios returns the fileState of each scenario file in the supplied
+// directories and their subdirectories, keyed by path.
+func scanScenarios(dirs []string) (map[string]fileState, error) {
+	res := map[string]fileState{}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !isScenarioFile(path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					// The file was removed while we were scanning.
+					return nil
+				}
+				return err
+			}
+			res[path] = newFileState(info)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
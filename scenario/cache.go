@@ -0,0 +1,147 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/run"
+)
+
+// specDigest returns the hex-encoded SHA-256 digest of the supplied test spec
+// YAML node and scenario defaults YAML node, which may be nil.
+func specDigest(node *yaml.Node, defaults *yaml.Node) (string, error) {
+	h := sha256.New()
+	for _, n := range []*yaml.Node{node, defaults} {
+		if n == nil {
+			continue
+		}
+		b, err := yaml.Marshal(n)
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheKey returns the key of the test spec with the supplied index in the
+// Run's result cache, which combines the test spec's digest with the inputs
+// returned by cacheInputs. An empty string is returned if the Run has no
+// result cache or the test spec cannot be cached, e.g. because it sets
+// fixture state, a computed variable cannot be computed or the run data
+// cannot be encoded.
+func (s *Scenario) cacheKey(
+	ctx context.Context,
+	r *run.Run,
+	idx int,
+) string {
+	if !r.Caching() || idx >= len(s.digests) {
+		return ""
+	}
+	if len(s.Tests[idx].Base().Set) > 0 {
+		return ""
+	}
+	inputs, err := s.cacheInputs(ctx, idx)
+	if err != nil {
+		return ""
+	}
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(s.digests[idx]))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheInputs returns what the test spec with the supplied index depends on
+// besides its YAML and the scenario's defaults: the scenario's path, the
+// digests of the files the test spec references, the run data the test spec
+// is evaluated with, with computed variables resolved to their values, and
+// the scenario's fixtures, identified by their names and parameters, along
+// with the environment variables that the fixtures publish.
+func (s *Scenario) cacheInputs(
+	ctx context.Context,
+	idx int,
+) (map[string]any, error) {
+	files := map[string]string{}
+	if fr, ok := s.Tests[idx].(api.FileReferencer); ok {
+		for _, path := range fr.Files() {
+			digest, err := fileDigest(path)
+			if err != nil {
+				return nil, err
+			}
+			files[path] = digest
+		}
+	}
+	data := map[string]any{}
+	for k, v := range gdtcontext.Run(ctx) {
+		if l, ok := v.(*gdtcontext.Lazy); ok {
+			val, err := l.Value(ctx)
+			if err != nil {
+				return nil, err
+			}
+			v = val
+		}
+		data[k] = v
+	}
+	fixtures := make([]string, len(s.Fixtures))
+	for x, fname := range s.Fixtures {
+		fixtures[x] = s.fixtureParamsKey(fname)
+	}
+	return map[string]any{
+		"path":     s.Path,
+		"files":    files,
+		"data":     data,
+		"fixtures": fixtures,
+		"env":      gdtcontext.Env(ctx),
+	}, nil
+}
+
+// fileDigest returns the hex-encoded SHA-256 digest of the contents of the
+// file at the supplied path, or an empty string if the file does not exist.
+func fileDigest(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cacheableData returns true if the supplied run data, saved by a passing
+// test spec, can be written to the result cache. Run data containing any of
+// the context's secrets is never written to the result cache, so a test spec
+// that saves a secret is evaluated again on the next run.
+func cacheableData(ctx context.Context, data map[string]any) bool {
+	if !gdtcontext.HasSecrets(ctx) {
+		return true
+	}
+	for _, v := range data {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if gdtcontext.MaskSecrets(ctx, string(b)) != string(b) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/expr"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// Condition is a `skip-if` or `run-if` entry that is an expression instead of
+// a plugin test spec, e.g. `- expr: env.CI == "true"`. The condition passes
+// when the expression is true.
+type Condition struct {
+	api.Spec
+	// Expr is the condition's expression, which must evaluate to a bool.
+	Expr *expr.Expr
+}
+
+func (c *Condition) SetBase(b api.Spec) {
+	c.Spec = b
+}
+
+func (c *Condition) Base() *api.Spec {
+	return &c.Spec
+}
+
+func (c *Condition) Retry() *api.Retry {
+	return nil
+}
+
+func (c *Condition) Timeout() *api.Timeout {
+	return nil
+}
+
+// Eval evaluates the condition's expression, returning a Result with a
+// failure if the expression is false. An expression that cannot be evaluated
+// is a runtime error.
+func (c *Condition) Eval(ctx context.Context) (*api.Result, error) {
+	ok, err := c.Expr.EvalBool(pluginutil.ExprEnv(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("expr %s: %w", c.Expr, err)
+	}
+	if !ok {
+		return api.NewResult(
+			api.WithFailures(api.ExpressionFalse(c.Expr.String())),
+		), nil
+	}
+	return api.NewResult(), nil
+}
+
+// conditionFields are the fields of a `skip-if` or `run-if` entry that is an
+// expression.
+var conditionFields = []string{"expr", "name", "description"}
+
+// isCondition returns true if the supplied `skip-if` or `run-if` entry node
+// is an expression condition, i.e. a map with an `expr` field and otherwise
+// only a `name` and `description`.
+func isCondition(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	hasExpr := false
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if !slices.Contains(conditionFields, key) {
+			return false
+		}
+		hasExpr = hasExpr || key == "expr"
+	}
+	return hasExpr
+}
+
+// parseCondition parses the supplied expression condition node. A condition
+// without a name is named after its expression.
+func parseCondition(node *yaml.Node, idx int) (*Condition, error) {
+	base := api.Spec{}
+	if err := node.Decode(&base); err != nil {
+		return nil, err
+	}
+	base.Index = idx
+	c := &Condition{}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value != "expr" {
+			continue
+		}
+		valNode := node.Content[i+1]
+		if valNode.Kind != yaml.ScalarNode {
+			return nil, parse.ExpectedScalarAt(valNode)
+		}
+		e, err := expr.Parse(valNode.Value)
+		if err != nil {
+			return nil, parse.InvalidExpressionAt(valNode, valNode.Value, err)
+		}
+		c.Expr = e
+	}
+	if base.Name == "" && base.Description == "" {
+		base.Name = c.Expr.String()
+	}
+	c.SetBase(base)
+	return c, nil
+}
+
+// parseConditions parses the supplied `skip-if` or `run-if` sequence node.
+// Each entry is either an expression condition or a plugin test spec.
+func (s *Scenario) parseConditions(
+	node *yaml.Node,
+	defaults api.Defaults,
+	plugins []api.Plugin,
+) ([]api.Evaluable, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, parse.ExpectedSequenceAt(node)
+	}
+	res := []api.Evaluable{}
+	for idx, condNode := range node.Content {
+		if isCondition(condNode) {
+			c, err := parseCondition(condNode, idx)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, c)
+			continue
+		}
+		sp, err := s.parseSpec(condNode, idx, defaults, plugins)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, sp)
+	}
+	return res, nil
+}
+
+// extractAssertExpr removes the `expr` field from the `assert` field of the
+// supplied test spec node, so that plugins do not see it, and returns the
+// parsed expressions. The `expr` field is either a single expression or a
+// sequence of expressions. The returned node is a copy of the supplied node
+// if the field was removed.
+func extractAssertExpr(node *yaml.Node) (*yaml.Node, []*expr.Expr, error) {
+	if node.Kind != yaml.MappingNode {
+		return node, nil, nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		assertNode := node.Content[i+1]
+		if node.Content[i].Value != "assert" ||
+			assertNode.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j < len(assertNode.Content); j += 2 {
+			if assertNode.Content[j].Value != "expr" {
+				continue
+			}
+			exprs, err := pluginutil.ExprsAt(assertNode.Content[j+1])
+			if err != nil {
+				return nil, nil, err
+			}
+			assertCopy := *assertNode
+			assertCopy.Content = slices.Delete(
+				slices.Clone(assertNode.Content), j, j+2,
+			)
+			nodeCopy := *node
+			nodeCopy.Content = slices.Clone(node.Content)
+			if len(assertCopy.Content) == 0 {
+				nodeCopy.Content = slices.Delete(nodeCopy.Content, i, i+2)
+			} else {
+				nodeCopy.Content[i+1] = &assertCopy
+			}
+			return &nodeCopy, exprs, nil
+		}
+	}
+	return node, nil, nil
+}
+
+// assertExpr evaluates the supplied test spec's `assert.expr` expressions
+// once the test spec has been evaluated, adding a failure to the supplied
+// Result for each expression that is not true. The expressions see the run
+// data saved by the test spec.
+func assertExpr(ctx context.Context, spec api.Evaluable, res *api.Result) {
+	exprs := spec.Base().AssertExpr
+	if len(exprs) == 0 {
+		return
+	}
+	env := pluginutil.ExprEnv(gdtcontext.SetRun(ctx, res.Data()))
+	failures := res.Failures()
+	for _, e := range exprs {
+		ok, err := e.EvalBool(env)
+		switch {
+		case err != nil:
+			failures = append(failures, api.ExpressionFailed(e.String(), err))
+		case !ok:
+			failures = append(failures, api.ExpressionFalse(e.String()))
+		}
+	}
+	res.SetFailures(failures...)
+}
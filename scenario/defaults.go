@@ -5,8 +5,6 @@
 package scenario
 
 import (
-	"time"
-
 	"gopkg.in/yaml.v3"
 
 	"github.com/gdt-dev/core/api"
@@ -29,6 +27,9 @@ type Defaults struct {
 	// Retry has fields that represent the default retry behaviour for test
 	// specs in the scenario.
 	Retry *api.Retry `yaml:"retry,omitempty"`
+	// DependsMissing is what happens when one of the scenario's dependencies
+	// that does not have its own policy is not satisfied.
+	DependsMissing api.DependencyMissing `yaml:"-"`
 }
 
 func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
@@ -61,8 +62,7 @@ func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
 			default:
 				return parse.ExpectedScalarOrMapAt(valNode)
 			}
-			_, err := time.ParseDuration(to.After)
-			if err != nil {
+			if err := to.ValidateAt(valNode); err != nil {
 				return err
 			}
 			d.Timeout = to
@@ -74,19 +74,25 @@ func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
 			if err := valNode.Decode(&r); err != nil {
 				return parse.ExpectedRetryAt(valNode)
 			}
-			if r.Attempts != nil {
-				attempts := *r.Attempts
-				if attempts < 1 {
-					return parse.InvalidRetryAttemptsAt(valNode, attempts)
-				}
+			if err := r.ValidateAt(valNode); err != nil {
+				return err
 			}
-			if r.Interval != "" {
-				_, err := time.ParseDuration(r.Interval)
+			d.Retry = r
+		case "depends":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			for j := 0; j < len(valNode.Content); j += 2 {
+				depKeyNode := valNode.Content[j]
+				if depKeyNode.Value != "missing" {
+					return parse.UnknownFieldAt(depKeyNode.Value, depKeyNode)
+				}
+				missing, err := api.DependencyMissingAt(valNode.Content[j+1])
 				if err != nil {
 					return err
 				}
+				d.DependsMissing = missing
 			}
-			d.Retry = r
 		default:
 			continue
 		}
@@ -2,17 +2,29 @@ package scenario
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
 	"os/exec"
+	"os/user"
 	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"testing"
 
 	"github.com/Masterminds/semver/v3"
 
 	"github.com/gdt-dev/core/api"
 	gdtcontext "github.com/gdt-dev/core/context"
 	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/run"
+	"github.com/gdt-dev/core/testunit"
 )
 
 var defaultVersionSelectorArgs = []string{"-v"}
@@ -31,12 +43,15 @@ var (
 )
 
 // checkDependencies examines the scenario's set of dependencies and returns a
-// runtime error if any dependency isn't satisfied.
+// runtime error if any dependency isn't satisfied. The
+// ErrDependencyNotSatisfied of the first dependency that isn't satisfied and
+// whose missing policy is to skip is returned as notSatisfied, and the
+// scenario should be skipped.
 func (s *Scenario) checkDependencies(
 	ctx context.Context,
-) error {
+) (notSatisfied error, err error) {
 	if len(s.Depends) == 0 {
-		return nil
+		return nil, nil
 	}
 	ctx = gdtcontext.PushTrace(ctx, "scenario.check-deps")
 	defer func() {
@@ -44,11 +59,84 @@ func (s *Scenario) checkDependencies(
 	}()
 
 	for _, dep := range s.Depends {
-		if err := s.checkDependency(ctx, dep); err != nil {
-			return err
+		err := s.checkDependency(ctx, dep)
+		if errors.Is(err, api.ErrDependencyNotSatisfied) &&
+			s.dependencyMissing(dep) == api.DependencyMissingSkip {
+			debug.Printf(ctx, "%s: skipping scenario", err)
+			if notSatisfied == nil {
+				notSatisfied = err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
-	return nil
+	return notSatisfied, nil
+}
+
+// dependencyMissing returns the policy for when the supplied Dependency is
+// not satisfied, falling back to the scenario's default policy.
+func (s *Scenario) dependencyMissing(dep *api.Dependency) api.DependencyMissing {
+	if dep.Missing != "" {
+		return dep.Missing
+	}
+	if d := s.getDefaults(); d != nil && d.DependsMissing != "" {
+		return d.DependsMissing
+	}
+	return api.DependencyMissingFail
+}
+
+// skipDependencies skips the scenario's test specs because of the supplied
+// dependency that isn't satisfied. When running under the `gdt` CLI tool, a
+// skipped result is stored for each test spec so that the reason is reported.
+func (s *Scenario) skipDependencies(
+	ctx context.Context,
+	subject any,
+	notSatisfied error,
+) {
+	switch subject := subject.(type) {
+	case *testing.T:
+		subject.Skipf("%s. skipping test.", notSatisfied)
+	case *run.Run:
+		subject.StartScenario(s.Path)
+		subject.SkipScenario(s.Path)
+		for idx, spec := range s.Tests {
+			tu := testunit.New(
+				ctx,
+				testunit.WithStableID(spec.Base().ID),
+				testunit.WithName(
+					fmt.Sprintf("%s/%s", s.Title(), spec.Base().Title()),
+				),
+			)
+			tu.Skipf("%s. skipping test.", notSatisfied)
+			subject.StoreResult(idx, s.Path, tu, api.NewResult())
+		}
+	}
+}
+
+// checkSpecDependencies examines the supplied test spec's set of dependencies
+// and returns an ErrDependencyNotSatisfied for the first dependency that isn't
+// satisfied, and the test spec should be skipped, along with any other runtime
+// error.
+func (s *Scenario) checkSpecDependencies(
+	ctx context.Context,
+	spec api.Evaluable,
+) (notSatisfied error, err error) {
+	deps := spec.Base().Depends
+	if len(deps) == 0 {
+		return nil, nil
+	}
+	for _, dep := range deps {
+		err := s.checkDependency(ctx, dep)
+		if errors.Is(err, api.ErrDependencyNotSatisfied) {
+			return err, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
 }
 
 // checkDependency returns an error if the supplied Dependency isn't satisfied.
@@ -60,15 +148,58 @@ func (s *Scenario) checkDependency(
 		return nil
 	}
 
-	when := dep.When
-	if when != nil {
-		if when.OS != "" {
-			if !strings.EqualFold(runtime.GOOS, when.OS) {
-				return nil
-			}
+	applies, err := dependencyApplies(ctx, dep.When)
+	if err != nil {
+		return err
+	}
+	if !applies {
+		debug.Printf(ctx, "dependency %q does not apply", dep.Name)
+		return nil
+	}
+
+	if dep.Service != nil {
+		return checkService(ctx, dep)
+	}
+	if dep.File != "" || dep.Dir != "" {
+		return checkPath(ctx, dep)
+	}
+
+	cached, err := gdtcontext.CheckDependency(
+		ctx, programCacheKey(dep),
+		func() error {
+			return checkProgram(ctx, dep)
+		},
+	)
+	if cached {
+		debug.Printf(ctx, "dependency %q checked earlier in run", dep.Name)
+	}
+	return err
+}
+
+// programCacheKey returns the key that the result of checking the supplied
+// program Dependency is cached with in a test suite run. Dependencies on the
+// same program with the same version constraint and selector share a key.
+func programCacheKey(dep *api.Dependency) string {
+	key := dep.Name
+	if dv := dep.Version; dv != nil {
+		key += "|" + dv.Constraint
+		if sel := dv.Selector; sel != nil {
+			key += fmt.Sprintf(
+				"|%q|%s|%s|%s|%s",
+				sel.Args, sel.PackageManager, sel.Package,
+				sel.JSONPath, sel.Filter,
+			)
 		}
 	}
+	return key
+}
 
+// checkProgram returns an error if the supplied Dependency's program is not
+// in the PATH or does not satisfy the Dependency's version constraint.
+func checkProgram(
+	ctx context.Context,
+	dep *api.Dependency,
+) error {
 	binPath, err := exec.LookPath(dep.Name)
 	if err != nil {
 		execErr, ok := err.(*exec.Error)
@@ -86,7 +217,7 @@ func (s *Scenario) checkDependency(
 	if dv != nil {
 		vc := dv.SemVerConstraints
 		if vc != nil {
-			verStr, err := versionStringFromDependency(binPath, dv.Selector)
+			verStr, err := versionStringFromDependency(ctx, dep, binPath)
 			if err != nil {
 				return err
 			}
@@ -106,13 +237,200 @@ func (s *Scenario) checkDependency(
 	return nil
 }
 
-// versionStringFromDependency returns a version string from the supplied
-// dependency binary path and an optional version selector struct that
-// instructs us how to get the version from the binary.
+// dependencyApplies returns true if all of the supplied dependency conditions
+// hold. Environment variables published by fixtures are checked before those
+// of the gdt process.
+func dependencyApplies(
+	ctx context.Context,
+	when *api.DependencyConditions,
+) (bool, error) {
+	if when == nil {
+		return true, nil
+	}
+	if when.OS != "" && !strings.EqualFold(runtime.GOOS, when.OS) {
+		return false, nil
+	}
+	if when.Arch != "" && runtime.GOARCH != when.Arch {
+		return false, nil
+	}
+	env := gdtcontext.Env(ctx)
+	for _, name := range when.Env {
+		if _, found := env[name]; found {
+			continue
+		}
+		if _, found := os.LookupEnv(name); !found {
+			return false, nil
+		}
+	}
+	if when.KernelSemVerConstraints != nil {
+		ver, err := kernelVersion(ctx)
+		if err != nil {
+			return false, err
+		}
+		if !when.KernelSemVerConstraints.Check(ver) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// checkService returns an error if the supplied Dependency's service cannot
+// be reached within the service's timeout.
+func checkService(
+	ctx context.Context,
+	dep *api.Dependency,
+) error {
+	svc := dep.Service
+	timeout := svc.Timeout
+	if timeout == 0 {
+		timeout = api.DefaultDependencyServiceTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if svc.TCP != "" {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", svc.TCP)
+		if err != nil {
+			return api.DependencyNotSatisfiedService(dep, err)
+		}
+		_ = conn.Close()
+		debug.Printf(ctx, "service %q reachable", svc.Target())
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, svc.HTTP, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return api.DependencyNotSatisfiedService(dep, err)
+	}
+	_ = resp.Body.Close()
+	if svc.Status != 0 {
+		if resp.StatusCode != svc.Status {
+			return api.DependencyNotSatisfiedStatus(dep, resp.StatusCode)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return api.DependencyNotSatisfiedStatus(dep, resp.StatusCode)
+	}
+	debug.Printf(
+		ctx, "service %q reachable with status %d",
+		svc.Target(), resp.StatusCode,
+	)
+	return nil
+}
+
+// checkPath returns an error if the supplied Dependency's file or directory
+// does not exist or does not have the Dependency's mode or owner.
+func checkPath(
+	ctx context.Context,
+	dep *api.Dependency,
+) error {
+	path := dep.Path()
+	fi, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return api.DependencyNotSatisfiedPath(dep, "not found")
+		}
+		return fmt.Errorf("error checking for path %q: %w", path, err)
+	}
+	if dep.Dir != "" && !fi.IsDir() {
+		return api.DependencyNotSatisfiedPath(dep, "is not a directory")
+	}
+	if dep.File != "" && fi.IsDir() {
+		return api.DependencyNotSatisfiedPath(dep, "is a directory")
+	}
+	if dep.Mode != "" && fi.Mode().Perm() != dep.FileMode {
+		return api.DependencyNotSatisfiedPath(dep, fmt.Sprintf(
+			"has mode %#o, expected %#o", fi.Mode().Perm(), dep.FileMode,
+		))
+	}
+	if dep.Owner != "" {
+		want, err := lookupUID(dep.Owner)
+		if err != nil {
+			return err
+		}
+		got, err := fileOwner(fi)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return api.DependencyNotSatisfiedPath(dep, fmt.Sprintf(
+				"is owned by %d, expected %s", got, dep.Owner,
+			))
+		}
+	}
+	debug.Printf(ctx, "path %q satisfied", path)
+	return nil
+}
+
+// lookupUID returns the user ID of the supplied user name or numeric user ID.
+// A numeric user ID need not be in the user database.
+func lookupUID(name string) (uint32, error) {
+	if uid, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(uid), nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown user %q", name)
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("user %q has non-numeric ID %q", name, u.Uid)
+	}
+	return uint32(uid), nil
+}
+
+// kernelVersionRegex matches the leading numeric part of a kernel release,
+// e.g. "6.8.0" in "6.8.0-45-generic".
+var kernelVersionRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,2}`)
+
+// kernelVersion returns the version of the operating system kernel. Only the
+// leading numeric part of the kernel release is kept, since the rest, e.g.
+// "-45-generic", would make it a pre-release version that version constraints
+// never match.
+func kernelVersion(ctx context.Context) (*semver.Version, error) {
+	var release string
+	// Linux publishes the kernel release in procfs, so we avoid executing
+	// uname where we can.
+	if b, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		release = string(b)
+	} else {
+		out, err := exec.CommandContext(ctx, "uname", "-r").Output()
+		if err != nil {
+			return nil, fmt.Errorf("error determining kernel version: %w", err)
+		}
+		release = string(out)
+	}
+	release = strings.TrimSpace(release)
+	verStr := kernelVersionRegex.FindString(release)
+	if verStr == "" {
+		return nil, fmt.Errorf(
+			"unable to determine kernel version from %q", release,
+		)
+	}
+	ver, err := semver.NewVersion(verStr)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"unable to determine kernel version from %q: %w", release, err,
+		)
+	}
+	debug.Printf(ctx, "kernel version: %s", ver)
+	return ver, nil
+}
+
+// versionStringFromDependency returns a version string for the supplied
+// dependency, either by executing the dependency binary at the supplied path
+// or by querying a package manager, as instructed by the dependency's
+// optional version selector.
 func versionStringFromDependency(
+	ctx context.Context,
+	dep *api.Dependency,
 	binPath string,
-	selector *api.DependencyVersionSelector,
 ) (string, error) {
+	selector := dep.Version.Selector
 	if selector == nil {
 		selector = defaultVersionSelector
 	}
@@ -120,19 +438,124 @@ func versionStringFromDependency(
 		selector.Filter = defaultVersionSelectorFilter
 		selector.FilterRegex = regexp.MustCompile(defaultVersionSelectorFilter)
 	}
-	args := selector.Args
-	out, err := exec.CommandContext(context.TODO(), binPath, args...).Output()
-	if err != nil {
-		return "", err
+	var out string
+	if selector.PackageManager != "" {
+		var err error
+		out, err = packageVersion(ctx, dep, selector)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		args := selector.Args
+		b, err := exec.CommandContext(ctx, binPath, args...).Output()
+		if err != nil {
+			return "", err
+		}
+		out = string(b)
+	}
+	if selector.JSONPathExpr != nil {
+		var err error
+		out, err = selectJSONPath(out, selector)
+		if err != nil {
+			return "", err
+		}
 	}
 	if selector.FilterRegex != nil {
-		if !selector.FilterRegex.MatchString(string(out)) {
+		if !selector.FilterRegex.MatchString(out) {
 			return "", fmt.Errorf(
 				"unable to determine version string from %q using regex %q",
-				string(out), selector.FilterRegex.String(),
+				out, selector.FilterRegex.String(),
 			)
 		}
-		return selector.FilterRegex.FindString(string(out)), nil
+		return selector.FilterRegex.FindString(out), nil
+	}
+	return out, nil
+}
+
+// selectJSONPath returns the value that the supplied selector's JSONPath
+// expression selects from the supplied JSON output.
+func selectJSONPath(
+	out string,
+	selector *api.DependencyVersionSelector,
+) (string, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		return "", fmt.Errorf(
+			"unable to determine version string from %q: %w", out, err,
+		)
+	}
+	nodes := selector.JSONPathExpr.Select(doc)
+	if len(nodes) == 0 {
+		return "", fmt.Errorf(
+			"unable to determine version string from %q using JSONPath %q",
+			out, selector.JSONPath,
+		)
+	}
+	if verStr, ok := nodes[0].(string); ok {
+		return verStr, nil
+	}
+	return fmt.Sprintf("%v", nodes[0]), nil
+}
+
+// packageManagerCommands are the command-lines, keyed by package manager, that
+// output the version of the package named by the final argument.
+var packageManagerCommands = map[string][]string{
+	"dpkg": {"dpkg-query", "--show", "--showformat=${Version}"},
+	"rpm":  {"rpm", "--query", "--queryformat", "%{VERSION}"},
+	"brew": {"brew", "list", "--versions"},
+}
+
+// dpkgEpochRegex matches the epoch prefix of a Debian package version, e.g.
+// "1:" in "1:9.4-3ubuntu6".
+var dpkgEpochRegex = regexp.MustCompile(`^[0-9]+:`)
+
+// packageVersion returns the version of the package that the supplied
+// selector names, or the dependency's name if the selector does not name a
+// package, by querying the selector's package manager. A package that is not
+// installed does not satisfy the dependency.
+func packageVersion(
+	ctx context.Context,
+	dep *api.Dependency,
+	selector *api.DependencyVersionSelector,
+) (string, error) {
+	pkg := selector.Package
+	if pkg == "" {
+		pkg = dep.Name
+	}
+	pm := selector.PackageManager
+	cmdline := append(slices.Clone(packageManagerCommands[pm]), pkg)
+	b, err := exec.CommandContext(ctx, cmdline[0], cmdline[1:]...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			debug.Printf(
+				ctx, "%s: package %q not installed: %s",
+				pm, pkg, strings.TrimSpace(string(exitErr.Stderr)),
+			)
+			return "", api.DependencyNotSatisfied(dep)
+		}
+		return "", fmt.Errorf(
+			"error querying %s for package %q: %w", pm, pkg, err,
+		)
+	}
+	out := strings.TrimSpace(string(b))
+	switch pm {
+	case "dpkg":
+		// Debian package versions may carry an epoch and a Debian revision,
+		// e.g. "1:9.4-3ubuntu6", around the upstream version. The revision
+		// would otherwise make the version a pre-release version.
+		out = dpkgEpochRegex.ReplaceAllString(out, "")
+		if i := strings.LastIndex(out, "-"); i > 0 {
+			out = out[:i]
+		}
+	case "brew":
+		// brew outputs the package name followed by each installed version,
+		// e.g. "jq 1.6 1.7.1", so we keep the latest version.
+		fields := strings.Fields(out)
+		if len(fields) > 0 {
+			out = fields[len(fields)-1]
+		}
 	}
-	return string(out), nil
+	debug.Printf(ctx, "%s: package %q version: %s", pm, pkg, out)
+	return out, nil
 }
@@ -0,0 +1,65 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+)
+
+// parseVarNames parses the supplied `exports` or `imports` sequence node of
+// variable names.
+func parseVarNames(node *yaml.Node) ([]string, error) {
+	names := []string{}
+	for _, nameNode := range node.Content {
+		if nameNode.Kind != yaml.ScalarNode || nameNode.Value == "" {
+			return nil, parse.ExpectedScalarAt(nameNode)
+		}
+		names = append(names, nameNode.Value)
+	}
+	return names, nil
+}
+
+// withImports returns a copy of the supplied context with the variables in
+// the scenario's Imports, exported by earlier scenarios in the test suite run,
+// seeded into the run data, or an ErrVariable if any of them has not been
+// exported.
+func (s *Scenario) withImports(ctx context.Context) (context.Context, error) {
+	for _, name := range s.Imports {
+		var err error
+		ctx, err = gdtcontext.Import(ctx, name)
+		if err != nil {
+			return ctx, api.ImportFailed(name, err)
+		}
+		debug.Printf(ctx, "imports: %s", name)
+	}
+	return ctx, nil
+}
+
+// export stores the values of the variables in the scenario's Exports from
+// the supplied context's run data for later scenarios in the test suite run
+// to import. Variables without a value, e.g. because the test spec that saves
+// them did not run, are not exported, and nothing is exported when the
+// scenario is not run as part of a test suite.
+func (s *Scenario) export(ctx context.Context) {
+	for _, name := range s.Exports {
+		val, found, err := gdtcontext.RunValue(ctx, name)
+		if err != nil || !found {
+			debug.Printf(ctx, "exports: %s has no value. not exporting.", name)
+			continue
+		}
+		if err := gdtcontext.Export(ctx, name, val); err != nil {
+			debug.Printf(ctx, "exports: not exporting %s: %s", name, err)
+			continue
+		}
+		debug.Printf(ctx, "exports: %s", name)
+	}
+}
@@ -0,0 +1,554 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/progress"
+	"github.com/gdt-dev/core/tracing"
+)
+
+// fixtureRef is a started fixture and the number of scopes using it. The
+// fixture is started, restarted and stopped without holding startedFixtures'
+// lock, so the fixtureRef records whether the fixture is ready to use. Its
+// fields are guarded by startedFixtures' lock.
+type fixtureRef struct {
+	// base is the fixture as registered with the context or returned from
+	// api.ConfigurableFixture.Configure.
+	base api.Fixture
+	fix  api.FixtureV2
+	refs int
+	// cancel cancels the context the fixture was started with.
+	cancel context.CancelFunc
+	// checker checks the fixture's health, if the fixture implements
+	// api.HealthChecker.
+	checker api.HealthChecker
+	// ready is closed once the fixture has started or restarted, or has
+	// failed to. It is replaced by a new channel while the fixture restarts.
+	ready chan struct{}
+	// err is the error from starting or restarting the fixture. A fixtureRef
+	// with an error has been removed from startedFixtures.
+	err error
+	// stopped is closed once the fixture has stopped after the last scope
+	// using it released it. It is nil while the fixture is in use.
+	stopped chan struct{}
+}
+
+// startedFixtures contains the started fixtures, keyed by fixtureKey.
+var startedFixtures = struct {
+	sync.Mutex
+	refs map[string]*fixtureRef
+}{
+	refs: map[string]*fixtureRef{},
+}
+
+// fixtureScope returns the scope of the fixture with the supplied name.
+func (s *Scenario) fixtureScope(fname string) api.FixtureScope {
+	if scope, found := s.FixtureScopes[strings.ToLower(fname)]; found {
+		return scope
+	}
+	return api.FixtureScopeScenario
+}
+
+// registeredFixture returns the fixture registered with the supplied name,
+// constructing it first if it was registered with a factory.
+func registeredFixture(
+	ctx context.Context,
+	fixtures map[string]api.Fixture,
+	fname string,
+) (api.Fixture, error) {
+	fix, found := fixtures[strings.ToLower(fname)]
+	if !found {
+		return nil, api.RequiredFixtureMissing(fname)
+	}
+	if lazy, ok := fix.(*api.LazyFixture); ok {
+		built, err := lazy.Build(ctx)
+		if err != nil {
+			return nil, api.FixtureStartFailed(fname, err)
+		}
+		return built, nil
+	}
+	return fix, nil
+}
+
+// fixtureKey returns the key that the fixture with the supplied name is
+// tracked under in startedFixtures: the identity of the fixture instance
+// registered with the context under that name, followed by the fixture's
+// fixtureParamsKey. Scopes that use the same registered fixture with the same
+// parameters share the started fixture, while fixtures registered under the
+// same name with different contexts, e.g. by parallel tests, do not.
+func (s *Scenario) fixtureKey(ctx context.Context, fname string) string {
+	fix := gdtcontext.Fixtures(ctx)[strings.ToLower(fname)]
+	return fixtureID(fix) + "/" + s.fixtureParamsKey(fname)
+}
+
+// fixtureID returns an identifier for the supplied registered fixture
+// instance. Fixtures are usually registered as pointers, which are identified
+// by their address.
+func fixtureID(fix api.Fixture) string {
+	if fix == nil {
+		return ""
+	}
+	if v := reflect.ValueOf(fix); v.Kind() == reflect.Pointer {
+		return fmt.Sprintf("%T@%#x", fix, v.Pointer())
+	}
+	return fmt.Sprintf("%T:%v", fix, fix)
+}
+
+// fixtureParamsKey returns the lowercased name of the fixture with the
+// supplied name and, if the scenario has parameters for the fixture, the JSON
+// encoding of the parameters. Maps are encoded sorted by key, so equal
+// parameters, including nested ones, have equal keys.
+func (s *Scenario) fixtureParamsKey(fname string) string {
+	lookup := strings.ToLower(fname)
+	params, found := s.FixtureParams[lookup]
+	if !found {
+		return lookup
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprintf("%s:%v", lookup, params)
+	}
+	return lookup + ":" + string(b)
+}
+
+// fixture returns the fixture to start for the supplied name. If the scenario
+// has parameters for the fixture, the fixture is configured with them.
+func (s *Scenario) fixture(
+	ctx context.Context,
+	fixtures map[string]api.Fixture,
+	fname string,
+) (api.Fixture, error) {
+	fix, err := registeredFixture(ctx, fixtures, fname)
+	if err != nil {
+		return nil, err
+	}
+	params, found := s.FixtureParams[strings.ToLower(fname)]
+	if !found {
+		return fix, nil
+	}
+	cfix, ok := fix.(api.ConfigurableFixture)
+	if !ok {
+		return nil, api.FixtureNotConfigurable(fname)
+	}
+	configured, err := cfix.Configure(params)
+	if err != nil {
+		return nil, api.FixtureConfigInvalid(fname, err)
+	}
+	return configured, nil
+}
+
+// startedFixture returns the started fixture with the supplied key, waiting
+// for the fixture to finish starting or restarting, or nil if the fixture is
+// not started.
+func startedFixture(key string) *fixtureRef {
+	startedFixtures.Lock()
+	ref, found := startedFixtures.refs[key]
+	if !found {
+		startedFixtures.Unlock()
+		return nil
+	}
+	ready := ref.ready
+	startedFixtures.Unlock()
+	<-ready
+	startedFixtures.Lock()
+	defer startedFixtures.Unlock()
+	if ref.err != nil || ref.stopped != nil {
+		return nil
+	}
+	return ref
+}
+
+// fixtureAs returns the supplied fixture as a T, checking both the fixture
+// itself and, for a fixture adapted from an api.FixtureV2, the FixtureV2.
+func fixtureAs[T any](fix api.Fixture) (T, bool) {
+	if t, ok := fix.(T); ok {
+		return t, true
+	}
+	t, ok := api.AsFixtureV2(fix).(T)
+	return t, ok
+}
+
+// startFixture starts the supplied fixture, returning an ErrFixtureStart if
+// the fixture fails to start or, when the scenario has a start timeout for
+// the fixture, does not start within that timeout. The context the fixture
+// is started with is cancelled by the returned function, which is called when
+// the fixture stops, or when the start timeout is exceeded.
+func (s *Scenario) startFixture(
+	ctx context.Context,
+	fix api.FixtureV2,
+	fname string,
+) (_ context.CancelFunc, err error) {
+	progress.FixtureStarting(ctx, fname)
+	ctx, span := tracing.Start(
+		ctx, tracing.SpanFixtureStart, tracing.AttrFixture.String(fname),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	startCtx, cancel := context.WithCancel(ctx)
+	timeout, found := s.FixtureTimeouts[strings.ToLower(fname)]
+	if !found {
+		if err := fix.Start(startCtx); err != nil {
+			cancel()
+			return nil, api.FixtureStartFailed(fname, err)
+		}
+		return cancel, nil
+	}
+	ch := make(chan error, 1)
+	go func() {
+		ch <- fix.Start(startCtx)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-ch:
+		if err != nil {
+			cancel()
+			return nil, api.FixtureStartFailed(fname, err)
+		}
+		return cancel, nil
+	case <-timer.C:
+		cancel()
+		// Clean up anything the fixture started once Start returns.
+		go func() {
+			if err := <-ch; err == nil {
+				_ = fix.Stop(ctx)
+			}
+		}()
+		return nil, api.FixtureStartTimeout(fname, timeout)
+	}
+}
+
+// acquireFixture starts the fixture with the supplied name unless another
+// scope has already started it, and returns a function that releases the
+// fixture. The fixture is stopped when the last scope using it releases it,
+// and the release function returns an ErrFixtureStop if the fixture fails to
+// stop. The fixture is started and stopped without holding startedFixtures'
+// lock, so that a slow fixture does not hold up other fixtures. Scopes that
+// acquire the fixture while it is starting wait for it to start, and scopes
+// that acquire it while it is stopping wait for it to stop and start it anew.
+func (s *Scenario) acquireFixture(
+	ctx context.Context,
+	fixtures map[string]api.Fixture,
+	fname string,
+) (func() error, error) {
+	key := s.fixtureKey(ctx, fname)
+	startedFixtures.Lock()
+	for {
+		ref, found := startedFixtures.refs[key]
+		if !found {
+			break
+		}
+		if ref.stopped != nil {
+			stopped := ref.stopped
+			startedFixtures.Unlock()
+			<-stopped
+			startedFixtures.Lock()
+			continue
+		}
+		ref.refs++
+		ready := ref.ready
+		startedFixtures.Unlock()
+		<-ready
+		startedFixtures.Lock()
+		err := ref.err
+		startedFixtures.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return s.releaseFixture(ctx, key, fname, ref), nil
+	}
+	ref := &fixtureRef{refs: 1, ready: make(chan struct{})}
+	startedFixtures.refs[key] = ref
+	startedFixtures.Unlock()
+
+	fix, err := s.fixture(ctx, fixtures, fname)
+	var v2 api.FixtureV2
+	var cancel context.CancelFunc
+	if err == nil {
+		v2 = api.AsFixtureV2(fix)
+		cancel, err = s.startFixture(ctx, v2, fname)
+	}
+	startedFixtures.Lock()
+	if err != nil {
+		ref.err = err
+		delete(startedFixtures.refs, key)
+	} else {
+		ref.base, ref.fix, ref.cancel = fix, v2, cancel
+		if hc, ok := fixtureAs[api.HealthChecker](fix); ok {
+			ref.checker = hc
+		}
+	}
+	close(ref.ready)
+	startedFixtures.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	debug.Printf(ctx, "fixture: %s started", fname)
+	return s.releaseFixture(ctx, key, fname, ref), nil
+}
+
+// releaseFixture returns the function that releases the supplied started
+// fixture for a scope that acquired it. The last scope to release the
+// fixture stops it.
+func (s *Scenario) releaseFixture(
+	ctx context.Context,
+	key string,
+	fname string,
+	ref *fixtureRef,
+) func() error {
+	return func() error {
+		startedFixtures.Lock()
+		ref.refs--
+		if ref.refs > 0 || ref.err != nil {
+			// The fixture is still in use, or has failed to restart and
+			// is already stopped.
+			startedFixtures.Unlock()
+			return nil
+		}
+		ref.stopped = make(chan struct{})
+		cancel := ref.cancel
+		startedFixtures.Unlock()
+		defer func() {
+			startedFixtures.Lock()
+			if startedFixtures.refs[key] == ref {
+				delete(startedFixtures.refs, key)
+			}
+			startedFixtures.Unlock()
+			close(ref.stopped)
+		}()
+		defer cancel()
+		stopCtx, span := tracing.Start(
+			ctx, tracing.SpanFixtureStop, tracing.AttrFixture.String(fname),
+		)
+		err := ref.fix.Stop(stopCtx)
+		tracing.End(span, err)
+		if err != nil {
+			return api.FixtureStopFailed(fname, err)
+		}
+		debug.Printf(ctx, "fixture: %s stopped", fname)
+		return nil
+	}
+}
+
+// acquireFixtures acquires, in order, each of the scenario's fixtures that
+// has one of the supplied scopes, and returns a function that releases them
+// in reverse order, joining any errors from stopping them. If any fixture
+// cannot be acquired, the fixtures acquired so far are released.
+func (s *Scenario) acquireFixtures(
+	ctx context.Context,
+	scopes ...api.FixtureScope,
+) (func() error, error) {
+	fixtures := gdtcontext.Fixtures(ctx)
+	releases := []func() error{}
+	release := func() error {
+		errs := []error{}
+		for x := len(releases) - 1; x >= 0; x-- {
+			if err := releases[x](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	for _, fname := range s.Fixtures {
+		if !lo.Contains(scopes, s.fixtureScope(fname)) {
+			continue
+		}
+		r, err := s.acquireFixture(ctx, fixtures, fname)
+		if err != nil {
+			if relErr := release(); relErr != nil {
+				return nil, errors.Join(err, relErr)
+			}
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
+
+// AcquireFixtures starts each of the scenario's fixtures with the supplied
+// scope that is not already started, and returns a function that releases
+// them. A fixture is stopped once every scope that acquired it has released
+// it, and the release function returns any errors from stopping fixtures. For example, a test suite acquires its scenarios' fixtures with
+// api.FixtureScopeSuite before running the scenarios so that those fixtures
+// are started once for the whole test suite.
+func (s *Scenario) AcquireFixtures(
+	ctx context.Context,
+	scope api.FixtureScope,
+) (func() error, error) {
+	return s.acquireFixtures(ctx, scope)
+}
+
+// checkFixtures checks the health of each of the scenario's started fixtures
+// that has a health policy and implements api.HealthChecker. Spec-scoped
+// fixtures are started anew for each test spec and are not checked.
+func (s *Scenario) checkFixtures(ctx context.Context) error {
+	for _, fname := range s.Fixtures {
+		policy, found := s.FixtureHealths[strings.ToLower(fname)]
+		if !found || s.fixtureScope(fname) == api.FixtureScopeSpec {
+			continue
+		}
+		if err := s.checkFixture(ctx, s.fixtureKey(ctx, fname), fname, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFixture checks the health of the started fixture with the supplied key
+// and, if it is unhealthy, either returns an ErrFixtureDied or restarts it,
+// depending on the supplied health policy. The fixture is checked and
+// restarted without holding startedFixtures' lock. Scopes that use the
+// fixture while it restarts wait for the restart, and if the restart fails
+// the fixture is removed from startedFixtures so that it is started anew the
+// next time it is acquired.
+func (s *Scenario) checkFixture(
+	ctx context.Context,
+	key string,
+	fname string,
+	policy api.FixtureHealth,
+) error {
+	ref := startedFixture(key)
+	if ref == nil {
+		return nil
+	}
+	startedFixtures.Lock()
+	checker, ready := ref.checker, ref.ready
+	startedFixtures.Unlock()
+	if checker == nil {
+		return nil
+	}
+	unhealthy := checker.Healthy(ctx)
+	if unhealthy == nil {
+		return nil
+	}
+	if policy != api.FixtureHealthRestart {
+		return api.FixtureDied(fname, unhealthy)
+	}
+	startedFixtures.Lock()
+	if ref.ready != ready {
+		// Another scope has restarted the fixture since it was checked.
+		ready = ref.ready
+		startedFixtures.Unlock()
+		<-ready
+		startedFixtures.Lock()
+		defer startedFixtures.Unlock()
+		return ref.err
+	}
+	ref.ready = make(chan struct{})
+	cancel := ref.cancel
+	startedFixtures.Unlock()
+
+	debug.Warnf(ctx, "fixture: %s unhealthy, restarting: %s", fname, unhealthy)
+	if err := ref.fix.Stop(ctx); err != nil {
+		debug.Errorf(ctx, "fixture: %s failed to stop: %s", fname, err)
+	}
+	cancel()
+	cancel, err := s.startFixture(ctx, ref.fix, fname)
+	startedFixtures.Lock()
+	if err != nil {
+		err = api.FixtureRestartFailed(fname, unhealthy, err)
+		ref.err = err
+		if startedFixtures.refs[key] == ref {
+			delete(startedFixtures.refs, key)
+		}
+	} else {
+		ref.cancel = cancel
+	}
+	close(ref.ready)
+	startedFixtures.Unlock()
+	if err != nil {
+		return err
+	}
+	debug.Printf(ctx, "fixture: %s restarted", fname)
+	return nil
+}
+
+// setFixtureState applies the supplied `set` field of a test spec, keyed by
+// fixture name and then state path, to the fixtures' state. The started
+// instance of a fixture is used when the scenario has started the fixture,
+// otherwise the fixture registered with the context is used.
+func (s *Scenario) setFixtureState(
+	ctx context.Context,
+	set map[string]map[string]interface{},
+) error {
+	fixtures := gdtcontext.Fixtures(ctx)
+	fnames := lo.Keys(set)
+	slices.Sort(fnames)
+	for _, fname := range fnames {
+		var fix api.Fixture
+		if ref := startedFixture(s.fixtureKey(ctx, fname)); ref != nil {
+			fix = ref.base
+		} else {
+			var err error
+			if fix, err = registeredFixture(ctx, fixtures, fname); err != nil {
+				return err
+			}
+		}
+		setter, ok := fixtureAs[api.StateSetter](fix)
+		if !ok {
+			return api.FixtureStateNotSettable(fname)
+		}
+		state := set[fname]
+		paths := lo.Keys(state)
+		slices.Sort(paths)
+		for _, path := range paths {
+			if err := setter.SetState(path, state[path]); err != nil {
+				return api.FixtureStateSetFailed(fname, path, err)
+			}
+			debug.Printf(ctx, "fixture: %s state %s set", fname, path)
+		}
+	}
+	return nil
+}
+
+// withFixtures returns the context with the environment variables published
+// by each of the scenario's started fixtures that has one of the supplied
+// scopes and implements api.EnvProvider, and with the Clock of any such
+// fixture that implements api.ClockProvider.
+func (s *Scenario) withFixtures(
+	ctx context.Context,
+	scopes ...api.FixtureScope,
+) context.Context {
+	env := map[string]string{}
+	for _, fname := range s.Fixtures {
+		if !lo.Contains(scopes, s.fixtureScope(fname)) {
+			continue
+		}
+		ref := startedFixture(s.fixtureKey(ctx, fname))
+		if ref == nil {
+			continue
+		}
+		if ep, ok := fixtureAs[api.EnvProvider](ref.base); ok {
+			for k, v := range ep.Env() {
+				debug.Printf(ctx, "fixture: %s env: %s=%s", fname, k, v)
+				env[k] = v
+			}
+		}
+		if cp, ok := fixtureAs[api.ClockProvider](ref.base); ok {
+			debug.Printf(ctx, "fixture: %s provides clock", fname)
+			ctx = gdtcontext.SetClock(ctx, cp.Clock())
+		}
+	}
+	if len(env) == 0 {
+		return ctx
+	}
+	return gdtcontext.SetEnv(ctx, env)
+}
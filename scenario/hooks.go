@@ -0,0 +1,102 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// info returns the api.ScenarioInfo passed to the plugins' ScenarioHooks for
+// the run of the scenario with the supplied context.
+func (s *Scenario) info(ctx context.Context) api.ScenarioInfo {
+	return api.ScenarioInfo{
+		ID:    gdtcontext.ScenarioID(ctx),
+		RunID: gdtcontext.RunID(ctx),
+		Path:  s.Path,
+		Title: s.Title(),
+		Tests: s.Tests,
+	}
+}
+
+// hooks returns the api.ScenarioHooks for the plugins used by the scenario's
+// test specs, in the order in which each plugin is first used.
+func (s *Scenario) hooks() []api.ScenarioHooks {
+	seen := map[api.Plugin]bool{}
+	res := []api.ScenarioHooks{}
+	specs := append([]api.Evaluable{}, s.SkipIf...)
+	specs = append(specs, s.RunIf...)
+	specs = append(specs, s.Tests...)
+	for _, sp := range specs {
+		p := sp.Base().Plugin
+		if p == nil || seen[p] {
+			continue
+		}
+		seen[p] = true
+		if h, ok := p.(api.ScenarioHooks); ok {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+// beforeScenario calls BeforeScenario on each of the supplied hooks and
+// returns the hooks that succeeded. Callers should pass the returned hooks to
+// afterScenario even when an error is returned.
+func (s *Scenario) beforeScenario(
+	ctx context.Context,
+	hooks []api.ScenarioHooks,
+) ([]api.ScenarioHooks, error) {
+	called := []api.ScenarioHooks{}
+	info := s.info(ctx)
+	for _, h := range hooks {
+		if err := h.BeforeScenario(ctx, info); err != nil {
+			name := h.(api.Plugin).Info().Name
+			return called, api.PluginHookFailed(name, "BeforeScenario", err)
+		}
+		called = append(called, h)
+	}
+	return called, nil
+}
+
+// afterScenario calls AfterScenario on each of the supplied hooks in reverse
+// order, passing the supplied results of the scenario's test specs.
+func (s *Scenario) afterScenario(
+	ctx context.Context,
+	hooks []api.ScenarioHooks,
+	results []api.SpecResult,
+) error {
+	errs := []error{}
+	info := s.info(ctx)
+	info.Results = results
+	for x := len(hooks) - 1; x >= 0; x-- {
+		h := hooks[x]
+		if err := h.AfterScenario(ctx, info); err != nil {
+			name := h.(api.Plugin).Info().Name
+			errs = append(
+				errs, api.PluginHookFailed(name, "AfterScenario", err),
+			)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// specResult returns the api.SpecResult for the scenario's test spec with the
+// supplied index.
+func (s *Scenario) specResult(
+	idx int,
+	skipped bool,
+	res *api.Result,
+) api.SpecResult {
+	return api.SpecResult{
+		Index:    idx,
+		Title:    s.Tests[idx].Base().Title(),
+		Skipped:  skipped,
+		Failures: res.Failures(),
+	}
+}
@@ -0,0 +1,18 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+//go:build !unix
+
+package scenario
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// fileOwner returns an error on platforms whose files are not owned by a
+// numeric user ID.
+func fileOwner(fs.FileInfo) (uint32, error) {
+	return 0, errors.New("owner dependencies are not supported on this platform")
+}
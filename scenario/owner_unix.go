@@ -0,0 +1,22 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+//go:build unix
+
+package scenario
+
+import (
+	"fmt"
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the user ID of the owner of the supplied file.
+func fileOwner(fi fs.FileInfo) (uint32, error) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("unable to determine owner of %q", fi.Name())
+	}
+	return st.Uid, nil
+}
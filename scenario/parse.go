@@ -6,12 +6,18 @@ package scenario
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/samber/lo"
 	"gopkg.in/yaml.v3"
 
 	"github.com/gdt-dev/core/api"
 	"github.com/gdt-dev/core/parse"
 	"github.com/gdt-dev/core/plugin"
+	// The check plugin is built into gdt core, so it is registered whenever
+	// scenarios are parsed.
+	_ "github.com/gdt-dev/core/plugin/check"
 )
 
 // UnmarshalYAML is a custom unmarshaler that asks plugins for their known spec
@@ -23,6 +29,7 @@ func (s *Scenario) UnmarshalYAML(node *yaml.Node) error {
 	s.Timings = &api.Timings{}
 	plugins := plugin.Registered()
 	defaults := api.Defaults{}
+	var defaultsNode *yaml.Node
 	// maps/structs are stored in a top-level Node.Content field which is a
 	// concatenated slice of Node pointers in pairs of key/values.
 	//
@@ -61,15 +68,48 @@ func (s *Scenario) UnmarshalYAML(node *yaml.Node) error {
 			if valNode.Kind != yaml.SequenceNode {
 				return parse.ExpectedSequenceAt(valNode)
 			}
-			var fixtures []string
-			if err := valNode.Decode(&fixtures); err != nil {
+			if err := s.parseFixtures(valNode); err != nil {
+				return err
+			}
+		case "vars":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			if err := s.parseVars(valNode); err != nil {
+				return err
+			}
+		case "var-files":
+			if valNode.Kind != yaml.SequenceNode {
+				return parse.ExpectedSequenceAt(valNode)
+			}
+			if err := s.parseVarFiles(valNode); err != nil {
+				return err
+			}
+		case "exports", "imports":
+			if valNode.Kind != yaml.SequenceNode {
 				return parse.ExpectedSequenceAt(valNode)
 			}
-			s.Fixtures = fixtures
+			names, err := parseVarNames(valNode)
+			if err != nil {
+				return err
+			}
+			if key == "exports" {
+				s.Exports = names
+			} else {
+				s.Imports = names
+			}
+		case "secrets":
+			if valNode.Kind != yaml.MappingNode {
+				return parse.ExpectedMapAt(valNode)
+			}
+			if err := s.parseSecrets(valNode); err != nil {
+				return err
+			}
 		case "defaults":
 			if valNode.Kind != yaml.MappingNode {
 				return parse.ExpectedMapAt(valNode)
 			}
+			defaultsNode = valNode
 			// Each plugin can have its own set of default configuration values
 			// under an outer map field keyed to the name of the plugin.
 			// Plugins return a Defaults prototype from
@@ -93,7 +133,7 @@ func (s *Scenario) UnmarshalYAML(node *yaml.Node) error {
 			if err := valNode.Decode(&scenDefaults); err != nil {
 				return err
 			}
-			if scenDefaults.Timeout != nil {
+			if scenDefaults.Timeout != nil && scenDefaults.Timeout.After != "" {
 				s.Timings.AddTimeout(
 					scenDefaults.Timeout.Duration(),
 					api.SetOnDefault,
@@ -116,89 +156,299 @@ func (s *Scenario) UnmarshalYAML(node *yaml.Node) error {
 			if valNode.Kind != yaml.SequenceNode {
 				return parse.ExpectedSequenceAt(valNode)
 			}
+			ids := map[string]bool{}
 			for idx, testNode := range valNode.Content {
-				parsed := false
-				base := api.Spec{}
-				if err := testNode.Decode(&base); err != nil {
+				sp, err := s.parseSpec(testNode, idx, defaults, plugins)
+				if err != nil {
 					return err
 				}
-				base.Index = idx
-				base.Defaults = &defaults
-				pluginSpecs := map[api.Plugin][]api.Evaluable{}
-				for _, p := range plugins {
-					pluginSpecs[p] = p.Specs()
+				base := sp.Base()
+				if base.ID != "" {
+					if ids[base.ID] {
+						s.Warnings = append(
+							s.Warnings,
+							parse.DuplicateSpecIDAt(
+								s.Path, base.ID,
+								findField(testNode, []string{"id"}),
+							),
+						)
+					}
+					ids[base.ID] = true
 				}
-				for plugin, specs := range pluginSpecs {
-					for idx, sp := range specs {
-						if err := testNode.Decode(sp); err != nil {
-							if errors.Is(err, parse.ErrParseUnknownField) {
-								continue
-							}
-							return err
-						}
-						base.Plugin = plugin
-						if base.Wait != nil {
-							if base.Wait.Before != "" {
-								s.Timings.AddWait(
-									base.Wait.BeforeDuration(),
-								)
-							}
-							if base.Wait.After != "" {
-								s.Timings.AddWait(
-									base.Wait.AfterDuration(),
-								)
-							}
-						}
-						if base.Timeout != nil {
-							s.Timings.AddTimeout(
-								base.Timeout.Duration(),
-								api.SetOnSpec,
-								idx,
-							)
-						}
-						sp.SetBase(base)
-						s.Tests = append(s.Tests, sp)
-						parsed = true
-						break
+				if base.Wait != nil {
+					if base.Wait.Before != "" {
+						s.Timings.AddWait(base.Wait.BeforeDuration())
 					}
+					if base.Wait.After != "" {
+						s.Timings.AddWait(base.Wait.AfterDuration())
+					}
+				}
+				to := sp.Timeout()
+				if to == nil {
+					to = base.Timeout
+				}
+				if to != nil && to.After != "" {
+					s.Timings.AddTimeout(
+						to.Duration(),
+						api.SetOnSpec,
+						idx,
+					)
 				}
-				if !parsed {
-					return parse.UnknownSpecAt(s.Path, valNode)
+				digest, err := specDigest(testNode, defaultsNode)
+				if err != nil {
+					return err
 				}
+				s.Tests = append(s.Tests, sp)
+				s.digests = append(s.digests, digest)
 			}
 		case "skip-if":
-			if valNode.Kind != yaml.SequenceNode {
-				return parse.ExpectedSequenceAt(valNode)
+			conds, err := s.parseConditions(valNode, defaults, plugins)
+			if err != nil {
+				return err
 			}
-			for idx, testNode := range valNode.Content {
-				parsed := false
-				base := api.Spec{}
-				if err := testNode.Decode(&base); err != nil {
-					return err
-				}
-				base.Index = idx
-				base.Defaults = &defaults
-				specs := []api.Evaluable{}
-				for _, p := range plugins {
-					specs = append(specs, p.Specs()...)
-				}
-				for _, sp := range specs {
-					if err := testNode.Decode(sp); err != nil {
-						if errors.Is(err, parse.ErrParseUnknownField) {
-							continue
-						}
+			s.SkipIf = conds
+		case "run-if":
+			conds, err := s.parseConditions(valNode, defaults, plugins)
+			if err != nil {
+				return err
+			}
+			s.RunIf = conds
+		}
+	}
+	return nil
+}
+
+// parseFixtures parses the supplied `fixtures` sequence node. Each entry is
+// either the name of a fixture or a map with the fixture's `name`, the
+// parameters, in `with`, to configure the fixture with, the fixture's `scope`
+// the `timeout` for starting the fixture and the fixture's `health` policy.
+func (s *Scenario) parseFixtures(node *yaml.Node) error {
+	fixtures := []string{}
+	params := map[string]map[string]interface{}{}
+	scopes := map[string]api.FixtureScope{}
+	timeouts := map[string]time.Duration{}
+	healths := map[string]api.FixtureHealth{}
+	for _, fixNode := range node.Content {
+		switch fixNode.Kind {
+		case yaml.ScalarNode:
+			fixtures = append(fixtures, fixNode.Value)
+		case yaml.MappingNode:
+			var name string
+			var with map[string]interface{}
+			var scope api.FixtureScope
+			var timeout time.Duration
+			var health api.FixtureHealth
+			for i := 0; i < len(fixNode.Content); i += 2 {
+				keyNode := fixNode.Content[i]
+				valNode := fixNode.Content[i+1]
+				switch keyNode.Value {
+				case "name":
+					if valNode.Kind != yaml.ScalarNode {
+						return parse.ExpectedScalarAt(valNode)
+					}
+					name = valNode.Value
+				case "with":
+					if valNode.Kind != yaml.MappingNode {
+						return parse.ExpectedMapAt(valNode)
+					}
+					if err := valNode.Decode(&with); err != nil {
 						return err
 					}
-					sp.SetBase(base)
-					s.SkipIf = append(s.SkipIf, sp)
-					parsed = true
-					break
+				case "scope":
+					if valNode.Kind != yaml.ScalarNode {
+						return parse.ExpectedScalarAt(valNode)
+					}
+					scope = api.FixtureScope(strings.ToLower(valNode.Value))
+					if !lo.Contains(api.FixtureScopes, scope) {
+						return parse.InvalidFixtureScopeAt(
+							valNode, valNode.Value,
+							lo.Map(api.FixtureScopes, func(
+								sc api.FixtureScope, _ int,
+							) string {
+								return string(sc)
+							}),
+						)
+					}
+				case "timeout":
+					if valNode.Kind != yaml.ScalarNode {
+						return parse.ExpectedScalarAt(valNode)
+					}
+					dur, err := time.ParseDuration(valNode.Value)
+					if err != nil || dur <= 0 {
+						return parse.ExpectedDurationAt(valNode)
+					}
+					timeout = dur
+				case "health":
+					if valNode.Kind != yaml.ScalarNode {
+						return parse.ExpectedScalarAt(valNode)
+					}
+					health = api.FixtureHealth(strings.ToLower(valNode.Value))
+					if !lo.Contains(api.FixtureHealths, health) {
+						return parse.InvalidFixtureHealthAt(
+							valNode, valNode.Value,
+							lo.Map(api.FixtureHealths, func(
+								h api.FixtureHealth, _ int,
+							) string {
+								return string(h)
+							}),
+						)
+					}
+				default:
+					return parse.UnknownFieldAt(keyNode.Value, keyNode)
+				}
+			}
+			if name == "" {
+				return parse.ExpectedScalarAt(fixNode)
+			}
+			fixtures = append(fixtures, name)
+			if with != nil {
+				params[strings.ToLower(name)] = with
+			}
+			if scope != "" && scope != api.FixtureScopeScenario {
+				scopes[strings.ToLower(name)] = scope
+			}
+			if timeout > 0 {
+				timeouts[strings.ToLower(name)] = timeout
+			}
+			if health != "" {
+				healths[strings.ToLower(name)] = health
+			}
+		default:
+			return parse.ExpectedScalarOrMapAt(fixNode)
+		}
+	}
+	s.Fixtures = fixtures
+	if len(params) > 0 {
+		s.FixtureParams = params
+	}
+	if len(scopes) > 0 {
+		s.FixtureScopes = scopes
+	}
+	if len(timeouts) > 0 {
+		s.FixtureTimeouts = timeouts
+	}
+	if len(healths) > 0 {
+		s.FixtureHealths = healths
+	}
+	return nil
+}
+
+// parseSpec returns the Evaluable for the supplied test spec YAML node. Each
+// plugin, in priority order, is asked to parse the test spec and the first
+// plugin that understands all of the test spec's fields is chosen. If the test
+// spec has a `plugin` field, only the plugins with that name or alias, which
+// may be qualified with a namespace, are tried.
+//
+// If another plugin with the same priority as the chosen plugin can also parse
+// the test spec, the parse is ambiguous and a warning is recorded in the
+// scenario's Warnings.
+func (s *Scenario) parseSpec(
+	node *yaml.Node,
+	idx int,
+	defaults api.Defaults,
+	plugins []api.Plugin,
+) (api.Evaluable, error) {
+	node, assertExpr, err := extractAssertExpr(node)
+	if err != nil {
+		return nil, err
+	}
+	base := api.Spec{}
+	if err := node.Decode(&base); err != nil {
+		return nil, err
+	}
+	base.Index = idx
+	base.AssertExpr = assertExpr
+	base.Defaults = &defaults
+	if base.PluginName != "" {
+		selected := lo.Filter(plugins, func(p api.Plugin, _ int) bool {
+			return plugin.HasName(p, base.PluginName)
+		})
+		if len(selected) == 0 {
+			return nil, parse.UnknownPluginAt(base.PluginName, node)
+		}
+		plugins = selected
+	}
+	var chosen api.Evaluable
+	var chosenPlugin api.Plugin
+	ambiguous := []string{}
+	for _, p := range plugins {
+		if chosenPlugin != nil &&
+			plugin.Priority(p) != plugin.Priority(chosenPlugin) {
+			// Plugins are ordered by priority, so there are no more plugins
+			// that could make the parse ambiguous.
+			break
+		}
+		for _, sp := range p.Specs() {
+			if err := node.Decode(sp); err != nil {
+				if errors.Is(err, parse.ErrParseUnknownField) {
+					continue
 				}
-				if !parsed {
-					return parse.UnknownSpecAt(s.Path, valNode)
+				if chosen != nil {
+					// Another plugin already parsed the test spec, so this
+					// plugin just doesn't understand it.
+					continue
 				}
+				return nil, err
 			}
+			if chosen == nil {
+				chosen = sp
+				chosenPlugin = p
+				ambiguous = append(ambiguous, plugin.QualifiedName(p))
+			} else if p != chosenPlugin {
+				ambiguous = append(ambiguous, plugin.QualifiedName(p))
+			}
+			break
+		}
+	}
+	if chosen == nil {
+		return nil, parse.UnknownSpecAt(s.Path, node)
+	}
+	if len(ambiguous) > 1 {
+		s.Warnings = append(
+			s.Warnings,
+			parse.AmbiguousSpecAt(s.Path, ambiguous, node),
+		)
+	}
+	s.warnDeprecated(node, chosenPlugin)
+	base.Plugin = chosenPlugin
+	chosen.SetBase(base)
+	return chosen, nil
+}
+
+// warnDeprecated records a warning in the scenario's Warnings for each field
+// in the supplied test spec YAML node that the supplied plugin has
+// deprecated.
+func (s *Scenario) warnDeprecated(node *yaml.Node, p api.Plugin) {
+	for _, df := range p.Info().Deprecated {
+		keyNode := findField(node, strings.Split(df.Name, "."))
+		if keyNode == nil {
+			continue
+		}
+		s.Warnings = append(
+			s.Warnings,
+			parse.DeprecatedFieldAt(
+				s.Path, plugin.QualifiedName(p), df.Name, df.Guidance(),
+				keyNode,
+			),
+		)
+	}
+}
+
+// findField returns the key node for the field with the supplied path in the
+// supplied mapping node, or nil if the field is not present.
+func findField(node *yaml.Node, path []string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return keyNode
 		}
+		return findField(node.Content[i+1], path[1:])
 	}
 	return nil
 }
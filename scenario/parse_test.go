@@ -18,7 +18,9 @@ import (
 	"github.com/gdt-dev/core/internal/testutil/plugin/bar"
 	"github.com/gdt-dev/core/internal/testutil/plugin/failer"
 	"github.com/gdt-dev/core/internal/testutil/plugin/foo"
+	"github.com/gdt-dev/core/internal/testutil/plugin/hooks"
 	"github.com/gdt-dev/core/internal/testutil/plugin/priorrun"
+	"github.com/gdt-dev/core/plugin/check"
 )
 
 func TestFailingDefaults(t *testing.T) {
@@ -34,6 +36,220 @@ func TestFailingDefaults(t *testing.T) {
 	require.Nil(s)
 }
 
+func TestFailingFixtureParamsUnknownField(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "fixture-params-bad-key.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.ErrorContains(err, "unknown field")
+	require.Nil(s)
+}
+
+func TestFailingFixtureBadScope(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "fixture-bad-scope.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidFixtureScope, api.ErrorCode(err))
+	require.ErrorContains(err, "invalid fixture scope specified: session")
+	require.Nil(s)
+}
+
+func TestFailingFixtureBadTimeout(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "fixture-bad-timeout.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeExpectedDuration, api.ErrorCode(err))
+	require.Nil(s)
+}
+
+func TestFailingFixtureBadHealth(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "fixture-bad-health.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidFixtureHealth, api.ErrorCode(err))
+	require.ErrorContains(err, "invalid fixture health policy specified: ignore")
+	require.Nil(s)
+}
+
+func TestFixtureParams(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-params.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	assert.Equal([]string{"plain", "httpmock"}, s.Fixtures)
+	assert.Equal(
+		map[string]map[string]interface{}{
+			"httpmock": {"port": 8081},
+		},
+		s.FixtureParams,
+	)
+}
+
+func TestFailingVarsUnknownField(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "vars-unknown-field.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeUnknownField, api.ErrorCode(err))
+	require.ErrorContains(err, "unknown field")
+	require.Nil(s)
+}
+
+func TestFailingVarsFileNotFound(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "vars-file-not-found.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeFileNotFound, api.ErrorCode(err))
+	require.ErrorContains(err, "does-not-exist.json")
+	require.Nil(s)
+}
+
+func TestFailingVarsExprInvalid(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "vars-expr-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidExpression, api.ErrorCode(err))
+	require.ErrorContains(err, "syntax error")
+	require.Nil(s)
+}
+
+func TestFailingSkipIfExprInvalid(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "skip-if-expr-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidExpression, api.ErrorCode(err))
+	require.ErrorContains(err, "env.CI ==")
+	require.Nil(s)
+}
+
+func TestFailingAssertExprInvalid(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "assert-expr-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidExpression, api.ErrorCode(err))
+	require.ErrorContains(err, `vars.FOO = "bar"`)
+	require.Nil(s)
+}
+
+func TestFailingVarsExprCycle(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "vars-expr-cycle.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidExpression, api.ErrorCode(err))
+	require.ErrorContains(err, "FIRST -> SECOND -> FIRST")
+	require.Nil(s)
+}
+
+func TestFailingVarsTypeMismatch(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "vars-type-mismatch.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidVariable, api.ErrorCode(err))
+	require.ErrorContains(err, `invalid variable REPLICAS: value "five" is not a valid int`)
+	require.Nil(s)
+}
+
+func TestFailingVarsNoSource(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "vars-no-source.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidVariable, api.ErrorCode(err))
+	require.ErrorContains(err, "exactly one of value, env, file or expr")
+	require.Nil(s)
+}
+
+func TestFailingSecretsLiteral(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "secrets-literal.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeExpectedMap, api.ErrorCode(err))
+	require.Nil(s)
+}
+
+func TestFailingVarFilesNotFound(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "var-files-not-found.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeFileNotFound, api.ErrorCode(err))
+	require.ErrorContains(err, "does-not-exist.yaml")
+	require.Nil(s)
+}
+
 func TestFailingDependsUnknownField(t *testing.T) {
 	require := require.New(t)
 
@@ -60,6 +276,89 @@ func TestFailingDependsInvalidOS(t *testing.T) {
 	require.Nil(s)
 }
 
+func TestFailingDependsInvalidArch(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-invalid-arch.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidArch, api.ErrorCode(err))
+	require.ErrorContains(err, "invalid architecture specified: x86_64")
+	require.Nil(s)
+}
+
+func TestFailingDependsInvalidKernelVersion(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-invalid-kernel-version.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.ErrorContains(err, "invalid version constraint specified")
+	require.Nil(s)
+}
+
+func TestFailingDependsServiceInvalid(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-service-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidService, api.ErrorCode(err))
+	require.ErrorContains(err, "expected exactly one of tcp or http")
+	require.Nil(s)
+}
+
+func TestFailingDependsServiceInvalidTCP(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-service-invalid-tcp.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidService, api.ErrorCode(err))
+	require.ErrorContains(err, "tcp must be a host:port address")
+	require.Nil(s)
+}
+
+func TestFailingDependsModeWithoutPath(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-mode-without-path.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidDependency, api.ErrorCode(err))
+	require.ErrorContains(err, "mode and owner require file or dir")
+	require.Nil(s)
+}
+
+func TestFailingDependsInvalidMissing(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-invalid-missing.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidDependencyMissing, api.ErrorCode(err))
+	require.ErrorContains(err, "invalid dependency missing policy specified: ignore")
+	require.Nil(s)
+}
+
 func TestFailingDependsVersionInvalidConstraint(t *testing.T) {
 	require := require.New(t)
 
@@ -73,6 +372,34 @@ func TestFailingDependsVersionInvalidConstraint(t *testing.T) {
 	require.Nil(s)
 }
 
+func TestFailingDependsVersionInvalidPackageManager(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-version-invalid-package-manager.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidPackageManager, api.ErrorCode(err))
+	require.ErrorContains(err, "invalid package manager specified: apt")
+	require.Nil(s)
+}
+
+func TestFailingDependsVersionInvalidJSONPath(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "depends-version-invalid-json-path.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.NotNil(err)
+	require.Equal(parse.CodeInvalidJSONPath, api.ErrorCode(err))
+	require.ErrorContains(err, "expression must start with '$'")
+	require.Nil(s)
+}
+
 func TestFailingDependsVersionFilterInvalidRegex(t *testing.T) {
 	require := require.New(t)
 
@@ -116,6 +443,8 @@ func TestNoTests(t *testing.T) {
 				InnerDefaults: failer.InnerDefaults{},
 			},
 			"priorRun":           &priorrun.Defaults{},
+			"check":              &check.Defaults{},
+			"hooks":              &hooks.Defaults{},
 			scenario.DefaultsKey: &scenario.Defaults{},
 		},
 		s.Defaults,
@@ -150,6 +479,51 @@ func TestUnknownSpec(t *testing.T) {
 	assert.Nil(s)
 }
 
+func TestPluginSelect(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "plugin-select.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.Len(s.Tests, 1)
+	assert.Equal("foo", s.Tests[0].Base().Plugin.Info().Name)
+	assert.Equal("FOO", s.Tests[0].Base().PluginName)
+	assert.Empty(s.Warnings)
+}
+
+func TestPluginSelectMismatch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "plugin-select-mismatch.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	assert.Nil(s)
+	require.NotNil(err)
+	assert.Equal(parse.CodeUnknownSpec, api.ErrorCode(err))
+}
+
+func TestPluginSelectUnknown(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "plugin-select-unknown.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	assert.Nil(s)
+	require.NotNil(err)
+	assert.Equal(parse.CodeUnknownPlugin, api.ErrorCode(err))
+	assert.ErrorContains(err, `unknown plugin "nope"`)
+}
+
 func TestTimeoutScalarOrMap(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -240,6 +614,58 @@ func TestBadRetryIntervalDuration(t *testing.T) {
 	assert.Nil(s)
 }
 
+func TestBadRetryJitter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "bad-retry-jitter.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	assert.ErrorContains(err, "jitter must be between 0 and 1")
+	assert.Nil(s)
+}
+
+func TestBadRetryMultiplierNotExponential(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "bad-retry-multiplier-not-exponential.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	assert.ErrorContains(err, "require exponential")
+	assert.Nil(s)
+}
+
+func TestBadRetrySuccessesRequired(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "bad-retry-successes-required.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	assert.ErrorContains(err, "attempts must be at least successes-required")
+	assert.Nil(s)
+}
+
+func TestBadTimeoutAttempt(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "parse", "fail", "bad-timeout-attempt.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	assert.ErrorContains(err, "attempt must not be longer than after")
+	assert.Nil(s)
+}
+
 func TestKnownSpec(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -268,6 +694,8 @@ func TestKnownSpec(t *testing.T) {
 				InnerDefaults: failer.InnerDefaults{},
 			},
 			"priorRun":           &priorrun.Defaults{},
+			"check":              &check.Defaults{},
+			"hooks":              &hooks.Defaults{},
 			scenario.DefaultsKey: &scenario.Defaults{},
 		},
 		s.Defaults,
@@ -283,6 +711,8 @@ func TestKnownSpec(t *testing.T) {
 			InnerDefaults: failer.InnerDefaults{},
 		},
 		"priorRun":           &priorrun.Defaults{},
+		"check":              &check.Defaults{},
+		"hooks":              &hooks.Defaults{},
 		scenario.DefaultsKey: &scenario.Defaults{},
 	}
 	expTests := []api.Evaluable{
@@ -376,6 +806,8 @@ func TestEnvExpansion(t *testing.T) {
 				InnerDefaults: failer.InnerDefaults{},
 			},
 			"priorRun":           &priorrun.Defaults{},
+			"check":              &check.Defaults{},
+			"hooks":              &hooks.Defaults{},
 			scenario.DefaultsKey: &scenario.Defaults{},
 		},
 		s.Defaults,
@@ -391,6 +823,8 @@ func TestEnvExpansion(t *testing.T) {
 			InnerDefaults: failer.InnerDefaults{},
 		},
 		"priorRun":           &priorrun.Defaults{},
+		"check":              &check.Defaults{},
+		"hooks":              &hooks.Defaults{},
 		scenario.DefaultsKey: &scenario.Defaults{},
 	}
 	expTests := []api.Evaluable{
@@ -440,6 +874,8 @@ func TestScenarioDefaults(t *testing.T) {
 				InnerDefaults: failer.InnerDefaults{},
 			},
 			"priorRun": &priorrun.Defaults{},
+			"check":    &check.Defaults{},
+			"hooks":    &hooks.Defaults{},
 			scenario.DefaultsKey: &scenario.Defaults{
 				Timeout: &api.Timeout{
 					After: "2s",
@@ -455,6 +891,8 @@ func TestScenarioDefaults(t *testing.T) {
 			InnerDefaults: failer.InnerDefaults{},
 		},
 		"priorRun": &priorrun.Defaults{},
+		"check":    &check.Defaults{},
+		"hooks":    &hooks.Defaults{},
 		scenario.DefaultsKey: &scenario.Defaults{
 			Timeout: &api.Timeout{
 				After: "2s",
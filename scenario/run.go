@@ -6,22 +6,28 @@ package scenario
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
-	"strings"
 	"testing"
 	"time"
 
 	"github.com/cenkalti/backoff"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gdt-dev/core/api"
 	gdtcontext "github.com/gdt-dev/core/context"
 	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/internal/specconfig"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+	"github.com/gdt-dev/core/progress"
 	"github.com/gdt-dev/core/run"
 	"github.com/gdt-dev/core/testunit"
+	"github.com/gdt-dev/core/tracing"
 )
 
 // Run executes the scenario. The error that is returned will always be derived
@@ -31,7 +37,18 @@ import (
 // method controls whether the test runner calls `Fail()` or `Skip()` which
 // will mark the test units failed or skipped if a test unit evaluates to
 // false.
-func (s *Scenario) Run(ctx context.Context, subject any) error {
+func (s *Scenario) Run(ctx context.Context, subject any) (err error) {
+	ctx = withRunID(ctx, subject)
+	ctx = gdtcontext.SetScenarioStart(ctx, time.Now())
+	ctx, span := tracing.Start(
+		ctx, tracing.SpanScenario,
+		tracing.AttrRunID.String(gdtcontext.RunID(ctx)),
+		tracing.AttrScenario.String(s.Title()),
+		tracing.AttrPath.String(s.Path),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
 	if s.Path != "" {
 		// NOTE(jaypipes): This is necessary to allow relative path lookups for
 		// file loads *within* the test scenario itself.
@@ -43,50 +60,119 @@ func (s *Scenario) Run(ctx context.Context, subject any) error {
 			_ = os.Chdir(cwd)
 		}()
 	}
-	if err := s.checkDependencies(ctx); err != nil {
+	notSatisfied, err := s.checkDependencies(ctx)
+	if err != nil {
 		return err
 	}
+	if errs := s.Validate(ctx); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if notSatisfied != nil {
+		s.skipDependencies(ctx, subject, notSatisfied)
+		return nil
+	}
+	var r *run.Run
 	switch subject := subject.(type) {
 	case *testing.T:
-		return s.runGo(ctx, subject)
 	case *run.Run:
-		return s.runExternal(ctx, subject)
+		r = subject
 	default:
 		return fmt.Errorf("unknown run type %T", subject)
 	}
+	ctx = gdtcontext.SetScenarioID(ctx, gdtcontext.NewScenarioID())
+	hooks, err := s.beforeScenario(ctx, s.hooks())
+	results := []api.SpecResult{}
+	if err == nil {
+		if r != nil {
+			err = s.runExternal(ctx, r, &results)
+		} else {
+			err = s.runGo(ctx, subject.(*testing.T), &results)
+		}
+	}
+	if afterErr := s.afterScenario(ctx, hooks, results); afterErr != nil {
+		if err == nil {
+			return afterErr
+		}
+		return errors.Join(err, afterErr)
+	}
+	return err
+}
+
+// withRunID returns a copy of the supplied context with the identifier of the
+// test run. A `*run.Run` subject's ID is used, otherwise any run ID already in
+// the context is kept or a new one generated.
+func withRunID(ctx context.Context, subject any) context.Context {
+	if r, ok := subject.(*run.Run); ok {
+		return gdtcontext.SetRunID(ctx, r.ID())
+	}
+	if gdtcontext.RunID(ctx) != "" {
+		return ctx
+	}
+	return gdtcontext.SetRunID(ctx, gdtcontext.NewRunID())
 }
 
 // runExternal executes the scenario using the `gdt` CLI tool as the underlying
-// test runner and a `*RunState` to track test run state. The error that is
+// test runner and a `*RunState` to track test run state. The results of the
+// test specs are appended to the supplied results. The error that is
 // returned will always be derived from `api.RuntimeError` and represents an
 // *unrecoverable* error.
-func (s *Scenario) runExternal(ctx context.Context, run *run.Run) error {
+func (s *Scenario) runExternal(
+	ctx context.Context,
+	run *run.Run,
+	results *[]api.SpecResult,
+) (err error) {
 	ctx = gdtcontext.PushTrace(ctx, s.Title())
 	defer func() {
 		ctx = gdtcontext.PopTrace(ctx)
 	}()
+	run.StartScenario(s.Path)
 
+	mask := func(msg string) string {
+		return gdtcontext.MaskSecrets(ctx, msg)
+	}
 	rootUnit := testunit.New(
 		ctx,
 		testunit.WithName(s.Title()),
+		testunit.WithMask(mask),
 	)
 	ctx = gdtcontext.SetTestUnit(ctx, rootUnit)
 
-	if len(s.Fixtures) > 0 {
-		fixtures := gdtcontext.Fixtures(ctx)
-		for _, fname := range s.Fixtures {
-			lookup := strings.ToLower(fname)
-			fix, found := fixtures[lookup]
-			if !found {
-				return api.RequiredFixtureMissing(fname)
-			}
-			if err := fix.Start(ctx); err != nil {
-				return err
-			}
-			defer fix.Stop(ctx)
+	if s.hasRunTimeoutConflict(ctx, run) {
+		return api.TimeoutConflict(s.Timings)
+	}
+
+	releaseFixtures, err := s.acquireFixtures(
+		ctx, api.FixtureScopeSuite, api.FixtureScopeScenario,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// If the test run was interrupted, the fixtures are given the Run's
+		// shutdown grace period to stop.
+		if relErr := run.WithinGrace(releaseFixtures); relErr != nil {
+			err = errors.Join(err, relErr)
 		}
+	}()
+	ctx = s.withFixtures(ctx, api.FixtureScopeSuite, api.FixtureScopeScenario)
+	ctx, err = s.withSecrets(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, err = s.withImports(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, err = s.withVars(ctx)
+	if err != nil {
+		return err
 	}
 
+	// The context is cancelled if the test run is interrupted by SIGINT or
+	// SIGTERM, which stops the test spec being evaluated.
+	ctx, stopTrap := run.TrapSignals(ctx)
+	defer stopTrap()
+
 	// If the test author has specified any pre-flight checks in the `skip-if`
 	// collection, evaluate those first and if any failed, skip the scenario's
 	// tests.
@@ -100,16 +186,43 @@ func (s *Scenario) runExternal(ctx context.Context, run *run.Run) error {
 				"skip-if: %s passed. skipping test.",
 				skipIf.Base().Title(),
 			)
+			run.SkipScenario(s.Path)
 			return nil
 		}
 	}
+	// Conversely, if any of the conditions in the `run-if` collection fail,
+	// skip the scenario's tests.
+	for _, runIf := range s.RunIf {
+		res, err := runIf.Eval(ctx)
+		if err != nil {
+			return err
+		}
+		if len(res.Failures()) > 0 {
+			rootUnit.Skipf(
+				"run-if: %s failed. skipping test.",
+				runIf.Base().Title(),
+			)
+			run.SkipScenario(s.Path)
+			return nil
+		}
+	}
+
+	// store stores the result of the test spec with the supplied index to
+	// the Run and appends it to the results passed to AfterScenario.
+	store := func(idx int, tu *testunit.TestUnit, res *api.Result) {
+		run.StoreResult(idx, s.Path, tu, res)
+		*results = append(*results, s.specResult(idx, tu.Skipped(), res))
+	}
 
 	scenCleanups := []func(){}
 	scenOK := true
 outer:
 	for idx, t := range s.Tests {
+		specID := gdtcontext.NewSpecID()
 		tu := testunit.New(
 			ctx,
+			testunit.WithID(specID),
+			testunit.WithStableID(t.Base().ID),
 			testunit.WithName(
 				fmt.Sprintf(
 					"%s/%s",
@@ -117,9 +230,44 @@ outer:
 					t.Base().Title(),
 				),
 			),
+			testunit.WithMask(mask),
 		)
+		if run.Interrupted() != nil {
+			// Record the test specs that were not run so that the Run has a
+			// result for every test spec in the scenario.
+			tu.Skip("test run interrupted. skipping test.")
+			store(idx, tu, api.NewResult())
+			continue
+		}
+		notSatisfied, err := s.checkSpecDependencies(ctx, t)
+		if err != nil {
+			return err
+		}
+		if notSatisfied != nil {
+			tu.Skipf("%s. skipping test.", notSatisfied)
+			store(idx, tu, api.NewResult())
+			continue
+		}
+		key := s.cacheKey(ctx, run, idx)
+		if entry, ok := run.CachedPass(key); ok {
+			tu.Logf(
+				"cache: passed at %s. skipping evaluation.",
+				entry.PassedAt.Format(time.RFC3339),
+			)
+			if len(entry.Data) > 0 {
+				ctx = gdtcontext.SetRun(ctx, entry.Data)
+			}
+			tu.Finish()
+			res := api.NewResult()
+			for k, v := range entry.Data {
+				res.SetData(k, v)
+			}
+			run.StoreCachedResult(idx, s.Path, tu, res)
+			*results = append(*results, s.specResult(idx, false, res))
+			continue
+		}
 		ctx = gdtcontext.SetTestUnit(ctx, tu)
-		res, err := s.runSpec(ctx, tu, idx)
+		res, err := s.runSpec(ctx, tu, idx, specID, run.ShutdownGrace())
 		if err != nil {
 			return err
 		}
@@ -135,17 +283,32 @@ outer:
 		for _, fail := range res.Failures() {
 			if res.StopOnFail() {
 				tu.Fatal(fail)
-				run.StoreResult(idx, s.Path, tu, res)
+				store(idx, tu, res)
 				break outer
 			}
 			tu.Error(fail)
 		}
 		tu.Finish() // necessary for elapsed timer to stop
 		scenOK = scenOK && !tu.Failed()
+		if !tu.Failed() && !tu.Skipped() && cacheableData(ctx, res.Data()) {
+			run.StorePass(key, res.Data())
+		}
 
-		run.StoreResult(idx, s.Path, tu, res)
+		store(idx, tu, res)
 	}
 	slices.Reverse(scenCleanups)
+	if sig := run.Interrupted(); sig != nil {
+		// Cleanups are run even though the interrupted test spec did not
+		// pass so that nothing the test specs created is left behind.
+		err := run.WithinGrace(func() error {
+			for _, cleanup := range scenCleanups {
+				cleanup()
+			}
+			return nil
+		})
+		return errors.Join(api.Interrupted(sig), err)
+	}
+	s.export(ctx)
 	if scenOK {
 		for _, cleanup := range scenCleanups {
 			cleanup()
@@ -155,10 +318,15 @@ outer:
 }
 
 // runGo executes the scenario using the `go test` tool as the underlying test
-// runner and the Go `*testing.T` to track test run state. The error that is
+// runner and the Go `*testing.T` to track test run state. The results of the
+// test specs are appended to the supplied results. The error that is
 // returned will always be derived from `api.RuntimeError` and represents an
 // *unrecoverable* error.
-func (s *Scenario) runGo(ctx context.Context, t *testing.T) error {
+func (s *Scenario) runGo(
+	ctx context.Context,
+	t *testing.T,
+	results *[]api.SpecResult,
+) (err error) {
 	ctx = gdtcontext.PushTrace(ctx, s.Title())
 	defer func() {
 		ctx = gdtcontext.PopTrace(ctx)
@@ -168,19 +336,29 @@ func (s *Scenario) runGo(ctx context.Context, t *testing.T) error {
 		return api.TimeoutConflict(s.Timings)
 	}
 
-	if len(s.Fixtures) > 0 {
-		fixtures := gdtcontext.Fixtures(ctx)
-		for _, fname := range s.Fixtures {
-			lookup := strings.ToLower(fname)
-			fix, found := fixtures[lookup]
-			if !found {
-				return api.RequiredFixtureMissing(fname)
-			}
-			if err := fix.Start(ctx); err != nil {
-				return err
-			}
-			defer fix.Stop(ctx)
+	releaseFixtures, err := s.acquireFixtures(
+		ctx, api.FixtureScopeSuite, api.FixtureScopeScenario,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if relErr := releaseFixtures(); relErr != nil {
+			err = errors.Join(err, relErr)
 		}
+	}()
+	ctx = s.withFixtures(ctx, api.FixtureScopeSuite, api.FixtureScopeScenario)
+	ctx, err = s.withSecrets(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, err = s.withImports(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, err = s.withVars(ctx)
+	if err != nil {
+		return err
 	}
 
 	// If the test author has specified any pre-flight checks in the `skip-if`
@@ -199,16 +377,50 @@ func (s *Scenario) runGo(ctx context.Context, t *testing.T) error {
 			return nil
 		}
 	}
+	// Conversely, if any of the conditions in the `run-if` collection fail,
+	// skip the scenario's tests.
+	for _, runIf := range s.RunIf {
+		res, err := runIf.Eval(ctx)
+		if err != nil {
+			return err
+		}
+		if len(res.Failures()) > 0 {
+			t.Skipf(
+				"run-if: %s failed. skipping test.",
+				runIf.Base().Title(),
+			)
+			return nil
+		}
+	}
 
 	var res *api.Result
-	var err error
 
 	t.Run(s.Title(), func(tt *testing.T) {
-		for idx := range s.Tests {
-			res, err = s.runSpec(ctx, tt, idx)
+		for idx, spec := range s.Tests {
+			var notSatisfied error
+			notSatisfied, err = s.checkSpecDependencies(ctx, spec)
+			if err != nil {
+				break
+			}
+			if notSatisfied != nil {
+				// The scenario's test specs share a *testing.T, so the
+				// test spec is skipped in its own subtest rather than
+				// skipping the rest of the scenario.
+				tt.Run(spec.Base().Title(), func(st *testing.T) {
+					st.Skipf("%s. skipping test.", notSatisfied)
+				})
+				*results = append(
+					*results, s.specResult(idx, true, api.NewResult()),
+				)
+				continue
+			}
+			res, err = s.runSpec(
+				ctx, tt, idx, gdtcontext.NewSpecID(), run.DefaultShutdownGrace,
+			)
 			if err != nil {
 				break
 			}
+			*results = append(*results, s.specResult(idx, false, res))
 
 			for _, cleanup := range res.Cleanups() {
 				t.Cleanup(cleanup)
@@ -229,6 +441,9 @@ func (s *Scenario) runGo(ctx context.Context, t *testing.T) error {
 			}
 		}
 	})
+	if err == nil {
+		s.export(ctx)
+	}
 	return err
 }
 
@@ -242,6 +457,8 @@ func (s *Scenario) runSpec(
 	ctx context.Context, // this is the overall scenario's context
 	t api.T, // T specific to the goroutine running this test spec
 	idx int, // index of the test spec within Scenario.Tests
+	specID string, // identifies this run of the test spec
+	grace time.Duration, // how long to wait for the evaluation after a timeout
 ) (res *api.Result, err error) {
 	// Create a brand new context that inherits the top-level context's
 	// cancel func. We want to set deadlines for each test spec and if
@@ -249,11 +466,53 @@ func (s *Scenario) runSpec(
 	// first deadline/timeout will be used.
 	specCtx, specCancel := context.WithCancel(ctx)
 	defer specCancel()
+	specCtx = gdtcontext.SetSpecID(specCtx, specID)
 
-	defaults := s.getDefaults()
 	spec := s.Tests[idx]
 	sb := spec.Base()
 
+	attrs := []attribute.KeyValue{
+		tracing.AttrRunID.String(gdtcontext.RunID(specCtx)),
+		tracing.AttrSpecID.String(specID),
+		tracing.AttrSpec.String(sb.Title()),
+		tracing.AttrIndex.Int(idx),
+	}
+	if sb.Plugin != nil {
+		attrs = append(attrs, tracing.AttrPlugin.String(sb.Plugin.Info().Name))
+	}
+	specCtx, span := tracing.Start(specCtx, tracing.SpanSpec, attrs...)
+	defer func() {
+		tracing.EndResult(span, res, err)
+	}()
+
+	// When debug output is only written for failed test specs, the test
+	// spec's debug messages are captured until its result is known.
+	specCtx = debug.StartCapture(specCtx)
+	defer func() {
+		debug.EndCapture(specCtx, err != nil || (res != nil && res.Failed()))
+	}()
+
+	if err := s.checkFixtures(ctx); err != nil {
+		return nil, err
+	}
+
+	// Spec-scoped fixtures are started with the scenario's context but their
+	// spans are children of the test spec's span.
+	releaseFixtures, err := s.acquireFixtures(
+		trace.ContextWithSpan(ctx, span), api.FixtureScopeSpec,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if relErr := releaseFixtures(); relErr != nil {
+			err = errors.Join(err, relErr)
+		}
+	}()
+	specCtx = s.withFixtures(specCtx, api.FixtureScopeSpec)
+
+	defaults := s.getDefaults()
+
 	specTraceMsg := strconv.Itoa(idx)
 	if sb.Name != "" {
 		specTraceMsg += ":" + sb.Name
@@ -262,65 +521,267 @@ func (s *Scenario) runSpec(
 	defer func() {
 		specCtx = gdtcontext.PopTrace(specCtx)
 	}()
+	debug.Printf(
+		specCtx, "spec/run: run ID %s spec ID %s",
+		gdtcontext.RunID(specCtx), specID,
+	)
+	progress.SpecStarted(specCtx)
+	defer func() {
+		progress.SpecFinished(specCtx, err != nil || (res != nil && res.Failed()))
+	}()
+
+	// The test spec's scratch directory is removed along with anything else
+	// the test spec cleans up, or right away if the test spec errored.
+	specCtx = gdtcontext.SetScratchDir(specCtx)
+	defer func() {
+		cleanup := gdtcontext.CloseScratchDir(specCtx)
+		if cleanup == nil {
+			return
+		}
+		if res == nil {
+			cleanup()
+			return
+		}
+		res.AddCleanup(cleanup)
+	}()
 
-	plugin := sb.Plugin
-	rt := getRetry(specCtx, defaults, plugin, spec)
-	to := getTimeout(specCtx, defaults, plugin, spec)
+	rt := getRetry(specCtx, defaults, spec)
+	to := getTimeout(specCtx, defaults, spec)
 	ch := make(chan runSpecRes, 1)
 
 	wait := sb.Wait
 	if wait != nil && wait.Before != "" {
 		debug.Printf(specCtx, "wait: %s before", wait.Before)
-		time.Sleep(wait.BeforeDuration())
+		sleep(specCtx, wait.BeforeDuration())
 	}
 
+	var attemptTimeout time.Duration
 	if to != nil {
-		specCtx, specCancel = context.WithTimeout(specCtx, to.Duration())
-		defer specCancel()
+		if to.After != "" {
+			specCtx, specCancel = context.WithTimeout(specCtx, to.Duration())
+			defer specCancel()
+			specCtx, specCancel = withClockTimeout(specCtx, to.Duration())
+			defer specCancel()
+		}
+		attemptTimeout = to.AttemptDuration()
 	}
 
-	go s.execSpec(specCtx, ch, rt, idx, spec)
+	// The test spec is evaluated with a fork of the test unit, which is
+	// joined to the test unit once the evaluation returns. An evaluation that
+	// ignores its context and outlives the grace period is left writing to
+	// the fork, so that it does not touch the finished and stored test unit.
+	// The fork masks the secrets known to the test spec's context, since the
+	// test unit's mask reads the scenario's context as it changes.
+	evalCtx := specCtx
+	tu := gdtcontext.TestUnit(specCtx)
+	var fork *testunit.TestUnit
+	if tu != nil {
+		maskCtx := specCtx
+		fork = tu.Fork(testunit.WithMask(func(msg string) string {
+			return gdtcontext.MaskSecrets(maskCtx, msg)
+		}))
+		evalCtx = gdtcontext.SetTestUnit(specCtx, fork)
+	}
+	go s.execSpec(evalCtx, ch, rt, attemptTimeout, grace, idx, spec)
 
+	var runres runSpecRes
 	select {
+	case runres = <-ch:
 	case <-specCtx.Done():
-		fail := fmt.Errorf(
-			"assertion failed: timeout exceeded (%s)", to.After,
-		)
+		// The test spec's timeout expired or the test run was interrupted.
+		// Give the evaluation the grace period to return before recording
+		// the failure.
+		timer := time.NewTimer(grace)
+		select {
+		case runres = <-ch:
+		case <-timer.C:
+			debug.Warnf(
+				specCtx, "spec/run: evaluation still running after %s grace period",
+				grace,
+			)
+			fork = nil
+		}
+		timer.Stop()
+	}
+	if fork != nil {
+		tu.Join(fork)
+	}
+	res, err = runres.r, runres.err
+	if specCtx.Err() != nil {
+		var fail error
+		switch {
+		case ctx.Err() != nil:
+			// The scenario's context was cancelled, e.g. because the test
+			// run was interrupted or the caller stopped `run.Watch`, which is
+			// not a timeout of the test spec.
+			fail = context.Cause(ctx)
+		case to != nil && to.After != "":
+			fail = fmt.Errorf(
+				"assertion failed: timeout exceeded (%s)", to.After,
+			)
+		default:
+			fail = context.Cause(specCtx)
+		}
 		res = api.NewResult(
 			api.WithFailures(fail),
 		)
 		err = nil
-	case runres := <-ch:
-		res = runres.r
-		err = runres.err
 	}
 	if err != nil {
 		return nil, err
 	}
+	maskResult(specCtx, res)
+
+	if sb.Set != nil && len(res.Failures()) == 0 {
+		if err := s.setFixtureState(ctx, sb.Set); err != nil {
+			return nil, err
+		}
+	}
 
 	if wait != nil && wait.After != "" {
 		debug.Printf(specCtx, "wait: %s after", wait.After)
-		time.Sleep(wait.AfterDuration())
+		sleep(specCtx, wait.AfterDuration())
 	}
 	return res, nil
 }
 
+// maskResult masks any of the context's secrets in the supplied Result's
+// assertion failures and artifacts.
+func maskResult(ctx context.Context, res *api.Result) {
+	if !gdtcontext.HasSecrets(ctx) {
+		return
+	}
+	failures := res.Failures()
+	masked := make([]error, len(failures))
+	for x, f := range failures {
+		masked[x] = gdtcontext.MaskError(ctx, f)
+	}
+	res.SetFailures(masked...)
+	artifacts := res.Artifacts()
+	maskedArtifacts := make([]api.Artifact, len(artifacts))
+	for x, a := range artifacts {
+		a.Content = []byte(gdtcontext.MaskSecrets(ctx, string(a.Content)))
+		maskedArtifacts[x] = a
+	}
+	res.SetArtifacts(maskedArtifacts...)
+}
+
+// evalAttempt evaluates the test spec once, within a span for the supplied
+// attempt number. If timeout is non-zero, an attempt that does not complete
+// within it fails with an attempt timeout, waiting no longer than the supplied
+// grace period for the test spec's Eval to return.
+func evalAttempt(
+	ctx context.Context,
+	spec api.Evaluable,
+	attempt int,
+	timeout time.Duration,
+	grace time.Duration,
+) (*api.Result, error) {
+	progress.Attempt(ctx, attempt)
+	ctx, span := tracing.Start(
+		ctx, tracing.SpanAttempt, tracing.AttrAttempt.Int(attempt),
+	)
+	evalCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		evalCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		evalCtx, cancel = withClockTimeout(evalCtx, timeout)
+		defer cancel()
+	}
+	// References to saved variables are replaced in all of the test spec's
+	// fields for this attempt only, since the run data may differ when the
+	// test spec is evaluated again.
+	restore := pluginutil.Interpolate(evalCtx, spec)
+	var res *api.Result
+	var err error
+	abandoned := false
+	if timeout > 0 {
+		res, abandoned, err = evalWithin(evalCtx, spec, restore, grace)
+	} else {
+		res, err = spec.Eval(evalCtx)
+		restore()
+	}
+	if evalCtx.Err() != nil && ctx.Err() == nil {
+		// Only the attempt's own timeout expired, so the attempt fails and
+		// may be retried within the test spec's overall timeout.
+		debug.Printf(ctx, "spec/run: attempt %d timed out", attempt)
+		res = api.NewResult(
+			api.WithFailures(api.AttemptTimeoutExceeded(timeout.String())),
+			api.WithTerminal(abandoned),
+		)
+		err = nil
+	}
+	if err == nil {
+		assertExpr(ctx, spec, res)
+	}
+	tracing.EndResult(span, res, err)
+	return res, err
+}
+
+// evalWithin evaluates the test spec with the supplied attempt context and
+// then calls restore. If the test spec's Eval does not return within the
+// supplied grace period after the attempt context is done, e.g. because Eval
+// ignores the context, evalWithin returns with abandoned set, and the attempt
+// must not be retried, since the test spec cannot be evaluated again while
+// Eval is still running. The abandoned Eval writes to a fork of the context's
+// test unit that is never joined.
+func evalWithin(
+	ctx context.Context,
+	spec api.Evaluable,
+	restore func(),
+	grace time.Duration,
+) (res *api.Result, abandoned bool, err error) {
+	tu := gdtcontext.TestUnit(ctx)
+	var fork *testunit.TestUnit
+	if tu != nil {
+		fork = tu.Fork()
+		ctx = gdtcontext.SetTestUnit(ctx, fork)
+	}
+	ch := make(chan runSpecRes, 1)
+	go func() {
+		res, err := spec.Eval(ctx)
+		restore()
+		ch <- runSpecRes{res, err}
+	}()
+	var r runSpecRes
+	select {
+	case r = <-ch:
+	case <-ctx.Done():
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case r = <-ch:
+		case <-timer.C:
+			return api.NewResult(api.WithTerminal(true)), true, nil
+		}
+	}
+	if fork != nil {
+		tu.Join(fork)
+	}
+	return r.r, false, r.err
+}
+
 // execSpec executes an individual test spec, performing any retries as
-// necessary until a timeout is exceeded or the test spec succeeds
+// necessary until a timeout is exceeded or the test spec succeeds. It always
+// sends exactly one runSpecRes on the supplied channel before returning.
 func (s *Scenario) execSpec(
 	ctx context.Context,
 	ch chan runSpecRes,
 	retry *api.Retry,
+	attemptTimeout time.Duration,
+	grace time.Duration,
 	idx int,
 	spec api.Evaluable,
 ) {
 	if retry == nil || retry == api.NoRetry {
 		// Just evaluate the test spec once
-		res, err := spec.Eval(ctx)
+		res, err := evalAttempt(ctx, spec, 1, attemptTimeout, grace)
 		if err != nil {
 			ch <- runSpecRes{nil, err}
 			return
 		}
+		collectMetrics(spec, res)
 		debug.Printf(
 			ctx, "spec/run: single-shot (no retries) ok: %v",
 			!res.Failed(),
@@ -335,11 +796,30 @@ func (s *Scenario) execSpec(
 	var res *api.Result
 	var err error
 
+	clock := gdtcontext.Clock(ctx)
+	maxElapsed := retry.MaxElapsedDuration()
 	if retry.Exponential {
-		bo = backoff.WithContext(
-			backoff.NewExponentialBackOff(),
-			ctx,
-		)
+		ebo := backoff.NewExponentialBackOff()
+		if retry.Interval != "" {
+			ebo.InitialInterval = retry.IntervalDuration()
+		}
+		if retry.Jitter != nil {
+			ebo.RandomizationFactor = *retry.Jitter
+		}
+		if retry.Multiplier != nil {
+			ebo.Multiplier = *retry.Multiplier
+		}
+		if retry.MaxInterval != "" {
+			ebo.MaxInterval = retry.MaxIntervalDuration()
+		}
+		if maxElapsed > 0 {
+			// max-elapsed is checked below against the next attempt's
+			// start, for both constant and exponential backoff.
+			ebo.MaxElapsedTime = 0
+		}
+		ebo.Clock = clock
+		ebo.Reset()
+		bo = backoff.WithContext(ebo, ctx)
 	} else {
 		interval := api.DefaultRetryConstantInterval
 		if retry.Interval != "" {
@@ -350,50 +830,167 @@ func (s *Scenario) execSpec(
 			ctx,
 		)
 	}
-	ticker := backoff.NewTicker(bo)
 	maxAttempts := 0
 	if retry.Attempts != nil {
 		maxAttempts = *retry.Attempts
 	}
+	required := 1
+	if retry.SuccessesRequired != nil {
+		required = *retry.SuccessesRequired
+	}
 	attempts := 1
-	start := time.Now().UTC()
+	start := clock.Now()
 	success := false
-	for tick := range ticker.C {
+	successes := 0
+	// metrics accumulates the Metrics from every attempt.
+	metrics := &api.Metrics{}
+	// measurements accumulates the recorded values from every attempt.
+	measurements := []api.Measurement{}
+	for {
 		if (maxAttempts > 0) && (attempts > maxAttempts) {
 			debug.Printf(
 				ctx, "spec/run: exceeded max attempts %d. stopping.",
 				maxAttempts,
 			)
-			ticker.Stop()
 			break
 		}
-		after := tick.Sub(start)
+		after := clock.Now().Sub(start)
 
-		res, err = spec.Eval(ctx)
+		res, err = evalAttempt(ctx, spec, attempts, attemptTimeout, grace)
 		if err != nil {
 			ch <- runSpecRes{nil, err}
 			return
 		}
+		collectMetrics(spec, res)
+		metrics.Add(res.Metrics())
+		measurements = append(measurements, res.Measurements()...)
+		if attempts > 1 {
+			metrics.Retries++
+		}
 		success = !res.Failed()
-		debug.Printf(
+		debug.Tracef(
 			ctx, "spec/run: attempt %d after %s ok: %v",
 			attempts, after, success,
 		)
 		if success {
-			ticker.Stop()
-			break
+			successes++
+			if successes >= required {
+				break
+			}
+			debug.Tracef(
+				ctx, "spec/run: %d of %d required consecutive successes",
+				successes, required,
+			)
+		} else {
+			successes = 0
 		}
 		for _, f := range res.Failures() {
-			debug.Printf(
+			debug.Tracef(
 				ctx, "spec/run: attempt %d failure: %s",
 				attempts, f,
 			)
 		}
+		if res.Terminal() {
+			debug.Printf(
+				ctx, "spec/run: attempt %d failure is terminal. stopping.",
+				attempts,
+			)
+			break
+		}
 		attempts++
+		next := bo.NextBackOff()
+		if next == backoff.Stop {
+			break
+		}
+		if maxElapsed > 0 && clock.Now().Sub(start)+next > maxElapsed {
+			debug.Printf(
+				ctx, "spec/run: next attempt exceeds max elapsed %s. stopping.",
+				maxElapsed,
+			)
+			break
+		}
+		if !sleep(ctx, next) {
+			// The test spec's timeout expired or the test run was
+			// interrupted, which runSpec reports once we return.
+			ch <- runSpecRes{res, nil}
+			return
+		}
+	}
+	if res != nil {
+		if success && successes < required {
+			res.SetFailures(api.NotStable(required, successes))
+		}
+		res.SetMetrics(metrics)
+		res.SetMeasurements(measurements...)
 	}
 	ch <- runSpecRes{res, nil}
 }
 
+// clockDeadlineKey is the context key for the time on the context's clock at
+// which a test spec's timeout expires.
+var clockDeadlineKey = gdtcontext.ContextKey("gdt.scenario.clock-deadline")
+
+// sleep waits for the supplied duration to pass on the context's clock and
+// returns true, or returns false if the context is done first. A clock that
+// only moves when it is advanced is advanced by the duration instead, or only
+// as far as the test spec's timeout if that expires first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	progress.Waiting(ctx, d)
+	clock := gdtcontext.Clock(ctx)
+	if ca, ok := clock.(api.ClockAdvancer); ok {
+		deadline, found := ctx.Value(clockDeadlineKey).(time.Time)
+		if found && !clock.Now().Add(d).Before(deadline) {
+			ca.Advance(deadline.Sub(clock.Now()))
+			<-ctx.Done()
+			return false
+		}
+		ca.Advance(d)
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-clock.After(d):
+		return true
+	}
+}
+
+// withClockTimeout returns a copy of the supplied context that is cancelled
+// once the supplied duration has passed on the context's clock, if the clock
+// only moves when it is advanced. This allows a test spec's timeout to expire
+// when its retries advance the clock. Otherwise, the supplied context is
+// returned, as the timeout is measured on the system clock.
+func withClockTimeout(
+	ctx context.Context,
+	d time.Duration,
+) (context.Context, context.CancelFunc) {
+	clock := gdtcontext.Clock(ctx)
+	if _, ok := clock.(api.ClockAdvancer); !ok {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, clockDeadlineKey, clock.Now().Add(d))
+	expired := clock.After(d)
+	go func() {
+		select {
+		case <-expired:
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel(nil)
+	}
+}
+
+// collectMetrics adds the Metrics reported by the supplied test spec to the
+// supplied Result if the test spec implements api.MetricsReporter.
+func collectMetrics(spec api.Evaluable, res *api.Result) {
+	if mr, ok := spec.(api.MetricsReporter); ok {
+		res.AddMetrics(mr.Metrics())
+	}
+}
+
 // hasTimeoutConflict returns true if the scenario or any of its test specs has
 // a wait or timeout that exceeds the go test tool's specified timeout value
 func (s *Scenario) hasTimeoutConflict(
@@ -408,16 +1005,27 @@ func (s *Scenario) hasTimeoutConflict(
 			ctx, "scenario/run: go test tool timeout: %s",
 			(s.Timings.GoTestTimeout + time.Second).Round(time.Second),
 		)
-		if s.Timings.TotalWait > 0 {
-			if s.Timings.TotalWait.Abs() > s.Timings.GoTestTimeout.Abs() {
-				return true
-			}
-		}
-		if s.Timings.MaxTimeout > 0 {
-			if s.Timings.MaxTimeout.Abs() > s.Timings.GoTestTimeout.Abs() {
-				return true
-			}
-		}
+		return s.Timings.Exceed(s.Timings.GoTestTimeout)
+	}
+	return false
+}
+
+// hasRunTimeoutConflict returns true if the scenario or any of its test specs
+// has a wait or timeout that exceeds the time remaining before the supplied
+// Run's deadline, if any.
+func (s *Scenario) hasRunTimeoutConflict(
+	ctx context.Context,
+	run *run.Run,
+) bool {
+	d, ok := run.Deadline()
+	if ok {
+		now := time.Now()
+		s.Timings.RunTimeout = d.Sub(now)
+		debug.Printf(
+			ctx, "scenario/run: run deadline timeout: %s",
+			(s.Timings.RunTimeout + time.Second).Round(time.Second),
+		)
+		return s.Timings.Exceed(s.Timings.RunTimeout)
 	}
 	return false
 }
@@ -432,47 +1040,17 @@ func (s *Scenario) hasTimeoutConflict(
 func getTimeout(
 	ctx context.Context,
 	defaults *Defaults,
-	plugin api.Plugin,
 	eval api.Evaluable,
 ) *api.Timeout {
-	evalTimeout := eval.Timeout()
-	if evalTimeout != nil {
-		debug.Printf(
-			ctx, "using timeout of %s",
-			evalTimeout.After,
-		)
-		return evalTimeout
+	var scDefault *api.Timeout
+	if defaults != nil {
+		scDefault = defaults.Timeout
 	}
-
-	sb := eval.Base()
-	baseTimeout := sb.Timeout
-	if baseTimeout != nil {
-		debug.Printf(
-			ctx, "using timeout of %s",
-			baseTimeout.After,
-		)
-		return baseTimeout
-	}
-
-	if defaults != nil && defaults.Timeout != nil {
-		debug.Printf(
-			ctx, "using timeout of %s [scenario default]",
-			defaults.Timeout.After,
-		)
-		return defaults.Timeout
-	}
-
-	pluginInfo := plugin.Info()
-	pluginTimeout := pluginInfo.Timeout
-
-	if pluginTimeout != nil {
-		debug.Printf(
-			ctx, "using timeout of %s [plugin default]",
-			pluginTimeout.After,
-		)
-		return pluginTimeout
+	to, src := specconfig.Timeout(eval, scDefault)
+	if to != nil {
+		debug.Printf(ctx, "using timeout of %s%s", to.After, sourceSuffix(src))
 	}
-	return nil
+	return to
 }
 
 // getRetry returns the retry configuration for the test spec. We check for
@@ -485,80 +1063,39 @@ func getTimeout(
 func getRetry(
 	ctx context.Context,
 	defaults *Defaults,
-	plugin api.Plugin,
 	eval api.Evaluable,
 ) *api.Retry {
-	evalRetry := eval.Retry()
-	if evalRetry != nil {
-		if evalRetry == api.NoRetry {
-			return evalRetry
-		}
-		msg := "using retry"
-		if evalRetry.Attempts != nil {
-			msg += fmt.Sprintf(" (attempts: %d)", *evalRetry.Attempts)
-		}
-		if evalRetry.Interval != "" {
-			msg += fmt.Sprintf(" (interval: %s)", evalRetry.Interval)
-		}
-		msg += fmt.Sprintf(" (exponential: %t)", evalRetry.Exponential)
-		debug.Println(ctx, msg)
-		return evalRetry
+	var scDefault *api.Retry
+	if defaults != nil {
+		scDefault = defaults.Retry
 	}
-
-	sb := eval.Base()
-	baseRetry := sb.Retry
-	if baseRetry != nil {
-		if baseRetry == api.NoRetry {
-			return baseRetry
-		}
-		msg := "using retry"
-		if baseRetry.Attempts != nil {
-			msg += fmt.Sprintf(" (attempts: %d)", *baseRetry.Attempts)
-		}
-		if baseRetry.Interval != "" {
-			msg += fmt.Sprintf(" (interval: %s)", baseRetry.Interval)
-		}
-		msg += fmt.Sprintf(" (exponential: %t)", baseRetry.Exponential)
-		debug.Println(ctx, msg)
-		return baseRetry
+	rt, src := specconfig.Retry(eval, scDefault)
+	if rt == nil || rt == api.NoRetry {
+		return rt
 	}
-
-	if defaults != nil && defaults.Retry != nil {
-		defaultRetry := defaults.Retry
-		if defaultRetry == api.NoRetry {
-			return defaultRetry
-		}
-		msg := "using retry"
-		if defaultRetry.Attempts != nil {
-			msg += fmt.Sprintf(" (attempts: %d)", *defaultRetry.Attempts)
-		}
-		if defaultRetry.Interval != "" {
-			msg += fmt.Sprintf(" (interval: %s)", defaultRetry.Interval)
-		}
-		msg += fmt.Sprintf(" (exponential: %t) [scenario default]", defaultRetry.Exponential)
-		debug.Println(ctx, msg)
-		return defaultRetry
+	msg := "using retry"
+	if rt.Attempts != nil {
+		msg += fmt.Sprintf(" (attempts: %d)", *rt.Attempts)
 	}
+	if rt.Interval != "" {
+		msg += fmt.Sprintf(" (interval: %s)", rt.Interval)
+	}
+	msg += fmt.Sprintf(" (exponential: %t)%s", rt.Exponential, sourceSuffix(src))
+	debug.Println(ctx, msg)
+	return rt
+}
 
-	pluginInfo := plugin.Info()
-	pluginRetry := pluginInfo.Retry
-
-	if pluginRetry != nil {
-		if pluginRetry == api.NoRetry {
-			return pluginRetry
-		}
-		msg := "using retry"
-		if pluginRetry.Attempts != nil {
-			msg += fmt.Sprintf(" (attempts: %d)", *pluginRetry.Attempts)
-		}
-		if pluginRetry.Interval != "" {
-			msg += fmt.Sprintf(" (interval: %s)", pluginRetry.Interval)
-		}
-		msg += fmt.Sprintf(" (exponential: %t) [plugin default]", pluginRetry.Exponential)
-		debug.Println(ctx, msg)
-		return pluginRetry
+// sourceSuffix returns the suffix of the debug message about a test spec's
+// timeout or retry configuration that says where the configuration came from.
+func sourceSuffix(src specconfig.Source) string {
+	switch src {
+	case specconfig.SourceScenario:
+		return " [scenario default]"
+	case specconfig.SourcePlugin:
+		return " [plugin default]"
+	default:
+		return ""
 	}
-	return nil
 }
 
 // getDefaults returns the Defaults parsed from the scenario's YAML
@@ -8,20 +8,37 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gdt-dev/core/api"
 	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/run"
 	"github.com/gdt-dev/core/scenario"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
+	"github.com/gdt-dev/core/fixture"
+	clockfix "github.com/gdt-dev/core/fixture/clock"
+	"github.com/gdt-dev/core/internal/testutil/fixture/configurable"
+	"github.com/gdt-dev/core/internal/testutil/fixture/dying"
 	"github.com/gdt-dev/core/internal/testutil/fixture/errstarter"
+	"github.com/gdt-dev/core/internal/testutil/fixture/errstopper"
+	"github.com/gdt-dev/core/internal/testutil/plugin/hooks"
+	"github.com/gdt-dev/core/tracing"
 )
 
 var failFlag = flag.Bool("fail", false, "run tests expected to fail")
@@ -56,11 +73,1224 @@ func TestPriorRun(t *testing.T) {
 	require.Nil(err)
 }
 
+func TestPriorRunInterpolation(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "prior-run-interpolation.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	// The test specs' fields are restored after each evaluation, so the
+	// scenario can be run again.
+	for range 2 {
+		r := run.New()
+		err = s.Run(context.TODO(), r)
+		require.Nil(err)
+		require.True(r.OK())
+	}
+}
+
+func TestScenarioHooks(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "hooks.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	hooks.PluginRef.Calls()
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+	require.Equal(
+		[]string{"before:hooks", "eval:one", "eval:two", "after:hooks"},
+		hooks.PluginRef.Calls(),
+	)
+
+	err = s.Run(context.TODO(), run.New())
+	require.Nil(err)
+	require.Equal(
+		[]string{"before:hooks", "eval:one", "eval:two", "after:hooks"},
+		hooks.PluginRef.Calls(),
+	)
+}
+
+func TestScenarioHooksSpecResults(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "hooks-spec-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	// AfterScenario sees the failure of the failing test spec.
+	hooks.PluginRef.Calls()
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+	require.False(r.OK())
+	require.Equal(
+		[]string{
+			"before:hooks-spec-fail",
+			"eval:one",
+			"eval:fail",
+			"after:hooks-spec-fail:failed(failing: hooks spec failed)",
+		},
+		hooks.PluginRef.Calls(),
+	)
+}
+
+func TestScenarioHooksDependsSpecGoTest(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "hooks-depends-spec.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	// Under go test, the test spec with the unmet dependency is skipped
+	// without being evaluated and the rest of the scenario still runs.
+	hooks.PluginRef.Calls()
+	var skipped bool
+	t.Run("go", func(gt *testing.T) {
+		err = s.Run(context.TODO(), gt)
+		skipped = gt.Skipped()
+	})
+	require.Nil(err)
+	require.False(skipped)
+	require.Equal(
+		[]string{
+			"before:hooks-depends-spec",
+			"eval:two",
+			"after:hooks-depends-spec",
+		},
+		hooks.PluginRef.Calls(),
+	)
+}
+
+func TestMetrics(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "hooks.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	require.Equal(1, results[0].Metrics().Requests)
+	require.Equal(int64(3), results[0].Metrics().BytesSent)
+
+	// "one" and "two" are each sent in a single request.
+	total := r.Metrics()
+	require.Equal(2, total.Requests)
+	require.Equal(int64(6), total.BytesSent)
+	require.Equal(0, total.Retries)
+	require.Equal(total, r.ScenarioMetrics(fp))
+}
+
+func TestScenarioHooksBeforeFail(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "hooks-before-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	hooks.PluginRef.Calls()
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrPluginHook)
+	require.ErrorIs(err, api.RuntimeError)
+	require.ErrorContains(err, "hooks.BeforeScenario")
+	require.Equal(
+		[]string{"before:hooks-before-fail"},
+		hooks.PluginRef.Calls(),
+	)
+}
+
+func TestValidateFailure(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "hooks-invalid.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	hooks.PluginRef.Calls()
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrSpecInvalid)
+	require.ErrorIs(err, api.RuntimeError)
+	require.ErrorContains(err, "spec 1 (bad-hooks): hooks must not be invalid")
+	// Validation happens before any plugin hooks are called or test specs
+	// are evaluated.
+	require.Empty(hooks.PluginRef.Calls())
+}
+
+func TestConfigurableFixture(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-params.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	cfix := configurable.New()
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "plain", fixture.New())
+	ctx = gdtcontext.RegisterFixture(ctx, "httpmock", cfix)
+
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	assert.Equal(
+		[]map[string]interface{}{{"port": 8081}},
+		cfix.Started(),
+	)
+}
+
+func TestFixtureScopeSpec(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-scope.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	starts, stops := 0, 0
+	fix := fixture.New(
+		fixture.WithStarter(func(context.Context) error {
+			starts++
+			return nil
+		}),
+		fixture.WithStopper(func(context.Context) {
+			stops++
+		}),
+	)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "perspec", fix)
+
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	assert.Equal(2, starts)
+	assert.Equal(2, stops)
+}
+
+func TestTracing(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-scope.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	ctx := gdtcontext.New(gdtcontext.WithTracerProvider(tp))
+	ctx = gdtcontext.RegisterFixture(ctx, "perspec", fixture.New())
+
+	err = s.Run(ctx, t)
+	require.Nil(err)
+
+	byName := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range rec.Ended() {
+		byName[span.Name()] = append(byName[span.Name()], span)
+	}
+	require.Len(byName[tracing.SpanScenario], 1)
+	require.Len(byName[tracing.SpanSpec], 2)
+	require.Len(byName[tracing.SpanAttempt], 2)
+	require.Len(byName[tracing.SpanFixtureStart], 2)
+	require.Len(byName[tracing.SpanFixtureStop], 2)
+
+	scen := byName[tracing.SpanScenario][0]
+	require.Contains(
+		scen.Attributes(), tracing.AttrScenario.String("fixture-scope"),
+	)
+	spec := byName[tracing.SpanSpec][0]
+	require.Equal(scen.SpanContext().SpanID(), spec.Parent().SpanID())
+	require.Contains(spec.Attributes(), tracing.AttrIndex.Int(0))
+	require.Contains(spec.Attributes(), tracing.AttrFailed.Bool(false))
+	for _, name := range []string{
+		tracing.SpanAttempt, tracing.SpanFixtureStart, tracing.SpanFixtureStop,
+	} {
+		child := byName[name][0]
+		require.Equal(spec.SpanContext().SpanID(), child.Parent().SpanID(), name)
+	}
+	require.Contains(
+		byName[tracing.SpanFixtureStart][0].Attributes(),
+		tracing.AttrFixture.String("perspec"),
+	)
+}
+
+func TestRunIDs(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	var b bytes.Buffer
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	ctx := gdtcontext.New(
+		gdtcontext.WithTracerProvider(tp),
+		gdtcontext.WithDebug(&b),
+	)
+
+	r := run.New(run.WithID("run-1"))
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	specIDs := []string{}
+	for _, res := range results {
+		require.Equal("run-1", res.RunID())
+		require.NotEmpty(res.ID())
+		require.Contains(
+			b.String(), "spec/run: run ID run-1 spec ID "+res.ID(),
+		)
+		specIDs = append(specIDs, res.ID())
+	}
+	require.NotEqual(specIDs[0], specIDs[1])
+
+	for _, span := range rec.Ended() {
+		switch span.Name() {
+		case tracing.SpanScenario:
+			require.Contains(
+				span.Attributes(), tracing.AttrRunID.String("run-1"),
+			)
+		case tracing.SpanSpec:
+			require.Contains(
+				span.Attributes(), tracing.AttrRunID.String("run-1"),
+			)
+			require.Contains(
+				span.Attributes(), tracing.AttrSpecID.String(specIDs[0]),
+			)
+			specIDs = specIDs[1:]
+		}
+	}
+	require.Empty(specIDs)
+
+	// Without a Run, a run ID is generated.
+	rec.Reset()
+	err = s.Run(gdtcontext.New(gdtcontext.WithTracerProvider(tp)), t)
+	require.Nil(err)
+	scen := rec.Ended()[len(rec.Ended())-1]
+	require.Equal(tracing.SpanScenario, scen.Name())
+	runID := ""
+	for _, attr := range scen.Attributes() {
+		if attr.Key == tracing.AttrRunID {
+			runID = attr.Value.AsString()
+		}
+	}
+	require.Len(runID, 32)
+}
+
+func TestStableSpecIDs(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo-ids.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.Empty(s.Warnings)
+	require.Equal("create-widget", s.Tests[0].Base().ID)
+
+	r := run.New()
+	err = s.Run(gdtcontext.New(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	require.Equal("create-widget", results[0].StableID())
+	require.Empty(results[1].StableID())
+}
+
+func TestResultCache(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo-cache.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+
+	dir := t.TempDir()
+	cached := func(opts ...run.Option) []bool {
+		r := run.New(opts...)
+		err := s.Run(gdtcontext.New(), r)
+		require.Nil(err)
+		res := []bool{}
+		for _, tur := range r.ScenarioResults(fp) {
+			res = append(res, tur.Cached())
+		}
+		return res
+	}
+
+	// Only the passing test spec is cached, and only once it has passed.
+	require.Equal([]bool{false, false}, cached(run.WithCache(dir, 0)))
+	require.Equal([]bool{true, false}, cached(run.WithCache(dir, 0)))
+
+	r := run.New(run.WithCache(dir, 0))
+	err = s.Run(gdtcontext.New(), r)
+	require.Nil(err)
+	results := r.ScenarioResults(fp)
+	require.True(results[0].OK())
+	require.Contains(results[0].Detail(), "skipping evaluation")
+	require.False(results[1].OK())
+
+	// Cached passes older than the maximum age are not used.
+	require.Equal([]bool{false, false}, cached(run.WithCache(dir, time.Nanosecond)))
+	// Without a cache, every test spec is evaluated.
+	require.Equal([]bool{false, false}, cached())
+
+	// The run data saved by a cached test spec is restored for later test
+	// specs. The scenario's path is part of the cache key, so the scenario
+	// that caches the test spec has the same path.
+	dir = t.TempDir()
+	fp = filepath.Join("testdata", "prior-run.yaml")
+	first, err := scenario.FromBytes(
+		[]byte("tests:\n  - state: foo\n"), scenario.WithPath(fp),
+	)
+	require.Nil(err)
+	err = first.Run(gdtcontext.New(), run.New(run.WithCache(dir, 0)))
+	require.Nil(err)
+
+	f, err = os.Open(fp)
+	require.Nil(err)
+	s, err = scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	r = run.New(run.WithCache(dir, 0))
+	err = s.Run(gdtcontext.New(), r)
+	require.Nil(err)
+	results = r.ScenarioResults(fp)
+	require.Len(results, 3)
+	require.True(results[0].Cached())
+	require.False(results[1].Cached())
+	require.True(r.OK())
+}
+
+func TestResultCacheInputs(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo-cache-inputs.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+
+	dir := t.TempDir()
+	cached := func(zone string, region string) bool {
+		t.Setenv("GDT_CACHE_ZONE", zone)
+		ctx := gdtcontext.New()
+		ctx = gdtcontext.RegisterFixture(ctx, "region", fixture.New(
+			fixture.WithEnv(map[string]string{"REGION": region}),
+		))
+		r := run.New(run.WithCache(dir, 0))
+		err := s.Run(ctx, r)
+		require.Nil(err)
+		results := r.ScenarioResults(fp)
+		require.Len(results, 1)
+		require.True(results[0].OK())
+		return results[0].Cached()
+	}
+
+	require.False(cached("eu-west-1", "eu"))
+	require.True(cached("eu-west-1", "eu"))
+	// The value of a computed variable is part of the cache key.
+	require.False(cached("us-east-1", "eu"))
+	require.True(cached("us-east-1", "eu"))
+	// So are the environment variables published by the fixtures.
+	require.False(cached("us-east-1", "us"))
+	require.True(cached("us-east-1", "us"))
+}
+
+func TestNotifierWebhook(t *testing.T) {
+	require := require.New(t)
+
+	var got run.Summary
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			contentType = req.Header.Get("Content-Type")
+			require.Nil(json.NewDecoder(req.Body).Decode(&got))
+		},
+	))
+	defer srv.Close()
+
+	failSrv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+	))
+	defer failSrv.Close()
+
+	called := false
+	r := run.New(
+		run.WithID("run-1"),
+		run.WithNotifier(&run.Webhook{URL: failSrv.URL + "/secret-token"}),
+		run.WithNotifier(&run.Webhook{URL: srv.URL}),
+		run.WithNotifier(run.NotifierFunc(
+			func(_ context.Context, sum run.Summary) error {
+				called = true
+				return nil
+			},
+		)),
+	)
+
+	fp := filepath.Join("testdata", "foo-cache.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	err = s.Run(gdtcontext.New(), r)
+	require.Nil(err)
+
+	err = r.Notify(context.TODO())
+	require.ErrorIs(err, api.ErrNotify)
+	require.ErrorContains(err, "500")
+	require.NotContains(err.Error(), "secret-token")
+	require.True(called)
+
+	require.Equal("application/json", contentType)
+	require.Equal("run-1", got.RunID)
+	require.False(got.OK)
+	require.Equal(1, got.Passed)
+	require.Equal(1, got.Failed)
+	require.Len(got.Scenarios, 1)
+	ss := got.Scenarios[0]
+	require.Equal(fp, ss.Path)
+	require.Len(ss.Failures, 1)
+	require.Equal(1, ss.Failures[0].Index)
+	require.NotEmpty(ss.Failures[0].Messages)
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo-cache.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+
+	reg := run.NewMetricsRegistry(60)
+	for range 2 {
+		err = s.Run(gdtcontext.New(), run.New(run.WithMetricsRegistry(reg)))
+		require.Nil(err)
+	}
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(
+		rec, httptest.NewRequest(http.MethodGet, "/metrics", nil),
+	)
+	require.Equal(http.StatusOK, rec.Code)
+	require.Contains(rec.Header().Get("Content-Type"), "version=0.0.4")
+
+	body := rec.Body.String()
+	label := `scenario="` + fp + `"`
+	require.Contains(body, "# TYPE gdt_scenarios_total counter\n")
+	require.Contains(body, "gdt_scenarios_total{"+label+"} 2\n")
+	require.Contains(body, "gdt_scenario_failures_total{"+label+"} 2\n")
+	require.Contains(body, "gdt_specs_total{"+label+`,result="passed"} 2`+"\n")
+	require.Contains(body, "gdt_specs_total{"+label+`,result="failed"} 2`+"\n")
+	require.Contains(body, "# TYPE gdt_spec_duration_seconds histogram\n")
+	require.Contains(body, "gdt_spec_duration_seconds_bucket{"+label+`,le="60"} 4`+"\n")
+	require.Contains(body, "gdt_spec_duration_seconds_bucket{"+label+`,le="+Inf"} 4`+"\n")
+	require.Contains(body, "gdt_spec_duration_seconds_count{"+label+"} 4\n")
+	require.Contains(body, "gdt_spec_retries_total{"+label+"} ")
+}
+
+func TestMetricsRegistryScenarioRuns(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "foo-cache.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+
+	skipFp := filepath.Join("testdata", "skip-if.yaml")
+	f, err = os.Open(skipFp)
+	require.Nil(err)
+	skip, err := scenario.FromReader(f, scenario.WithPath(skipFp))
+	require.Nil(err)
+
+	// Every execution of a scenario counts as a run, even when the
+	// executions share a Run, and a scenario skipped by skip-if counts as
+	// both a run and a skip.
+	reg := run.NewMetricsRegistry()
+	r := run.New(run.WithMetricsRegistry(reg))
+	for range 2 {
+		err = s.Run(gdtcontext.New(), r)
+		require.Nil(err)
+	}
+	err = skip.Run(gdtcontext.New(), r)
+	require.Nil(err)
+	require.Len(r.ScenarioResults(fp), 4)
+
+	b := bytes.Buffer{}
+	require.Nil(reg.Write(&b))
+	body := b.String()
+	label := `scenario="` + fp + `"`
+	require.Contains(body, "gdt_scenarios_total{"+label+"} 2\n")
+	require.Contains(body, "gdt_scenario_failures_total{"+label+"} 2\n")
+	require.Contains(body, "gdt_scenario_skips_total{"+label+"} 0\n")
+	skipLabel := `scenario="` + skipFp + `"`
+	require.Contains(body, "gdt_scenarios_total{"+skipLabel+"} 1\n")
+	require.Contains(body, "gdt_scenario_skips_total{"+skipLabel+"} 1\n")
+	require.Contains(body, "gdt_scenario_failures_total{"+skipLabel+"} 0\n")
+}
+
+func TestWatch(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		t.Run(fmt.Sprintf("poll=%t", poll), func(t *testing.T) {
+			testWatch(t, &run.Watcher{Interval: 10 * time.Millisecond, Poll: poll})
+		})
+	}
+}
+
+func testWatch(t *testing.T, w *run.Watcher) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	fp := filepath.Join(dir, "watched.yaml")
+	pass := []byte("tests:\n  - foo: bar\n    name: bar\n")
+	require.Nil(os.WriteFile(fp, pass, 0o644))
+	require.Nil(os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644))
+
+	events := make(chan api.ProgressEvent, 100)
+	ctx, cancel := context.WithCancel(gdtcontext.New(
+		gdtcontext.WithProgress(func(ev api.ProgressEvent) {
+			if ev.Kind == api.ProgressScenarioChanged ||
+				ev.Kind == api.ProgressScenarioFinished {
+				events <- ev
+			}
+		}),
+	))
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(ctx, dir)
+	}()
+	next := func() api.ProgressEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			require.FailNow("timed out waiting for watch event")
+		}
+		return api.ProgressEvent{}
+	}
+
+	// The scenario runs once when the watch starts...
+	ev := next()
+	require.Equal(api.ProgressScenarioChanged, ev.Kind)
+	require.Equal(fp, ev.Path)
+	ev = next()
+	require.Equal(api.ProgressScenarioFinished, ev.Kind)
+	require.False(ev.Failed)
+	require.Nil(ev.Err)
+
+	// ... and again whenever it is modified.
+	fail := []byte("tests:\n  - foo: bar\n")
+	require.Nil(os.WriteFile(fp, fail, 0o644))
+	later := time.Now().Add(time.Minute)
+	require.Nil(os.Chtimes(fp, later, later))
+	require.Equal(api.ProgressScenarioChanged, next().Kind)
+	ev = next()
+	require.Equal(api.ProgressScenarioFinished, ev.Kind)
+	require.True(ev.Failed)
+
+	// Errors that stop the scenario from running are reported in the event.
+	require.Nil(os.WriteFile(fp, []byte("tests: notalist\n"), 0o644))
+	later = later.Add(time.Minute)
+	require.Nil(os.Chtimes(fp, later, later))
+	require.Equal(api.ProgressScenarioChanged, next().Kind)
+	ev = next()
+	require.True(ev.Failed)
+	require.NotNil(ev.Err)
+
+	// Scenarios in new subdirectories are watched too.
+	sub := filepath.Join(dir, "sub")
+	require.Nil(os.Mkdir(sub, 0o755))
+	subfp := filepath.Join(sub, "new.yml")
+	require.Nil(os.WriteFile(subfp, pass, 0o644))
+	ev = next()
+	require.Equal(api.ProgressScenarioChanged, ev.Kind)
+	require.Equal(subfp, ev.Path)
+	ev = next()
+	require.Equal(api.ProgressScenarioFinished, ev.Kind)
+	require.False(ev.Failed)
+
+	cancel()
+	require.Nil(<-done)
+}
+
+func TestConfigurableFixtureErrors(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-params.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+
+	// httpmock has parameters but the registered fixture does not accept
+	// them.
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "plain", fixture.New())
+	ctx = gdtcontext.RegisterFixture(ctx, "httpmock", fixture.New())
+
+	err = s.Run(ctx, t)
+	assert.ErrorIs(err, api.ErrFixtureConfig)
+	assert.ErrorIs(err, api.RuntimeError)
+	assert.ErrorContains(err, "httpmock does not accept parameters")
+
+	fp = filepath.Join("testdata", "fixture-params-fail.yaml")
+	f, err = os.Open(fp)
+	require.Nil(err)
+
+	s, err = scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+
+	ctx = gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "httpmock", configurable.New())
+
+	err = s.Run(ctx, t)
+	assert.ErrorIs(err, api.ErrFixtureConfig)
+	assert.ErrorContains(err, "fail parameter supplied")
+}
+
 func TestMissingFixtures(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "foo-fixtures.yaml")
+	fp := filepath.Join("testdata", "foo-fixtures.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	// Pass a context with no fixtures registered...
+	err = s.Run(context.TODO(), t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrRequiredFixture)
+	assert.ErrorIs(err, api.RuntimeError)
+}
+
+func TestMissingDepends(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "missing-depends.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	assert.ErrorIs(err, api.RuntimeError)
+}
+
+func TestDependsNotSatisfiedOS(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "depends-not-satisfied-os.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	if runtime.GOOS == "windows" {
+		assert.NotNil(err)
+		assert.ErrorIs(err, api.ErrDependencyNotSatisfied)
+		assert.ErrorIs(err, api.RuntimeError)
+	} else {
+		assert.Nil(err)
+	}
+}
+
+func TestDependsWhen(t *testing.T) {
+	if runtime.GOARCH == "s390x" {
+		t.Skip("skipping s390x host")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-when.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestDependsWhenEnvSet(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH == "s390x" {
+		t.Skip("skipping non-linux or s390x host")
+	}
+	require := require.New(t)
+
+	t.Setenv("GDT_DEPENDS_WHEN_ENV", "1")
+
+	fp := filepath.Join("testdata", "depends-when.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorContains(err, "(OS:linux,env:GDT_DEPENDS_WHEN_ENV)")
+}
+
+func TestDependsNotSatisfiedKernelVersion(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping non-linux host")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-not-satisfied-kernel-version.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorContains(err, "kernel:>= 1.0")
+}
+
+func TestDependsNotSatisfiedVersionConstraint(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping non-linux host")
+	}
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "depends-not-satisfied-version-constraint.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	assert.ErrorIs(err, api.RuntimeError)
+}
+
+func serviceServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/teapot" {
+				w.WriteHeader(http.StatusTeapot)
+			}
+		},
+	))
+	t.Setenv("GDT_DEPENDS_SERVICE_ADDR", srv.Listener.Addr().String())
+	t.Setenv("GDT_DEPENDS_SERVICE_URL", srv.URL)
+	return srv
+}
+
+func TestDependsService(t *testing.T) {
+	require := require.New(t)
+
+	srv := serviceServer(t)
+	defer srv.Close()
+
+	fp := filepath.Join("testdata", "depends-service.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestDependsServiceNotReachable(t *testing.T) {
+	require := require.New(t)
+
+	srv := serviceServer(t)
+
+	fp := filepath.Join("testdata", "depends-service.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	srv.Close()
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorIs(err, api.RuntimeError)
+	require.ErrorContains(err, "tcp://"+srv.Listener.Addr().String()+" not reachable")
+}
+
+func TestDependsServiceNotSatisfiedStatus(t *testing.T) {
+	require := require.New(t)
+
+	srv := serviceServer(t)
+	defer srv.Close()
+
+	fp := filepath.Join("testdata", "depends-service-status.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorContains(err, "api ("+srv.URL+"/teapot) returned status 418, expected 200")
+}
+
+func TestDependsPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping windows host")
+	}
+	require := require.New(t)
+
+	credsPath := filepath.Join(t.TempDir(), "credentials")
+	require.Nil(os.WriteFile(credsPath, []byte("secret"), 0o600))
+	t.Setenv("GDT_DEPENDS_PATH_FILE", credsPath)
+	t.Setenv("GDT_DEPENDS_PATH_OWNER", strconv.Itoa(os.Getuid()))
+
+	fp := filepath.Join("testdata", "depends-path.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+
+	require.Nil(os.Chmod(credsPath, 0o644))
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorContains(err, "has mode 0644, expected 0600")
+}
+
+func TestDependsPathNotFound(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-path-not-found.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorIs(err, api.RuntimeError)
+	require.ErrorContains(err, "kubeconfig (file nonexisting/kubeconfig) not found")
+}
+
+func TestDependsVersionPackageManager(t *testing.T) {
+	if _, err := exec.LookPath("dpkg-query"); err != nil {
+		t.Skip("skipping host without dpkg")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-version-package-manager.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestDependsVersionPackageNotInstalled(t *testing.T) {
+	if _, err := exec.LookPath("dpkg-query"); err != nil {
+		t.Skip("skipping host without dpkg")
+	}
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-version-package-not-installed.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorIs(err, api.RuntimeError)
+}
+
+// jsonVersionBinary puts a gdt-json-version program that outputs its version
+// as JSON in the PATH.
+func jsonVersionBinary(t *testing.T) {
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+echo '{"clientVersion": {"gitVersion": "v1.30.2", "major": "1"}}'
+`
+	err := os.WriteFile(
+		filepath.Join(binDir, "gdt-json-version"), []byte(script), 0o755,
+	)
+	require.Nil(t, err)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestDependsVersionJSONPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping windows host")
+	}
+	require := require.New(t)
+
+	jsonVersionBinary(t)
+
+	fp := filepath.Join("testdata", "depends-version-json-path.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestDependsVersionJSONPathNotMatched(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping windows host")
+	}
+	require := require.New(t)
+
+	jsonVersionBinary(t)
+
+	fp := filepath.Join("testdata", "depends-version-json-path-not-matched.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorContains(err, `using JSONPath "$.serverVersion.gitVersion"`)
+}
+
+func TestDependsSpec(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-spec.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+	require.Len(s.Tests[0].Base().Depends, 1)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	require.True(results[0].Skipped())
+	require.Contains(
+		results[0].Detail(),
+		"dependency not satisfied: nonexistingbinary",
+	)
+	require.False(results[1].Skipped())
+	require.True(results[1].OK())
+
+	// Under go test, the test spec is passed over and the rest of the
+	// scenario still runs.
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+}
+
+func TestDependsMissingSkip(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-missing-skip.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	for _, res := range results {
+		require.True(res.Skipped())
+		require.Contains(
+			res.Detail(),
+			"dependency not satisfied: nonexistingbinary. skipping test.",
+		)
+	}
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+	require.True(t.Skipped())
+}
+
+func TestDependsMissingSkipDefault(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-missing-skip-default.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	r := run.New()
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.True(results[0].Skipped())
+}
+
+func TestDependsMissingFail(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "depends-missing-fail.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	require.ErrorContains(err, "othernonexistingbinary")
+}
+
+func TestTimeoutConflictTotalWait(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "timeout-conflict-total-wait.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrTimeoutConflict)
+	assert.ErrorIs(err, api.RuntimeError)
+}
+
+func TestTimeoutConflictSpecTimeout(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "timeout-conflict-spec-timeout.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	err = s.Run(context.TODO(), t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrTimeoutConflict)
+	assert.ErrorIs(err, api.RuntimeError)
+}
+
+func TestTimeoutConflictDefaultTimeout(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "timeout-conflict-default-timeout.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -68,18 +1298,17 @@ func TestMissingFixtures(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	// Pass a context with no fixtures registered...
 	err = s.Run(context.TODO(), t)
 	assert.NotNil(err)
-	assert.ErrorIs(err, api.ErrRequiredFixture)
+	assert.ErrorIs(err, api.ErrTimeoutConflict)
 	assert.ErrorIs(err, api.RuntimeError)
 }
 
-func TestMissingDepends(t *testing.T) {
+func TestTimeoutConflictRunDeadline(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "missing-depends.yaml")
+	fp := filepath.Join("testdata", "timeout-conflict-spec-timeout.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -87,17 +1316,25 @@ func TestMissingDepends(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	err = s.Run(context.TODO(), t)
+	r := run.New(run.WithDeadline(time.Now().Add(5 * time.Second)))
+	err = s.Run(context.TODO(), r)
 	assert.NotNil(err)
-	assert.ErrorIs(err, api.ErrDependencyNotSatisfied)
+	assert.ErrorIs(err, api.ErrTimeoutConflict)
 	assert.ErrorIs(err, api.RuntimeError)
+	assert.ErrorContains(err, "run deadline of")
+	assert.ErrorContains(err, "is shorter than the maximum timeout")
+
+	r = run.New(run.WithDeadline(time.Now().Add(time.Hour)))
+	err = s.Run(context.TODO(), r)
+	require.Nil(err)
+	require.True(r.OK())
 }
 
-func TestDependsNotSatisfiedOS(t *testing.T) {
+func TestFixtureStartError(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "depends-not-satisfied-os.yaml")
+	fp := filepath.Join("testdata", "fixture-start-error.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -105,24 +1342,154 @@ func TestDependsNotSatisfiedOS(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	err = s.Run(context.TODO(), t)
-	if runtime.GOOS == "windows" {
-		assert.NotNil(err)
-		assert.ErrorIs(err, api.ErrDependencyNotSatisfied)
-		assert.ErrorIs(err, api.RuntimeError)
-	} else {
-		assert.Nil(err)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "start-error", errstarter.Fixture)
+
+	err = s.Run(ctx, t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrFixtureStart)
+	assert.ErrorContains(err, "error starting fixture!")
+}
+
+func TestFixtureStartTimeout(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-start-timeout.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	slow := fixture.New(
+		fixture.WithStarter(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "slow", slow)
+
+	err = s.Run(ctx, t)
+	assert.NotNil(err)
+	assert.ErrorIs(err, api.ErrFixtureStart)
+	assert.Equal(api.CodeFixtureStart, api.ErrorCode(err))
+	assert.ErrorContains(err, "slow did not start within 50ms")
+}
+
+func TestFixtureSlowStart(t *testing.T) {
+	require := require.New(t)
+
+	// The scenarios have no path, because running a scenario with a path
+	// changes the working directory.
+	parse := func(name string) *scenario.Scenario {
+		f, err := os.Open(filepath.Join("testdata", name))
+		require.Nil(err)
+		defer f.Close()
+		s, err := scenario.FromReader(f)
+		require.Nil(err)
+		return s
+	}
+	slowSc := parse("fixture-slow-start.yaml")
+	fastSc := parse("fixture-fast-start.yaml")
+
+	starting := make(chan struct{})
+	unblock := make(chan struct{})
+	slow := fixture.New(
+		fixture.WithStarter(func(context.Context) error {
+			close(starting)
+			<-unblock
+			return nil
+		}),
+	)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "slow", slow)
+	ctx = gdtcontext.RegisterFixture(ctx, "fast", fixture.New())
+
+	slowErr := make(chan error, 1)
+	go func() {
+		slowErr <- slowSc.Run(ctx, run.New())
+	}()
+	<-starting
+
+	// A fixture that is slow to start does not hold up a scenario that uses
+	// another fixture.
+	fastErr := make(chan error, 1)
+	go func() {
+		fastErr <- fastSc.Run(ctx, run.New())
+	}()
+	select {
+	case err := <-fastErr:
+		require.Nil(err)
+	case <-time.After(2 * time.Second):
+		require.Fail("scenario blocked by another scenario's fixture starting")
 	}
+
+	close(unblock)
+	require.Nil(<-slowErr)
 }
 
-func TestDependsNotSatisfiedVersionConstraint(t *testing.T) {
-	if runtime.GOOS != "linux" {
-		t.Skip("skipping non-linux host")
+func TestFixtureSameNameDifferentContexts(t *testing.T) {
+	require := require.New(t)
+
+	// The scenario has no path, because running a scenario with a path
+	// changes the working directory.
+	f, err := os.Open(filepath.Join("testdata", "fixture-slow-start.yaml"))
+	require.Nil(err)
+	defer f.Close()
+	s, err := scenario.FromReader(f)
+	require.Nil(err)
+
+	starting := make(chan struct{})
+	unblock := make(chan struct{})
+	first := fixture.New(
+		fixture.WithStarter(func(context.Context) error {
+			close(starting)
+			<-unblock
+			return nil
+		}),
+	)
+	secondStarted := false
+	second := fixture.New(
+		fixture.WithStarter(func(context.Context) error {
+			secondStarted = true
+			return nil
+		}),
+	)
+	firstCtx := gdtcontext.RegisterFixture(gdtcontext.New(), "slow", first)
+	secondCtx := gdtcontext.RegisterFixture(gdtcontext.New(), "slow", second)
+
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- s.Run(firstCtx, run.New())
+	}()
+	<-starting
+
+	// A fixture registered under the same name with another context is a
+	// different fixture, so it is started rather than shared.
+	secondErr := make(chan error, 1)
+	go func() {
+		secondErr <- s.Run(secondCtx, run.New())
+	}()
+	select {
+	case err := <-secondErr:
+		require.Nil(err)
+		require.True(secondStarted)
+	case <-time.After(2 * time.Second):
+		require.Fail("scenario waited for another context's fixture")
 	}
+
+	close(unblock)
+	require.Nil(<-firstErr)
+}
+
+func TestFixtureHealthRestart(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "depends-not-satisfied-version-constraint.yaml")
+	fp := filepath.Join("testdata", "fixture-health-restart.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -130,17 +1497,20 @@ func TestDependsNotSatisfiedVersionConstraint(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	err = s.Run(context.TODO(), t)
-	assert.NotNil(err)
-	assert.ErrorIs(err, api.ErrDependencyNotSatisfied)
-	assert.ErrorIs(err, api.RuntimeError)
+	fix := dying.New()
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "dying", fix)
+
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	assert.Equal(2, fix.Starts())
 }
 
-func TestTimeoutConflictTotalWait(t *testing.T) {
+func TestFixtureHealthRestartFailed(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "timeout-conflict-total-wait.yaml")
+	fp := filepath.Join("testdata", "fixture-health-restart-suite.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -148,17 +1518,34 @@ func TestTimeoutConflictTotalWait(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	err = s.Run(context.TODO(), t)
-	assert.NotNil(err)
-	assert.ErrorIs(err, api.ErrTimeoutConflict)
-	assert.ErrorIs(err, api.RuntimeError)
+	fix := dying.NewFailingRestart()
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "dying", fix)
+
+	release, err := s.AcquireFixtures(ctx, api.FixtureScopeSuite)
+	require.Nil(err)
+	assert.Equal(1, fix.Starts())
+
+	err = s.Run(ctx, t)
+	assert.ErrorIs(err, api.ErrFixtureDied)
+	assert.ErrorContains(err, "fixture failed to restart!")
+	assert.Equal(2, fix.Starts())
+
+	// The fixture that failed to restart is no longer shared, so acquiring
+	// it again starts it anew.
+	again, err := s.AcquireFixtures(ctx, api.FixtureScopeSuite)
+	require.Nil(err)
+	assert.Equal(3, fix.Starts())
+
+	require.Nil(release())
+	require.Nil(again())
 }
 
-func TestTimeoutConflictSpecTimeout(t *testing.T) {
+func TestFixtureHealthFail(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "timeout-conflict-spec-timeout.yaml")
+	fp := filepath.Join("testdata", "fixture-health-fail.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -166,35 +1553,50 @@ func TestTimeoutConflictSpecTimeout(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(s)
 
-	err = s.Run(context.TODO(), t)
-	assert.NotNil(err)
-	assert.ErrorIs(err, api.ErrTimeoutConflict)
-	assert.ErrorIs(err, api.RuntimeError)
+	fix := dying.New()
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "dying", fix)
+
+	err = s.Run(ctx, t)
+	assert.ErrorIs(err, api.ErrFixtureDied)
+	assert.Equal(api.CodeFixtureDied, api.ErrorCode(err))
+	assert.ErrorContains(err, "dying: fixture died!")
+	assert.Equal(1, fix.Starts())
 }
 
-func TestTimeoutConflictDefaultTimeout(t *testing.T) {
+func TestFixtureSetState(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "timeout-conflict-default-timeout.yaml")
+	fp := filepath.Join("testdata", "fixture-set-state.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
 	s, err := scenario.FromReader(f, scenario.WithPath(fp))
 	require.Nil(err)
 	require.NotNil(s)
+	assert.Equal(
+		map[string]map[string]interface{}{
+			"flags": {"feature": "enabled", "retries": 3},
+		},
+		s.Tests[0].Base().Set,
+	)
+
+	flags := fixture.New()
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "flags", flags)
 
-	err = s.Run(context.TODO(), t)
-	assert.NotNil(err)
-	assert.ErrorIs(err, api.ErrTimeoutConflict)
-	assert.ErrorIs(err, api.RuntimeError)
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	assert.Equal("enabled", flags.State("feature"))
+	assert.Equal(3, flags.State("retries"))
 }
 
-func TestFixtureStartError(t *testing.T) {
+func TestFixtureSetStateNotSettable(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 
-	fp := filepath.Join("testdata", "fixture-start-error.yaml")
+	fp := filepath.Join("testdata", "fixture-set-state-unsettable.yaml")
 	f, err := os.Open(fp)
 	require.Nil(err)
 
@@ -203,11 +1605,296 @@ func TestFixtureStartError(t *testing.T) {
 	require.NotNil(s)
 
 	ctx := gdtcontext.New()
-	ctx = gdtcontext.RegisterFixture(ctx, "start-error", errstarter.Fixture)
+	ctx = gdtcontext.RegisterFixture(ctx, "plain", configurable.New())
+
+	err = s.Run(ctx, t)
+	assert.ErrorIs(err, api.ErrFixtureState)
+	assert.Equal(api.CodeFixtureState, api.ErrorCode(err))
+	assert.ErrorContains(err, "plain does not accept state changes")
+}
+
+func TestFixtureFactory(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-set-state.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	built := []string{}
+	factory := func(name string) api.FixtureFactory {
+		return func(context.Context) (api.Fixture, error) {
+			built = append(built, name)
+			return fixture.New(), nil
+		}
+	}
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixtureFactory(ctx, "flags", factory("flags"))
+	ctx = gdtcontext.RegisterFixtureFactory(ctx, "unused", factory("unused"))
+
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	// Only the fixture referenced by the scenario is constructed.
+	assert.Equal([]string{"flags"}, built)
+	flags := gdtcontext.Fixtures(ctx)["flags"]
+	assert.Equal("enabled", flags.State("feature"))
+}
+
+func TestFixtureFactoryError(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-set-state.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixtureFactory(
+		ctx, "flags",
+		func(context.Context) (api.Fixture, error) {
+			return nil, fmt.Errorf("no flags today")
+		},
+	)
+
+	err = s.Run(ctx, t)
+	assert.ErrorIs(err, api.ErrFixtureStart)
+	assert.ErrorContains(err, "flags: no flags today")
+}
+
+func TestFixtureClock(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-clock.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockfix.New(clockfix.WithStart(start))
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "clock", clock)
+
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	assert.Equal(start.Add(2*time.Hour), clock.Now())
+}
+
+func TestFixtureClockWaitsAndRetries(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-clock-retry.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockfix.New(clockfix.WithStart(start))
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "clock", clock)
+
+	began := time.Now()
+	r := run.New()
+	err = s.Run(ctx, r)
+	require.Nil(err)
+	require.Less(time.Since(began), 5*time.Second)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	require.True(results[0].OK())
+	require.False(results[1].OK())
+	require.ErrorContains(results[1].Failures()[0], "timeout exceeded (30m)")
+	require.Equal(start.Add(90*time.Minute), clock.Now())
+}
+
+func TestFixtureClockRetryExponential(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-clock-retry-exponential.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockfix.New(clockfix.WithStart(start))
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "clock", clock)
+
+	r := run.New()
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.Equal(4, results[0].Metrics().Retries)
+	require.Equal(start.Add(9*time.Minute), clock.Now())
+}
+
+func TestFixtureClockRetrySuccessesRequired(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-clock-retry-successes.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockfix.New(clockfix.WithStart(start))
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "clock", clock)
+
+	r := run.New()
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 2)
+	require.True(results[0].OK())
+	require.Equal(2, results[0].Metrics().Retries)
+	require.False(results[1].OK())
+	require.ErrorIs(results[1].Failures()[0], api.ErrNotStable)
+	require.ErrorContains(
+		results[1].Failures()[0],
+		"passed 2 of 3 required consecutive attempts",
+	)
+	require.Equal(start.Add(3*time.Minute), clock.Now())
+}
+
+func TestFixtureClockRetryTerminal(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-clock-retry-terminal.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockfix.New(clockfix.WithStart(start))
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "clock", clock)
+
+	r := run.New()
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	results := r.ScenarioResults(fp)
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.True(api.IsTerminal(results[0].Failures()[0]))
+	require.Equal(0, results[0].Metrics().Retries)
+	require.Equal(start, clock.Now())
+}
+
+func TestProgress(t *testing.T) {
+	require := require.New(t)
+
+	fp := filepath.Join("testdata", "fixture-clock-retry.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	var mu sync.Mutex
+	events := []api.ProgressEvent{}
+	ctx := gdtcontext.New(gdtcontext.WithProgress(func(ev api.ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}))
+	ctx = gdtcontext.RegisterFixture(ctx, "clock", clockfix.New())
+
+	r := run.New()
+	err = s.Run(ctx, r)
+	require.Nil(err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	got := []string{}
+	for _, ev := range events {
+		desc := ev.Kind.String()
+		switch ev.Kind {
+		case api.ProgressFixtureStarting:
+			desc += " " + ev.Fixture
+		case api.ProgressSpecStarted:
+			desc += " " + ev.Trace
+		case api.ProgressAttempt:
+			desc += fmt.Sprintf(" %d", ev.Attempt)
+		case api.ProgressWaiting:
+			desc += " " + ev.Wait.String()
+		case api.ProgressSpecFinished:
+			desc += fmt.Sprintf(" failed=%t", ev.Failed)
+		}
+		got = append(got, desc)
+		require.Equal(r.ID(), ev.RunID)
+	}
+	require.Equal([]string{
+		"fixture-starting clock",
+		"spec-started fixture-clock-retry/0:bar",
+		"waiting 1h0m0s",
+		"attempt 1",
+		"spec-finished failed=false",
+		"spec-started fixture-clock-retry/1:baz",
+		"attempt 1",
+		"waiting 10m0s",
+		"attempt 2",
+		"waiting 10m0s",
+		"attempt 3",
+		"waiting 10m0s",
+		"spec-finished failed=true",
+	}, got)
+
+	results := r.ScenarioResults(fp)
+	require.Empty(events[0].SpecID)
+	require.Equal(results[0].ID(), events[1].SpecID)
+	require.Equal(results[1].ID(), events[len(events)-1].SpecID)
+}
+
+func TestFixtureStopError(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	fp := filepath.Join("testdata", "fixture-stop-error.yaml")
+	f, err := os.Open(fp)
+	require.Nil(err)
+
+	s, err := scenario.FromReader(f, scenario.WithPath(fp))
+	require.Nil(err)
+	require.NotNil(s)
+
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixtureV2(ctx, "stop-error", errstopper.Fixture)
 
 	err = s.Run(ctx, t)
 	assert.NotNil(err)
-	assert.ErrorContains(err, "error starting fixture!")
+	assert.ErrorIs(err, api.ErrFixtureStop)
+	assert.Equal(api.CodeFixtureStop, api.ErrorCode(err))
+	assert.ErrorContains(err, "error stopping fixture!")
 }
 
 func TestDebugFlushing(t *testing.T) {
@@ -6,6 +6,7 @@ package scenario
 
 import (
 	gopath "path"
+	"time"
 
 	"github.com/gdt-dev/core/api"
 )
@@ -33,6 +34,46 @@ type Scenario struct {
 	Defaults map[string]interface{} `yaml:"defaults,omitempty"`
 	// Fixtures specifies an ordered list of fixtures the test case depends on.
 	Fixtures []string `yaml:"fixtures,omitempty"`
+	// FixtureParams contains the parameters, keyed by lowercased fixture
+	// name, from the `with` field of map entries in the `fixtures` field.
+	// Fixtures with parameters must implement api.ConfigurableFixture.
+	FixtureParams map[string]map[string]interface{} `yaml:"-"`
+	// FixtureScopes contains the scopes, keyed by lowercased fixture name,
+	// from the `scope` field of map entries in the `fixtures` field. Fixtures
+	// without a scope have api.FixtureScopeScenario.
+	FixtureScopes map[string]api.FixtureScope `yaml:"-"`
+	// FixtureTimeouts contains the start timeouts, keyed by lowercased
+	// fixture name, from the `timeout` field of map entries in the
+	// `fixtures` field. Fixtures without a timeout are not bounded.
+	FixtureTimeouts map[string]time.Duration `yaml:"-"`
+	// FixtureHealths contains the health policies, keyed by lowercased
+	// fixture name, from the `health` field of map entries in the `fixtures`
+	// field. Fixtures with a health policy that implement api.HealthChecker
+	// are checked before each test spec.
+	FixtureHealths map[string]api.FixtureHealth `yaml:"-"`
+	// Vars contains the variables, keyed by name, from the `vars` field. The
+	// variables' values are seeded into the run data before the first test
+	// spec runs, so test specs refer to them like variables saved by a prior
+	// test spec.
+	Vars map[string]*Var `yaml:"-"`
+	// VarFiles contains the paths, relative to the scenario file, of YAML or
+	// JSON files from the `var-files` field. The keys and values of the map
+	// in each file are seeded into the run data before the scenario's Vars,
+	// with later files overriding earlier ones.
+	VarFiles []string `yaml:"var-files,omitempty"`
+	// Exports contains the names, from the `exports` field, of the variables
+	// whose values are handed to later scenarios in the same test suite run
+	// once the scenario's test specs have run.
+	Exports []string `yaml:"exports,omitempty"`
+	// Imports contains the names, from the `imports` field, of the variables
+	// exported by earlier scenarios in the same test suite run that are
+	// seeded into the run data before the scenario's Vars.
+	Imports []string `yaml:"imports,omitempty"`
+	// Secrets contains the secrets, keyed by name, from the `secrets` field.
+	// The secrets' values are resolved when the scenario runs, masked
+	// wherever test output is written and seeded into the run data before
+	// the scenario's Vars, so test specs refer to them like variables.
+	Secrets map[string]*Secret `yaml:"-"`
 	// SkipIf contains a list of evaluable conditions. If any of the conditions
 	// evaluates successfully, the test scenario will be skipped.  This allows
 	// test authors to specify "pre-flight checks" that should pass before
@@ -74,10 +115,25 @@ type Scenario struct {
 	//
 	// With the above, if an 'nginx' deployment exists already, the scenario
 	// will skip all the tests.
+	//
+	// An entry can also be an expression, e.g. `- expr: env.CI == "true"`,
+	// which passes when the expression is true.
 	SkipIf []api.Evaluable `yaml:"skip-if,omitempty"`
+	// RunIf contains a list of evaluable conditions, like SkipIf, that must
+	// all evaluate successfully for the test scenario to run. If any of the
+	// conditions fails, the test scenario will be skipped.
+	RunIf []api.Evaluable `yaml:"run-if,omitempty"`
 	// Tests is the collection of test units in this test case. These will be
 	// the fully parsed and materialized plugin Spec structs.
 	Tests []api.Evaluable `yaml:"tests,omitempty"`
+	// Warnings contains problems found while parsing the scenario that do not
+	// prevent the scenario from being run, for example a test spec that more
+	// than one plugin is able to parse. Linting reports these warnings.
+	Warnings []error `yaml:"-"`
+	// digests contains, for each of Tests, the hex-encoded SHA-256 digest of
+	// the test spec's YAML, after environment variables are expanded, and of
+	// the scenario's defaults.
+	digests []string
 }
 
 // Title returns the Name of the scenario or the Path's file/base name if there
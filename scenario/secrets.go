@@ -0,0 +1,165 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/parse"
+)
+
+// Secret is a secret from the scenario's `secrets` field. Its value is
+// resolved when the scenario runs from an environment variable, a file or the
+// output of a command, and is masked wherever `gdt` writes test output.
+type Secret struct {
+	// Env is the name of the environment variable whose value is the
+	// secret. Environment variables published by fixtures take precedence
+	// over those of the process.
+	Env string
+	// File is the path, relative to the scenario file, of the file whose
+	// contents, without trailing newlines, are the secret.
+	File string
+	// Command is the command, run from the scenario file's directory, whose
+	// standard output, without trailing newlines, is the secret, e.g. `vault
+	// kv get -field=token secret/ci`. The command is not run in a shell.
+	Command string
+}
+
+// value returns the secret's value. An empty value is an error, since it
+// cannot be masked and is almost certainly a misconfiguration.
+func (sec *Secret) value(ctx context.Context) (string, error) {
+	var val string
+	switch {
+	case sec.Env != "":
+		v, found := gdtcontext.Env(ctx)[sec.Env]
+		if !found {
+			v = os.Getenv(sec.Env)
+		}
+		if v == "" {
+			return "", fmt.Errorf("environment variable %s is not set", sec.Env)
+		}
+		val = v
+	case sec.File != "":
+		b, err := os.ReadFile(sec.File)
+		if err != nil {
+			return "", err
+		}
+		val = strings.TrimRight(string(b), "\r\n")
+	default:
+		args, err := shlex.Split(sec.Command)
+		if err != nil {
+			return "", err
+		}
+		if len(args) == 0 {
+			return "", errors.New("empty command")
+		}
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("%s: %w: %s", args[0], err, msg)
+			}
+			return "", fmt.Errorf("%s: %w", args[0], err)
+		}
+		val = strings.TrimRight(string(out), "\r\n")
+	}
+	if val == "" {
+		return "", errors.New("resolved to an empty value")
+	}
+	return val, nil
+}
+
+// source describes where the secret's value comes from for debug output.
+func (sec *Secret) source() string {
+	switch {
+	case sec.Env != "":
+		return "env " + sec.Env
+	case sec.File != "":
+		return "file " + sec.File
+	default:
+		return "command " + sec.Command
+	}
+}
+
+// parseSecrets parses the supplied `secrets` map node. Each entry's value is a
+// map with an `env`, `file` or `command` field. Unlike variables, secrets
+// cannot have literal values, which would put the secret in the scenario file.
+func (s *Scenario) parseSecrets(node *yaml.Node) error {
+	secrets := map[string]*Secret{}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		valNode := node.Content[i+1]
+		if valNode.Kind != yaml.MappingNode || len(valNode.Content) != 2 {
+			return parse.ExpectedMapAt(valNode)
+		}
+		fieldNode := valNode.Content[0]
+		refNode := valNode.Content[1]
+		if refNode.Kind != yaml.ScalarNode || refNode.Value == "" {
+			return parse.ExpectedScalarAt(refNode)
+		}
+		sec := &Secret{}
+		switch fieldNode.Value {
+		case "env":
+			sec.Env = refNode.Value
+		case "file":
+			if _, err := os.Stat(refNode.Value); err != nil {
+				return parse.FileNotFoundAt(refNode.Value, refNode)
+			}
+			sec.File = refNode.Value
+		case "command":
+			sec.Command = refNode.Value
+		default:
+			return parse.UnknownFieldAt(fieldNode.Value, fieldNode)
+		}
+		secrets[keyNode.Value] = sec
+	}
+	s.Secrets = secrets
+	return nil
+}
+
+// withSecrets returns a copy of the supplied context with the scenario's
+// secrets resolved, registered with the context so that they are masked, and
+// seeded into the run data, or an ErrVariable if any of them cannot be
+// resolved.
+func (s *Scenario) withSecrets(ctx context.Context) (context.Context, error) {
+	if len(s.Secrets) == 0 {
+		return ctx, nil
+	}
+	data := make(map[string]any, len(s.Secrets))
+	values := make([]string, 0, len(s.Secrets))
+	names := lo.Keys(s.Secrets)
+	slices.Sort(names)
+	for _, name := range names {
+		sec := s.Secrets[name]
+		val, err := sec.value(ctx)
+		if err != nil {
+			return ctx, api.SecretLoadFailed(name, err)
+		}
+		debug.Printf(ctx, "secrets: %s resolved from %s", name, sec.source())
+		data[name] = val
+		values = append(values, val)
+	}
+	ctx = gdtcontext.AddSecrets(ctx, values...)
+	return gdtcontext.SetRun(ctx, data), nil
+}
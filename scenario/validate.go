@@ -0,0 +1,32 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+
+	"github.com/gdt-dev/core/api"
+)
+
+// Validate asks the plugin of each of the scenario's test specs to validate
+// the test spec, if the plugin implements api.Validator. Each returned error
+// is an api.ErrSpecInvalid.
+func (s *Scenario) Validate(ctx context.Context) []error {
+	errs := []error{}
+	specs := append([]api.Evaluable{}, s.SkipIf...)
+	specs = append(specs, s.RunIf...)
+	specs = append(specs, s.Tests...)
+	for _, sp := range specs {
+		base := sp.Base()
+		v, ok := base.Plugin.(api.Validator)
+		if !ok {
+			continue
+		}
+		for _, err := range v.Validate(ctx, sp) {
+			errs = append(errs, api.SpecInvalid(base, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,352 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+	"github.com/gdt-dev/core/expr"
+	"github.com/gdt-dev/core/parse"
+	"github.com/gdt-dev/core/plugin/pluginutil"
+)
+
+// Var is a variable from the scenario's `vars` field. Its value is either a
+// literal scalar, the value of an environment variable, the contents of a file
+// or the result of an expression. A variable can declare the type of its
+// value and constraints that the value must meet.
+type Var struct {
+	// Value is the literal value of the variable.
+	Value any
+	// Env is the name of the environment variable whose value is the
+	// variable's value. Environment variables published by fixtures take
+	// precedence over those of the process.
+	Env string
+	// File is the path, relative to the scenario file, of the file whose
+	// contents are the variable's value.
+	File string
+	// Expr is the expression that computes the variable's value the first
+	// time the variable is referenced.
+	Expr *expr.Expr
+	// Type is the declared type of the variable's value, one of "string",
+	// "int", "float" or "bool". The value is converted to the type, so that
+	// e.g. an environment variable's "5" is the integer 5. If empty, the
+	// value is left as is.
+	Type string
+	// Enum contains the values that the variable's value must be one of.
+	Enum []any
+	// Pattern is a regular expression that the variable's value must match.
+	Pattern *regexp.Regexp
+	// exprNode is the YAML node of the variable's expression.
+	exprNode *yaml.Node
+}
+
+// value returns the variable's value, converted to the variable's type and
+// checked against its constraints. The value of a variable with an expression
+// is a Lazy that computes and checks the value when first referenced.
+func (v *Var) value(ctx context.Context, name string) (any, error) {
+	val, err := v.rawValue(ctx, name)
+	if err != nil || v.Expr != nil {
+		return val, err
+	}
+	return v.check(val)
+}
+
+// rawValue returns the variable's value before conversion and checking.
+func (v *Var) rawValue(ctx context.Context, name string) (any, error) {
+	switch {
+	case v.Expr != nil:
+		return gdtcontext.NewLazy(func(ctx context.Context) (any, error) {
+			val, err := v.compute(ctx)
+			if err == nil {
+				val, err = v.check(val)
+			}
+			if err != nil {
+				debug.Printf(ctx, "vars: failed computing %s: %s", name, err)
+				return nil, err
+			}
+			debug.Printf(ctx, "vars: computed %s -> %v", name, val)
+			return val, nil
+		}), nil
+	case v.Env != "":
+		if val, found := gdtcontext.Env(ctx)[v.Env]; found {
+			return val, nil
+		}
+		return os.Getenv(v.Env), nil
+	case v.File != "":
+		b, err := os.ReadFile(v.File)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		return v.Value, nil
+	}
+}
+
+// compute evaluates the variable's expression. Other variables referred to by
+// the expression are looked up in the supplied context's run data.
+func (v *Var) compute(ctx context.Context) (any, error) {
+	return v.Expr.Eval(pluginutil.ExprEnv(ctx))
+}
+
+// parseVars parses the supplied `vars` map node. Each entry's value is either
+// a scalar literal or a map with a `value`, `env`, `file` or `expr` field and
+// optional `type`, `enum` and `pattern` fields.
+func (s *Scenario) parseVars(node *yaml.Node) error {
+	vars := map[string]*Var{}
+	exprNodes := map[string]*yaml.Node{}
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return parse.ExpectedScalarAt(keyNode)
+		}
+		valNode := node.Content[i+1]
+		v := &Var{}
+		switch valNode.Kind {
+		case yaml.ScalarNode:
+			if err := valNode.Decode(&v.Value); err != nil {
+				return err
+			}
+		case yaml.MappingNode:
+			var err error
+			v, err = parseVar(keyNode.Value, valNode)
+			if err != nil {
+				return err
+			}
+			if v.Expr != nil {
+				exprNodes[keyNode.Value] = v.exprNode
+			}
+		default:
+			return parse.ExpectedScalarOrMapAt(valNode)
+		}
+		vars[keyNode.Value] = v
+	}
+	names := lo.Keys(exprNodes)
+	slices.Sort(names)
+	for _, name := range names {
+		node := exprNodes[name]
+		if cycle := exprCycle(vars, name, nil); cycle != nil {
+			return parse.InvalidExpressionAt(
+				node, node.Value,
+				fmt.Errorf(
+					"variables refer to each other: %s",
+					strings.Join(cycle, " -> "),
+				),
+			)
+		}
+	}
+	s.Vars = vars
+	return nil
+}
+
+// parseVar parses the supplied map node of the variable with the supplied
+// name. A literal `value` is checked against the variable's type and
+// constraints.
+func parseVar(name string, node *yaml.Node) (*Var, error) {
+	v := &Var{}
+	var valueNode *yaml.Node
+	sources := 0
+	for i := 0; i < len(node.Content); i += 2 {
+		fieldNode := node.Content[i]
+		refNode := node.Content[i+1]
+		switch fieldNode.Value {
+		case "value", "env", "file", "expr", "type", "pattern":
+			if refNode.Kind != yaml.ScalarNode || refNode.Value == "" {
+				return nil, parse.ExpectedScalarAt(refNode)
+			}
+		case "enum":
+			if refNode.Kind != yaml.SequenceNode {
+				return nil, parse.ExpectedSequenceAt(refNode)
+			}
+		default:
+			return nil, parse.UnknownFieldAt(fieldNode.Value, fieldNode)
+		}
+		switch fieldNode.Value {
+		case "value":
+			if err := refNode.Decode(&v.Value); err != nil {
+				return nil, err
+			}
+			valueNode = refNode
+			sources++
+		case "env":
+			v.Env = refNode.Value
+			sources++
+		case "file":
+			if _, err := os.Stat(refNode.Value); err != nil {
+				return nil, parse.FileNotFoundAt(refNode.Value, refNode)
+			}
+			v.File = refNode.Value
+			sources++
+		case "expr":
+			e, err := expr.Parse(refNode.Value)
+			if err != nil {
+				return nil, parse.InvalidExpressionAt(refNode, refNode.Value, err)
+			}
+			v.Expr = e
+			v.exprNode = refNode
+			sources++
+		case "type":
+			if !slices.Contains(varTypes, refNode.Value) {
+				return nil, parse.InvalidVariableAt(
+					refNode, name,
+					fmt.Errorf(
+						"unknown type %s. valid types are %v",
+						refNode.Value, varTypes,
+					),
+				)
+			}
+			v.Type = refNode.Value
+		case "pattern":
+			re, err := regexp.Compile(refNode.Value)
+			if err != nil {
+				return nil, parse.InvalidRegexAt(refNode, refNode.Value, err)
+			}
+			v.Pattern = re
+		case "enum":
+			for _, enumNode := range refNode.Content {
+				if enumNode.Kind != yaml.ScalarNode {
+					return nil, parse.ExpectedScalarAt(enumNode)
+				}
+				var val any
+				if err := enumNode.Decode(&val); err != nil {
+					return nil, err
+				}
+				v.Enum = append(v.Enum, val)
+			}
+			if len(v.Enum) == 0 {
+				return nil, parse.InvalidVariableAt(
+					refNode, name, errors.New("enum has no values"),
+				)
+			}
+		}
+	}
+	if sources != 1 {
+		return nil, parse.InvalidVariableAt(
+			node, name,
+			errors.New("exactly one of value, env, file or expr is required"),
+		)
+	}
+	// The enum values are converted to the variable's type so that they
+	// compare equal to converted values.
+	for x, val := range v.Enum {
+		conv, err := convertVar(val, v.Type)
+		if err != nil {
+			return nil, parse.InvalidVariableAt(node, name, err)
+		}
+		v.Enum[x] = conv
+	}
+	if valueNode != nil {
+		val, err := v.check(v.Value)
+		if err != nil {
+			return nil, parse.InvalidVariableAt(valueNode, name, err)
+		}
+		v.Value = val
+	}
+	return v, nil
+}
+
+// exprCycle returns the names of the variables in a cycle of expressions
+// referring to each other that starts at the named variable, or nil if there
+// is no such cycle. A cycle would otherwise never finish computing.
+func exprCycle(vars map[string]*Var, name string, path []string) []string {
+	if len(path) > 0 && path[0] == name {
+		return append(path, name)
+	}
+	if slices.Contains(path, name) {
+		return nil
+	}
+	v, found := vars[name]
+	if !found || v.Expr == nil {
+		return nil
+	}
+	path = append(path, name)
+	for _, ref := range v.Expr.Vars() {
+		if cycle := exprCycle(vars, ref, path); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// parseVarFiles parses the supplied `var-files` sequence node of paths to
+// variables files.
+func (s *Scenario) parseVarFiles(node *yaml.Node) error {
+	paths := []string{}
+	for _, pathNode := range node.Content {
+		if pathNode.Kind != yaml.ScalarNode || pathNode.Value == "" {
+			return parse.ExpectedScalarAt(pathNode)
+		}
+		if _, err := os.Stat(pathNode.Value); err != nil {
+			return parse.FileNotFoundAt(pathNode.Value, pathNode)
+		}
+		paths = append(paths, pathNode.Value)
+	}
+	s.VarFiles = paths
+	return nil
+}
+
+// LoadVarFiles returns a copy of the supplied context with the keys and
+// values of the map in each of the supplied YAML or JSON files seeded into
+// the run data, with later files overriding earlier ones. An ErrVariable is
+// returned if any file cannot be read or does not contain a map.
+func LoadVarFiles(
+	ctx context.Context,
+	paths ...string,
+) (context.Context, error) {
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return ctx, api.VarFileLoadFailed(path, err)
+		}
+		data := map[string]any{}
+		if err := yaml.Unmarshal(b, &data); err != nil {
+			return ctx, api.VarFileLoadFailed(path, err)
+		}
+		debug.Printf(ctx, "vars: loaded %d variables from %s", len(data), path)
+		ctx = gdtcontext.SetRun(ctx, data)
+	}
+	return ctx, nil
+}
+
+// withVars returns a copy of the supplied context with the variables from
+// the scenario's variables files and then the scenario's variables seeded
+// into the run data, or an ErrVariable if any of them cannot be loaded.
+func (s *Scenario) withVars(ctx context.Context) (context.Context, error) {
+	ctx, err := LoadVarFiles(ctx, s.VarFiles...)
+	if err != nil {
+		return ctx, err
+	}
+	if len(s.Vars) == 0 {
+		return ctx, nil
+	}
+	data := make(map[string]any, len(s.Vars))
+	names := lo.Keys(s.Vars)
+	slices.Sort(names)
+	for _, name := range names {
+		val, err := s.Vars[name].value(ctx, name)
+		if err != nil {
+			return ctx, api.VariableLoadFailed(name, err)
+		}
+		if v := s.Vars[name]; v.Expr != nil {
+			debug.Printf(ctx, "vars: %s -> expr(%s)", name, v.Expr)
+		} else {
+			debug.Printf(ctx, "vars: %s -> %v", name, val)
+		}
+		data[name] = val
+	}
+	return gdtcontext.SetRun(ctx, data), nil
+}
@@ -0,0 +1,120 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// VarTypeString is the type of a variable whose value is a string.
+	VarTypeString = "string"
+	// VarTypeInt is the type of a variable whose value is an integer.
+	VarTypeInt = "int"
+	// VarTypeFloat is the type of a variable whose value is a floating point
+	// number.
+	VarTypeFloat = "float"
+	// VarTypeBool is the type of a variable whose value is a boolean.
+	VarTypeBool = "bool"
+)
+
+// varTypes are the valid types of a variable.
+var varTypes = []string{VarTypeString, VarTypeInt, VarTypeFloat, VarTypeBool}
+
+// check returns the supplied value converted to the variable's type, or an
+// error if it cannot be converted or does not meet the variable's
+// constraints.
+func (v *Var) check(val any) (any, error) {
+	conv, err := convertVar(val, v.Type)
+	if err != nil {
+		return nil, err
+	}
+	if v.Pattern != nil && !v.Pattern.MatchString(varString(conv)) {
+		return nil, fmt.Errorf(
+			"value %q does not match pattern %s", varString(conv), v.Pattern,
+		)
+	}
+	if len(v.Enum) > 0 && !slices.Contains(v.Enum, conv) {
+		enum := make([]string, len(v.Enum))
+		for x, e := range v.Enum {
+			enum[x] = varString(e)
+		}
+		return nil, fmt.Errorf(
+			"value %q is not one of %s",
+			varString(conv), strings.Join(enum, ", "),
+		)
+	}
+	return conv, nil
+}
+
+// convertVar returns the supplied value converted to the supplied variable
+// type. Strings are parsed, e.g. "5" is converted to the int 5, and numbers
+// and booleans are formatted. If the type is empty, the value is returned as
+// is.
+func convertVar(val any, typ string) (any, error) {
+	if typ == "" {
+		return val, nil
+	}
+	s := strings.TrimSpace(varString(val))
+	switch typ {
+	case VarTypeString:
+		return varString(val), nil
+	case VarTypeInt:
+		switch val := val.(type) {
+		case int:
+			return val, nil
+		case int64:
+			return int(val), nil
+		case float64:
+			if val == float64(int(val)) {
+				return int(val), nil
+			}
+		case string:
+			if i, err := strconv.Atoi(s); err == nil {
+				return i, nil
+			}
+		}
+	case VarTypeFloat:
+		switch val := val.(type) {
+		case float64:
+			return val, nil
+		case int:
+			return float64(val), nil
+		case int64:
+			return float64(val), nil
+		case string:
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+		}
+	case VarTypeBool:
+		switch val := val.(type) {
+		case bool:
+			return val, nil
+		case string:
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("value %q is not a valid %s", varString(val), typ)
+}
+
+// varString returns the string form of a variable's value.
+func varString(val any) string {
+	switch val := val.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,31 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package scenario
+
+import (
+	"context"
+	"os"
+
+	"github.com/gdt-dev/core/run"
+)
+
+func init() {
+	run.RegisterScenarioRunner(runFile)
+}
+
+// runFile parses the scenario file at the supplied path and runs it with the
+// supplied Run. It is the ScenarioRunner that run.Watch uses.
+func runFile(ctx context.Context, path string, r *run.Run) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s, err := FromReader(f, WithPath(path))
+	if err != nil {
+		return err
+	}
+	return s.Run(ctx, r)
+}
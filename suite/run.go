@@ -6,10 +6,71 @@ package suite
 
 import (
 	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/run"
+	"github.com/gdt-dev/core/scenario"
+	"github.com/gdt-dev/core/tracing"
 )
 
-// Run executes the tests in the test suite
-func (s *Suite) Run(ctx context.Context, subject any) error {
+// Run executes the tests in the test suite. Fixtures that the test suite's
+// scenarios declare with the suite scope are started before any scenario runs
+// and stopped once all scenarios have run. Errors from stopping those fixtures
+// are joined to the returned error. Variables that a scenario exports are
+// seeded into the run data of later scenarios that import them and, if the
+// test suite has a StateFile, saved for the next run. Program dependencies
+// that several scenarios share are only checked once.
+func (s *Suite) Run(ctx context.Context, subject any) (err error) {
+	// All of the suite's scenarios share the test run's identifier.
+	if r, ok := subject.(*run.Run); ok {
+		ctx = gdtcontext.SetRunID(ctx, r.ID())
+	} else if gdtcontext.RunID(ctx) == "" {
+		ctx = gdtcontext.SetRunID(ctx, gdtcontext.NewRunID())
+	}
+	ctx, span := tracing.Start(
+		ctx, tracing.SpanSuite,
+		tracing.AttrRunID.String(gdtcontext.RunID(ctx)),
+		tracing.AttrSuite.String(s.Title()),
+		tracing.AttrPath.String(s.Path),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	ctx = gdtcontext.SetExports(ctx)
+	ctx = gdtcontext.SetDependencyCache(ctx)
+	if err := s.loadState(ctx); err != nil {
+		return err
+	}
+	if err := s.checkImports(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if saveErr := s.saveState(ctx); saveErr != nil {
+			err = errors.Join(err, saveErr)
+		}
+	}()
+	ctx, err = scenario.LoadVarFiles(ctx, s.VarFiles...)
+	if err != nil {
+		return err
+	}
+	releases := []func() error{}
+	defer func() {
+		for x := len(releases) - 1; x >= 0; x-- {
+			if relErr := releases[x](); relErr != nil {
+				err = errors.Join(err, relErr)
+			}
+		}
+	}()
+	for _, sc := range s.Scenarios {
+		release, err := sc.AcquireFixtures(ctx, api.FixtureScopeSuite)
+		if err != nil {
+			return err
+		}
+		releases = append(releases, release)
+	}
 	for _, sc := range s.Scenarios {
 		if err := sc.Run(ctx, subject); err != nil {
 			return err
@@ -17,3 +78,30 @@ func (s *Suite) Run(ctx context.Context, subject any) error {
 	}
 	return nil
 }
+
+// checkImports returns an ErrVariable if any scenario imports a variable that
+// neither an earlier scenario in the test suite nor the state of an earlier
+// run exports, so that a mistyped name fails the test suite before any
+// scenario has run.
+func (s *Suite) checkImports(ctx context.Context) error {
+	exported := map[string]bool{}
+	for name := range gdtcontext.Exported(ctx) {
+		exported[name] = true
+	}
+	for _, sc := range s.Scenarios {
+		for _, name := range sc.Imports {
+			if !exported[name] {
+				return api.ImportFailed(
+					name, fmt.Errorf(
+						"%w by a scenario before %s",
+						gdtcontext.ErrNotExported, sc.Title(),
+					),
+				)
+			}
+		}
+		for _, name := range sc.Exports {
+			exported[name] = true
+		}
+	}
+	return nil
+}
@@ -6,8 +6,19 @@ package suite_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/fixture"
+	_ "github.com/gdt-dev/core/internal/testutil/plugin/stubborn"
+	"github.com/gdt-dev/core/run"
+	"github.com/gdt-dev/core/scenario"
 	"github.com/gdt-dev/core/suite"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,3 +36,287 @@ func TestRunExecSuite(t *testing.T) {
 	err = s.Run(ctx, t)
 	assert.Nil(err)
 }
+
+func TestRunSuiteFixtureScope(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	scenarioYAML := `
+name: %s
+fixtures:
+  - name: shared
+    scope: suite
+  - private
+tests:
+  - exec: "true"
+`
+	s := suite.New()
+	for _, name := range []string{"first", "second"} {
+		sc, err := scenario.FromReader(
+			strings.NewReader(strings.Replace(scenarioYAML, "%s", name, 1)),
+		)
+		require.Nil(err)
+		s.Append(sc)
+	}
+
+	sharedStarts, sharedStops := 0, 0
+	privateStarts, privateStops := 0, 0
+	shared := fixture.New(
+		fixture.WithStarter(func(context.Context) error {
+			sharedStarts++
+			return nil
+		}),
+		fixture.WithStopper(func(context.Context) {
+			sharedStops++
+		}),
+	)
+	private := fixture.New(
+		fixture.WithStarter(func(context.Context) error {
+			privateStarts++
+			return nil
+		}),
+		fixture.WithStopper(func(context.Context) {
+			// The suite-scoped fixture is still running while each
+			// scenario's own fixtures are stopped.
+			assert.Equal(0, sharedStops)
+			privateStops++
+		}),
+	)
+	ctx := gdtcontext.New()
+	ctx = gdtcontext.RegisterFixture(ctx, "shared", shared)
+	ctx = gdtcontext.RegisterFixture(ctx, "private", private)
+
+	err := s.Run(ctx, t)
+	require.Nil(err)
+	assert.Equal(1, sharedStarts)
+	assert.Equal(1, sharedStops)
+	assert.Equal(2, privateStarts)
+	assert.Equal(2, privateStops)
+}
+
+func TestRunSuiteVarFiles(t *testing.T) {
+	require := require.New(t)
+
+	sc, err := scenario.FromReader(strings.NewReader(`
+name: var-files
+tests:
+  - exec: echo "$${ENDPOINT}"
+    assert:
+      out:
+        is: https://staging.example.com
+`))
+	require.Nil(err)
+
+	varFile := filepath.Join(t.TempDir(), "staging.yaml")
+	err = os.WriteFile(
+		varFile, []byte("ENDPOINT: https://staging.example.com\n"), 0o644,
+	)
+	require.Nil(err)
+	s := suite.New(suite.WithVarFiles(varFile))
+	s.Append(sc)
+
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+
+	missing := filepath.Join(t.TempDir(), "does-not-exist.yaml")
+	s = suite.New(suite.WithVarFiles(missing))
+	s.Append(sc)
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrVariable)
+	require.ErrorContains(err, "does-not-exist.yaml")
+}
+
+func TestRunSuiteExports(t *testing.T) {
+	require := require.New(t)
+
+	t.Setenv("GDT_EXPORTS_TOKEN", "tok-1234")
+
+	provision, err := scenario.FromReader(strings.NewReader(`
+name: provision
+secrets:
+  TOKEN:
+    env: GDT_EXPORTS_TOKEN
+exports:
+  - RESOURCE_ID
+  - TOKEN
+tests:
+  - exec: echo res-42
+    var-stdout: RESOURCE_ID
+`))
+	require.Nil(err)
+	use, err := scenario.FromReader(strings.NewReader(`
+name: use
+imports:
+  - RESOURCE_ID
+  - TOKEN
+tests:
+  - exec: echo "$${RESOURCE_ID} $${TOKEN}"
+    assert:
+      out:
+        is: res-42 tok-1234
+`))
+	require.Nil(err)
+
+	s := suite.New()
+	s.Append(provision)
+	s.Append(use)
+
+	var b strings.Builder
+	ctx := gdtcontext.New(gdtcontext.WithDebug(&b))
+	err = s.Run(ctx, t)
+	require.Nil(err)
+	require.Contains(b.String(), "[use] imports: TOKEN")
+	require.Contains(b.String(), "exec: stdout: res-42 ********")
+	require.NotContains(b.String(), "tok-1234")
+
+	// A scenario may not import a variable that only a later scenario
+	// exports.
+	s = suite.New()
+	s.Append(use)
+	s.Append(provision)
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, api.ErrVariable)
+	require.ErrorIs(err, gdtcontext.ErrNotExported)
+	require.ErrorContains(err, "import RESOURCE_ID")
+
+	// Outside of a test suite there is nothing to import.
+	err = use.Run(context.TODO(), t)
+	require.ErrorIs(err, gdtcontext.ErrNoExports)
+}
+
+func TestRunSuiteStateFile(t *testing.T) {
+	require := require.New(t)
+
+	provision, err := scenario.FromReader(strings.NewReader(`
+name: provision
+exports:
+  - RESOURCE_ID
+  - REGION
+tests:
+  - exec: echo res-42
+    var-stdout: RESOURCE_ID
+  - exec: echo us-east-1
+    var-stdout: REGION
+`))
+	require.Nil(err)
+	use, err := scenario.FromReader(strings.NewReader(`
+name: use
+imports:
+  - RESOURCE_ID
+tests:
+  - exec: echo "$${RESOURCE_ID}"
+    assert:
+      out:
+        is: res-42
+`))
+	require.Nil(err)
+
+	stateFile := filepath.Join(t.TempDir(), "state.yaml")
+
+	// The first run provisions the resource and saves its ID...
+	s := suite.New(suite.WithStateFile(stateFile, "RESOURCE_ID"))
+	s.Append(provision)
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+
+	b, err := os.ReadFile(stateFile)
+	require.Nil(err)
+	require.Equal("RESOURCE_ID: res-42\n", string(b))
+
+	// ...and the next run uses it without provisioning again.
+	s = suite.New(suite.WithStateFile(stateFile))
+	s.Append(use)
+	err = s.Run(context.TODO(), t)
+	require.Nil(err)
+
+	// Without the state file, nothing exports the imported variable.
+	s = suite.New()
+	s.Append(use)
+	err = s.Run(context.TODO(), t)
+	require.ErrorIs(err, gdtcontext.ErrNotExported)
+}
+
+func TestRunSuiteDependencyCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping windows host")
+	}
+	require := require.New(t)
+
+	// gdt-counted-version records each time it is executed.
+	binDir := t.TempDir()
+	countPath := filepath.Join(binDir, "count")
+	script := "#!/bin/sh\necho run >> " + countPath + "\necho 1.2.3\n"
+	err := os.WriteFile(
+		filepath.Join(binDir, "gdt-counted-version"), []byte(script), 0o755,
+	)
+	require.Nil(err)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	s := suite.New()
+	for _, name := range []string{"first", "second", "third"} {
+		sc, err := scenario.FromReader(strings.NewReader(`
+name: ` + name + `
+depends:
+  - name: gdt-counted-version
+    version:
+      constraint: ">= 1.2"
+      selector:
+        filter: "[0-9.]+"
+tests:
+  - exec: "true"
+`))
+		require.Nil(err)
+		s.Append(sc)
+	}
+
+	var b strings.Builder
+	ctx := gdtcontext.New(gdtcontext.WithDebug(&b))
+	err = s.Run(ctx, t)
+	require.Nil(err)
+
+	count, err := os.ReadFile(countPath)
+	require.Nil(err)
+	require.Equal("run\n", string(count))
+	require.Contains(b.String(), `dependency "gdt-counted-version" checked earlier in run`)
+}
+
+func TestRunSuiteTimeoutEvalIgnoresContext(t *testing.T) {
+	require := require.New(t)
+
+	sc, err := scenario.FromReader(strings.NewReader(`
+name: stubborn-timeout
+tests:
+  - stubborn: 500ms
+    timeout: 100ms
+`))
+	require.Nil(err)
+	s := suite.New()
+	s.Append(sc)
+
+	// The test spec's evaluation returns within the grace period after its
+	// timeout, so what it logged is kept.
+	r := run.New()
+	err = s.Run(gdtcontext.New(), r)
+	require.Nil(err)
+	results := r.ScenarioResults("")
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.ErrorContains(results[0].Failures()[0], "timeout exceeded (100ms)")
+	require.Contains(results[0].Detail(), "stubborn: woke after 500ms")
+
+	// The test spec's evaluation outlives the grace period after its
+	// timeout, so the timeout is recorded without waiting for it, and it
+	// no longer writes to the stored test unit.
+	began := time.Now()
+	r = run.New(run.WithShutdownGrace(100 * time.Millisecond))
+	err = s.Run(gdtcontext.New(), r)
+	require.Nil(err)
+	require.Less(time.Since(began), 400*time.Millisecond)
+	results = r.ScenarioResults("")
+	require.Len(results, 1)
+	require.False(results[0].OK())
+	require.ErrorContains(results[0].Failures()[0], "timeout exceeded (100ms)")
+
+	time.Sleep(600 * time.Millisecond)
+	require.NotContains(results[0].Detail(), "stubborn: woke")
+}
@@ -0,0 +1,73 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+package suite
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+	"github.com/gdt-dev/core/debug"
+)
+
+// loadState exports the variables saved in the test suite's StateFile by an
+// earlier run, so that the test suite's scenarios can import them. A missing
+// StateFile is not an error, since the first run has no state to load.
+func (s *Suite) loadState(ctx context.Context) error {
+	if s.StateFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(s.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		debug.Printf(ctx, "state: %s does not exist. nothing to load.", s.StateFile)
+		return nil
+	}
+	if err != nil {
+		return api.StateFileFailed(s.StateFile, err)
+	}
+	data := map[string]any{}
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return api.StateFileFailed(s.StateFile, err)
+	}
+	for name, val := range data {
+		if err := gdtcontext.Export(ctx, name, val); err != nil {
+			return api.StateFileFailed(s.StateFile, err)
+		}
+	}
+	debug.Printf(ctx, "state: loaded %d variables from %s", len(data), s.StateFile)
+	return nil
+}
+
+// saveState writes the exported variables named in the test suite's
+// StateVars, or all exported variables if there are no StateVars, to the
+// test suite's StateFile. Secrets are never saved.
+func (s *Suite) saveState(ctx context.Context) error {
+	if s.StateFile == "" {
+		return nil
+	}
+	data := gdtcontext.Exported(ctx)
+	if len(s.StateVars) > 0 {
+		selected := make(map[string]any, len(s.StateVars))
+		for _, name := range s.StateVars {
+			if val, found := data[name]; found {
+				selected[name] = val
+			}
+		}
+		data = selected
+	}
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return api.StateFileFailed(s.StateFile, err)
+	}
+	if err := os.WriteFile(s.StateFile, b, 0o600); err != nil {
+		return api.StateFileFailed(s.StateFile, err)
+	}
+	debug.Printf(ctx, "state: saved %d variables to %s", len(data), s.StateFile)
+	return nil
+}
@@ -30,6 +30,20 @@ type Suite struct {
 	// Fixtures specifies an ordered list of fixtures the test suite's test
 	// cases depend on.
 	Fixtures []string `yaml:"fixtures,omitempty"`
+	// VarFiles contains the paths of YAML or JSON files whose keys and values
+	// are seeded into the run data before any of the test suite's scenarios
+	// run. Relative paths are relative to the working directory when the test
+	// suite is run.
+	VarFiles []string `yaml:"var-files,omitempty"`
+	// StateFile is the path of a YAML file that exported variables are saved
+	// to at the end of a run and loaded from at the start of the next, so
+	// that e.g. a later run can test or tear down resources provisioned by an
+	// earlier one. If empty, no state is kept between runs.
+	StateFile string `yaml:"-"`
+	// StateVars contains the names of the exported variables saved to the
+	// StateFile. If empty, all exported variables other than secrets are
+	// saved.
+	StateVars []string `yaml:"-"`
 	// Scenarios is a collection of test scenarios in this test suite
 	Scenarios []*scenario.Scenario `yaml:"-"`
 }
@@ -89,6 +103,26 @@ func WithFixtures(fixtures []string) SuiteModifier {
 	}
 }
 
+// WithVarFiles sets the paths of the YAML or JSON files whose keys and values
+// are seeded into the run data of each of the test suite's scenarios, e.g.
+// endpoints or account IDs that differ between environments.
+func WithVarFiles(paths ...string) SuiteModifier {
+	return func(s *Suite) {
+		s.VarFiles = paths
+	}
+}
+
+// WithStateFile sets the path of the YAML file that the test suite's exported
+// variables are saved to at the end of a run and loaded from at the start of
+// the next, and optionally the names of the exported variables that are
+// saved.
+func WithStateFile(path string, names ...string) SuiteModifier {
+	return func(s *Suite) {
+		s.StateFile = path
+		s.StateVars = names
+	}
+}
+
 // New returns a new Suite
 func New(mods ...SuiteModifier) *Suite {
 	s := &Suite{}
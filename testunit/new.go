@@ -40,6 +40,30 @@ func WithName(name string) Option {
 	}
 }
 
+// WithID creates TestUnit with the identifier of the run of the test spec that
+// it executes.
+func WithID(id string) Option {
+	return func(u *TestUnit) {
+		u.id = id
+	}
+}
+
+// WithStableID creates TestUnit with the identifier, from the `id` field of
+// the test spec that it executes, that stays the same across runs.
+func WithStableID(id string) Option {
+	return func(u *TestUnit) {
+		u.stableID = id
+	}
+}
+
+// WithMask creates TestUnit that applies the supplied function to entries
+// written to its detail log, e.g. to mask secret values.
+func WithMask(mask func(string) string) Option {
+	return func(u *TestUnit) {
+		u.mask = mask
+	}
+}
+
 // New returns a new initialized *TestUnit
 func New(ctx context.Context, opts ...Option) *TestUnit {
 	u := &TestUnit{
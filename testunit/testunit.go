@@ -30,6 +30,11 @@ type TestUnit struct {
 	detail *strings.Builder
 	// name is the name/title of the test unit
 	name string
+	// id identifies the run of the test spec that the test unit executes.
+	id string
+	// stableID is the identifier, from the `id` field of the test spec that
+	// the test unit executes, that stays the same across runs.
+	stableID string
 	// parent points at another test unit if it's a subtest.
 	parent *TestUnit
 	// failed is true if the test unit has been marked as failed.
@@ -46,6 +51,11 @@ type TestUnit struct {
 	started time.Time
 	// elapsed is the amount of time spent executing the test unit.
 	elapsed time.Duration
+	// mask, if set, is applied to entries written to the detail log.
+	mask func(string) string
+	// dirtyGoldens is the collection of paths to golden files that were
+	// rewritten with new content while executing the test unit.
+	dirtyGoldens []string
 }
 
 func (u *TestUnit) Finish() {
@@ -55,6 +65,19 @@ func (u *TestUnit) Finish() {
 	u.Unlock()
 }
 
+// ID returns the identifier of the run of the test spec that the test unit
+// executes, or an empty string if the test unit has no ID.
+func (u *TestUnit) ID() string {
+	return u.id
+}
+
+// StableID returns the identifier, from the `id` field of the test spec that
+// the test unit executes, that stays the same across runs, or an empty string
+// if the test spec has no `id`.
+func (u *TestUnit) StableID() string {
+	return u.stableID
+}
+
 // Name returns the full name of the test unit. The test unit name is a
 // concatenation of the parent(s) name and this test unit's name.
 func (u *TestUnit) Name() string {
@@ -107,6 +130,9 @@ func (u *TestUnit) log(s string) {
 	if u.detail == nil {
 		return
 	}
+	if u.mask != nil {
+		s = u.mask(s)
+	}
 	s = strings.TrimSuffix(s, "\n")
 	// Second and subsequent lines are indented 4 spaces. This is in addition to
 	// the indentation provided by outputWriter.
@@ -179,11 +205,75 @@ func (u *TestUnit) Skipf(format string, args ...any) {
 // SkipNow marks the test unit as having been skipped and stops its execution.
 func (u *TestUnit) SkipNow() {
 	u.Lock()
-	defer u.RUnlock()
 	u.skipped = true
+	u.Unlock()
 	u.Finish()
 }
 
+// AddDirtyGolden records the path to a golden file that was rewritten with
+// new content while executing the test unit.
+func (u *TestUnit) AddDirtyGolden(path string) {
+	u.Lock()
+	defer u.Unlock()
+	if !lo.Contains(u.dirtyGoldens, path) {
+		u.dirtyGoldens = append(u.dirtyGoldens, path)
+	}
+}
+
+// DirtyGoldens returns the paths to golden files that were rewritten with new
+// content while executing the test unit.
+func (u *TestUnit) DirtyGoldens() []string {
+	u.RLock()
+	defer u.RUnlock()
+	return u.dirtyGoldens
+}
+
+// Fork returns a new test unit with the same name, identifiers and mask as
+// the test unit, for a goroutine that evaluates the test unit's test spec and
+// may outlive it. The forked test unit's log entries, failures and rewritten
+// golden files are only added to the test unit by Join, so a forked test unit
+// that is never joined can be written to after the test unit is finished.
+// The supplied options are applied to the forked test unit.
+func (u *TestUnit) Fork(opts ...Option) *TestUnit {
+	f := &TestUnit{
+		ctx:       u.ctx,
+		cancelCtx: func() {},
+		detail:    &strings.Builder{},
+		name:      u.name,
+		id:        u.id,
+		stableID:  u.stableID,
+		failures:  []error{},
+		started:   time.Now(),
+		mask:      u.mask,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Join adds the log entries, failures and rewritten golden files of the
+// supplied test unit, which was returned by Fork, to the test unit. The forked
+// test unit must no longer be written to.
+func (u *TestUnit) Join(f *TestUnit) {
+	f.RLock()
+	detail := f.detail.String()
+	failures := f.failures
+	failed := f.failed
+	goldens := f.dirtyGoldens
+	f.RUnlock()
+	if u.detail != nil {
+		u.detail.WriteString(detail)
+	}
+	u.failures = append(u.failures, failures...)
+	for _, path := range goldens {
+		u.AddDirtyGolden(path)
+	}
+	if failed {
+		u.Fail()
+	}
+}
+
 // Skipped reports whether the test was skipped.
 func (u *TestUnit) Skipped() bool {
 	u.RLock()
@@ -0,0 +1,90 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+
+// Package tracing creates OpenTelemetry spans for the work gdt performs, using
+// the TracerProvider set in the context with
+// `gdtcontext.WithTracerProvider()`.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gdt-dev/core/api"
+	gdtcontext "github.com/gdt-dev/core/context"
+)
+
+// TracerName is the name of the OpenTelemetry Tracer that gdt creates spans
+// with.
+const TracerName = "github.com/gdt-dev/core"
+
+// Span names for the work gdt performs.
+const (
+	SpanSuite        = "gdt.suite"
+	SpanScenario     = "gdt.scenario"
+	SpanSpec         = "gdt.spec"
+	SpanAttempt      = "gdt.spec.attempt"
+	SpanFixtureStart = "gdt.fixture.start"
+	SpanFixtureStop  = "gdt.fixture.stop"
+)
+
+// Attribute keys set on gdt's spans.
+const (
+	AttrRunID    = attribute.Key("gdt.run.id")
+	AttrSpecID   = attribute.Key("gdt.spec.id")
+	AttrSuite    = attribute.Key("gdt.suite")
+	AttrScenario = attribute.Key("gdt.scenario")
+	AttrPath     = attribute.Key("gdt.path")
+	AttrSpec     = attribute.Key("gdt.spec")
+	AttrIndex    = attribute.Key("gdt.spec.index")
+	AttrPlugin   = attribute.Key("gdt.plugin")
+	AttrAttempt  = attribute.Key("gdt.attempt")
+	AttrFixture  = attribute.Key("gdt.fixture")
+	AttrFailed   = attribute.Key("gdt.failed")
+)
+
+// Start starts a span with the supplied name and attributes as a child of any
+// span in the supplied context, and returns a context containing the new
+// span. Callers must call End on the returned span.
+func Start(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	tracer := gdtcontext.TracerProvider(ctx).Tracer(TracerName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the supplied span, recording the supplied error, if any, and
+// marking the span as failed.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// EndResult ends the supplied span for the evaluation of a test spec,
+// recording the supplied error, if any, or marking the span as failed if the
+// supplied Result has any assertion failures. Assertion failures are recorded
+// as span events.
+func EndResult(span trace.Span, res *api.Result, err error) {
+	if err != nil || res == nil {
+		End(span, err)
+		return
+	}
+	failures := res.Failures()
+	span.SetAttributes(AttrFailed.Bool(len(failures) > 0))
+	if len(failures) > 0 {
+		for _, f := range failures {
+			span.RecordError(f)
+		}
+		span.SetStatus(codes.Error, "assertion failed")
+	}
+	span.End()
+}